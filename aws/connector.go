@@ -0,0 +1,189 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package aws
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// DefaultTokenRefreshInterval is the default interval after which the authentication token is regenerated.
+// It's less than AuthTokenTTL, so the token doesn't expire while it's being used for connecting.
+const DefaultTokenRefreshInterval = 10 * time.Minute
+
+// IAMAuthConfig is a configuration of RDS IAM authentication.
+type IAMAuthConfig struct {
+	// Region is the AWS region of the database (e.g., "us-east-1").
+	Region string
+	// Credentials provides AWS credentials for signing authentication tokens.
+	// The IAM identity must be allowed to perform the "rds-db:connect" action for the database user.
+	Credentials CredentialsProvider
+	// TokenRefreshInterval is the interval after which the authentication token is regenerated
+	// (DefaultTokenRefreshInterval if zero). It must be less than AuthTokenTTL.
+	TokenRefreshInterval time.Duration
+}
+
+// Connector is a driver.Connector that connects to the database using RDS IAM authentication tokens
+// as passwords. The token is generated for the first connection and regenerated after TokenRefreshInterval,
+// so new connections (e.g., after the pool recycled old ones) don't fail because of the expired token.
+type Connector struct {
+	cfg             dbkit.Config
+	endpoint        string
+	dbUser          string
+	iamCfg          IAMAuthConfig
+	driverInstance  driver.Driver
+	connectorOpener func(drv driver.Driver, dsn string) (driver.Connector, error)
+	now             func() time.Time
+
+	mu             sync.Mutex
+	connector      driver.Connector
+	tokenCreatedAt time.Time
+}
+
+var _ driver.Connector = (*Connector)(nil)
+
+// NewConnector creates a new Connector for the database configuration (MySQL, Postgres or pgx dialect).
+// The endpoint and the database user are taken from the configuration, its password (and PasswordProvider) are ignored.
+// For MySQL, the allowCleartextPasswords parameter (required by IAM authentication) is added,
+// and tls=true is added if the tls parameter is not specified in MySQLConfig.AdditionalParameters.
+// For Postgres, SSLMode should be "require" or stricter.
+func NewConnector(cfg *dbkit.Config, iamCfg IAMAuthConfig) (*Connector, error) {
+	if iamCfg.Region == "" {
+		return nil, fmt.Errorf("region must be specified")
+	}
+	if iamCfg.Credentials == nil {
+		return nil, fmt.Errorf("credentials provider must be specified")
+	}
+	if iamCfg.TokenRefreshInterval == 0 {
+		iamCfg.TokenRefreshInterval = DefaultTokenRefreshInterval
+	}
+	if iamCfg.TokenRefreshInterval < 0 || iamCfg.TokenRefreshInterval >= AuthTokenTTL {
+		return nil, fmt.Errorf("token refresh interval must be positive and less than %s", AuthTokenTTL)
+	}
+
+	c := &Connector{cfg: *cfg, iamCfg: iamCfg, now: time.Now, connectorOpener: openConnector}
+	c.cfg.PasswordProvider = dbkit.PasswordProviderFunc(c.token)
+	switch cfg.Dialect {
+	case dbkit.DialectMySQL:
+		c.endpoint = cfg.MySQL.Host + ":" + strconv.Itoa(cfg.MySQL.Port)
+		c.dbUser = cfg.MySQL.User
+		params := make(map[string]string, len(cfg.MySQL.AdditionalParameters)+2)
+		for k, v := range cfg.MySQL.AdditionalParameters {
+			params[k] = v
+		}
+		params["allowCleartextPasswords"] = "true"
+		if _, ok := params["tls"]; !ok {
+			params["tls"] = "true"
+		}
+		c.cfg.MySQL.AdditionalParameters = params
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		if len(cfg.Postgres.Hosts) > 1 {
+			return nil, fmt.Errorf("multiple hosts are not supported")
+		}
+		host := cfg.Postgres.Host
+		if len(cfg.Postgres.Hosts) == 1 {
+			host = cfg.Postgres.Hosts[0]
+		}
+		c.endpoint = host + ":" + strconv.Itoa(cfg.Postgres.Port)
+		c.dbUser = cfg.Postgres.User
+	default:
+		return nil, fmt.Errorf("unsupported dialect %q", cfg.Dialect)
+	}
+
+	driverName, _ := c.cfg.DriverNameAndDSN()
+	// sql.Open doesn't establish connections, it's used only to look up the registered driver.
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		return nil, err
+	}
+	c.driverInstance = db.Driver()
+	if err = db.Close(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Open opens a new database connection pool using RDS IAM authentication (see NewConnector)
+// and initializes it in the same way as dbkit.Open.
+func Open(cfg *dbkit.Config, iamCfg IAMAuthConfig, ping bool, options ...dbkit.OpenOption) (*sql.DB, error) {
+	connector, err := NewConnector(cfg, iamCfg)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(connector)
+	if err = dbkit.InitOpenedDB(db, cfg, ping, options...); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Connect establishes a new connection using the current authentication token (regenerating it if needed).
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	connector, err := c.getConnector(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+// Driver returns the underlying driver.
+func (c *Connector) Driver() driver.Driver {
+	return c.driverInstance
+}
+
+func (c *Connector) getConnector(ctx context.Context) (driver.Connector, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.connector != nil && c.now().Sub(c.tokenCreatedAt) < c.iamCfg.TokenRefreshInterval {
+		return c.connector, nil
+	}
+	tokenCreatedAt := c.now()
+	_, dsn, err := c.cfg.ResolveDriverNameAndDSN(ctx) // Calls c.token via PasswordProvider.
+	if err != nil {
+		return nil, err
+	}
+	connector, err := c.connectorOpener(c.driverInstance, dsn)
+	if err != nil {
+		return nil, err
+	}
+	c.connector = connector
+	c.tokenCreatedAt = tokenCreatedAt
+	return connector, nil
+}
+
+func (c *Connector) token(ctx context.Context) (string, error) {
+	return BuildAuthToken(ctx, c.endpoint, c.iamCfg.Region, c.dbUser, c.iamCfg.Credentials, c.now())
+}
+
+func openConnector(drv driver.Driver, dsn string) (driver.Connector, error) {
+	if driverCtx, ok := drv.(driver.DriverContext); ok {
+		return driverCtx.OpenConnector(dsn)
+	}
+	return dsnConnector{dsn: dsn, driver: drv}, nil
+}
+
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
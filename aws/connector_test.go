@@ -0,0 +1,132 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package aws
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql/driver"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+type fakeConn struct {
+	driver.Conn
+	dsn string
+}
+
+type fakeConnector struct {
+	dsn string
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{dsn: c.dsn}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return nil
+}
+
+func TestConnector(t *testing.T) {
+	ctx := context.Background()
+	var credsCalls atomic.Int32
+	iamCfg := IAMAuthConfig{
+		Region: "eu-west-1",
+		Credentials: CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+			credsCalls.Add(1)
+			return testCreds, nil
+		}),
+	}
+	newTestConnector := func(t *testing.T, cfg *dbkit.Config) (*Connector, *time.Time) {
+		t.Helper()
+		connector, err := NewConnector(cfg, iamCfg)
+		require.NoError(t, err)
+		now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+		connector.now = func() time.Time { return now }
+		connector.connectorOpener = func(drv driver.Driver, dsn string) (driver.Connector, error) {
+			return fakeConnector{dsn: dsn}, nil
+		}
+		return connector, &now
+	}
+	connectDSN := func(t *testing.T, connector *Connector) string {
+		t.Helper()
+		conn, err := connector.Connect(ctx)
+		require.NoError(t, err)
+		return conn.(*fakeConn).dsn
+	}
+
+	t.Run("mysql", func(t *testing.T) {
+		credsCalls.Store(0)
+		require.NoError(t, mysql.RegisterTLSConfig("rds", &tls.Config{MinVersion: tls.VersionTLS12})) // Usually with RDS CA bundle.
+		defer mysql.DeregisterTLSConfig("rds")
+		cfg := &dbkit.Config{
+			Dialect: dbkit.DialectMySQL,
+			MySQL: dbkit.MySQLConfig{Host: "mydb.rds.amazonaws.com", Port: 3306, User: "app", Password: "ignored", Database: "orders",
+				AdditionalParameters: map[string]string{"tls": "rds"}},
+		}
+		connector, now := newTestConnector(t, cfg)
+
+		mysqlCfg, err := mysql.ParseDSN(connectDSN(t, connector))
+		require.NoError(t, err)
+		require.Equal(t, "app", mysqlCfg.User)
+		require.True(t, strings.HasPrefix(mysqlCfg.Passwd, "mydb.rds.amazonaws.com:3306/?Action=connect&DBUser=app&"), mysqlCfg.Passwd)
+		require.True(t, mysqlCfg.AllowCleartextPasswords)
+		require.Equal(t, "rds", mysqlCfg.TLSConfig)
+		require.Equal(t, map[string]string{"tls": "rds"}, cfg.MySQL.AdditionalParameters, "config must not be modified")
+		firstToken := mysqlCfg.Passwd
+
+		// The token is reused until the refresh interval passes.
+		*now = now.Add(DefaultTokenRefreshInterval - time.Second)
+		mysqlCfg, err = mysql.ParseDSN(connectDSN(t, connector))
+		require.NoError(t, err)
+		require.Equal(t, firstToken, mysqlCfg.Passwd)
+		require.Equal(t, int32(1), credsCalls.Load())
+
+		*now = now.Add(time.Second)
+		mysqlCfg, err = mysql.ParseDSN(connectDSN(t, connector))
+		require.NoError(t, err)
+		require.NotEqual(t, firstToken, mysqlCfg.Passwd)
+		require.Equal(t, int32(2), credsCalls.Load())
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		cfg := &dbkit.Config{
+			Dialect: dbkit.DialectPgx,
+			Postgres: dbkit.PostgresConfig{Hosts: []string{"pgdb.rds.amazonaws.com"}, Port: 5432, User: "app", Database: "orders",
+				SSLMode: dbkit.PostgresSSLModeVerifyFull},
+		}
+		connector, _ := newTestConnector(t, cfg)
+		dsn, err := url.Parse(connectDSN(t, connector))
+		require.NoError(t, err)
+		require.Equal(t, "app", dsn.User.Username())
+		password, _ := dsn.User.Password()
+		require.True(t, strings.HasPrefix(password, "pgdb.rds.amazonaws.com:5432/?Action=connect&DBUser=app&"), password)
+		require.Equal(t, "verify-full", dsn.Query().Get("sslmode"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := NewConnector(&dbkit.Config{Dialect: dbkit.DialectMSSQL}, iamCfg)
+		require.EqualError(t, err, `unsupported dialect "mssql"`)
+		_, err = NewConnector(&dbkit.Config{Dialect: dbkit.DialectPgx,
+			Postgres: dbkit.PostgresConfig{Hosts: []string{"pg-1", "pg-2"}}}, iamCfg)
+		require.EqualError(t, err, "multiple hosts are not supported")
+		_, err = NewConnector(&dbkit.Config{Dialect: dbkit.DialectMySQL}, IAMAuthConfig{Credentials: iamCfg.Credentials})
+		require.EqualError(t, err, "region must be specified")
+		_, err = NewConnector(&dbkit.Config{Dialect: dbkit.DialectMySQL},
+			IAMAuthConfig{Region: "eu-west-1", Credentials: iamCfg.Credentials, TokenRefreshInterval: AuthTokenTTL})
+		require.EqualError(t, err, "token refresh interval must be positive and less than 15m0s")
+	})
+}
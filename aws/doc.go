@@ -0,0 +1,14 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package aws provides AWS RDS IAM database authentication for MySQL and Postgres.
+// Instead of a static password, a short-lived authentication token signed with AWS credentials is used
+// (see BuildAuthToken). Connector (and Open) generates the token for new connections and refreshes it
+// before it expires, so services running on AWS don't need database passwords at all.
+//
+// The package doesn't depend on the AWS SDK: credentials are provided via the CredentialsProvider interface,
+// which may be implemented with the SDK credentials chain (e.g., instance profile or IRSA), see CredentialsProviderFunc.
+package aws
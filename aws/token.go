@@ -0,0 +1,196 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package aws
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuthTokenTTL is the lifetime of the RDS IAM authentication token.
+// The token is needed only for establishing a connection, the established connection is not closed after that.
+const AuthTokenTTL = 15 * time.Minute
+
+// Credentials are AWS credentials used for signing authentication tokens.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is used for temporary credentials (e.g., of the assumed role).
+	SessionToken string
+}
+
+// CredentialsProvider provides AWS credentials.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// CredentialsProviderFunc is an adapter to allow the use of ordinary functions as CredentialsProvider.
+// It's a way to use credentials of the AWS SDK, e.g., for github.com/aws/aws-sdk-go-v2:
+//
+//	aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+//		creds, err := awsCfg.Credentials.Retrieve(ctx)
+//		return aws.Credentials{AccessKeyID: creds.AccessKeyID, SecretAccessKey: creds.SecretAccessKey, SessionToken: creds.SessionToken}, err
+//	})
+type CredentialsProviderFunc func(ctx context.Context) (Credentials, error)
+
+// Credentials calls f(ctx).
+func (f CredentialsProviderFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+// NewStaticCredentialsProvider returns CredentialsProvider that always provides the specified credentials.
+func NewStaticCredentialsProvider(creds Credentials) CredentialsProvider {
+	return CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+		return creds, nil
+	})
+}
+
+// NewEnvCredentialsProvider returns CredentialsProvider that takes credentials from the standard environment variables
+// (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and optional AWS_SESSION_TOKEN).
+func NewEnvCredentialsProvider() CredentialsProvider {
+	return CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+		creds := Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+			return Credentials{}, fmt.Errorf("environment variables AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+		}
+		return creds, nil
+	})
+}
+
+// BuildAuthToken builds the RDS IAM authentication token that is used as the password
+// for connecting to the database endpoint ("host:port") as the database user.
+// The token is valid for AuthTokenTTL since signingTime.
+func BuildAuthToken(
+	ctx context.Context, endpoint, region, dbUser string, credsProvider CredentialsProvider, signingTime time.Time,
+) (string, error) {
+	if endpoint == "" || region == "" || dbUser == "" {
+		return "", fmt.Errorf("endpoint, region and database user must be specified")
+	}
+	creds, err := credsProvider.Credentials(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get AWS credentials: %w", err)
+	}
+	query := url.Values{}
+	query.Set("Action", "connect")
+	query.Set("DBUser", dbUser)
+	const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" // SHA-256 of empty string.
+	presignedQuery := presignQuery(presignRequest{
+		method:      "GET",
+		host:        endpoint,
+		path:        "/",
+		query:       query,
+		service:     "rds-db",
+		region:      region,
+		creds:       creds,
+		signingTime: signingTime,
+		expires:     AuthTokenTTL,
+		payloadHash: emptyPayloadHash,
+	})
+	return endpoint + "/?" + presignedQuery, nil
+}
+
+type presignRequest struct {
+	method      string
+	host        string
+	path        string
+	query       url.Values
+	service     string
+	region      string
+	creds       Credentials
+	signingTime time.Time
+	expires     time.Duration
+	payloadHash string
+}
+
+// presignQuery returns the query string of the URL presigned with AWS Signature Version 4
+// (https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_sigv-create-signed-request.html).
+func presignQuery(req presignRequest) string {
+	const algorithm = "AWS4-HMAC-SHA256"
+	signingTime := req.signingTime.UTC()
+	amzDate := signingTime.Format("20060102T150405Z")
+	shortDate := signingTime.Format("20060102")
+	scope := strings.Join([]string{shortDate, req.region, req.service, "aws4_request"}, "/")
+
+	query := url.Values{}
+	for k, v := range req.query {
+		query[k] = v
+	}
+	query.Set("X-Amz-Algorithm", algorithm)
+	query.Set("X-Amz-Credential", req.creds.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(req.expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if req.creds.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", req.creds.SessionToken)
+	}
+	canonicalQuery := canonicalQueryString(query)
+
+	canonicalRequest := strings.Join([]string{
+		req.method,
+		req.path,
+		canonicalQuery,
+		"host:" + req.host + "\n",
+		"host",
+		req.payloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{algorithm, amzDate, scope, hexSHA256(canonicalRequest)}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+req.creds.SecretAccessKey), shortDate)
+	signingKey = hmacSHA256(signingKey, req.region)
+	signingKey = hmacSHA256(signingKey, req.service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	return canonicalQuery + "&X-Amz-Signature=" + signature
+}
+
+// canonicalQueryString returns the query string with parameters sorted by name and URI-encoded as required by AWS.
+func canonicalQueryString(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode encodes all characters except unreserved ones (A-Z, a-z, 0-9, '-', '.', '_' and '~').
+func uriEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data)) //nolint:errcheck // hash.Hash.Write never returns an error.
+	return h.Sum(nil)
+}
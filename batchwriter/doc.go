@@ -0,0 +1,12 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package batchwriter provides an asynchronous batch writer for high-volume writes (e.g., telemetry).
+// Rows are accumulated in memory and flushed when a batch is full or by interval, typically with a multi-row
+// INSERT or upsert statement (see NewInsertFlushFunc). The number of pending rows is bounded, so when the database
+// can't keep up, producers are slowed down (Writer.Write blocks) or rows are rejected (Writer.TryWrite)
+// instead of growing memory usage. Pending rows are flushed on shutdown.
+package batchwriter
@@ -0,0 +1,179 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package batchwriter
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// Maximum numbers of bind parameters in a single statement.
+const (
+	postgresMaxParams = 65535
+	mySQLMaxParams    = 65535
+	sqliteMaxParams   = 32766
+)
+
+type insertOptions struct {
+	conflictColumns []string
+	updateColumns   []string
+	txOptions       []dbkit.DoInTxOption
+}
+
+// InsertOption is a functional option for NewInsertFlushFunc.
+type InsertOption func(*insertOptions)
+
+// WithUpsert makes the flush function update the specified columns of the existing rows
+// that conflict with the inserted ones by conflictColumns (primary or unique key) instead of failing.
+// conflictColumns are ignored for MySQL since ON DUPLICATE KEY UPDATE is applied on conflict by any unique key.
+func WithUpsert(conflictColumns []string, updateColumns ...string) InsertOption {
+	return func(opts *insertOptions) {
+		opts.conflictColumns = conflictColumns
+		opts.updateColumns = updateColumns
+	}
+}
+
+// WithInsertTxOptions sets options of the transaction in which the batch is inserted
+// (e.g., dbkit.WithRetryPolicy for retrying transient errors, so rows are not dropped).
+func WithInsertTxOptions(options ...dbkit.DoInTxOption) InsertOption {
+	return func(opts *insertOptions) {
+		opts.txOptions = options
+	}
+}
+
+// NewInsertFlushFunc creates a FlushFunc that inserts the batch of rows into the table with multi-row INSERT statements
+// in a single transaction (dbkit.DoInTx). rowValues must return values of the columns in the same order.
+// If the batch exceeds the maximum number of bind parameters of the dialect, it's split into several statements.
+// Postgres, MySQL and SQLite dialects are supported.
+func NewInsertFlushFunc[T any](
+	dbConn *sql.DB, dialect dbkit.Dialect, table string, columns []string, rowValues func(row T) []interface{},
+	options ...InsertOption,
+) (FlushFunc[T], error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("columns must be specified")
+	}
+	var opts insertOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	builder, err := newInsertBuilder(dialect, table, columns, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, rows []T) error {
+		if len(rows) == 0 {
+			return nil
+		}
+		return dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+			for start := 0; start < len(rows); start += builder.maxRows {
+				end := start + builder.maxRows
+				if end > len(rows) {
+					end = len(rows)
+				}
+				args := make([]interface{}, 0, (end-start)*len(columns))
+				for _, row := range rows[start:end] {
+					values := rowValues(row)
+					if len(values) != len(columns) {
+						return fmt.Errorf("got %d values for %d columns", len(values), len(columns))
+					}
+					args = append(args, values...)
+				}
+				if _, execErr := tx.ExecContext(ctx, builder.build(end-start), args...); execErr != nil {
+					return fmt.Errorf("insert %d rows into %s: %w", end-start, table, execErr)
+				}
+			}
+			return nil
+		}, opts.txOptions...)
+	}, nil
+}
+
+type insertBuilder struct {
+	prefix       string
+	suffix       string
+	columnsCount int
+	maxRows      int
+	placeholder  func(i int) string
+}
+
+func newInsertBuilder(dialect dbkit.Dialect, table string, columns []string, opts insertOptions) (insertBuilder, error) {
+	b := insertBuilder{columnsCount: len(columns)}
+	var maxParams int
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		maxParams = postgresMaxParams
+		b.prefix = fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES `, table, strings.Join(columns, ", "))
+		b.placeholder = func(i int) string { return "$" + strconv.Itoa(i) }
+		b.suffix = makeOnConflictClause(opts, "EXCLUDED")
+	case dbkit.DialectSQLite:
+		maxParams = sqliteMaxParams
+		b.prefix = fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES `, table, strings.Join(columns, ", "))
+		b.placeholder = func(int) string { return "?" }
+		b.suffix = makeOnConflictClause(opts, "excluded")
+	case dbkit.DialectMySQL:
+		maxParams = mySQLMaxParams
+		b.prefix = fmt.Sprintf("INSERT INTO `%s` (%s) VALUES ", table, strings.Join(columns, ", "))
+		b.placeholder = func(int) string { return "?" }
+		if len(opts.updateColumns) != 0 {
+			assignments := make([]string, 0, len(opts.updateColumns))
+			for _, col := range opts.updateColumns {
+				assignments = append(assignments, fmt.Sprintf("%[1]s = VALUES(%[1]s)", col))
+			}
+			b.suffix = " ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+		}
+	default:
+		return insertBuilder{}, fmt.Errorf("unsupported sql dialect %q", dialect)
+	}
+	if len(opts.updateColumns) != 0 && len(opts.conflictColumns) == 0 && dialect != dbkit.DialectMySQL {
+		return insertBuilder{}, fmt.Errorf("conflict columns must be specified for upsert")
+	}
+	b.maxRows = maxParams / len(columns)
+	if b.maxRows == 0 {
+		return insertBuilder{}, fmt.Errorf("too many columns")
+	}
+	return b, nil
+}
+
+// makeOnConflictClause makes ON CONFLICT clause for Postgres and SQLite.
+func makeOnConflictClause(opts insertOptions, excludedTable string) string {
+	if len(opts.updateColumns) == 0 {
+		return ""
+	}
+	assignments := make([]string, 0, len(opts.updateColumns))
+	for _, col := range opts.updateColumns {
+		assignments = append(assignments, fmt.Sprintf("%s = %s.%s", col, excludedTable, col))
+	}
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s",
+		strings.Join(opts.conflictColumns, ", "), strings.Join(assignments, ", "))
+}
+
+// build returns the INSERT statement for the specified number of rows.
+func (b insertBuilder) build(rowsCount int) string {
+	var sb strings.Builder
+	sb.WriteString(b.prefix)
+	param := 1
+	for i := 0; i < rowsCount; i++ {
+		if i != 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('(')
+		for j := 0; j < b.columnsCount; j++ {
+			if j != 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(b.placeholder(param))
+			param++
+		}
+		sb.WriteByte(')')
+	}
+	sb.WriteString(b.suffix)
+	return sb.String()
+}
@@ -0,0 +1,156 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package batchwriter
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+type metric struct {
+	Name  string
+	Value int
+}
+
+func metricValues(m metric) []interface{} {
+	return []interface{}{m.Name, m.Value}
+}
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "batchwriter.db")+"?_journal=MEMORY&_sync=OFF&_busy_timeout=5000")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	_, err = dbConn.Exec(`CREATE TABLE "metrics" (name TEXT PRIMARY KEY, value INTEGER NOT NULL)`)
+	require.NoError(t, err)
+	return dbConn
+}
+
+func readMetrics(t *testing.T, dbConn *sql.DB) map[string]int {
+	t.Helper()
+	rows, err := dbConn.Query(`SELECT name, value FROM "metrics"`)
+	require.NoError(t, err)
+	defer func() { _ = rows.Close() }()
+	result := make(map[string]int)
+	for rows.Next() {
+		var m metric
+		require.NoError(t, rows.Scan(&m.Name, &m.Value))
+		result[m.Name] = m.Value
+	}
+	require.NoError(t, rows.Err())
+	return result
+}
+
+func TestNewInsertFlushFunc(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("insert", func(t *testing.T) {
+		dbConn := newTestDB(t)
+		flush, err := NewInsertFlushFunc(dbConn, dbkit.DialectSQLite, "metrics", []string{"name", "value"}, metricValues)
+		require.NoError(t, err)
+
+		require.NoError(t, flush(ctx, []metric{{"a", 1}, {"b", 2}}))
+		require.Equal(t, map[string]int{"a": 1, "b": 2}, readMetrics(t, dbConn))
+
+		// The whole batch is inserted in a single transaction.
+		require.Error(t, flush(ctx, []metric{{"c", 3}, {"a", 4}}))
+		require.Equal(t, map[string]int{"a": 1, "b": 2}, readMetrics(t, dbConn))
+	})
+
+	t.Run("upsert", func(t *testing.T) {
+		dbConn := newTestDB(t)
+		flush, err := NewInsertFlushFunc(dbConn, dbkit.DialectSQLite, "metrics", []string{"name", "value"}, metricValues,
+			WithUpsert([]string{"name"}, "value"))
+		require.NoError(t, err)
+
+		require.NoError(t, flush(ctx, []metric{{"a", 1}, {"b", 2}}))
+		require.NoError(t, flush(ctx, []metric{{"a", 3}, {"c", 4}}))
+		require.Equal(t, map[string]int{"a": 3, "b": 2, "c": 4}, readMetrics(t, dbConn))
+	})
+
+	t.Run("batch exceeding max bind parameters", func(t *testing.T) {
+		dbConn := newTestDB(t)
+		flush, err := NewInsertFlushFunc(dbConn, dbkit.DialectSQLite, "metrics", []string{"name", "value"}, metricValues)
+		require.NoError(t, err)
+
+		rows := make([]metric, sqliteMaxParams)
+		for i := range rows {
+			rows[i] = metric{Name: fmt.Sprintf("m%d", i), Value: i}
+		}
+		require.NoError(t, flush(ctx, rows))
+		require.Len(t, readMetrics(t, dbConn), len(rows))
+	})
+
+	t.Run("with writer", func(t *testing.T) {
+		dbConn := newTestDB(t)
+		flush, err := NewInsertFlushFunc(dbConn, dbkit.DialectSQLite, "metrics", []string{"name", "value"}, metricValues)
+		require.NoError(t, err)
+		w := NewWriter(flush, WithBatchSize(10), WithFlushInterval(time.Hour))
+		runCtx, cancel := context.WithCancel(ctx)
+		runDone := make(chan error)
+		go func() { runDone <- w.Run(runCtx) }()
+
+		for i := 0; i < 25; i++ {
+			require.NoError(t, w.Write(ctx, metric{Name: fmt.Sprintf("m%d", i), Value: i}))
+		}
+		cancel()
+		require.ErrorIs(t, <-runDone, context.Canceled)
+		require.Len(t, readMetrics(t, dbConn), 25)
+		require.Equal(t, Stats{Written: 25, Flushed: 25, Batches: 3}, w.Stats())
+	})
+}
+
+func TestInsertBuilder(t *testing.T) {
+	columns := []string{"name", "value"}
+	upsert := insertOptions{conflictColumns: []string{"name"}, updateColumns: []string{"value"}}
+	tests := []struct {
+		dialect dbkit.Dialect
+		opts    insertOptions
+		want    string
+	}{
+		{
+			dialect: dbkit.DialectPgx,
+			want:    `INSERT INTO "metrics" (name, value) VALUES ($1, $2), ($3, $4)`,
+		},
+		{
+			dialect: dbkit.DialectPostgres,
+			opts:    upsert,
+			want:    `INSERT INTO "metrics" (name, value) VALUES ($1, $2), ($3, $4) ON CONFLICT (name) DO UPDATE SET value = EXCLUDED.value`,
+		},
+		{
+			dialect: dbkit.DialectMySQL,
+			opts:    upsert,
+			want:    "INSERT INTO `metrics` (name, value) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE value = VALUES(value)",
+		},
+		{
+			dialect: dbkit.DialectSQLite,
+			opts:    upsert,
+			want:    `INSERT INTO "metrics" (name, value) VALUES (?, ?), (?, ?) ON CONFLICT (name) DO UPDATE SET value = excluded.value`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			builder, err := newInsertBuilder(tt.dialect, "metrics", columns, tt.opts)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, builder.build(2))
+		})
+	}
+
+	_, err := newInsertBuilder(dbkit.DialectMSSQL, "metrics", columns, insertOptions{})
+	require.EqualError(t, err, `unsupported sql dialect "mssql"`)
+	_, err = newInsertBuilder(dbkit.DialectPostgres, "metrics", columns, insertOptions{updateColumns: []string{"value"}})
+	require.EqualError(t, err, "conflict columns must be specified for upsert")
+}
@@ -0,0 +1,285 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package batchwriter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default values for the Writer options.
+const (
+	DefaultBatchSize       = 500
+	DefaultFlushInterval   = time.Second
+	DefaultMaxPendingRows  = 10000
+	DefaultShutdownTimeout = 10 * time.Second
+)
+
+// ErrStopped is returned when a row is written to the stopped Writer.
+var ErrStopped = errors.New("batch writer is stopped")
+
+// FlushFunc writes the batch of rows to the database (see NewInsertFlushFunc).
+// The rows slice is reused by Writer, so it must not be retained after returning.
+type FlushFunc[T any] func(ctx context.Context, rows []T) error
+
+// Logger is an interface for logging errors.
+type Logger interface {
+	Errorf(format string, args ...interface{})
+}
+
+type writerOptions struct {
+	batchSize       int
+	flushInterval   time.Duration
+	maxPendingRows  int
+	shutdownTimeout time.Duration
+	logger          Logger
+}
+
+// Option is a functional option for NewWriter.
+type Option func(*writerOptions)
+
+// WithBatchSize sets the maximum number of rows flushed at once. By default, DefaultBatchSize is used.
+func WithBatchSize(size int) Option {
+	return func(opts *writerOptions) {
+		opts.batchSize = size
+	}
+}
+
+// WithFlushInterval sets the interval of flushing the accumulated rows even if the batch is not full.
+// By default, DefaultFlushInterval is used.
+func WithFlushInterval(interval time.Duration) Option {
+	return func(opts *writerOptions) {
+		opts.flushInterval = interval
+	}
+}
+
+// WithMaxPendingRows sets the maximum number of rows waiting for flushing in addition to the batch being flushed.
+// When it's reached, Write blocks and TryWrite rejects rows. By default, DefaultMaxPendingRows is used.
+func WithMaxPendingRows(n int) Option {
+	return func(opts *writerOptions) {
+		opts.maxPendingRows = n
+	}
+}
+
+// WithShutdownTimeout sets the timeout for flushing the pending rows when Run is stopped.
+// By default, DefaultShutdownTimeout is used.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(opts *writerOptions) {
+		opts.shutdownTimeout = timeout
+	}
+}
+
+// WithLogger sets the logger for errors of flushing.
+func WithLogger(logger Logger) Option {
+	return func(opts *writerOptions) {
+		opts.logger = logger
+	}
+}
+
+// Stats contains statistics of the Writer.
+type Stats struct {
+	// Written is the number of rows accepted by Write and TryWrite.
+	Written int64
+	// Rejected is the number of rows rejected by TryWrite because of the full buffer.
+	Rejected int64
+	// Flushed is the number of rows successfully flushed.
+	Flushed int64
+	// Dropped is the number of rows dropped because their batch failed to flush.
+	Dropped int64
+	// Batches is the number of successfully flushed batches.
+	Batches int64
+}
+
+// Writer accumulates rows and flushes them in batches asynchronously (in Run).
+// Errors of flushing are logged, and rows of the failed batch are dropped,
+// so FlushFunc should retry transient errors itself if the rows must not be lost.
+type Writer[T any] struct {
+	flush FlushFunc[T]
+	opts  writerOptions
+	rows  chan T
+	done  chan struct{}
+
+	mu      sync.RWMutex
+	stopped bool
+	running atomic.Bool
+
+	written  atomic.Int64
+	rejected atomic.Int64
+	flushed  atomic.Int64
+	dropped  atomic.Int64
+	batches  atomic.Int64
+}
+
+// NewWriter creates a new Writer that flushes rows with the flush function.
+func NewWriter[T any](flush FlushFunc[T], options ...Option) *Writer[T] {
+	opts := writerOptions{
+		batchSize:       DefaultBatchSize,
+		flushInterval:   DefaultFlushInterval,
+		maxPendingRows:  DefaultMaxPendingRows,
+		shutdownTimeout: DefaultShutdownTimeout,
+		logger:          disabledLogger{},
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.batchSize <= 0 {
+		opts.batchSize = DefaultBatchSize
+	}
+	if opts.maxPendingRows < 0 {
+		opts.maxPendingRows = 0
+	}
+	return &Writer[T]{
+		flush: flush,
+		opts:  opts,
+		rows:  make(chan T, opts.maxPendingRows),
+		done:  make(chan struct{}),
+	}
+}
+
+// Write adds the row to the buffer. If the buffer is full, it blocks until there is free space (backpressure),
+// ctx is done, or the Writer is stopped (ErrStopped is returned then).
+func (w *Writer[T]) Write(ctx context.Context, row T) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.stopped {
+		return ErrStopped
+	}
+	select {
+	case w.rows <- row:
+		w.written.Add(1)
+		return nil
+	case <-w.done:
+		return ErrStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TryWrite adds the row to the buffer without blocking.
+// It returns false if the buffer is full or the Writer is stopped.
+func (w *Writer[T]) TryWrite(row T) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.stopped {
+		return false
+	}
+	select {
+	case w.rows <- row:
+		w.written.Add(1)
+		return true
+	default:
+		w.rejected.Add(1)
+		return false
+	}
+}
+
+// Stats returns statistics of the Writer.
+func (w *Writer[T]) Stats() Stats {
+	return Stats{
+		Written:  w.written.Load(),
+		Rejected: w.rejected.Load(),
+		Flushed:  w.flushed.Load(),
+		Dropped:  w.dropped.Load(),
+		Batches:  w.batches.Load(),
+	}
+}
+
+// Run flushes the accumulated rows when the batch is full or by interval until ctx is done.
+// Then the Writer is stopped (subsequent writes fail with ErrStopped), and all pending rows
+// (including the batch being flushed at the moment) are flushed within the shutdown timeout.
+// Run may be called only once, and it returns ctx.Err().
+func (w *Writer[T]) Run(ctx context.Context) error {
+	if !w.running.CompareAndSwap(false, true) {
+		return errors.New("batch writer is already running")
+	}
+
+	// Flushing is not interrupted by ctx immediately, only when the shutdown timeout is exceeded.
+	flushCtx, cancelFlush := context.WithCancel(context.Background())
+	defer cancelFlush()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-flushCtx.Done():
+			return
+		}
+		timer := time.NewTimer(w.opts.shutdownTimeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancelFlush()
+		case <-flushCtx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(w.opts.flushInterval)
+	defer ticker.Stop()
+	batch := make([]T, 0, w.opts.batchSize)
+	for {
+		if ctx.Err() != nil { // Don't accept new rows after the long flushing if ctx is already done.
+			w.flushOnShutdown(flushCtx, batch)
+			return ctx.Err()
+		}
+		select {
+		case <-ctx.Done():
+			continue
+		case row := <-w.rows:
+			batch = append(batch, row)
+			if len(batch) < w.opts.batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		w.flushBatch(flushCtx, batch)
+		batch = batch[:0]
+		ticker.Reset(w.opts.flushInterval)
+	}
+}
+
+// flushOnShutdown stops accepting new rows and flushes the batch and all rows remaining in the buffer.
+func (w *Writer[T]) flushOnShutdown(ctx context.Context, batch []T) {
+	close(w.done) // Release writers blocked on the full buffer.
+	w.mu.Lock()   // Wait for writers that are sending rows right now.
+	w.stopped = true
+	w.mu.Unlock()
+
+	for {
+		select {
+		case row := <-w.rows:
+			batch = append(batch, row)
+			if len(batch) < w.opts.batchSize {
+				continue
+			}
+		default:
+			if len(batch) != 0 {
+				w.flushBatch(ctx, batch)
+			}
+			return
+		}
+		w.flushBatch(ctx, batch)
+		batch = batch[:0]
+	}
+}
+
+func (w *Writer[T]) flushBatch(ctx context.Context, batch []T) {
+	if err := w.flush(ctx, batch); err != nil {
+		w.dropped.Add(int64(len(batch)))
+		w.opts.logger.Errorf("failed to flush batch of %d rows: %v", len(batch), err)
+		return
+	}
+	w.flushed.Add(int64(len(batch)))
+	w.batches.Add(1)
+}
+
+type disabledLogger struct{}
+
+func (disabledLogger) Errorf(format string, args ...interface{}) {}
@@ -0,0 +1,201 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package batchwriter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]int
+	err     error
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *batchRecorder) flush(ctx context.Context, rows []int) error {
+	if r.started != nil {
+		r.started <- struct{}{}
+	}
+	if r.release != nil {
+		select {
+		case <-r.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.batches = append(r.batches, append([]int(nil), rows...))
+	return nil
+}
+
+func (r *batchRecorder) getBatches() [][]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]int(nil), r.batches...)
+}
+
+func runWriter(t *testing.T, w *Writer[int]) (cancel func()) {
+	t.Helper()
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	runDone := make(chan error)
+	go func() { runDone <- w.Run(ctx) }()
+	var once sync.Once
+	cancel = func() {
+		once.Do(func() {
+			cancelCtx()
+			require.ErrorIs(t, <-runDone, context.Canceled)
+		})
+	}
+	t.Cleanup(cancel)
+	return cancel
+}
+
+func TestWriter_FlushBySize(t *testing.T) {
+	recorder := &batchRecorder{}
+	w := NewWriter(recorder.flush, WithBatchSize(3), WithFlushInterval(time.Hour))
+	runWriter(t, w)
+
+	for i := 1; i <= 7; i++ {
+		require.NoError(t, w.Write(context.Background(), i))
+	}
+	require.Eventually(t, func() bool { return len(recorder.getBatches()) == 2 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, [][]int{{1, 2, 3}, {4, 5, 6}}, recorder.getBatches())
+	require.Equal(t, Stats{Written: 7, Flushed: 6, Batches: 2}, w.Stats())
+}
+
+func TestWriter_FlushByInterval(t *testing.T) {
+	recorder := &batchRecorder{}
+	w := NewWriter(recorder.flush, WithBatchSize(100), WithFlushInterval(50*time.Millisecond))
+	runWriter(t, w)
+
+	require.NoError(t, w.Write(context.Background(), 1))
+	require.NoError(t, w.Write(context.Background(), 2))
+	require.Eventually(t, func() bool { return len(recorder.getBatches()) == 1 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, [][]int{{1, 2}}, recorder.getBatches())
+}
+
+func TestWriter_FlushOnShutdown(t *testing.T) {
+	recorder := &batchRecorder{}
+	w := NewWriter(recorder.flush, WithBatchSize(2), WithFlushInterval(time.Hour))
+	cancel := runWriter(t, w)
+
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, w.Write(context.Background(), i))
+	}
+	cancel()
+
+	var rows []int
+	for _, batch := range recorder.getBatches() {
+		require.LessOrEqual(t, len(batch), 2)
+		rows = append(rows, batch...)
+	}
+	require.Equal(t, []int{1, 2, 3, 4, 5}, rows)
+
+	require.ErrorIs(t, w.Write(context.Background(), 6), ErrStopped)
+	require.False(t, w.TryWrite(6))
+	require.Equal(t, int64(5), w.Stats().Flushed)
+}
+
+func TestWriter_Backpressure(t *testing.T) {
+	recorder := &batchRecorder{started: make(chan struct{}, 10), release: make(chan struct{})}
+	w := NewWriter(recorder.flush, WithBatchSize(1), WithMaxPendingRows(2), WithFlushInterval(time.Hour))
+	cancel := runWriter(t, w)
+
+	// The first row is being flushed, and the next two fill the buffer.
+	require.NoError(t, w.Write(context.Background(), 1))
+	<-recorder.started
+	require.True(t, w.TryWrite(2))
+	require.True(t, w.TryWrite(3))
+
+	require.False(t, w.TryWrite(4))
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer ctxCancel()
+	require.ErrorIs(t, w.Write(ctx, 4), context.DeadlineExceeded)
+
+	writeDone := make(chan error)
+	go func() { writeDone <- w.Write(context.Background(), 4) }()
+	select {
+	case <-writeDone:
+		t.Fatal("Write must block while the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	recorder.release <- struct{}{}
+	require.NoError(t, <-writeDone)
+
+	close(recorder.release)
+	cancel()
+	require.Equal(t, [][]int{{1}, {2}, {3}, {4}}, recorder.getBatches())
+	require.Equal(t, Stats{Written: 4, Rejected: 1, Flushed: 4, Batches: 4}, w.Stats())
+}
+
+func TestWriter_BlockedWriteIsReleasedOnShutdown(t *testing.T) {
+	recorder := &batchRecorder{started: make(chan struct{}, 10), release: make(chan struct{})}
+	w := NewWriter(recorder.flush, WithBatchSize(1), WithMaxPendingRows(1), WithFlushInterval(time.Hour),
+		WithShutdownTimeout(50*time.Millisecond))
+	cancel := runWriter(t, w)
+
+	require.NoError(t, w.Write(context.Background(), 1))
+	<-recorder.started
+	require.NoError(t, w.Write(context.Background(), 2))
+
+	writeDone := make(chan error)
+	go func() { writeDone <- w.Write(context.Background(), 3) }()
+	cancel()
+	require.ErrorIs(t, <-writeDone, ErrStopped)
+	// Flushing is interrupted by the shutdown timeout.
+	require.Equal(t, int64(2), w.Stats().Dropped)
+}
+
+func TestWriter_FlushError(t *testing.T) {
+	recorder := &batchRecorder{err: errors.New("insert failed")}
+	logger := &testLogger{}
+	w := NewWriter(recorder.flush, WithBatchSize(2), WithFlushInterval(time.Hour), WithLogger(logger))
+	cancel := runWriter(t, w)
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, w.Write(context.Background(), i))
+	}
+	cancel()
+	require.Equal(t, Stats{Written: 3, Dropped: 3}, w.Stats())
+	require.Equal(t, 2, logger.count())
+}
+
+func TestWriter_RunTwice(t *testing.T) {
+	w := NewWriter((&batchRecorder{}).flush)
+	runWriter(t, w)
+	require.Eventually(t, w.running.Load, time.Second, time.Millisecond)
+	require.EqualError(t, w.Run(context.Background()), "batch writer is already running")
+}
+
+type testLogger struct {
+	mu     sync.Mutex
+	errors []string
+}
+
+func (l *testLogger) Errorf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, format)
+}
+
+func (l *testLogger) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.errors)
+}
@@ -0,0 +1,221 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package benchkit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Default values for the benchmark options.
+const (
+	DefaultConcurrency = 10
+	DefaultDuration    = 10 * time.Second
+)
+
+// Workload is a unit of work (e.g., a query or a transaction) that is executed repeatedly during the benchmark.
+type Workload func(ctx context.Context, dbConn *sql.DB) error
+
+// PoolSettings contains connection pool settings of the database handle.
+type PoolSettings struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// String returns a human-readable representation of the pool settings.
+func (s PoolSettings) String() string {
+	return fmt.Sprintf("maxOpen=%d maxIdle=%d maxLifetime=%s", s.MaxOpenConns, s.MaxIdleConns, s.ConnMaxLifetime)
+}
+
+// Apply applies the pool settings to the database handle.
+func (s PoolSettings) Apply(dbConn *sql.DB) {
+	dbConn.SetMaxOpenConns(s.MaxOpenConns)
+	dbConn.SetMaxIdleConns(s.MaxIdleConns)
+	dbConn.SetConnMaxLifetime(s.ConnMaxLifetime)
+}
+
+// Result contains the result of the benchmark run with the specific pool settings.
+type Result struct {
+	Settings   PoolSettings
+	Requests   int
+	Errors     int
+	Elapsed    time.Duration
+	Throughput float64 // Successful requests per second.
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+}
+
+type runOptions struct {
+	concurrency int
+	duration    time.Duration
+	requests    int
+}
+
+// RunOption represents an option for the benchmark run.
+type RunOption func(*runOptions)
+
+// WithConcurrency sets the number of goroutines that execute the workload concurrently.
+func WithConcurrency(concurrency int) RunOption {
+	return func(opts *runOptions) {
+		opts.concurrency = concurrency
+	}
+}
+
+// WithDuration sets how long the workload is executed for each pool settings.
+func WithDuration(duration time.Duration) RunOption {
+	return func(opts *runOptions) {
+		opts.duration = duration
+	}
+}
+
+// WithRequests limits the total number of workload executions for each pool settings.
+// The run stops when either the limit is reached or the duration is elapsed.
+func WithRequests(requests int) RunOption {
+	return func(opts *runOptions) {
+		opts.requests = requests
+	}
+}
+
+// Run applies the pool settings to the database handle and executes the workload concurrently.
+// Workload errors are counted in the result, only successful executions are used for latency percentiles.
+func Run(ctx context.Context, dbConn *sql.DB, settings PoolSettings, workload Workload, options ...RunOption) (Result, error) {
+	opts := runOptions{concurrency: DefaultConcurrency, duration: DefaultDuration}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.concurrency <= 0 {
+		return Result{}, fmt.Errorf("concurrency should be positive, got %d", opts.concurrency)
+	}
+
+	settings.Apply(dbConn)
+
+	runCtx, runCtxCancel := context.WithTimeout(ctx, opts.duration)
+	defer runCtxCancel()
+
+	var mu sync.Mutex
+	var latencies []time.Duration
+	var requests, errs int
+
+	takeRequest := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if opts.requests > 0 && requests >= opts.requests {
+			return false
+		}
+		requests++
+		return true
+	}
+
+	startTime := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < opts.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for runCtx.Err() == nil && takeRequest() {
+				reqStartTime := time.Now()
+				err := workload(runCtx, dbConn)
+				latency := time.Since(reqStartTime)
+				mu.Lock()
+				if err != nil {
+					if runCtx.Err() != nil {
+						requests-- // The request was interrupted by the end of the run.
+					} else {
+						errs++
+					}
+				} else {
+					latencies = append(latencies, latency)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(startTime)
+
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	result := Result{Settings: settings, Requests: requests, Errors: errs, Elapsed: elapsed}
+	if len(latencies) != 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		result.P50 = percentile(latencies, 50)
+		result.P90 = percentile(latencies, 90)
+		result.P99 = percentile(latencies, 99)
+		result.Max = latencies[len(latencies)-1]
+		result.Throughput = float64(len(latencies)) / elapsed.Seconds()
+	}
+	return result, nil
+}
+
+// Sweep runs the benchmark for each of the pool settings sequentially.
+// Original pool settings of the database handle are not restored.
+func Sweep(
+	ctx context.Context, dbConn *sql.DB, settings []PoolSettings, workload Workload, options ...RunOption,
+) ([]Result, error) {
+	results := make([]Result, 0, len(settings))
+	for _, s := range settings {
+		result, err := Run(ctx, dbConn, s, workload, options...)
+		if err != nil {
+			return results, fmt.Errorf("run benchmark with %s: %w", s, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// PoolSettingsGrid returns all combinations of the passed pool settings values.
+// Combinations where maxIdleConns exceeds positive maxOpenConns are skipped since database/sql reduces them anyway.
+func PoolSettingsGrid(maxOpenConns, maxIdleConns []int, connMaxLifetimes []time.Duration) []PoolSettings {
+	var grid []PoolSettings
+	for _, maxOpen := range maxOpenConns {
+		for _, maxIdle := range maxIdleConns {
+			if maxOpen > 0 && maxIdle > maxOpen {
+				continue
+			}
+			for _, lifetime := range connMaxLifetimes {
+				grid = append(grid, PoolSettings{MaxOpenConns: maxOpen, MaxIdleConns: maxIdle, ConnMaxLifetime: lifetime})
+			}
+		}
+	}
+	return grid
+}
+
+// WriteReport writes the benchmark results as a table.
+func WriteReport(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "MAX OPEN\tMAX IDLE\tMAX LIFETIME\tREQUESTS\tERRORS\tRPS\tP50\tP90\tP99\tMAX"); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if _, err := fmt.Fprintf(tw, "%d\t%d\t%s\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\n",
+			r.Settings.MaxOpenConns, r.Settings.MaxIdleConns, r.Settings.ConnMaxLifetime,
+			r.Requests, r.Errors, r.Throughput, r.P50, r.P90, r.P99, r.Max); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	idx := (len(sorted)*p + 99) / 100
+	if idx < 1 {
+		idx = 1
+	}
+	return sorted[idx-1]
+}
@@ -0,0 +1,73 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package benchkit
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSweep(t *testing.T) {
+	dbConn, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, dbConn.Close()) }()
+
+	var calls int32
+	workload := func(ctx context.Context, dbConn *sql.DB) error {
+		if atomic.AddInt32(&calls, 1)%10 == 0 {
+			return errors.New("workload failed")
+		}
+		var one int
+		return dbConn.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+	}
+
+	settings := PoolSettingsGrid([]int{1, 4}, []int{1, 2}, []time.Duration{time.Minute})
+	require.Equal(t, []PoolSettings{
+		{MaxOpenConns: 1, MaxIdleConns: 1, ConnMaxLifetime: time.Minute},
+		{MaxOpenConns: 4, MaxIdleConns: 1, ConnMaxLifetime: time.Minute},
+		{MaxOpenConns: 4, MaxIdleConns: 2, ConnMaxLifetime: time.Minute},
+	}, settings)
+
+	results, err := Sweep(context.Background(), dbConn, settings, workload,
+		WithConcurrency(4), WithDuration(10*time.Second), WithRequests(100))
+	require.NoError(t, err)
+	require.Len(t, results, len(settings))
+	for i, result := range results {
+		require.Equal(t, settings[i], result.Settings)
+		require.Equal(t, 100, result.Requests)
+		require.Equal(t, 10, result.Errors)
+		require.Greater(t, result.Throughput, 0.0)
+		require.LessOrEqual(t, result.P50, result.P90)
+		require.LessOrEqual(t, result.P90, result.P99)
+		require.LessOrEqual(t, result.P99, result.Max)
+	}
+	require.Equal(t, 4, dbConn.Stats().MaxOpenConnections)
+
+	var report bytes.Buffer
+	require.NoError(t, WriteReport(&report, results))
+	require.Contains(t, report.String(), "MAX OPEN")
+	require.Equal(t, len(results)+1, bytes.Count(report.Bytes(), []byte("\n")))
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration(i+1) * time.Millisecond
+	}
+	require.Equal(t, 50*time.Millisecond, percentile(latencies, 50))
+	require.Equal(t, 99*time.Millisecond, percentile(latencies, 99))
+	require.Equal(t, time.Millisecond, percentile(latencies[:1], 99))
+}
@@ -0,0 +1,10 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package benchkit provides a harness for benchmarking query workloads against a database handle
+// with different connection pool settings (MaxOpenConns, MaxIdleConns, ConnMaxLifetime).
+// It reports latency percentiles and throughput for each setting, so pool settings may be picked empirically.
+package benchkit
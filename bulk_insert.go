@@ -0,0 +1,261 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/acronis/go-appkit/retry"
+)
+
+// Maximum numbers of bind parameters in a single statement.
+const (
+	postgresMaxBindParams = 65535
+	mySQLMaxBindParams    = 65535
+	sqliteMaxBindParams   = 32766
+
+	// SQL Server limits RPC requests to 2100 parameters, but go-mssqldb sends statements via sp_executesql,
+	// whose @stmt and @params take 2 of them.
+	mssqlMaxBindParams = 2098
+)
+
+// mssqlMaxInsertRows is the maximum number of rows in the VALUES clause of the INSERT statement in MSSQL.
+const mssqlMaxInsertRows = 1000
+
+type bulkInsertOptions struct {
+	batchSize       int
+	conflictColumns []string
+	updateColumns   []string
+	ignoreConflicts bool
+	retryPolicy     retry.Policy
+	isRetryable     retry.IsRetryable
+}
+
+// BulkInsertOption is a functional option for BulkInsert.
+type BulkInsertOption func(*bulkInsertOptions)
+
+// WithBulkInsertBatchSize sets the maximum number of rows inserted by a single statement.
+// By default (and if the size exceeds it), the maximum number of rows fitting into the bind parameters limit
+// of the dialect is used.
+func WithBulkInsertBatchSize(size int) BulkInsertOption {
+	return func(opts *bulkInsertOptions) {
+		opts.batchSize = size
+	}
+}
+
+// WithBulkInsertUpsert makes BulkInsert update the specified columns of the existing rows
+// that conflict with the inserted ones by conflictColumns (primary or unique key) instead of failing
+// (ON CONFLICT ... DO UPDATE for Postgres and SQLite, ON DUPLICATE KEY UPDATE for MySQL).
+// conflictColumns are ignored for MySQL since ON DUPLICATE KEY UPDATE is applied on conflict by any unique key.
+// It's not supported for MSSQL.
+func WithBulkInsertUpsert(conflictColumns []string, updateColumns ...string) BulkInsertOption {
+	return func(opts *bulkInsertOptions) {
+		opts.conflictColumns = conflictColumns
+		opts.updateColumns = updateColumns
+		opts.ignoreConflicts = false
+	}
+}
+
+// WithBulkInsertIgnoreConflicts makes BulkInsert skip rows that conflict with the existing ones
+// by conflictColumns (any unique key if they are not specified) instead of failing.
+// It's not supported for MSSQL.
+func WithBulkInsertIgnoreConflicts(conflictColumns ...string) BulkInsertOption {
+	return func(opts *bulkInsertOptions) {
+		opts.conflictColumns = conflictColumns
+		opts.updateColumns = nil
+		opts.ignoreConflicts = true
+	}
+}
+
+// WithBulkInsertRetryPolicy sets retry policy for each statement (batch) executed by BulkInsert.
+// It should be used only if the executor is not a transaction (e.g., *sql.DB),
+// since failed statements usually abort the transaction, so the whole transaction should be retried instead.
+func WithBulkInsertRetryPolicy(policy retry.Policy) BulkInsertOption {
+	return func(opts *bulkInsertOptions) {
+		opts.retryPolicy = policy
+	}
+}
+
+// WithBulkInsertIsRetryable sets the function that determines whether the failed batch may be retried.
+// By default, GetIsRetryableForDialect is used.
+func WithBulkInsertIsRetryable(isRetryable retry.IsRetryable) BulkInsertOption {
+	return func(opts *bulkInsertOptions) {
+		opts.isRetryable = isRetryable
+	}
+}
+
+// BulkInsert inserts rows into the table with multi-row INSERT statements. Each row must contain values
+// of the columns in the same order. Rows are split into batches, so each statement fits into the bind parameters limit
+// of the dialect (e.g., 65535 for Postgres, see also WithBulkInsertBatchSize).
+// Postgres, MySQL, SQLite and MSSQL dialects are supported.
+// The table name may be schema-qualified (e.g., "public.users"), it's quoted according to the dialect.
+// It returns the total number of rows affected by the executed statements (as reported by the driver).
+// If some batch fails, the number of rows affected by the previous ones is returned along with the error.
+func BulkInsert(
+	ctx context.Context, execer SQLExecutor, dialect Dialect, table string, columns []string, rows [][]interface{},
+	options ...BulkInsertOption,
+) (int64, error) {
+	var opts bulkInsertOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	builder, err := newBulkInsertBuilder(dialect, table, columns, opts)
+	if err != nil {
+		return 0, err
+	}
+	if opts.retryPolicy != nil && opts.isRetryable == nil {
+		opts.isRetryable = GetIsRetryableForDialect(dialect)
+	}
+
+	var affected int64
+	for start := 0; start < len(rows); start += builder.maxRows {
+		end := start + builder.maxRows
+		if end > len(rows) {
+			end = len(rows)
+		}
+		args := make([]interface{}, 0, (end-start)*len(columns))
+		for _, row := range rows[start:end] {
+			if len(row) != len(columns) {
+				return affected, fmt.Errorf("got %d values for %d columns", len(row), len(columns))
+			}
+			args = append(args, row...)
+		}
+		query := builder.build(end - start)
+		var batchAffected int64
+		execBatch := func(ctx context.Context) error {
+			result, execErr := execer.ExecContext(ctx, query, args...)
+			if execErr != nil {
+				return execErr
+			}
+			batchAffected, execErr = result.RowsAffected()
+			return execErr
+		}
+		if opts.retryPolicy != nil {
+			err = retry.DoWithRetry(ctx, opts.retryPolicy, opts.isRetryable, nil, execBatch)
+		} else {
+			err = execBatch(ctx)
+		}
+		if err != nil {
+			return affected, fmt.Errorf("insert %d rows into %s: %w", end-start, table, err)
+		}
+		affected += batchAffected
+	}
+	return affected, nil
+}
+
+type bulkInsertBuilder struct {
+	prefix       string
+	suffix       string
+	columnsCount int
+	maxRows      int
+	placeholder  func(i int) string
+}
+
+func newBulkInsertBuilder(dialect Dialect, table string, columns []string, opts bulkInsertOptions) (bulkInsertBuilder, error) {
+	if len(columns) == 0 {
+		return bulkInsertBuilder{}, fmt.Errorf("columns must be specified")
+	}
+	if len(opts.updateColumns) != 0 && len(opts.conflictColumns) == 0 && dialect != DialectMySQL {
+		return bulkInsertBuilder{}, fmt.Errorf("conflict columns must be specified for upsert")
+	}
+	b := bulkInsertBuilder{columnsCount: len(columns)}
+	quotedTable := quoteTableName(dialect, table)
+	columnsList := strings.Join(columns, ", ")
+	var maxParams int
+	switch dialect {
+	case DialectPostgres, DialectPgx:
+		maxParams = postgresMaxBindParams
+		b.prefix = fmt.Sprintf("INSERT INTO %s (%s) VALUES ", quotedTable, columnsList)
+		b.placeholder = func(i int) string { return "$" + strconv.Itoa(i) }
+		b.suffix = makeBulkInsertOnConflictClause(opts, "EXCLUDED")
+	case DialectSQLite:
+		maxParams = sqliteMaxBindParams
+		b.prefix = fmt.Sprintf("INSERT INTO %s (%s) VALUES ", quotedTable, columnsList)
+		b.placeholder = func(int) string { return "?" }
+		b.suffix = makeBulkInsertOnConflictClause(opts, "excluded")
+	case DialectMySQL:
+		maxParams = mySQLMaxBindParams
+		b.prefix = fmt.Sprintf("INSERT INTO %s (%s) VALUES ", quotedTable, columnsList)
+		b.placeholder = func(int) string { return "?" }
+		switch {
+		case opts.ignoreConflicts:
+			// Unlike INSERT IGNORE, the no-op update doesn't suppress errors other than duplicate keys.
+			b.suffix = fmt.Sprintf(" ON DUPLICATE KEY UPDATE %[1]s = %[1]s", columns[0])
+		case len(opts.updateColumns) != 0:
+			assignments := make([]string, 0, len(opts.updateColumns))
+			for _, col := range opts.updateColumns {
+				assignments = append(assignments, fmt.Sprintf("%[1]s = VALUES(%[1]s)", col))
+			}
+			b.suffix = " ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+		}
+	case DialectMSSQL:
+		if len(opts.updateColumns) != 0 || opts.ignoreConflicts {
+			return bulkInsertBuilder{}, fmt.Errorf("conflict handling is not supported for %s dialect", dialect)
+		}
+		maxParams = mssqlMaxBindParams
+		b.prefix = fmt.Sprintf("INSERT INTO %s (%s) VALUES ", quotedTable, columnsList)
+		b.placeholder = func(i int) string { return "@p" + strconv.Itoa(i) }
+	default:
+		return bulkInsertBuilder{}, fmt.Errorf("unsupported dialect %q", dialect)
+	}
+	b.maxRows = maxParams / len(columns)
+	if b.maxRows == 0 {
+		return bulkInsertBuilder{}, fmt.Errorf("too many columns")
+	}
+	if dialect == DialectMSSQL && b.maxRows > mssqlMaxInsertRows {
+		b.maxRows = mssqlMaxInsertRows
+	}
+	if opts.batchSize > 0 && opts.batchSize < b.maxRows {
+		b.maxRows = opts.batchSize
+	}
+	return b, nil
+}
+
+// makeBulkInsertOnConflictClause makes ON CONFLICT clause for Postgres and SQLite.
+func makeBulkInsertOnConflictClause(opts bulkInsertOptions, excludedTable string) string {
+	var target string
+	if len(opts.conflictColumns) != 0 {
+		target = " (" + strings.Join(opts.conflictColumns, ", ") + ")"
+	}
+	if opts.ignoreConflicts {
+		return " ON CONFLICT" + target + " DO NOTHING"
+	}
+	if len(opts.updateColumns) == 0 {
+		return ""
+	}
+	assignments := make([]string, 0, len(opts.updateColumns))
+	for _, col := range opts.updateColumns {
+		assignments = append(assignments, fmt.Sprintf("%s = %s.%s", col, excludedTable, col))
+	}
+	return " ON CONFLICT" + target + " DO UPDATE SET " + strings.Join(assignments, ", ")
+}
+
+// build returns the INSERT statement for the specified number of rows.
+func (b bulkInsertBuilder) build(rowsCount int) string {
+	var sb strings.Builder
+	sb.WriteString(b.prefix)
+	param := 1
+	for i := 0; i < rowsCount; i++ {
+		if i != 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('(')
+		for j := 0; j < b.columnsCount; j++ {
+			if j != 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(b.placeholder(param))
+			param++
+		}
+		sb.WriteByte(')')
+	}
+	sb.WriteString(b.suffix)
+	return sb.String()
+}
@@ -0,0 +1,214 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/acronis/go-appkit/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkInsert(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "bulk_insert.db"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, dbConn.Close()) }()
+	_, err = dbConn.Exec(`CREATE TABLE "metrics" (name TEXT PRIMARY KEY, value INTEGER NOT NULL)`)
+	require.NoError(t, err)
+	columns := []string{"name", "value"}
+
+	readMetrics := func() map[string]int {
+		t.Helper()
+		rows, queryErr := dbConn.Query(`SELECT name, value FROM "metrics"`)
+		require.NoError(t, queryErr)
+		defer func() { require.NoError(t, rows.Close()) }()
+		result := make(map[string]int)
+		for rows.Next() {
+			var name string
+			var value int
+			require.NoError(t, rows.Scan(&name, &value))
+			result[name] = value
+		}
+		require.NoError(t, rows.Err())
+		return result
+	}
+
+	rows := make([][]interface{}, sqliteMaxBindParams)
+	for i := range rows {
+		rows[i] = []interface{}{fmt.Sprintf("m%d", i), i}
+	}
+	affected, err := BulkInsert(ctx, dbConn, DialectSQLite, "metrics", columns, rows)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(rows)), affected)
+	require.Len(t, readMetrics(), len(rows))
+	_, err = dbConn.Exec(`DELETE FROM "metrics"`)
+	require.NoError(t, err)
+
+	affected, err = BulkInsert(ctx, dbConn, DialectSQLite, "metrics", columns, [][]interface{}{{"a", 1}, {"b", 2}})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), affected)
+
+	// The statement is atomic, so no rows are inserted if some of them conflict.
+	_, err = BulkInsert(ctx, dbConn, DialectSQLite, "metrics", columns, [][]interface{}{{"c", 3}, {"a", 4}})
+	require.Error(t, err)
+
+	affected, err = BulkInsert(ctx, dbConn, DialectSQLite, "metrics", columns, [][]interface{}{{"a", 5}, {"d", 6}},
+		WithBulkInsertIgnoreConflicts("name"))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), affected)
+
+	_, err = BulkInsert(ctx, dbConn, DialectSQLite, "metrics", columns, [][]interface{}{{"a", 7}, {"e", 8}},
+		WithBulkInsertUpsert([]string{"name"}, "value"), WithBulkInsertBatchSize(1))
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"a": 7, "b": 2, "d": 6, "e": 8}, readMetrics())
+
+	_, err = BulkInsert(ctx, dbConn, DialectSQLite, "metrics", columns, [][]interface{}{{"f"}})
+	require.EqualError(t, err, "got 1 values for 2 columns")
+}
+
+func TestBulkInsert_Retry(t *testing.T) {
+	dbConn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, mock.ExpectationsWereMet())
+	}()
+
+	retryableErr := errors.New("retryable error")
+	query := `INSERT INTO "metrics" (name, value) VALUES ($1, $2), ($3, $4)`
+	mock.ExpectExec(query).WithArgs("a", 1, "b", 2).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO "metrics" (name, value) VALUES ($1, $2)`).WithArgs("c", 3).WillReturnError(retryableErr)
+	mock.ExpectExec(`INSERT INTO "metrics" (name, value) VALUES ($1, $2)`).WithArgs("c", 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.MatchExpectationsInOrder(true)
+
+	affected, err := BulkInsert(context.Background(), dbConn, DialectPostgres, "metrics", []string{"name", "value"},
+		[][]interface{}{{"a", 1}, {"b", 2}, {"c", 3}},
+		WithBulkInsertBatchSize(2),
+		WithBulkInsertRetryPolicy(retry.NewConstantBackoffPolicy(time.Millisecond, 1)),
+		WithBulkInsertIsRetryable(func(err error) bool { return errors.Is(err, retryableErr) }))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), affected)
+}
+
+func TestBulkInsert_MSSQLParamsLimit(t *testing.T) {
+	dbConn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, mock.ExpectationsWereMet())
+	}()
+
+	anyArgs := func(n int) []driver.Value {
+		args := make([]driver.Value, n)
+		for i := range args {
+			args[i] = sqlmock.AnyArg()
+		}
+		return args
+	}
+	// 3 x 700 parameters along with @stmt and @params of sp_executesql exceed the limit of 2100, so rows are split.
+	mock.ExpectExec(`INSERT INTO \[metrics\]`).WithArgs(anyArgs(699 * 3)...).WillReturnResult(sqlmock.NewResult(0, 699))
+	mock.ExpectExec(`INSERT INTO \[metrics\]`).WithArgs(anyArgs(3)...).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rows := make([][]interface{}, 700)
+	for i := range rows {
+		rows[i] = []interface{}{i, i, i}
+	}
+	affected, err := BulkInsert(context.Background(), dbConn, DialectMSSQL, "metrics", []string{"a", "b", "c"}, rows)
+	require.NoError(t, err)
+	require.Equal(t, int64(700), affected)
+}
+
+func TestBulkInsertBuilder(t *testing.T) {
+	columns := []string{"name", "value"}
+	upsert := bulkInsertOptions{conflictColumns: []string{"name"}, updateColumns: []string{"value"}}
+	ignore := bulkInsertOptions{conflictColumns: []string{"name"}, ignoreConflicts: true}
+	tests := []struct {
+		dialect Dialect
+		opts    bulkInsertOptions
+		want    string
+	}{
+		{
+			dialect: DialectPgx,
+			want:    `INSERT INTO "metrics" (name, value) VALUES ($1, $2), ($3, $4)`,
+		},
+		{
+			dialect: DialectPostgres,
+			opts:    upsert,
+			want:    `INSERT INTO "metrics" (name, value) VALUES ($1, $2), ($3, $4) ON CONFLICT (name) DO UPDATE SET value = EXCLUDED.value`,
+		},
+		{
+			dialect: DialectPostgres,
+			opts:    bulkInsertOptions{ignoreConflicts: true},
+			want:    `INSERT INTO "metrics" (name, value) VALUES ($1, $2), ($3, $4) ON CONFLICT DO NOTHING`,
+		},
+		{
+			dialect: DialectMySQL,
+			opts:    upsert,
+			want:    "INSERT INTO `metrics` (name, value) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE value = VALUES(value)",
+		},
+		{
+			dialect: DialectMySQL,
+			opts:    ignore,
+			want:    "INSERT INTO `metrics` (name, value) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE name = name",
+		},
+		{
+			dialect: DialectSQLite,
+			opts:    ignore,
+			want:    `INSERT INTO "metrics" (name, value) VALUES (?, ?), (?, ?) ON CONFLICT (name) DO NOTHING`,
+		},
+		{
+			dialect: DialectMSSQL,
+			want:    `INSERT INTO [metrics] (name, value) VALUES (@p1, @p2), (@p3, @p4)`,
+		},
+	}
+	for _, tt := range tests {
+		builder, err := newBulkInsertBuilder(tt.dialect, "metrics", columns, tt.opts)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, builder.build(2))
+	}
+
+	builder, err := newBulkInsertBuilder(DialectPostgres, "metrics", columns, bulkInsertOptions{})
+	require.NoError(t, err)
+	require.Equal(t, postgresMaxBindParams/2, builder.maxRows)
+	builder, err = newBulkInsertBuilder(DialectMSSQL, "metrics", columns, bulkInsertOptions{})
+	require.NoError(t, err)
+	require.Equal(t, mssqlMaxInsertRows, builder.maxRows)
+
+	// sp_executesql takes 2 of 2100 parameters allowed by SQL Server, so 3 x 700 rows must be split.
+	builder, err = newBulkInsertBuilder(DialectMSSQL, "metrics", []string{"a", "b", "c"}, bulkInsertOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 699, builder.maxRows)
+	builder, err = newBulkInsertBuilder(DialectMSSQL, "metrics", []string{"a", "b", "c", "d", "e", "f", "g"}, bulkInsertOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 299, builder.maxRows)
+	require.LessOrEqual(t, builder.maxRows*7+2, 2100)
+
+	for dialect, want := range map[Dialect]string{
+		DialectPostgres: `INSERT INTO "public"."user""s" (name, value) VALUES ($1, $2)`,
+		DialectSQLite:   `INSERT INTO "public"."user""s" (name, value) VALUES (?, ?)`,
+		DialectMySQL:    "INSERT INTO `public`.`user\"s` (name, value) VALUES (?, ?)",
+		DialectMSSQL:    `INSERT INTO [public].[user"s] (name, value) VALUES (@p1, @p2)`,
+	} {
+		builder, err = newBulkInsertBuilder(dialect, `public.user"s`, columns, bulkInsertOptions{})
+		require.NoError(t, err)
+		require.Equal(t, want, builder.build(1), dialect)
+	}
+
+	_, err = newBulkInsertBuilder(DialectMSSQL, "metrics", columns, upsert)
+	require.EqualError(t, err, "conflict handling is not supported for mssql dialect")
+	_, err = newBulkInsertBuilder(DialectPostgres, "metrics", columns, bulkInsertOptions{updateColumns: []string{"value"}})
+	require.EqualError(t, err, "conflict columns must be specified for upsert")
+	_, err = newBulkInsertBuilder("oracle", "metrics", columns, bulkInsertOptions{})
+	require.EqualError(t, err, `unsupported dialect "oracle"`)
+}
@@ -0,0 +1,178 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package cfghistory
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// RedactedValue replaces secrets in the redacted configuration.
+const RedactedValue = "[REDACTED]"
+
+// secretParamSubstrings are substrings of names of additional connection parameters that contain secrets.
+var secretParamSubstrings = []string{"password", "passwd", "secret", "token"}
+
+// RedactConfig returns the JSON representation of the configuration with redacted passwords
+// and additional connection parameters which names look like secrets (e.g., "password" or "token").
+func RedactConfig(cfg *dbkit.Config) (string, error) {
+	redacted := *cfg
+	redacted.MySQL.Password = redactValue(redacted.MySQL.Password)
+	redacted.MSSQL.Password = redactValue(redacted.MSSQL.Password)
+	redacted.MSSQL.AzureAD.ClientSecret = redactValue(redacted.MSSQL.AzureAD.ClientSecret)
+	redacted.Postgres.Password = redactValue(redacted.Postgres.Password)
+	redacted.MySQL.AdditionalParameters = redactParams(cfg.MySQL.AdditionalParameters)
+	redacted.MSSQL.AdditionalParameters = redactParams(cfg.MSSQL.AdditionalParameters)
+	redacted.Postgres.AdditionalParameters = redactParams(cfg.Postgres.AdditionalParameters)
+	data, err := json.Marshal(&redacted)
+	if err != nil {
+		return "", fmt.Errorf("marshal config: %w", err)
+	}
+	return string(data), nil
+}
+
+// redactParams returns the copy of additional connection parameters with redacted values of secret ones.
+func redactParams(params map[string]string) map[string]string {
+	if len(params) == 0 {
+		return params
+	}
+	redacted := make(map[string]string, len(params))
+	for name, value := range params {
+		if isSecretParam(name) {
+			value = redactValue(value)
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+func redactValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	return RedactedValue
+}
+
+func isSecretParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretParamSubstrings {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigChange describes the change of a single configuration value.
+// Key is the dot-separated path of the value in the JSON representation of the configuration (e.g., "postgres.host").
+// Old or New is empty if the value is added or removed respectively.
+type ConfigChange struct {
+	Key string
+	Old string
+	New string
+}
+
+// Diff describes what changed between two snapshots.
+type Diff struct {
+	ConfigChanges []ConfigChange
+	// AppliedMigrations are migrations applied after the older snapshot.
+	AppliedMigrations []string
+	// RolledBackMigrations are migrations rolled back after the older snapshot.
+	RolledBackMigrations []string
+}
+
+// Empty reports whether nothing changed.
+func (d Diff) Empty() bool {
+	return len(d.ConfigChanges) == 0 && len(d.AppliedMigrations) == 0 && len(d.RolledBackMigrations) == 0
+}
+
+// Compare returns what changed between the older and the newer snapshots.
+// Configuration changes are ordered by key.
+func Compare(older, newer Snapshot) (Diff, error) {
+	olderValues, err := flattenConfig(older.Config)
+	if err != nil {
+		return Diff{}, fmt.Errorf("flatten config of snapshot %d: %w", older.ID, err)
+	}
+	newerValues, err := flattenConfig(newer.Config)
+	if err != nil {
+		return Diff{}, fmt.Errorf("flatten config of snapshot %d: %w", newer.ID, err)
+	}
+
+	var diff Diff
+	for key, oldValue := range olderValues {
+		if newValue, ok := newerValues[key]; !ok || newValue != oldValue {
+			diff.ConfigChanges = append(diff.ConfigChanges, ConfigChange{Key: key, Old: oldValue, New: newValue})
+		}
+	}
+	for key, newValue := range newerValues {
+		if _, ok := olderValues[key]; !ok {
+			diff.ConfigChanges = append(diff.ConfigChanges, ConfigChange{Key: key, New: newValue})
+		}
+	}
+	sort.Slice(diff.ConfigChanges, func(i, j int) bool {
+		return diff.ConfigChanges[i].Key < diff.ConfigChanges[j].Key
+	})
+
+	diff.AppliedMigrations = subtractStrings(newer.Migrations, older.Migrations)
+	diff.RolledBackMigrations = subtractStrings(older.Migrations, newer.Migrations)
+	return diff, nil
+}
+
+// subtractStrings returns elements of a that are not in b preserving the order.
+func subtractStrings(a, b []string) []string {
+	bSet := make(map[string]struct{}, len(b))
+	for _, s := range b {
+		bSet[s] = struct{}{}
+	}
+	var result []string
+	for _, s := range a {
+		if _, ok := bSet[s]; !ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func flattenConfig(cfgJSON string) (map[string]string, error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(cfgJSON), &value); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	if err := flattenValue("", value, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func flattenValue(key string, value interface{}, values map[string]string) error {
+	if m, ok := value.(map[string]interface{}); ok && len(m) != 0 {
+		for k, v := range m {
+			if key != "" {
+				k = key + "." + k
+			}
+			if err := flattenValue(k, v, values); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if s, ok := value.(string); ok {
+		values[key] = s
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	values[key] = string(data)
+	return nil
+}
@@ -0,0 +1,11 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package cfghistory records snapshots of the effective database configuration (dbkit.Config with redacted secrets)
+// and the set of applied migrations into a history table, usually on each service startup (see History.RecordStartup).
+// The history may be queried during incident reviews to find out what changed between two points in time
+// (see History.LatestAt and Compare).
+package cfghistory
@@ -0,0 +1,313 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package cfghistory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+// DefaultTableName is a default name for the table that stores configuration snapshots.
+const DefaultTableName = "config_history"
+
+const maxServiceLen = 255
+
+// Snapshot is the effective configuration and the set of applied migrations recorded at some point in time.
+type Snapshot struct {
+	ID      int64
+	Service string
+	// Host is the hostname of the service instance that recorded the snapshot.
+	Host string
+	// Config is the JSON representation of dbkit.Config with redacted secrets (see RedactConfig).
+	Config string
+	// Migrations are IDs of applied migrations in the order of applying.
+	Migrations []string
+	RecordedAt time.Time
+}
+
+// NewSnapshot makes a snapshot of the effective configuration (secrets are redacted, see RedactConfig)
+// and the applied migrations (see migrate.MigrationsManager.Status) of the service.
+func NewSnapshot(service string, cfg *dbkit.Config, migStatus migrate.MigrationStatus) (Snapshot, error) {
+	redactedCfg, err := RedactConfig(cfg)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	migrations := make([]string, 0, len(migStatus.AppliedMigrations))
+	for _, mig := range migStatus.AppliedMigrations {
+		migrations = append(migrations, mig.ID)
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return Snapshot{Service: service, Host: host, Config: redactedCfg, Migrations: migrations}, nil
+}
+
+// History manages configuration snapshots stored in the database table.
+type History struct {
+	queries dbQueries
+	clock   dbkit.Clock
+}
+
+// Option is an option for NewHistory.
+type Option func(*historyOptions)
+
+type historyOptions struct {
+	tableName string
+	clock     dbkit.Clock
+}
+
+// WithTableName sets a custom table name for the table that stores configuration snapshots.
+func WithTableName(tableName string) Option {
+	return func(o *historyOptions) {
+		o.tableName = tableName
+	}
+}
+
+// WithClock sets the clock that is used for calculating the time of recorded snapshots (dbkit.RealClock by default). It's intended for tests.
+func WithClock(clock dbkit.Clock) Option {
+	return func(o *historyOptions) {
+		o.clock = clock
+	}
+}
+
+// NewHistory creates a new History.
+func NewHistory(dialect dbkit.Dialect, options ...Option) (*History, error) {
+	opts := historyOptions{clock: dbkit.RealClock{}}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.tableName == "" {
+		opts.tableName = DefaultTableName
+	}
+	q, err := newDBQueries(dialect, opts.tableName)
+	if err != nil {
+		return nil, err
+	}
+	return &History{queries: q, clock: opts.clock}, nil
+}
+
+// Migrations returns set of migrations that must be applied before using the history.
+func (h *History) Migrations() []migrate.Migration {
+	return []migrate.Migration{
+		migrate.NewCustomMigration(createTableMigrationID,
+			[]string{h.queries.createTable, h.queries.createIndex}, []string{h.queries.dropTable}, nil, nil),
+	}
+}
+
+// CreateTableSQL returns SQL query for creating a table that stores configuration snapshots.
+func (h *History) CreateTableSQL() string {
+	return h.queries.createTable
+}
+
+// CreateIndexSQL returns SQL query for creating an index for querying snapshots of the service by time.
+func (h *History) CreateIndexSQL() string {
+	return h.queries.createIndex
+}
+
+// DropTableSQL returns SQL query for dropping a table that stores configuration snapshots.
+func (h *History) DropTableSQL() string {
+	return h.queries.dropTable
+}
+
+// RecordStartup records the snapshot of the effective configuration and the migrations applied by migMngr.
+// It's intended to be called on each service startup after migrations are applied.
+func (h *History) RecordStartup(
+	ctx context.Context, dbConn *sql.DB, service string, cfg *dbkit.Config, migMngr *migrate.MigrationsManager,
+) error {
+	migStatus, err := migMngr.Status()
+	if err != nil {
+		return err
+	}
+	snapshot, err := NewSnapshot(service, cfg, migStatus)
+	if err != nil {
+		return err
+	}
+	return h.Record(ctx, dbConn, snapshot)
+}
+
+// Record stores the snapshot. RecordedAt is set to the current time if it's zero.
+func (h *History) Record(ctx context.Context, executor SQLExecutor, snapshot Snapshot) error {
+	if snapshot.Service == "" {
+		return fmt.Errorf("service cannot be empty")
+	}
+	if len(snapshot.Service) > maxServiceLen || len(snapshot.Host) > maxServiceLen {
+		return fmt.Errorf("service and host cannot be longer than %d symbols", maxServiceLen)
+	}
+	migrations, err := json.Marshal(snapshot.Migrations)
+	if err != nil {
+		return fmt.Errorf("marshal migrations: %w", err)
+	}
+	recordedAt := snapshot.RecordedAt
+	if recordedAt.IsZero() {
+		recordedAt = h.clock.Now()
+	}
+	if _, err = executor.ExecContext(ctx, h.queries.insert, snapshot.Service, snapshot.Host, snapshot.Config,
+		string(migrations), recordedAt.UnixMilli()); err != nil {
+		return fmt.Errorf("record config snapshot of service %s: %w", snapshot.Service, err)
+	}
+	return nil
+}
+
+// List returns snapshots of the service recorded in the [since, until) time range ordered by recording time.
+func (h *History) List(
+	ctx context.Context, executor SQLQueryExecutor, service string, since, until time.Time,
+) ([]Snapshot, error) {
+	rows, err := executor.QueryContext(ctx, h.queries.list, service, since.UnixMilli(), until.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("list config snapshots: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var snapshots []Snapshot
+	for rows.Next() {
+		snapshot, scanErr := scanSnapshot(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list config snapshots: %w", err)
+	}
+	return snapshots, nil
+}
+
+// LatestAt returns the latest snapshot of the service recorded not later than the given time,
+// i.e., the configuration that was effective at that time. False is returned if there is no such snapshot.
+func (h *History) LatestAt(
+	ctx context.Context, executor SQLQueryRowExecutor, service string, at time.Time,
+) (Snapshot, bool, error) {
+	snapshot, err := scanSnapshot(executor.QueryRowContext(ctx, h.queries.latestAt, service, at.UnixMilli()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Snapshot{}, false, nil
+		}
+		return Snapshot{}, false, err
+	}
+	return snapshot, true, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSnapshot(row rowScanner) (Snapshot, error) {
+	var snapshot Snapshot
+	var migrations string
+	var recordedAt int64
+	if err := row.Scan(&snapshot.ID, &snapshot.Service, &snapshot.Host, &snapshot.Config,
+		&migrations, &recordedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Snapshot{}, err
+		}
+		return Snapshot{}, fmt.Errorf("scan config snapshot: %w", err)
+	}
+	if err := json.Unmarshal([]byte(migrations), &snapshot.Migrations); err != nil {
+		return Snapshot{}, fmt.Errorf("unmarshal migrations of config snapshot %d: %w", snapshot.ID, err)
+	}
+	snapshot.RecordedAt = time.UnixMilli(recordedAt)
+	return snapshot, nil
+}
+
+// SQLExecutor is an interface for executing SQL queries (e.g., *sql.DB or *sql.Tx).
+type SQLExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// SQLQueryExecutor is an interface for executing SQL queries that return rows (e.g., *sql.DB or *sql.Tx).
+type SQLQueryExecutor interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// SQLQueryRowExecutor is an interface for executing SQL queries that return a single row (e.g., *sql.DB or *sql.Tx).
+type SQLQueryRowExecutor interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type dbQueries struct {
+	createTable string
+	createIndex string
+	dropTable   string
+	insert      string
+	list        string
+	latestAt    string
+}
+
+func newDBQueries(dialect dbkit.Dialect, tableName string) (dbQueries, error) {
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		return dbQueries{
+			createTable: fmt.Sprintf(postgresCreateTableQuery, tableName),
+			createIndex: fmt.Sprintf(postgresCreateIndexQuery, tableName),
+			dropTable:   fmt.Sprintf(postgresDropTableQuery, tableName),
+			insert:      fmt.Sprintf(postgresInsertQuery, tableName),
+			list:        fmt.Sprintf(postgresListQuery, tableName),
+			latestAt:    fmt.Sprintf(postgresLatestAtQuery, tableName),
+		}, nil
+	case dbkit.DialectMySQL:
+		return dbQueries{
+			createTable: fmt.Sprintf(mySQLCreateTableQuery, tableName),
+			createIndex: fmt.Sprintf(mySQLCreateIndexQuery, tableName),
+			dropTable:   fmt.Sprintf(mySQLDropTableQuery, tableName),
+			insert:      fmt.Sprintf(mySQLInsertQuery, tableName),
+			list:        fmt.Sprintf(mySQLListQuery, tableName),
+			latestAt:    fmt.Sprintf(mySQLLatestAtQuery, tableName),
+		}, nil
+	case dbkit.DialectSQLite:
+		return dbQueries{
+			createTable: fmt.Sprintf(sqliteCreateTableQuery, tableName),
+			createIndex: fmt.Sprintf(sqliteCreateIndexQuery, tableName),
+			dropTable:   fmt.Sprintf(sqliteDropTableQuery, tableName),
+			insert:      fmt.Sprintf(sqliteInsertQuery, tableName),
+			list:        fmt.Sprintf(sqliteListQuery, tableName),
+			latestAt:    fmt.Sprintf(sqliteLatestAtQuery, tableName),
+		}, nil
+	default:
+		return dbQueries{}, fmt.Errorf("unsupported sql dialect %q", dialect)
+	}
+}
+
+const createTableMigrationID = "cfghistory_00001_create_table"
+
+//nolint:lll
+const (
+	postgresCreateTableQuery = `CREATE TABLE IF NOT EXISTS "%s" (id BIGSERIAL PRIMARY KEY, service varchar(255) NOT NULL, host varchar(255) NOT NULL, config text NOT NULL, migrations text NOT NULL, recorded_at bigint NOT NULL);`
+	postgresCreateIndexQuery = `CREATE INDEX IF NOT EXISTS "%[1]s_service_recorded_at_idx" ON "%[1]s" (service, recorded_at);`
+	postgresDropTableQuery   = `DROP TABLE IF EXISTS "%s";`
+	postgresInsertQuery      = `INSERT INTO "%s" (service, host, config, migrations, recorded_at) VALUES ($1, $2, $3, $4, $5);`
+	postgresListQuery        = `SELECT id, service, host, config, migrations, recorded_at FROM "%s" WHERE service = $1 AND recorded_at >= $2 AND recorded_at < $3 ORDER BY recorded_at, id;`
+	postgresLatestAtQuery    = `SELECT id, service, host, config, migrations, recorded_at FROM "%s" WHERE service = $1 AND recorded_at <= $2 ORDER BY recorded_at DESC, id DESC LIMIT 1;`
+)
+
+//nolint:lll
+const (
+	mySQLCreateTableQuery = "CREATE TABLE IF NOT EXISTS `%s` (id BIGINT AUTO_INCREMENT PRIMARY KEY, service VARCHAR(255) NOT NULL, host VARCHAR(255) NOT NULL, config TEXT NOT NULL, migrations TEXT NOT NULL, recorded_at BIGINT NOT NULL);"
+	mySQLCreateIndexQuery = "CREATE INDEX `%[1]s_service_recorded_at_idx` ON `%[1]s` (service, recorded_at);"
+	mySQLDropTableQuery   = "DROP TABLE IF EXISTS `%s`;"
+	mySQLInsertQuery      = "INSERT INTO `%s` (service, host, config, migrations, recorded_at) VALUES (?, ?, ?, ?, ?);"
+	mySQLListQuery        = "SELECT id, service, host, config, migrations, recorded_at FROM `%s` WHERE service = ? AND recorded_at >= ? AND recorded_at < ? ORDER BY recorded_at, id;"
+	mySQLLatestAtQuery    = "SELECT id, service, host, config, migrations, recorded_at FROM `%s` WHERE service = ? AND recorded_at <= ? ORDER BY recorded_at DESC, id DESC LIMIT 1;"
+)
+
+//nolint:lll
+const (
+	sqliteCreateTableQuery = `CREATE TABLE IF NOT EXISTS "%s" (id INTEGER PRIMARY KEY AUTOINCREMENT, service TEXT NOT NULL, host TEXT NOT NULL, config TEXT NOT NULL, migrations TEXT NOT NULL, recorded_at INTEGER NOT NULL);`
+	sqliteCreateIndexQuery = `CREATE INDEX IF NOT EXISTS "%[1]s_service_recorded_at_idx" ON "%[1]s" (service, recorded_at);`
+	sqliteDropTableQuery   = `DROP TABLE IF EXISTS "%s";`
+	sqliteInsertQuery      = `INSERT INTO "%s" (service, host, config, migrations, recorded_at) VALUES (?, ?, ?, ?, ?);`
+	sqliteListQuery        = `SELECT id, service, host, config, migrations, recorded_at FROM "%s" WHERE service = ? AND recorded_at >= ? AND recorded_at < ? ORDER BY recorded_at, id;`
+	sqliteLatestAtQuery    = `SELECT id, service, host, config, migrations, recorded_at FROM "%s" WHERE service = ? AND recorded_at <= ? ORDER BY recorded_at DESC, id DESC LIMIT 1;`
+)
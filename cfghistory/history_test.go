@@ -0,0 +1,185 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package cfghistory
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/config"
+	"github.com/acronis/go-appkit/log/logtest"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+func newTestHistory(t *testing.T) (*History, *sql.DB) {
+	t.Helper()
+	history, err := NewHistory(dbkit.DialectSQLite)
+	require.NoError(t, err)
+	dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "history.db")+"?_journal=MEMORY&_sync=OFF&_busy_timeout=5000")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	_, err = dbConn.Exec(history.CreateTableSQL())
+	require.NoError(t, err)
+	_, err = dbConn.Exec(history.CreateIndexSQL())
+	require.NoError(t, err)
+	return history, dbConn
+}
+
+func newTestConfig() *dbkit.Config {
+	cfg := dbkit.NewDefaultConfig([]dbkit.Dialect{dbkit.DialectPostgres})
+	cfg.Dialect = dbkit.DialectPostgres
+	cfg.Postgres.Host = "pg-primary"
+	cfg.Postgres.Port = 5432
+	cfg.Postgres.User = "app"
+	cfg.Postgres.Password = "secret-password"
+	cfg.Postgres.Database = "app"
+	cfg.Postgres.AdditionalParameters = map[string]string{"application_name": "app", "auth_token": "secret-token"}
+	return cfg
+}
+
+func TestNewHistory(t *testing.T) {
+	_, err := NewHistory(dbkit.DialectPostgres)
+	require.NoError(t, err)
+	history, err := NewHistory(dbkit.DialectMySQL, WithTableName("db_config_history"))
+	require.NoError(t, err)
+	require.Contains(t, history.CreateTableSQL(), "`db_config_history`")
+	require.Len(t, history.Migrations(), 1)
+
+	_, err = NewHistory(dbkit.DialectMSSQL)
+	require.Error(t, err)
+}
+
+func TestRedactConfig(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.MSSQL.AzureAD.ClientSecret = "secret-client"
+	redacted, err := RedactConfig(cfg)
+	require.NoError(t, err)
+	require.NotContains(t, redacted, "secret-password")
+	require.NotContains(t, redacted, "secret-client")
+	require.NotContains(t, redacted, "secret-token")
+	require.Contains(t, redacted, `"host":"pg-primary"`)
+	require.Contains(t, redacted, `"application_name":"app"`)
+	require.Equal(t, "secret-password", cfg.Postgres.Password, "original config must not be changed")
+	require.Equal(t, "secret-token", cfg.Postgres.AdditionalParameters["auth_token"])
+}
+
+func TestRedactConfig_AdditionalParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dbkit.Dialect
+		setup   func(cfg *dbkit.Config, params map[string]string)
+	}{
+		{name: "mysql", dialect: dbkit.DialectMySQL, setup: func(cfg *dbkit.Config, params map[string]string) {
+			cfg.MySQL.AdditionalParameters = params
+		}},
+		{name: "mssql", dialect: dbkit.DialectMSSQL, setup: func(cfg *dbkit.Config, params map[string]string) {
+			cfg.MSSQL.AdditionalParameters = params
+		}},
+		{name: "postgres", dialect: dbkit.DialectPostgres, setup: func(cfg *dbkit.Config, params map[string]string) {
+			cfg.Postgres.AdditionalParameters = params
+		}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &dbkit.Config{Dialect: tt.dialect}
+			tt.setup(cfg, map[string]string{"ServerPassword": "secret-password", "access_token": "secret-token", "charset": "utf8mb4"})
+			redacted, err := RedactConfig(cfg)
+			require.NoError(t, err)
+			require.NotContains(t, redacted, "secret-password")
+			require.NotContains(t, redacted, "secret-token")
+			require.Contains(t, redacted, `"charset":"utf8mb4"`)
+			require.Contains(t, redacted, `"ServerPassword":"`+RedactedValue+`"`)
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	history, dbConn := newTestHistory(t)
+	yesterday := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	today := yesterday.Add(24 * time.Hour)
+
+	_, found, err := history.LatestAt(ctx, dbConn, "app", today)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	cfg := newTestConfig()
+	snapshot, err := NewSnapshot("app", cfg, migrate.MigrationStatus{AppliedMigrations: []migrate.AppliedMigration{
+		{ID: "0001_create_users"}, {ID: "0002_add_email"},
+	}})
+	require.NoError(t, err)
+	snapshot.RecordedAt = yesterday
+	require.NoError(t, history.Record(ctx, dbConn, snapshot))
+
+	cfg.Postgres.Host = "pg-new-primary"
+	cfg.MaxOpenConns = 50
+	cfg.ConnMaxLifetime = config.TimeDuration(time.Minute)
+	snapshot, err = NewSnapshot("app", cfg, migrate.MigrationStatus{AppliedMigrations: []migrate.AppliedMigration{
+		{ID: "0001_create_users"}, {ID: "0003_add_orders"},
+	}})
+	require.NoError(t, err)
+	snapshot.RecordedAt = today
+	require.NoError(t, history.Record(ctx, dbConn, snapshot))
+	require.NoError(t, history.Record(ctx, dbConn, Snapshot{Service: "other", Config: "{}", RecordedAt: today}))
+
+	snapshots, err := history.List(ctx, dbConn, "app", yesterday, today.Add(time.Second))
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	require.Equal(t, yesterday.UnixMilli(), snapshots[0].RecordedAt.UnixMilli())
+	require.Equal(t, []string{"0001_create_users", "0002_add_email"}, snapshots[0].Migrations)
+	require.NotEmpty(t, snapshots[0].Host)
+
+	older, found, err := history.LatestAt(ctx, dbConn, "app", today.Add(-time.Hour))
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, snapshots[0].ID, older.ID)
+	newer, found, err := history.LatestAt(ctx, dbConn, "app", today)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, snapshots[1].ID, newer.ID)
+
+	diff, err := Compare(older, newer)
+	require.NoError(t, err)
+	require.Equal(t, []ConfigChange{
+		{Key: "connMaxLifeTime", Old: "10m0s", New: "1m0s"},
+		{Key: "maxOpenConns", Old: "10", New: "50"},
+		{Key: "postgres.host", Old: "pg-primary", New: "pg-new-primary"},
+	}, diff.ConfigChanges)
+	require.Equal(t, []string{"0003_add_orders"}, diff.AppliedMigrations)
+	require.Equal(t, []string{"0002_add_email"}, diff.RolledBackMigrations)
+	require.False(t, diff.Empty())
+
+	diff, err = Compare(newer, newer)
+	require.NoError(t, err)
+	require.True(t, diff.Empty())
+}
+
+func TestHistory_RecordStartup(t *testing.T) {
+	ctx := context.Background()
+	history, dbConn := newTestHistory(t)
+
+	migMngr, err := migrate.NewMigrationsManager(dbConn, dbkit.DialectSQLite, logtest.NewLogger())
+	require.NoError(t, err)
+	require.NoError(t, migMngr.Run([]migrate.Migration{
+		migrate.NewCustomMigration("0001_create_users", []string{"CREATE TABLE users (id INTEGER)"}, nil, nil, nil),
+	}, migrate.MigrationsDirectionUp))
+
+	require.NoError(t, history.RecordStartup(ctx, dbConn, "app", newTestConfig(), migMngr))
+	snapshot, found, err := history.LatestAt(ctx, dbConn, "app", time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []string{"0001_create_users"}, snapshot.Migrations)
+	require.NotContains(t, snapshot.Config, "secret-password")
+}
@@ -0,0 +1,13 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package changepoll provides a poller that repeatedly reads rows changed since the last seen watermark
+// (a monotonically growing sequence number or an updated_at timestamp in milliseconds) and passes them to a handler.
+// The watermark is persisted in a small table (see WatermarkStore), so polling is resumed after restarts.
+// Rows committed with a watermark lower than already seen ones (e.g., because of clock skew between writers
+// or long transactions) are picked up by re-reading the overlap window below the watermark (see WithOverlap).
+// It's a common building block for ETL and data synchronization between services.
+package changepoll
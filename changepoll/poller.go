@@ -0,0 +1,323 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package changepoll
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// Default values for the Poller options.
+const (
+	DefaultBatchSize    = 100
+	DefaultPollInterval = 5 * time.Second
+)
+
+// Config describes the polled table.
+type Config struct {
+	// Name identifies the poller in the WatermarkStore.
+	Name string
+	// Table is the name of the polled table.
+	Table string
+	// WatermarkColumn is the integer column that grows on each change of the row
+	// (e.g., a sequence number or an updated_at timestamp stored as unix time in milliseconds).
+	WatermarkColumn string
+	// KeyColumn is the column that uniquely identifies the row (usually, the primary key).
+	// It orders rows with the same watermark, so the batch boundary may fall between them.
+	KeyColumn string
+	// Columns are additional columns that are read and passed to the handler in Change.Values.
+	Columns []string
+}
+
+// Change is a changed row read by the Poller.
+type Change struct {
+	// Watermark is the value of the watermark column of the row.
+	Watermark int64
+	// Key is the value of the key column of the row.
+	Key string
+	// Values are values of Config.Columns in the same order.
+	Values []interface{}
+}
+
+// Handler handles a batch of changes ordered by watermark and key.
+// If it returns an error, the watermark is not advanced, and the batch is read again on the next poll,
+// so changes are delivered at least once and the handler should be idempotent.
+type Handler func(ctx context.Context, changes []Change) error
+
+// Logger is an interface for logging errors.
+type Logger interface {
+	Errorf(format string, args ...interface{})
+}
+
+type pollerOptions struct {
+	batchSize    int
+	pollInterval time.Duration
+	overlap      int64
+	clock        dbkit.Clock
+	logger       Logger
+}
+
+// PollerOption is a functional option for NewPoller.
+type PollerOption func(*pollerOptions)
+
+// WithBatchSize sets the maximum number of changes passed to the handler at once. By default, DefaultBatchSize is used.
+func WithBatchSize(batchSize int) PollerOption {
+	return func(opts *pollerOptions) {
+		opts.batchSize = batchSize
+	}
+}
+
+// WithPollInterval sets the interval between polls when there are no more changes.
+// By default, DefaultPollInterval is used.
+func WithPollInterval(interval time.Duration) PollerOption {
+	return func(opts *pollerOptions) {
+		opts.pollInterval = interval
+	}
+}
+
+// WithOverlap sets the window (in units of the watermark column) below the watermark that is read again on each poll.
+// It allows picking up rows that become visible with a watermark lower than already seen ones,
+// because of clock skew between writers or transactions committed late
+// (e.g., 5000 for a 5 seconds window if the watermark is a timestamp in milliseconds).
+// Rows from the window that were already handled by the poller are skipped unless their watermark is changed,
+// but they may be delivered again after a restart. No overlap is used by default.
+func WithOverlap(overlap int64) PollerOption {
+	return func(opts *pollerOptions) {
+		opts.overlap = overlap
+	}
+}
+
+// WithClock sets the clock that is used for waiting between polls (dbkit.RealClock by default). It's intended for tests.
+func WithClock(clock dbkit.Clock) PollerOption {
+	return func(opts *pollerOptions) {
+		opts.clock = clock
+	}
+}
+
+// WithLogger sets the logger for errors that occur during polling in Run.
+func WithLogger(logger Logger) PollerOption {
+	return func(opts *pollerOptions) {
+		opts.logger = logger
+	}
+}
+
+// Poller repeatedly reads rows changed since the watermark and passes them to the handler.
+// Only one poller with the same name should run at a time (e.g., use distrlock or lease.Partitioner to ensure it).
+type Poller struct {
+	dbConn  *sql.DB
+	store   *WatermarkStore
+	cfg     Config
+	handler Handler
+	opts    pollerOptions
+	queries pollerQueries
+
+	watermark       int64
+	watermarkLoaded bool
+	// seen contains keys and watermarks of handled rows from the overlap window.
+	seen map[string]int64
+}
+
+// NewPoller creates a new Poller.
+func NewPoller(
+	dbConn *sql.DB, dialect dbkit.Dialect, store *WatermarkStore, cfg Config, handler Handler, options ...PollerOption,
+) (*Poller, error) {
+	if cfg.Name == "" || cfg.Table == "" || cfg.WatermarkColumn == "" || cfg.KeyColumn == "" {
+		return nil, errors.New("name, table, watermark column and key column must be specified")
+	}
+	opts := pollerOptions{
+		batchSize: DefaultBatchSize, pollInterval: DefaultPollInterval, clock: dbkit.RealClock{}, logger: disabledLogger{},
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.batchSize <= 0 {
+		return nil, fmt.Errorf("batch size must be positive, got %d", opts.batchSize)
+	}
+	if opts.overlap < 0 {
+		return nil, fmt.Errorf("overlap cannot be negative, got %d", opts.overlap)
+	}
+	q, err := newPollerQueries(dialect, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Poller{
+		dbConn: dbConn, store: store, cfg: cfg, handler: handler, opts: opts, queries: q, seen: make(map[string]int64),
+	}, nil
+}
+
+// Watermark returns the current watermark of the poller.
+func (p *Poller) Watermark() int64 {
+	return p.watermark
+}
+
+// Run polls changes until ctx is done, waiting for the poll interval when all changes are handled.
+// Errors are logged, and polling is retried after the interval. Run returns ctx.Err().
+func (p *Poller) Run(ctx context.Context) error {
+	for {
+		if _, err := p.PollOnce(ctx); err != nil && ctx.Err() == nil {
+			p.opts.logger.Errorf("failed to poll changes of %s: %v", p.cfg.Name, err)
+		}
+		timer := p.opts.clock.NewTimer(p.opts.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C():
+		}
+	}
+}
+
+// PollOnce reads all changes since the watermark batch by batch, passes them to the handler,
+// and saves the advanced watermark after each batch. It returns the number of handled changes.
+// PollOnce must not be called concurrently.
+func (p *Poller) PollOnce(ctx context.Context) (int, error) {
+	if !p.watermarkLoaded {
+		watermark, err := p.store.Load(ctx, p.dbConn, p.cfg.Name)
+		if err != nil {
+			return 0, err
+		}
+		p.watermark, p.watermarkLoaded = watermark, true
+	}
+
+	handled := 0
+	lowerBound := p.watermark - p.opts.overlap
+	var last *Change
+	for {
+		changes, err := p.readBatch(ctx, lowerBound, last)
+		if err != nil {
+			return handled, err
+		}
+		if len(changes) == 0 {
+			break
+		}
+		fresh := changes
+		if p.opts.overlap > 0 {
+			fresh = make([]Change, 0, len(changes))
+			for _, change := range changes {
+				if watermark, ok := p.seen[change.Key]; !ok || watermark != change.Watermark {
+					fresh = append(fresh, change)
+				}
+			}
+		}
+		if len(fresh) > 0 {
+			if err = p.handler(ctx, fresh); err != nil {
+				return handled, fmt.Errorf("handle changes of %s: %w", p.cfg.Name, err)
+			}
+			handled += len(fresh)
+		}
+		if err = p.advance(ctx, changes); err != nil {
+			return handled, err
+		}
+		if len(changes) < p.opts.batchSize {
+			break
+		}
+		last = &changes[len(changes)-1]
+	}
+
+	// Rows below the overlap window are not read anymore.
+	for key, watermark := range p.seen {
+		if watermark <= p.watermark-p.opts.overlap {
+			delete(p.seen, key)
+		}
+	}
+	return handled, nil
+}
+
+func (p *Poller) advance(ctx context.Context, changes []Change) error {
+	watermark := p.watermark
+	for _, change := range changes {
+		if p.opts.overlap > 0 {
+			p.seen[change.Key] = change.Watermark
+		}
+		if change.Watermark > watermark {
+			watermark = change.Watermark
+		}
+	}
+	if watermark == p.watermark {
+		return nil
+	}
+	if err := p.store.Save(ctx, p.dbConn, p.cfg.Name, watermark); err != nil {
+		return err
+	}
+	p.watermark = watermark
+	return nil
+}
+
+func (p *Poller) readBatch(ctx context.Context, lowerBound int64, last *Change) ([]Change, error) {
+	var rows *sql.Rows
+	var err error
+	if last == nil {
+		rows, err = p.dbConn.QueryContext(ctx, p.queries.first, lowerBound, p.opts.batchSize)
+	} else {
+		rows, err = p.dbConn.QueryContext(ctx, p.queries.next, last.Watermark, last.Watermark, last.Key, p.opts.batchSize)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read changes of %s: %w", p.cfg.Name, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	changes := make([]Change, 0, p.opts.batchSize)
+	for rows.Next() {
+		change := Change{Values: make([]interface{}, len(p.cfg.Columns))}
+		dest := make([]interface{}, 0, len(p.cfg.Columns)+2)
+		dest = append(dest, &change.Watermark, &change.Key)
+		for i := range change.Values {
+			dest = append(dest, &change.Values[i])
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("scan change of %s: %w", p.cfg.Name, err)
+		}
+		changes = append(changes, change)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("read changes of %s: %w", p.cfg.Name, err)
+	}
+	return changes, nil
+}
+
+type pollerQueries struct {
+	first string
+	next  string
+}
+
+func newPollerQueries(dialect dbkit.Dialect, cfg Config) (pollerQueries, error) {
+	var table string
+	var placeholder func(i int) string
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		table = `"` + cfg.Table + `"`
+		placeholder = func(i int) string { return "$" + strconv.Itoa(i) }
+	case dbkit.DialectMySQL:
+		table = "`" + cfg.Table + "`"
+		placeholder = func(int) string { return "?" }
+	case dbkit.DialectSQLite:
+		table = `"` + cfg.Table + `"`
+		placeholder = func(int) string { return "?" }
+	default:
+		return pollerQueries{}, fmt.Errorf("unsupported sql dialect %q", dialect)
+	}
+	columns := strings.Join(append([]string{cfg.WatermarkColumn, cfg.KeyColumn}, cfg.Columns...), ", ")
+	orderBy := cfg.WatermarkColumn + ", " + cfg.KeyColumn
+	wm, key := cfg.WatermarkColumn, cfg.KeyColumn
+	return pollerQueries{
+		first: fmt.Sprintf("SELECT %s FROM %s WHERE %s > %s ORDER BY %s LIMIT %s",
+			columns, table, wm, placeholder(1), orderBy, placeholder(2)),
+		next: fmt.Sprintf("SELECT %s FROM %s WHERE %s > %s OR (%s = %s AND %s > %s) ORDER BY %s LIMIT %s",
+			columns, table, wm, placeholder(1), wm, placeholder(2), key, placeholder(3), orderBy, placeholder(4)),
+	}, nil
+}
+
+type disabledLogger struct{}
+
+func (disabledLogger) Errorf(format string, args ...interface{}) {}
@@ -0,0 +1,214 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package changepoll
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/testkit"
+)
+
+type changesRecorder struct {
+	changes []Change
+	err     error
+}
+
+func (r *changesRecorder) handle(_ context.Context, changes []Change) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.changes = append(r.changes, changes...)
+	return nil
+}
+
+func (r *changesRecorder) popKeys() []string {
+	keys := make([]string, 0, len(r.changes))
+	for _, change := range r.changes {
+		keys = append(keys, change.Key)
+	}
+	r.changes = nil
+	return keys
+}
+
+func newTestDB(t *testing.T) (*sql.DB, *WatermarkStore) {
+	t.Helper()
+	store, err := NewWatermarkStore(dbkit.DialectSQLite)
+	require.NoError(t, err)
+	dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "changepoll.db")+"?_journal=MEMORY&_sync=OFF&_busy_timeout=5000")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	_, err = dbConn.Exec(store.CreateTableSQL())
+	require.NoError(t, err)
+	_, err = dbConn.Exec(`CREATE TABLE "items" (id INTEGER PRIMARY KEY, name TEXT NOT NULL, updated_at INTEGER NOT NULL)`)
+	require.NoError(t, err)
+	return dbConn, store
+}
+
+func upsertItem(t *testing.T, dbConn *sql.DB, id int, name string, updatedAt int64) {
+	t.Helper()
+	_, err := dbConn.Exec(`INSERT INTO "items" (id, name, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name, updated_at = excluded.updated_at`, id, name, updatedAt)
+	require.NoError(t, err)
+}
+
+var testConfig = Config{
+	Name: "items-sync", Table: "items", WatermarkColumn: "updated_at", KeyColumn: "id", Columns: []string{"name"},
+}
+
+func TestNewPoller(t *testing.T) {
+	store, err := NewWatermarkStore(dbkit.DialectPostgres)
+	require.NoError(t, err)
+	handler := (&changesRecorder{}).handle
+
+	p, err := NewPoller(nil, dbkit.DialectPostgres, store, testConfig, handler)
+	require.NoError(t, err)
+	require.Equal(t, `SELECT updated_at, id, name FROM "items" WHERE updated_at > $1 ORDER BY updated_at, id LIMIT $2`,
+		p.queries.first)
+	require.Equal(t, `SELECT updated_at, id, name FROM "items" WHERE updated_at > $1 OR (updated_at = $2 AND id > $3) `+
+		`ORDER BY updated_at, id LIMIT $4`, p.queries.next)
+
+	_, err = NewPoller(nil, dbkit.DialectMSSQL, store, testConfig, handler)
+	require.Error(t, err)
+	_, err = NewPoller(nil, dbkit.DialectPostgres, store, Config{Name: "items-sync", Table: "items"}, handler)
+	require.Error(t, err)
+	_, err = NewPoller(nil, dbkit.DialectPostgres, store, testConfig, handler, WithBatchSize(0))
+	require.Error(t, err)
+}
+
+func TestWatermarkStore(t *testing.T) {
+	ctx := context.Background()
+	dbConn, store := newTestDB(t)
+
+	watermark, err := store.Load(ctx, dbConn, "items-sync")
+	require.NoError(t, err)
+	require.Zero(t, watermark)
+
+	require.NoError(t, store.Save(ctx, dbConn, "items-sync", 10))
+	require.NoError(t, store.Save(ctx, dbConn, "items-sync", 20))
+	require.Error(t, store.Save(ctx, dbConn, "", 20))
+	watermark, err = store.Load(ctx, dbConn, "items-sync")
+	require.NoError(t, err)
+	require.Equal(t, int64(20), watermark)
+}
+
+func TestPoller_PollOnce(t *testing.T) {
+	ctx := context.Background()
+	dbConn, store := newTestDB(t)
+	recorder := &changesRecorder{}
+	newPoller := func() *Poller {
+		p, err := NewPoller(dbConn, dbkit.DialectSQLite, store, testConfig, recorder.handle, WithBatchSize(2))
+		require.NoError(t, err)
+		return p
+	}
+	p := newPoller()
+
+	// Rows with the same watermark are split between batches.
+	upsertItem(t, dbConn, 1, "a", 100)
+	upsertItem(t, dbConn, 2, "b", 200)
+	upsertItem(t, dbConn, 3, "c", 200)
+	upsertItem(t, dbConn, 4, "d", 200)
+	handled, err := p.PollOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 4, handled)
+	require.Equal(t, Change{Watermark: 100, Key: "1", Values: []interface{}{"a"}}, recorder.changes[0])
+	require.Equal(t, []string{"1", "2", "3", "4"}, recorder.popKeys())
+	require.Equal(t, int64(200), p.Watermark())
+
+	handled, err = p.PollOnce(ctx)
+	require.NoError(t, err)
+	require.Zero(t, handled)
+
+	// Handler error doesn't advance the watermark, changes are read again.
+	upsertItem(t, dbConn, 1, "a2", 300)
+	recorder.err = errors.New("handler failed")
+	_, err = p.PollOnce(ctx)
+	require.ErrorIs(t, err, recorder.err)
+	require.Equal(t, int64(200), p.Watermark())
+	recorder.err = nil
+	_, err = p.PollOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1"}, recorder.popKeys())
+
+	// Watermark is loaded from the store after restart.
+	upsertItem(t, dbConn, 5, "e", 400)
+	p = newPoller()
+	_, err = p.PollOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"5"}, recorder.popKeys())
+	require.Equal(t, int64(400), p.Watermark())
+}
+
+func TestPoller_Overlap(t *testing.T) {
+	ctx := context.Background()
+	dbConn, store := newTestDB(t)
+	recorder := &changesRecorder{}
+	p, err := NewPoller(dbConn, dbkit.DialectSQLite, store, testConfig, recorder.handle, WithOverlap(50), WithBatchSize(2))
+	require.NoError(t, err)
+
+	upsertItem(t, dbConn, 1, "a", 100)
+	upsertItem(t, dbConn, 2, "b", 120)
+	_, err = p.PollOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", "2"}, recorder.popKeys())
+
+	// Row committed late by a writer with a lagging clock is picked up, already handled rows are skipped.
+	upsertItem(t, dbConn, 3, "c", 110)
+	upsertItem(t, dbConn, 4, "d", 130)
+	_, err = p.PollOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"3", "4"}, recorder.popKeys())
+
+	// Row from the overlap window is changed again.
+	upsertItem(t, dbConn, 2, "b2", 125)
+	_, err = p.PollOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2"}, recorder.popKeys())
+
+	// Row below the overlap window is missed.
+	upsertItem(t, dbConn, 5, "e", 70)
+	_, err = p.PollOnce(ctx)
+	require.NoError(t, err)
+	require.Empty(t, recorder.popKeys())
+	require.Equal(t, int64(130), p.Watermark())
+}
+
+func TestPoller_Run(t *testing.T) {
+	dbConn, store := newTestDB(t)
+	clock := testkit.NewFakeClock(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	handled := make(chan []Change, 1)
+	p, err := NewPoller(dbConn, dbkit.DialectSQLite, store, testConfig, func(_ context.Context, changes []Change) error {
+		handled <- changes
+		return nil
+	}, WithClock(clock), WithPollInterval(time.Minute))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runErr := make(chan error)
+	go func() { runErr <- p.Run(ctx) }()
+
+	waitCtx, waitCtxCancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer waitCtxCancel()
+	require.NoError(t, clock.WaitForTimers(waitCtx, 1))
+	upsertItem(t, dbConn, 1, "a", 100)
+	clock.Advance(time.Minute)
+	changes := <-handled
+	require.Len(t, changes, 1)
+	require.Equal(t, "1", changes[0].Key)
+
+	cancel()
+	require.ErrorIs(t, <-runErr, context.Canceled)
+}
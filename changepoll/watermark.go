@@ -0,0 +1,185 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package changepoll
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+// DefaultWatermarksTableName is a default name for the table that stores watermarks of pollers.
+const DefaultWatermarksTableName = "change_poll_watermarks"
+
+// WatermarkStore loads and saves watermarks of pollers by their names.
+type WatermarkStore struct {
+	queries watermarkQueries
+	clock   dbkit.Clock
+}
+
+// WatermarkStoreOption is an option for NewWatermarkStore.
+type WatermarkStoreOption func(*watermarkStoreOptions)
+
+type watermarkStoreOptions struct {
+	tableName string
+	clock     dbkit.Clock
+}
+
+// WithWatermarksTableName sets a custom table name for the table that stores watermarks.
+func WithWatermarksTableName(tableName string) WatermarkStoreOption {
+	return func(o *watermarkStoreOptions) {
+		o.tableName = tableName
+	}
+}
+
+// WithWatermarkStoreClock sets the clock that is used for the update time of watermarks (dbkit.RealClock by default).
+// It's intended for tests.
+func WithWatermarkStoreClock(clock dbkit.Clock) WatermarkStoreOption {
+	return func(o *watermarkStoreOptions) {
+		o.clock = clock
+	}
+}
+
+// NewWatermarkStore creates a new WatermarkStore.
+func NewWatermarkStore(dialect dbkit.Dialect, options ...WatermarkStoreOption) (*WatermarkStore, error) {
+	opts := watermarkStoreOptions{clock: dbkit.RealClock{}}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.tableName == "" {
+		opts.tableName = DefaultWatermarksTableName
+	}
+	q, err := newWatermarkQueries(dialect, opts.tableName)
+	if err != nil {
+		return nil, err
+	}
+	return &WatermarkStore{queries: q, clock: opts.clock}, nil
+}
+
+// Migrations returns set of migrations that must be applied before using the store.
+func (s *WatermarkStore) Migrations() []migrate.Migration {
+	return []migrate.Migration{
+		migrate.NewCustomMigration(createWatermarksTableMigrationID,
+			[]string{s.queries.createTable}, []string{s.queries.dropTable}, nil, nil),
+	}
+}
+
+// CreateTableSQL returns SQL query for creating a table that stores watermarks.
+func (s *WatermarkStore) CreateTableSQL() string {
+	return s.queries.createTable
+}
+
+// DropTableSQL returns SQL query for dropping a table that stores watermarks.
+func (s *WatermarkStore) DropTableSQL() string {
+	return s.queries.dropTable
+}
+
+// Load returns the saved watermark of the poller. If it's not saved yet, 0 is returned.
+func (s *WatermarkStore) Load(ctx context.Context, executor SQLQueryExecutor, name string) (int64, error) {
+	rows, err := executor.QueryContext(ctx, s.queries.load, name)
+	if err != nil {
+		return 0, fmt.Errorf("load watermark of %s: %w", name, err)
+	}
+	defer func() { _ = rows.Close() }()
+	var watermark int64
+	if rows.Next() {
+		if err = rows.Scan(&watermark); err != nil {
+			return 0, fmt.Errorf("scan watermark of %s: %w", name, err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("load watermark of %s: %w", name, err)
+	}
+	return watermark, nil
+}
+
+// Save saves the watermark of the poller.
+// It may be called inside the transaction that applies the changes, so the watermark is advanced atomically with them.
+func (s *WatermarkStore) Save(ctx context.Context, executor SQLExecutor, name string, watermark int64) error {
+	if name == "" {
+		return errors.New("poller name cannot be empty")
+	}
+	if _, err := executor.ExecContext(ctx, s.queries.save, name, watermark, s.clock.Now().UnixMilli()); err != nil {
+		return fmt.Errorf("save watermark of %s: %w", name, err)
+	}
+	return nil
+}
+
+// SQLExecutor is an interface for executing SQL queries (e.g., *sql.DB or *sql.Tx).
+type SQLExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// SQLQueryExecutor is an interface for executing SQL queries that return rows (e.g., *sql.DB or *sql.Tx).
+type SQLQueryExecutor interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+type watermarkQueries struct {
+	createTable string
+	dropTable   string
+	load        string
+	save        string
+}
+
+func newWatermarkQueries(dialect dbkit.Dialect, tableName string) (watermarkQueries, error) {
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		return watermarkQueries{
+			createTable: fmt.Sprintf(postgresCreateWatermarksTableQuery, tableName),
+			dropTable:   fmt.Sprintf(postgresDropWatermarksTableQuery, tableName),
+			load:        fmt.Sprintf(postgresLoadWatermarkQuery, tableName),
+			save:        fmt.Sprintf(postgresSaveWatermarkQuery, tableName),
+		}, nil
+	case dbkit.DialectMySQL:
+		return watermarkQueries{
+			createTable: fmt.Sprintf(mySQLCreateWatermarksTableQuery, tableName),
+			dropTable:   fmt.Sprintf(mySQLDropWatermarksTableQuery, tableName),
+			load:        fmt.Sprintf(mySQLLoadWatermarkQuery, tableName),
+			save:        fmt.Sprintf(mySQLSaveWatermarkQuery, tableName),
+		}, nil
+	case dbkit.DialectSQLite:
+		return watermarkQueries{
+			createTable: fmt.Sprintf(sqliteCreateWatermarksTableQuery, tableName),
+			dropTable:   fmt.Sprintf(sqliteDropWatermarksTableQuery, tableName),
+			load:        fmt.Sprintf(sqliteLoadWatermarkQuery, tableName),
+			save:        fmt.Sprintf(sqliteSaveWatermarkQuery, tableName),
+		}, nil
+	default:
+		return watermarkQueries{}, fmt.Errorf("unsupported sql dialect %q", dialect)
+	}
+}
+
+const createWatermarksTableMigrationID = "changepoll_00001_create_watermarks_table"
+
+//nolint:lll
+const (
+	postgresCreateWatermarksTableQuery = `CREATE TABLE IF NOT EXISTS "%s" (name varchar(255) PRIMARY KEY, watermark bigint NOT NULL, updated_at bigint NOT NULL);`
+	postgresDropWatermarksTableQuery   = `DROP TABLE IF EXISTS "%s";`
+	postgresLoadWatermarkQuery         = `SELECT watermark FROM "%s" WHERE name = $1;`
+	postgresSaveWatermarkQuery         = `INSERT INTO "%s" (name, watermark, updated_at) VALUES ($1, $2, $3) ON CONFLICT (name) DO UPDATE SET watermark = EXCLUDED.watermark, updated_at = EXCLUDED.updated_at;`
+)
+
+//nolint:lll
+const (
+	mySQLCreateWatermarksTableQuery = "CREATE TABLE IF NOT EXISTS `%s` (name VARCHAR(255) PRIMARY KEY, watermark BIGINT NOT NULL, updated_at BIGINT NOT NULL);"
+	mySQLDropWatermarksTableQuery   = "DROP TABLE IF EXISTS `%s`;"
+	mySQLLoadWatermarkQuery         = "SELECT watermark FROM `%s` WHERE name = ?;"
+	mySQLSaveWatermarkQuery         = "INSERT INTO `%s` (name, watermark, updated_at) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE watermark = VALUES(watermark), updated_at = VALUES(updated_at);"
+)
+
+//nolint:lll
+const (
+	sqliteCreateWatermarksTableQuery = `CREATE TABLE IF NOT EXISTS "%s" (name TEXT PRIMARY KEY, watermark INTEGER NOT NULL, updated_at INTEGER NOT NULL);`
+	sqliteDropWatermarksTableQuery   = `DROP TABLE IF EXISTS "%s";`
+	sqliteLoadWatermarkQuery         = `SELECT watermark FROM "%s" WHERE name = ?;`
+	sqliteSaveWatermarkQuery         = `INSERT INTO "%s" (name, watermark, updated_at) VALUES (?, ?, ?) ON CONFLICT (name) DO UPDATE SET watermark = excluded.watermark, updated_at = excluded.updated_at;`
+)
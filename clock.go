@@ -0,0 +1,46 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import "time"
+
+// Clock provides the current time and timers.
+// Subsystems measuring TTLs, deadlines and intervals (distrlock, queue, lease, ratelimit, cfghistory) accept it
+// as an option and use RealClock by default, so time-dependent behavior may be unit-tested
+// without sleeps by injecting a fake implementation (see testkit.FakeClock).
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by Clock (see time.Timer).
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// RealClock is the Clock based on the time package.
+type RealClock struct{}
+
+// Now returns time.Now().
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer returns the timer created by time.NewTimer.
+func (RealClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
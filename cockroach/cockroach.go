@@ -0,0 +1,129 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package cockroach provides helpers for working with the CockroachDB database.
+// CockroachDB speaks the Postgres wire protocol, so the github.com/jackc/pgx driver is used
+// (the package imports github.com/acronis/go-dbkit/pgx, so its retryable funcs and error classifiers are registered too).
+// Should be imported explicitly:
+//
+//	import "github.com/acronis/go-dbkit/cockroach"
+package cockroach
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	pg "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/acronis/go-dbkit"
+	_ "github.com/acronis/go-dbkit/pgx" // Register retryable funcs and error classifiers for the pgx driver.
+)
+
+// Dialect is the SQL dialect of CockroachDB.
+const Dialect dbkit.Dialect = "cockroach"
+
+// DriverName is the name of the database/sql driver that should be used for opening CockroachDB (see MakeDSN).
+const DriverName = "pgx"
+
+// DefaultPort is the default port of CockroachDB SQL interface.
+const DefaultPort = 26257
+
+// DefaultSSLMode is the SSL mode which will be used by default.
+const DefaultSSLMode = dbkit.PostgresSSLModeVerifyFull
+
+// nolint
+func init() {
+	dbkit.RegisterDialectDriver(Dialect, &pg.Driver{})
+}
+
+// ErrCode defines the type for CockroachDB error codes (SQLSTATE).
+type ErrCode string
+
+// CockroachDB error codes.
+const (
+	// ErrCodeRetry is returned when the transaction should be retried by the client
+	// (e.g., "restart transaction: TransactionRetryWithProtoRefreshError: ...").
+	ErrCodeRetry ErrCode = "40001"
+	// ErrCodeAmbiguousResult is returned when the result of COMMIT (or RELEASE SAVEPOINT) is unknown.
+	// It's not safe to retry such transaction blindly.
+	ErrCodeAmbiguousResult ErrCode = "40003"
+)
+
+// sqlStateError is implemented by errors of both github.com/jackc/pgx and github.com/lib/pq drivers.
+type sqlStateError interface {
+	SQLState() string
+}
+
+// CheckError checks if the passed error relates to CockroachDB and has the given code.
+func CheckError(err error, errCode ErrCode) bool {
+	var stateErr sqlStateError
+	if errors.As(err, &stateErr) {
+		return ErrCode(stateErr.SQLState()) == errCode
+	}
+	return false
+}
+
+// IsRetryError checks if the error means that the transaction should be retried by the client (SQLSTATE 40001).
+func IsRetryError(err error) bool {
+	return CheckError(err, ErrCodeRetry)
+}
+
+// Config represents a set of configuration parameters for CockroachDB.
+type Config struct {
+	Host        string                `mapstructure:"host" yaml:"host" json:"host"`
+	Port        int                   `mapstructure:"port" yaml:"port" json:"port"`
+	User        string                `mapstructure:"user" yaml:"user" json:"user"`
+	Password    string                `mapstructure:"password" yaml:"password" json:"password"`
+	Database    string                `mapstructure:"database" yaml:"database" json:"database"`
+	SSLMode     dbkit.PostgresSSLMode `mapstructure:"sslMode" yaml:"sslMode" json:"sslMode"`
+	SSLRootCert string                `mapstructure:"sslRootCert" yaml:"sslRootCert" json:"sslRootCert"`
+	// Cluster is the routing ID of the cluster (required by CockroachDB Serverless when it can't be taken from the host).
+	Cluster              string            `mapstructure:"cluster" yaml:"cluster" json:"cluster"`
+	ApplicationName      string            `mapstructure:"applicationName" yaml:"applicationName" json:"applicationName"`
+	AdditionalParameters map[string]string `mapstructure:"additionalParameters" yaml:"additionalParameters" json:"additionalParameters"`
+}
+
+// MakeDSN makes DSN for opening CockroachDB database with the pgx driver (see DriverName).
+func MakeDSN(cfg *Config) string {
+	port := cfg.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+	sslMode := cfg.SSLMode
+	if sslMode == "" {
+		sslMode = DefaultSSLMode
+	}
+	params := map[string]string{"sslmode": string(sslMode)}
+	if cfg.SSLRootCert != "" {
+		params["sslrootcert"] = cfg.SSLRootCert
+	}
+	if cfg.Cluster != "" {
+		params["options"] = "--cluster=" + cfg.Cluster
+	}
+	if cfg.ApplicationName != "" {
+		params["application_name"] = cfg.ApplicationName
+	}
+	for k, v := range cfg.AdditionalParameters {
+		params[k] = v
+	}
+	queryParts := make([]string, 0, len(params))
+	for k, v := range params {
+		queryParts = append(queryParts, fmt.Sprintf("%s=%s", k, url.QueryEscape(v)))
+	}
+	sort.Strings(queryParts) // Sort to make DSN deterministic.
+
+	connURI := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     fmt.Sprintf("%s:%d", cfg.Host, port),
+		Path:     cfg.Database,
+		RawQuery: strings.Join(queryParts, "&"),
+	}
+	return connURI.String()
+}
@@ -0,0 +1,112 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// DefaultMaxRetries is the default maximum number of transaction retries in DoInTx.
+const DefaultMaxRetries = 10
+
+// ErrMaxRetriesExceeded is returned by DoInTx (wrapped together with the last retry error)
+// when the transaction can't be committed after the maximum number of retries.
+var ErrMaxRetriesExceeded = errors.New("max retries of cockroach transaction exceeded")
+
+const restartSavepoint = "cockroach_restart"
+
+type doInTxOptions struct {
+	txOpts     *sql.TxOptions
+	maxRetries int
+}
+
+// DoInTxOption is a functional option for DoInTx.
+type DoInTxOption func(*doInTxOptions)
+
+// WithTxOptions sets transaction options for DoInTx.
+func WithTxOptions(txOpts *sql.TxOptions) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.txOpts = txOpts
+	}
+}
+
+// WithMaxRetries sets the maximum number of transaction retries for DoInTx.
+// DefaultMaxRetries is used by default, negative value means unlimited retries.
+func WithMaxRetries(maxRetries int) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.maxRetries = maxRetries
+	}
+}
+
+// DoInTx begins a new transaction and calls the passed function in it following
+// the client-side retry protocol recommended by CockroachDB:
+//
+//	BEGIN;
+//	SAVEPOINT cockroach_restart;
+//	-- statements of fn
+//	RELEASE SAVEPOINT cockroach_restart; -- on retry error (40001): ROLLBACK TO SAVEPOINT cockroach_restart and call fn again
+//	COMMIT;
+//
+// Unlike restarting the whole transaction, retrying from the savepoint keeps the transaction's timestamp and priority,
+// so it has better chances to succeed under contention.
+// fn may be called several times, so it shouldn't have side effects outside the transaction.
+// Errors that are not retry errors (see IsRetryError) are returned as is, and the transaction is rolled back.
+func DoInTx(ctx context.Context, dbConn *sql.DB, fn func(tx *sql.Tx) error, options ...DoInTxOption) (err error) {
+	opts := doInTxOptions{maxRetries: DefaultMaxRetries}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	var tx *sql.Tx
+	if tx, err = dbConn.BeginTx(ctx, opts.txOpts); err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			err = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, "SAVEPOINT "+restartSavepoint); err != nil {
+		return fmt.Errorf("create savepoint: %w", err)
+	}
+	for retries := 0; ; retries++ {
+		err = fn(tx)
+		if err == nil {
+			// Retry errors may be returned on RELEASE since it actually commits the transaction.
+			if _, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT "+restartSavepoint); err == nil {
+				return nil
+			}
+			if !IsRetryError(err) {
+				return fmt.Errorf("release savepoint: %w", err)
+			}
+		}
+		if !IsRetryError(err) {
+			return err
+		}
+		if opts.maxRetries >= 0 && retries >= opts.maxRetries {
+			return fmt.Errorf("%w: %w", ErrMaxRetriesExceeded, err)
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return err
+		}
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+restartSavepoint); rollbackErr != nil {
+			return fmt.Errorf("rollback to savepoint: %w", rollbackErr)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoInTx(t *testing.T) {
+	retryErr := &pgconn.PgError{Code: string(ErrCodeRetry), Message: "restart transaction"}
+
+	newMock := func(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+		t.Helper()
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+		return db, mock
+	}
+
+	t.Run("success without retries", func(t *testing.T) {
+		db, mock := newMock(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT cockroach_restart").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE accounts SET balance = 0").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("RELEASE SAVEPOINT cockroach_restart").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		var calls int
+		require.NoError(t, DoInTx(context.Background(), db, func(tx *sql.Tx) error {
+			calls++
+			_, err := tx.Exec("UPDATE accounts SET balance = 0")
+			return err
+		}))
+		require.Equal(t, 1, calls)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("retry from savepoint", func(t *testing.T) {
+		db, mock := newMock(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT cockroach_restart").WillReturnResult(sqlmock.NewResult(0, 0))
+		// The first attempt fails on the statement.
+		mock.ExpectExec("UPDATE accounts SET balance = 0").WillReturnError(retryErr)
+		mock.ExpectExec("ROLLBACK TO SAVEPOINT cockroach_restart").WillReturnResult(sqlmock.NewResult(0, 0))
+		// The second attempt fails on release.
+		mock.ExpectExec("UPDATE accounts SET balance = 0").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("RELEASE SAVEPOINT cockroach_restart").WillReturnError(retryErr)
+		mock.ExpectExec("ROLLBACK TO SAVEPOINT cockroach_restart").WillReturnResult(sqlmock.NewResult(0, 0))
+		// The third attempt succeeds.
+		mock.ExpectExec("UPDATE accounts SET balance = 0").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("RELEASE SAVEPOINT cockroach_restart").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		var calls int
+		require.NoError(t, DoInTx(context.Background(), db, func(tx *sql.Tx) error {
+			calls++
+			_, err := tx.Exec("UPDATE accounts SET balance = 0")
+			return err
+		}))
+		require.Equal(t, 3, calls)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("max retries exceeded", func(t *testing.T) {
+		db, mock := newMock(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT cockroach_restart").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ROLLBACK TO SAVEPOINT cockroach_restart").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		var calls int
+		err := DoInTx(context.Background(), db, func(tx *sql.Tx) error {
+			calls++
+			return retryErr
+		}, WithMaxRetries(1))
+		require.ErrorIs(t, err, ErrMaxRetriesExceeded)
+		require.True(t, IsRetryError(err))
+		require.Equal(t, 2, calls)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("non-retry error", func(t *testing.T) {
+		db, mock := newMock(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT cockroach_restart").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		fnErr := errors.New("fn error")
+		err := DoInTx(context.Background(), db, func(tx *sql.Tx) error {
+			return fnErr
+		})
+		require.ErrorIs(t, err, fnErr)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ambiguous result on release is not retried", func(t *testing.T) {
+		db, mock := newMock(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT cockroach_restart").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("RELEASE SAVEPOINT cockroach_restart").
+			WillReturnError(&pgconn.PgError{Code: string(ErrCodeAmbiguousResult)})
+		mock.ExpectRollback()
+
+		err := DoInTx(context.Background(), db, func(tx *sql.Tx) error { return nil })
+		require.True(t, CheckError(err, ErrCodeAmbiguousResult))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
@@ -20,27 +21,39 @@ import (
 const cfgDefaultKeyPrefix = "db"
 
 const (
-	cfgKeyDialect         = "dialect"
-	cfgKeyMaxIdleConns    = "maxIdleConns"
-	cfgKeyMaxOpenConns    = "maxOpenConns"
-	cfgKeyConnMaxLifetime = "connMaxLifeTime"
-
-	cfgKeyMySQLHost     = "mysql.host"
-	cfgKeyMySQLPort     = "mysql.port"
-	cfgKeyMySQLDatabase = "mysql.database"
-	cfgKeyMySQLUser     = "mysql.user"
-	cfgKeyMySQLPassword = "mysql.password" //nolint: gosec
-	cfgKeyMySQLTxLevel  = "mysql.txLevel"
-
-	cfgKeySQLitePath = "sqlite3.path"
+	cfgKeyDialect          = "dialect"
+	cfgKeyProfile          = "profile"
+	cfgKeyMaxIdleConns     = "maxIdleConns"
+	cfgKeyMaxOpenConns     = "maxOpenConns"
+	cfgKeyConnMaxLifetime  = "connMaxLifeTime"
+	cfgKeyStatementTimeout = "statementTimeout"
+
+	cfgKeyMySQLHost             = "mysql.host"
+	cfgKeyMySQLPort             = "mysql.port"
+	cfgKeyMySQLDatabase         = "mysql.database"
+	cfgKeyMySQLUser             = "mysql.user"
+	cfgKeyMySQLPassword         = "mysql.password" //nolint: gosec
+	cfgKeyMySQLTxLevel          = "mysql.txLevel"
+	cfgKeyMySQLAdditionalParams = "mysql.additionalParameters"
+
+	cfgKeySQLitePath        = "sqlite3.path"
+	cfgKeySQLiteJournalMode = "sqlite3.journalMode"
+	cfgKeySQLiteBusyTimeout = "sqlite3.busyTimeout"
+	cfgKeySQLiteForeignKeys = "sqlite3.foreignKeys"
+	cfgKeySQLiteCacheMode   = "sqlite3.cacheMode"
+	cfgKeySQLiteCacheSize   = "sqlite3.cacheSize"
 
 	cfgKeyPostgresHost             = "postgres.host"
+	cfgKeyPostgresHosts            = "postgres.hosts"
 	cfgKeyPostgresPort             = "postgres.port"
 	cfgKeyPostgresDatabase         = "postgres.database"
 	cfgKeyPostgresUser             = "postgres.user"
 	cfgKeyPostgresPassword         = "postgres.password" //nolint: gosec
 	cfgKeyPostgresTxLevel          = "postgres.txLevel"
 	cfgKeyPostgresSSLMode          = "postgres.sslMode"
+	cfgKeyPostgresSSLCert          = "postgres.sslCert"
+	cfgKeyPostgresSSLKey           = "postgres.sslKey"
+	cfgKeyPostgresSSLRootCert      = "postgres.sslRootCert"
 	cfgKeyPostgresSearchPath       = "postgres.searchPath"
 	cfgKeyPostgresAdditionalParams = "postgres.additionalParameters"
 	cfgKeyMSSQLHost                = "mssql.host"
@@ -49,6 +62,23 @@ const (
 	cfgKeyMSSQLUser                = "mssql.user"
 	cfgKeyMSSQLPassword            = "mssql.password" //nolint: gosec
 	cfgKeyMSSQLTxLevel             = "mssql.txLevel"
+	cfgKeyMSSQLAdditionalParams    = "mssql.additionalParameters"
+	cfgKeyMSSQLAzureADMethod       = "mssql.azureAD.method"
+	cfgKeyMSSQLAzureADTenantID     = "mssql.azureAD.tenantID"
+	cfgKeyMSSQLAzureADClientID     = "mssql.azureAD.clientID"
+	cfgKeyMSSQLAzureADClientSecret = "mssql.azureAD.clientSecret" //nolint: gosec
+
+	cfgKeyInstrumentationDisableMetrics         = "instrumentation.disableMetrics"
+	cfgKeyInstrumentationEnableTracing          = "instrumentation.enableTracing"
+	cfgKeyInstrumentationTracingSampleRate      = "instrumentation.tracingSampleRate"
+	cfgKeyInstrumentationSlowQueryThreshold     = "instrumentation.slowQueryThreshold"
+	cfgKeyInstrumentationSlowQueryLogSampleRate = "instrumentation.slowQueryLogSampleRate"
+	cfgKeyInstrumentationQueryRedaction         = "instrumentation.queryRedaction"
+
+	cfgKeyRetryPolicy          = "retry.policy"
+	cfgKeyRetryMaxAttempts     = "retry.maxAttempts"
+	cfgKeyRetryInitialInterval = "retry.initialInterval"
+	cfgKeyRetryMaxInterval     = "retry.maxInterval"
 )
 
 // Config represents a set of configuration parameters working with SQL databases.
@@ -62,6 +92,27 @@ type Config struct {
 	SQLite          SQLiteConfig        `mapstructure:"sqlite3" yaml:"sqlite3" json:"sqlite3"`
 	Postgres        PostgresConfig      `mapstructure:"postgres" yaml:"postgres" json:"postgres"`
 
+	// StatementTimeout bounds each statement client-side (see StatementTimeoutConnector), so statements are canceled
+	// even if callers pass context.Background(). It's applied by Open. Zero means no timeout.
+	StatementTimeout config.TimeDuration `mapstructure:"statementTimeout" yaml:"statementTimeout" json:"statementTimeout"`
+
+	// Instrumentation configures observability (metrics, tracing, slow query log) of the opened database.
+	// It's applied by dbrutil.OpenInstrumented.
+	Instrumentation InstrumentationConfig `mapstructure:"instrumentation" yaml:"instrumentation" json:"instrumentation"`
+
+	// Retry configures the default retry policy of transactions (see RetryConfig.NewPolicy).
+	// Retries are disabled by default.
+	Retry RetryConfig `mapstructure:"retry" yaml:"retry" json:"retry"`
+
+	// Profile is a name of the tuning profile (see Profile) which values are used as defaults for the dialect.
+	// It's applied automatically only when the configuration is loaded with config.Loader (see also ApplyProfile).
+	Profile Profile `mapstructure:"profile" yaml:"profile" json:"profile"`
+
+	// PasswordProvider provides the password when the database is opened (see ResolveDriverNameAndDSN).
+	// If specified, it takes precedence over the password of the dialect configuration.
+	// It's not loaded from the configuration and should be set in code.
+	PasswordProvider PasswordProvider `mapstructure:"-" yaml:"-" json:"-"`
+
 	keyPrefix         string
 	supportedDialects []Dialect
 }
@@ -126,6 +177,11 @@ func NewDefaultConfig(supportedDialects []Dialect, options ...ConfigOption) *Con
 		MSSQL: MSSQLConfig{
 			TxIsolationLevel: IsolationLevel(MSSQLDefaultTxLevel),
 		},
+		Retry: RetryConfig{
+			MaxAttempts:     DefaultRetryMaxAttempts,
+			InitialInterval: config.TimeDuration(DefaultRetryInitialInterval),
+			MaxInterval:     config.TimeDuration(DefaultRetryMaxInterval),
+		},
 	}
 }
 
@@ -155,6 +211,10 @@ func (c *Config) SetProviderDefaults(dp config.DataProvider) {
 	dp.SetDefault(cfgKeyPostgresTxLevel, PostgresDefaultTxLevel.String())
 	dp.SetDefault(cfgKeyPostgresSSLMode, string(PostgresDefaultSSLMode))
 	dp.SetDefault(cfgKeyMSSQLTxLevel, MSSQLDefaultTxLevel.String())
+	dp.SetDefault(cfgKeyRetryMaxAttempts, DefaultRetryMaxAttempts)
+	dp.SetDefault(cfgKeyRetryInitialInterval, DefaultRetryInitialInterval)
+	dp.SetDefault(cfgKeyRetryMaxInterval, DefaultRetryMaxInterval)
+	setProfileProviderDefaults(dp)
 }
 
 // MySQLConfig represents a set of configuration parameters for working with MySQL.
@@ -165,6 +225,10 @@ type MySQLConfig struct {
 	Password         string         `mapstructure:"password" yaml:"password" json:"password"`
 	Database         string         `mapstructure:"database" yaml:"database" json:"database"`
 	TxIsolationLevel IsolationLevel `mapstructure:"txLevel" yaml:"txLevel" json:"txLevel"`
+	// AdditionalParameters are appended to DSN as driver parameters (e.g., readTimeout, charset),
+	// they override parameters set by dbkit (parseTime, multiStatements, autocommit).
+	// Names must not contain '&' and '=' because the DSN format doesn't allow escaping them.
+	AdditionalParameters map[string]string `mapstructure:"additionalParameters" yaml:"additionalParameters" json:"additionalParameters"`
 }
 
 // MSSQLConfig represents a set of configuration parameters for working with MSSQL.
@@ -175,22 +239,80 @@ type MSSQLConfig struct {
 	Password         string         `mapstructure:"password" yaml:"password" json:"password"`
 	Database         string         `mapstructure:"database" yaml:"database" json:"database"`
 	TxIsolationLevel IsolationLevel `mapstructure:"txLevel" yaml:"txLevel" json:"txLevel"`
+	// AdditionalParameters are appended to DSN as driver parameters (e.g., "connection timeout", ApplicationIntent).
+	AdditionalParameters map[string]string `mapstructure:"additionalParameters" yaml:"additionalParameters" json:"additionalParameters"`
+	// AzureAD configures Azure Active Directory authentication (User and Password are not used if it's enabled).
+	// The connection pool should be opened with mssql.OpenAzureAD (or mssql.NewAzureADConnector) in this case.
+	AzureAD MSSQLAzureADConfig `mapstructure:"azureAD" yaml:"azureAD" json:"azureAD"`
+}
+
+// MSSQLAzureADConfig represents a set of configuration parameters of Azure Active Directory (Microsoft Entra ID)
+// authentication for MSSQL (Azure SQL).
+type MSSQLAzureADConfig struct {
+	// Method is the authentication method, Azure AD authentication is disabled if it's empty.
+	Method MSSQLAzureADAuthMethod `mapstructure:"method" yaml:"method" json:"method"`
+	// TenantID is the ID of the Azure AD tenant (directory), required for the service principal.
+	TenantID string `mapstructure:"tenantID" yaml:"tenantID" json:"tenantID"`
+	// ClientID is the application (client) ID of the service principal or of the user-assigned managed identity.
+	ClientID string `mapstructure:"clientID" yaml:"clientID" json:"clientID"`
+	// ClientSecret is the client secret of the service principal.
+	ClientSecret string `mapstructure:"clientSecret" yaml:"clientSecret" json:"clientSecret"`
+}
+
+// Enabled returns true if Azure AD authentication is configured.
+func (c *MSSQLAzureADConfig) Enabled() bool {
+	return c.Method != ""
+}
+
+// InstrumentationConfig represents a set of configuration parameters of the database instrumentation,
+// so observability posture of the service is configured in one place.
+// Zero value keeps the default behavior: metrics are collected, tracing and slow query log are disabled.
+type InstrumentationConfig struct {
+	// DisableMetrics disables Prometheus metrics of SQL queries and connection pool statistics.
+	DisableMetrics bool `mapstructure:"disableMetrics" yaml:"disableMetrics" json:"disableMetrics"`
+	// EnableTracing enables tracing of SQL queries. The tracer itself is passed in code
+	// (e.g., dbrutil.InstrumentationOpts.Tracer), tracing is disabled if it's not specified.
+	EnableTracing bool `mapstructure:"enableTracing" yaml:"enableTracing" json:"enableTracing"`
+	// TracingSampleRate is a fraction of traced SQL queries in the (0, 1] range, 0 means all queries.
+	TracingSampleRate float64 `mapstructure:"tracingSampleRate" yaml:"tracingSampleRate" json:"tracingSampleRate"`
+	// SlowQueryThreshold is a minimal duration of SQL query to be logged as slow, 0 disables slow query log.
+	SlowQueryThreshold config.TimeDuration `mapstructure:"slowQueryThreshold" yaml:"slowQueryThreshold" json:"slowQueryThreshold"`
+	// SlowQueryLogSampleRate is a fraction of logged slow SQL queries in the (0, 1] range, 0 means all queries.
+	SlowQueryLogSampleRate float64 `mapstructure:"slowQueryLogSampleRate" yaml:"slowQueryLogSampleRate" json:"slowQueryLogSampleRate"`
+	// QueryRedaction defines how the query text is exposed in logs and traces (QueryRedactionFull is used if empty).
+	QueryRedaction QueryRedactionMode `mapstructure:"queryRedaction" yaml:"queryRedaction" json:"queryRedaction"`
 }
 
 // SQLiteConfig represents a set of configuration parameters for working with SQLite.
+// Empty (zero) values of pragma options leave SQLite defaults.
 type SQLiteConfig struct {
-	Path string `mapstructure:"path" yaml:"path" json:"path"`
+	Path        string              `mapstructure:"path" yaml:"path" json:"path"`
+	JournalMode SQLiteJournalMode   `mapstructure:"journalMode" yaml:"journalMode" json:"journalMode"`
+	BusyTimeout config.TimeDuration `mapstructure:"busyTimeout" yaml:"busyTimeout" json:"busyTimeout"`
+	ForeignKeys bool                `mapstructure:"foreignKeys" yaml:"foreignKeys" json:"foreignKeys"`
+	CacheMode   SQLiteCacheMode     `mapstructure:"cacheMode" yaml:"cacheMode" json:"cacheMode"`
+	// CacheSize is the value of cache_size pragma: positive value is a number of pages,
+	// negative value is a size in KiB.
+	CacheSize int `mapstructure:"cacheSize" yaml:"cacheSize" json:"cacheSize"`
 }
 
 // PostgresConfig represents a set of configuration parameters for working with Postgres.
 type PostgresConfig struct {
-	Host                 string            `mapstructure:"host" yaml:"host" json:"host"`
+	Host string `mapstructure:"host" yaml:"host" json:"host"`
+	// Hosts is a list of hosts in the "host[:port]" format (Port is used if the port is omitted) for multi-host DSN.
+	// If specified, Host is ignored, and the driver tries hosts in order until it finds one that matches
+	// target_session_attrs (see PgTargetSessionAttrs, read-write by default for pgx), enabling driver-level failover
+	// for HA clusters (e.g., Patroni) without an external proxy. Supported only by pgx driver, IPv6 addresses are not supported.
+	Hosts                []string          `mapstructure:"hosts" yaml:"hosts" json:"hosts"`
 	Port                 int               `mapstructure:"port" yaml:"port" json:"port"`
 	User                 string            `mapstructure:"user" yaml:"user" json:"user"`
 	Password             string            `mapstructure:"password" yaml:"password" json:"password"`
 	Database             string            `mapstructure:"database" yaml:"database" json:"database"`
 	TxIsolationLevel     IsolationLevel    `mapstructure:"txLevel" yaml:"txLevel" json:"txLevel"`
 	SSLMode              PostgresSSLMode   `mapstructure:"sslMode" yaml:"sslMode" json:"sslMode"`
+	SSLCert              string            `mapstructure:"sslCert" yaml:"sslCert" json:"sslCert"`
+	SSLKey               string            `mapstructure:"sslKey" yaml:"sslKey" json:"sslKey"`
+	SSLRootCert          string            `mapstructure:"sslRootCert" yaml:"sslRootCert" json:"sslRootCert"`
 	SearchPath           string            `mapstructure:"searchPath" yaml:"searchPath" json:"searchPath"`
 	AdditionalParameters map[string]string `mapstructure:"additionalParameters" yaml:"additionalParameters" json:"additionalParameters"`
 }
@@ -199,6 +321,10 @@ type PostgresConfig struct {
 func (c *Config) Set(dp config.DataProvider) error {
 	var err error
 
+	if err = c.setProfile(dp); err != nil {
+		return err
+	}
+
 	err = c.setDialectSpecificConfig(dp)
 	if err != nil {
 		return err
@@ -230,9 +356,112 @@ func (c *Config) Set(dp config.DataProvider) error {
 	}
 	c.ConnMaxLifetime = config.TimeDuration(connMaxLifeTime)
 
+	var statementTimeout time.Duration
+	if statementTimeout, err = dp.GetDuration(cfgKeyStatementTimeout); err != nil {
+		return err
+	}
+	if statementTimeout < 0 {
+		return dp.WrapKeyErr(cfgKeyStatementTimeout, fmt.Errorf("must be positive"))
+	}
+	c.StatementTimeout = config.TimeDuration(statementTimeout)
+
+	if err = c.setRetryConfig(dp); err != nil {
+		return err
+	}
+
+	return c.setInstrumentationConfig(dp)
+}
+
+func (c *Config) setRetryConfig(dp config.DataProvider) error {
+	var err error
+
+	var policyStr string
+	if policyStr, err = dp.GetString(cfgKeyRetryPolicy); err != nil {
+		return err
+	}
+	switch policy := RetryPolicyType(policyStr); policy {
+	case "", RetryPolicyTypeNone, RetryPolicyTypeConstant, RetryPolicyTypeExponential:
+		c.Retry.Policy = policy
+	default:
+		return dp.WrapKeyErr(cfgKeyRetryPolicy, fmt.Errorf("unknown value %q, should be one of %v",
+			policyStr, RetryPolicyTypes()))
+	}
+	if c.Retry.MaxAttempts, err = dp.GetInt(cfgKeyRetryMaxAttempts); err != nil {
+		return err
+	}
+	if c.Retry.MaxAttempts < 0 {
+		return dp.WrapKeyErr(cfgKeyRetryMaxAttempts, fmt.Errorf("must be positive"))
+	}
+	var initialInterval time.Duration
+	if initialInterval, err = dp.GetDuration(cfgKeyRetryInitialInterval); err != nil {
+		return err
+	}
+	if initialInterval < 0 {
+		return dp.WrapKeyErr(cfgKeyRetryInitialInterval, fmt.Errorf("must be positive"))
+	}
+	c.Retry.InitialInterval = config.TimeDuration(initialInterval)
+	var maxInterval time.Duration
+	if maxInterval, err = dp.GetDuration(cfgKeyRetryMaxInterval); err != nil {
+		return err
+	}
+	if maxInterval < 0 {
+		return dp.WrapKeyErr(cfgKeyRetryMaxInterval, fmt.Errorf("must be positive"))
+	}
+	c.Retry.MaxInterval = config.TimeDuration(maxInterval)
+
 	return nil
 }
 
+func (c *Config) setInstrumentationConfig(dp config.DataProvider) error {
+	var err error
+
+	if c.Instrumentation.DisableMetrics, err = dp.GetBool(cfgKeyInstrumentationDisableMetrics); err != nil {
+		return err
+	}
+	if c.Instrumentation.EnableTracing, err = dp.GetBool(cfgKeyInstrumentationEnableTracing); err != nil {
+		return err
+	}
+	if c.Instrumentation.TracingSampleRate, err = getSampleRate(dp, cfgKeyInstrumentationTracingSampleRate); err != nil {
+		return err
+	}
+	var slowQueryThreshold time.Duration
+	if slowQueryThreshold, err = dp.GetDuration(cfgKeyInstrumentationSlowQueryThreshold); err != nil {
+		return err
+	}
+	if slowQueryThreshold < 0 {
+		return dp.WrapKeyErr(cfgKeyInstrumentationSlowQueryThreshold, fmt.Errorf("must be positive"))
+	}
+	c.Instrumentation.SlowQueryThreshold = config.TimeDuration(slowQueryThreshold)
+	if c.Instrumentation.SlowQueryLogSampleRate, err = getSampleRate(dp, cfgKeyInstrumentationSlowQueryLogSampleRate); err != nil {
+		return err
+	}
+
+	var redactionStr string
+	if redactionStr, err = dp.GetString(cfgKeyInstrumentationQueryRedaction); err != nil {
+		return err
+	}
+	switch redaction := QueryRedactionMode(redactionStr); redaction {
+	case "", QueryRedactionFull, QueryRedactionMask, QueryRedactionNone:
+		c.Instrumentation.QueryRedaction = redaction
+	default:
+		return dp.WrapKeyErr(cfgKeyInstrumentationQueryRedaction, fmt.Errorf("unknown value %q, should be one of %v",
+			redactionStr, []QueryRedactionMode{QueryRedactionFull, QueryRedactionMask, QueryRedactionNone}))
+	}
+
+	return nil
+}
+
+func getSampleRate(dp config.DataProvider, key string) (float64, error) {
+	rate, err := dp.GetFloat64(key)
+	if err != nil {
+		return 0, err
+	}
+	if rate < 0 || rate > 1 {
+		return 0, dp.WrapKeyErr(key, fmt.Errorf("must be in the [0, 1] range"))
+	}
+	return rate, nil
+}
+
 // TxIsolationLevel returns transaction isolation level from parsed config for specified dialect.
 func (c *Config) TxIsolationLevel() sql.IsolationLevel {
 	switch c.Dialect {
@@ -247,6 +476,7 @@ func (c *Config) TxIsolationLevel() sql.IsolationLevel {
 }
 
 // DriverNameAndDSN returns driver name and DSN for connecting.
+// PasswordProvider is not used here (see ResolveDriverNameAndDSN).
 func (c *Config) DriverNameAndDSN() (driverName, dsn string) {
 	switch c.Dialect {
 	case DialectMySQL:
@@ -289,6 +519,22 @@ func (c *Config) setDialectSpecificConfig(dp config.DataProvider) error {
 	return err
 }
 
+func (c *Config) setProfile(dp config.DataProvider) error {
+	profileStr, err := dp.GetString(cfgKeyProfile)
+	if err != nil {
+		return err
+	}
+	if profileStr == "" {
+		c.Profile = ""
+		return nil
+	}
+	if isKnownProfile(Profile(profileStr)) {
+		c.Profile = Profile(profileStr)
+		return nil
+	}
+	return dp.WrapKeyErr(cfgKeyProfile, fmt.Errorf("unknown value %q, should be one of %v", profileStr, Profiles()))
+}
+
 // nolint: dupl
 func (c *Config) setMySQLConfig(dp config.DataProvider) error {
 	var err error
@@ -302,6 +548,9 @@ func (c *Config) setMySQLConfig(dp config.DataProvider) error {
 	if c.MySQL.User, err = dp.GetString(cfgKeyMySQLUser); err != nil {
 		return err
 	}
+	if strings.Contains(c.MySQL.User, ":") {
+		return dp.WrapKeyErr(cfgKeyMySQLUser, fmt.Errorf("must not contain ':'"))
+	}
 	if c.MySQL.Password, err = dp.GetString(cfgKeyMySQLPassword); err != nil {
 		return err
 	}
@@ -312,6 +561,17 @@ func (c *Config) setMySQLConfig(dp config.DataProvider) error {
 		return err
 	}
 
+	var additionalParams map[string]string
+	if additionalParams, err = dp.GetStringMapString(cfgKeyMySQLAdditionalParams); err != nil {
+		return err
+	}
+	if err = checkMySQLParamNames(additionalParams); err != nil {
+		return dp.WrapKeyErr(cfgKeyMySQLAdditionalParams, err)
+	}
+	if len(additionalParams) != 0 {
+		c.MySQL.AdditionalParameters = additionalParams
+	}
+
 	return nil
 }
 
@@ -338,6 +598,41 @@ func (c *Config) setMSSQLConfig(dp config.DataProvider) error {
 		return err
 	}
 
+	var additionalParams map[string]string
+	if additionalParams, err = dp.GetStringMapString(cfgKeyMSSQLAdditionalParams); err != nil {
+		return err
+	}
+	if len(additionalParams) != 0 {
+		c.MSSQL.AdditionalParameters = additionalParams
+	}
+
+	return c.setMSSQLAzureADConfig(dp)
+}
+
+func (c *Config) setMSSQLAzureADConfig(dp config.DataProvider) error {
+	var err error
+
+	var methodStr string
+	if methodStr, err = dp.GetString(cfgKeyMSSQLAzureADMethod); err != nil {
+		return err
+	}
+	switch method := MSSQLAzureADAuthMethod(methodStr); method {
+	case "", MSSQLAzureADAuthServicePrincipal, MSSQLAzureADAuthManagedIdentity:
+		c.MSSQL.AzureAD.Method = method
+	default:
+		return dp.WrapKeyErr(cfgKeyMSSQLAzureADMethod, fmt.Errorf("unknown value %q, should be one of %v",
+			methodStr, []MSSQLAzureADAuthMethod{MSSQLAzureADAuthServicePrincipal, MSSQLAzureADAuthManagedIdentity}))
+	}
+	if c.MSSQL.AzureAD.TenantID, err = dp.GetString(cfgKeyMSSQLAzureADTenantID); err != nil {
+		return err
+	}
+	if c.MSSQL.AzureAD.ClientID, err = dp.GetString(cfgKeyMSSQLAzureADClientID); err != nil {
+		return err
+	}
+	if c.MSSQL.AzureAD.ClientSecret, err = dp.GetString(cfgKeyMSSQLAzureADClientSecret); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -348,6 +643,23 @@ func (c *Config) setPostgresConfig(dp config.DataProvider, dialect Dialect) erro
 	if c.Postgres.Host, err = dp.GetString(cfgKeyPostgresHost); err != nil {
 		return err
 	}
+	var hosts []string
+	if hosts, err = dp.GetStringSlice(cfgKeyPostgresHosts); err != nil {
+		return err
+	}
+	if len(hosts) > 1 && dialect != DialectPgx {
+		return dp.WrapKeyErr(cfgKeyPostgresHosts, fmt.Errorf("multiple hosts are supported only by %s dialect", DialectPgx))
+	}
+	if len(hosts) > 1 {
+		for _, host := range hosts {
+			if isIPv6Host(host) {
+				return dp.WrapKeyErr(cfgKeyPostgresHosts, fmt.Errorf("IPv6 address %q is not supported in multi-host DSN", host))
+			}
+		}
+	}
+	if len(hosts) != 0 {
+		c.Postgres.Hosts = hosts
+	}
 	if c.Postgres.Port, err = dp.GetInt(cfgKeyPostgresPort); err != nil {
 		return err
 	}
@@ -384,6 +696,9 @@ func (c *Config) setPostgresConfig(dp config.DataProvider, dialect Dialect) erro
 			c.Postgres.AdditionalParameters[PgTargetSessionAttrs] = PgReadWriteParam
 		}
 	}
+	if settings, ok := GetProfileSettings(c.Profile, dialect); ok {
+		c.Postgres.setStatementTimeoutParam(settings.StatementTimeout)
+	}
 
 	availableSSLModesStr := []string{
 		string(PostgresSSLModeDisable),
@@ -397,9 +712,47 @@ func (c *Config) setPostgresConfig(dp config.DataProvider, dialect Dialect) erro
 	}
 	c.Postgres.SSLMode = PostgresSSLMode(gotSSLModeStr)
 
+	// Client certificate and key are used for authentication together.
+	if c.Postgres.SSLCert, err = getExistingFilePath(dp, cfgKeyPostgresSSLCert); err != nil {
+		return err
+	}
+	if c.Postgres.SSLKey, err = getExistingFilePath(dp, cfgKeyPostgresSSLKey); err != nil {
+		return err
+	}
+	if c.Postgres.SSLCert != "" && c.Postgres.SSLKey == "" {
+		return dp.WrapKeyErr(cfgKeyPostgresSSLKey, fmt.Errorf("must be specified when %s is set", cfgKeyPostgresSSLCert))
+	}
+	if c.Postgres.SSLKey != "" && c.Postgres.SSLCert == "" {
+		return dp.WrapKeyErr(cfgKeyPostgresSSLCert, fmt.Errorf("must be specified when %s is set", cfgKeyPostgresSSLKey))
+	}
+	if c.Postgres.SSLRootCert, err = getExistingFilePath(dp, cfgKeyPostgresSSLRootCert); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// getExistingFilePath returns the file path by the key and checks that the file exists (if the path is not empty).
+// isIPv6Host reports whether the host in the "host[:port]" format is an IPv6 address.
+func isIPv6Host(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.To4() == nil
+}
+
+func getExistingFilePath(dp config.DataProvider, key string) (string, error) {
+	path, err := dp.GetString(key)
+	if err != nil || path == "" {
+		return path, err
+	}
+	if err = checkFileExists(path); err != nil {
+		return "", dp.WrapKeyErr(key, err)
+	}
+	return path, nil
+}
+
 func (c *Config) setSQLiteConfig(dp config.DataProvider) error {
 	var err error
 
@@ -407,6 +760,45 @@ func (c *Config) setSQLiteConfig(dp config.DataProvider) error {
 		return err
 	}
 
+	availableJournalModesStr := []string{
+		"",
+		string(SQLiteJournalModeDelete),
+		string(SQLiteJournalModeTruncate),
+		string(SQLiteJournalModePersist),
+		string(SQLiteJournalModeMemory),
+		string(SQLiteJournalModeWAL),
+		string(SQLiteJournalModeOff),
+	}
+	journalModeStr, err := dp.GetStringFromSet(cfgKeySQLiteJournalMode, availableJournalModesStr, true)
+	if err != nil {
+		return err
+	}
+	c.SQLite.JournalMode = SQLiteJournalMode(strings.ToUpper(journalModeStr))
+
+	var busyTimeout time.Duration
+	if busyTimeout, err = dp.GetDuration(cfgKeySQLiteBusyTimeout); err != nil {
+		return err
+	}
+	if busyTimeout < 0 {
+		return dp.WrapKeyErr(cfgKeySQLiteBusyTimeout, fmt.Errorf("must be positive"))
+	}
+	c.SQLite.BusyTimeout = config.TimeDuration(busyTimeout)
+
+	if c.SQLite.ForeignKeys, err = dp.GetBool(cfgKeySQLiteForeignKeys); err != nil {
+		return err
+	}
+
+	availableCacheModesStr := []string{"", string(SQLiteCacheModeShared), string(SQLiteCacheModePrivate)}
+	cacheModeStr, err := dp.GetStringFromSet(cfgKeySQLiteCacheMode, availableCacheModesStr, true)
+	if err != nil {
+		return err
+	}
+	c.SQLite.CacheMode = SQLiteCacheMode(strings.ToLower(cacheModeStr))
+
+	if c.SQLite.CacheSize, err = dp.GetInt(cfgKeySQLiteCacheSize); err != nil {
+		return err
+	}
+
 	return nil
 }
 
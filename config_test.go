@@ -10,6 +10,10 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -121,6 +125,27 @@ db:
 				return cfg
 			},
 		},
+		{
+			name: "postgres dialect, github.com/jackc/pgx driver, multiple hosts",
+			cfgData: `
+db:
+  dialect: pgx
+  postgres:
+    hosts:
+      - pg-1
+      - pg-2:5433
+    port: 5432
+    database: pg_db
+`,
+			expectedCfg: func() *Config {
+				cfg := NewDefaultConfig(supportedDialects)
+				cfg.Dialect = DialectPgx
+				cfg.Postgres.Hosts = []string{"pg-1", "pg-2:5433"}
+				cfg.Postgres.Port = 5432
+				cfg.Postgres.Database = "pg_db"
+				return cfg
+			},
+		},
 		{
 			name: "postgres dialect, github.com/jackc/pgx driver, overridden target_session_attrs",
 			cfgData: `
@@ -178,6 +203,94 @@ db:
 				return cfg
 			},
 		},
+		{
+			name: "mysql dialect with additional parameters",
+			cfgData: `
+db:
+  dialect: mysql
+  mysql:
+    host: mysql-host
+    additionalParameters:
+      charset: utf8mb4
+`,
+			expectedCfg: func() *Config {
+				cfg := NewDefaultConfig(supportedDialects)
+				cfg.Dialect = DialectMySQL
+				cfg.MySQL.Host = "mysql-host"
+				cfg.MySQL.AdditionalParameters = map[string]string{"charset": "utf8mb4"}
+				return cfg
+			},
+		},
+		{
+			name: "mssql dialect with additional parameters",
+			cfgData: `
+db:
+  dialect: mssql
+  mssql:
+    host: mssql-host
+    additionalParameters:
+      applicationintent: ReadOnly
+`,
+			expectedCfg: func() *Config {
+				cfg := NewDefaultConfig(supportedDialects)
+				cfg.Dialect = DialectMSSQL
+				cfg.MSSQL.Host = "mssql-host"
+				cfg.MSSQL.AdditionalParameters = map[string]string{"applicationintent": "ReadOnly"}
+				return cfg
+			},
+		},
+		{
+			name: "mssql dialect with Azure AD authentication",
+			cfgData: `
+db:
+  dialect: mssql
+  mssql:
+    host: myserver.database.windows.net
+    azureAD:
+      method: service-principal
+      tenantID: tenant
+      clientID: client
+      clientSecret: secret
+`,
+			expectedCfg: func() *Config {
+				cfg := NewDefaultConfig(supportedDialects)
+				cfg.Dialect = DialectMSSQL
+				cfg.MSSQL.Host = "myserver.database.windows.net"
+				cfg.MSSQL.AzureAD = MSSQLAzureADConfig{
+					Method: MSSQLAzureADAuthServicePrincipal, TenantID: "tenant", ClientID: "client", ClientSecret: "secret"}
+				return cfg
+			},
+		},
+		{
+			name: "instrumentation",
+			cfgData: `
+db:
+  dialect: sqlite3
+  sqlite3:
+    path: ":memory:"
+  instrumentation:
+    disableMetrics: true
+    enableTracing: true
+    tracingSampleRate: 0.1
+    slowQueryThreshold: 500ms
+    slowQueryLogSampleRate: 0.5
+    queryRedaction: mask
+`,
+			expectedCfg: func() *Config {
+				cfg := NewDefaultConfig(supportedDialects)
+				cfg.Dialect = DialectSQLite
+				cfg.SQLite.Path = ":memory:"
+				cfg.Instrumentation = InstrumentationConfig{
+					DisableMetrics:         true,
+					EnableTracing:          true,
+					TracingSampleRate:      0.1,
+					SlowQueryThreshold:     config.TimeDuration(500 * time.Millisecond),
+					SlowQueryLogSampleRate: 0.5,
+					QueryRedaction:         QueryRedactionMask,
+				}
+				return cfg
+			},
+		},
 		{
 			name: "sqlite dialect",
 			cfgData: `
@@ -199,6 +312,46 @@ db:
 				return cfg
 			},
 		},
+		{
+			name: "sqlite dialect with pragmas",
+			cfgData: `
+db:
+  maxOpenConns: 20
+  maxIdleConns: 10
+  connMaxLifeTime: 1m
+  statementTimeout: 30s
+  retry:
+    policy: exponential
+    maxAttempts: 5
+    initialInterval: 10ms
+  dialect: sqlite3
+  sqlite3:
+    path: "/var/lib/app/app.db"
+    journalMode: WAL
+    busyTimeout: 5s
+    foreignKeys: true
+    cacheMode: shared
+    cacheSize: -2000
+`,
+			expectedCfg: func() *Config {
+				cfg := NewDefaultConfig(supportedDialects)
+				cfg.Dialect = DialectSQLite
+				cfg.MaxOpenConns = 20
+				cfg.MaxIdleConns = 10
+				cfg.ConnMaxLifetime = config.TimeDuration(time.Minute)
+				cfg.StatementTimeout = config.TimeDuration(30 * time.Second)
+				cfg.Retry.Policy = RetryPolicyTypeExponential
+				cfg.Retry.MaxAttempts = 5
+				cfg.Retry.InitialInterval = config.TimeDuration(10 * time.Millisecond)
+				cfg.SQLite.Path = "/var/lib/app/app.db"
+				cfg.SQLite.JournalMode = SQLiteJournalModeWAL
+				cfg.SQLite.BusyTimeout = config.TimeDuration(5 * time.Second)
+				cfg.SQLite.ForeignKeys = true
+				cfg.SQLite.CacheMode = SQLiteCacheModeShared
+				cfg.SQLite.CacheSize = -2000
+				return cfg
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -333,6 +486,116 @@ db:
 `,
 			expectedErrMsg: `db.connMaxLifeTime: time: invalid duration "invalid-duration"`,
 		},
+		{
+			name: "negative statement timeout",
+			yamlData: `
+db:
+  dialect: mysql
+  statementTimeout: -1s
+`,
+			expectedErrMsg: `db.statementTimeout: must be positive`,
+		},
+		{
+			name: "unknown retry policy",
+			yamlData: `
+db:
+  dialect: mysql
+  retry:
+    policy: linear
+`,
+			expectedErrMsg: `db.retry.policy: unknown value "linear", should be one of [none constant exponential]`,
+		},
+		{
+			name: "postgres ssl root certificate doesn't exist",
+			yamlData: `
+db:
+  dialect: postgres
+  postgres:
+    sslMode: verify-full
+    sslRootCert: /nonexistent/root.crt
+`,
+			expectedErrMsg: `db.postgres.sslRootCert: stat /nonexistent/root.crt: no such file or directory`,
+		},
+		{
+			name: "postgres ssl client key doesn't exist",
+			yamlData: `
+db:
+  dialect: pgx
+  postgres:
+    sslKey: /nonexistent/client.key
+`,
+			expectedErrMsg: `db.postgres.sslKey: stat /nonexistent/client.key: no such file or directory`,
+		},
+		{
+			name: "postgres multiple hosts with lib/pq driver",
+			yamlData: `
+db:
+  dialect: postgres
+  postgres:
+    hosts: [pg-1, pg-2]
+`,
+			expectedErrMsg: `db.postgres.hosts: multiple hosts are supported only by pgx dialect`,
+		},
+		{
+			name: "postgres multiple hosts with IPv6 address",
+			yamlData: `
+db:
+  dialect: pgx
+  postgres:
+    hosts: [pg-1, "[::1]:5433"]
+`,
+			expectedErrMsg: `db.postgres.hosts: IPv6 address "[::1]:5433" is not supported in multi-host DSN`,
+		},
+		{
+			name: "mysql user with colon",
+			yamlData: `
+db:
+  dialect: mysql
+  mysql:
+    user: "admin:ro"
+`,
+			expectedErrMsg: `db.mysql.user: must not contain ':'`,
+		},
+		{
+			name: "unknown profile",
+			yamlData: `
+db:
+  dialect: mysql
+  profile: oltp-huge
+`,
+			expectedErrMsg: `db.profile: unknown value "oltp-huge", should be one of [oltp-small oltp-large batch]`,
+		},
+		{
+			name: "unknown mssql Azure AD authentication method",
+			yamlData: `
+db:
+  dialect: mssql
+  mssql:
+    azureAD:
+      method: password
+`,
+			expectedErrMsg: `db.mssql.azureAD.method: unknown value "password", should be one of [service-principal managed-identity]`,
+		},
+		{
+			name: "instrumentation sample rate out of range",
+			yamlData: `
+db:
+  dialect: mysql
+  instrumentation:
+    tracingSampleRate: 1.5
+`,
+			expectedErrMsg: `db.instrumentation.tracingSampleRate: must be in the [0, 1] range`,
+		},
+		{
+			name: "unknown instrumentation query redaction mode",
+			yamlData: `
+db:
+  dialect: mysql
+  instrumentation:
+    queryRedaction: partial
+`,
+			expectedErrMsg: `db.instrumentation.queryRedaction: unknown value "partial", should be one of [full mask none]`,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -343,6 +606,86 @@ db:
 	}
 }
 
+func TestConfigPostgresClientCertificate(t *testing.T) {
+	certsDir := t.TempDir()
+	for _, name := range []string{"client.crt", "client.key", "root.crt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(certsDir, name), []byte("test"), 0o600))
+	}
+	loadConfig := func(postgresCfgData string) (*Config, error) {
+		cfgData := "db:\n  dialect: postgres\n  postgres:\n    sslMode: verify-full\n" + postgresCfgData
+		cfg := NewConfig([]Dialect{DialectPostgres})
+		err := config.NewDefaultLoader("").LoadFromReader(bytes.NewBufferString(cfgData), config.DataTypeYAML, cfg)
+		return cfg, err
+	}
+
+	cfg, err := loadConfig(fmt.Sprintf("    sslCert: %[1]s/client.crt\n    sslKey: %[1]s/client.key\n    sslRootCert: %[1]s/root.crt\n", certsDir))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(certsDir, "client.crt"), cfg.Postgres.SSLCert)
+	require.Equal(t, filepath.Join(certsDir, "client.key"), cfg.Postgres.SSLKey)
+	require.Equal(t, filepath.Join(certsDir, "root.crt"), cfg.Postgres.SSLRootCert)
+	_, dsn := cfg.DriverNameAndDSN()
+	require.Contains(t, dsn, "&sslcert="+url.QueryEscape(cfg.Postgres.SSLCert))
+
+	_, err = loadConfig(fmt.Sprintf("    sslCert: %s/client.crt\n", certsDir))
+	require.EqualError(t, err, "db.postgres.sslKey: must be specified when postgres.sslCert is set")
+
+	_, err = loadConfig(fmt.Sprintf("    sslRootCert: %s\n", certsDir))
+	require.EqualError(t, err, fmt.Sprintf("db.postgres.sslRootCert: %s is a directory", certsDir))
+}
+
+func TestConfigProfile(t *testing.T) {
+	loadConfig := func(cfgData string) *Config {
+		cfg := NewDefaultConfig([]Dialect{DialectSQLite, DialectMySQL, DialectPgx})
+		err := config.NewDefaultLoader("").LoadFromReader(bytes.NewBufferString(cfgData), config.DataTypeYAML, cfg)
+		require.NoError(t, err)
+		return cfg
+	}
+
+	t.Run("profile values are used as defaults", func(t *testing.T) {
+		cfg := loadConfig("db:\n  dialect: pgx\n  profile: oltp-large\n")
+		require.Equal(t, ProfileOLTPLarge, cfg.Profile)
+		require.Equal(t, 50, cfg.MaxOpenConns)
+		require.Equal(t, 25, cfg.MaxIdleConns)
+		require.Equal(t, config.TimeDuration(30*time.Minute), cfg.ConnMaxLifetime)
+		require.Equal(t, IsolationLevel(sql.LevelReadCommitted), cfg.Postgres.TxIsolationLevel)
+		require.Equal(t, map[string]string{PgTargetSessionAttrs: PgReadWriteParam, PgStatementTimeout: "10000"},
+			cfg.Postgres.AdditionalParameters)
+
+		cfg = loadConfig("db:\n  dialect: sqlite3\n  profile: batch\n  sqlite3:\n    path: /tmp/app.db\n")
+		require.Equal(t, 1, cfg.MaxOpenConns)
+		require.Equal(t, config.TimeDuration(time.Minute), cfg.SQLite.BusyTimeout)
+	})
+
+	t.Run("explicit values override profile ones", func(t *testing.T) {
+		cfg := loadConfig(`
+db:
+  dialect: pgx
+  profile: batch
+  maxOpenConns: 3
+  postgres:
+    txLevel: Serializable
+    additionalParameters:
+      statement_timeout: "60000"
+`)
+		require.Equal(t, 3, cfg.MaxOpenConns)
+		require.Equal(t, 2, cfg.MaxIdleConns)
+		require.Equal(t, config.TimeDuration(time.Hour), cfg.ConnMaxLifetime)
+		require.Equal(t, IsolationLevel(sql.LevelSerializable), cfg.Postgres.TxIsolationLevel)
+		require.Equal(t, "60000", cfg.Postgres.AdditionalParameters[PgStatementTimeout])
+	})
+
+	t.Run("apply profile", func(t *testing.T) {
+		cfg := NewDefaultConfig([]Dialect{DialectMySQL})
+		cfg.Dialect = DialectMySQL
+		require.NoError(t, cfg.ApplyProfile(ProfileBatch))
+		require.Equal(t, ProfileBatch, cfg.Profile)
+		require.Equal(t, 5, cfg.MaxOpenConns)
+		require.Equal(t, IsolationLevel(sql.LevelRepeatableRead), cfg.MySQL.TxIsolationLevel)
+
+		require.EqualError(t, cfg.ApplyProfile("unknown"), `unknown profile "unknown" for mysql dialect`)
+	})
+}
+
 func mustYAMLToJSON(yamlData []byte) []byte {
 	var yamlMap map[string]interface{}
 	if err := yaml.Unmarshal(yamlData, &yamlMap); err != nil {
@@ -0,0 +1,351 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/acronis/go-appkit/config"
+)
+
+// Validate checks the configuration and returns all found problems joined into one error (see errors.Join),
+// each of them is prefixed with the corresponding configuration key (e.g., "db.postgres.port: ...").
+// It checks required fields, port ranges, SSL modes, isolation levels and mutually exclusive options,
+// so a bad configuration is reported at startup instead of surfacing as a cryptic driver error at connect time.
+// It's useful when the configuration is filled manually or unmarshaled from YAML/JSON bypassing config.Loader
+// (that performs some of these checks in Set).
+func (c *Config) Validate() error {
+	v := newConfigValidator(c.KeyPrefix() + ".")
+
+	if c.Dialect == "" {
+		v.addErr(cfgKeyDialect, fmt.Errorf("must be specified"))
+	} else if !c.isDialectSupported(c.Dialect) {
+		v.addErr(cfgKeyDialect, fmt.Errorf("unknown value %q, should be one of %v", c.Dialect, c.SupportedDialects()))
+	}
+	if c.Profile != "" && !isKnownProfile(c.Profile) {
+		v.addErr(cfgKeyProfile, fmt.Errorf("unknown value %q, should be one of %v", c.Profile, Profiles()))
+	}
+	if c.MaxOpenConns < 0 {
+		v.addErr(cfgKeyMaxOpenConns, fmt.Errorf("must be positive"))
+	}
+	if c.MaxIdleConns < 0 {
+		v.addErr(cfgKeyMaxIdleConns, fmt.Errorf("must be positive"))
+	}
+	if c.MaxIdleConns > 0 && c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		v.addErr(cfgKeyMaxIdleConns, fmt.Errorf("must be less than %s", cfgKeyMaxOpenConns))
+	}
+	if c.ConnMaxLifetime < 0 {
+		v.addErr(cfgKeyConnMaxLifetime, fmt.Errorf("must be positive"))
+	}
+	if c.StatementTimeout < 0 {
+		v.addErr(cfgKeyStatementTimeout, fmt.Errorf("must be positive"))
+	}
+	c.Instrumentation.validate(v.sub("instrumentation"))
+	c.Retry.validate(v.sub("retry"))
+
+	switch c.Dialect {
+	case DialectMySQL:
+		c.MySQL.validate(v.sub("mysql"))
+	case DialectSQLite:
+		c.SQLite.validate(v.sub("sqlite3"))
+	case DialectPostgres, DialectPgx:
+		c.Postgres.validate(v.sub("postgres"), c.Dialect)
+	case DialectMSSQL:
+		c.MSSQL.validate(v.sub("mssql"))
+	}
+	return v.err()
+}
+
+// Validate checks the MySQL configuration (see Config.Validate).
+func (c *MySQLConfig) Validate() error {
+	v := newConfigValidator("")
+	c.validate(v)
+	return v.err()
+}
+
+func (c *MySQLConfig) validate(v *configValidator) {
+	validateServerAddress(v, c.Host, c.Port)
+	if c.User == "" {
+		v.addErr("user", fmt.Errorf("must be specified"))
+	} else if strings.Contains(c.User, ":") {
+		v.addErr("user", fmt.Errorf("must not contain ':'"))
+	}
+	validateIsolationLevel(v, "txLevel", c.TxIsolationLevel)
+	if err := checkMySQLParamNames(c.AdditionalParameters); err != nil {
+		v.addErr("additionalParameters", err)
+	}
+}
+
+// Validate checks the MSSQL configuration (see Config.Validate).
+func (c *MSSQLConfig) Validate() error {
+	v := newConfigValidator("")
+	c.validate(v)
+	return v.err()
+}
+
+func (c *MSSQLConfig) validate(v *configValidator) {
+	validateServerAddress(v, c.Host, c.Port)
+	if c.User == "" && !c.AzureAD.Enabled() {
+		v.addErr("user", fmt.Errorf("must be specified"))
+	}
+	validateIsolationLevel(v, "txLevel", c.TxIsolationLevel)
+	c.AzureAD.validate(v.sub("azureAD"))
+}
+
+func (c *MSSQLAzureADConfig) validate(v *configValidator) {
+	switch c.Method {
+	case "":
+	case MSSQLAzureADAuthServicePrincipal:
+		if c.TenantID == "" {
+			v.addErr("tenantID", fmt.Errorf("must be specified for %s method", c.Method))
+		}
+		if c.ClientID == "" {
+			v.addErr("clientID", fmt.Errorf("must be specified for %s method", c.Method))
+		}
+		if c.ClientSecret == "" {
+			v.addErr("clientSecret", fmt.Errorf("must be specified for %s method", c.Method))
+		}
+	case MSSQLAzureADAuthManagedIdentity:
+		if c.ClientSecret != "" {
+			v.addErr("clientSecret", fmt.Errorf("must not be set for %s method", c.Method))
+		}
+	default:
+		v.addErr("method", fmt.Errorf("unknown value %q, should be one of %v", c.Method,
+			[]MSSQLAzureADAuthMethod{MSSQLAzureADAuthServicePrincipal, MSSQLAzureADAuthManagedIdentity}))
+	}
+}
+
+// Validate checks the instrumentation configuration (see Config.Validate).
+func (c *InstrumentationConfig) Validate() error {
+	v := newConfigValidator("")
+	c.validate(v)
+	return v.err()
+}
+
+func (c *InstrumentationConfig) validate(v *configValidator) {
+	if c.TracingSampleRate < 0 || c.TracingSampleRate > 1 {
+		v.addErr("tracingSampleRate", fmt.Errorf("must be in the [0, 1] range"))
+	}
+	if c.SlowQueryThreshold < 0 {
+		v.addErr("slowQueryThreshold", fmt.Errorf("must be positive"))
+	}
+	if c.SlowQueryLogSampleRate < 0 || c.SlowQueryLogSampleRate > 1 {
+		v.addErr("slowQueryLogSampleRate", fmt.Errorf("must be in the [0, 1] range"))
+	}
+	switch c.QueryRedaction {
+	case "", QueryRedactionFull, QueryRedactionMask, QueryRedactionNone:
+	default:
+		v.addErr("queryRedaction", fmt.Errorf("unknown value %q, should be one of %v", c.QueryRedaction,
+			[]QueryRedactionMode{QueryRedactionFull, QueryRedactionMask, QueryRedactionNone}))
+	}
+}
+
+// Validate checks the retry configuration (see Config.Validate).
+func (c *RetryConfig) Validate() error {
+	v := newConfigValidator("")
+	c.validate(v)
+	return v.err()
+}
+
+func (c *RetryConfig) validate(v *configValidator) {
+	switch c.Policy {
+	case "", RetryPolicyTypeNone, RetryPolicyTypeConstant, RetryPolicyTypeExponential:
+	default:
+		v.addErr("policy", fmt.Errorf("unknown value %q, should be one of %v", c.Policy, RetryPolicyTypes()))
+	}
+	if c.MaxAttempts < 0 {
+		v.addErr("maxAttempts", fmt.Errorf("must be positive"))
+	}
+	if c.InitialInterval < 0 {
+		v.addErr("initialInterval", fmt.Errorf("must be positive"))
+	}
+	if c.MaxInterval < 0 {
+		v.addErr("maxInterval", fmt.Errorf("must be positive"))
+	}
+	if c.MaxInterval > 0 && c.InitialInterval > c.MaxInterval {
+		v.addErr("maxInterval", fmt.Errorf("must not be less than initialInterval"))
+	}
+}
+
+// Validate checks the Postgres configuration (see Config.Validate).
+// Multiple hosts are allowed, since the dialect (only pgx supports them) is unknown here.
+func (c *PostgresConfig) Validate() error {
+	v := newConfigValidator("")
+	c.validate(v, DialectPgx)
+	return v.err()
+}
+
+func (c *PostgresConfig) validate(v *configValidator, dialect Dialect) {
+	if len(c.Hosts) == 0 {
+		validateServerAddress(v, c.Host, c.Port)
+	} else {
+		if len(c.Hosts) > 1 && dialect != DialectPgx {
+			v.addErr("hosts", fmt.Errorf("multiple hosts are supported only by %s dialect", DialectPgx))
+		}
+		for _, host := range c.Hosts {
+			if err := validatePostgresHost(host, len(c.Hosts) > 1); err != nil {
+				v.addErr("hosts", err)
+			}
+		}
+		if c.Port != 0 {
+			validatePort(v, c.Port)
+		}
+	}
+	if c.User == "" {
+		v.addErr("user", fmt.Errorf("must be specified"))
+	}
+	validateIsolationLevel(v, "txLevel", c.TxIsolationLevel)
+
+	sslFiles := []struct{ key, path string }{
+		{"sslCert", c.SSLCert}, {"sslKey", c.SSLKey}, {"sslRootCert", c.SSLRootCert},
+	}
+	switch c.SSLMode {
+	case "", PostgresSSLModeRequire, PostgresSSLModeVerifyCA, PostgresSSLModeVerifyFull:
+	case PostgresSSLModeDisable:
+		for _, f := range sslFiles {
+			if f.path != "" {
+				v.addErr(f.key, fmt.Errorf("must not be set when sslMode is %q", PostgresSSLModeDisable))
+			}
+		}
+	default:
+		v.addErr("sslMode", fmt.Errorf("unknown value %q, should be one of %v", c.SSLMode, []PostgresSSLMode{
+			PostgresSSLModeDisable, PostgresSSLModeRequire, PostgresSSLModeVerifyCA, PostgresSSLModeVerifyFull}))
+	}
+	// Client certificate and key are used for authentication together.
+	if c.SSLCert != "" && c.SSLKey == "" {
+		v.addErr("sslKey", fmt.Errorf("must be specified when sslCert is set"))
+	}
+	if c.SSLKey != "" && c.SSLCert == "" {
+		v.addErr("sslCert", fmt.Errorf("must be specified when sslKey is set"))
+	}
+	for _, f := range sslFiles {
+		if err := checkFileExists(f.path); err != nil {
+			v.addErr(f.key, err)
+		}
+	}
+}
+
+func validatePostgresHost(host string, multiHost bool) error {
+	if multiHost && isIPv6Host(host) {
+		return fmt.Errorf("IPv6 address %q is not supported in multi-host DSN", host)
+	}
+	h, portStr, err := net.SplitHostPort(host)
+	if err != nil {
+		h, portStr = host, "" // Port is omitted.
+	}
+	if h == "" {
+		return fmt.Errorf("host in %q must be specified", host)
+	}
+	if portStr != "" {
+		if port, convErr := strconv.Atoi(portStr); convErr != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("port in %q must be in range [1, 65535]", host)
+		}
+	}
+	return nil
+}
+
+// Validate checks the SQLite configuration (see Config.Validate).
+func (c *SQLiteConfig) Validate() error {
+	v := newConfigValidator("")
+	c.validate(v)
+	return v.err()
+}
+
+func (c *SQLiteConfig) validate(v *configValidator) {
+	if c.Path == "" {
+		v.addErr("path", fmt.Errorf("must be specified"))
+	}
+	switch c.JournalMode {
+	case "", SQLiteJournalModeDelete, SQLiteJournalModeTruncate, SQLiteJournalModePersist,
+		SQLiteJournalModeMemory, SQLiteJournalModeWAL, SQLiteJournalModeOff:
+	default:
+		v.addErr("journalMode", fmt.Errorf("unknown value %q", c.JournalMode))
+	}
+	if c.BusyTimeout < 0 {
+		v.addErr("busyTimeout", fmt.Errorf("must be positive"))
+	}
+	switch c.CacheMode {
+	case "", SQLiteCacheModeShared, SQLiteCacheModePrivate:
+	default:
+		v.addErr("cacheMode", fmt.Errorf("unknown value %q", c.CacheMode))
+	}
+}
+
+// configValidator collects validation errors wrapping them with the configuration keys.
+type configValidator struct {
+	keyPrefix string
+	errs      *[]error
+}
+
+func newConfigValidator(keyPrefix string) *configValidator {
+	return &configValidator{keyPrefix: keyPrefix, errs: new([]error)}
+}
+
+func (v *configValidator) addErr(key string, err error) {
+	*v.errs = append(*v.errs, config.WrapKeyErr(v.keyPrefix+key, err))
+}
+
+// sub returns a validator for the nested configuration that shares errors with the parent one.
+func (v *configValidator) sub(key string) *configValidator {
+	return &configValidator{keyPrefix: v.keyPrefix + key + ".", errs: v.errs}
+}
+
+func (v *configValidator) err() error {
+	return errors.Join(*v.errs...)
+}
+
+func validateServerAddress(v *configValidator, host string, port int) {
+	if host == "" {
+		v.addErr("host", fmt.Errorf("must be specified"))
+	}
+	validatePort(v, port)
+}
+
+func validatePort(v *configValidator, port int) {
+	if port < 1 || port > 65535 {
+		v.addErr("port", fmt.Errorf("must be in range [1, 65535]"))
+	}
+}
+
+func validateIsolationLevel(v *configValidator, key string, level IsolationLevel) {
+	if sql.IsolationLevel(level) == sql.LevelDefault {
+		return
+	}
+	if _, ok := availableTxIsolationLevelsMap[level.String()]; !ok {
+		v.addErr(key, fmt.Errorf("unsupported isolation level %s", level))
+	}
+}
+
+// checkFileExists checks that the file exists (if the path is not empty) and is not a directory.
+func checkFileExists(path string) error {
+	if path == "" {
+		return nil
+	}
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if fileInfo.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return nil
+}
+
+func (c *Config) isDialectSupported(dialect Dialect) bool {
+	for _, d := range c.SupportedDialects() {
+		if d == dialect {
+			return true
+		}
+	}
+	return false
+}
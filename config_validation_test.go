@@ -0,0 +1,214 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigValidate(t *testing.T) {
+	certsDir := t.TempDir()
+	certPath := filepath.Join(certsDir, "client.crt")
+	require.NoError(t, os.WriteFile(certPath, []byte("cert"), 0o600))
+
+	validPostgres := PostgresConfig{Host: "pg-host", Port: 5432, User: "user", SSLMode: PostgresSSLModeVerifyFull}
+
+	tests := []struct {
+		name           string
+		cfg            *Config
+		wantErrStrings []string
+	}{
+		{
+			name: "valid mysql",
+			cfg: &Config{
+				Dialect:      DialectMySQL,
+				MaxOpenConns: 10,
+				MaxIdleConns: 2,
+				MySQL:        MySQLConfig{Host: "mysql-host", Port: 3306, User: "user", TxIsolationLevel: IsolationLevel(sql.LevelRepeatableRead)},
+			},
+		},
+		{
+			name: "valid pgx with multiple hosts",
+			cfg: &Config{
+				Dialect:  DialectPgx,
+				Postgres: PostgresConfig{Hosts: []string{"pg-1:5432", "pg-2"}, Port: 5432, User: "user", SSLCert: certPath, SSLKey: certPath},
+			},
+		},
+		{
+			name: "valid sqlite",
+			cfg:  &Config{Dialect: DialectSQLite, SQLite: SQLiteConfig{Path: ":memory:", JournalMode: SQLiteJournalModeWAL}},
+		},
+		{
+			name: "missing dialect and bad pool settings",
+			cfg:  &Config{MaxOpenConns: 2, MaxIdleConns: 5, ConnMaxLifetime: -1, Profile: "huge"},
+			wantErrStrings: []string{
+				"db.dialect: must be specified",
+				`db.profile: unknown value "huge", should be one of [oltp-small oltp-large batch]`,
+				"db.maxIdleConns: must be less than maxOpenConns",
+				"db.connMaxLifeTime: must be positive",
+			},
+		},
+		{
+			name: "unsupported dialect",
+			cfg:  &Config{Dialect: "oracle"},
+			wantErrStrings: []string{
+				`db.dialect: unknown value "oracle", should be one of [sqlite3 mysql postgres pgx mssql]`,
+			},
+		},
+		{
+			name: "mysql required fields",
+			cfg: &Config{
+				Dialect: DialectMySQL,
+				MySQL: MySQLConfig{Port: 70000, User: "us:er", TxIsolationLevel: IsolationLevel(sql.LevelSnapshot),
+					AdditionalParameters: map[string]string{"a=b": "c"}},
+			},
+			wantErrStrings: []string{
+				"db.mysql.host: must be specified",
+				"db.mysql.port: must be in range [1, 65535]",
+				"db.mysql.user: must not contain ':'",
+				"db.mysql.txLevel: unsupported isolation level Snapshot",
+				`db.mysql.additionalParameters: invalid parameter name "a=b", it must be non-empty and must not contain '&' and '='`,
+			},
+		},
+		{
+			name: "mssql required fields",
+			cfg:  &Config{Dialect: DialectMSSQL, MSSQL: MSSQLConfig{Host: "mssql-host"}},
+			wantErrStrings: []string{
+				"db.mssql.port: must be in range [1, 65535]",
+				"db.mssql.user: must be specified",
+			},
+		},
+		{
+			name: "mssql Azure AD service principal",
+			cfg: &Config{Dialect: DialectMSSQL, MSSQL: MSSQLConfig{Host: "mssql-host", Port: 1433,
+				AzureAD: MSSQLAzureADConfig{Method: MSSQLAzureADAuthServicePrincipal, ClientID: "client"}}},
+			wantErrStrings: []string{
+				"db.mssql.azureAD.tenantID: must be specified for service-principal method",
+				"db.mssql.azureAD.clientSecret: must be specified for service-principal method",
+			},
+		},
+		{
+			name: "mssql Azure AD managed identity",
+			cfg: &Config{Dialect: DialectMSSQL, MSSQL: MSSQLConfig{Host: "mssql-host", Port: 1433,
+				AzureAD: MSSQLAzureADConfig{Method: MSSQLAzureADAuthManagedIdentity, ClientSecret: "secret"}}},
+			wantErrStrings: []string{
+				"db.mssql.azureAD.clientSecret: must not be set for managed-identity method",
+			},
+		},
+		{
+			name: "postgres multiple hosts are not supported by lib/pq",
+			cfg: func() *Config {
+				pgCfg := validPostgres
+				pgCfg.Hosts = []string{"pg-1", "[::1]:5432", ":5433", "pg-4:0"}
+				return &Config{Dialect: DialectPostgres, Postgres: pgCfg}
+			}(),
+			wantErrStrings: []string{
+				"db.postgres.hosts: multiple hosts are supported only by pgx dialect",
+				`db.postgres.hosts: IPv6 address "[::1]:5432" is not supported in multi-host DSN`,
+				`db.postgres.hosts: host in ":5433" must be specified`,
+				`db.postgres.hosts: port in "pg-4:0" must be in range [1, 65535]`,
+			},
+		},
+		{
+			name: "postgres ssl options",
+			cfg: func() *Config {
+				pgCfg := validPostgres
+				pgCfg.SSLMode = PostgresSSLModeDisable
+				pgCfg.SSLCert = certPath
+				pgCfg.SSLRootCert = certsDir
+				return &Config{Dialect: DialectPostgres, Postgres: pgCfg}
+			}(),
+			wantErrStrings: []string{
+				`db.postgres.sslCert: must not be set when sslMode is "disable"`,
+				`db.postgres.sslRootCert: must not be set when sslMode is "disable"`,
+				"db.postgres.sslKey: must be specified when sslCert is set",
+				"db.postgres.sslRootCert: " + certsDir + " is a directory",
+			},
+		},
+		{
+			name: "postgres unknown ssl mode",
+			cfg: func() *Config {
+				pgCfg := validPostgres
+				pgCfg.SSLMode = "prefer"
+				return &Config{Dialect: DialectPgx, Postgres: pgCfg}
+			}(),
+			wantErrStrings: []string{
+				`db.postgres.sslMode: unknown value "prefer", should be one of [disable require verify-ca verify-full]`,
+			},
+		},
+		{
+			name: "instrumentation",
+			cfg: &Config{Dialect: DialectSQLite, SQLite: SQLiteConfig{Path: ":memory:"}, Instrumentation: InstrumentationConfig{
+				TracingSampleRate: -0.1, SlowQueryThreshold: -1, SlowQueryLogSampleRate: 2, QueryRedaction: "partial"}},
+			wantErrStrings: []string{
+				"db.instrumentation.tracingSampleRate: must be in the [0, 1] range",
+				"db.instrumentation.slowQueryThreshold: must be positive",
+				"db.instrumentation.slowQueryLogSampleRate: must be in the [0, 1] range",
+				`db.instrumentation.queryRedaction: unknown value "partial", should be one of [full mask none]`,
+			},
+		},
+		{
+			name: "retry",
+			cfg: &Config{Dialect: DialectSQLite, SQLite: SQLiteConfig{Path: ":memory:"}, Retry: RetryConfig{
+				Policy: "linear", MaxAttempts: -1, InitialInterval: config.TimeDuration(time.Second),
+				MaxInterval: config.TimeDuration(time.Millisecond)}},
+			wantErrStrings: []string{
+				`db.retry.policy: unknown value "linear", should be one of [none constant exponential]`,
+				"db.retry.maxAttempts: must be positive",
+				"db.retry.maxInterval: must not be less than initialInterval",
+			},
+		},
+		{
+			name: "sqlite",
+			cfg:  &Config{Dialect: DialectSQLite, SQLite: SQLiteConfig{JournalMode: "wal2", BusyTimeout: -1, CacheMode: "none"}},
+			wantErrStrings: []string{
+				"db.sqlite3.path: must be specified",
+				`db.sqlite3.journalMode: unknown value "wal2"`,
+				"db.sqlite3.busyTimeout: must be positive",
+				`db.sqlite3.cacheMode: unknown value "none"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if len(tt.wantErrStrings) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, strings.Join(tt.wantErrStrings, "\n"))
+		})
+	}
+}
+
+func TestConfigValidate_KeyPrefix(t *testing.T) {
+	cfg := NewConfig(nil, WithKeyPrefix("reports.db"))
+	cfg.Dialect = DialectMSSQL
+	cfg.MSSQL = MSSQLConfig{Host: "mssql-host", Port: 1433}
+	require.EqualError(t, cfg.Validate(), "reports.db.mssql.user: must be specified")
+
+	// Dialect sub-configs may be validated separately.
+	require.EqualError(t, cfg.MSSQL.Validate(), "user: must be specified")
+	require.NoError(t, (&PostgresConfig{Hosts: []string{"pg-1", "pg-2"}, User: "user"}).Validate())
+}
+
+func TestConfigValidate_LoadedDefaults(t *testing.T) {
+	cfg := NewDefaultConfig(nil)
+	cfg.Dialect = DialectPostgres
+	cfg.Postgres.Host = "pg-host"
+	cfg.Postgres.Port = 5432
+	cfg.Postgres.User = "user"
+	require.NoError(t, cfg.Validate())
+}
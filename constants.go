@@ -36,6 +36,12 @@ const PgTargetSessionAttrs = "target_session_attrs"
 // PgReadWriteParam read-write session attribute value name
 const PgReadWriteParam = "read-write"
 
+// PgAnyParam any session attribute value name
+const PgAnyParam = "any"
+
+// PgStatementTimeout statement timeout parameter name
+const PgStatementTimeout = "statement_timeout"
+
 // Dialect defines possible values for planned supported SQL dialects.
 type Dialect string
 
@@ -58,3 +64,51 @@ const (
 	PostgresSSLModeVerifyCA   PostgresSSLMode = "verify-ca"
 	PostgresSSLModeVerifyFull PostgresSSLMode = "verify-full"
 )
+
+// MSSQLAzureADAuthMethod defines possible methods of Azure Active Directory (Microsoft Entra ID) authentication for MSSQL.
+type MSSQLAzureADAuthMethod string
+
+// MSSQL Azure AD authentication methods.
+const (
+	// MSSQLAzureADAuthServicePrincipal authenticates as the application (service principal) with the client secret.
+	MSSQLAzureADAuthServicePrincipal MSSQLAzureADAuthMethod = "service-principal"
+	// MSSQLAzureADAuthManagedIdentity authenticates with the managed identity of the Azure resource
+	// (system-assigned, or user-assigned if the client ID is specified).
+	MSSQLAzureADAuthManagedIdentity MSSQLAzureADAuthMethod = "managed-identity"
+)
+
+// QueryRedactionMode defines how the text of SQL queries is exposed by the instrumentation (logs, traces).
+type QueryRedactionMode string
+
+// Query redaction modes.
+const (
+	// QueryRedactionFull hides the query text completely, only the annotation of the query is exposed.
+	// It's used by default (when the mode is empty).
+	QueryRedactionFull QueryRedactionMode = "full"
+	// QueryRedactionMask exposes the query text with masked literal values (numbers, strings, placeholders).
+	QueryRedactionMask QueryRedactionMode = "mask"
+	// QueryRedactionNone exposes the query text as is.
+	QueryRedactionNone QueryRedactionMode = "none"
+)
+
+// SQLiteJournalMode defines possible values for SQLite journal_mode pragma.
+type SQLiteJournalMode string
+
+// SQLite journal modes.
+const (
+	SQLiteJournalModeDelete   SQLiteJournalMode = "DELETE"
+	SQLiteJournalModeTruncate SQLiteJournalMode = "TRUNCATE"
+	SQLiteJournalModePersist  SQLiteJournalMode = "PERSIST"
+	SQLiteJournalModeMemory   SQLiteJournalMode = "MEMORY"
+	SQLiteJournalModeWAL      SQLiteJournalMode = "WAL"
+	SQLiteJournalModeOff      SQLiteJournalMode = "OFF"
+)
+
+// SQLiteCacheMode defines possible values for SQLite cache URI parameter.
+type SQLiteCacheMode string
+
+// SQLite cache modes.
+const (
+	SQLiteCacheModeShared  SQLiteCacheMode = "shared"
+	SQLiteCacheModePrivate SQLiteCacheMode = "private"
+)
@@ -0,0 +1,106 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"time"
+)
+
+// RotatingCredentialsConnector implements driver.Connector and takes the password from Config.PasswordProvider
+// for each new connection, so rotating the database password (or using short-lived tokens as passwords)
+// doesn't require restarting the service or recreating *sql.DB.
+// Existing connections keep working with the old password until the pool recycles them
+// (see Config.ConnMaxLifetime), that's why the old password should stay valid for a while after rotation.
+// The connector of the underlying driver is reopened only when the DSN changes.
+type RotatingCredentialsConnector struct {
+	cfg    Config
+	driver driver.Driver
+
+	mu        sync.Mutex
+	dsn       string
+	connector driver.Connector
+}
+
+var _ driver.Connector = (*RotatingCredentialsConnector)(nil)
+
+// NewRotatingCredentialsConnector creates a new RotatingCredentialsConnector for the configuration.
+// The password is taken from the provider right away, so an error is returned if it's unavailable.
+func NewRotatingCredentialsConnector(ctx context.Context, cfg *Config) (*RotatingCredentialsConnector, error) {
+	driverName, dsn, err := cfg.ResolveDriverNameAndDSN(ctx)
+	if err != nil {
+		return nil, err
+	}
+	connector, err := openConnector(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &RotatingCredentialsConnector{cfg: *cfg, driver: connector.Driver(), dsn: dsn, connector: connector}, nil
+}
+
+// OpenWithCredentialRotation opens a new database connection pool using the provided configuration (see Open),
+// taking the password from Config.PasswordProvider for each new connection (see RotatingCredentialsConnector).
+// If Config.StatementTimeout is set, each statement is bounded with it (see StatementTimeoutConnector).
+func OpenWithCredentialRotation(cfg *Config, ping bool, options ...OpenOption) (*sql.DB, error) {
+	rotatingConnector, err := NewRotatingCredentialsConnector(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	var connector driver.Connector = rotatingConnector
+	if cfg.StatementTimeout > 0 {
+		connector = NewStatementTimeoutConnector(connector, time.Duration(cfg.StatementTimeout))
+	}
+	db := sql.OpenDB(connector)
+	return db, InitOpenedDB(db, cfg, ping, options...)
+}
+
+// Connect establishes a new connection with the current password.
+func (c *RotatingCredentialsConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	connector, err := c.getConnector(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+// Driver returns the underlying driver.
+func (c *RotatingCredentialsConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Unwrap returns the connector of the underlying driver for the current DSN.
+func (c *RotatingCredentialsConnector) Unwrap() driver.Connector {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connector
+}
+
+func (c *RotatingCredentialsConnector) getConnector(ctx context.Context) (driver.Connector, error) {
+	_, dsn, err := c.cfg.ResolveDriverNameAndDSN(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if dsn == c.dsn {
+		return c.connector, nil
+	}
+	var connector driver.Connector = dsnConnector{dsn: dsn, driver: c.driver}
+	if driverCtx, ok := c.driver.(driver.DriverContext); ok {
+		if connector, err = driverCtx.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+	c.dsn = dsn
+	c.connector = connector
+	return connector, nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingCredentialsConnector(t *testing.T) {
+	ctx := context.Background()
+	passwords := []string{"initial", "rotated", "rotated"}
+	var providerCalls int
+	cfg := &Config{
+		Dialect: DialectMySQL,
+		MySQL:   MySQLConfig{Host: "127.0.0.1", Port: 1, User: "user", Database: "db"}, // Nothing listens on the port.
+		PasswordProvider: PasswordProviderFunc(func(ctx context.Context) (string, error) {
+			if providerCalls == len(passwords) {
+				return "", errors.New("vault is sealed")
+			}
+			providerCalls++
+			return passwords[providerCalls-1], nil
+		}),
+	}
+
+	connector, err := NewRotatingCredentialsConnector(ctx, cfg)
+	require.NoError(t, err)
+	require.Equal(t, 1, providerCalls)
+	require.Contains(t, connector.dsn, ":initial@")
+	initialConnector := connector.Unwrap()
+
+	db := sql.OpenDB(connector)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	// Each new connection takes the current password, the driver connector is reopened only if it's changed.
+	require.Error(t, db.PingContext(ctx))
+	require.Equal(t, 2, providerCalls)
+	require.Contains(t, connector.dsn, ":rotated@")
+	rotatedConnector := connector.Unwrap()
+	require.NotSame(t, initialConnector, rotatedConnector)
+
+	require.Error(t, db.PingContext(ctx))
+	require.Equal(t, 3, providerCalls)
+	require.Same(t, rotatedConnector, connector.Unwrap())
+
+	require.ErrorContains(t, db.PingContext(ctx), "vault is sealed")
+	require.Equal(t, connector.Driver(), initialConnector.Driver())
+}
+
+func TestOpenWithCredentialRotation(t *testing.T) {
+	cfg := &Config{
+		Dialect:          DialectSQLite,
+		SQLite:           SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")},
+		StatementTimeout: config.TimeDuration(100 * time.Millisecond),
+	}
+	db, err := OpenWithCredentialRotation(cfg, true)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	// The statement is bounded by Config.StatementTimeout even if the context has no deadline.
+	var count int64
+	require.Error(t, db.QueryRowContext(context.Background(), infiniteQuery).Scan(&count))
+}
@@ -0,0 +1,12 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package datagen provides a generator that fills tables with fake data for load testing.
+// Data is described by a declarative spec: number of rows per table and a value generator per column
+// (sequences, uniform and normal distributions, weighted choices, nullable values, etc.).
+// Foreign key consistency is kept with Ref generator that picks values already generated for the referenced table.
+// Rows are inserted in batches using multi-row INSERT statements built by the dbr query builder.
+package datagen
@@ -0,0 +1,159 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package datagen
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/gocraft/dbr/v2"
+)
+
+// DefaultBatchSize is the default number of rows inserted by a single INSERT statement.
+const DefaultBatchSize = 500
+
+// Spec is a declarative description of the data to be generated.
+// Tables are filled in the specified order, so referenced tables should be specified before referencing ones.
+type Spec struct {
+	// Seed is used for the random generator, so the same spec produces the same data.
+	Seed int64
+	// BatchSize is the number of rows inserted by a single INSERT statement. DefaultBatchSize is used if it's 0.
+	BatchSize int
+	Tables    []TableSpec
+}
+
+// TableSpec describes the data generated for a table.
+type TableSpec struct {
+	Name    string
+	Rows    int
+	Columns []ColumnSpec
+}
+
+// ColumnSpec describes the data generated for a column.
+type ColumnSpec struct {
+	Name      string
+	Generator ValueGenerator
+}
+
+// GenContext is passed to value generators.
+type GenContext struct {
+	Rand *rand.Rand
+	// Row is the zero-based index of the generated row in the table.
+	Row int
+
+	refs map[refKey][]interface{}
+}
+
+// Ref returns a random value from the values that were generated for the column of the referenced table.
+// Values are tracked only for the columns that are referenced by Ref generators in the spec.
+func (gc *GenContext) Ref(table, column string) (interface{}, error) {
+	values := gc.refs[refKey{table, column}]
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no values were generated for column %s of table %s", column, table)
+	}
+	return values[gc.Rand.Intn(len(values))], nil
+}
+
+// Stats contains statistics of the data generation.
+type Stats struct {
+	// RowsByTable contains the number of inserted rows per table.
+	RowsByTable map[string]int
+	// Batches is the total number of executed INSERT statements.
+	Batches int
+}
+
+type refKey struct {
+	table  string
+	column string
+}
+
+// Generate generates the data according to the spec and inserts it into the database.
+// Runner may be dbr.Session or dbr.Tx (the latter makes generation atomic).
+func Generate(ctx context.Context, runner dbr.SessionRunner, spec Spec) (Stats, error) {
+	batchSize := spec.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	// Values are kept only for the columns that are referenced by other tables.
+	refs := make(map[refKey][]interface{})
+	for _, table := range spec.Tables {
+		for _, col := range table.Columns {
+			if ref, ok := findRef(col.Generator); ok {
+				refs[refKey{ref.table, ref.column}] = nil
+			}
+		}
+	}
+
+	gc := &GenContext{Rand: newRand(spec.Seed), refs: refs}
+	stats := Stats{RowsByTable: make(map[string]int, len(spec.Tables))}
+	for _, table := range spec.Tables {
+		if err := generateTable(ctx, runner, gc, table, batchSize, &stats); err != nil {
+			return stats, fmt.Errorf("generate data for table %s: %w", table.Name, err)
+		}
+	}
+	return stats, nil
+}
+
+func generateTable(
+	ctx context.Context, runner dbr.SessionRunner, gc *GenContext, table TableSpec, batchSize int, stats *Stats,
+) error {
+	columns := make([]string, len(table.Columns))
+	for i, col := range table.Columns {
+		columns[i] = col.Name
+	}
+
+	var stmt *dbr.InsertStmt
+	var stmtRows int
+	flush := func() error {
+		if stmtRows == 0 {
+			return nil
+		}
+		if _, err := stmt.ExecContext(ctx); err != nil {
+			return err
+		}
+		stats.RowsByTable[table.Name] += stmtRows
+		stats.Batches++
+		stmtRows = 0
+		return nil
+	}
+
+	for row := 0; row < table.Rows; row++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		gc.Row = row
+		values := make([]interface{}, len(table.Columns))
+		for i, col := range table.Columns {
+			val, err := col.Generator.Generate(gc)
+			if err != nil {
+				return fmt.Errorf("generate value for column %s: %w", col.Name, err)
+			}
+			values[i] = val
+			key := refKey{table.Name, col.Name}
+			if refValues, ok := gc.refs[key]; ok && val != nil {
+				gc.refs[key] = append(refValues, val)
+			}
+		}
+		if stmtRows == 0 {
+			stmt = runner.InsertInto(table.Name).Columns(columns...)
+		}
+		stmt.Values(values...)
+		stmtRows++
+		if stmtRows == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+func newRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed)) //nolint:gosec // Fake data doesn't need crypto rand.
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package datagen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+const sqlCreateTables = `
+CREATE TABLE tenants (id INTEGER PRIMARY KEY, name TEXT NOT NULL, kind TEXT NOT NULL);
+CREATE TABLE users (
+	id INTEGER PRIMARY KEY,
+	tenant_id INTEGER NOT NULL REFERENCES tenants(id),
+	email TEXT NOT NULL UNIQUE,
+	age INTEGER,
+	created_at DATETIME NOT NULL
+);`
+
+func TestGenerate(t *testing.T) {
+	dbConn, err := dbr.Open("sqlite3", ":memory:", nil)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, dbConn.Close()) }()
+	dbConn.SetMaxOpenConns(1)
+	_, err = dbConn.Exec(sqlCreateTables)
+	require.NoError(t, err)
+
+	now := time.Now()
+	spec := Spec{
+		Seed:      42,
+		BatchSize: 30,
+		Tables: []TableSpec{
+			{Name: "tenants", Rows: 10, Columns: []ColumnSpec{
+				{Name: "id", Generator: Sequence(1)},
+				{Name: "name", Generator: String(5, 10)},
+				{Name: "kind", Generator: Weighted(WeightedValue{"partner", 1}, WeightedValue{"customer", 9})},
+			}},
+			{Name: "users", Rows: 100, Columns: []ColumnSpec{
+				{Name: "id", Generator: Sequence(1)},
+				{Name: "tenant_id", Generator: Ref("tenants", "id")},
+				{Name: "email", Generator: Format("user-%d@example.com")},
+				{Name: "age", Generator: Nullable(0.2, Round(Normal(35, 10)))},
+				{Name: "created_at", Generator: TimeRange(now.Add(-time.Hour), now)},
+			}},
+		},
+	}
+
+	stats, err := Generate(context.Background(), dbConn.NewSession(nil), spec)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"tenants": 10, "users": 100}, stats.RowsByTable)
+	require.Equal(t, 5, stats.Batches) // 1 batch for tenants and 4 batches for users.
+
+	var orphans int
+	require.NoError(t, dbConn.QueryRow(
+		`SELECT COUNT(*) FROM users LEFT JOIN tenants ON tenants.id = users.tenant_id WHERE tenants.id IS NULL`,
+	).Scan(&orphans))
+	require.Equal(t, 0, orphans)
+
+	var nullAges int
+	require.NoError(t, dbConn.QueryRow(`SELECT COUNT(*) FROM users WHERE age IS NULL`).Scan(&nullAges))
+	require.Greater(t, nullAges, 0)
+	require.Less(t, nullAges, 50)
+
+	t.Run("referenced table must be generated first", func(t *testing.T) {
+		_, err := Generate(context.Background(), dbConn.NewSession(nil), Spec{Tables: []TableSpec{
+			{Name: "users", Rows: 1, Columns: []ColumnSpec{{Name: "tenant_id", Generator: Ref("unknown", "id")}}},
+		}})
+		require.ErrorContains(t, err, "no values were generated for column id of table unknown")
+	})
+}
+
+func TestGeneratorsAreDeterministic(t *testing.T) {
+	generate := func() []interface{} {
+		gc := &GenContext{Rand: newRand(7)}
+		var values []interface{}
+		for _, gen := range []ValueGenerator{IntRange(1, 100), Zipf(1.5, 1, 1000), OneOf("a", "b", "c"), String(1, 8)} {
+			val, err := gen.Generate(gc)
+			require.NoError(t, err)
+			values = append(values, val)
+		}
+		return values
+	}
+	require.Equal(t, generate(), generate())
+}
@@ -0,0 +1,229 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package datagen
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ValueGenerator generates values for a column.
+type ValueGenerator interface {
+	Generate(gc *GenContext) (interface{}, error)
+}
+
+// GeneratorFunc is an adapter to allow the use of ordinary functions as ValueGenerator.
+type GeneratorFunc func(gc *GenContext) (interface{}, error)
+
+// Generate implements ValueGenerator interface.
+func (f GeneratorFunc) Generate(gc *GenContext) (interface{}, error) {
+	return f(gc)
+}
+
+// Sequence returns the generator of sequential integers starting from start (e.g., for primary keys).
+func Sequence(start int64) ValueGenerator {
+	return GeneratorFunc(func(gc *GenContext) (interface{}, error) {
+		return start + int64(gc.Row), nil
+	})
+}
+
+// Constant returns the generator that always returns the same value.
+func Constant(value interface{}) ValueGenerator {
+	return GeneratorFunc(func(gc *GenContext) (interface{}, error) {
+		return value, nil
+	})
+}
+
+// IntRange returns the generator of uniformly distributed integers in [min, max].
+func IntRange(min, max int64) ValueGenerator {
+	return GeneratorFunc(func(gc *GenContext) (interface{}, error) {
+		if max < min {
+			return nil, fmt.Errorf("max (%d) is less than min (%d)", max, min)
+		}
+		return min + gc.Rand.Int63n(max-min+1), nil
+	})
+}
+
+// Normal returns the generator of normally distributed floats with the specified mean and standard deviation.
+func Normal(mean, stdDev float64) ValueGenerator {
+	return GeneratorFunc(func(gc *GenContext) (interface{}, error) {
+		return gc.Rand.NormFloat64()*stdDev + mean, nil
+	})
+}
+
+// Zipf returns the generator of integers in [0, imax] distributed according to Zipf's law
+// (a few values are very frequent and most values are rare), which is typical for real-world data
+// like tenants' sizes. s should be > 1 and v should be >= 1.
+func Zipf(s, v float64, imax uint64) ValueGenerator {
+	return GeneratorFunc(func(gc *GenContext) (interface{}, error) {
+		if s <= 1 || v < 1 {
+			return nil, fmt.Errorf("invalid zipf parameters: s=%f, v=%f", s, v)
+		}
+		return int64(rand.NewZipf(gc.Rand, s, v, imax).Uint64()), nil
+	})
+}
+
+// OneOf returns the generator that picks one of the values with equal probability.
+func OneOf(values ...interface{}) ValueGenerator {
+	return GeneratorFunc(func(gc *GenContext) (interface{}, error) {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("no values to pick from")
+		}
+		return values[gc.Rand.Intn(len(values))], nil
+	})
+}
+
+// WeightedValue is a value with its weight for Weighted generator.
+type WeightedValue struct {
+	Value  interface{}
+	Weight float64
+}
+
+// Weighted returns the generator that picks one of the values with probability proportional to its weight.
+func Weighted(values ...WeightedValue) ValueGenerator {
+	var total float64
+	for _, v := range values {
+		total += v.Weight
+	}
+	return GeneratorFunc(func(gc *GenContext) (interface{}, error) {
+		if total <= 0 {
+			return nil, fmt.Errorf("total weight should be positive")
+		}
+		r := gc.Rand.Float64() * total
+		for _, v := range values {
+			if r < v.Weight {
+				return v.Value, nil
+			}
+			r -= v.Weight
+		}
+		return values[len(values)-1].Value, nil
+	})
+}
+
+const alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// String returns the generator of random alphanumeric strings with length in [minLen, maxLen].
+func String(minLen, maxLen int) ValueGenerator {
+	return GeneratorFunc(func(gc *GenContext) (interface{}, error) {
+		if maxLen < minLen || minLen < 0 {
+			return nil, fmt.Errorf("invalid string length range [%d, %d]", minLen, maxLen)
+		}
+		b := make([]byte, minLen+gc.Rand.Intn(maxLen-minLen+1))
+		for i := range b {
+			b[i] = alphanumeric[gc.Rand.Intn(len(alphanumeric))]
+		}
+		return string(b), nil
+	})
+}
+
+// Format returns the generator of strings formatted (see fmt.Sprintf) with the row index,
+// e.g. Format("user-%d@example.com") produces unique emails.
+func Format(format string) ValueGenerator {
+	return GeneratorFunc(func(gc *GenContext) (interface{}, error) {
+		return fmt.Sprintf(format, gc.Row), nil
+	})
+}
+
+// TimeRange returns the generator of uniformly distributed times in [from, to).
+func TimeRange(from, to time.Time) ValueGenerator {
+	return GeneratorFunc(func(gc *GenContext) (interface{}, error) {
+		d := to.Sub(from)
+		if d <= 0 {
+			return nil, fmt.Errorf("empty time range [%s, %s)", from, to)
+		}
+		return from.Add(time.Duration(gc.Rand.Int63n(int64(d)))), nil
+	})
+}
+
+// Nullable returns the generator that returns nil with the specified probability
+// and delegates to the passed generator otherwise.
+func Nullable(nullProbability float64, gen ValueGenerator) ValueGenerator {
+	return nullableGenerator{nullProbability: nullProbability, gen: gen}
+}
+
+type nullableGenerator struct {
+	nullProbability float64
+	gen             ValueGenerator
+}
+
+// Generate implements ValueGenerator interface.
+func (g nullableGenerator) Generate(gc *GenContext) (interface{}, error) {
+	if gc.Rand.Float64() < g.nullProbability {
+		return nil, nil
+	}
+	return g.gen.Generate(gc)
+}
+
+func (g nullableGenerator) unwrap() ValueGenerator {
+	return g.gen
+}
+
+// Round returns the generator that rounds float values of the passed generator to the integer.
+// It may be used with Normal generator for integer columns.
+func Round(gen ValueGenerator) ValueGenerator {
+	return roundGenerator{gen: gen}
+}
+
+type roundGenerator struct {
+	gen ValueGenerator
+}
+
+// Generate implements ValueGenerator interface.
+func (g roundGenerator) Generate(gc *GenContext) (interface{}, error) {
+	val, err := g.gen.Generate(gc)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := val.(float64)
+	if !ok {
+		return nil, fmt.Errorf("float64 value is expected, got %T", val)
+	}
+	return int64(math.Round(f)), nil
+}
+
+func (g roundGenerator) unwrap() ValueGenerator {
+	return g.gen
+}
+
+// wrappingGenerator is implemented by generators that delegate to another generator.
+// It's used to find Ref generators in the spec.
+type wrappingGenerator interface {
+	unwrap() ValueGenerator
+}
+
+type refGenerator struct {
+	table  string
+	column string
+}
+
+// Ref returns the generator that picks a random value from the values generated for the column of the referenced table.
+// It keeps foreign keys consistent. Referenced table should be specified in the spec before the referencing one.
+func Ref(table, column string) ValueGenerator {
+	return refGenerator{table: table, column: column}
+}
+
+// Generate implements ValueGenerator interface.
+func (g refGenerator) Generate(gc *GenContext) (interface{}, error) {
+	return gc.Ref(g.table, g.column)
+}
+
+// findRef returns the Ref generator (if any) that is used by the passed generator directly or via wrappers.
+func findRef(gen ValueGenerator) (refGenerator, bool) {
+	for gen != nil {
+		if ref, ok := gen.(refGenerator); ok {
+			return ref, true
+		}
+		w, ok := gen.(wrappingGenerator)
+		if !ok {
+			break
+		}
+		gen = w.unwrap()
+	}
+	return refGenerator{}, false
+}
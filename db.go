@@ -9,39 +9,136 @@ package dbkit
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"time"
 
+	"github.com/acronis/go-appkit/log"
 	"github.com/acronis/go-appkit/retry"
+	"github.com/cenkalti/backoff/v4"
 )
 
+type openOptions struct {
+	pingTimeout     time.Duration
+	pingRetryPolicy retry.Policy
+	pingRetryLogger log.FieldLogger
+}
+
+// OpenOption is a functional option for Open and InitOpenedDB.
+type OpenOption func(*openOptions)
+
+// WithPingTimeout sets the timeout of each ping attempt (if ping is enabled). There is no timeout by default,
+// so opening may hang when the database host is unreachable.
+func WithPingTimeout(timeout time.Duration) OpenOption {
+	return func(opts *openOptions) {
+		opts.pingTimeout = timeout
+	}
+}
+
+// WithPingRetry makes opening wait until the database is ready: a failed ping is retried according to the policy
+// (e.g., retry.NewExponentialBackoffPolicy) until it succeeds or the policy is exhausted.
+// It's useful for services that may be started before the database is up.
+// Each failed attempt is logged with the logger (may be nil).
+func WithPingRetry(policy retry.Policy, logger log.FieldLogger) OpenOption {
+	return func(opts *openOptions) {
+		opts.pingRetryPolicy = policy
+		opts.pingRetryLogger = logger
+	}
+}
+
 // Open opens a new database connection using the provided configuration.
-// If ping is true, it will check the connection by sending a ping to the database.
-func Open(cfg *Config, ping bool) (*sql.DB, error) {
-	driver, dsn := cfg.DriverNameAndDSN()
-	db, err := sql.Open(driver, dsn)
+// If ping is true, it will check the connection by sending a ping to the database
+// (see WithPingTimeout and WithPingRetry options).
+// If Config.StatementTimeout is set, each statement is bounded with it (see StatementTimeoutConnector).
+func Open(cfg *Config, ping bool, options ...OpenOption) (*sql.DB, error) {
+	var db *sql.DB
+	if cfg.StatementTimeout > 0 {
+		connector, err := OpenConnector(cfg)
+		if err != nil {
+			return nil, err
+		}
+		db = sql.OpenDB(connector)
+	} else {
+		driverName, dsn, err := cfg.ResolveDriverNameAndDSN(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		if db, err = sql.Open(driverName, dsn); err != nil {
+			return nil, err
+		}
+	}
+	return db, InitOpenedDB(db, cfg, ping, options...)
+}
+
+// OpenConnector returns the driver.Connector for the configuration without establishing connections
+// (the password is taken from Config.PasswordProvider if it's specified).
+// The connector is wrapped with StatementTimeoutConnector if Config.StatementTimeout is set.
+// It's useful for decorating the connector (e.g., with RowsMetricsConnector or sqllog.Connector)
+// before passing it to sql.OpenDB and InitOpenedDB.
+func OpenConnector(cfg *Config) (driver.Connector, error) {
+	driverName, dsn, err := cfg.ResolveDriverNameAndDSN(context.Background())
 	if err != nil {
 		return nil, err
 	}
-	return db, InitOpenedDB(db, cfg, ping)
+	connector, err := openConnector(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.StatementTimeout > 0 {
+		return NewStatementTimeoutConnector(connector, time.Duration(cfg.StatementTimeout)), nil
+	}
+	return connector, nil
 }
 
 // InitOpenedDB initializes early opened *sql.DB instance.
-func InitOpenedDB(db *sql.DB, cfg *Config, ping bool) error {
+func InitOpenedDB(db *sql.DB, cfg *Config, ping bool, options ...OpenOption) error {
 	db.SetMaxOpenConns(cfg.MaxOpenConns)
 	db.SetMaxIdleConns(cfg.MaxIdleConns)
 	db.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
 	if ping {
-		if err := db.Ping(); err != nil {
-			return err
+		var opts openOptions
+		for _, opt := range options {
+			opt(&opts)
 		}
+		return pingDB(db, opts)
 	}
 	return nil
 }
 
+func pingDB(db *sql.DB, opts openOptions) error {
+	doPing := func(ctx context.Context) error {
+		if opts.pingTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, opts.pingTimeout)
+			defer cancel()
+		}
+		return db.PingContext(ctx)
+	}
+	if opts.pingRetryPolicy == nil {
+		return doPing(context.Background())
+	}
+	attempt := 0
+	notify := func(err error, delay time.Duration) {
+		attempt++
+		if opts.pingRetryLogger != nil {
+			opts.pingRetryLogger.Warn("database is not ready, ping will be retried",
+				log.Int("attempt", attempt), log.Int64("backoff_ms", delay.Milliseconds()), log.Error(err))
+		}
+	}
+	return retry.DoWithRetry(context.Background(), opts.pingRetryPolicy, nil, notify, doPing)
+}
+
 type doInTxOptions struct {
-	txOpts      *sql.TxOptions
-	retryPolicy retry.Policy
+	txOpts                 *sql.TxOptions
+	retryPolicy            retry.Policy
+	classRetryPolicies     map[ErrorClass]retry.Policy
+	retryableRegistry      *RetryableRegistry
+	deadlineTimeoutDialect Dialect
+	deadlineTimeoutEnabled bool
+	retryLogger            log.FieldLogger
+	txAnnotation           string
+	errorMetrics           ErrorMetricsCollector
+	txMetrics              TxMetricsCollector
 }
 
 // DoInTxOption is a functional option for DoInTx.
@@ -61,26 +158,139 @@ func WithRetryPolicy(policy retry.Policy) DoInTxOption {
 	}
 }
 
+// WithRetryableRegistry sets the registry that is used by DoInTx to determine whether errors are retryable.
+// DefaultRetryableRegistry is used by default.
+func WithRetryableRegistry(registry *RetryableRegistry) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.retryableRegistry = registry
+	}
+}
+
+// WithClassRetryPolicy sets retry policy for DoInTx that is used for errors of the given class (see ClassifyError)
+// instead of the policy set by WithRetryPolicy. Errors of the class are retried even if they are not retryable
+// for the driver. Nil policy disables retries for the class.
+// It allows using different policies for different errors, e.g. fast retries for serialization failures,
+// slower ones with jitter for connection failures, and no retries for constraint violations.
+// Each class has its own attempts counter. Errors of the classes without the policy are handled
+// by the policy set by WithRetryPolicy (if any).
+func WithClassRetryPolicy(class ErrorClass, policy retry.Policy) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		if opts.classRetryPolicies == nil {
+			opts.classRetryPolicies = make(map[ErrorClass]retry.Policy)
+		}
+		opts.classRetryPolicies[class] = policy
+	}
+}
+
+// WithErrorMetrics sets the collector (e.g., PrometheusMetrics) that is used by DoInTx to count failed attempts
+// of the transaction by the error class. The annotation of the transaction (see WithTxAnnotation)
+// or the name stored in the context (see ContextWithTxName) is used as the query label.
+func WithErrorMetrics(collector ErrorMetricsCollector) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.errorMetrics = collector
+	}
+}
+
 // DoInTx begins a new transaction, calls passed function and do commit or rollback
 // depending on whether the function returns an error or not.
+// If ctx has a deadline, retries (see WithRetryPolicy and WithClassRetryPolicy) are stopped as soon as
+// the next backoff delay doesn't fit into the remaining time, and the error of the last attempt
+// is returned wrapped with ErrRetryDeadlineExceeded.
 func DoInTx(ctx context.Context, dbConn *sql.DB, fn func(tx *sql.Tx) error, options ...DoInTxOption) (err error) {
-	var opts doInTxOptions
+	opts := doInTxOptions{retryableRegistry: DefaultRetryableRegistry}
 	for _, opt := range options {
 		opt(&opts)
 	}
+	if len(opts.classRetryPolicies) != 0 {
+		return doInTxWithClassRetry(ctx, dbConn, fn, opts)
+	}
 	if opts.retryPolicy == nil {
-		return doInTx(ctx, dbConn, fn, opts.txOpts)
+		return doInTx(ctx, dbConn, fn, opts)
 	}
-	return retry.DoWithRetry(ctx, opts.retryPolicy, GetIsRetryable(dbConn.Driver()), nil, func(ctx context.Context) error {
-		return doInTx(ctx, dbConn, fn, opts.txOpts)
+	var notify backoff.Notify
+	if opts.retryLogger != nil || opts.txMetrics != nil {
+		attempt := 0
+		notify = func(err error, delay time.Duration) {
+			attempt++
+			notifyTxRetry(ctx, &opts, attempt, err, delay)
+		}
+	}
+	isRetryable := opts.retryableRegistry.GetIsRetryable(dbConn.Driver())
+	return doWithDeadlineAwareRetry(ctx, opts.retryPolicy, isRetryable, notify, func(ctx context.Context) error {
+		return doInTx(ctx, dbConn, fn, opts)
 	})
 }
 
-func doInTx(ctx context.Context, dbConn *sql.DB, fn func(tx *sql.Tx) error, txOpts *sql.TxOptions) (err error) {
+// defaultRetryPolicyClass is used as a key for the backoff of the policy set by WithRetryPolicy.
+const defaultRetryPolicyClass ErrorClass = "*"
+
+func doInTxWithClassRetry(ctx context.Context, dbConn *sql.DB, fn func(tx *sql.Tx) error, opts doInTxOptions) error {
+	isRetryable := opts.retryableRegistry.GetIsRetryable(dbConn.Driver())
+	backOffs := make(map[ErrorClass]backoff.BackOff)
+	for attempt := 1; ; attempt++ {
+		err := doInTx(ctx, dbConn, fn, opts)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		class := ClassifyError(err)
+		policy, ok := opts.classRetryPolicies[class]
+		if !ok {
+			if opts.retryPolicy == nil || !isRetryable(err) {
+				return err
+			}
+			class, policy = defaultRetryPolicyClass, opts.retryPolicy
+		}
+		if policy == nil {
+			return err
+		}
+		b, ok := backOffs[class]
+		if !ok {
+			b = policy.NewBackOff()
+			backOffs[class] = b
+		}
+		delay := b.NextBackOff()
+		if delay == backoff.Stop {
+			return err
+		}
+		if exceedsDeadline(ctx, delay) {
+			return wrapRetryDeadlineExceeded(err)
+		}
+		notifyTxRetry(ctx, &opts, attempt, err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func doInTx(ctx context.Context, dbConn *sql.DB, fn func(tx *sql.Tx) error, opts doInTxOptions) (err error) {
+	if opts.errorMetrics != nil {
+		defer func() {
+			if err != nil {
+				opts.errorMetrics.ObserveQueryError(opts.txName(ctx), err)
+			}
+		}()
+	}
 	var tx *sql.Tx
-	if tx, err = dbConn.BeginTx(ctx, txOpts); err != nil {
+	if tx, err = dbConn.BeginTx(ctx, opts.txOpts); err != nil {
 		return fmt.Errorf("begin tx: %w", err)
 	}
+	committed := false
+	if opts.txMetrics != nil {
+		txName, startedAt := opts.txName(ctx), time.Now()
+		defer func() { ObserveTx(opts.txMetrics, txName, startedAt, committed) }()
+	}
+	if opts.deadlineTimeoutEnabled {
+		if err = SetStatementTimeoutFromDeadline(ctx, tx, opts.deadlineTimeoutDialect); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
 	defer func() {
 		if p := recover(); p != nil {
 			_ = tx.Rollback()
@@ -92,7 +302,9 @@ func doInTx(ctx context.Context, dbConn *sql.DB, fn func(tx *sql.Tx) error, txOp
 		}
 		if err = tx.Commit(); err != nil {
 			err = fmt.Errorf("commit tx: %w", err)
+			return
 		}
+		committed = true
 	}()
 	return fn(tx)
 }
@@ -9,15 +9,20 @@ package dbkit
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/acronis/go-appkit/config"
+	"github.com/acronis/go-appkit/log/logtest"
 	"github.com/acronis/go-appkit/retry"
+	"github.com/acronis/go-appkit/testutil"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 )
 
@@ -78,6 +83,71 @@ func TestOpen(t *testing.T) {
 	}
 }
 
+type closableConn struct {
+	driver.Conn
+}
+
+func (closableConn) Close() error {
+	return nil
+}
+
+type startingDBConnector struct {
+	failedConnects int32
+	connects       atomic.Int32
+}
+
+func (c *startingDBConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if c.connects.Add(1) <= c.failedConnects {
+		return nil, errors.New("connection refused")
+	}
+	return closableConn{}, nil
+}
+
+func (c *startingDBConnector) Driver() driver.Driver {
+	return nil
+}
+
+type hangingConnector struct{}
+
+func (hangingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (hangingConnector) Driver() driver.Driver {
+	return nil
+}
+
+func TestInitOpenedDBPing(t *testing.T) {
+	cfg := &Config{MaxOpenConns: 1, MaxIdleConns: 1}
+
+	t.Run("ping timeout", func(t *testing.T) {
+		db := sql.OpenDB(hangingConnector{})
+		defer func() { _ = db.Close() }()
+		require.ErrorIs(t, InitOpenedDB(db, cfg, true, WithPingTimeout(time.Millisecond*50)), context.DeadlineExceeded)
+	})
+
+	t.Run("ping retry until database is ready", func(t *testing.T) {
+		connector := &startingDBConnector{failedConnects: 3}
+		db := sql.OpenDB(connector)
+		defer func() { _ = db.Close() }()
+		logRecorder := logtest.NewRecorder()
+		require.NoError(t, InitOpenedDB(db, cfg, true,
+			WithPingRetry(retry.NewConstantBackoffPolicy(time.Millisecond, 5), logRecorder)))
+		require.Equal(t, int32(4), connector.connects.Load())
+		require.Len(t, logRecorder.Entries(), 3)
+	})
+
+	t.Run("ping retry policy is exhausted", func(t *testing.T) {
+		connector := &startingDBConnector{failedConnects: 10}
+		db := sql.OpenDB(connector)
+		defer func() { _ = db.Close() }()
+		err := InitOpenedDB(db, cfg, true, WithPingRetry(retry.NewConstantBackoffPolicy(time.Millisecond, 2), nil))
+		require.EqualError(t, err, "connection refused")
+		require.Equal(t, int32(3), connector.connects.Load())
+	})
+}
+
 func TestDoInTx(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -166,6 +236,86 @@ func TestDoInTx(t *testing.T) {
 	}
 }
 
+func TestDoInTxWithErrorMetrics(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, mock.ExpectationsWereMet())
+	}()
+
+	metrics := NewPrometheusMetrics()
+	options := []DoInTxOption{WithErrorMetrics(metrics), WithTxAnnotation("create_user")}
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	require.NoError(t, DoInTx(context.Background(), db, func(tx *sql.Tx) error { return nil }, options...))
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	require.Error(t, DoInTx(context.Background(), db, func(tx *sql.Tx) error {
+		return fmt.Errorf("insert: %w", context.DeadlineExceeded)
+	}, options...))
+
+	mock.ExpectBegin().WillReturnError(errors.New("begin error"))
+	require.Error(t, DoInTx(context.Background(), db, func(tx *sql.Tx) error { return nil }, options...))
+
+	requireErrorsCount := func(class QueryErrorClass, want int) {
+		t.Helper()
+		testutil.RequireSamplesCountInCounter(t, metrics.QueryErrors.With(prometheus.Labels{
+			PrometheusMetricsLabelQuery: "create_user", PrometheusMetricsLabelErrorClass: string(class)}), want)
+	}
+	requireErrorsCount(QueryErrorClassTimeout, 1)
+	requireErrorsCount(QueryErrorClassOther, 1)
+	requireErrorsCount(QueryErrorClassConnection, 0)
+}
+
+func TestDoInTxWithTxMetrics(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, mock.ExpectationsWereMet())
+	}()
+
+	metrics := NewPrometheusMetrics()
+	ctx := ContextWithTxName(context.Background(), "create_user")
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	attempts := 0
+	require.NoError(t, DoInTx(ctx, db, func(tx *sql.Tx) error {
+		attempts++
+		if attempts == 1 {
+			return driver.ErrBadConn
+		}
+		return nil
+	}, WithTxMetrics(metrics), WithClassRetryPolicy(ErrorClassConnectionFailure, retry.NewConstantBackoffPolicy(time.Millisecond, 1))))
+
+	// Annotation set by the option takes precedence over the name from the context.
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	require.Error(t, DoInTx(ctx, db, func(tx *sql.Tx) error {
+		return errors.New("insert error")
+	}, WithTxMetrics(metrics), WithTxAnnotation("delete_user")))
+
+	labels := func(tx string) prometheus.Labels { return prometheus.Labels{PrometheusMetricsLabelTx: tx} }
+	testutil.RequireSamplesCountInHistogram(t, metrics.TxDurations.With(labels("create_user")).(prometheus.Histogram), 2)
+	testutil.RequireSamplesCountInCounter(t, metrics.TxCommits.With(labels("create_user")), 1)
+	testutil.RequireSamplesCountInCounter(t, metrics.TxRollbacks.With(labels("create_user")), 1)
+	testutil.RequireSamplesCountInCounter(t, metrics.TxRetries.With(labels("create_user")), 1)
+	testutil.RequireSamplesCountInCounter(t, metrics.TxRollbacks.With(labels("delete_user")), 1)
+	testutil.RequireSamplesCountInCounter(t, metrics.TxCommits.With(labels("delete_user")), 0)
+}
+
+func TestClassifyQueryError(t *testing.T) {
+	require.Equal(t, QueryErrorClass(""), ClassifyQueryError(nil))
+	require.Equal(t, QueryErrorClassTimeout, ClassifyQueryError(context.DeadlineExceeded))
+	require.Equal(t, QueryErrorClassConnection, ClassifyQueryError(driver.ErrBadConn))
+	require.Equal(t, QueryErrorClassOther, ClassifyQueryError(context.Canceled))
+	require.Equal(t, QueryErrorClassOther, ClassifyQueryError(errors.New("syntax error")))
+}
+
 func TestDoInTxWithRetryPolicy(t *testing.T) {
 	retryableError := errors.New("retryable error")
 
@@ -268,3 +418,78 @@ func TestDoInTxWithRetryPolicy(t *testing.T) {
 		})
 	}
 }
+
+func TestDoInTxWithClassRetryPolicy(t *testing.T) {
+	serializationErr := errors.New("serialization failure")
+	constraintErr := errors.New("constraint violation")
+	retryableErr := errors.New("retryable error")
+
+	prevClassifiers := errorClassifiers
+	defer func() { errorClassifiers = prevClassifiers }()
+	RegisterErrorClassifier(func(err error) ErrorClass {
+		switch {
+		case errors.Is(err, serializationErr):
+			return ErrorClassSerializationFailure
+		case errors.Is(err, constraintErr):
+			return ErrorClassConstraintViolation
+		}
+		return ErrorClassNone
+	})
+
+	runInTx := func(t *testing.T, errs []error, options ...DoInTxOption) (attempts int, err error) {
+		t.Helper()
+		db, mock, mockErr := sqlmock.New()
+		require.NoError(t, mockErr)
+		defer func() { _ = db.Close() }()
+
+		UnregisterAllIsRetryableFuncs(db.Driver())
+		RegisterIsRetryableFunc(db.Driver(), func(err error) bool {
+			return errors.Is(err, retryableErr) || errors.Is(err, constraintErr)
+		})
+		defer UnregisterAllIsRetryableFuncs(db.Driver())
+
+		for i := 0; i < len(errs)+1; i++ {
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+		}
+		err = DoInTx(context.Background(), db, func(tx *sql.Tx) error {
+			attempts++
+			if attempts > len(errs) {
+				return errors.New("unexpected attempt")
+			}
+			return errs[attempts-1]
+		}, options...)
+		return attempts, err
+	}
+
+	fastPolicy := retry.NewConstantBackoffPolicy(time.Millisecond, 2)
+	slowPolicy := retry.NewConstantBackoffPolicy(10*time.Millisecond, 1)
+
+	t.Run("class policy limits attempts", func(t *testing.T) {
+		attempts, err := runInTx(t, []error{serializationErr, serializationErr, serializationErr, serializationErr},
+			WithClassRetryPolicy(ErrorClassSerializationFailure, fastPolicy))
+		require.ErrorIs(t, err, serializationErr)
+		require.Equal(t, 3, attempts) // 1 initial + 2 retries.
+	})
+
+	t.Run("nil class policy disables retries", func(t *testing.T) {
+		attempts, err := runInTx(t, []error{constraintErr, constraintErr},
+			WithRetryPolicy(slowPolicy), WithClassRetryPolicy(ErrorClassConstraintViolation, nil))
+		require.ErrorIs(t, err, constraintErr)
+		require.Equal(t, 1, attempts)
+	})
+
+	t.Run("each class has own attempts counter", func(t *testing.T) {
+		attempts, err := runInTx(t, []error{serializationErr, retryableErr, serializationErr, retryableErr},
+			WithRetryPolicy(slowPolicy), WithClassRetryPolicy(ErrorClassSerializationFailure, fastPolicy))
+		require.ErrorIs(t, err, retryableErr)
+		require.Equal(t, 4, attempts) // The second retryable error exceeds the default policy.
+	})
+
+	t.Run("errors without class policy are not retried without default policy", func(t *testing.T) {
+		attempts, err := runInTx(t, []error{retryableErr, retryableErr},
+			WithClassRetryPolicy(ErrorClassSerializationFailure, fastPolicy))
+		require.ErrorIs(t, err, retryableErr)
+		require.Equal(t, 1, attempts)
+	})
+}
@@ -0,0 +1,223 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbrutil
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gocraft/dbr/v2"
+)
+
+// ErrQueryNotAllowed is returned when the query annotation is not in the allow-list and the allow-list is enforced.
+var ErrQueryNotAllowed = errors.New("query is not in the allow-list")
+
+// QueryAllowListMode defines how QueryAllowList handles executed queries.
+type QueryAllowListMode int
+
+// Query allow-list modes.
+const (
+	// QueryAllowListModeRecord records annotations of all executed queries, so they may be written into the manifest.
+	// It's intended to be used in the "compilation" run (e.g., integration tests) of the service.
+	QueryAllowListModeRecord QueryAllowListMode = iota
+	// QueryAllowListModeEnforce rejects queries with annotations that are not in the allow-list.
+	QueryAllowListModeEnforce
+)
+
+// QueryAllowListOpts contains options for QueryAllowList.
+type QueryAllowListOpts struct {
+	Mode               QueryAllowListMode
+	AnnotationPrefix   string
+	AnnotationModifier func(string) string
+	// AllowUnannotated allows queries without annotation in QueryAllowListModeEnforce mode.
+	AllowUnannotated bool
+}
+
+// QueryAllowList contains annotations of queries that the service is allowed to execute.
+// It helps security reviews of services with strict SQL surface requirements:
+// the manifest of all annotated queries is produced in QueryAllowListModeRecord mode
+// and may be enforced at runtime in QueryAllowListModeEnforce mode.
+type QueryAllowList struct {
+	opts    QueryAllowListOpts
+	mu      sync.RWMutex
+	allowed map[string]struct{}
+}
+
+// NewQueryAllowList creates a new QueryAllowList.
+func NewQueryAllowList(opts QueryAllowListOpts) *QueryAllowList {
+	return &QueryAllowList{opts: opts, allowed: make(map[string]struct{})}
+}
+
+// Register adds annotations to the allow-list.
+func (al *QueryAllowList) Register(annotations ...string) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	for _, annotation := range annotations {
+		al.allowed[annotation] = struct{}{}
+	}
+}
+
+// Annotations returns sorted list of all allowed (or recorded) annotations.
+func (al *QueryAllowList) Annotations() []string {
+	al.mu.RLock()
+	defer al.mu.RUnlock()
+	annotations := make([]string, 0, len(al.allowed))
+	for annotation := range al.allowed {
+		annotations = append(annotations, annotation)
+	}
+	sort.Strings(annotations)
+	return annotations
+}
+
+// WriteManifest writes all allowed (or recorded) annotations into the manifest (one annotation per line).
+func (al *QueryAllowList) WriteManifest(w io.Writer) error {
+	for _, annotation := range al.Annotations() {
+		if _, err := fmt.Fprintln(w, annotation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadManifest registers annotations from the manifest. Empty lines and lines starting with "#" are ignored.
+func (al *QueryAllowList) LoadManifest(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		al.Register(line)
+	}
+	return scanner.Err()
+}
+
+// Check checks the query according to the allow-list mode.
+// In QueryAllowListModeRecord mode, the annotation is recorded, and nil is always returned.
+// In QueryAllowListModeEnforce mode, error wrapping ErrQueryNotAllowed is returned if the annotation is unknown.
+func (al *QueryAllowList) Check(query string) error {
+	annotation := ParseAnnotationInQuery(query, al.opts.AnnotationPrefix, al.opts.AnnotationModifier)
+	if al.opts.Mode == QueryAllowListModeRecord {
+		if annotation != "" {
+			al.Register(annotation)
+		}
+		return nil
+	}
+	if annotation == "" {
+		if al.opts.AllowUnannotated {
+			return nil
+		}
+		return fmt.Errorf("%w: query is not annotated", ErrQueryNotAllowed)
+	}
+	al.mu.RLock()
+	_, ok := al.allowed[annotation]
+	al.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: unknown annotation %q", ErrQueryNotAllowed, annotation)
+	}
+	return nil
+}
+
+// AllowListSessionRunner wraps dbr.SessionRunner (dbr.Session or dbr.Tx)
+// and checks all queries built by it against QueryAllowList before execution.
+type AllowListSessionRunner struct {
+	dbr.SessionRunner
+	allowList *QueryAllowList
+}
+
+var _ dbr.SessionRunner = (*AllowListSessionRunner)(nil)
+
+// NewAllowListSessionRunner creates a new AllowListSessionRunner.
+func NewAllowListSessionRunner(runner dbr.SessionRunner, allowList *QueryAllowList) *AllowListSessionRunner {
+	return &AllowListSessionRunner{SessionRunner: runner, allowList: allowList}
+}
+
+// Select creates a SelectStmt.
+func (r *AllowListSessionRunner) Select(column ...string) *dbr.SelectStmt {
+	stmt := r.SessionRunner.Select(column...)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+// SelectBySql creates a SelectStmt from raw query.
+func (r *AllowListSessionRunner) SelectBySql(query string, value ...interface{}) *dbr.SelectStmt { //nolint:revive,stylecheck
+	stmt := r.SessionRunner.SelectBySql(query, value...)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+// InsertInto creates an InsertStmt.
+func (r *AllowListSessionRunner) InsertInto(table string) *dbr.InsertStmt {
+	stmt := r.SessionRunner.InsertInto(table)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+// InsertBySql creates an InsertStmt from raw query.
+func (r *AllowListSessionRunner) InsertBySql(query string, value ...interface{}) *dbr.InsertStmt { //nolint:revive,stylecheck
+	stmt := r.SessionRunner.InsertBySql(query, value...)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+// Update creates an UpdateStmt.
+func (r *AllowListSessionRunner) Update(table string) *dbr.UpdateStmt {
+	stmt := r.SessionRunner.Update(table)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+// UpdateBySql creates an UpdateStmt from raw query.
+func (r *AllowListSessionRunner) UpdateBySql(query string, value ...interface{}) *dbr.UpdateStmt { //nolint:revive,stylecheck
+	stmt := r.SessionRunner.UpdateBySql(query, value...)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+// DeleteFrom creates a DeleteStmt.
+func (r *AllowListSessionRunner) DeleteFrom(table string) *dbr.DeleteStmt {
+	stmt := r.SessionRunner.DeleteFrom(table)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+// DeleteBySql creates a DeleteStmt from raw query.
+func (r *AllowListSessionRunner) DeleteBySql(query string, value ...interface{}) *dbr.DeleteStmt { //nolint:revive,stylecheck
+	stmt := r.SessionRunner.DeleteBySql(query, value...)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+func (r *AllowListSessionRunner) wrapRunner(runner dbr.Runner) dbr.Runner {
+	return &allowListRunner{Runner: runner, allowList: r.allowList}
+}
+
+type allowListRunner struct {
+	dbr.Runner
+	allowList *QueryAllowList
+}
+
+func (r *allowListRunner) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if err := r.allowList.Check(query); err != nil {
+		return nil, err
+	}
+	return r.Runner.ExecContext(ctx, query, args...)
+}
+
+func (r *allowListRunner) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if err := r.allowList.Check(query); err != nil {
+		return nil, err
+	}
+	return r.Runner.QueryContext(ctx, query, args...)
+}
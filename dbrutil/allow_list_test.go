@@ -0,0 +1,53 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbrutil
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryAllowList(t *testing.T) {
+	dbConn := openAndSeedDB(t)
+	defer func() {
+		require.NoError(t, dbConn.Close())
+	}()
+
+	// "Compilation" run records annotations of all executed queries into the manifest.
+	recordList := NewQueryAllowList(QueryAllowListOpts{Mode: QueryAllowListModeRecord, AnnotationPrefix: "query_"})
+	recordSess := NewAllowListSessionRunner(dbConn.NewSession(nil), recordList)
+	countUsersByName(t, recordSess, "query_count_users_by_name", "Sam", 2)
+	_, err := recordSess.InsertInto("users").Columns("name").Values("Alice").Comment("query_insert_user").Exec()
+	require.NoError(t, err)
+	countUsersByName(t, recordSess, "not_annotated", "Alice", 1)
+
+	var manifest bytes.Buffer
+	require.NoError(t, recordList.WriteManifest(&manifest))
+	require.Equal(t, "query_count_users_by_name\nquery_insert_user\n", manifest.String())
+
+	// Manifest is enforced at runtime.
+	enforceList := NewQueryAllowList(QueryAllowListOpts{Mode: QueryAllowListModeEnforce, AnnotationPrefix: "query_"})
+	require.NoError(t, enforceList.LoadManifest(strings.NewReader("# Allowed queries\n\n"+manifest.String())))
+	enforceSess := NewAllowListSessionRunner(dbConn.NewSession(nil), enforceList)
+	countUsersByName(t, enforceSess, "query_count_users_by_name", "Alice", 1)
+
+	var usersCount int
+	err = enforceSess.Select("COUNT(*)").From("users").Comment("query_count_users").LoadOne(&usersCount)
+	require.ErrorIs(t, err, ErrQueryNotAllowed)
+	err = enforceSess.Select("COUNT(*)").From("users").LoadOne(&usersCount)
+	require.ErrorIs(t, err, ErrQueryNotAllowed)
+	_, err = enforceSess.DeleteFrom("users").Where(dbr.Eq("name", "Alice")).Comment("query_delete_user").Exec()
+	require.ErrorIs(t, err, ErrQueryNotAllowed)
+
+	enforceList.Register("query_delete_user")
+	_, err = enforceSess.DeleteFrom("users").Where(dbr.Eq("name", "Alice")).Comment("query_delete_user").Exec()
+	require.NoError(t, err)
+}
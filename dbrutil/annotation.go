@@ -0,0 +1,263 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbrutil
+
+import (
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gocraft/dbr/v2"
+)
+
+// Operations that are used in automatically generated annotations.
+const (
+	AnnotationOperationSelect = "select"
+	AnnotationOperationInsert = "insert"
+	AnnotationOperationUpdate = "update"
+	AnnotationOperationDelete = "delete"
+)
+
+// AnnotatingSessionRunnerOpts contains options for AnnotatingSessionRunner.
+type AnnotatingSessionRunnerOpts struct {
+	// AnnotationPrefix is prepended to all generated annotations.
+	// It should be the same prefix that is used by QueryMetricsEventReceiver and SlowQueryLogEventReceiver.
+	AnnotationPrefix string
+
+	// MakeAnnotation allows overriding the default annotation format (<prefix><operation>_<table>).
+	MakeAnnotation func(prefix, operation, table string) string
+
+	// AnnotateWithCaller makes annotations from the name of the function that builds the statement
+	// in the <prefix><package>.<Function> format (e.g., "query_users.ListActive" or "query_users.Repo.ListActive")
+	// instead of the operation and the table. It also enables annotating statements created by Select and *BySql methods.
+	AnnotateWithCaller bool
+
+	// VersionStamp (e.g., made by MakeVersionStamp) is added to annotated statements as a separate comment,
+	// so DBAs inspecting server-side query logs can tell which deploy introduced a new query shape.
+	// Being a separate comment, it doesn't get into annotations used by metrics and slow query log.
+	VersionStamp string
+}
+
+// AnnotatingSessionRunner wraps dbr.SessionRunner (dbr.Session or dbr.Tx)
+// and automatically annotates built statements with a comment derived from the operation and the primary table name.
+// For example, InsertInto("users") is annotated as "<prefix>insert_users".
+// Since the table is unknown when Select is called, use SelectFrom to get annotated SELECT statements.
+// Statements created by Select and from raw SQL (*BySql methods) are annotated only if the name of the query
+// is known, i.e. it's set by WithName or derived from the caller (see AnnotatingSessionRunnerOpts.AnnotateWithCaller).
+type AnnotatingSessionRunner struct {
+	dbr.SessionRunner
+	opts AnnotatingSessionRunnerOpts
+	name string
+}
+
+var _ dbr.SessionRunner = (*AnnotatingSessionRunner)(nil)
+
+// NewAnnotatingSessionRunner creates a new AnnotatingSessionRunner.
+func NewAnnotatingSessionRunner(runner dbr.SessionRunner, opts AnnotatingSessionRunnerOpts) *AnnotatingSessionRunner {
+	if opts.MakeAnnotation == nil {
+		opts.MakeAnnotation = MakeAnnotation
+	}
+	return &AnnotatingSessionRunner{SessionRunner: runner, opts: opts}
+}
+
+// WithName returns a copy of the runner that annotates all statements with the given name
+// in the <prefix><name> format, regardless of the operation, the table and the caller.
+func (r *AnnotatingSessionRunner) WithName(name string) *AnnotatingSessionRunner {
+	return &AnnotatingSessionRunner{SessionRunner: r.SessionRunner, opts: r.opts, name: name}
+}
+
+// Select creates a SelectStmt and annotates it if the name of the query is known.
+func (r *AnnotatingSessionRunner) Select(column ...string) *dbr.SelectStmt {
+	stmt := r.SessionRunner.Select(column...)
+	if annotation := r.namedAnnotation(); annotation != "" {
+		stmt.Comment(annotation)
+		if r.opts.VersionStamp != "" {
+			stmt.Comment(r.opts.VersionStamp)
+		}
+	}
+	return stmt
+}
+
+// SelectBySql creates a SelectStmt from raw query and annotates it if the name of the query is known.
+func (r *AnnotatingSessionRunner) SelectBySql(query string, value ...interface{}) *dbr.SelectStmt { //nolint:revive,stylecheck // dbr naming.
+	return r.SessionRunner.SelectBySql(r.annotateRawQuery(r.namedAnnotation(), query), value...)
+}
+
+// InsertBySql creates an InsertStmt from raw query and annotates it if the name of the query is known.
+func (r *AnnotatingSessionRunner) InsertBySql(query string, value ...interface{}) *dbr.InsertStmt { //nolint:revive,stylecheck // dbr naming.
+	return r.SessionRunner.InsertBySql(r.annotateRawQuery(r.namedAnnotation(), query), value...)
+}
+
+// UpdateBySql creates an UpdateStmt from raw query and annotates it if the name of the query is known.
+func (r *AnnotatingSessionRunner) UpdateBySql(query string, value ...interface{}) *dbr.UpdateStmt { //nolint:revive,stylecheck // dbr naming.
+	return r.SessionRunner.UpdateBySql(r.annotateRawQuery(r.namedAnnotation(), query), value...)
+}
+
+// DeleteBySql creates a DeleteStmt from raw query and annotates it if the name of the query is known.
+func (r *AnnotatingSessionRunner) DeleteBySql(query string, value ...interface{}) *dbr.DeleteStmt { //nolint:revive,stylecheck // dbr naming.
+	return r.SessionRunner.DeleteBySql(r.annotateRawQuery(r.namedAnnotation(), query), value...)
+}
+
+// SelectFrom creates a SelectStmt for the table and annotates it.
+func (r *AnnotatingSessionRunner) SelectFrom(table string, column ...string) *dbr.SelectStmt {
+	stmt := r.SessionRunner.Select(column...).From(table).Comment(r.annotation(AnnotationOperationSelect, table))
+	if r.opts.VersionStamp != "" {
+		stmt.Comment(r.opts.VersionStamp)
+	}
+	return stmt
+}
+
+// InsertInto creates an annotated InsertStmt.
+func (r *AnnotatingSessionRunner) InsertInto(table string) *dbr.InsertStmt {
+	stmt := r.SessionRunner.InsertInto(table).Comment(r.annotation(AnnotationOperationInsert, table))
+	if r.opts.VersionStamp != "" {
+		stmt.Comment(r.opts.VersionStamp)
+	}
+	return stmt
+}
+
+// Update creates an annotated UpdateStmt.
+func (r *AnnotatingSessionRunner) Update(table string) *dbr.UpdateStmt {
+	stmt := r.SessionRunner.Update(table).Comment(r.annotation(AnnotationOperationUpdate, table))
+	if r.opts.VersionStamp != "" {
+		stmt.Comment(r.opts.VersionStamp)
+	}
+	return stmt
+}
+
+// DeleteFrom creates an annotated DeleteStmt.
+func (r *AnnotatingSessionRunner) DeleteFrom(table string) *dbr.DeleteStmt {
+	stmt := r.SessionRunner.DeleteFrom(table).Comment(r.annotation(AnnotationOperationDelete, table))
+	if r.opts.VersionStamp != "" {
+		stmt.Comment(r.opts.VersionStamp)
+	}
+	return stmt
+}
+
+func (r *AnnotatingSessionRunner) annotation(operation, table string) string {
+	if annotation := r.namedAnnotation(); annotation != "" {
+		return annotation
+	}
+	return r.opts.MakeAnnotation(r.opts.AnnotationPrefix, operation, table)
+}
+
+func (r *AnnotatingSessionRunner) namedAnnotation() string {
+	if r.name != "" {
+		return r.opts.AnnotationPrefix + r.name
+	}
+	if r.opts.AnnotateWithCaller {
+		if name := callerFuncName(); name != "" {
+			return r.opts.AnnotationPrefix + name
+		}
+	}
+	return ""
+}
+
+func (r *AnnotatingSessionRunner) annotateRawQuery(annotation, query string) string {
+	if annotation == "" {
+		return query
+	}
+	if r.opts.VersionStamp != "" {
+		query = "/* " + r.opts.VersionStamp + " */\n" + query
+	}
+	return "/* " + annotation + " */\n" + query
+}
+
+var annotatingSessionRunnerMethodPrefix = reflect.TypeOf(AnnotatingSessionRunner{}).PkgPath() + ".(*AnnotatingSessionRunner)."
+
+// callerFuncName returns the name of the first function in the stack that is not a method of AnnotatingSessionRunner
+// in the <package>.<Function> format (see FuncNameAnnotation).
+func callerFuncName() string {
+	var pcs [16]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, annotatingSessionRunnerMethodPrefix) {
+			return FuncNameAnnotation(frame.Function)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// FuncNameAnnotation converts the full name of the function (as returned by runtime.FuncForPC)
+// to the short <package>.<Function> form that is used by AnnotatingSessionRunner for annotations derived from the caller.
+// The import path is stripped, the receiver type is kept without pointer and parentheses, and closures are
+// attributed to the enclosing function (e.g., "github.com/org/app/users.(*Repo).List.func1" becomes "users.Repo.List").
+func FuncNameAnnotation(funcName string) string {
+	if idx := strings.LastIndexByte(funcName, '/'); idx != -1 {
+		funcName = funcName[idx+1:]
+	}
+	funcName = strings.NewReplacer("(*", "", "(", "", ")", "").Replace(funcName)
+	parts := strings.Split(funcName, ".")
+	for len(parts) > 2 && isClosureName(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, ".")
+}
+
+func isClosureName(name string) bool {
+	name = strings.TrimPrefix(name, "func")
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MakeAnnotation makes annotation in the default format: <prefix><operation>_<table>.
+// Schema qualifier, alias and quotes are stripped from the table name.
+func MakeAnnotation(prefix, operation, table string) string {
+	if fields := strings.Fields(table); len(fields) != 0 {
+		table = fields[0] // Strip alias ("users AS u" or "users u").
+	}
+	if idx := strings.LastIndexByte(table, '.'); idx != -1 {
+		table = table[idx+1:]
+	}
+	table = strings.Trim(table, "`\"[]")
+	return prefix + operation + "_" + table
+}
+
+// MakeVersionStamp makes a version stamp for AnnotatingSessionRunnerOpts.VersionStamp
+// in the "service=<name> version=<version> revision=<vcs revision>" format.
+// The version and the revision are taken from the build info of the running binary,
+// parts that are not available (e.g., the revision if the binary is built without VCS stamping) are omitted.
+func MakeVersionStamp(serviceName string) string {
+	var version, revision string
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		version = buildInfo.Main.Version
+		for _, setting := range buildInfo.Settings {
+			if setting.Key == "vcs.revision" {
+				revision = setting.Value
+			}
+		}
+	}
+	return makeVersionStamp(serviceName, version, revision)
+}
+
+func makeVersionStamp(serviceName, version, revision string) string {
+	parts := make([]string, 0, 3)
+	if serviceName != "" {
+		parts = append(parts, "service="+serviceName)
+	}
+	if version != "" && version != "(devel)" {
+		parts = append(parts, "version="+version)
+	}
+	if revision != "" {
+		if len(revision) > 12 {
+			revision = revision[:12]
+		}
+		parts = append(parts, "revision="+revision)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,147 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbrutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/acronis/go-appkit/testutil"
+	"github.com/gocraft/dbr/v2"
+	"github.com/gocraft/dbr/v2/dialect"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+func TestAnnotatingSessionRunner(t *testing.T) {
+	dbConn := openAndSeedDB(t)
+	defer func() {
+		require.NoError(t, dbConn.Close())
+	}()
+
+	mc := dbkit.NewPrometheusMetrics()
+	dbSess := NewAnnotatingSessionRunner(
+		dbConn.NewSession(NewQueryMetricsEventReceiver(mc, "query_")), AnnotatingSessionRunnerOpts{AnnotationPrefix: "query_"})
+
+	_, err := dbSess.InsertInto("users").Columns("name").Values("Alice").Exec()
+	require.NoError(t, err)
+	_, err = dbSess.Update("users").Set("name", "Alex").Where(dbr.Eq("name", "Alice")).Exec()
+	require.NoError(t, err)
+	var usersCount int
+	require.NoError(t, dbSess.SelectFrom("users", "COUNT(*)").Where(dbr.Eq("name", "Alex")).LoadOne(&usersCount))
+	require.Equal(t, 1, usersCount)
+	_, err = dbSess.DeleteFrom("users").Where(dbr.Eq("name", "Alex")).Exec()
+	require.NoError(t, err)
+
+	for _, query := range []string{"query_insert_users", "query_update_users", "query_select_users", "query_delete_users"} {
+		labels := prometheus.Labels{dbkit.PrometheusMetricsLabelQuery: query}
+		hist := mc.QueryDurations.With(labels).(prometheus.Histogram)
+		testutil.RequireSamplesCountInHistogram(t, hist, 1)
+	}
+}
+
+func TestMakeAnnotation(t *testing.T) {
+	tests := []struct {
+		table string
+		want  string
+	}{
+		{table: "users", want: "query_select_users"},
+		{table: "public.users", want: "query_select_users"},
+		{table: `"users" AS u`, want: "query_select_users"},
+		{table: "`app`.`users` u", want: "query_select_users"},
+		{table: "[dbo].[users]", want: "query_select_users"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, MakeAnnotation("query_", AnnotationOperationSelect, tt.table))
+	}
+}
+
+func TestAnnotatingSessionRunner_VersionStamp(t *testing.T) {
+	dbConn := openAndSeedDB(t)
+	defer func() {
+		require.NoError(t, dbConn.Close())
+	}()
+
+	dbSess := NewAnnotatingSessionRunner(dbConn.NewSession(nil), AnnotatingSessionRunnerOpts{
+		AnnotationPrefix: "query_",
+		VersionStamp:     "service=app version=v1.2.3",
+	})
+	buf := dbr.NewBuffer()
+	require.NoError(t, dbSess.InsertInto("users").Columns("name").Values("Alice").Build(dialect.SQLite3, buf))
+	query := buf.String()
+	require.Contains(t, query, "/* query_insert_users */\n/* service=app version=v1.2.3 */\n")
+	require.Equal(t, "query_insert_users", ParseAnnotationInQuery(query, "query_", nil))
+
+	_, err := dbSess.InsertInto("users").Columns("name").Values("Alice").Exec()
+	require.NoError(t, err)
+}
+
+func TestMakeVersionStamp(t *testing.T) {
+	require.Equal(t, "service=app version=v1.2.3 revision=0123456789ab",
+		makeVersionStamp("app", "v1.2.3", "0123456789abcdef0123456789abcdef01234567"))
+	require.Equal(t, "service=app", makeVersionStamp("app", "(devel)", ""))
+	require.Equal(t, "version=v1.2.3", makeVersionStamp("", "v1.2.3", ""))
+	require.True(t, strings.HasPrefix(MakeVersionStamp("app"), "service=app"))
+}
+
+func TestAnnotatingSessionRunner_NameAndCaller(t *testing.T) {
+	dbConn := openAndSeedDB(t)
+	defer func() {
+		require.NoError(t, dbConn.Close())
+	}()
+
+	build := func(stmt dbr.Builder) string {
+		t.Helper()
+		buf := dbr.NewBuffer()
+		require.NoError(t, stmt.Build(dialect.SQLite3, buf))
+		return ParseAnnotationInQuery(buf.String(), "query_", nil)
+	}
+
+	dbSess := NewAnnotatingSessionRunner(dbConn.NewSession(nil), AnnotatingSessionRunnerOpts{AnnotationPrefix: "query_"})
+	require.Equal(t, "", build(dbSess.Select("COUNT(*)").From("users")))
+	require.Equal(t, "", build(dbSess.SelectBySql("SELECT COUNT(*) FROM users")))
+
+	named := dbSess.WithName("count_users")
+	require.Equal(t, "query_count_users", build(named.Select("COUNT(*)").From("users")))
+	require.Equal(t, "query_count_users", build(named.SelectFrom("users", "COUNT(*)")))
+	require.Equal(t, "query_count_users", build(named.SelectBySql("SELECT COUNT(*) FROM users")))
+	require.Equal(t, "query_count_users", build(named.DeleteBySql("DELETE FROM users")))
+	require.Equal(t, "query_insert_users", build(dbSess.InsertInto("users").Columns("name").Values("Alice")))
+
+	callerSess := NewAnnotatingSessionRunner(dbConn.NewSession(nil), AnnotatingSessionRunnerOpts{
+		AnnotationPrefix: "query_", AnnotateWithCaller: true, VersionStamp: "service=app",
+	})
+	wantAnnotation := "query_dbrutil.TestAnnotatingSessionRunner_NameAndCaller"
+	require.Equal(t, wantAnnotation, build(callerSess.InsertInto("users").Columns("name").Values("Alice")))
+	require.Equal(t, wantAnnotation, build(callerSess.UpdateBySql("UPDATE users SET name = 'Alex'")))
+	func() {
+		require.Equal(t, wantAnnotation, build(callerSess.Select("COUNT(*)").From("users")))
+	}()
+	require.Equal(t, "query_count_users", build(callerSess.WithName("count_users").Update("users").Set("name", "Alex")))
+
+	var usersCount int
+	require.NoError(t, callerSess.SelectBySql("SELECT COUNT(*) FROM users").LoadOne(&usersCount))
+}
+
+func TestFuncNameAnnotation(t *testing.T) {
+	tests := []struct {
+		funcName string
+		want     string
+	}{
+		{funcName: "github.com/org/app/users.ListActive", want: "users.ListActive"},
+		{funcName: "github.com/org/app/users.(*Repo).ListActive", want: "users.Repo.ListActive"},
+		{funcName: "github.com/org/app/users.Repo.ListActive.func1", want: "users.Repo.ListActive"},
+		{funcName: "github.com/org/app/users.ListActive.func1.2", want: "users.ListActive"},
+		{funcName: "main.main", want: "main.main"},
+		{funcName: "main.func1", want: "main.func1"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, FuncNameAnnotation(tt.funcName))
+	}
+}
@@ -7,6 +7,8 @@ Released under MIT license.
 package dbrutil
 
 import (
+	"context"
+
 	"github.com/gocraft/dbr/v2"
 )
 
@@ -16,6 +18,8 @@ type CompositeEventReceiver struct {
 	Receivers []dbr.EventReceiver
 }
 
+var _ dbr.TracingEventReceiver = (*CompositeEventReceiver)(nil)
+
 // NewCompositeReceiver creates a new CompositeEventReceiver.
 func NewCompositeReceiver(receivers []dbr.EventReceiver) *CompositeEventReceiver {
 	return &CompositeEventReceiver{receivers}
@@ -37,18 +41,22 @@ func (r *CompositeEventReceiver) EventKv(eventName string, kvs map[string]string
 }
 
 // EventErr receives a notification of an error if one occurs.
+// Error returned by each receiver in composition is passed to the next one (so receivers may wrap it),
+// and the last one is returned.
 func (r *CompositeEventReceiver) EventErr(eventName string, err error) error {
 	for _, recv := range r.Receivers {
-		_ = recv.EventErr(eventName, err)
+		err = recv.EventErr(eventName, err)
 	}
 	return err
 }
 
 // EventErrKv receives a notification of an error if one occurs along with
 // optional key/value data.
+// Error returned by each receiver in composition is passed to the next one (so receivers may wrap it),
+// and the last one is returned.
 func (r *CompositeEventReceiver) EventErrKv(eventName string, err error, kvs map[string]string) error {
 	for _, recv := range r.Receivers {
-		_ = recv.EventErrKv(eventName, err, kvs)
+		err = recv.EventErrKv(eventName, err, kvs)
 	}
 	return err
 }
@@ -67,3 +75,34 @@ func (r *CompositeEventReceiver) TimingKv(eventName string, nanoseconds int64, k
 		recv.TimingKv(eventName, nanoseconds, kvs)
 	}
 }
+
+// SpanStart is called before the query execution. It calls SpanStart for each receiver
+// in composition that implements dbr.TracingEventReceiver, passing the context returned by the previous one.
+func (r *CompositeEventReceiver) SpanStart(ctx context.Context, eventName, query string) context.Context {
+	for _, recv := range r.Receivers {
+		if tracingRecv, ok := recv.(dbr.TracingEventReceiver); ok {
+			ctx = tracingRecv.SpanStart(ctx, eventName, query)
+		}
+	}
+	return ctx
+}
+
+// SpanError is called when the query execution fails.
+// It calls SpanError for each receiver in composition that implements dbr.TracingEventReceiver.
+func (r *CompositeEventReceiver) SpanError(ctx context.Context, err error) {
+	for _, recv := range r.Receivers {
+		if tracingRecv, ok := recv.(dbr.TracingEventReceiver); ok {
+			tracingRecv.SpanError(ctx, err)
+		}
+	}
+}
+
+// SpanFinish is called after the query execution.
+// It calls SpanFinish for each receiver in composition that implements dbr.TracingEventReceiver.
+func (r *CompositeEventReceiver) SpanFinish(ctx context.Context) {
+	for _, recv := range r.Receivers {
+		if tracingRecv, ok := recv.(dbr.TracingEventReceiver); ok {
+			tracingRecv.SpanFinish(ctx)
+		}
+	}
+}
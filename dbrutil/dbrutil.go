@@ -24,15 +24,19 @@ import (
 )
 
 // Open opens database (using dbr query builder) with specified configuration parameters
-// and verifies (if ping argument is true) that connection can be established.
-func Open(cfg *dbkit.Config, ping bool, eventReceiver dbr.EventReceiver) (*dbr.Connection, error) {
-	driver, dsn := cfg.DriverNameAndDSN()
+// and verifies (if ping argument is true) that connection can be established
+// (see dbkit.WithPingTimeout and dbkit.WithPingRetry options).
+func Open(cfg *dbkit.Config, ping bool, eventReceiver dbr.EventReceiver, options ...dbkit.OpenOption) (*dbr.Connection, error) {
+	driver, dsn, err := cfg.ResolveDriverNameAndDSN(context.Background())
+	if err != nil {
+		return nil, err
+	}
 	conn, err := dbr.Open(driver, dsn, eventReceiver)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := dbkit.InitOpenedDB(conn.DB, cfg, ping); err != nil {
+	if err := dbkit.InitOpenedDB(conn.DB, cfg, ping, options...); err != nil {
 		return nil, err
 	}
 
@@ -96,6 +100,10 @@ type TxRunner interface {
 type TxSession struct {
 	*dbr.Session
 	TxOpts *sql.TxOptions
+
+	// TxMetrics (e.g., dbkit.PrometheusMetrics) observes transactions executed with DoInTx if it's not nil.
+	// Metrics are labeled by the transaction name stored in the context (see dbkit.ContextWithTxName).
+	TxMetrics dbkit.TxMetricsCollector
 }
 
 // NewTxSession creates a new TxSession.
@@ -122,6 +130,7 @@ func (s *TxSession) BeginTx(ctx context.Context) (*dbr.Tx, error) {
 // DoInTx begins a new transaction, calls passed function and do commit or rollback
 // depending on whether the function returns an error or not.
 func (s *TxSession) DoInTx(ctx context.Context, fn func(runner dbr.SessionRunner) error) error {
+	txName := dbkit.TxNameFromContext(ctx)
 	if s.Connection.Dialect == dialect.SQLite3 {
 		// race of ctx cancel with transaction begin leads to 'cannot start a transaction within a transaction'
 		// https://github.com/mattn/go-sqlite3/pull/765
@@ -132,6 +141,11 @@ func (s *TxSession) DoInTx(ctx context.Context, fn func(runner dbr.SessionRunner
 		return &TxBeginError{err}
 	}
 
+	committed := false
+	if s.TxMetrics != nil {
+		startedAt := time.Now()
+		defer func() { dbkit.ObserveTx(s.TxMetrics, txName, startedAt, committed) }()
+	}
 	defer tx.RollbackUnlessCommitted()
 	if err := fn(tx); err != nil {
 		return err
@@ -140,6 +154,7 @@ func (s *TxSession) DoInTx(ctx context.Context, fn func(runner dbr.SessionRunner
 	if err := tx.Commit(); err != nil {
 		return &TxCommitError{err}
 	}
+	committed = true
 
 	return nil
 }
@@ -168,6 +183,19 @@ func NewRetryableTxRunner(conn *dbr.Connection, opts *sql.TxOptions, eventReceiv
 	}
 }
 
+// NewTxRunnerWithRetryConfig creates a new object of TxRunner with retries according to the configuration
+// (see dbkit.RetryConfig), so the organization-wide default policy may be set in the service configuration.
+// The returned runner doesn't retry transactions if retries are disabled by the configuration.
+func NewTxRunnerWithRetryConfig(
+	conn *dbr.Connection, opts *sql.TxOptions, eventReceiver dbr.EventReceiver, retryCfg *dbkit.RetryConfig,
+) TxRunner {
+	policy := retryCfg.NewPolicy()
+	if policy == nil {
+		return NewTxRunner(conn, opts, eventReceiver)
+	}
+	return NewRetryableTxRunner(conn, opts, eventReceiver, policy)
+}
+
 // RetryableTxSession is a wrapper around TxSession that makes transaction executed with DoInTx retryable.
 type RetryableTxSession struct {
 	TxSession
@@ -178,9 +206,17 @@ type RetryableTxSession struct {
 // DoInTx implements TxRunner.
 func (s *RetryableTxSession) DoInTx(ctx context.Context, fn func(runner dbr.SessionRunner) error) error {
 	var notify backoff.Notify
-	if s.log != nil {
+	if s.log != nil || s.TxMetrics != nil {
 		notify = func(err error, d time.Duration) {
-			_ = s.log.EventErrKv("backoff", err, map[string]string{"duration_ms": strconv.Itoa(int(d.Milliseconds()))})
+			if s.TxMetrics != nil {
+				s.TxMetrics.IncTxRetries(dbkit.TxNameFromContext(ctx))
+			}
+			if s.log != nil {
+				_ = s.log.EventErrKv("backoff", err, map[string]string{
+					"duration_ms": strconv.Itoa(int(d.Milliseconds())),
+					"error_class": dbkit.ClassifyError(err).String(),
+				})
+			}
 		}
 	}
 	return retry.DoWithRetry(ctx, s.policy, dbkit.GetIsRetryable(s.Driver()), notify, func(ctx context.Context) error {
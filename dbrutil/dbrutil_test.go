@@ -9,10 +9,12 @@ package dbrutil
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/acronis/go-appkit/config"
 	"github.com/acronis/go-appkit/log/logtest"
 	"github.com/acronis/go-appkit/testutil"
 	"github.com/gocraft/dbr/v2"
@@ -88,6 +90,48 @@ func TestDbrBegTxContextCancel(t *testing.T) {
 	wg.Wait()
 }
 
+func TestTxSession_TxMetrics(t *testing.T) {
+	dbConn := openAndSeedDB(t)
+	defer func() {
+		require.NoError(t, dbConn.Close())
+	}()
+
+	metrics := dbkit.NewPrometheusMetrics()
+	txSess := NewTxSession(dbConn, nil)
+	txSess.TxMetrics = metrics
+	ctx := dbkit.ContextWithTxName(context.Background(), "rename_user")
+
+	require.NoError(t, txSess.DoInTx(ctx, func(runner dbr.SessionRunner) error {
+		_, err := runner.Update("users").Set("name", "Robert").Where(dbr.Eq("name", "Bob")).Exec()
+		return err
+	}))
+	require.Error(t, txSess.DoInTx(ctx, func(runner dbr.SessionRunner) error {
+		return errors.New("validation error")
+	}))
+
+	labels := prometheus.Labels{dbkit.PrometheusMetricsLabelTx: "rename_user"}
+	testutil.RequireSamplesCountInHistogram(t, metrics.TxDurations.With(labels).(prometheus.Histogram), 2)
+	testutil.RequireSamplesCountInCounter(t, metrics.TxCommits.With(labels), 1)
+	testutil.RequireSamplesCountInCounter(t, metrics.TxRollbacks.With(labels), 1)
+}
+
+func TestNewTxRunnerWithRetryConfig(t *testing.T) {
+	dbConn := openAndSeedDB(t)
+	defer func() {
+		require.NoError(t, dbConn.Close())
+	}()
+
+	require.IsType(t, &TxSession{}, NewTxRunnerWithRetryConfig(dbConn, nil, nil, &dbkit.RetryConfig{}))
+
+	txRunner := NewTxRunnerWithRetryConfig(dbConn, nil, nil, &dbkit.RetryConfig{
+		Policy: dbkit.RetryPolicyTypeConstant, MaxAttempts: 2, InitialInterval: config.TimeDuration(time.Millisecond)})
+	require.IsType(t, &RetryableTxSession{}, txRunner)
+	require.NoError(t, txRunner.DoInTx(context.Background(), func(runner dbr.SessionRunner) error {
+		_, err := runner.Update("users").Set("name", "Robert").Where(dbr.Eq("name", "Bob")).Exec()
+		return err
+	}))
+}
+
 func TestDbrOpen(t *testing.T) {
 	dbConn := openAndSeedDB(t)
 	defer func() {
@@ -137,6 +181,20 @@ func TestDbrSlowQueryLogEventReceiver_TimingKv(t *testing.T) {
 		require.True(t, sqlFieldFound)
 		require.Equal(t, "query_count_users_by_name", string(logField.Bytes))
 	})
+
+	t.Run("slow query without annotation is logged with masked text", func(t *testing.T) {
+		logRecorder := logtest.NewRecorder()
+		slowQueryEventReceiver := NewSlowQueryLogEventReceiverWithOpts(logRecorder, 0, SlowQueryLogEventReceiverOpts{
+			AnnotationPrefix: "query_", QueryRedaction: dbkit.QueryRedactionMask})
+		dbSess := dbConn.NewSession(slowQueryEventReceiver)
+
+		countUsersByName(t, dbSess, "count_users_by_name", "Bob", 1)
+
+		require.Equal(t, 1, len(logRecorder.Entries()))
+		logField, found := logRecorder.Entries()[0].FindField("query")
+		require.True(t, found)
+		require.Equal(t, `/* count_users_by_name */ SELECT COUNT(*) FROM users WHERE ("name" = ?)`, string(logField.Bytes))
+	})
 }
 
 func TestDbrQueryMetricsEventReceiver_TimingKv(t *testing.T) {
@@ -168,6 +226,22 @@ func TestDbrQueryMetricsEventReceiver_TimingKv(t *testing.T) {
 		hist := mc.QueryDurations.With(labels).(prometheus.Histogram)
 		testutil.RequireSamplesCountInHistogram(t, hist, 1)
 	})
+
+	t.Run("errors of query are counted by class", func(t *testing.T) {
+		mc := dbkit.NewPrometheusMetrics()
+		metricsEventReceiver := NewQueryMetricsEventReceiver(mc, "query_")
+		dbSess := dbConn.NewSession(metricsEventReceiver)
+
+		var count int
+		err := dbSess.Select("COUNT(*)").From("missing_table").Comment("query_count_missing").LoadOne(&count)
+		require.Error(t, err)
+
+		counter := mc.QueryErrors.With(prometheus.Labels{
+			dbkit.PrometheusMetricsLabelQuery:      "query_count_missing",
+			dbkit.PrometheusMetricsLabelErrorClass: string(dbkit.QueryErrorClassOther),
+		})
+		testutil.RequireSamplesCountInCounter(t, counter, 1)
+	})
 }
 
 func addExclamation(s string) string {
@@ -238,9 +312,66 @@ func TestParseAnnotationInQuery(t *testing.T) {
 			want:     "",
 			modifier: doEmpty,
 		},
+		{
+			query:  dbkit.AnnotateQuery("count_users", "select count(*) from users"),
+			prefix: dbkit.QueryAnnotationPrefix,
+			want:   "query:count_users",
+		},
 	}
 	for _, c := range cases {
 		got := ParseAnnotationInQuery(c.query, c.prefix, c.modifier)
 		assert.Equal(t, c.want, got)
 	}
 }
+
+func TestQueryBudgetEventReceiver(t *testing.T) {
+	dbConn := openAndSeedDB(t)
+	defer func() {
+		require.NoError(t, dbConn.Close())
+	}()
+
+	countUsers := func(ctx context.Context, dbSess dbr.SessionRunner) error {
+		var usersCount int
+		return dbSess.Select("COUNT(*)").From("users").Comment("query_count_users").LoadOneContext(ctx, &usersCount)
+	}
+
+	t.Run("exceeded budget is logged once", func(t *testing.T) {
+		logRecorder := logtest.NewRecorder()
+		dbSess := dbConn.NewSession(NewCompositeReceiver([]dbr.EventReceiver{
+			&dbr.NullEventReceiver{},
+			NewQueryBudgetEventReceiver(logRecorder, QueryBudgetEventReceiverOpts{AnnotationPrefix: "query_"}),
+		}))
+		budget := dbkit.NewQueryBudget(dbkit.QueryBudgetOpts{MaxQueries: 2})
+		ctx := dbkit.NewContextWithQueryBudget(context.Background(), budget)
+		for i := 0; i < 4; i++ {
+			require.NoError(t, countUsers(ctx, dbSess))
+		}
+		require.Equal(t, 4, budget.Stats().Queries)
+		require.Greater(t, budget.Stats().TotalTime, time.Duration(0))
+
+		require.Equal(t, 1, len(logRecorder.Entries()))
+		logRecEntry := logRecorder.Entries()[0]
+		require.Equal(t, "query budget exceeded", logRecEntry.Text)
+		logField, found := logRecEntry.FindField("annotation")
+		require.True(t, found)
+		require.Equal(t, "query_count_users", string(logField.Bytes))
+	})
+
+	t.Run("exceeded budget is enforced", func(t *testing.T) {
+		logRecorder := logtest.NewRecorder()
+		dbSess := dbConn.NewSession(NewQueryBudgetEventReceiver(logRecorder, QueryBudgetEventReceiverOpts{}))
+		budget := dbkit.NewQueryBudget(dbkit.QueryBudgetOpts{MaxQueries: 1, Enforce: true})
+		ctx := dbkit.NewContextWithQueryBudget(context.Background(), budget)
+		require.NoError(t, countUsers(ctx, dbSess))
+		require.ErrorIs(t, countUsers(ctx, dbSess), context.Canceled)
+		require.ErrorIs(t, budget.Err(), dbkit.ErrQueryBudgetExceeded)
+		require.Equal(t, 1, len(logRecorder.Entries()))
+	})
+
+	t.Run("queries without budget are not tracked", func(t *testing.T) {
+		logRecorder := logtest.NewRecorder()
+		dbSess := dbConn.NewSession(NewQueryBudgetEventReceiver(logRecorder, QueryBudgetEventReceiverOpts{}))
+		require.NoError(t, countUsers(context.Background(), dbSess))
+		require.Equal(t, 0, len(logRecorder.Entries()))
+	})
+}
@@ -0,0 +1,190 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbrutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/acronis/go-appkit/log"
+	"github.com/gocraft/dbr/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// InstrumentationOpts represents options for OpenInstrumented.
+type InstrumentationOpts struct {
+	// MetricsOpts are used for creating dbkit.PrometheusMetrics.
+	MetricsOpts dbkit.PrometheusMetricsOpts
+
+	// QueryMetricsOpts are used for creating QueryMetricsEventReceiver.
+	// Only queries annotated with QueryMetricsOpts.AnnotationPrefix are observed.
+	// The same annotation options are used for the slow query log.
+	QueryMetricsOpts QueryMetricsEventReceiverOpts
+
+	// Logger is used for the slow query log (see dbkit.InstrumentationConfig.SlowQueryThreshold).
+	// The slow query log is disabled if it's nil.
+	Logger log.FieldLogger
+
+	// Tracer receives spans of SQL queries if tracing is enabled (see dbkit.InstrumentationConfig.EnableTracing).
+	Tracer dbr.TracingEventReceiver
+
+	// DBName is a value of the db_name label of connection pool metrics (go_sql_* metrics).
+	// If empty, the dialect name is used.
+	DBName string
+
+	// EventReceivers are additional event receivers (e.g., SlowQueryLogEventReceiver)
+	// that are composed with the query metrics one.
+	EventReceivers []dbr.EventReceiver
+
+	// OpenOptions are passed to Open (e.g., dbkit.WithPingTimeout).
+	OpenOptions []dbkit.OpenOption
+}
+
+// InstrumentedConnection is a dbr connection with registered Prometheus metrics
+// of SQL query durations and connection pool statistics.
+type InstrumentedConnection struct {
+	*dbr.Connection
+	// Metrics is nil if metrics are disabled (see dbkit.InstrumentationConfig.DisableMetrics).
+	Metrics *dbkit.PrometheusMetrics
+
+	registerer prometheus.Registerer
+	collectors []prometheus.Collector
+}
+
+// OpenInstrumented opens database (see Open) with collecting Prometheus metrics in one call.
+// It creates dbkit.PrometheusMetrics and the connection pool statistics collector (collectors.NewDBStatsCollector),
+// registers them on the passed registerer (prometheus.DefaultRegisterer is used if nil),
+// and binds QueryMetricsEventReceiver to the connection.
+// Instrumentation is applied according to cfg.Instrumentation: metrics may be disabled,
+// SlowQueryLogEventReceiver is bound if the slow query threshold and opts.Logger are specified,
+// and opts.Tracer receives spans if tracing is enabled, all with the same query redaction and sampling.
+// InstrumentedConnection.Close closes the connection and unregisters all metrics.
+func OpenInstrumented(
+	cfg *dbkit.Config, ping bool, registerer prometheus.Registerer, opts InstrumentationOpts,
+) (*InstrumentedConnection, error) {
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+	instrCfg := cfg.Instrumentation
+
+	var metrics *dbkit.PrometheusMetrics
+	var eventReceivers []dbr.EventReceiver
+	if !instrCfg.DisableMetrics {
+		metrics = dbkit.NewPrometheusMetricsWithOpts(opts.MetricsOpts)
+		eventReceivers = append(eventReceivers, NewQueryMetricsEventReceiverWithOpts(metrics, opts.QueryMetricsOpts))
+	}
+	if instrCfg.SlowQueryThreshold > 0 && opts.Logger != nil {
+		eventReceivers = append(eventReceivers, NewSlowQueryLogEventReceiverWithOpts(
+			opts.Logger, time.Duration(instrCfg.SlowQueryThreshold), SlowQueryLogEventReceiverOpts{
+				AnnotationPrefix:   opts.QueryMetricsOpts.AnnotationPrefix,
+				AnnotationModifier: opts.QueryMetricsOpts.AnnotationModifier,
+				QueryRedaction:     instrCfg.QueryRedaction,
+				SampleRate:         instrCfg.SlowQueryLogSampleRate,
+			}))
+	}
+	if instrCfg.EnableTracing && opts.Tracer != nil {
+		eventReceivers = append(eventReceivers,
+			newSampledTracingEventReceiver(opts.Tracer, instrCfg.TracingSampleRate, instrCfg.QueryRedaction))
+	}
+	eventReceivers = append(eventReceivers, opts.EventReceivers...)
+	conn, err := Open(cfg, ping, NewCompositeReceiver(eventReceivers), opts.OpenOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	instrumentedConn := &InstrumentedConnection{Connection: conn, Metrics: metrics, registerer: registerer}
+	if metrics == nil {
+		return instrumentedConn, nil
+	}
+	dbName := opts.DBName
+	if dbName == "" {
+		dbName = string(cfg.Dialect)
+	}
+	for _, collector := range append(metrics.AllMetrics(), collectors.NewDBStatsCollector(conn.DB, dbName)) {
+		if err = registerer.Register(collector); err != nil {
+			return nil, errors.Join(fmt.Errorf("register metrics: %w", err), instrumentedConn.Close())
+		}
+		instrumentedConn.collectors = append(instrumentedConn.collectors, collector)
+	}
+	return instrumentedConn, nil
+}
+
+// Close unregisters all metrics and closes the connection.
+func (c *InstrumentedConnection) Close() error {
+	for _, collector := range c.collectors {
+		c.registerer.Unregister(collector)
+	}
+	c.collectors = nil
+	return c.Connection.Close()
+}
+
+// RedactQuery returns the query text to be exposed in logs and traces according to the redaction mode.
+// Empty string is returned for dbkit.QueryRedactionFull (or empty mode),
+// literal values are masked (see NormalizeQuery) for dbkit.QueryRedactionMask.
+func RedactQuery(query string, mode dbkit.QueryRedactionMode) string {
+	switch mode {
+	case dbkit.QueryRedactionNone:
+		return query
+	case dbkit.QueryRedactionMask:
+		return NormalizeQuery(query)
+	default:
+		return ""
+	}
+}
+
+// sampled returns true if the event should be observed with the given sample rate (0 means all events).
+func sampled(rate float64) bool {
+	return rate <= 0 || rate >= 1 || rand.Float64() < rate //nolint:gosec // Sampling doesn't need crypto rand.
+}
+
+type unsampledSpanCtxKey struct{}
+
+// sampledTracingEventReceiver passes sampled spans with redacted queries to the tracer.
+type sampledTracingEventReceiver struct {
+	*dbr.NullEventReceiver
+	tracer         dbr.TracingEventReceiver
+	sampleRate     float64
+	queryRedaction dbkit.QueryRedactionMode
+}
+
+var _ dbr.TracingEventReceiver = (*sampledTracingEventReceiver)(nil)
+
+func newSampledTracingEventReceiver(
+	tracer dbr.TracingEventReceiver, sampleRate float64, queryRedaction dbkit.QueryRedactionMode,
+) *sampledTracingEventReceiver {
+	return &sampledTracingEventReceiver{
+		NullEventReceiver: &dbr.NullEventReceiver{},
+		tracer:            tracer,
+		sampleRate:        sampleRate,
+		queryRedaction:    queryRedaction,
+	}
+}
+
+func (r *sampledTracingEventReceiver) SpanStart(ctx context.Context, eventName, query string) context.Context {
+	if !sampled(r.sampleRate) {
+		return context.WithValue(ctx, unsampledSpanCtxKey{}, true)
+	}
+	return r.tracer.SpanStart(ctx, eventName, RedactQuery(query, r.queryRedaction))
+}
+
+func (r *sampledTracingEventReceiver) SpanError(ctx context.Context, err error) {
+	if ctx.Value(unsampledSpanCtxKey{}) == nil {
+		r.tracer.SpanError(ctx, err)
+	}
+}
+
+func (r *sampledTracingEventReceiver) SpanFinish(ctx context.Context) {
+	if ctx.Value(unsampledSpanCtxKey{}) == nil {
+		r.tracer.SpanFinish(ctx)
+	}
+}
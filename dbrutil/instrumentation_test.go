@@ -0,0 +1,142 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbrutil
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/config"
+	"github.com/acronis/go-appkit/log/logtest"
+	"github.com/gocraft/dbr/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+func TestOpenInstrumented(t *testing.T) {
+	cfg := &dbkit.Config{
+		Dialect:      dbkit.DialectSQLite,
+		SQLite:       dbkit.SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")},
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	}
+	gatherMetricNames := func(registry *prometheus.Registry) []string {
+		metricFamilies, err := registry.Gather()
+		require.NoError(t, err)
+		var names []string
+		for _, mf := range metricFamilies {
+			names = append(names, mf.GetName())
+		}
+		return names
+	}
+
+	registry := prometheus.NewRegistry()
+	conn, err := OpenInstrumented(cfg, true, registry, InstrumentationOpts{
+		MetricsOpts:      dbkit.PrometheusMetricsOpts{Namespace: "app"},
+		QueryMetricsOpts: QueryMetricsEventReceiverOpts{AnnotationPrefix: "query_"},
+	})
+	require.NoError(t, err)
+
+	_, err = conn.Exec(sqlCreateAndSeedTestUsersTable)
+	require.NoError(t, err)
+	countUsersByName(t, conn.NewSession(nil), "query_count_users_by_name", "Sam", 2)
+
+	metricNames := gatherMetricNames(registry)
+	require.Contains(t, metricNames, "app_db_query_duration_seconds")
+	require.Contains(t, metricNames, "go_sql_open_connections")
+
+	// Metrics are already registered, so the second connection cannot be instrumented.
+	_, err = OpenInstrumented(cfg, false, registry, InstrumentationOpts{MetricsOpts: dbkit.PrometheusMetricsOpts{Namespace: "app"}})
+	require.ErrorContains(t, err, "register metrics")
+	require.Contains(t, gatherMetricNames(registry), "app_db_query_duration_seconds")
+
+	require.NoError(t, conn.Close())
+	require.Empty(t, gatherMetricNames(registry))
+}
+
+type recordingTracer struct {
+	*dbr.NullEventReceiver
+	queries  []string
+	finished int
+}
+
+func (r *recordingTracer) SpanStart(ctx context.Context, eventName, query string) context.Context {
+	r.queries = append(r.queries, query)
+	return ctx
+}
+
+func (r *recordingTracer) SpanError(ctx context.Context, err error) {}
+
+func (r *recordingTracer) SpanFinish(ctx context.Context) {
+	r.finished++
+}
+
+func TestOpenInstrumented_InstrumentationConfig(t *testing.T) {
+	cfg := &dbkit.Config{
+		Dialect:      dbkit.DialectSQLite,
+		SQLite:       dbkit.SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")},
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+		Instrumentation: dbkit.InstrumentationConfig{
+			DisableMetrics:     true,
+			EnableTracing:      true,
+			SlowQueryThreshold: config.TimeDuration(time.Nanosecond),
+			QueryRedaction:     dbkit.QueryRedactionMask,
+		},
+	}
+	registry := prometheus.NewRegistry()
+	logRecorder := logtest.NewRecorder()
+	tracer := &recordingTracer{NullEventReceiver: &dbr.NullEventReceiver{}}
+	conn, err := OpenInstrumented(cfg, true, registry, InstrumentationOpts{
+		QueryMetricsOpts: QueryMetricsEventReceiverOpts{AnnotationPrefix: "query_"},
+		Logger:           logRecorder,
+		Tracer:           tracer,
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, conn.Close()) }()
+
+	_, err = conn.Exec(sqlCreateAndSeedTestUsersTable)
+	require.NoError(t, err)
+	countUsersByName(t, conn.NewSession(nil), "query_count_users_by_name", "Sam", 2)
+
+	// Metrics are disabled.
+	require.Nil(t, conn.Metrics)
+	metricFamilies, err := registry.Gather()
+	require.NoError(t, err)
+	require.Empty(t, metricFamilies)
+
+	// Slow query is logged with masked literals.
+	require.Len(t, logRecorder.Entries(), 1)
+	logField, found := logRecorder.Entries()[0].FindField("query")
+	require.True(t, found)
+	require.Equal(t, `/* query_count_users_by_name */ SELECT COUNT(*) FROM users WHERE ("name" = ?)`, string(logField.Bytes))
+
+	// Span receives the masked query as well.
+	require.Equal(t, []string{`/* query_count_users_by_name */ SELECT COUNT(*) FROM users WHERE ("name" = ?)`}, tracer.queries)
+	require.Equal(t, 1, tracer.finished)
+}
+
+func TestSampledTracingEventReceiver(t *testing.T) {
+	tracer := &recordingTracer{NullEventReceiver: &dbr.NullEventReceiver{}}
+
+	receiver := newSampledTracingEventReceiver(tracer, 0, "")
+	receiver.SpanFinish(receiver.SpanStart(context.Background(), "dbr.select", "SELECT 1"))
+	require.Equal(t, []string{""}, tracer.queries, "query text must be hidden by default")
+	require.Equal(t, 1, tracer.finished)
+
+	receiver = newSampledTracingEventReceiver(tracer, math.SmallestNonzeroFloat64, dbkit.QueryRedactionNone)
+	for i := 0; i < 10; i++ {
+		receiver.SpanFinish(receiver.SpanStart(context.Background(), "dbr.select", "SELECT 1"))
+	}
+	require.Len(t, tracer.queries, 1)
+	require.Equal(t, 1, tracer.finished)
+}
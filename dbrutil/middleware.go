@@ -14,6 +14,8 @@ import (
 
 	"github.com/acronis/go-appkit/httpserver/middleware"
 	"github.com/gocraft/dbr/v2"
+
+	"github.com/acronis/go-dbkit"
 )
 
 type ctxKey int
@@ -30,7 +32,22 @@ type TxRunnerMiddlewareOpts struct {
 		MinTime          time.Duration
 		AnnotationPrefix string
 	}
-	NewTxRunner NewTxRunnerFunc
+	// QueryBudget, if MaxQueries or MaxTotalTime is set, enables per-request query budget tracking
+	// (see dbkit.QueryBudget and QueryBudgetEventReceiver).
+	QueryBudget struct {
+		dbkit.QueryBudgetOpts
+		AnnotationPrefix string
+	}
+	// NPlusOneDetector, if Enabled, enables N+1 query pattern detection within the request
+	// (see NPlusOneDetectorEventReceiver). It's intended for development and staging environments.
+	NPlusOneDetector struct {
+		Enabled bool
+		NPlusOneDetectorEventReceiverOpts
+	}
+	// DefaultQueryTimeout, if positive, is applied to statements executed in TxRunner.DoInTx
+	// if their contexts have no deadline (see NewTimeoutTxRunner).
+	DefaultQueryTimeout time.Duration
+	NewTxRunner         NewTxRunnerFunc
 }
 
 type txRunnerHandler struct {
@@ -84,17 +101,29 @@ func (m *txRunnerHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	reqCtx := r.Context()
 
 	dbEventReceiver := m.dbConn.EventReceiver
-	if m.opts.SlowQueryLog.MinTime > 0 {
-		slowLogEventReceiver := NewSlowQueryLogEventReceiver(
-			middleware.GetLoggerFromContext(reqCtx), m.opts.SlowQueryLog.MinTime, m.opts.SlowQueryLog.AnnotationPrefix)
+	addEventReceiver := func(recv dbr.EventReceiver) {
 		if dbEventReceiver != nil {
-			dbEventReceiver = NewCompositeReceiver([]dbr.EventReceiver{dbEventReceiver, slowLogEventReceiver})
+			dbEventReceiver = NewCompositeReceiver([]dbr.EventReceiver{dbEventReceiver, recv})
 		} else {
-			dbEventReceiver = slowLogEventReceiver
+			dbEventReceiver = recv
 		}
 	}
+	if m.opts.SlowQueryLog.MinTime > 0 {
+		addEventReceiver(NewSlowQueryLogEventReceiver(
+			middleware.GetLoggerFromContext(reqCtx), m.opts.SlowQueryLog.MinTime, m.opts.SlowQueryLog.AnnotationPrefix))
+	}
+	if m.opts.QueryBudget.MaxQueries > 0 || m.opts.QueryBudget.MaxTotalTime > 0 {
+		reqCtx = dbkit.NewContextWithQueryBudget(reqCtx, dbkit.NewQueryBudget(m.opts.QueryBudget.QueryBudgetOpts))
+		addEventReceiver(NewQueryBudgetEventReceiver(middleware.GetLoggerFromContext(reqCtx),
+			QueryBudgetEventReceiverOpts{AnnotationPrefix: m.opts.QueryBudget.AnnotationPrefix}))
+	}
+	if m.opts.NPlusOneDetector.Enabled {
+		reqCtx = NewContextWithNPlusOneTracking(reqCtx)
+		addEventReceiver(NewNPlusOneDetectorEventReceiver(middleware.GetLoggerFromContext(reqCtx),
+			m.opts.NPlusOneDetector.NPlusOneDetectorEventReceiverOpts))
+	}
 
-	dbSess := m.opts.NewTxRunner(m.dbConn, m.txOpts, dbEventReceiver)
+	dbSess := NewTimeoutTxRunner(m.opts.NewTxRunner(m.dbConn, m.txOpts, dbEventReceiver), m.opts.DefaultQueryTimeout)
 	m.next.ServeHTTP(rw, r.WithContext(NewContextWithTxRunnerByKey(reqCtx, dbSess, m.opts.ContextKey)))
 }
 
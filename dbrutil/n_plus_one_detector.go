@@ -0,0 +1,184 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbrutil
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/acronis/go-appkit/log"
+	"github.com/gocraft/dbr/v2"
+)
+
+// DefaultNPlusOneThreshold is the default number of consecutive executions
+// of the same normalized query that is considered as N+1 query pattern.
+const DefaultNPlusOneThreshold = 5
+
+// NPlusOneReport describes the detected N+1 query pattern.
+type NPlusOneReport struct {
+	// Query is the normalized query (literals are replaced with "?").
+	Query string
+	// Count is the number of consecutive executions of the query.
+	Count int
+	// CallSite is the first frame outside dbr and dbrutil packages ("file:line function") of the query execution.
+	CallSite string
+}
+
+// NPlusOneDetectorEventReceiverOpts contains options for NPlusOneDetectorEventReceiver.
+type NPlusOneDetectorEventReceiverOpts struct {
+	// Threshold is the number of consecutive executions of the same normalized query that is reported.
+	// DefaultNPlusOneThreshold is used if it's not set.
+	Threshold int
+	// OnDetect is called when N+1 query pattern is detected (e.g., to fail tests).
+	// If it's not set, the pattern is logged with warning level.
+	OnDetect func(ctx context.Context, report NPlusOneReport)
+}
+
+// NPlusOneDetectorEventReceiver implements the dbr.EventReceiver and dbr.TracingEventReceiver interfaces
+// and detects many consecutive executions of the same normalized query with different parameters
+// within one traced request (see NewContextWithNPlusOneTracking). Usually, it's an accidental loop of queries.
+// Each detected pattern is reported once with the call site of the query execution.
+// Since the call site is captured for every query, the detector is intended for development and staging environments.
+// Queries should be executed with context (e.g., LoadContext or ExecContext) to be tracked.
+type NPlusOneDetectorEventReceiver struct {
+	*dbr.NullEventReceiver
+	logger    log.FieldLogger
+	threshold int
+	onDetect  func(ctx context.Context, report NPlusOneReport)
+}
+
+var _ dbr.TracingEventReceiver = (*NPlusOneDetectorEventReceiver)(nil)
+
+// NewNPlusOneDetectorEventReceiver creates a new NPlusOneDetectorEventReceiver.
+func NewNPlusOneDetectorEventReceiver(
+	logger log.FieldLogger, options NPlusOneDetectorEventReceiverOpts,
+) *NPlusOneDetectorEventReceiver {
+	if options.Threshold <= 0 {
+		options.Threshold = DefaultNPlusOneThreshold
+	}
+	return &NPlusOneDetectorEventReceiver{
+		NullEventReceiver: &dbr.NullEventReceiver{},
+		logger:            logger,
+		threshold:         options.Threshold,
+		onDetect:          options.OnDetect,
+	}
+}
+
+type nPlusOneTracker struct {
+	mu          sync.Mutex
+	lastQuery   string
+	count       int
+	rawQueries  map[string]struct{}
+	reported    bool
+	firstCaller string
+}
+
+type nPlusOneCtxKey struct{}
+
+// NewContextWithNPlusOneTracking creates a new context in which queries are tracked by NPlusOneDetectorEventReceiver.
+// Usually, it's called once per request (e.g., in HTTP middleware).
+func NewContextWithNPlusOneTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, nPlusOneCtxKey{}, &nPlusOneTracker{})
+}
+
+// SpanStart is called before the query execution.
+func (er *NPlusOneDetectorEventReceiver) SpanStart(ctx context.Context, eventName, query string) context.Context {
+	tracker, ok := ctx.Value(nPlusOneCtxKey{}).(*nPlusOneTracker)
+	if !ok {
+		return ctx
+	}
+	normalizedQuery := NormalizeQuery(query)
+
+	tracker.mu.Lock()
+	if normalizedQuery != tracker.lastQuery {
+		tracker.lastQuery = normalizedQuery
+		tracker.count = 0
+		tracker.rawQueries = make(map[string]struct{})
+		tracker.reported = false
+		tracker.firstCaller = ""
+	}
+	tracker.count++
+	if tracker.firstCaller == "" {
+		tracker.firstCaller = queryCallSite()
+	}
+	if len(tracker.rawQueries) < er.threshold {
+		tracker.rawQueries[query] = struct{}{}
+	}
+	// When arguments are not interpolated into the query, different parameters cannot be distinguished,
+	// so only the number of executions is taken into account.
+	differentParams := len(tracker.rawQueries) > 1 || query == normalizedQuery
+	var report *NPlusOneReport
+	if !tracker.reported && tracker.count >= er.threshold && differentParams {
+		tracker.reported = true
+		report = &NPlusOneReport{Query: normalizedQuery, Count: tracker.count, CallSite: tracker.firstCaller}
+	}
+	tracker.mu.Unlock()
+
+	if report != nil {
+		if er.onDetect != nil {
+			er.onDetect(ctx, *report)
+		} else {
+			er.logger.Warn("N+1 query pattern detected",
+				log.String("query", report.Query),
+				log.Int("count", report.Count),
+				log.String("call_site", report.CallSite),
+			)
+		}
+	}
+	return ctx
+}
+
+// SpanError is called when the query execution fails.
+func (er *NPlusOneDetectorEventReceiver) SpanError(ctx context.Context, err error) {}
+
+// SpanFinish is called after the query execution.
+func (er *NPlusOneDetectorEventReceiver) SpanFinish(ctx context.Context) {}
+
+var (
+	normalizeStringLiteralRe = regexp.MustCompile(`'(?:[^']|'')*'`)
+	normalizeNumberRe        = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	normalizePlaceholderRe   = regexp.MustCompile(`\$\d+|\?\d*`)
+	normalizeListRe          = regexp.MustCompile(`\?(?:\s*,\s*\?)+`)
+	normalizeSpacesRe        = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeQuery replaces literals and placeholders in SQL query with "?",
+// collapses lists of them (e.g., in IN clause) into the single "?" and collapses whitespaces,
+// so the queries that differ only in parameters are normalized to the same string.
+func NormalizeQuery(query string) string {
+	query = normalizeStringLiteralRe.ReplaceAllString(query, "?")
+	query = normalizePlaceholderRe.ReplaceAllString(query, "?")
+	query = normalizeNumberRe.ReplaceAllString(query, "?")
+	query = normalizeListRe.ReplaceAllString(query, "?")
+	return strings.TrimSpace(normalizeSpacesRe.ReplaceAllString(query, " "))
+}
+
+const dbkitDbrutilPkgPrefix = "github.com/acronis/go-dbkit/dbrutil."
+
+// queryCallSite returns the first frame outside dbr, dbrutil, database/sql and runtime packages.
+func queryCallSite() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		internal := strings.HasPrefix(frame.Function, "github.com/gocraft/dbr/") ||
+			strings.HasPrefix(frame.Function, "database/sql.") ||
+			strings.HasPrefix(frame.Function, "runtime.") ||
+			(strings.HasPrefix(frame.Function, dbkitDbrutilPkgPrefix) && !strings.HasSuffix(frame.File, "_test.go"))
+		if !internal {
+			return fmt.Sprintf("%s:%d %s", frame.File, frame.Line, frame.Function)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbrutil
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/acronis/go-appkit/log/logtest"
+	"github.com/gocraft/dbr/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM users WHERE id = 10", "SELECT * FROM users WHERE id = ?"},
+		{"SELECT * FROM users WHERE name = 'O''Brien' AND score > 1.5", "SELECT * FROM users WHERE name = ? AND score > ?"},
+		{"SELECT * FROM t1 WHERE id IN (1, 2,3)", "SELECT * FROM t1 WHERE id IN (?)"},
+		{"SELECT *\n  FROM users WHERE id = $1 AND name = $2", "SELECT * FROM users WHERE id = ? AND name = ?"},
+		{"UPDATE users SET name = ?1 WHERE id = ?2", "UPDATE users SET name = ? WHERE id = ?"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, NormalizeQuery(tt.query), tt.query)
+	}
+}
+
+func TestNPlusOneDetectorEventReceiver(t *testing.T) {
+	dbConn := openAndSeedDB(t)
+	defer func() {
+		require.NoError(t, dbConn.Close())
+	}()
+
+	loadUserName := func(ctx context.Context, dbSess dbr.SessionRunner, id int) {
+		var name string
+		require.NoError(t, dbSess.Select("name").From("users").Where(dbr.Eq("id", id)).LoadOneContext(ctx, &name))
+	}
+
+	t.Run("loop of queries with different parameters is reported once", func(t *testing.T) {
+		var reports []NPlusOneReport
+		dbSess := dbConn.NewSession(NewNPlusOneDetectorEventReceiver(nil, NPlusOneDetectorEventReceiverOpts{
+			Threshold: 3,
+			OnDetect:  func(_ context.Context, report NPlusOneReport) { reports = append(reports, report) },
+		}))
+		ctx := NewContextWithNPlusOneTracking(context.Background())
+		for id := 1; id <= 5; id++ {
+			loadUserName(ctx, dbSess, id)
+		}
+		require.Len(t, reports, 1)
+		require.Equal(t, 3, reports[0].Count)
+		require.Equal(t, `SELECT name FROM users WHERE ("id" = ?)`, reports[0].Query)
+		require.Contains(t, reports[0].CallSite, "n_plus_one_detector_test.go")
+		require.Contains(t, reports[0].CallSite, "TestNPlusOneDetectorEventReceiver")
+	})
+
+	t.Run("repeated identical query is not reported", func(t *testing.T) {
+		logRecorder := logtest.NewRecorder()
+		dbSess := dbConn.NewSession(NewNPlusOneDetectorEventReceiver(logRecorder, NPlusOneDetectorEventReceiverOpts{Threshold: 3}))
+		ctx := NewContextWithNPlusOneTracking(context.Background())
+		for i := 0; i < 5; i++ {
+			loadUserName(ctx, dbSess, 1)
+		}
+		require.Empty(t, logRecorder.Entries())
+	})
+
+	t.Run("interleaved queries are not reported", func(t *testing.T) {
+		logRecorder := logtest.NewRecorder()
+		dbSess := dbConn.NewSession(NewNPlusOneDetectorEventReceiver(logRecorder, NPlusOneDetectorEventReceiverOpts{Threshold: 3}))
+		ctx := NewContextWithNPlusOneTracking(context.Background())
+		for id := 1; id <= 5; id++ {
+			loadUserName(ctx, dbSess, id)
+			var usersCount int
+			require.NoError(t, dbSess.Select("COUNT(*)").From("users").LoadOneContext(ctx, &usersCount))
+		}
+		require.Empty(t, logRecorder.Entries())
+	})
+
+	t.Run("detected pattern is logged", func(t *testing.T) {
+		logRecorder := logtest.NewRecorder()
+		dbSess := dbConn.NewSession(NewNPlusOneDetectorEventReceiver(logRecorder, NPlusOneDetectorEventReceiverOpts{}))
+		ctx := NewContextWithNPlusOneTracking(context.Background())
+		for id := 1; id <= DefaultNPlusOneThreshold; id++ {
+			loadUserName(ctx, dbSess, id)
+		}
+		require.Len(t, logRecorder.Entries(), 1)
+		logRecEntry := logRecorder.Entries()[0]
+		require.Equal(t, "N+1 query pattern detected", logRecEntry.Text)
+		logField, found := logRecEntry.FindField("call_site")
+		require.True(t, found)
+		require.True(t, strings.Contains(string(logField.Bytes), "n_plus_one_detector_test.go"))
+	})
+
+	t.Run("queries without tracking are ignored", func(t *testing.T) {
+		logRecorder := logtest.NewRecorder()
+		dbSess := dbConn.NewSession(NewNPlusOneDetectorEventReceiver(logRecorder, NPlusOneDetectorEventReceiverOpts{Threshold: 2}))
+		for id := 1; id <= 5; id++ {
+			loadUserName(context.Background(), dbSess, id)
+		}
+		require.Empty(t, logRecorder.Entries())
+	})
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbrutil
+
+import (
+	"context"
+	"time"
+
+	"github.com/acronis/go-appkit/log"
+	"github.com/gocraft/dbr/v2"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// QueryBudgetEventReceiverOpts contains options for QueryBudgetEventReceiver.
+type QueryBudgetEventReceiverOpts struct {
+	AnnotationPrefix   string
+	AnnotationModifier func(string) string
+}
+
+// QueryBudgetEventReceiver implements the dbr.EventReceiver and dbr.TracingEventReceiver interfaces
+// and tracks queries against the dbkit.QueryBudget stored in the query context (see dbkit.NewContextWithQueryBudget).
+// When the budget is exceeded, it's logged once per budget. If the budget is enforced,
+// all subsequent queries fail with the context canceled error (context.Cause returns error wrapping dbkit.ErrQueryBudgetExceeded).
+// Queries should be executed with context (e.g., LoadContext or ExecContext) to be tracked.
+type QueryBudgetEventReceiver struct {
+	*dbr.NullEventReceiver
+	logger             log.FieldLogger
+	annotationPrefix   string
+	annotationModifier func(string) string
+}
+
+var _ dbr.TracingEventReceiver = (*QueryBudgetEventReceiver)(nil)
+
+// NewQueryBudgetEventReceiver creates a new QueryBudgetEventReceiver.
+func NewQueryBudgetEventReceiver(logger log.FieldLogger, options QueryBudgetEventReceiverOpts) *QueryBudgetEventReceiver {
+	return &QueryBudgetEventReceiver{
+		NullEventReceiver:  &dbr.NullEventReceiver{},
+		logger:             logger,
+		annotationPrefix:   options.AnnotationPrefix,
+		annotationModifier: options.AnnotationModifier,
+	}
+}
+
+type queryBudgetSpanCtxKey struct{}
+
+type queryBudgetSpan struct {
+	startTime time.Time
+	query     string
+}
+
+// SpanStart is called before the query execution.
+func (er *QueryBudgetEventReceiver) SpanStart(ctx context.Context, eventName, query string) context.Context {
+	budget := dbkit.GetQueryBudgetFromContext(ctx)
+	if budget == nil {
+		return ctx
+	}
+	if budget.BeginQuery() {
+		er.logExceeded(budget, query)
+	}
+	if budget.Opts().Enforce {
+		if err := budget.Err(); err != nil {
+			rejectedCtx, cancel := context.WithCancelCause(ctx)
+			cancel(err)
+			return rejectedCtx
+		}
+	}
+	return context.WithValue(ctx, queryBudgetSpanCtxKey{}, queryBudgetSpan{startTime: time.Now(), query: query})
+}
+
+// SpanError is called when the query execution fails.
+func (er *QueryBudgetEventReceiver) SpanError(ctx context.Context, err error) {}
+
+// SpanFinish is called after the query execution.
+func (er *QueryBudgetEventReceiver) SpanFinish(ctx context.Context) {
+	span, ok := ctx.Value(queryBudgetSpanCtxKey{}).(queryBudgetSpan)
+	if !ok {
+		return
+	}
+	budget := dbkit.GetQueryBudgetFromContext(ctx)
+	if budget.EndQuery(time.Since(span.startTime)) {
+		er.logExceeded(budget, span.query)
+	}
+}
+
+func (er *QueryBudgetEventReceiver) logExceeded(budget *dbkit.QueryBudget, query string) {
+	stats, opts := budget.Stats(), budget.Opts()
+	er.logger.Warn("query budget exceeded",
+		log.Int("queries", stats.Queries),
+		log.Int("max_queries", opts.MaxQueries),
+		log.Int64("total_time_ms", stats.TotalTime.Milliseconds()),
+		log.Int64("max_total_time_ms", opts.MaxTotalTime.Milliseconds()),
+		log.String("annotation", ParseAnnotationInQuery(query, er.annotationPrefix, er.annotationModifier)),
+		log.Bool("enforced", opts.Enforce),
+	)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbrutil
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gocraft/dbr/v2"
+)
+
+// QueryError is returned by the dbr session with QueryErrorEventReceiver when SQL query fails.
+// It contains the query context that makes the error actionable in logs at higher layers:
+// the annotation, the normalized statement (literal values are masked, see NormalizeQuery), and the target table.
+// Use errors.As to get it. Original error is accessible via errors.Unwrap (so errors.Is/errors.As and retry logic work as usual).
+type QueryError struct {
+	Annotation string
+	Statement  string
+	Table      string
+	Inner      error
+}
+
+// Unwrap returns the original error.
+func (e *QueryError) Unwrap() error {
+	return e.Inner
+}
+
+// Error returns a string representation of QueryError.
+func (e *QueryError) Error() string {
+	query := e.Annotation
+	if query == "" {
+		query = e.Statement
+	}
+	if e.Table == "" {
+		return fmt.Sprintf("query %q failed: %v", query, e.Inner)
+	}
+	return fmt.Sprintf("query %q on table %s failed: %v", query, e.Table, e.Inner)
+}
+
+// QueryErrorEventReceiverOpts contains options for QueryErrorEventReceiver.
+type QueryErrorEventReceiverOpts struct {
+	AnnotationPrefix   string
+	AnnotationModifier func(string) string
+}
+
+// QueryErrorEventReceiver implements the dbr.EventReceiver interface and wraps errors of failed SQL queries
+// into QueryError. Query arguments are never included into the error.
+type QueryErrorEventReceiver struct {
+	*dbr.NullEventReceiver
+	annotationPrefix   string
+	annotationModifier func(string) string
+}
+
+// NewQueryErrorEventReceiver creates a new QueryErrorEventReceiver.
+func NewQueryErrorEventReceiver(options QueryErrorEventReceiverOpts) *QueryErrorEventReceiver {
+	return &QueryErrorEventReceiver{
+		NullEventReceiver:  &dbr.NullEventReceiver{},
+		annotationPrefix:   options.AnnotationPrefix,
+		annotationModifier: options.AnnotationModifier,
+	}
+}
+
+// EventErrKv is called when SQL query fails. It returns QueryError that wraps the passed error.
+func (er *QueryErrorEventReceiver) EventErrKv(eventName string, err error, kvs map[string]string) error {
+	query, ok := kvs["sql"]
+	if !ok || err == nil {
+		return err
+	}
+	statement := NormalizeQuery(query)
+	return &QueryError{
+		Annotation: ParseAnnotationInQuery(query, er.annotationPrefix, er.annotationModifier),
+		Statement:  statement,
+		Table:      parseQueryTable(statement),
+		Inner:      err,
+	}
+}
+
+var queryTableRe = regexp.MustCompile(`(?i)\b(?:FROM|INTO|UPDATE|JOIN)\s+([\w."` + "`" + `\[\]]+)`)
+
+// parseQueryTable returns the first table name mentioned in the query (quotes are stripped).
+func parseQueryTable(query string) string {
+	query = stripLeadingComments(query)
+	m := queryTableRe.FindStringSubmatch(query)
+	if m == nil {
+		return ""
+	}
+	return strings.NewReplacer("`", "", `"`, "", "[", "", "]", "").Replace(m[1])
+}
+
+func stripLeadingComments(query string) string {
+	for {
+		query = strings.TrimSpace(query)
+		if !strings.HasPrefix(query, "/*") {
+			return query
+		}
+		end := strings.Index(query, "*/")
+		if end == -1 {
+			return query
+		}
+		query = query[end+2:]
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbrutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryErrorEventReceiver(t *testing.T) {
+	dbConn := openAndSeedDB(t)
+	defer func() {
+		require.NoError(t, dbConn.Close())
+	}()
+
+	const secret = "very-secret-value"
+
+	t.Run("failed query error contains masked query context", func(t *testing.T) {
+		dbSess := dbConn.NewSession(NewCompositeReceiver([]dbr.EventReceiver{
+			&dbr.NullEventReceiver{},
+			NewQueryErrorEventReceiver(QueryErrorEventReceiverOpts{AnnotationPrefix: "query_"}),
+		}))
+		_, err := dbSess.Update("users").Set("unknown_column", secret).Where(dbr.Eq("id", 42)).
+			Comment("query_update_user").ExecContext(context.Background())
+		require.Error(t, err)
+		require.NotContains(t, err.Error(), secret)
+
+		var queryErr *QueryError
+		require.True(t, errors.As(err, &queryErr))
+		require.Equal(t, "query_update_user", queryErr.Annotation)
+		require.Equal(t, "users", queryErr.Table)
+		require.Equal(t, `/* query_update_user */ UPDATE "users" SET "unknown_column" = ? WHERE ("id" = ?)`, queryErr.Statement)
+		require.NotContains(t, queryErr.Statement, secret)
+		require.Contains(t, queryErr.Error(), "query_update_user")
+		require.Contains(t, queryErr.Unwrap().Error(), "unknown_column")
+	})
+
+	t.Run("failed select", func(t *testing.T) {
+		dbSess := dbConn.NewSession(NewQueryErrorEventReceiver(QueryErrorEventReceiverOpts{}))
+		var names []string
+		_, err := dbSess.Select("name").From("unknown_table").Where(dbr.Eq("name", secret)).
+			LoadContext(context.Background(), &names)
+		var queryErr *QueryError
+		require.True(t, errors.As(err, &queryErr))
+		require.Equal(t, "unknown_table", queryErr.Table)
+		require.Empty(t, queryErr.Annotation)
+		require.NotContains(t, queryErr.Error(), secret)
+	})
+}
+
+func TestParseQueryTable(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`SELECT * FROM "users" WHERE id = ?`, "users"},
+		{"/* query_from_users */ INSERT INTO `orders` (id) VALUES (?)", "orders"},
+		{"UPDATE [dbo].[accounts] SET name = ?", "dbo.accounts"},
+		{"SELECT 1", ""},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, parseQueryTable(tt.query), tt.query)
+	}
+}
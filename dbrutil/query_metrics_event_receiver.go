@@ -10,12 +10,13 @@ import (
 	"time"
 
 	"github.com/gocraft/dbr/v2"
+
+	"github.com/acronis/go-dbkit"
 )
 
 // MetricsCollector is an interface for collecting metrics about SQL queries.
-type MetricsCollector interface {
-	ObserveQueryDuration(query string, duration time.Duration)
-}
+// It's an alias of dbkit.QueryMetricsCollector, so any dbkit.MetricsCollector may be used.
+type MetricsCollector = dbkit.QueryMetricsCollector
 
 // QueryMetricsEventReceiverOpts consists options for QueryMetricsEventReceiver.
 type QueryMetricsEventReceiverOpts struct {
@@ -25,6 +26,7 @@ type QueryMetricsEventReceiverOpts struct {
 
 // QueryMetricsEventReceiver implements the dbr.EventReceiver interface and collects metrics about SQL queries.
 // To be collected, SQL query should be annotated (comment starting with specified prefix).
+// Besides durations, errors of failed queries are counted by class if the collector supports it (see EventErrKv).
 type QueryMetricsEventReceiver struct {
 	*dbr.NullEventReceiver
 	metricsCollector   MetricsCollector
@@ -60,3 +62,18 @@ func (er *QueryMetricsEventReceiver) TimingKv(eventName string, nanoseconds int6
 	}
 	er.metricsCollector.ObserveQueryDuration(annotation, time.Duration(nanoseconds))
 }
+
+// EventErrKv is called when SQL query fails. If the metrics collector implements dbkit.ErrorMetricsCollector
+// (as dbkit.PrometheusMetrics does), it parses annotation from SQL comment and counts the error by its class.
+func (er *QueryMetricsEventReceiver) EventErrKv(eventName string, err error, kvs map[string]string) error {
+	errCollector, ok := er.metricsCollector.(dbkit.ErrorMetricsCollector)
+	if !ok || err == nil {
+		return err
+	}
+	annotation := ParseAnnotationInQuery(kvs["sql"], er.annotationPrefix, er.annotationModifier)
+	if annotation == "" {
+		return err
+	}
+	errCollector.ObserveQueryError(annotation, err)
+	return err
+}
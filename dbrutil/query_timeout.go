@@ -0,0 +1,176 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbrutil
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+)
+
+// TimeoutSessionRunner wraps dbr.SessionRunner (dbr.Session or dbr.Tx) and executes all statements built by it
+// with the default timeout if the context passed to the statement has no deadline.
+// It closes a common gap where dbr statements are executed with context.TODO() or without context at all
+// (e.g., Load instead of LoadContext) and may hang forever.
+// Unlike dbr.Session.Timeout, the timeout isn't applied if the context already has a deadline (even a longer one).
+// Rows and Iterate methods of SelectStmt return the rows owned by the caller, so they are executed without the timeout.
+type TimeoutSessionRunner struct {
+	dbr.SessionRunner
+	timeout time.Duration
+}
+
+var _ dbr.SessionRunner = (*TimeoutSessionRunner)(nil)
+
+// NewTimeoutSessionRunner creates a new TimeoutSessionRunner.
+func NewTimeoutSessionRunner(runner dbr.SessionRunner, timeout time.Duration) *TimeoutSessionRunner {
+	return &TimeoutSessionRunner{SessionRunner: runner, timeout: timeout}
+}
+
+// Select creates a SelectStmt.
+func (r *TimeoutSessionRunner) Select(column ...string) *dbr.SelectStmt {
+	stmt := r.SessionRunner.Select(column...)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+// SelectBySql creates a SelectStmt from raw query.
+func (r *TimeoutSessionRunner) SelectBySql(query string, value ...interface{}) *dbr.SelectStmt { //nolint:revive,stylecheck
+	stmt := r.SessionRunner.SelectBySql(query, value...)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+// InsertInto creates an InsertStmt.
+func (r *TimeoutSessionRunner) InsertInto(table string) *dbr.InsertStmt {
+	stmt := r.SessionRunner.InsertInto(table)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+// InsertBySql creates an InsertStmt from raw query.
+func (r *TimeoutSessionRunner) InsertBySql(query string, value ...interface{}) *dbr.InsertStmt { //nolint:revive,stylecheck
+	stmt := r.SessionRunner.InsertBySql(query, value...)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+// Update creates an UpdateStmt.
+func (r *TimeoutSessionRunner) Update(table string) *dbr.UpdateStmt {
+	stmt := r.SessionRunner.Update(table)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+// UpdateBySql creates an UpdateStmt from raw query.
+func (r *TimeoutSessionRunner) UpdateBySql(query string, value ...interface{}) *dbr.UpdateStmt { //nolint:revive,stylecheck
+	stmt := r.SessionRunner.UpdateBySql(query, value...)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+// DeleteFrom creates a DeleteStmt.
+func (r *TimeoutSessionRunner) DeleteFrom(table string) *dbr.DeleteStmt {
+	stmt := r.SessionRunner.DeleteFrom(table)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+// DeleteBySql creates a DeleteStmt from raw query.
+func (r *TimeoutSessionRunner) DeleteBySql(query string, value ...interface{}) *dbr.DeleteStmt { //nolint:revive,stylecheck
+	stmt := r.SessionRunner.DeleteBySql(query, value...)
+	stmt.Runner = r.wrapRunner(stmt.Runner)
+	return stmt
+}
+
+func (r *TimeoutSessionRunner) wrapRunner(runner dbr.Runner) dbr.Runner {
+	if r.timeout <= 0 {
+		return runner
+	}
+	return &timeoutRunner{Runner: runner, timeout: r.timeout}
+}
+
+// NewTimeoutTxRunner wraps the TxRunner so that statements executed in DoInTx
+// get the default timeout if their contexts have no deadline (see TimeoutSessionRunner).
+// Statements of transactions started by BeginTx are not affected.
+// Non-positive timeout disables wrapping.
+func NewTimeoutTxRunner(runner TxRunner, timeout time.Duration) TxRunner {
+	if timeout <= 0 {
+		return runner
+	}
+	return &timeoutTxRunner{TxRunner: runner, timeout: timeout}
+}
+
+type timeoutTxRunner struct {
+	TxRunner
+	timeout time.Duration
+}
+
+func (r *timeoutTxRunner) DoInTx(ctx context.Context, fn func(runner dbr.SessionRunner) error) error {
+	return r.TxRunner.DoInTx(ctx, func(runner dbr.SessionRunner) error {
+		return fn(NewTimeoutSessionRunner(runner, r.timeout))
+	})
+}
+
+// noDeadlineTimeout is reported to dbr as the statement timeout, so dbr wraps the context of Load* and Exec*
+// calls into a cancelable one and cancels it when the statement (including loading of the rows) is finished.
+// It's long enough to distinguish the deadline set by dbr from the deadline of the caller's context.
+const noDeadlineTimeout = 100 * 365 * 24 * time.Hour
+
+// timeoutRunner applies the timeout to the statement execution if ctx has no deadline.
+// Rows returned by Rows and Iterate methods of dbr statements are owned by the caller,
+// and dbr doesn't wrap their contexts, so the timeout isn't applied to them.
+type timeoutRunner struct {
+	dbr.Runner
+	timeout time.Duration
+}
+
+func (r *timeoutRunner) GetTimeout() time.Duration {
+	if timeout := r.Runner.GetTimeout(); timeout > 0 {
+		return timeout
+	}
+	return noDeadlineTimeout
+}
+
+// withTimeout returns ctx with the timeout if the caller's context has no deadline.
+// ok is false if the timeout shouldn't be applied.
+func (r *timeoutRunner) withTimeout(ctx context.Context) (timeoutCtx context.Context, cancel context.CancelFunc, ok bool) {
+	if deadline, hasDeadline := ctx.Deadline(); hasDeadline && time.Until(deadline) < noDeadlineTimeout/2 {
+		return ctx, nil, false
+	}
+	timeoutCtx, cancel = context.WithTimeout(ctx, r.timeout)
+	return timeoutCtx, cancel, true
+}
+
+func (r *timeoutRunner) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel, ok := r.withTimeout(ctx)
+	if !ok {
+		return r.Runner.ExecContext(ctx, query, args...)
+	}
+	defer cancel()
+	return r.Runner.ExecContext(ctx, query, args...)
+}
+
+func (r *timeoutRunner) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		// The query is executed by Rows or Iterate, so rows may be read after the method returns.
+		return r.Runner.QueryContext(ctx, query, args...)
+	}
+	timeoutCtx, cancel, ok := r.withTimeout(ctx)
+	if !ok {
+		return r.Runner.QueryContext(ctx, query, args...)
+	}
+	// ctx is canceled by dbr after the rows are loaded, so the timeout is released together with the statement.
+	context.AfterFunc(ctx, cancel)
+	rows, err := r.Runner.QueryContext(timeoutCtx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return rows, nil
+}
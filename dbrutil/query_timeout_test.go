@@ -0,0 +1,129 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbrutil
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// deadlineRecordingRunner records contexts passed to the statements and their deadlines.
+type deadlineRecordingRunner struct {
+	dbr.Runner
+	contexts  []context.Context
+	deadlines []time.Time
+}
+
+func (r *deadlineRecordingRunner) record(ctx context.Context) {
+	deadline, _ := ctx.Deadline()
+	r.contexts = append(r.contexts, ctx)
+	r.deadlines = append(r.deadlines, deadline)
+}
+
+func (r *deadlineRecordingRunner) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	r.record(ctx)
+	return r.Runner.ExecContext(ctx, query, args...)
+}
+
+func (r *deadlineRecordingRunner) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	r.record(ctx)
+	return r.Runner.QueryContext(ctx, query, args...)
+}
+
+func TestTimeoutSessionRunner(t *testing.T) {
+	const timeout = time.Minute
+
+	dbConn := openAndSeedDB(t)
+	defer func() { require.NoError(t, dbConn.Close()) }()
+
+	sess := NewTimeoutSessionRunner(dbConn.NewSession(nil), timeout)
+
+	t.Run("timeout is applied if context has no deadline", func(t *testing.T) {
+		stmt := sess.Select("COUNT(*)").From("users")
+		recorder := &deadlineRecordingRunner{Runner: stmt.Runner.(*timeoutRunner).Runner}
+		stmt.Runner.(*timeoutRunner).Runner = recorder
+
+		startedAt := time.Now()
+		var usersCount int
+		require.NoError(t, stmt.LoadOne(&usersCount))
+		require.Equal(t, 5, usersCount)
+		require.Len(t, recorder.deadlines, 1)
+		require.WithinDuration(t, startedAt.Add(timeout), recorder.deadlines[0], time.Second*5)
+		require.ErrorIs(t, recorder.contexts[0].Err(), context.Canceled, "context should be released after loading")
+	})
+
+	t.Run("timeout is not applied to rows owned by caller", func(t *testing.T) {
+		stmt := sess.Select("name").From("users")
+		recorder := &deadlineRecordingRunner{Runner: stmt.Runner.(*timeoutRunner).Runner}
+		stmt.Runner.(*timeoutRunner).Runner = recorder
+
+		rows, err := stmt.Rows()
+		require.NoError(t, err)
+		defer func() { require.NoError(t, rows.Close()) }()
+		var names []string
+		for rows.Next() {
+			var name string
+			require.NoError(t, rows.Scan(&name))
+			names = append(names, name)
+		}
+		require.NoError(t, rows.Err())
+		require.Len(t, names, 5)
+		require.Equal(t, []time.Time{{}}, recorder.deadlines)
+	})
+
+	t.Run("deadline of context is respected", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout*10)
+		defer cancel()
+		ctxDeadline, _ := ctx.Deadline()
+
+		stmt := sess.Update("users").Set("name", "Samuel").Where(dbr.Eq("name", "Sam"))
+		recorder := &deadlineRecordingRunner{Runner: stmt.Runner.(*timeoutRunner).Runner}
+		stmt.Runner.(*timeoutRunner).Runner = recorder
+
+		res, err := stmt.ExecContext(ctx)
+		require.NoError(t, err)
+		affected, err := res.RowsAffected()
+		require.NoError(t, err)
+		require.Equal(t, int64(2), affected)
+		require.Equal(t, []time.Time{ctxDeadline}, recorder.deadlines)
+	})
+
+	t.Run("all statements are wrapped", func(t *testing.T) {
+		require.IsType(t, &timeoutRunner{}, sess.SelectBySql("SELECT 1").Runner)
+		require.IsType(t, &timeoutRunner{}, sess.InsertInto("users").Runner)
+		require.IsType(t, &timeoutRunner{}, sess.InsertBySql("INSERT INTO users(name) VALUES ('Tom')").Runner)
+		require.IsType(t, &timeoutRunner{}, sess.UpdateBySql("UPDATE users SET name = 'Tom'").Runner)
+		require.IsType(t, &timeoutRunner{}, sess.DeleteFrom("users").Runner)
+		require.IsType(t, &timeoutRunner{}, sess.DeleteBySql("DELETE FROM users").Runner)
+	})
+
+	t.Run("non-positive timeout disables wrapping", func(t *testing.T) {
+		require.IsType(t, &dbr.Session{}, NewTimeoutSessionRunner(dbConn.NewSession(nil), 0).Select("1").Runner)
+	})
+}
+
+func TestTimeoutTxRunner(t *testing.T) {
+	dbConn := openAndSeedDB(t)
+	defer func() { require.NoError(t, dbConn.Close()) }()
+
+	txRunner := NewTimeoutTxRunner(NewTxRunner(dbConn, nil, nil), time.Minute)
+	require.NoError(t, txRunner.DoInTx(context.Background(), func(runner dbr.SessionRunner) error {
+		stmt := runner.DeleteFrom("users").Where(dbr.Eq("name", "Albert"))
+		require.IsType(t, &timeoutRunner{}, stmt.Runner)
+		_, err := stmt.Exec()
+		return err
+	}))
+
+	var usersCount int
+	require.NoError(t, dbConn.NewSession(nil).Select("COUNT(*)").From("users").LoadOne(&usersCount))
+	require.Equal(t, 4, usersCount)
+}
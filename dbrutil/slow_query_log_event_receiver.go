@@ -11,22 +11,34 @@ import (
 
 	"github.com/acronis/go-appkit/log"
 	"github.com/gocraft/dbr/v2"
+
+	"github.com/acronis/go-dbkit"
 )
 
 // SlowQueryLogEventReceiverOpts contains options for SlowQueryLogEventReceiver.
 type SlowQueryLogEventReceiverOpts struct {
 	AnnotationPrefix   string
 	AnnotationModifier func(string) string
+
+	// QueryRedaction defines whether the query text is logged (dbkit.QueryRedactionFull is used if empty).
+	// If the query text is logged (masked or as is), slow queries without annotation are logged as well.
+	QueryRedaction dbkit.QueryRedactionMode
+
+	// SampleRate is a fraction of logged slow queries in the (0, 1] range, 0 means all queries.
+	SampleRate float64
 }
 
 // SlowQueryLogEventReceiver implements the dbr.EventReceiver interface and logs long SQL queries.
-// To be logged, SQL query should be annotated (comment starting with specified prefix).
+// To be logged, SQL query should be annotated (comment starting with specified prefix)
+// unless the query text is logged (see SlowQueryLogEventReceiverOpts.QueryRedaction).
 type SlowQueryLogEventReceiver struct {
 	*dbr.NullEventReceiver
 	logger             log.FieldLogger
 	longQueryTime      time.Duration
 	annotationPrefix   string
 	annotationModifier func(string) string
+	queryRedaction     dbkit.QueryRedactionMode
+	sampleRate         float64
 }
 
 // NewSlowQueryLogEventReceiverWithOpts creates a new SlowQueryLogEventReceiver with additional options.
@@ -39,6 +51,8 @@ func NewSlowQueryLogEventReceiverWithOpts(
 		longQueryTime:      longQueryTime,
 		annotationPrefix:   options.AnnotationPrefix,
 		annotationModifier: options.AnnotationModifier,
+		queryRedaction:     options.QueryRedaction,
+		sampleRate:         options.SampleRate,
 	}
 }
 
@@ -58,11 +72,19 @@ func (er *SlowQueryLogEventReceiver) TimingKv(eventName string, nanoseconds int6
 		return
 	}
 	annotation := ParseAnnotationInQuery(kvs["sql"], er.annotationPrefix, er.annotationModifier)
-	if annotation == "" {
+	query := RedactQuery(kvs["sql"], er.queryRedaction)
+	if annotation == "" && query == "" {
 		return
 	}
-	er.logger.Warn("slow SQL query",
+	if !sampled(er.sampleRate) {
+		return
+	}
+	fields := []log.Field{
 		log.String("annotation", annotation),
 		log.Int64("duration_ms", nanoseconds/int64(time.Millisecond)),
-	)
+	}
+	if query != "" {
+		fields = append(fields, log.String("query", query))
+	}
+	er.logger.Warn("slow SQL query", fields...)
 }
@@ -0,0 +1,340 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/config"
+	"github.com/acronis/go-appkit/log"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/mariadb"
+	"github.com/testcontainers/testcontainers-go/modules/mssql"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+// Environment variables with DSNs of existing databases that are used instead of starting containers.
+// EnvPostgresDSN is used for both postgres and pgx dialects.
+const (
+	EnvPostgresDSN = "DBTEST_POSTGRES_DSN"
+	EnvMySQLDSN    = "DBTEST_MYSQL_DSN"
+	EnvMSSQLDSN    = "DBTEST_MSSQL_DSN"
+)
+
+// Default images of the started containers.
+const (
+	DefaultPostgresImage = "postgres:16-alpine"
+	DefaultMySQLImage    = "mariadb:11.0.3"
+	DefaultMSSQLImage    = "mcr.microsoft.com/mssql/server:2022-CU10-ubuntu-22.04"
+)
+
+// DefaultStartTimeout is a default timeout for starting the container and opening the database in Open.
+const DefaultStartTimeout = 2 * time.Minute
+
+const (
+	testDBUser     = "root"
+	testDBPassword = "password"
+	testDBName     = "testdb"
+	// MSSQL requires a password that satisfies its complexity policy.
+	testMSSQLPassword = "dbtest-Passw0rd"
+)
+
+const (
+	testConnMaxLifetime = 3 * time.Minute
+	testMaxOpenConns    = 16
+	testMaxIdleConns    = 16
+)
+
+// Database is a database opened for tests.
+type Database struct {
+	// DB is the opened connection pool.
+	DB *sql.DB
+	// Config describes the database, it may be used for opening additional connection pools
+	// or for testing code that accepts the configuration.
+	Config *dbkit.Config
+
+	stop func(ctx context.Context) error
+}
+
+// Close closes the connection pool and terminates the container (if it was started).
+func (d *Database) Close(ctx context.Context) error {
+	var resErr error
+	if err := d.DB.Close(); err != nil {
+		resErr = fmt.Errorf("close db: %w", err)
+	}
+	if d.stop != nil {
+		if err := d.stop(ctx); err != nil {
+			resErr = fmt.Errorf("stop db container: %w", err)
+		}
+	}
+	return resErr
+}
+
+// Option is a functional option for Run and Open.
+type Option func(*runOptions)
+
+type runOptions struct {
+	image        string
+	startTimeout time.Duration
+	migrations   []migrate.Migration
+}
+
+// WithImage sets the image of the started container (e.g., "mysql:8.0" or "postgres:13").
+// By default, DefaultPostgresImage, DefaultMySQLImage or DefaultMSSQLImage is used depending on the dialect.
+func WithImage(image string) Option {
+	return func(opts *runOptions) {
+		opts.image = image
+	}
+}
+
+// WithStartTimeout sets the timeout for starting the container and opening the database in Open.
+// By default, DefaultStartTimeout is used.
+func WithStartTimeout(timeout time.Duration) Option {
+	return func(opts *runOptions) {
+		opts.startTimeout = timeout
+	}
+}
+
+// WithMigrations sets migrations that are applied to the opened database.
+func WithMigrations(migrations ...migrate.Migration) Option {
+	return func(opts *runOptions) {
+		opts.migrations = append(opts.migrations, migrations...)
+	}
+}
+
+// Open returns the database for the test. The database is closed (and the container is terminated)
+// when the test and all its subtests complete.
+// The test is skipped if there is no DSN in the environment and Docker is not available.
+func Open(t *testing.T, dialect dbkit.Dialect, options ...Option) *Database {
+	t.Helper()
+	opts := makeOptions(options)
+	if os.Getenv(envDSNName(dialect)) == "" {
+		testcontainers.SkipIfProviderIsNotHealthy(t)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opts.startTimeout)
+	defer cancel()
+	db, err := Run(ctx, dialect, options...)
+	if err != nil {
+		t.Fatalf("run test db: %v", err)
+	}
+	t.Cleanup(func() {
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), opts.startTimeout)
+		defer stopCancel()
+		if closeErr := db.Close(stopCtx); closeErr != nil {
+			t.Errorf("close test db: %v", closeErr)
+		}
+	})
+	return db
+}
+
+// Run returns the database of the given dialect. It uses the DSN from the environment variable if it's set
+// and starts the container otherwise. Database.Close must be called when the database is not needed anymore.
+// It may be used in TestMain, when the database is shared between tests.
+func Run(ctx context.Context, dialect dbkit.Dialect, options ...Option) (db *Database, err error) {
+	opts := makeOptions(options)
+	dsn := os.Getenv(envDSNName(dialect))
+	var stop func(ctx context.Context) error
+	if dsn == "" {
+		if dsn, stop, err = startContainer(ctx, dialect, opts); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err != nil {
+				_ = stop(ctx)
+			}
+		}()
+	}
+
+	cfg, err := makeConfig(dialect, dsn)
+	if err != nil {
+		return nil, err
+	}
+	dbConn, err := dbkit.Open(cfg, false)
+	if err != nil {
+		return nil, fmt.Errorf("open db: %w", err)
+	}
+	if err = dbConn.PingContext(ctx); err != nil {
+		_ = dbConn.Close()
+		return nil, fmt.Errorf("ping db: %w", err)
+	}
+	if err = applyMigrations(dbConn, dialect, opts.migrations); err != nil {
+		_ = dbConn.Close()
+		return nil, err
+	}
+	return &Database{DB: dbConn, Config: cfg, stop: stop}, nil
+}
+
+func applyMigrations(dbConn *sql.DB, dialect dbkit.Dialect, migrations []migrate.Migration) error {
+	if len(migrations) == 0 {
+		return nil
+	}
+	mm, err := migrate.NewMigrationsManager(dbConn, dialect, log.NewDisabledLogger())
+	if err != nil {
+		return fmt.Errorf("create migrations manager: %w", err)
+	}
+	if err = mm.Run(migrations, migrate.MigrationsDirectionUp); err != nil {
+		return fmt.Errorf("apply migrations: %w", err)
+	}
+	return nil
+}
+
+func makeOptions(options []Option) runOptions {
+	opts := runOptions{startTimeout: DefaultStartTimeout}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
+
+func envDSNName(dialect dbkit.Dialect) string {
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		return EnvPostgresDSN
+	case dbkit.DialectMySQL:
+		return EnvMySQLDSN
+	case dbkit.DialectMSSQL:
+		return EnvMSSQLDSN
+	default:
+		return ""
+	}
+}
+
+// makeConfig makes the configuration of the database from its DSN.
+func makeConfig(dialect dbkit.Dialect, dsn string) (*dbkit.Config, error) {
+	cfg := dbkit.NewDefaultConfig([]dbkit.Dialect{dialect})
+	cfg.Dialect = dialect
+	cfg.MaxOpenConns = testMaxOpenConns
+	cfg.MaxIdleConns = testMaxIdleConns
+	cfg.ConnMaxLifetime = config.TimeDuration(testConnMaxLifetime)
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		pgCfg, err := dbkit.ParsePostgresDSN(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("parse postgres dsn: %w", err)
+		}
+		cfg.Postgres = *pgCfg
+	case dbkit.DialectMySQL:
+		mySQLCfg, err := dbkit.ParseMySQLDSN(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("parse mysql dsn: %w", err)
+		}
+		cfg.MySQL = *mySQLCfg
+	case dbkit.DialectMSSQL:
+		mssqlCfg, err := dbkit.ParseMSSQLDSN(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("parse mssql dsn: %w", err)
+		}
+		cfg.MSSQL = *mssqlCfg
+	default:
+		return nil, fmt.Errorf("unsupported sql dialect %q", dialect)
+	}
+	return cfg, nil
+}
+
+func startContainer(
+	ctx context.Context, dialect dbkit.Dialect, opts runOptions,
+) (dsn string, stop func(ctx context.Context) error, err error) {
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		if dsn, stop, err = startPostgresContainer(ctx, imageOrDefault(opts.image, DefaultPostgresImage)); err != nil {
+			return "", nil, fmt.Errorf("start postgres container: %w", err)
+		}
+	case dbkit.DialectMySQL:
+		if dsn, stop, err = startMySQLContainer(ctx, imageOrDefault(opts.image, DefaultMySQLImage)); err != nil {
+			return "", nil, fmt.Errorf("start mysql container: %w", err)
+		}
+	case dbkit.DialectMSSQL:
+		if dsn, stop, err = startMSSQLContainer(ctx, imageOrDefault(opts.image, DefaultMSSQLImage)); err != nil {
+			return "", nil, fmt.Errorf("start mssql container: %w", err)
+		}
+	default:
+		return "", nil, fmt.Errorf("unsupported sql dialect %q", dialect)
+	}
+	return dsn, stop, nil
+}
+
+func imageOrDefault(image, defaultImage string) string {
+	if image == "" {
+		return defaultImage
+	}
+	return image
+}
+
+func startPostgresContainer(ctx context.Context, image string) (dsn string, stop func(ctx context.Context) error, err error) {
+	postgresContainer, err := postgres.Run(ctx,
+		image,
+		postgres.WithDatabase(testDBName),
+		postgres.WithUsername(testDBUser),
+		postgres.WithPassword(testDBPassword),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(10*time.Second)),
+	)
+	if err != nil {
+		return "", nil, fmt.Errorf("create container: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = postgresContainer.Terminate(ctx)
+		}
+	}()
+	if dsn, err = postgresContainer.ConnectionString(ctx, "sslmode=disable"); err != nil {
+		return "", nil, fmt.Errorf("get connection string: %w", err)
+	}
+	return dsn, postgresContainer.Terminate, nil
+}
+
+func startMySQLContainer(ctx context.Context, image string) (dsn string, stop func(ctx context.Context) error, err error) {
+	mariaDBContainer, err := mariadb.Run(ctx,
+		image,
+		mariadb.WithDatabase(testDBName),
+		mariadb.WithUsername(testDBUser),
+		mariadb.WithPassword(testDBPassword),
+	)
+	if err != nil {
+		return "", nil, fmt.Errorf("create container: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = mariaDBContainer.Terminate(ctx)
+		}
+	}()
+	if dsn, err = mariaDBContainer.ConnectionString(ctx, "parseTime=true"); err != nil {
+		return "", nil, fmt.Errorf("get connection string: %w", err)
+	}
+	return dsn, mariaDBContainer.Terminate, nil
+}
+
+func startMSSQLContainer(ctx context.Context, image string) (dsn string, stop func(ctx context.Context) error, err error) {
+	mssqlContainer, err := mssql.Run(ctx,
+		image,
+		mssql.WithAcceptEULA(),
+		mssql.WithPassword(testMSSQLPassword),
+	)
+	if err != nil {
+		return "", nil, fmt.Errorf("create container: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = mssqlContainer.Terminate(ctx)
+		}
+	}()
+	if dsn, err = mssqlContainer.ConnectionString(ctx); err != nil {
+		return "", nil, fmt.Errorf("get connection string: %w", err)
+	}
+	return dsn, mssqlContainer.Terminate, nil
+}
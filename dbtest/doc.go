@@ -0,0 +1,23 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package dbtest provides databases for integration tests.
+// Open (or Run outside of tests) starts a Postgres, MySQL (MariaDB) or MSSQL container using testcontainers
+// and returns the opened *sql.DB together with the dbkit.Config describing it. The container is terminated
+// when the test finishes. If the DSN of an existing database is set in the environment variable
+// (see EnvPostgresDSN, EnvMySQLDSN and EnvMSSQLDSN), it's used instead, so tests may run in CI environments
+// without Docker. If neither the DSN nor Docker is available, the test is skipped.
+//
+// NewIsolatedDB creates a uniquely named database (or Postgres schema) on the given server for each test,
+// applies migrations and drops it on cleanup, so parallel integration tests may share one server.
+//
+// For unit tests of retry and transaction logic without a real database, NewMock creates a sqlmock database
+// with MockDriver, for which errors wrapping ErrRetryable (see RetryableError) are retryable,
+// and ExpectTx, ExpectTxRollback, ExpectTxRetries and other helpers set expectations of dbkit.DoInTx attempts.
+//
+// The package doesn't register database drivers, so the corresponding dialect package
+// (e.g., github.com/acronis/go-dbkit/pgx) should be imported by the test.
+package dbtest
@@ -0,0 +1,148 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbtest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/acronis/go-dbkit"
+)
+
+const maxIsolatedNamePrefixLen = 40
+
+// NewIsolatedDB creates a uniquely named database (a schema for Postgres, a file for SQLite)
+// on the server described by cfg, applies migrations (see WithMigrations) and returns it.
+// The database is dropped when the test and all its subtests complete,
+// so parallel integration tests may share one server without interfering with each other.
+// cfg is not modified, the returned Database.Config points to the created database.
+func NewIsolatedDB(t *testing.T, cfg *dbkit.Config, options ...Option) *Database {
+	t.Helper()
+	opts := makeOptions(options)
+	ctx, cancel := context.WithTimeout(context.Background(), opts.startTimeout)
+	defer cancel()
+
+	name, err := makeIsolatedName(t.Name())
+	if err != nil {
+		t.Fatalf("make isolated db name: %v", err)
+	}
+	isolatedCfg, drop, err := createIsolatedDB(ctx, cfg, name, t.TempDir())
+	if err != nil {
+		t.Fatalf("create isolated db: %v", err)
+	}
+	t.Cleanup(func() {
+		dropCtx, dropCancel := context.WithTimeout(context.Background(), opts.startTimeout)
+		defer dropCancel()
+		if dropErr := drop(dropCtx); dropErr != nil {
+			t.Errorf("drop isolated db %s: %v", name, dropErr)
+		}
+	})
+
+	dbConn, err := dbkit.Open(isolatedCfg, false)
+	if err != nil {
+		t.Fatalf("open isolated db: %v", err)
+	}
+	// Registered after drop, so the connection pool is closed before the database is dropped.
+	t.Cleanup(func() { _ = dbConn.Close() })
+	if err = dbConn.PingContext(ctx); err != nil {
+		t.Fatalf("ping isolated db: %v", err)
+	}
+	if err = applyMigrations(dbConn, isolatedCfg.Dialect, opts.migrations); err != nil {
+		t.Fatalf("migrate isolated db: %v", err)
+	}
+	return &Database{DB: dbConn, Config: isolatedCfg}
+}
+
+// makeIsolatedName makes the unique name of the database that is valid as an identifier in all dialects.
+func makeIsolatedName(testName string) (string, error) {
+	var b strings.Builder
+	b.WriteString("test_")
+	for _, r := range strings.ToLower(testName) {
+		if b.Len() >= maxIsolatedNamePrefixLen {
+			break
+		}
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	b.WriteByte('_')
+	b.WriteString(hex.EncodeToString(suffix))
+	return b.String(), nil
+}
+
+func createIsolatedDB(
+	ctx context.Context, cfg *dbkit.Config, name string, tempDir string,
+) (isolatedCfg *dbkit.Config, drop func(ctx context.Context) error, err error) {
+	isolatedCfg = copyConfig(cfg)
+	var createQuery, dropQuery string
+	switch cfg.Dialect {
+	case dbkit.DialectSQLite:
+		isolatedCfg.SQLite.Path = filepath.Join(tempDir, name+".db")
+		return isolatedCfg, func(context.Context) error { return nil }, nil // The file is removed with the temp dir.
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		isolatedCfg.Postgres.SearchPath = name
+		createQuery = `CREATE SCHEMA "` + name + `"`
+		dropQuery = `DROP SCHEMA IF EXISTS "` + name + `" CASCADE`
+	case dbkit.DialectMySQL:
+		isolatedCfg.MySQL.Database = name
+		createQuery = "CREATE DATABASE `" + name + "`"
+		dropQuery = "DROP DATABASE IF EXISTS `" + name + "`"
+	case dbkit.DialectMSSQL:
+		isolatedCfg.MSSQL.Database = name
+		createQuery = "CREATE DATABASE [" + name + "]"
+		dropQuery = "IF DB_ID('" + name + "') IS NOT NULL BEGIN " +
+			"ALTER DATABASE [" + name + "] SET SINGLE_USER WITH ROLLBACK IMMEDIATE; DROP DATABASE [" + name + "] END"
+	default:
+		return nil, nil, fmt.Errorf("unsupported sql dialect %q", cfg.Dialect)
+	}
+
+	adminConn, err := dbkit.Open(cfg, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open db: %w", err)
+	}
+	if _, err = adminConn.ExecContext(ctx, createQuery); err != nil {
+		_ = adminConn.Close()
+		return nil, nil, err
+	}
+	return isolatedCfg, func(ctx context.Context) error {
+		defer func() { _ = adminConn.Close() }()
+		_, dropErr := adminConn.ExecContext(ctx, dropQuery)
+		return dropErr
+	}, nil
+}
+
+// copyConfig returns the copy of the configuration that may be modified without affecting the original one.
+func copyConfig(cfg *dbkit.Config) *dbkit.Config {
+	cfgCopy := *cfg
+	cfgCopy.Postgres.AdditionalParameters = copyParameters(cfg.Postgres.AdditionalParameters)
+	cfgCopy.MySQL.AdditionalParameters = copyParameters(cfg.MySQL.AdditionalParameters)
+	cfgCopy.MSSQL.AdditionalParameters = copyParameters(cfg.MSSQL.AdditionalParameters)
+	cfgCopy.Postgres.Hosts = append([]string(nil), cfg.Postgres.Hosts...)
+	return &cfgCopy
+}
+
+func copyParameters(params map[string]string) map[string]string {
+	if params == nil {
+		return nil
+	}
+	result := make(map[string]string, len(params))
+	for k, v := range params {
+		result[k] = v
+	}
+	return result
+}
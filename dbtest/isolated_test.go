@@ -0,0 +1,62 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbtest
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+	_ "github.com/acronis/go-dbkit/sqlite"
+)
+
+func TestNewIsolatedDB(t *testing.T) {
+	cfg := dbkit.NewDefaultConfig(nil)
+	cfg.Dialect = dbkit.DialectSQLite
+	cfg.SQLite.Path = "shared.db"
+	migration := migrate.NewCustomMigration("00001_create_users",
+		[]string{`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`}, []string{`DROP TABLE users`}, nil, nil)
+
+	for _, name := range []string{"first", "second"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			db := NewIsolatedDB(t, cfg, WithMigrations(migration))
+			require.NotEqual(t, cfg.SQLite.Path, db.Config.SQLite.Path)
+			_, err := db.DB.ExecContext(context.Background(), `INSERT INTO users (id, name) VALUES (1, ?)`, name)
+			require.NoError(t, err, "databases of parallel tests must be isolated")
+		})
+	}
+	require.Equal(t, "shared.db", cfg.SQLite.Path)
+}
+
+func TestMakeIsolatedName(t *testing.T) {
+	name1, err := makeIsolatedName("TestUsers/Create-Duplicated")
+	require.NoError(t, err)
+	require.Regexp(t, regexp.MustCompile(`^test_testusers_create_duplicated_[0-9a-f]{8}$`), name1)
+	name2, err := makeIsolatedName("TestUsers/Create-Duplicated")
+	require.NoError(t, err)
+	require.NotEqual(t, name1, name2)
+
+	longName, err := makeIsolatedName("TestVeryLongNameOfTheTestThatExceedsTheLimitOfIdentifiers")
+	require.NoError(t, err)
+	require.Len(t, longName, maxIsolatedNamePrefixLen+9)
+}
+
+func TestCopyConfig(t *testing.T) {
+	cfg := dbkit.NewDefaultConfig(nil)
+	cfg.Postgres.AdditionalParameters = map[string]string{"application_name": "tests"}
+	cfgCopy := copyConfig(cfg)
+	cfgCopy.Postgres.AdditionalParameters["application_name"] = "changed"
+	cfgCopy.Postgres.SearchPath = "test_schema"
+	require.Equal(t, "tests", cfg.Postgres.AdditionalParameters["application_name"])
+	require.Empty(t, cfg.Postgres.SearchPath)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// ErrRetryable is a sentinel error that is retryable for MockDriver.
+// Errors wrapping it (see RetryableError) may be returned from sqlmock expectations
+// to test retry paths (e.g., of dbkit.DoInTx with dbkit.WithRetryPolicy).
+var ErrRetryable = errors.New("retryable mock error")
+
+// RetryableError wraps the error, so it's retryable for MockDriver.
+func RetryableError(err error) error {
+	return &retryableError{err: err}
+}
+
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() []error {
+	return []error{e.err, ErrRetryable}
+}
+
+// MockDriver is the driver of databases created by NewMock. It wraps the sqlmock driver,
+// so the IsRetryable function may be registered for it in dbkit without affecting other users of sqlmock.
+// Errors wrapping ErrRetryable are registered as retryable by default,
+// other functions may be registered with dbkit.RegisterIsRetryableFunc(&dbtest.MockDriver{}, ...).
+type MockDriver struct {
+	drv driver.Driver
+}
+
+var _ driver.Driver = (*MockDriver)(nil)
+
+// Open opens the connection of the underlying sqlmock driver.
+func (d *MockDriver) Open(dsn string) (driver.Conn, error) {
+	if d.drv == nil {
+		return nil, errors.New("mock driver is not initialized, use NewMock")
+	}
+	return d.drv.Open(dsn)
+}
+
+func init() {
+	dbkit.RegisterIsRetryableFunc(&MockDriver{}, func(err error) bool {
+		return errors.Is(err, ErrRetryable)
+	})
+}
+
+var mockDSNCounter int64
+
+// MockOption is a functional option for NewMock.
+type MockOption func(*mockOptions)
+
+type mockOptions struct {
+	queryMatcher sqlmock.QueryMatcher
+}
+
+// WithMockQueryMatcher sets the matcher of expected SQL queries (e.g., sqlmock.QueryMatcherEqual).
+// By default, sqlmock.QueryMatcherRegexp is used.
+func WithMockQueryMatcher(matcher sqlmock.QueryMatcher) MockOption {
+	return func(opts *mockOptions) {
+		opts.queryMatcher = matcher
+	}
+}
+
+// NewMock creates a mock database with MockDriver and the sqlmock to manage expectations.
+// The database is closed when the test completes.
+func NewMock(t *testing.T, options ...MockOption) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	opts := mockOptions{queryMatcher: sqlmock.QueryMatcherRegexp}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	dsn := fmt.Sprintf("dbtest_mock_%d", atomic.AddInt64(&mockDSNCounter, 1))
+	mockDB, mock, err := sqlmock.NewWithDSN(dsn, sqlmock.QueryMatcherOption(opts.queryMatcher))
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	db := sql.OpenDB(&mockConnector{dsn: dsn, drv: &MockDriver{drv: mockDB.Driver()}})
+	t.Cleanup(func() {
+		// Errors are ignored, since the close of the connection may be not expected by the test.
+		_ = db.Close()
+		_ = mockDB.Close()
+	})
+	return db, mock
+}
+
+type mockConnector struct {
+	dsn string
+	drv *MockDriver
+}
+
+func (c *mockConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.drv.Open(c.dsn)
+}
+
+func (c *mockConnector) Driver() driver.Driver {
+	return c.drv
+}
+
+// ExpectTx expects the transaction that begins, executes statements set by expectStatements (may be nil),
+// and commits.
+func ExpectTx(mock sqlmock.Sqlmock, expectStatements func()) {
+	mock.ExpectBegin()
+	if expectStatements != nil {
+		expectStatements()
+	}
+	mock.ExpectCommit()
+}
+
+// ExpectTxRollback expects the transaction that begins, executes statements set by expectStatements (may be nil),
+// and rolls back (e.g., because one of the statements fails).
+func ExpectTxRollback(mock sqlmock.Sqlmock, expectStatements func()) {
+	mock.ExpectBegin()
+	if expectStatements != nil {
+		expectStatements()
+	}
+	mock.ExpectRollback()
+}
+
+// ExpectTxBeginError expects the transaction that fails to begin with the error.
+func ExpectTxBeginError(mock sqlmock.Sqlmock, err error) {
+	mock.ExpectBegin().WillReturnError(err)
+}
+
+// ExpectTxCommitError expects the transaction that begins, executes statements set by expectStatements (may be nil),
+// and fails to commit with the error.
+func ExpectTxCommitError(mock sqlmock.Sqlmock, expectStatements func(), err error) {
+	mock.ExpectBegin()
+	if expectStatements != nil {
+		expectStatements()
+	}
+	mock.ExpectCommit().WillReturnError(err)
+}
+
+// ExpectTxRetries expects the transaction that is retried after failing with each of errs and finally commits.
+// expectStatements is called for each attempt with the error that the attempt should fail with
+// (nil for the last, successful one), so the statements of the failed attempts are expected to return it, e.g.:
+//
+//	dbtest.ExpectTxRetries(mock, func(attemptErr error) {
+//		exec := mock.ExpectExec("UPDATE users")
+//		if attemptErr != nil {
+//			exec.WillReturnError(attemptErr)
+//			return
+//		}
+//		exec.WillReturnResult(sqlmock.NewResult(0, 1))
+//	}, dbtest.RetryableError(errDeadlock))
+func ExpectTxRetries(mock sqlmock.Sqlmock, expectStatements func(attemptErr error), errs ...error) {
+	for _, err := range errs {
+		attemptErr := err
+		ExpectTxRollback(mock, func() { expectStatements(attemptErr) })
+	}
+	ExpectTx(mock, func() { expectStatements(nil) })
+}
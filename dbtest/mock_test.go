@@ -0,0 +1,91 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/acronis/go-appkit/retry"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+func TestNewMock(t *testing.T) {
+	db, mock := NewMock(t, WithMockQueryMatcher(sqlmock.QueryMatcherEqual))
+	require.IsType(t, &MockDriver{}, db.Driver())
+
+	isRetryable := dbkit.GetIsRetryable(db.Driver())
+	require.True(t, isRetryable(RetryableError(errors.New("deadlock"))))
+	require.True(t, isRetryable(errors.Join(errors.New("exec"), ErrRetryable)))
+	require.False(t, isRetryable(errors.New("syntax error")))
+	require.EqualError(t, RetryableError(errors.New("deadlock")), "deadlock")
+
+	mock.ExpectExec("DELETE FROM users").WillReturnResult(sqlmock.NewResult(0, 3))
+	result, err := db.Exec("DELETE FROM users")
+	require.NoError(t, err)
+	affected, err := result.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), affected)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpectTxRetries(t *testing.T) {
+	db, mock := NewMock(t)
+	errDeadlock := RetryableError(errors.New("deadlock"))
+	ExpectTxRetries(mock, func(attemptErr error) {
+		exec := mock.ExpectExec("UPDATE users")
+		if attemptErr != nil {
+			exec.WillReturnError(attemptErr)
+			return
+		}
+		exec.WillReturnResult(sqlmock.NewResult(0, 1))
+	}, errDeadlock, errDeadlock)
+
+	attempts := 0
+	err := dbkit.DoInTx(context.Background(), db, func(tx *sql.Tx) error {
+		attempts++
+		_, execErr := tx.Exec("UPDATE users SET name = 'bob'")
+		return execErr
+	}, dbkit.WithRetryPolicy(retry.NewConstantBackoffPolicy(time.Millisecond, 5)))
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpectTx(t *testing.T) {
+	ctx := context.Background()
+	db, mock := NewMock(t)
+	noop := func(tx *sql.Tx) error { return nil }
+
+	ExpectTx(mock, nil)
+	require.NoError(t, dbkit.DoInTx(ctx, db, noop))
+
+	errFn := errors.New("fn failed")
+	ExpectTxRollback(mock, nil)
+	require.ErrorIs(t, dbkit.DoInTx(ctx, db, func(tx *sql.Tx) error { return errFn }), errFn)
+
+	errBegin := errors.New("begin failed")
+	ExpectTxBeginError(mock, errBegin)
+	require.ErrorIs(t, dbkit.DoInTx(ctx, db, noop), errBegin)
+
+	errCommit := errors.New("commit failed")
+	ExpectTxCommitError(mock, func() {
+		mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	}, errCommit)
+	require.ErrorIs(t, dbkit.DoInTx(ctx, db, func(tx *sql.Tx) error {
+		var one int
+		return tx.QueryRow("SELECT 1").Scan(&one)
+	}), errCommit)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,119 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// Default values for AcquireWait options.
+const (
+	DefaultAcquireRetryInterval = 100 * time.Millisecond
+	DefaultAcquireRetryJitter   = 0.2
+)
+
+type acquireWaitOptions struct {
+	retryInterval    time.Duration
+	maxRetryInterval time.Duration
+	jitter           float64
+}
+
+// AcquireWaitOption is an option for AcquireWait method.
+type AcquireWaitOption func(*acquireWaitOptions)
+
+// WithAcquireRetryInterval sets the (initial) interval between attempts to acquire the lock.
+// By default, DefaultAcquireRetryInterval is used.
+func WithAcquireRetryInterval(interval time.Duration) AcquireWaitOption {
+	return func(o *acquireWaitOptions) {
+		o.retryInterval = interval
+	}
+}
+
+// WithAcquireMaxRetryInterval enables exponential backoff between attempts to acquire the lock.
+// The interval is doubled after each unsuccessful attempt but doesn't exceed the passed value.
+// By default, the interval is constant.
+func WithAcquireMaxRetryInterval(interval time.Duration) AcquireWaitOption {
+	return func(o *acquireWaitOptions) {
+		o.maxRetryInterval = interval
+	}
+}
+
+// WithAcquireRetryJitter sets the randomization factor (from 0 to 1) for the interval between attempts to acquire the lock.
+// It helps to avoid the thundering herd problem when many processes are waiting for the same lock.
+// By default, DefaultAcquireRetryJitter is used.
+func WithAcquireRetryJitter(jitter float64) AcquireWaitOption {
+	return func(o *acquireWaitOptions) {
+		o.jitter = jitter
+	}
+}
+
+// AcquireWait acquires lock for the key in the database, and if it's already acquired by someone else,
+// retries (each attempt is made in a separate transaction) until the lock is obtained or ctx is done.
+// It returns how long it waited for the lock.
+// If ctx is done before the lock is obtained, returned error wraps both ErrLockAlreadyAcquired and ctx.Err()
+// (and LockHeldError describing the last observed holder if owner tracking is enabled, see WithOwnerTracking).
+func (l *DBLock) AcquireWait(
+	ctx context.Context, dbConn *sql.DB, lockTTL time.Duration, options ...AcquireWaitOption,
+) (time.Duration, error) {
+	opts := acquireWaitOptions{retryInterval: DefaultAcquireRetryInterval, jitter: DefaultAcquireRetryJitter}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	b := newAcquireBackOff(opts)
+
+	clock := l.manager.clock
+	startedAt := clock.Now()
+	var heldErr error = ErrLockAlreadyAcquired
+	for attempt := 0; ; attempt++ {
+		err := dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+			return l.Acquire(ctx, tx, lockTTL)
+		})
+		if err == nil {
+			return clock.Now().Sub(startedAt), nil
+		}
+		if !errors.Is(err, ErrLockAlreadyAcquired) {
+			if attempt > 0 && ctx.Err() != nil {
+				// Context was done while we were waiting for the lock that is held by someone else.
+				return clock.Now().Sub(startedAt), fmt.Errorf("%w: %w", heldErr, ctx.Err())
+			}
+			return clock.Now().Sub(startedAt), err
+		}
+		heldErr = err
+
+		timer := clock.NewTimer(b.NextBackOff())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return clock.Now().Sub(startedAt), fmt.Errorf("%w: %w", heldErr, ctx.Err())
+		case <-timer.C():
+		}
+	}
+}
+
+func newAcquireBackOff(opts acquireWaitOptions) backoff.BackOff {
+	multiplier := 1.0
+	maxInterval := opts.retryInterval
+	if opts.maxRetryInterval > opts.retryInterval {
+		multiplier = 2
+		maxInterval = opts.maxRetryInterval
+	}
+	return backoff.NewExponentialBackOff(
+		backoff.WithInitialInterval(opts.retryInterval),
+		backoff.WithMaxInterval(maxInterval),
+		backoff.WithMultiplier(multiplier),
+		backoff.WithRandomizationFactor(opts.jitter),
+		backoff.WithMaxElapsedTime(0),
+	)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	"database/sql/driver"
+	gotesting "testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func expectLockAcquisition(mock sqlmock.Sqlmock, lock DBLock, lockTTL time.Duration, rowsAffected int64) {
+	mock.ExpectBegin()
+	args := []driver.Value{mySQLMakeInterval(lockTTL), sqlmock.AnyArg(), lock.Key, sqlmock.AnyArg()}
+	if lock.manager.trackOwner {
+		args = []driver.Value{mySQLMakeInterval(lockTTL), sqlmock.AnyArg(), lock.manager.owner, lock.Key, sqlmock.AnyArg()}
+	}
+	mock.ExpectExec(lock.manager.queries.acquireLock).WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+	if rowsAffected == 0 {
+		mock.ExpectRollback()
+		return
+	}
+	mock.ExpectCommit()
+}
+
+func TestDBLock_AcquireWait(t *gotesting.T) {
+	const lockTTL = time.Minute
+	const retryInterval = 20 * time.Millisecond
+
+	t.Run("lock is acquired after several attempts", func(t *gotesting.T) {
+		db, mock, lock := newMockedLock(t, 0)
+		defer func() { _ = db.Close() }()
+
+		expectLockAcquisition(mock, lock, lockTTL, 0)
+		expectLockAcquisition(mock, lock, lockTTL, 0)
+		expectLockAcquisition(mock, lock, lockTTL, 1)
+
+		waited, err := lock.AcquireWait(context.Background(), db, lockTTL,
+			WithAcquireRetryInterval(retryInterval), WithAcquireRetryJitter(0))
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, waited, 2*retryInterval)
+		require.Equal(t, lockTTL, lock.TTL)
+		require.NotEmpty(t, lock.Token())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("context is done before lock is acquired", func(t *gotesting.T) {
+		db, mock, lock := newMockedLock(t, 0)
+		defer func() { _ = db.Close() }()
+		mock.MatchExpectationsInOrder(false)
+		for i := 0; i < 10; i++ {
+			expectLockAcquisition(mock, lock, lockTTL, 0)
+		}
+
+		ctx, ctxCancel := context.WithTimeout(context.Background(), retryInterval*3)
+		defer ctxCancel()
+		waited, err := lock.AcquireWait(ctx, db, lockTTL,
+			WithAcquireRetryInterval(retryInterval), WithAcquireMaxRetryInterval(retryInterval*2))
+		require.ErrorIs(t, err, ErrLockAlreadyAcquired)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.GreaterOrEqual(t, waited, retryInterval*3)
+	})
+}
@@ -0,0 +1,156 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// LockInfo contains information about the lock stored in the database.
+// It's intended to be used by ops tooling for inspecting stuck locks.
+type LockInfo struct {
+	Key string
+	// Token is the token of the last holder of the lock. It's empty if the lock has never been acquired.
+	Token string
+	// ExpireAt is the expiration time of the lock. It's zero if the lock is released or has never been acquired.
+	ExpireAt time.Time
+	// Held is true if the lock is acquired and not expired yet (according to the database clock).
+	Held bool
+	// Owner is the owner identity of the last holder of the lock (see WithLockOwner).
+	Owner string
+	// AcquiredAt is the time when the lock was acquired by the last holder.
+	AcquiredAt time.Time
+}
+
+// SQLQueryExecutor is an interface for executing SQL queries that return rows (e.g., *sql.DB or *sql.Tx).
+type SQLQueryExecutor interface {
+	SQLExecutor
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// ListLocks returns information about all locks stored in the database ordered by key.
+func (m *DBManager) ListLocks(ctx context.Context, executor SQLQueryExecutor) ([]LockInfo, error) {
+	rows, err := executor.QueryContext(ctx, m.queries.listLocks)
+	if err != nil {
+		return nil, fmt.Errorf("list locks: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var locks []LockInfo
+	for rows.Next() {
+		lockInfo, scanErr := m.queries.scanLockInfo(rows)
+		if scanErr != nil {
+			return nil, fmt.Errorf("scan lock: %w", scanErr)
+		}
+		locks = append(locks, lockInfo)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list locks: %w", err)
+	}
+	return locks, nil
+}
+
+// GetLock returns information about the lock with the given key.
+// ErrLockNotFound is returned if there is no such lock in the database.
+func (m *DBManager) GetLock(ctx context.Context, executor SQLQueryExecutor, key string) (LockInfo, error) {
+	rows, err := executor.QueryContext(ctx, m.queries.getLock, key)
+	if err != nil {
+		return LockInfo{}, fmt.Errorf("get lock with key %s: %w", key, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return LockInfo{}, fmt.Errorf("get lock with key %s: %w", key, err)
+		}
+		return LockInfo{}, ErrLockNotFound
+	}
+	lockInfo, err := m.queries.scanLockInfo(rows)
+	if err != nil {
+		return LockInfo{}, fmt.Errorf("scan lock with key %s: %w", key, err)
+	}
+	return lockInfo, nil
+}
+
+// ForceRelease releases the lock with the given key regardless of its holder.
+// The current holder (if any) will fail to extend the lock with ErrLockAlreadyReleased error.
+// ErrLockAlreadyReleased is returned if the lock is not acquired (or doesn't exist).
+// As DBLock.Release, it notifies watchers if the manager is created with WithReleaseNotifications option.
+// Use it only for recovering from stuck locks since it breaks the mutual exclusion guarantee.
+func (m *DBManager) ForceRelease(ctx context.Context, executor SQLExecutor, key string) error {
+	if err := execQueryAndCheckAffectedRow(ctx, executor,
+		m.queries.forceRelease, []interface{}{key}, ErrLockAlreadyReleased); err != nil {
+		return err
+	}
+	return m.notifyReleased(ctx, executor, key)
+}
+
+// CleanupExpired marks all expired locks as released and returns the number of such locks.
+// Lock rows themselves are kept, so already initialized DBLock objects continue working.
+// As DBLock.Release, it notifies watchers if the manager is created with WithReleaseNotifications option.
+func (m *DBManager) CleanupExpired(ctx context.Context, executor SQLExecutor) (int64, error) {
+	query, args := m.queries.cleanupLocks, []interface{}(nil)
+	if m.notifyRelease {
+		// Released locks are notified by the same query, the number of notifications is the number of released locks.
+		query, args = m.queries.cleanupAndNotify, []interface{}{m.releaseChannel()}
+	}
+	result, err := executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("cleanup expired locks: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func postgresScanLockInfo(scanner rowScanner) (LockInfo, error) {
+	var lockInfo LockInfo
+	var token sql.NullString
+	var owner sql.NullString
+	var expireAt, acquiredAt sql.NullTime
+	if err := scanner.Scan(&lockInfo.Key, &token, &expireAt, &lockInfo.Held, &owner, &acquiredAt); err != nil {
+		return LockInfo{}, err
+	}
+	lockInfo.Token = token.String
+	lockInfo.Owner = owner.String
+	if expireAt.Valid {
+		lockInfo.ExpireAt = expireAt.Time
+	}
+	if acquiredAt.Valid {
+		lockInfo.AcquiredAt = acquiredAt.Time
+	}
+	return lockInfo, nil
+}
+
+func mySQLScanLockInfo(scanner rowScanner) (LockInfo, error) {
+	var lockInfo LockInfo
+	var token sql.NullString
+	var owner sql.NullString
+	var expireAt, acquiredAt sql.NullInt64
+	if err := scanner.Scan(&lockInfo.Key, &token, &expireAt, &lockInfo.Held, &owner, &acquiredAt); err != nil {
+		return LockInfo{}, err
+	}
+	lockInfo.Token = token.String
+	lockInfo.Owner = owner.String
+	if expireAt.Valid {
+		lockInfo.ExpireAt = mySQLParseTime(expireAt.Int64)
+	}
+	if acquiredAt.Valid {
+		lockInfo.AcquiredAt = mySQLParseTime(acquiredAt.Int64)
+	}
+	return lockInfo, nil
+}
+
+// mySQLParseTime converts time stored in 100-microsecond units (see mySQLAcquireLockQuery) to time.Time.
+func mySQLParseTime(v int64) time.Time {
+	return time.Unix(0, v*int64(100*time.Microsecond))
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	gotesting "testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+func TestDBManagerAdministration(t *gotesting.T) {
+	db, mock, lock := newMockedLock(t, 0, WithOwnerTracking())
+	defer func() { _ = db.Close() }()
+	manager := lock.manager
+
+	expireAt := time.Date(2025, 1, 2, 3, 4, 5, 600_000_000, time.UTC)
+	acquiredAt := expireAt.Add(-time.Minute)
+	columns := []string{"lock_key", "token", "expire_at", "held", "owner", "acquired_at"}
+
+	t.Run("list locks", func(t *gotesting.T) {
+		mock.ExpectQuery(manager.queries.listLocks).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("held-lock", "test-token", expireAt.UnixMicro()/100, 1, "worker-3", acquiredAt.UnixMicro()/100).
+			AddRow("never-acquired-lock", nil, nil, 0, nil, nil))
+		locks, err := manager.ListLocks(context.Background(), db)
+		require.NoError(t, err)
+		require.Len(t, locks, 2)
+		require.Equal(t, "held-lock", locks[0].Key)
+		require.Equal(t, "test-token", locks[0].Token)
+		require.True(t, locks[0].ExpireAt.Equal(expireAt))
+		require.True(t, locks[0].Held)
+		require.Equal(t, "worker-3", locks[0].Owner)
+		require.True(t, locks[0].AcquiredAt.Equal(acquiredAt))
+		require.Equal(t, LockInfo{Key: "never-acquired-lock"}, locks[1])
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get lock", func(t *gotesting.T) {
+		mock.ExpectQuery(manager.queries.getLock).WithArgs("expired-lock").WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("expired-lock", "test-token", expireAt.UnixMicro()/100, 0, "worker-3", acquiredAt.UnixMicro()/100))
+		lockInfo, err := manager.GetLock(context.Background(), db, "expired-lock")
+		require.NoError(t, err)
+		require.False(t, lockInfo.Held)
+		require.True(t, lockInfo.ExpireAt.Equal(expireAt))
+
+		mock.ExpectQuery(manager.queries.getLock).WithArgs("unknown-lock").WillReturnRows(sqlmock.NewRows(columns))
+		_, err = manager.GetLock(context.Background(), db, "unknown-lock")
+		require.ErrorIs(t, err, ErrLockNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("force release", func(t *gotesting.T) {
+		mock.ExpectExec(manager.queries.forceRelease).WithArgs("held-lock").WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, manager.ForceRelease(context.Background(), db, "held-lock"))
+
+		mock.ExpectExec(manager.queries.forceRelease).WithArgs("held-lock").WillReturnResult(sqlmock.NewResult(0, 0))
+		require.ErrorIs(t, manager.ForceRelease(context.Background(), db, "held-lock"), ErrLockAlreadyReleased)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cleanup expired", func(t *gotesting.T) {
+		mock.ExpectExec(manager.queries.cleanupLocks).WillReturnResult(sqlmock.NewResult(0, 3))
+		cleaned, err := manager.CleanupExpired(context.Background(), db)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), cleaned)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("release notifications", func(t *gotesting.T) {
+		notifyingManager, err := NewDBManager(dbkit.DialectPgx, WithReleaseNotifications())
+		require.NoError(t, err)
+		channel := notifyingManager.releaseChannel()
+
+		mock.ExpectExec(notifyingManager.queries.forceRelease).WithArgs("held-lock").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(notifyingManager.queries.notifyRelease).WithArgs(channel, "held-lock").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, notifyingManager.ForceRelease(context.Background(), db, "held-lock"))
+
+		mock.ExpectExec(notifyingManager.queries.cleanupAndNotify).WithArgs(channel).WillReturnResult(sqlmock.NewResult(0, 2))
+		cleaned, err := notifyingManager.CleanupExpired(context.Background(), db)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), cleaned)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"os"
 	"strconv"
 	"time"
 
@@ -23,16 +24,34 @@ import (
 // DefaultTableName is a default name for the table that stores distributed locks.
 const DefaultTableName = "distributed_locks"
 
+// Names of the lock operations that are passed to the metrics collector as query names (see WithMetrics).
+const (
+	MetricsQueryAcquire = "distrlock_acquire"
+	MetricsQueryRelease = "distrlock_release"
+	MetricsQueryExtend  = "distrlock_extend"
+)
+
 // DBManager provides management functionality for distributed locks based on the SQL database.
 type DBManager struct {
-	queries dbQueries
+	queries       dbQueries
+	owner         string
+	trackOwner    bool
+	dialect       dbkit.Dialect
+	notifyRelease bool
+	clock         dbkit.Clock
+	metrics       dbkit.MetricsCollector
 }
 
 // DBManagerOption is an option for NewDBManager.
 type DBManagerOption func(*dbManagerOptions)
 
 type dbManagerOptions struct {
-	tableName string
+	tableName     string
+	owner         string
+	trackOwner    bool
+	notifyRelease bool
+	clock         dbkit.Clock
+	metrics       dbkit.MetricsCollector
 }
 
 // WithTableName sets a custom table name for the table that stores distributed locks.
@@ -42,27 +61,107 @@ func WithTableName(tableName string) DBManagerOption {
 	}
 }
 
+// WithOwnerTracking makes acquired locks store the owner identity (DefaultLockOwner unless WithLockOwner is used)
+// and the acquisition time, so LockHeldError is returned when the lock cannot be acquired
+// because it's held by someone else.
+// The lock table must have the owner and acquired_at columns (see DBManager.Migrations and CreateTableSQL),
+// tables created by the previous versions should be upgraded before enabling the option.
+func WithOwnerTracking() DBManagerOption {
+	return func(o *dbManagerOptions) {
+		o.trackOwner = true
+	}
+}
+
+// WithLockOwner sets the owner identity that is stored with acquired locks
+// and reported when the lock cannot be acquired because it's held by someone else.
+// It enables owner tracking (see WithOwnerTracking).
+func WithLockOwner(owner string) DBManagerOption {
+	return func(o *dbManagerOptions) {
+		o.owner = owner
+		o.trackOwner = true
+	}
+}
+
+// WithReleaseNotifications makes released locks send notifications (NOTIFY) on Postgres,
+// so watchers (see DBManager.Watch) may react on the release immediately instead of waiting for the next poll.
+// It costs an additional query per release. The option is ignored for other dialects.
+func WithReleaseNotifications() DBManagerOption {
+	return func(o *dbManagerOptions) {
+		o.notifyRelease = true
+	}
+}
+
+// WithClock sets the clock that is used for local lock expiration tracking (see DBLock.ValidUntil),
+// keeping locks alive and waiting for them (dbkit.RealClock by default). It's intended for tests.
+func WithClock(clock dbkit.Clock) DBManagerOption {
+	return func(o *dbManagerOptions) {
+		o.clock = clock
+	}
+}
+
+// WithMetrics sets the collector that observes durations and failures of acquiring, releasing and extending locks
+// as queries named MetricsQueryAcquire, MetricsQueryRelease and MetricsQueryExtend (dbkit.NoOpMetricsCollector by default).
+// ErrLockAlreadyAcquired isn't counted as a failure since it's an expected outcome of the contention.
+func WithMetrics(collector dbkit.MetricsCollector) DBManagerOption {
+	return func(o *dbManagerOptions) {
+		o.metrics = collector
+	}
+}
+
+// DefaultLockOwner returns the default owner identity of the locks in the "<hostname>:<pid>" format.
+func DefaultLockOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return hostname + ":" + strconv.Itoa(os.Getpid())
+}
+
 // NewDBManager creates a new distributed lock manager that uses SQL database as a backend.
 func NewDBManager(dialect dbkit.Dialect, options ...DBManagerOption) (*DBManager, error) {
-	var opts dbManagerOptions
+	opts := dbManagerOptions{clock: dbkit.RealClock{}, metrics: dbkit.NoOpMetricsCollector{}}
 	for _, opt := range options {
 		opt(&opts)
 	}
 	if opts.tableName == "" {
 		opts.tableName = DefaultTableName
 	}
-	q, err := newDBQueries(dialect, opts.tableName)
+	if opts.owner == "" {
+		opts.owner = DefaultLockOwner()
+	}
+	if len(opts.owner) > maxLockOwnerLen {
+		opts.owner = opts.owner[:maxLockOwnerLen]
+	}
+	q, err := newDBQueries(dialect, opts.tableName, opts.trackOwner)
 	if err != nil {
 		return nil, err
 	}
-	return &DBManager{q}, nil
+	return &DBManager{
+		queries:       q,
+		owner:         opts.owner,
+		trackOwner:    opts.trackOwner,
+		dialect:       dialect,
+		notifyRelease: opts.notifyRelease && q.notifyRelease != "",
+		clock:         opts.clock,
+		metrics:       opts.metrics,
+	}, nil
+}
+
+// observe observes the duration of the lock operation and its failure.
+func (m *DBManager) observe(query string, startedAt time.Time, err error) {
+	m.metrics.ObserveQueryDuration(query, m.clock.Now().Sub(startedAt))
+	if err != nil && !errors.Is(err, ErrLockAlreadyAcquired) {
+		m.metrics.ObserveQueryError(query, err)
+	}
 }
 
 // Migrations returns set of migrations that must be applied before creating new locks.
 func (m *DBManager) Migrations() []migrate.Migration {
 	return []migrate.Migration{
 		migrate.NewCustomMigration(createTableMigrationID,
-			[]string{m.CreateTableSQL()}, []string{m.DropTableSQL()}, nil, nil),
+			[]string{m.queries.createTableV1}, []string{m.DropTableSQL()}, nil, nil),
+		migrate.NewCustomMigration(addOwnerColumnsMigrationID,
+			m.queries.addOwnerColumns, []string{m.queries.dropOwnerColumns}, nil, nil),
 	}
 }
 
@@ -96,11 +195,23 @@ type DBLock struct {
 	TTL     time.Duration
 	token   string
 	manager *DBManager
+
+	// validUntil is the local time until which the lock is guaranteed to be held.
+	// It's calculated conservatively from the moment the acquiring (extending) query was started.
+	validUntil time.Time
 }
 
 // Acquire acquires lock for the key in the database.
+// If the lock is held by someone else, ErrLockAlreadyAcquired is returned. When owner tracking is enabled
+// (see WithOwnerTracking) and the executor can query rows (e.g., *sql.Tx), it's returned as LockHeldError.
 func (l *DBLock) Acquire(ctx context.Context, executor SQLExecutor, lockTTL time.Duration) error {
-	return l.AcquireWithStaticToken(ctx, executor, uuid.NewString(), lockTTL)
+	err := l.acquire(ctx, executor, uuid.NewString(), lockTTL)
+	if errors.Is(err, ErrLockAlreadyAcquired) {
+		if queryExecutor, ok := executor.(SQLQueryExecutor); ok {
+			return l.describeHolder(ctx, queryExecutor, err)
+		}
+	}
+	return err
 }
 
 // AcquireWithStaticToken acquires lock for the key in the database with a static token.
@@ -112,29 +223,58 @@ func (l *DBLock) Acquire(ctx context.Context, executor SQLExecutor, lockTTL time
 //
 // Please use Acquire instead of this method unless you have a good reason to use it.
 func (l *DBLock) AcquireWithStaticToken(ctx context.Context, executor SQLExecutor, token string, lockTTL time.Duration) error {
+	return l.acquire(ctx, executor, token, lockTTL)
+}
+
+func (l *DBLock) acquire(ctx context.Context, executor SQLExecutor, token string, lockTTL time.Duration) error {
 	interval := l.manager.queries.intervalMaker(lockTTL)
-	err := execQueryAndCheckAffectedRow(ctx, executor, l.manager.queries.acquireLock,
-		[]interface{}{interval, token, l.Key, token}, ErrLockAlreadyAcquired)
+	args := []interface{}{interval, token, l.Key, token}
+	if l.manager.trackOwner {
+		args = []interface{}{interval, token, l.manager.owner, l.Key, token}
+	}
+	startedAt := l.manager.clock.Now()
+	err := execQueryAndCheckAffectedRow(ctx, executor, l.manager.queries.acquireLock, args, ErrLockAlreadyAcquired)
+	l.manager.observe(MetricsQueryAcquire, startedAt, err)
 	if err != nil {
 		return err
 	}
 	l.TTL = lockTTL
 	l.token = token
+	l.validUntil = startedAt.Add(lockTTL)
 	return nil
 }
 
 // Release releases lock for the key in the database.
-func (l *DBLock) Release(ctx context.Context, executor SQLExecutor) error {
-	return execQueryAndCheckAffectedRow(ctx, executor,
-		l.manager.queries.releaseLock, []interface{}{l.Key, l.token}, ErrLockAlreadyReleased)
+func (l *DBLock) Release(ctx context.Context, executor SQLExecutor) (err error) {
+	startedAt := l.manager.clock.Now()
+	defer func() { l.manager.observe(MetricsQueryRelease, startedAt, err) }()
+	if err = execQueryAndCheckAffectedRow(ctx, executor,
+		l.manager.queries.releaseLock, []interface{}{l.Key, l.token}, ErrLockAlreadyReleased); err != nil {
+		return err
+	}
+	return l.manager.notifyReleased(ctx, executor, l.Key)
 }
 
 // Extend resets expiration timeout for already acquired lock.
 // ErrLockAlreadyReleased error will be returned if lock is already released, in this case lock should be acquired again.
 func (l *DBLock) Extend(ctx context.Context, executor SQLExecutor) error {
 	interval := l.manager.queries.intervalMaker(l.TTL)
-	return execQueryAndCheckAffectedRow(ctx, executor,
+	startedAt := l.manager.clock.Now()
+	err := execQueryAndCheckAffectedRow(ctx, executor,
 		l.manager.queries.extendLock, []interface{}{interval, l.Key, l.token}, ErrLockAlreadyReleased)
+	l.manager.observe(MetricsQueryExtend, startedAt, err)
+	if err != nil {
+		return err
+	}
+	l.validUntil = startedAt.Add(l.TTL)
+	return nil
+}
+
+// ValidUntil returns the local time until which the lock is guaranteed to be held if it's not extended.
+// It's calculated conservatively from the moment the last successful acquiring (or extending) query was started.
+// Extensions made by LockKeeper are not reflected here, use LockKeeper.ValidUntil for kept locks.
+func (l *DBLock) ValidUntil() time.Time {
+	return l.validUntil
 }
 
 // Token returns token of the last acquired lock.
@@ -151,8 +291,12 @@ type Logger interface {
 type doOptions struct {
 	lockTTL                time.Duration
 	periodicExtendInterval time.Duration
+	periodicExtendDisabled bool
 	releaseTimeout         time.Duration
 	logger                 Logger
+	acquireWait            bool
+	acquireWaitOptions     []AcquireWaitOption
+	onLockLost             func(err error)
 }
 
 // DoOption is an option for DoExclusively method.
@@ -172,6 +316,34 @@ func WithPeriodicExtendInterval(interval time.Duration) DoOption {
 	}
 }
 
+// WithoutPeriodicExtend disables periodic lock extension.
+// It may be used when the function is guaranteed to be finished within the lock TTL.
+func WithoutPeriodicExtend() DoOption {
+	return func(o *doOptions) {
+		o.periodicExtendDisabled = true
+	}
+}
+
+// WithAcquireWait makes DoExclusively wait until the lock is obtained (see DBLock.AcquireWait)
+// instead of returning ErrLockAlreadyAcquired immediately if the lock is held by someone else.
+// Passed options configure the wait policy (retry interval, backoff and jitter).
+// Use the ctx deadline to limit the waiting time.
+func WithAcquireWait(options ...AcquireWaitOption) DoOption {
+	return func(o *doOptions) {
+		o.acquireWait = true
+		o.acquireWaitOptions = options
+	}
+}
+
+// WithLockLostCallback sets the callback that is called when the lock is lost during the function execution
+// (i.e., it cannot be extended because it was already released or acquired by someone else).
+// Context passed to the function is canceled in this case anyway.
+func WithLockLostCallback(fn func(err error)) DoOption {
+	return func(o *doOptions) {
+		o.onLockLost = fn
+	}
+}
+
 // WithReleaseTimeout sets timeout for lock release.
 func WithReleaseTimeout(timeout time.Duration) DoOption {
 	return func(o *doOptions) {
@@ -190,6 +362,13 @@ func WithLogger(logger Logger) DoOption {
 // Lock is acquired with a default TTL of 1 minute. TTL can be configured with WithLockTTL option.
 // Additionally, the lock is extended periodically within a separate goroutine.
 // Extension interval can be configured with WithPeriodicExtendInterval option. By default, it's half of the lock TTL.
+// Extension can be disabled with WithoutPeriodicExtend option.
+// If the lock is lost during the function execution, the context passed to the function is canceled,
+// and the callback set by WithLockLostCallback option is called.
+// The context is also canceled if the lock TTL elapses without successful extension (e.g., because the database is unavailable),
+// so the function doesn't continue running unprotected.
+// By default, if the lock is held by someone else, ErrLockAlreadyAcquired is returned immediately.
+// Use WithAcquireWait option to wait until the lock is obtained.
 // When the function is finished, acquired lock is released.
 // Timeout for lock release can be configured with WithReleaseTimeout option. By default, it's 5 seconds.
 func (l *DBLock) DoExclusively(
@@ -215,9 +394,7 @@ func (l *DBLock) DoExclusively(
 		opts.logger = disabledLogger{}
 	}
 
-	if acquireLockErr := dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
-		return l.Acquire(ctx, tx, opts.lockTTL)
-	}); acquireLockErr != nil {
+	if acquireLockErr := l.acquireForDo(ctx, dbConn, opts); acquireLockErr != nil {
 		return acquireLockErr
 	}
 
@@ -232,45 +409,73 @@ func (l *DBLock) DoExclusively(
 		}
 	}()
 
-	childCtx, childCtxCancel := context.WithCancel(ctx)
+	if opts.periodicExtendDisabled {
+		// Lock is not extended, so the function should be stopped when the lock expires.
+		childCtx, childCtxCancel := l.expirationContext(ctx)
+		defer childCtxCancel()
+		return fn(childCtx)
+	}
+
+	keeper, err := l.KeepAlive(ctx, dbConn,
+		WithKeepAliveInterval(opts.periodicExtendInterval),
+		WithKeepAliveLogger(opts.logger),
+		WithOnLockLost(opts.onLockLost))
+	if err != nil {
+		return err
+	}
+	defer keeper.Stop()
+
+	// If lock was lost or expired, let's try to stop an exclusive job asap.
+	childCtx, childCtxCancel := keeper.Context(ctx)
 	defer childCtxCancel()
 
-	periodicalExtensionExit := make(chan struct{})
-	periodicalExtensionDone := make(chan struct{})
-	defer func() {
-		close(periodicalExtensionDone)
-		<-periodicalExtensionExit
-	}()
+	return fn(childCtx)
+}
 
+// expirationContext returns the context that is canceled with ErrLockExpired cause when the lock expires.
+// Expiration is measured by the manager's clock, so the context works with the injected clock as well.
+func (l *DBLock) expirationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	expCtx, expCtxCancel := context.WithCancelCause(ctx)
+	clock := l.manager.clock
+	timer := clock.NewTimer(l.validUntil.Sub(clock.Now()))
 	go func() {
-		defer func() { close(periodicalExtensionExit) }()
-		ticker := time.NewTicker(opts.periodicExtendInterval)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-periodicalExtensionDone:
-				return
-			case <-ticker.C:
-				if extendErr := dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
-					return l.Extend(ctx, tx)
-				}); extendErr != nil {
-					opts.logger.Errorf("failed to extend lock with key %s and token %s, error: %v", l.Key, l.token, extendErr)
-					if errors.Is(extendErr, ErrLockAlreadyReleased) {
-						childCtxCancel() // If lock was already released, let's try to stop an exclusive job asap.
-						return
-					}
-				}
-			}
+		defer timer.Stop()
+		select {
+		case <-expCtx.Done():
+		case <-timer.C():
+			expCtxCancel(ErrLockExpired)
 		}
 	}()
+	return expCtx, func() { expCtxCancel(context.Canceled) }
+}
 
-	return fn(childCtx)
+func (l *DBLock) acquireForDo(ctx context.Context, dbConn *sql.DB, opts doOptions) error {
+	if opts.acquireWait {
+		_, err := l.AcquireWait(ctx, dbConn, opts.lockTTL, opts.acquireWaitOptions...)
+		return err
+	}
+	return dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+		return l.Acquire(ctx, tx, opts.lockTTL)
+	})
+}
+
+// describeHolder returns LockHeldError with the information about the current holder of the lock.
+// The passed error is returned as is if owner tracking is disabled or the information cannot be obtained.
+func (l *DBLock) describeHolder(ctx context.Context, executor SQLQueryExecutor, acquireErr error) error {
+	if !l.manager.trackOwner {
+		return acquireErr
+	}
+	lockInfo, err := l.manager.GetLock(ctx, executor, l.Key)
+	if err != nil || !lockInfo.Held || lockInfo.Owner == "" {
+		return acquireErr
+	}
+	return &LockHeldError{Key: l.Key, Owner: lockInfo.Owner, AcquiredAt: lockInfo.AcquiredAt}
 }
 
 // CreateTableSQL returns SQL query for creating a table that stores distributed locks.
 // DefaultTableName is used for the table name. If you need to use a custom table name, construct DBManager and DBLock manually instead.
 func CreateTableSQL(dialect dbkit.Dialect) (string, error) {
-	q, err := newDBQueries(dialect, DefaultTableName)
+	q, err := newDBQueries(dialect, DefaultTableName, false)
 	if err != nil {
 		return "", err
 	}
@@ -280,7 +485,7 @@ func CreateTableSQL(dialect dbkit.Dialect) (string, error) {
 // DropTableSQL returns SQL query for dropping a table that stores distributed locks.
 // DefaultTableName is used for the table name. If you need to use a custom table name, construct DBManager and DBLock manually instead.
 func DropTableSQL(dialect dbkit.Dialect) (string, error) {
-	q, err := newDBQueries(dialect, DefaultTableName)
+	q, err := newDBQueries(dialect, DefaultTableName, false)
 	if err != nil {
 		return "", err
 	}
@@ -339,37 +544,80 @@ func execQueryAndCheckAffectedRow(
 }
 
 type dbQueries struct {
-	createTable   string
-	dropTable     string
-	initLock      string
-	acquireLock   string
-	releaseLock   string
-	extendLock    string
-	intervalMaker func(interval time.Duration) string
+	createTable      string
+	createTableV1    string
+	addOwnerColumns  []string
+	dropOwnerColumns string
+	dropTable        string
+	initLock         string
+	acquireLock      string
+	releaseLock      string
+	extendLock       string
+	listLocks        string
+	getLock          string
+	forceRelease     string
+	cleanupLocks     string
+	cleanupAndNotify string
+	notifyRelease    string
+	tableName        string
+	intervalMaker    func(interval time.Duration) string
+	scanLockInfo     func(scanner rowScanner) (LockInfo, error)
 }
 
-func newDBQueries(dialect dbkit.Dialect, tableName string) (dbQueries, error) {
+func newDBQueries(dialect dbkit.Dialect, tableName string, trackOwner bool) (dbQueries, error) {
 	switch dialect {
 	case dbkit.DialectPostgres, dbkit.DialectPgx:
-		return dbQueries{
-			createTable:   fmt.Sprintf(postgresCreateTableQuery, tableName),
-			dropTable:     fmt.Sprintf(postgresDropTableQuery, tableName),
-			initLock:      fmt.Sprintf(postgresInitLockQuery, tableName),
-			acquireLock:   fmt.Sprintf(postgresAcquireLockQuery, tableName),
-			releaseLock:   fmt.Sprintf(postgresReleaseLockQuery, tableName),
-			extendLock:    fmt.Sprintf(postgresExtendLockQuery, tableName),
-			intervalMaker: postgresMakeInterval,
-		}, nil
+		q := dbQueries{
+			createTable:      fmt.Sprintf(postgresCreateTableQuery, tableName),
+			createTableV1:    fmt.Sprintf(postgresCreateTableV1Query, tableName),
+			addOwnerColumns:  []string{fmt.Sprintf(postgresAddOwnerColumnsQuery, tableName)},
+			dropOwnerColumns: fmt.Sprintf(postgresDropOwnerColumnsQuery, tableName),
+			dropTable:        fmt.Sprintf(postgresDropTableQuery, tableName),
+			initLock:         fmt.Sprintf(postgresInitLockQuery, tableName),
+			acquireLock:      fmt.Sprintf(postgresAcquireLockQuery, tableName),
+			releaseLock:      fmt.Sprintf(postgresReleaseLockQuery, tableName),
+			extendLock:       fmt.Sprintf(postgresExtendLockQuery, tableName),
+			listLocks:        fmt.Sprintf(postgresListLocksQuery, tableName),
+			getLock:          fmt.Sprintf(postgresGetLockQuery, tableName),
+			forceRelease:     fmt.Sprintf(postgresForceReleaseLockQuery, tableName),
+			cleanupLocks:     fmt.Sprintf(postgresCleanupExpiredLocksQuery, tableName),
+			cleanupAndNotify: fmt.Sprintf(postgresCleanupExpiredLocksAndNotifyQuery, tableName),
+			notifyRelease:    postgresNotifyReleaseQuery,
+			tableName:        tableName,
+			intervalMaker:    postgresMakeInterval,
+			scanLockInfo:     postgresScanLockInfo,
+		}
+		if trackOwner {
+			q.acquireLock = fmt.Sprintf(postgresAcquireLockWithOwnerQuery, tableName)
+			q.listLocks = fmt.Sprintf(postgresListLocksWithOwnerQuery, tableName)
+			q.getLock = fmt.Sprintf(postgresGetLockWithOwnerQuery, tableName)
+		}
+		return q, nil
 	case dbkit.DialectMySQL:
-		return dbQueries{
-			createTable:   fmt.Sprintf(mySQLCreateTableQuery, tableName),
-			dropTable:     fmt.Sprintf(mySQLDropTableQuery, tableName),
-			initLock:      fmt.Sprintf(mySQLInitLockQuery, tableName),
-			acquireLock:   fmt.Sprintf(mySQLAcquireLockQuery, tableName),
-			releaseLock:   fmt.Sprintf(mySQLReleaseLockQuery, tableName),
-			extendLock:    fmt.Sprintf(mySQLExtendLockQuery, tableName),
-			intervalMaker: mySQLMakeInterval,
-		}, nil
+		q := dbQueries{
+			createTable:      fmt.Sprintf(mySQLCreateTableQuery, tableName),
+			createTableV1:    fmt.Sprintf(mySQLCreateTableV1Query, tableName),
+			addOwnerColumns:  mySQLAddOwnerColumnsQueries(tableName),
+			dropOwnerColumns: fmt.Sprintf(mySQLDropOwnerColumnsQuery, tableName),
+			dropTable:        fmt.Sprintf(mySQLDropTableQuery, tableName),
+			initLock:         fmt.Sprintf(mySQLInitLockQuery, tableName),
+			acquireLock:      fmt.Sprintf(mySQLAcquireLockQuery, tableName),
+			releaseLock:      fmt.Sprintf(mySQLReleaseLockQuery, tableName),
+			extendLock:       fmt.Sprintf(mySQLExtendLockQuery, tableName),
+			listLocks:        fmt.Sprintf(mySQLListLocksQuery, tableName),
+			getLock:          fmt.Sprintf(mySQLGetLockQuery, tableName),
+			forceRelease:     fmt.Sprintf(mySQLForceReleaseLockQuery, tableName),
+			cleanupLocks:     fmt.Sprintf(mySQLCleanupExpiredLocksQuery, tableName),
+			tableName:        tableName,
+			intervalMaker:    mySQLMakeInterval,
+			scanLockInfo:     mySQLScanLockInfo,
+		}
+		if trackOwner {
+			q.acquireLock = fmt.Sprintf(mySQLAcquireLockWithOwnerQuery, tableName)
+			q.listLocks = fmt.Sprintf(mySQLListLocksWithOwnerQuery, tableName)
+			q.getLock = fmt.Sprintf(mySQLGetLockWithOwnerQuery, tableName)
+		}
+		return q, nil
 	default:
 		return dbQueries{}, fmt.Errorf("unsupported sql dialect %q", dialect)
 	}
@@ -379,16 +627,37 @@ type SQLExecutor interface {
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
-const createTableMigrationID = "distrlock_00001_create_table"
+const (
+	createTableMigrationID     = "distrlock_00001_create_table"
+	addOwnerColumnsMigrationID = "distrlock_00002_add_owner_columns"
+)
+
+const maxLockOwnerLen = 255
 
 //nolint:lll
 const (
-	postgresCreateTableQuery = `CREATE TABLE IF NOT EXISTS "%s" (lock_key varchar(40) PRIMARY KEY, token uuid, expire_at timestamp);`
+	postgresCreateTableQuery = `CREATE TABLE IF NOT EXISTS "%s" (lock_key varchar(40) PRIMARY KEY, token uuid, expire_at timestamp, owner varchar(255), acquired_at timestamp);`
 	postgresDropTableQuery   = `DROP TABLE IF EXISTS "%s";`
 	postgresInitLockQuery    = `INSERT INTO "%s" (lock_key) VALUES ($1) ON CONFLICT (lock_key) DO NOTHING;`
 	postgresAcquireLockQuery = `UPDATE "%s" SET expire_at = NOW() + $1::interval, token = $2 WHERE lock_key = $3 AND ((expire_at IS NULL OR expire_at < NOW()) OR token = $4);`
 	postgresReleaseLockQuery = `UPDATE "%s" SET expire_at = NULL WHERE lock_key = $1 AND token = $2 AND expire_at >= NOW();`
 	postgresExtendLockQuery  = `UPDATE "%s" SET expire_at = NOW() + $1::interval WHERE lock_key = $2 AND token = $3 AND expire_at >= NOW();`
+
+	postgresCreateTableV1Query    = `CREATE TABLE IF NOT EXISTS "%s" (lock_key varchar(40) PRIMARY KEY, token uuid, expire_at timestamp);`
+	postgresAddOwnerColumnsQuery  = `ALTER TABLE "%s" ADD COLUMN IF NOT EXISTS owner varchar(255), ADD COLUMN IF NOT EXISTS acquired_at timestamp;`
+	postgresDropOwnerColumnsQuery = `ALTER TABLE "%s" DROP COLUMN IF EXISTS owner, DROP COLUMN IF EXISTS acquired_at;`
+
+	postgresAcquireLockWithOwnerQuery = `UPDATE "%s" SET expire_at = NOW() + $1::interval, token = $2, owner = $3, acquired_at = NOW() WHERE lock_key = $4 AND ((expire_at IS NULL OR expire_at < NOW()) OR token = $5);`
+	postgresListLocksWithOwnerQuery   = `SELECT lock_key, token, expire_at, COALESCE(expire_at >= NOW(), FALSE), owner, acquired_at FROM "%s" ORDER BY lock_key;`
+	postgresGetLockWithOwnerQuery     = `SELECT lock_key, token, expire_at, COALESCE(expire_at >= NOW(), FALSE), owner, acquired_at FROM "%s" WHERE lock_key = $1;`
+
+	postgresListLocksQuery           = `SELECT lock_key, token, expire_at, COALESCE(expire_at >= NOW(), FALSE), NULL, NULL FROM "%s" ORDER BY lock_key;`
+	postgresGetLockQuery             = `SELECT lock_key, token, expire_at, COALESCE(expire_at >= NOW(), FALSE), NULL, NULL FROM "%s" WHERE lock_key = $1;`
+	postgresForceReleaseLockQuery    = `UPDATE "%s" SET expire_at = NULL WHERE lock_key = $1 AND expire_at IS NOT NULL;`
+	postgresCleanupExpiredLocksQuery = `UPDATE "%s" SET expire_at = NULL WHERE expire_at < NOW();`
+	postgresNotifyReleaseQuery       = `SELECT pg_notify($1, $2);`
+
+	postgresCleanupExpiredLocksAndNotifyQuery = `WITH released AS (UPDATE "%s" SET expire_at = NULL WHERE expire_at < NOW() RETURNING lock_key) SELECT pg_notify($1, lock_key) FROM released;`
 )
 
 func postgresMakeInterval(interval time.Duration) string {
@@ -397,18 +666,48 @@ func postgresMakeInterval(interval time.Duration) string {
 
 //nolint:lll
 const (
-	mySQLCreateTableQuery = "CREATE TABLE IF NOT EXISTS `%s` (lock_key VARCHAR(40) PRIMARY KEY, token VARCHAR(36), expire_at BIGINT);"
+	mySQLCreateTableQuery = "CREATE TABLE IF NOT EXISTS `%s` (lock_key VARCHAR(40) PRIMARY KEY, token VARCHAR(36), expire_at BIGINT, owner VARCHAR(255), acquired_at BIGINT);"
 	mySQLDropTableQuery   = "DROP TABLE IF EXISTS `%s`;"
 	mySQLInitLockQuery    = "INSERT IGNORE `%s` (lock_key) VALUES (?);"
 	mySQLAcquireLockQuery = "UPDATE `%s` SET expire_at = UNIX_TIMESTAMP(DATE_ADD(CURTIME(4), INTERVAL ? MICROSECOND))*10000, token = ? WHERE lock_key = ? AND ((expire_at IS NULL OR expire_at < UNIX_TIMESTAMP(CURTIME(4))*10000) OR token = ?);"
 	mySQLReleaseLockQuery = "UPDATE `%s` SET expire_at = NULL WHERE lock_key = ? AND token = ? AND expire_at >= UNIX_TIMESTAMP(CURTIME(4))*10000;"
 	mySQLExtendLockQuery  = "UPDATE `%s` SET expire_at = UNIX_TIMESTAMP(DATE_ADD(CURTIME(4), INTERVAL ? MICROSECOND))*10000 WHERE lock_key = ? AND token = ? AND expire_at >= UNIX_TIMESTAMP(CURTIME(4))*10000;"
+
+	mySQLCreateTableV1Query    = "CREATE TABLE IF NOT EXISTS `%s` (lock_key VARCHAR(40) PRIMARY KEY, token VARCHAR(36), expire_at BIGINT);"
+	mySQLDropOwnerColumnsQuery = "ALTER TABLE `%s` DROP COLUMN owner, DROP COLUMN acquired_at;"
+
+	// MySQL doesn't support ADD COLUMN IF NOT EXISTS, so the statement is chosen by checking information_schema
+	// (the columns may already exist if the table was created by CreateTableSQL) and executed as a prepared one.
+	mySQLAddColumnIfNotExistsQuery = "SET @distrlock_add_column = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE `%[1]s` ADD COLUMN %[2]s %[3]s', 'DO 0') FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '%[1]s' AND COLUMN_NAME = '%[2]s');"
+	mySQLPrepareAddColumnQuery     = "PREPARE distrlock_add_column FROM @distrlock_add_column;"
+	mySQLExecuteAddColumnQuery     = "EXECUTE distrlock_add_column;"
+	mySQLDeallocateAddColumnQuery  = "DEALLOCATE PREPARE distrlock_add_column;"
+
+	mySQLAcquireLockWithOwnerQuery = "UPDATE `%s` SET expire_at = UNIX_TIMESTAMP(DATE_ADD(CURTIME(4), INTERVAL ? MICROSECOND))*10000, token = ?, owner = ?, acquired_at = UNIX_TIMESTAMP(CURTIME(4))*10000 WHERE lock_key = ? AND ((expire_at IS NULL OR expire_at < UNIX_TIMESTAMP(CURTIME(4))*10000) OR token = ?);"
+	mySQLListLocksWithOwnerQuery   = "SELECT lock_key, token, expire_at, COALESCE(expire_at >= UNIX_TIMESTAMP(CURTIME(4))*10000, FALSE), owner, acquired_at FROM `%s` ORDER BY lock_key;"
+	mySQLGetLockWithOwnerQuery     = "SELECT lock_key, token, expire_at, COALESCE(expire_at >= UNIX_TIMESTAMP(CURTIME(4))*10000, FALSE), owner, acquired_at FROM `%s` WHERE lock_key = ?;"
+
+	mySQLListLocksQuery           = "SELECT lock_key, token, expire_at, COALESCE(expire_at >= UNIX_TIMESTAMP(CURTIME(4))*10000, FALSE), NULL, NULL FROM `%s` ORDER BY lock_key;"
+	mySQLGetLockQuery             = "SELECT lock_key, token, expire_at, COALESCE(expire_at >= UNIX_TIMESTAMP(CURTIME(4))*10000, FALSE), NULL, NULL FROM `%s` WHERE lock_key = ?;"
+	mySQLForceReleaseLockQuery    = "UPDATE `%s` SET expire_at = NULL WHERE lock_key = ? AND expire_at IS NOT NULL;"
+	mySQLCleanupExpiredLocksQuery = "UPDATE `%s` SET expire_at = NULL WHERE expire_at < UNIX_TIMESTAMP(CURTIME(4))*10000;"
 )
 
 func mySQLMakeInterval(interval time.Duration) string {
 	return strconv.FormatInt(interval.Microseconds(), 10)
 }
 
+// mySQLAddOwnerColumnsQueries returns queries that add the owner columns unless they already exist.
+func mySQLAddOwnerColumnsQueries(tableName string) []string {
+	var queries []string
+	for _, column := range [][2]string{{"owner", "VARCHAR(255)"}, {"acquired_at", "BIGINT"}} {
+		queries = append(queries,
+			fmt.Sprintf(mySQLAddColumnIfNotExistsQuery, tableName, column[0], column[1]),
+			mySQLPrepareAddColumnQuery, mySQLExecuteAddColumnQuery, mySQLDeallocateAddColumnQuery)
+	}
+	return queries
+}
+
 type disabledLogger struct{}
 
 func (disabledLogger) Errorf(msg string, args ...interface{}) {}
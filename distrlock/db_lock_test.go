@@ -18,6 +18,7 @@ import (
 	gotesting "testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/acronis/go-appkit/log/logtest"
 	"github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
@@ -29,6 +30,7 @@ import (
 	"github.com/acronis/go-dbkit/internal/testing"
 	"github.com/acronis/go-dbkit/migrate"
 	_ "github.com/acronis/go-dbkit/postgres"
+	"github.com/acronis/go-dbkit/testkit"
 )
 
 func TestDBManager_Postgres(t *gotesting.T) {
@@ -563,6 +565,42 @@ func runDBLockDoExclusivelyTests(t *gotesting.T, dialect dbkit.Dialect) {
 		// doExResult should contain the error since the first lock cannot be extended and context was canceled.
 		require.EqualError(t, <-doExResult, context.Canceled.Error())
 	})
+
+	t.Run("watch lock until it's released", func(t *gotesting.T) {
+		ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer ctxCancel()
+
+		notifyingManager, err := NewDBManager(dialect, WithReleaseNotifications())
+		require.NoError(t, err)
+		var lock DBLock
+		require.NoError(t, dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) (err error) {
+			if lock, err = notifyingManager.NewLock(ctx, tx, uuid.NewString()); err != nil {
+				return err
+			}
+			return lock.Acquire(ctx, tx, time.Minute)
+		}))
+
+		// With LISTEN/NOTIFY (pgx), the release is noticed long before the next poll.
+		pollInterval := 100 * time.Millisecond
+		if dialect == dbkit.DialectPgx {
+			pollInterval = time.Minute
+		}
+		notifications := notifyingManager.Watch(ctx, dbConn, lock.Key, WithWatchPollInterval(pollInterval))
+		select {
+		case <-notifications:
+			t.Fatal("lock is held, notification is not expected")
+		case <-time.After(time.Second):
+		}
+
+		require.NoError(t, dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+			return lock.Release(ctx, tx)
+		}))
+		select {
+		case <-notifications:
+		case <-time.After(5 * time.Second):
+			t.Fatal("notification about released lock is expected")
+		}
+	})
 }
 
 func makeTwoLocks(
@@ -610,3 +648,165 @@ func assertRollbackWithCtxTimeoutError(t *gotesting.T, dialect dbkit.Dialect, tx
 		}
 	}
 }
+
+func TestDBLockDoExclusivelyWithOptions(t *gotesting.T) {
+	const lockTTL = 40 * time.Millisecond
+
+	db, mock, lock := newMockedLock(t, 0)
+	defer func() { _ = db.Close() }()
+
+	expectLockAcquisition(mock, lock, lockTTL, 0)
+	expectLockAcquisition(mock, lock, lockTTL, 1)
+	mock.ExpectBegin()
+	mock.ExpectExec(lock.manager.queries.extendLock).
+		WithArgs(mySQLMakeInterval(lockTTL), lock.Key, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec(lock.manager.queries.releaseLock).
+		WithArgs(lock.Key, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	var lostErr error
+	err := lock.DoExclusively(context.Background(), db, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	},
+		WithLockTTL(lockTTL),
+		WithAcquireWait(WithAcquireRetryInterval(lockTTL/4)),
+		WithLockLostCallback(func(err error) { lostErr = err }))
+	require.ErrorIs(t, err, context.Canceled)
+	require.ErrorIs(t, lostErr, ErrLockAlreadyReleased)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	t.Run("lock expires without extension, fake clock", func(t *gotesting.T) {
+		clock := testkit.NewFakeClock(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+		clockDB, clockMock, clockLock := newMockedLock(t, 0, WithClock(clock))
+		defer func() { _ = clockDB.Close() }()
+
+		expectLockAcquisition(clockMock, clockLock, time.Minute, 1)
+		clockMock.ExpectBegin()
+		clockMock.ExpectExec(clockLock.manager.queries.releaseLock).
+			WithArgs(clockLock.Key, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		clockMock.ExpectRollback()
+
+		doErr := make(chan error, 1)
+		go func() {
+			doErr <- clockLock.DoExclusively(context.Background(), clockDB, func(ctx context.Context) error {
+				<-ctx.Done()
+				return context.Cause(ctx)
+			}, WithLockTTL(time.Minute), WithoutPeriodicExtend())
+		}()
+
+		waitCtx, waitCtxCancel := context.WithTimeout(context.Background(), time.Second)
+		defer waitCtxCancel()
+		require.NoError(t, clock.WaitForTimers(waitCtx, 1)) // Expiration timer.
+		clock.Advance(time.Minute - time.Millisecond)
+		select {
+		case err := <-doErr:
+			t.Fatalf("function must not be stopped before the lock expires, got %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		clock.Advance(time.Millisecond)
+		select {
+		case err := <-doErr:
+			require.ErrorIs(t, err, ErrLockExpired)
+		case <-time.After(time.Second):
+			t.Fatal("function must be stopped when the lock expires")
+		}
+		require.NoError(t, clockMock.ExpectationsWereMet())
+	})
+
+	t.Run("lock is held by someone else", func(t *gotesting.T) {
+		expectLockAcquisition(mock, lock, lockTTL, 0)
+		err = lock.DoExclusively(context.Background(), db, func(ctx context.Context) error {
+			return nil
+		}, WithLockTTL(lockTTL), WithoutPeriodicExtend())
+		require.ErrorIs(t, err, ErrLockAlreadyAcquired)
+		var heldErr *LockHeldError
+		require.False(t, errors.As(err, &heldErr), "owner must not be queried without owner tracking")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("lock is held by someone else, owner tracking", func(t *gotesting.T) {
+		trackingDB, trackingMock, trackingLock := newMockedLock(t, 0, WithOwnerTracking())
+		defer func() { _ = trackingDB.Close() }()
+
+		acquiredAt := time.Date(2025, 1, 2, 12, 4, 0, 0, time.UTC)
+		expectHolderQuery := func() {
+			trackingMock.ExpectQuery(trackingLock.manager.queries.getLock).WithArgs(trackingLock.Key).WillReturnRows(
+				sqlmock.NewRows([]string{"lock_key", "token", "expire_at", "held", "owner", "acquired_at"}).
+					AddRow(trackingLock.Key, "another-token", acquiredAt.Add(time.Minute).UnixMicro()/100, 1,
+						"worker-3", acquiredAt.UnixMicro()/100))
+		}
+		requireHeldErr := func(err error) {
+			require.ErrorIs(t, err, ErrLockAlreadyAcquired)
+			var heldErr *LockHeldError
+			require.ErrorAs(t, err, &heldErr)
+			require.Equal(t, "worker-3", heldErr.Owner)
+			require.True(t, heldErr.AcquiredAt.Equal(acquiredAt))
+			require.Contains(t, err.Error(), "is held by worker-3 since")
+		}
+
+		trackingMock.ExpectBegin()
+		trackingMock.ExpectExec(trackingLock.manager.queries.acquireLock).
+			WithArgs(mySQLMakeInterval(lockTTL), sqlmock.AnyArg(), trackingLock.manager.owner, trackingLock.Key, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		expectHolderQuery()
+		trackingMock.ExpectRollback()
+		err = trackingLock.DoExclusively(context.Background(), trackingDB, func(ctx context.Context) error {
+			return nil
+		}, WithLockTTL(lockTTL), WithoutPeriodicExtend())
+		requireHeldErr(err)
+
+		// Plain Acquire reports the holder too.
+		trackingMock.ExpectExec(trackingLock.manager.queries.acquireLock).
+			WithArgs(mySQLMakeInterval(lockTTL), sqlmock.AnyArg(), trackingLock.manager.owner, trackingLock.Key, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		expectHolderQuery()
+		requireHeldErr(trackingLock.Acquire(context.Background(), trackingDB, lockTTL))
+		require.NoError(t, trackingMock.ExpectationsWereMet())
+	})
+}
+
+// recordingMetrics records the number of observed durations and errors by the query name.
+type recordingMetrics struct {
+	dbkit.NoOpMetricsCollector
+	durations map[string]int
+	errors    map[string]int
+}
+
+func (m *recordingMetrics) ObserveQueryDuration(query string, _ time.Duration) {
+	m.durations[query]++
+}
+
+func (m *recordingMetrics) ObserveQueryError(query string, _ error) {
+	m.errors[query]++
+}
+
+func TestDBLockMetrics(t *gotesting.T) {
+	const lockTTL = time.Minute
+
+	metrics := &recordingMetrics{durations: make(map[string]int), errors: make(map[string]int)}
+	db, mock, lock := newMockedLock(t, 0, WithMetrics(metrics))
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectExec(lock.manager.queries.acquireLock).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(lock.manager.queries.acquireLock).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(lock.manager.queries.extendLock).WillReturnError(errors.New("connection reset"))
+	mock.ExpectExec(lock.manager.queries.extendLock).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(lock.manager.queries.releaseLock).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.ErrorIs(t, lock.Acquire(context.Background(), db, lockTTL), ErrLockAlreadyAcquired)
+	require.NoError(t, lock.Acquire(context.Background(), db, lockTTL))
+	require.Error(t, lock.Extend(context.Background(), db))
+	require.NoError(t, lock.Extend(context.Background(), db))
+	require.NoError(t, lock.Release(context.Background(), db))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	require.Equal(t, map[string]int{MetricsQueryAcquire: 2, MetricsQueryExtend: 2, MetricsQueryRelease: 1}, metrics.durations)
+	require.Equal(t, map[string]int{MetricsQueryExtend: 1}, metrics.errors, "lock contention must not be counted as failure")
+}
@@ -0,0 +1,214 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// Default values for Elector options.
+const (
+	DefaultElectorLeaseTTL      = 30 * time.Second
+	DefaultElectorRetryInterval = 5 * time.Second
+)
+
+// Elector campaigns for the leadership using the distributed lock.
+// Only one Elector among all instances that use the same lock key is the leader at a time.
+// Leadership is a lease: the lock is acquired with the lease TTL and renewed periodically in the background.
+// Leader may be changed if the lease cannot be renewed in time (e.g., because of network issues),
+// so the lease TTL should be noticeably larger than the renewal interval.
+type Elector struct {
+	lock     *DBLock
+	dbConn   *sql.DB
+	opts     electorOptions
+	isLeader int32
+}
+
+type electorOptions struct {
+	leaseTTL         time.Duration
+	renewInterval    time.Duration
+	retryInterval    time.Duration
+	releaseTimeout   time.Duration
+	onStartedLeading func(ctx context.Context)
+	onStoppedLeading func()
+	logger           Logger
+}
+
+// ElectorOption is an option for Elector.
+type ElectorOption func(*electorOptions)
+
+// WithElectorLeaseTTL sets TTL of the leadership lease (i.e., TTL of the lock). By default, it's 30 seconds.
+func WithElectorLeaseTTL(ttl time.Duration) ElectorOption {
+	return func(o *electorOptions) {
+		o.leaseTTL = ttl
+	}
+}
+
+// WithElectorRenewInterval sets interval for periodic lease renewal. By default, it's a third of the lease TTL.
+func WithElectorRenewInterval(interval time.Duration) ElectorOption {
+	return func(o *electorOptions) {
+		o.renewInterval = interval
+	}
+}
+
+// WithElectorRetryInterval sets interval between attempts to become the leader. By default, it's 5 seconds.
+func WithElectorRetryInterval(interval time.Duration) ElectorOption {
+	return func(o *electorOptions) {
+		o.retryInterval = interval
+	}
+}
+
+// WithElectorReleaseTimeout sets timeout for the lock release when the elector stops. By default, it's 5 seconds.
+func WithElectorReleaseTimeout(timeout time.Duration) ElectorOption {
+	return func(o *electorOptions) {
+		o.releaseTimeout = timeout
+	}
+}
+
+// WithOnStartedLeading sets the callback that is called in a separate goroutine when the elector becomes the leader.
+// Passed context is canceled when the leadership is lost or the elector stops.
+func WithOnStartedLeading(fn func(ctx context.Context)) ElectorOption {
+	return func(o *electorOptions) {
+		o.onStartedLeading = fn
+	}
+}
+
+// WithOnStoppedLeading sets the callback that is called when the elector stops being the leader.
+// It's called after the OnStartedLeading callback is returned.
+func WithOnStoppedLeading(fn func()) ElectorOption {
+	return func(o *electorOptions) {
+		o.onStoppedLeading = fn
+	}
+}
+
+// WithElectorLogger sets logger for Elector.
+func WithElectorLogger(logger Logger) ElectorOption {
+	return func(o *electorOptions) {
+		o.logger = logger
+	}
+}
+
+// NewElector creates a new Elector that campaigns for the leadership using the passed initialized lock
+// (see DBManager.NewLock).
+func NewElector(dbConn *sql.DB, lock DBLock, options ...ElectorOption) *Elector {
+	opts := electorOptions{
+		leaseTTL:       DefaultElectorLeaseTTL,
+		retryInterval:  DefaultElectorRetryInterval,
+		releaseTimeout: 5 * time.Second,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.renewInterval == 0 {
+		opts.renewInterval = opts.leaseTTL / 3
+	}
+	if opts.logger == nil {
+		opts.logger = disabledLogger{}
+	}
+	return &Elector{lock: &lock, dbConn: dbConn, opts: opts}
+}
+
+// IsLeader returns true if the elector is the leader at the moment.
+func (e *Elector) IsLeader() bool {
+	return atomic.LoadInt32(&e.isLeader) == 1
+}
+
+// Run continuously campaigns for the leadership until ctx is done.
+// When the elector becomes the leader, the lease is renewed periodically, and the OnStartedLeading callback is called.
+// If the lease is lost or expires without renewal, the OnStoppedLeading callback is called, and the elector campaigns again.
+// When ctx is done, the leadership (if any) is released, so another instance may become the leader without waiting
+// for the lease expiration. Run returns ctx.Err() or an error if the lease cannot be renewed
+// with the configured options (e.g., the renew interval is not positive).
+func (e *Elector) Run(ctx context.Context) error {
+	for {
+		acquireErr := dbkit.DoInTx(ctx, e.dbConn, func(tx *sql.Tx) error {
+			return e.lock.Acquire(ctx, tx, e.opts.leaseTTL)
+		})
+		if acquireErr == nil {
+			if err := e.lead(ctx); err != nil {
+				return err
+			}
+		} else if !errors.Is(acquireErr, ErrLockAlreadyAcquired) && ctx.Err() == nil {
+			e.opts.logger.Errorf("failed to acquire leadership lock with key %s, error: %v", e.lock.Key, acquireErr)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if acquireErr != nil {
+			timer := e.lock.manager.clock.NewTimer(e.opts.retryInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C():
+			}
+		}
+	}
+}
+
+// lead keeps the leadership until it's lost (or expired) or ctx is done.
+func (e *Elector) lead(ctx context.Context) error {
+	keeper, err := e.lock.KeepAlive(ctx, e.dbConn,
+		WithKeepAliveInterval(e.opts.renewInterval), WithKeepAliveLogger(e.opts.logger))
+	if err != nil {
+		e.resign()
+		return fmt.Errorf("keep leadership lock with key %s alive: %w", e.lock.Key, err)
+	}
+	leaderCtx, leaderCtxCancel := keeper.Context(ctx)
+	defer leaderCtxCancel()
+
+	atomic.StoreInt32(&e.isLeader, 1)
+
+	var callbackWG sync.WaitGroup
+	if e.opts.onStartedLeading != nil {
+		callbackWG.Add(1)
+		go func() {
+			defer callbackWG.Done()
+			e.opts.onStartedLeading(leaderCtx)
+		}()
+	}
+
+	<-leaderCtx.Done()
+	if ctx.Err() == nil {
+		e.opts.logger.Errorf("leadership lock with key %s and token %s is lost, error: %v",
+			e.lock.Key, e.lock.token, context.Cause(leaderCtx))
+	}
+
+	leaderCtxCancel()
+	keeper.Stop()
+	atomic.StoreInt32(&e.isLeader, 0)
+	callbackWG.Wait()
+
+	if ctx.Err() != nil {
+		e.resign()
+	}
+	if e.opts.onStoppedLeading != nil {
+		e.opts.onStoppedLeading()
+	}
+	return nil
+}
+
+func (e *Elector) resign() {
+	// ctx may be already done, so we should use a separate context to release the lock.
+	releaseCtx, releaseCtxCancel := context.WithTimeout(context.Background(), e.opts.releaseTimeout)
+	defer releaseCtxCancel()
+	if releaseErr := dbkit.DoInTx(releaseCtx, e.dbConn, func(tx *sql.Tx) error {
+		return e.lock.Release(releaseCtx, tx)
+	}); releaseErr != nil {
+		e.opts.logger.Errorf("failed to release leadership lock with key %s and token %s, error: %v",
+			e.lock.Key, e.lock.token, releaseErr)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	gotesting "testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElector(t *gotesting.T) {
+	const leaseTTL = time.Minute
+
+	expectAcquisition := func(mock sqlmock.Sqlmock, lock DBLock, rowsAffected int64) {
+		mock.ExpectBegin()
+		mock.ExpectExec(lock.manager.queries.acquireLock).
+			WithArgs(mySQLMakeInterval(leaseTTL), sqlmock.AnyArg(), lock.Key, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+		if rowsAffected == 0 {
+			mock.ExpectRollback()
+			return
+		}
+		mock.ExpectCommit()
+	}
+
+	t.Run("leadership is released on stop", func(t *gotesting.T) {
+		db, mock, lock := newMockedLock(t, 0)
+		defer func() { _ = db.Close() }()
+
+		expectAcquisition(mock, lock, 0)
+		expectAcquisition(mock, lock, 1)
+		mock.ExpectBegin()
+		mock.ExpectExec(lock.manager.queries.releaseLock).WithArgs(lock.Key, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		started := make(chan struct{})
+		stopped := make(chan struct{})
+		elector := NewElector(db, lock, WithElectorLeaseTTL(leaseTTL), WithElectorRetryInterval(10*time.Millisecond),
+			WithOnStartedLeading(func(ctx context.Context) {
+				close(started)
+				<-ctx.Done()
+			}),
+			WithOnStoppedLeading(func() { close(stopped) }))
+		require.False(t, elector.IsLeader())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		runErr := make(chan error, 1)
+		go func() { runErr <- elector.Run(ctx) }()
+
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("elector must become the leader")
+		}
+		require.True(t, elector.IsLeader())
+
+		cancel()
+		require.ErrorIs(t, <-runErr, context.Canceled)
+		<-stopped
+		require.False(t, elector.IsLeader())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("leadership is lost", func(t *gotesting.T) {
+		db, mock, lock := newMockedLock(t, 0)
+		defer func() { _ = db.Close() }()
+
+		expectAcquisition(mock, lock, 1)
+		mock.ExpectBegin()
+		mock.ExpectExec(lock.manager.queries.extendLock).
+			WithArgs(mySQLMakeInterval(leaseTTL), lock.Key, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+		expectAcquisition(mock, lock, 0)
+
+		stopped := make(chan struct{})
+		elector := NewElector(db, lock, WithElectorLeaseTTL(leaseTTL), WithElectorRenewInterval(10*time.Millisecond),
+			WithElectorRetryInterval(time.Hour), WithOnStoppedLeading(func() { close(stopped) }))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		runErr := make(chan error, 1)
+		go func() { runErr <- elector.Run(ctx) }()
+
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("leadership must be lost")
+		}
+		require.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, 10*time.Millisecond)
+		require.False(t, elector.IsLeader())
+
+		cancel()
+		require.ErrorIs(t, <-runErr, context.Canceled)
+	})
+}
@@ -8,10 +8,35 @@ package distrlock
 
 import (
 	"errors"
+	"fmt"
+	"time"
 )
 
 // Distributed lock errors.
 var (
 	ErrLockAlreadyAcquired = errors.New("distributed lock already acquired")
 	ErrLockAlreadyReleased = errors.New("distributed lock already released")
+	ErrNoFreeSemaphoreSlot = errors.New("no free distributed semaphore slot")
+	ErrLockNotFound        = errors.New("distributed lock not found")
+	ErrLockExpired         = errors.New("distributed lock expired")
 )
+
+// LockHeldError is returned when the lock cannot be acquired because it's held by someone else.
+// It contains the owner identity of the current holder and the acquisition time for easier debugging.
+// errors.Is(err, ErrLockAlreadyAcquired) returns true for it.
+type LockHeldError struct {
+	Key        string
+	Owner      string
+	AcquiredAt time.Time
+}
+
+// Error returns a string representation of LockHeldError.
+func (e *LockHeldError) Error() string {
+	return fmt.Sprintf("%s: lock with key %s is held by %s since %s",
+		ErrLockAlreadyAcquired, e.Key, e.Owner, e.AcquiredAt.Format(time.RFC3339))
+}
+
+// Is returns true if target is ErrLockAlreadyAcquired.
+func (e *LockHeldError) Is(target error) bool {
+	return target == ErrLockAlreadyAcquired
+}
@@ -0,0 +1,232 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// LockKeeper keeps an acquired lock alive by periodically extending its TTL in a separate goroutine.
+// If the lock cannot be extended because it was already released (e.g. it was expired and acquired by another process),
+// the keeper stops, Lost channel is closed and Err returns the reason.
+// The keeper extends its own copy of the lock, so ValidUntil of the kept DBLock is not updated;
+// use LockKeeper.ValidUntil instead.
+type LockKeeper struct {
+	lock     *DBLock
+	dbConn   *sql.DB
+	opts     keepAliveOptions
+	lost     chan struct{}
+	done     chan struct{}
+	exited   chan struct{}
+	stopOnce sync.Once
+
+	mu         sync.Mutex
+	err        error
+	validUntil time.Time
+}
+
+type keepAliveOptions struct {
+	extendInterval time.Duration
+	onLost         func(err error)
+	logger         Logger
+}
+
+// KeepAliveOption is an option for KeepAlive and AcquireAndKeepAlive methods.
+type KeepAliveOption func(*keepAliveOptions)
+
+// WithKeepAliveInterval sets interval for periodic lock extension. By default, it's half of the lock TTL.
+// The interval must be positive.
+func WithKeepAliveInterval(interval time.Duration) KeepAliveOption {
+	return func(o *keepAliveOptions) {
+		o.extendInterval = interval
+	}
+}
+
+// WithOnLockLost sets a callback that is called (once) when the lock is lost and cannot be extended anymore.
+func WithOnLockLost(fn func(err error)) KeepAliveOption {
+	return func(o *keepAliveOptions) {
+		o.onLost = fn
+	}
+}
+
+// WithKeepAliveLogger sets logger for reporting lock extension errors.
+func WithKeepAliveLogger(logger Logger) KeepAliveOption {
+	return func(o *keepAliveOptions) {
+		o.logger = logger
+	}
+}
+
+// AcquireAndKeepAlive acquires the lock in a separate transaction and starts extending it periodically in the background.
+// Returned LockKeeper must be stopped when the lock is not needed anymore. Stopping the keeper doesn't release the lock.
+func (l *DBLock) AcquireAndKeepAlive(
+	ctx context.Context, dbConn *sql.DB, lockTTL time.Duration, options ...KeepAliveOption,
+) (*LockKeeper, error) {
+	opts, err := makeKeepAliveOptions(lockTTL, options)
+	if err != nil {
+		return nil, err
+	}
+	if err = dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+		return l.Acquire(ctx, tx, lockTTL)
+	}); err != nil {
+		return nil, err
+	}
+	return l.startKeeper(ctx, dbConn, opts), nil
+}
+
+// KeepAlive starts extending already acquired lock periodically in the background.
+// Extension stops when LockKeeper.Stop is called, when ctx is done or when the lock is lost.
+// An error is returned if the extension interval is not positive (e.g., the lock TTL is zero).
+func (l *DBLock) KeepAlive(ctx context.Context, dbConn *sql.DB, options ...KeepAliveOption) (*LockKeeper, error) {
+	opts, err := makeKeepAliveOptions(l.TTL, options)
+	if err != nil {
+		return nil, err
+	}
+	return l.startKeeper(ctx, dbConn, opts), nil
+}
+
+func makeKeepAliveOptions(lockTTL time.Duration, options []KeepAliveOption) (keepAliveOptions, error) {
+	var opts keepAliveOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.extendInterval == 0 {
+		opts.extendInterval = lockTTL / 2
+	}
+	if opts.extendInterval <= 0 {
+		return keepAliveOptions{}, fmt.Errorf("lock extension interval must be positive, got %s", opts.extendInterval)
+	}
+	if opts.logger == nil {
+		opts.logger = disabledLogger{}
+	}
+	return opts, nil
+}
+
+func (l *DBLock) startKeeper(ctx context.Context, dbConn *sql.DB, opts keepAliveOptions) *LockKeeper {
+	// The lock is extended in the background goroutine, so it works with a copy to not race with the caller.
+	lockCopy := *l
+	validUntil := lockCopy.validUntil
+	if validUntil.IsZero() {
+		validUntil = lockCopy.manager.clock.Now().Add(lockCopy.TTL)
+	}
+	k := &LockKeeper{
+		lock:       &lockCopy,
+		dbConn:     dbConn,
+		opts:       opts,
+		lost:       make(chan struct{}),
+		done:       make(chan struct{}),
+		exited:     make(chan struct{}),
+		validUntil: validUntil,
+	}
+	go k.run(ctx)
+	return k
+}
+
+// Lost returns a channel that is closed when the lock is lost and cannot be extended anymore.
+func (k *LockKeeper) Lost() <-chan struct{} {
+	return k.lost
+}
+
+// Err returns the reason why the lock was lost or nil if it's still kept.
+func (k *LockKeeper) Err() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.err
+}
+
+// ValidUntil returns the local time until which the kept lock is guaranteed to be held.
+// It's moved forward on every successful extension.
+func (k *LockKeeper) ValidUntil() time.Time {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.validUntil
+}
+
+// Context returns a context derived from ctx that is canceled when the lock is lost
+// or its TTL elapses without successful extension (e.g., because the database is unavailable),
+// so the protected work stops promptly instead of running unprotected.
+// context.Cause returns ErrLockAlreadyReleased or ErrLockExpired respectively in these cases.
+// Returned cancel function should be called to release resources.
+func (k *LockKeeper) Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	guardCtx, guardCtxCancel := context.WithCancelCause(ctx)
+	go func() {
+		clock := k.lock.manager.clock
+		timer := clock.NewTimer(k.ValidUntil().Sub(clock.Now()))
+		defer timer.Stop()
+		for {
+			select {
+			case <-guardCtx.Done():
+				return
+			case <-k.lost:
+				guardCtxCancel(k.Err())
+				return
+			case <-timer.C():
+				remaining := k.ValidUntil().Sub(clock.Now())
+				if remaining <= 0 {
+					guardCtxCancel(ErrLockExpired)
+					return
+				}
+				timer.Reset(remaining)
+			}
+		}
+	}()
+	return guardCtx, func() { guardCtxCancel(context.Canceled) }
+}
+
+// Stop stops periodic lock extension and waits until the background goroutine exits.
+// It's safe to call Stop multiple times.
+func (k *LockKeeper) Stop() {
+	k.stopOnce.Do(func() { close(k.done) })
+	<-k.exited
+}
+
+func (k *LockKeeper) run(ctx context.Context) {
+	defer close(k.exited)
+	timer := k.lock.manager.clock.NewTimer(k.opts.extendInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-k.done:
+			return
+		case <-ctx.Done():
+			return
+		case <-timer.C():
+			timer.Reset(k.opts.extendInterval)
+			extendErr := dbkit.DoInTx(ctx, k.dbConn, func(tx *sql.Tx) error {
+				return k.lock.Extend(ctx, tx)
+			})
+			if extendErr == nil {
+				k.mu.Lock()
+				k.validUntil = k.lock.validUntil
+				k.mu.Unlock()
+				continue
+			}
+			k.opts.logger.Errorf("failed to extend lock with key %s and token %s, error: %v",
+				k.lock.Key, k.lock.token, extendErr)
+			if errors.Is(extendErr, ErrLockAlreadyReleased) {
+				k.markLost(extendErr)
+				return
+			}
+		}
+	}
+}
+
+func (k *LockKeeper) markLost(err error) {
+	k.mu.Lock()
+	k.err = err
+	k.mu.Unlock()
+	close(k.lost)
+	if k.opts.onLost != nil {
+		k.opts.onLost(err)
+	}
+}
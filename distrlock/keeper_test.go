@@ -0,0 +1,195 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	gotesting "testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/testkit"
+)
+
+func newMockedLock(t *gotesting.T, lockTTL time.Duration, options ...DBManagerOption) (*sql.DB, sqlmock.Sqlmock, DBLock) {
+	t.Helper()
+	dbManager, err := NewDBManager(dbkit.DialectMySQL, options...)
+	require.NoError(t, err)
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	return db, mock, DBLock{Key: "test-lock-key", TTL: lockTTL, token: "test-token", manager: dbManager}
+}
+
+func expectLockExtension(mock sqlmock.Sqlmock, lock DBLock, rowsAffected int64) {
+	mock.ExpectBegin()
+	mock.ExpectExec(lock.manager.queries.extendLock).
+		WithArgs(mySQLMakeInterval(lock.TTL), lock.Key, lock.token).
+		WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+	if rowsAffected == 0 {
+		mock.ExpectRollback()
+		return
+	}
+	mock.ExpectCommit()
+}
+
+func TestLockKeeper(t *gotesting.T) {
+	const lockTTL = 40 * time.Millisecond
+
+	t.Run("lock is extended periodically until stop", func(t *gotesting.T) {
+		db, mock, lock := newMockedLock(t, lockTTL)
+		defer func() { _ = db.Close() }()
+
+		expectLockExtension(mock, lock, 1)
+		expectLockExtension(mock, lock, 1)
+
+		var lostCalled bool
+		keeper, err := lock.KeepAlive(context.Background(), db, WithOnLockLost(func(error) { lostCalled = true }))
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, lockTTL/4)
+		keeper.Stop()
+		keeper.Stop() // Must be idempotent.
+
+		require.NoError(t, keeper.Err())
+		require.False(t, lostCalled)
+		select {
+		case <-keeper.Lost():
+			t.Fatal("lock must not be lost")
+		default:
+		}
+	})
+
+	t.Run("lock is lost", func(t *gotesting.T) {
+		db, mock, lock := newMockedLock(t, lockTTL)
+		defer func() { _ = db.Close() }()
+
+		expectLockExtension(mock, lock, 0)
+
+		lostErrs := make(chan error, 1)
+		keeper, err := lock.KeepAlive(context.Background(), db,
+			WithKeepAliveInterval(lockTTL/4), WithOnLockLost(func(err error) { lostErrs <- err }))
+		require.NoError(t, err)
+		defer keeper.Stop()
+
+		select {
+		case <-keeper.Lost():
+		case <-time.After(time.Second):
+			t.Fatal("lock must be lost")
+		}
+		require.ErrorIs(t, keeper.Err(), ErrLockAlreadyReleased)
+		require.ErrorIs(t, <-lostErrs, ErrLockAlreadyReleased)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("context is canceled when lock is lost", func(t *gotesting.T) {
+		db, mock, lock := newMockedLock(t, lockTTL)
+		defer func() { _ = db.Close() }()
+
+		expectLockExtension(mock, lock, 0)
+
+		keeper, err := lock.KeepAlive(context.Background(), db, WithKeepAliveInterval(lockTTL/4))
+		require.NoError(t, err)
+		defer keeper.Stop()
+		guardCtx, guardCtxCancel := keeper.Context(context.Background())
+		defer guardCtxCancel()
+
+		select {
+		case <-guardCtx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("context must be canceled")
+		}
+		require.ErrorIs(t, context.Cause(guardCtx), ErrLockAlreadyReleased)
+	})
+
+	t.Run("context is canceled when lock expires without extension", func(t *gotesting.T) {
+		db, mock, lock := newMockedLock(t, lockTTL)
+		defer func() { _ = db.Close() }()
+
+		mock.ExpectBegin().WillReturnError(errors.New("database is unavailable"))
+		mock.MatchExpectationsInOrder(false)
+
+		lock.validUntil = time.Now().Add(lockTTL)
+		keeper, err := lock.KeepAlive(context.Background(), db, WithKeepAliveInterval(lockTTL/4))
+		require.NoError(t, err)
+		defer keeper.Stop()
+		guardCtx, guardCtxCancel := keeper.Context(context.Background())
+		defer guardCtxCancel()
+
+		select {
+		case <-guardCtx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("context must be canceled")
+		}
+		require.ErrorIs(t, context.Cause(guardCtx), ErrLockExpired)
+		require.False(t, time.Now().Before(lock.validUntil))
+		require.NoError(t, keeper.Err()) // Lock is not reported as lost, since it may be extended later.
+	})
+	t.Run("context is canceled when lock expires, fake clock", func(t *gotesting.T) {
+		clock := testkit.NewFakeClock(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+		db, mock, lock := newMockedLock(t, time.Minute, WithClock(clock))
+		defer func() { _ = db.Close() }()
+
+		lock.validUntil = clock.Now().Add(time.Minute)
+		keeper, err := lock.KeepAlive(context.Background(), db, WithKeepAliveInterval(time.Hour))
+		require.NoError(t, err)
+		defer keeper.Stop()
+		guardCtx, guardCtxCancel := keeper.Context(context.Background())
+		defer guardCtxCancel()
+
+		waitCtx, waitCtxCancel := context.WithTimeout(context.Background(), time.Second)
+		defer waitCtxCancel()
+		require.NoError(t, clock.WaitForTimers(waitCtx, 2)) // Extension and expiration timers.
+		clock.Advance(time.Minute - time.Millisecond)
+		require.NoError(t, guardCtx.Err())
+
+		clock.Advance(time.Millisecond)
+		select {
+		case <-guardCtx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("context must be canceled")
+		}
+		require.ErrorIs(t, context.Cause(guardCtx), ErrLockExpired)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("extension interval must be positive", func(t *gotesting.T) {
+		db, _, lock := newMockedLock(t, 0)
+		defer func() { _ = db.Close() }()
+
+		_, err := lock.KeepAlive(context.Background(), db)
+		require.EqualError(t, err, "lock extension interval must be positive, got 0s")
+		_, err = lock.AcquireAndKeepAlive(context.Background(), db, lockTTL, WithKeepAliveInterval(-time.Second))
+		require.EqualError(t, err, "lock extension interval must be positive, got -1s")
+	})
+
+	t.Run("lock is read while being extended", func(t *gotesting.T) {
+		db, mock, lock := newMockedLock(t, lockTTL)
+		defer func() { _ = db.Close() }()
+
+		const extensionsNum = 3
+		for i := 0; i < extensionsNum; i++ {
+			expectLockExtension(mock, lock, 1)
+		}
+		lock.validUntil = time.Now().Add(lockTTL)
+		keeper, err := lock.KeepAlive(context.Background(), db, WithKeepAliveInterval(time.Millisecond))
+		require.NoError(t, err)
+		initialValidUntil := lock.ValidUntil()
+		// Lock fields are read concurrently with extensions (run with -race to catch data races).
+		require.Eventually(t, func() bool {
+			_, _ = lock.ValidUntil(), lock.Token()
+			return mock.ExpectationsWereMet() == nil
+		}, time.Second, time.Millisecond)
+		keeper.Stop()
+
+		require.Equal(t, initialValidUntil, lock.ValidUntil())
+		require.True(t, keeper.ValidUntil().After(initialValidUntil))
+	})
+}
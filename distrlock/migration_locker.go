@@ -0,0 +1,66 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+// DefaultMigrationLockTTL is the default TTL of the lock used by MigrationLocker.
+// The lock is extended periodically while migrations are applied, so the TTL doesn't limit their duration.
+const DefaultMigrationLockTTL = 30 * time.Second
+
+// MigrationLocker implements migrate.MigrationLocker on top of DBLock,
+// so only one service instance applies migrations in migrate.MigrationGate.
+// The lock is kept alive (see LockKeeper) until it's unlocked.
+type MigrationLocker struct {
+	dbConn  *sql.DB
+	lock    DBLock
+	lockTTL time.Duration
+	keeper  *LockKeeper
+}
+
+var _ migrate.MigrationLocker = (*MigrationLocker)(nil)
+
+// NewMigrationLocker creates a new MigrationLocker for the initialized lock (see DBManager.NewLock).
+// DefaultMigrationLockTTL is used if lockTTL is not positive.
+func NewMigrationLocker(dbConn *sql.DB, lock DBLock, lockTTL time.Duration) *MigrationLocker {
+	if lockTTL <= 0 {
+		lockTTL = DefaultMigrationLockTTL
+	}
+	return &MigrationLocker{dbConn: dbConn, lock: lock, lockTTL: lockTTL}
+}
+
+// TryLock tries to acquire the lock. It returns false if the lock is held by another instance.
+func (m *MigrationLocker) TryLock(ctx context.Context) (bool, error) {
+	keeper, err := m.lock.AcquireAndKeepAlive(ctx, m.dbConn, m.lockTTL)
+	if err != nil {
+		if errors.Is(err, ErrLockAlreadyAcquired) {
+			return false, nil
+		}
+		return false, err
+	}
+	m.keeper = keeper
+	return true, nil
+}
+
+// Unlock stops extending the lock and releases it.
+func (m *MigrationLocker) Unlock(ctx context.Context) error {
+	if m.keeper != nil {
+		m.keeper.Stop()
+		m.keeper = nil
+	}
+	return dbkit.DoInTx(ctx, m.dbConn, func(tx *sql.Tx) error {
+		return m.lock.Release(ctx, tx)
+	})
+}
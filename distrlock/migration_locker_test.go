@@ -0,0 +1,50 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	gotesting "testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationLocker(t *gotesting.T) {
+	const lockTTL = time.Minute
+
+	t.Run("lock is held by another instance", func(t *gotesting.T) {
+		db, mock, lock := newMockedLock(t, 0)
+		defer func() { _ = db.Close() }()
+		expectLockAcquisition(mock, lock, lockTTL, 0)
+
+		locked, err := NewMigrationLocker(db, lock, lockTTL).TryLock(context.Background())
+		require.NoError(t, err)
+		require.False(t, locked)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("lock is acquired and released", func(t *gotesting.T) {
+		db, mock, lock := newMockedLock(t, 0)
+		defer func() { _ = db.Close() }()
+		expectLockAcquisition(mock, lock, lockTTL, 1)
+
+		locker := NewMigrationLocker(db, lock, lockTTL)
+		locked, err := locker.TryLock(context.Background())
+		require.NoError(t, err)
+		require.True(t, locked)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(lock.manager.queries.releaseLock).
+			WithArgs(lock.Key, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		require.NoError(t, locker.Unlock(context.Background()))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
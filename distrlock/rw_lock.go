@@ -0,0 +1,164 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// DBRWLock represents a read-write lock in the database.
+// It allows up to MaxReaders concurrent readers or one writer across instances.
+// Lock uses the same table as usual locks: each reader holds one of MaxReaders slots,
+// and the writer holds all of them at once, so readers and writer exclude each other.
+// To prevent writer starvation, the writer registers its intent first (a separate lock with "<key>:writer" key),
+// and new readers are not admitted while it's held, so the writer gets the lock as soon as the current readers are done.
+// It may be used for coordinating schema-touching maintenance (writer) against normal processing (readers).
+type DBRWLock struct {
+	sem       *DBSemaphore
+	intentKey string
+
+	mu sync.Mutex
+	// writerToken is the token of the writer intent registered by the previous Lock call that failed
+	// because of the readers, so retries keep (and extend) the same intent.
+	writerToken string
+}
+
+// NewRWLock creates new initialized (but not acquired) distributed read-write lock
+// that allows up to maxReaders concurrent readers.
+func (m *DBManager) NewRWLock(ctx context.Context, executor SQLExecutor, key string, maxReaders int) (*DBRWLock, error) {
+	// Writer intent key is the longest one, so it's checked before the slots of the semaphore are initialized.
+	if len(rwLockIntentKey(key)) > 40 {
+		return nil, fmt.Errorf("read-write lock key with writer suffix cannot be longer than 40 symbols")
+	}
+	sem, err := m.NewSemaphore(ctx, executor, key, maxReaders)
+	if err != nil {
+		return nil, err
+	}
+	intent, err := m.NewLock(ctx, executor, rwLockIntentKey(key))
+	if err != nil {
+		return nil, fmt.Errorf("init writer intent: %w", err)
+	}
+	return &DBRWLock{sem: sem, intentKey: intent.Key}, nil
+}
+
+// Key returns the key of the read-write lock.
+func (l *DBRWLock) Key() string {
+	return l.sem.Key
+}
+
+// MaxReaders returns the maximum number of concurrent readers.
+func (l *DBRWLock) MaxReaders() int {
+	return l.sem.Limit
+}
+
+// RLock acquires the lock for reading.
+// Returned lock represents the acquired reader slot, and it should be released (DBLock.Release) when reading is done.
+// ErrLockAlreadyAcquired is returned if the lock is held by the writer, the writer waits for it (see Lock)
+// or the limit of concurrent readers is reached.
+func (l *DBRWLock) RLock(ctx context.Context, executor SQLQueryExecutor, lockTTL time.Duration) (DBLock, error) {
+	intent, err := l.sem.manager.GetLock(ctx, executor, l.intentKey)
+	if err != nil {
+		return DBLock{}, fmt.Errorf("get writer intent: %w", err)
+	}
+	if intent.Held {
+		return DBLock{}, ErrLockAlreadyAcquired
+	}
+	slot, err := l.sem.Acquire(ctx, executor, lockTTL)
+	if errors.Is(err, ErrNoFreeSemaphoreSlot) {
+		return DBLock{}, ErrLockAlreadyAcquired
+	}
+	return slot, err
+}
+
+// Lock acquires the lock for writing.
+// The writer intent is registered in a separate transaction before acquiring the reader slots,
+// so new readers are not admitted even if Lock fails with ErrLockAlreadyAcquired because some readers still hold the lock.
+// In this case Lock should be retried (with the same DBRWLock) until the readers are done.
+// If the writer gives up, its intent expires after lockTTL.
+// ErrLockAlreadyAcquired is also returned if the lock is held (or waited for) by another writer.
+func (l *DBRWLock) Lock(ctx context.Context, dbConn *sql.DB, lockTTL time.Duration) (*DBWriteLock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.writerToken == "" {
+		l.writerToken = uuid.NewString()
+	}
+	token := l.writerToken
+	intent := DBLock{Key: l.intentKey, manager: l.sem.manager}
+	if err := dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+		return intent.AcquireWithStaticToken(ctx, tx, token, lockTTL)
+	}); err != nil {
+		return nil, err
+	}
+
+	var locks []DBLock
+	if err := dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+		// Slots are acquired in the same transaction, so the ones acquired before the failure are not kept.
+		locks = make([]DBLock, 0, l.sem.Limit+1)
+		for i := 0; i < l.sem.Limit; i++ {
+			slot := DBLock{Key: semaphoreSlotKey(l.sem.Key, i), manager: l.sem.manager}
+			if err := slot.AcquireWithStaticToken(ctx, tx, token, lockTTL); err != nil {
+				return err
+			}
+			locks = append(locks, slot)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	l.writerToken = ""
+	return &DBWriteLock{locks: append(locks, intent)}, nil
+}
+
+// DBWriteLock represents the read-write lock acquired for writing.
+type DBWriteLock struct {
+	locks []DBLock // Reader slots and the writer intent.
+}
+
+// Release releases the write lock.
+// Executor should be a transaction, so the lock is released atomically.
+func (wl *DBWriteLock) Release(ctx context.Context, executor SQLExecutor) error {
+	for i := range wl.locks {
+		if err := wl.locks[i].Release(ctx, executor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Extend resets expiration timeout for the write lock.
+// ErrLockAlreadyReleased error will be returned if lock is already released, in this case lock should be acquired again.
+func (wl *DBWriteLock) Extend(ctx context.Context, executor SQLExecutor) error {
+	for i := range wl.locks {
+		if err := wl.locks[i].Extend(ctx, executor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Token returns token of the write lock.
+// May be used in logs to make the investigation process easier.
+func (wl *DBWriteLock) Token() string {
+	if len(wl.locks) == 0 {
+		return ""
+	}
+	return wl.locks[0].Token()
+}
+
+func rwLockIntentKey(key string) string {
+	return key + ":writer"
+}
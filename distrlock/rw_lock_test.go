@@ -0,0 +1,137 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	gotesting "testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBRWLock(t *gotesting.T) {
+	const lockTTL = time.Minute
+
+	db, mock, lock := newMockedLock(t, 0)
+	defer func() { _ = db.Close() }()
+	manager := lock.manager
+
+	expectSlotAcquisition := func(slotKey interface{}, token interface{}, rowsAffected int64) {
+		mock.ExpectExec(manager.queries.acquireLock).
+			WithArgs(mySQLMakeInterval(lockTTL), token, slotKey, token).
+			WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+	}
+	expectIntentQuery := func(held bool) {
+		heldValue := 0
+		if held {
+			heldValue = 1
+		}
+		mock.ExpectQuery(manager.queries.getLock).WithArgs("maintenance:writer").WillReturnRows(
+			sqlmock.NewRows([]string{"lock_key", "token", "expire_at", "held", "owner", "acquired_at"}).
+				AddRow("maintenance:writer", nil, nil, heldValue, nil, nil))
+	}
+
+	for _, key := range []string{"maintenance:0", "maintenance:1", "maintenance:writer"} {
+		mock.ExpectExec(manager.queries.initLock).WithArgs(key).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	rwLock, err := manager.NewRWLock(context.Background(), db, "maintenance", 2)
+	require.NoError(t, err)
+	require.Equal(t, "maintenance", rwLock.Key())
+	require.Equal(t, 2, rwLock.MaxReaders())
+
+	t.Run("too long key", func(t *gotesting.T) {
+		// No rows are initialized if the writer intent key is too long.
+		_, err := manager.NewRWLock(context.Background(), db, strings.Repeat("k", 35), 2)
+		require.EqualError(t, err, "read-write lock key with writer suffix cannot be longer than 40 symbols")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reader", func(t *gotesting.T) {
+		expectIntentQuery(false)
+		expectSlotAcquisition(sqlmock.AnyArg(), sqlmock.AnyArg(), 1)
+		slot, err := rwLock.RLock(context.Background(), db, lockTTL)
+		require.NoError(t, err)
+		require.NotEmpty(t, slot.Token())
+
+		// Writer holds all slots.
+		expectIntentQuery(false)
+		expectSlotAcquisition(sqlmock.AnyArg(), sqlmock.AnyArg(), 0)
+		expectSlotAcquisition(sqlmock.AnyArg(), sqlmock.AnyArg(), 0)
+		_, err = rwLock.RLock(context.Background(), db, lockTTL)
+		require.ErrorIs(t, err, ErrLockAlreadyAcquired)
+
+		// Writer waits for the lock, so new readers are not admitted even if there are free slots.
+		expectIntentQuery(true)
+		_, err = rwLock.RLock(context.Background(), db, lockTTL)
+		require.ErrorIs(t, err, ErrLockAlreadyAcquired)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("writer", func(t *gotesting.T) {
+		token := &capturedToken{}
+		expectIntentAcquisition := func(token sqlmock.Argument, rowsAffected int64) {
+			mock.ExpectBegin()
+			expectSlotAcquisition("maintenance:writer", token, rowsAffected)
+			if rowsAffected == 0 {
+				mock.ExpectRollback()
+				return
+			}
+			mock.ExpectCommit()
+		}
+
+		// One slot is held by a reader, but the writer intent is kept, so the retry uses the same token.
+		expectIntentAcquisition(token, 1)
+		mock.ExpectBegin()
+		expectSlotAcquisition("maintenance:0", token, 1)
+		expectSlotAcquisition("maintenance:1", token, 0)
+		mock.ExpectRollback()
+		_, err = rwLock.Lock(context.Background(), db, lockTTL)
+		require.ErrorIs(t, err, ErrLockAlreadyAcquired)
+
+		expectIntentAcquisition(token, 1)
+		mock.ExpectBegin()
+		expectSlotAcquisition("maintenance:0", token, 1)
+		expectSlotAcquisition("maintenance:1", token, 1)
+		mock.ExpectCommit()
+		writeLock, err := rwLock.Lock(context.Background(), db, lockTTL)
+		require.NoError(t, err)
+		require.Equal(t, token.value, writeLock.Token())
+
+		for _, key := range []string{"maintenance:0", "maintenance:1", "maintenance:writer"} {
+			mock.ExpectExec(manager.queries.releaseLock).WithArgs(key, writeLock.Token()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		require.NoError(t, writeLock.Release(context.Background(), db))
+
+		// Another writer waits for the lock.
+		expectIntentAcquisition(sqlmock.AnyArg(), 0)
+		_, err = rwLock.Lock(context.Background(), db, lockTTL)
+		require.ErrorIs(t, err, ErrLockAlreadyAcquired)
+		require.NotEqual(t, token.value, rwLock.writerToken, "new token must be used after successful Lock")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// capturedToken is a sqlmock.Argument that captures the first matched token and then matches only it.
+type capturedToken struct {
+	value string
+}
+
+func (c *capturedToken) Match(v driver.Value) bool {
+	token, ok := v.(string)
+	if !ok {
+		return false
+	}
+	if c.value == "" {
+		c.value = token
+	}
+	return c.value == token
+}
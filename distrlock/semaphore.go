@@ -0,0 +1,71 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DBSemaphore represents a counting semaphore in the database.
+// It allows up to Limit concurrent holders across instances.
+// Each slot of the semaphore is a separate lock (with "<key>:<slot number>" key) in the same table,
+// so slots are released automatically when their TTL expires (e.g., if the holder crashes).
+type DBSemaphore struct {
+	Key     string
+	Limit   int
+	manager *DBManager
+}
+
+// NewSemaphore creates new initialized (but not acquired) distributed semaphore that allows up to limit concurrent holders.
+func (m *DBManager) NewSemaphore(ctx context.Context, executor SQLExecutor, key string, limit int) (*DBSemaphore, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("semaphore limit must be positive")
+	}
+	if key == "" {
+		return nil, fmt.Errorf("semaphore key cannot be empty")
+	}
+	if len(semaphoreSlotKey(key, limit-1)) > 40 {
+		return nil, fmt.Errorf("semaphore key with slot number suffix cannot be longer than 40 symbols")
+	}
+	for i := 0; i < limit; i++ {
+		if _, err := m.NewLock(ctx, executor, semaphoreSlotKey(key, i)); err != nil {
+			return nil, fmt.Errorf("init semaphore slot: %w", err)
+		}
+	}
+	return &DBSemaphore{Key: key, Limit: limit, manager: m}, nil
+}
+
+// Acquire acquires a free slot of the semaphore for lockTTL.
+// Returned lock represents the acquired slot. It should be released (DBLock.Release)
+// when the work is done and may be extended (DBLock.Extend, DBLock.KeepAlive) as a usual lock.
+// ErrNoFreeSemaphoreSlot is returned if all slots are held by someone else.
+func (s *DBSemaphore) Acquire(ctx context.Context, executor SQLExecutor, lockTTL time.Duration) (DBLock, error) {
+	// Slots are tried in random order to reduce contention between instances.
+	for _, i := range rand.Perm(s.Limit) { //nolint:gosec // Crypto rand is not needed here.
+		slot := DBLock{Key: semaphoreSlotKey(s.Key, i), manager: s.manager}
+		// Holders of busy slots are not reported (see DBLock.Acquire), so they are not queried.
+		err := slot.acquire(ctx, executor, uuid.NewString(), lockTTL)
+		if err == nil {
+			return slot, nil
+		}
+		if !errors.Is(err, ErrLockAlreadyAcquired) {
+			return DBLock{}, err
+		}
+	}
+	return DBLock{}, ErrNoFreeSemaphoreSlot
+}
+
+func semaphoreSlotKey(key string, slot int) string {
+	return key + ":" + strconv.Itoa(slot)
+}
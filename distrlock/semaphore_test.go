@@ -0,0 +1,56 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	gotesting "testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBSemaphore(t *gotesting.T) {
+	const lockTTL = time.Minute
+
+	db, mock, lock := newMockedLock(t, 0)
+	defer func() { _ = db.Close() }()
+	manager := lock.manager
+
+	expectSlotAcquisition := func(rowsAffected int64) {
+		mock.ExpectExec(manager.queries.acquireLock).
+			WithArgs(mySQLMakeInterval(lockTTL), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+	}
+
+	mock.ExpectExec(manager.queries.initLock).WithArgs("reports:0").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(manager.queries.initLock).WithArgs("reports:1").WillReturnResult(sqlmock.NewResult(0, 1))
+	sem, err := manager.NewSemaphore(context.Background(), db, "reports", 2)
+	require.NoError(t, err)
+
+	// One slot is held by someone else, the other one is free.
+	expectSlotAcquisition(0)
+	expectSlotAcquisition(1)
+	slot, err := sem.Acquire(context.Background(), db, lockTTL)
+	require.NoError(t, err)
+	require.Contains(t, []string{"reports:0", "reports:1"}, slot.Key)
+	require.NotEmpty(t, slot.Token())
+	require.Equal(t, lockTTL, slot.TTL)
+
+	// All slots are held.
+	expectSlotAcquisition(0)
+	expectSlotAcquisition(0)
+	_, err = sem.Acquire(context.Background(), db, lockTTL)
+	require.ErrorIs(t, err, ErrNoFreeSemaphoreSlot)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = manager.NewSemaphore(context.Background(), db, "reports", 0)
+	require.Error(t, err)
+	_, err = manager.NewSemaphore(context.Background(), db, "very-long-semaphore-key-0123456789abcdef", 10)
+	require.Error(t, err)
+}
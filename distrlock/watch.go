@@ -0,0 +1,179 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// DefaultWatchPollInterval is the default interval of polling the lock state in DBManager.Watch.
+const DefaultWatchPollInterval = time.Second
+
+var errListenNotSupported = errors.New("LISTEN/NOTIFY is supported only for pgx driver")
+
+type watchOptions struct {
+	pollInterval time.Duration
+	logger       Logger
+}
+
+// WatchOption is an option for DBManager.Watch method.
+type WatchOption func(*watchOptions)
+
+// WithWatchPollInterval sets the interval of polling the lock state.
+// By default, DefaultWatchPollInterval is used.
+func WithWatchPollInterval(interval time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.pollInterval = interval
+	}
+}
+
+// WithWatchLogger sets the logger for errors that occur while watching the lock.
+func WithWatchLogger(logger Logger) WatchOption {
+	return func(o *watchOptions) {
+		o.logger = logger
+	}
+}
+
+// Watch watches the lock with the given key and notifies (via the returned channel) when the lock becomes free,
+// so waiters may avoid tight polling loops and try to acquire the lock as soon as the current holder releases it.
+// The notification is sent if the lock is free when watching is started and then each time
+// the lock is observed to become free (released or expired). Notifications are not queued:
+// if the previous one is not received yet, the new one is dropped.
+// The lock state is polled periodically (see WithWatchPollInterval). Additionally, for DialectPgx
+// when the manager is created with WithReleaseNotifications option, LISTEN/NOTIFY is used
+// to react on the release immediately.
+// The returned channel is closed when ctx is done.
+func (m *DBManager) Watch(ctx context.Context, dbConn *sql.DB, key string, options ...WatchOption) <-chan struct{} {
+	opts := watchOptions{pollInterval: DefaultWatchPollInterval, logger: disabledLogger{}}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	notifications := make(chan struct{}, 1)
+	released := make(chan struct{}, 1)
+	if m.notifyRelease && m.dialect == dbkit.DialectPgx {
+		go m.listenReleases(ctx, dbConn, key, released, opts.logger)
+	}
+	go func() {
+		defer close(notifications)
+		wasFree := false
+		timer := m.clock.NewTimer(opts.pollInterval)
+		defer timer.Stop()
+		for {
+			lockInfo, err := m.GetLock(ctx, dbConn, key)
+			switch {
+			case err == nil || errors.Is(err, ErrLockNotFound):
+				isFree := !lockInfo.Held
+				if isFree && !wasFree {
+					select {
+					case notifications <- struct{}{}:
+					default:
+					}
+				}
+				wasFree = isFree
+			case ctx.Err() == nil:
+				opts.logger.Errorf("failed to get state of lock with key %s: %v", key, err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C():
+				timer.Reset(opts.pollInterval)
+			case <-released:
+				wasFree = false // Lock could be released and acquired again between polls, so notify anyway.
+			}
+		}
+	}()
+	return notifications
+}
+
+// releaseChannel returns the name of the Postgres channel that is used for notifications about released locks.
+func (m *DBManager) releaseChannel() string {
+	return m.queries.tableName + "_released"
+}
+
+// notifyReleased notifies watchers about the released lock if release notifications are enabled.
+func (m *DBManager) notifyReleased(ctx context.Context, executor SQLExecutor, key string) error {
+	if !m.notifyRelease {
+		return nil
+	}
+	if _, err := executor.ExecContext(ctx, m.queries.notifyRelease, m.releaseChannel(), key); err != nil {
+		return fmt.Errorf("notify about release of lock with key %s: %w", key, err)
+	}
+	return nil
+}
+
+// listenReleases listens for notifications about released locks using a dedicated connection
+// and signals to the passed channel when the lock with the given key is released.
+// The connection is reconnected (after the poll interval) if it's broken.
+func (m *DBManager) listenReleases(
+	ctx context.Context, dbConn *sql.DB, key string, released chan<- struct{}, logger Logger,
+) {
+	for {
+		err := m.listenReleasesOnce(ctx, dbConn, key, released)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, errListenNotSupported) {
+			logger.Errorf("failed to listen for releases of lock with key %s, only polling is used: %v", key, err)
+			return
+		}
+		logger.Errorf("failed to listen for releases of lock with key %s: %v", key, err)
+		timer := m.clock.NewTimer(DefaultWatchPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+	}
+}
+
+func (m *DBManager) listenReleasesOnce(ctx context.Context, dbConn *sql.DB, key string, released chan<- struct{}) error {
+	conn, err := dbConn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	var listenErr error
+	_ = conn.Raw(func(driverConn interface{}) error {
+		pgxConnProvider, ok := driverConn.(interface{ Conn() *pgx.Conn })
+		if !ok {
+			listenErr = errListenNotSupported
+			return nil
+		}
+		pgxConn := pgxConnProvider.Conn()
+		if _, listenErr = pgxConn.Exec(ctx, "LISTEN "+pgx.Identifier{m.releaseChannel()}.Sanitize()); listenErr != nil {
+			return driver.ErrBadConn
+		}
+		for {
+			notification, waitErr := pgxConn.WaitForNotification(ctx)
+			if waitErr != nil {
+				listenErr = waitErr
+				// The connection is still subscribed (or broken), so it should not be returned to the pool.
+				return driver.ErrBadConn
+			}
+			if notification.Payload == key {
+				select {
+				case released <- struct{}{}:
+				default:
+				}
+			}
+		}
+	})
+	return listenErr
+}
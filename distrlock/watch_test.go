@@ -0,0 +1,67 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package distrlock
+
+import (
+	"context"
+	gotesting "testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBManager_Watch(t *gotesting.T) {
+	const pollInterval = 10 * time.Millisecond
+
+	db, mock, lock := newMockedLock(t, 0)
+	defer func() { _ = db.Close() }()
+
+	expectGetLock := func(held bool) {
+		mock.ExpectQuery(lock.manager.queries.getLock).WithArgs(lock.Key).WillReturnRows(
+			sqlmock.NewRows([]string{"lock_key", "token", "expire_at", "held", "owner", "acquired_at"}).
+				AddRow(lock.Key, lock.token, nil, held, nil, nil))
+	}
+	expectGetLock(false) // Free initially, notification is sent.
+	expectGetLock(false) // Still free, no notification.
+	expectGetLock(true)  // Acquired by someone.
+	expectGetLock(true)
+	expectGetLock(false) // Released, notification is sent.
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+	notifications := lock.manager.Watch(ctx, db, lock.Key, WithWatchPollInterval(pollInterval))
+
+	requireNotification := func() {
+		t.Helper()
+		select {
+		case _, ok := <-notifications:
+			require.True(t, ok)
+		case <-time.After(time.Second):
+			t.Fatal("notification is expected")
+		}
+	}
+	requireNotification()
+	requireNotification()
+	require.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, pollInterval)
+
+	select {
+	case <-notifications:
+		t.Fatal("unexpected notification")
+	case <-time.After(pollInterval * 3):
+	}
+
+	ctxCancel()
+	require.Eventually(t, func() bool {
+		select {
+		case _, ok := <-notifications:
+			return !ok
+		default:
+			return false
+		}
+	}, time.Second, pollInterval)
+}
@@ -0,0 +1,16 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package drivertest provides conformance tests for dialect packages of dbkit (e.g., mysql, postgres, pgx, mssql).
+// They check on a real database that errors returned by the driver are classified (see dbkit.ClassifyError
+// and dbkit.ClassifyConstraintViolation) and treated as retryable (see dbkit.GetIsRetryable) as dbkit expects.
+// It's useful for anyone implementing support for a new driver or dialect:
+//
+//	func TestConformance(t *testing.T) {
+//		db := dbtest.Open(t, dbkit.DialectPostgres)
+//		drivertest.RunConformanceTests(t, db.DB, dbkit.DialectPostgres)
+//	}
+package drivertest
@@ -0,0 +1,160 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package drivertest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/testkit"
+)
+
+// DefaultTestTimeout is a default timeout of each conformance test.
+const DefaultTestTimeout = 30 * time.Second
+
+// RunConformanceTests runs all conformance tests supported by the dialect as subtests.
+// The dialect package (e.g., github.com/acronis/go-dbkit/postgres) should be imported by the test,
+// and dbConn should allow at least 2 open connections.
+// Deadlock and serialization failure tests are skipped for dialects that don't detect them (e.g., SQLite).
+func RunConformanceTests(t *testing.T, dbConn *sql.DB, dialect dbkit.Dialect) {
+	t.Run("Deadlock", func(t *testing.T) {
+		if dialect == dbkit.DialectSQLite {
+			t.Skip("SQLite locks the whole database, so deadlocks are not detected")
+		}
+		DeadlockTest(t, dbConn, dialect, func(err error) bool {
+			return dbkit.IsDeadlock(err) && dbkit.GetIsRetryable(dbConn.Driver())(err)
+		})
+	})
+	t.Run("SerializationFailure", func(t *testing.T) {
+		if dialect != dbkit.DialectPostgres && dialect != dbkit.DialectPgx {
+			t.Skipf("serialization failures are not detected by %s", dialect)
+		}
+		SerializationFailureTest(t, dbConn, dialect, func(err error) bool {
+			return dbkit.IsSerializationFailure(err) && dbkit.GetIsRetryable(dbConn.Driver())(err)
+		})
+	})
+	t.Run("UniqueViolation", func(t *testing.T) {
+		UniqueViolationTest(t, dbConn, dialect)
+	})
+	t.Run("ContextCanceled", func(t *testing.T) {
+		ContextCanceledTest(t, dbConn)
+	})
+}
+
+// DeadlockTest provokes the deadlock between two concurrent transactions and checks
+// that exactly one of them fails with the error satisfying checkDeadlockErr.
+func DeadlockTest(t *testing.T, dbConn *sql.DB, dialect dbkit.Dialect, checkDeadlockErr func(err error) bool) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTestTimeout)
+	defer cancel()
+	table1 := createTestTable(ctx, t, dbConn, dialect, "drivertest_deadlock1", 2)
+	table2 := createTestTable(ctx, t, dbConn, dialect, "drivertest_deadlock2", 2)
+
+	conflict := testkit.NewDeadlockConflict(
+		testkit.Statement{Query: updateQuery(dialect, table1), Args: []interface{}{"updated", 1}},
+		testkit.Statement{Query: updateQuery(dialect, table2), Args: []interface{}{"updated", 1}},
+	)
+	tx1Err, tx2Err := testkit.RunTxConflict(ctx, dbConn, conflict)
+	require.NoError(t, testkit.CheckOneConflictError(tx1Err, tx2Err, checkDeadlockErr),
+		"deadlock error is expected in exactly one of the transactions")
+}
+
+// SerializationFailureTest provokes the serialization failure (write skew) between two concurrent
+// serializable transactions and checks that exactly one of them fails with the error satisfying checkErr.
+func SerializationFailureTest(t *testing.T, dbConn *sql.DB, dialect dbkit.Dialect, checkErr func(err error) bool) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTestTimeout)
+	defer cancel()
+	table := createTestTable(ctx, t, dbConn, dialect, "drivertest_serialization", 2)
+
+	selectQuery := fmt.Sprintf("SELECT name FROM %s WHERE id = %s", table, placeholder(dialect, 1))
+	conflict := testkit.NewSerializationFailureConflict(
+		testkit.Statement{Query: selectQuery, Args: []interface{}{1}},
+		testkit.Statement{Query: updateQuery(dialect, table), Args: []interface{}{"updated", 2}},
+		testkit.Statement{Query: selectQuery, Args: []interface{}{2}},
+		testkit.Statement{Query: updateQuery(dialect, table), Args: []interface{}{"updated", 1}},
+	)
+	tx1Err, tx2Err := testkit.RunTxConflict(ctx, dbConn, conflict)
+	require.NoError(t, testkit.CheckOneConflictError(tx1Err, tx2Err, checkErr),
+		"serialization failure is expected in exactly one of the transactions")
+}
+
+// UniqueViolationTest checks that the duplicated primary key is classified as the unique constraint violation
+// and is not retryable.
+func UniqueViolationTest(t *testing.T, dbConn *sql.DB, dialect dbkit.Dialect) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTestTimeout)
+	defer cancel()
+	table := createTestTable(ctx, t, dbConn, dialect, "drivertest_unique", 1)
+
+	_, err := dbConn.ExecContext(ctx, insertQuery(dialect, table), 1, "duplicate")
+	require.Error(t, err)
+	require.True(t, dbkit.IsUniqueViolation(err), "unique violation is expected, got: %v", err)
+	require.Equal(t, dbkit.ErrorClassConstraintViolation, dbkit.ClassifyError(err))
+	require.False(t, dbkit.GetIsRetryable(dbConn.Driver())(err), "unique violation must not be retryable")
+}
+
+// ContextCanceledTest checks that the error of the query with the canceled context is classified
+// as dbkit.ErrorClassContextCanceled.
+func ContextCanceledTest(t *testing.T, dbConn *sql.DB) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := dbConn.ExecContext(ctx, "SELECT 1")
+	require.Error(t, err)
+	require.Equal(t, dbkit.ErrorClassContextCanceled, dbkit.ClassifyError(err))
+	require.True(t, errors.Is(err, context.Canceled))
+}
+
+// createTestTable creates the table with rows (id, name) numbered from 1 to rowsCount.
+// The table is dropped when the test completes.
+func createTestTable(
+	ctx context.Context, t *testing.T, dbConn *sql.DB, dialect dbkit.Dialect, table string, rowsCount int,
+) string {
+	t.Helper()
+	dropQuery := "DROP TABLE IF EXISTS " + table
+	_, err := dbConn.ExecContext(ctx, dropQuery)
+	require.NoError(t, err)
+	_, err = dbConn.ExecContext(ctx, "CREATE TABLE "+table+" (id INTEGER NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL)")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, dropErr := dbConn.ExecContext(context.Background(), dropQuery)
+		require.NoError(t, dropErr)
+	})
+	for i := 1; i <= rowsCount; i++ {
+		_, err = dbConn.ExecContext(ctx, insertQuery(dialect, table), i, "name"+strconv.Itoa(i))
+		require.NoError(t, err)
+	}
+	return table
+}
+
+func insertQuery(dialect dbkit.Dialect, table string) string {
+	return fmt.Sprintf("INSERT INTO %s (id, name) VALUES (%s, %s)", table, placeholder(dialect, 1), placeholder(dialect, 2))
+}
+
+func updateQuery(dialect dbkit.Dialect, table string) string {
+	return fmt.Sprintf("UPDATE %s SET name = %s WHERE id = %s", table, placeholder(dialect, 1), placeholder(dialect, 2))
+}
+
+func placeholder(dialect dbkit.Dialect, i int) string {
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		return "$" + strconv.Itoa(i)
+	case dbkit.DialectMSSQL:
+		return "@p" + strconv.Itoa(i)
+	default:
+		return "?"
+	}
+}
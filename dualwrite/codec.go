@@ -0,0 +1,146 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dualwrite
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// JSONCodecName is the name of JSONCodec. It's also used for outbox records written before codecs were introduced.
+const JSONCodecName = "json"
+
+// JSONCodecSchemaVersion is the current version of the payload schema written by JSONCodec.
+// Version 2 tags []byte args (see JSONCodec), payloads of version 1 are still decoded.
+const JSONCodecSchemaVersion = 2
+
+// jsonCodecBytesKey is the key of the JSON object that JSONCodec writes instead of []byte args.
+const jsonCodecBytesKey = "$bytes"
+
+// Codec serializes statements into payloads of outbox records (ModeOutbox only).
+// The name of the codec and the schema version are stored with each record,
+// so payloads may evolve safely: the relay picks the codec by the name (see WithOutboxDecoders),
+// and the codec may decode payloads of older schema versions.
+// Besides the built-in JSONCodec, codecs based on protobuf, Avro, etc. may be implemented.
+type Codec interface {
+	// Name returns the unique name of the codec.
+	Name() string
+	// SchemaVersion returns the version of the payload schema that is written by Marshal.
+	SchemaVersion() int
+	// Marshal serializes statements into the payload.
+	Marshal(stmts []Statement) ([]byte, error)
+	// Unmarshal deserializes statements from the payload that was written with the given schema version.
+	Unmarshal(payload []byte, schemaVersion int) ([]Statement, error)
+}
+
+// BinaryCodec may be implemented by codecs that produce binary (not UTF-8 text) payloads, e.g. protobuf or Avro.
+// The payload column of the outbox table is textual, so such payloads are stored base64-encoded.
+type BinaryCodec interface {
+	Codec
+	Binary() bool
+}
+
+// JSONCodec is the default Codec that serializes statements in JSON.
+// Args should be of JSON-serializable types, numbers are decoded as json.Number to not lose precision of big integers.
+// []byte args (e.g., for BLOB or bytea columns) are written as {"$bytes": "<base64>"} objects and decoded back as []byte,
+// so they are not written to the secondary database as base64 text.
+type JSONCodec struct{}
+
+// Name implements Codec.
+func (JSONCodec) Name() string {
+	return JSONCodecName
+}
+
+// SchemaVersion implements Codec.
+func (JSONCodec) SchemaVersion() int {
+	return JSONCodecSchemaVersion
+}
+
+// Marshal implements Codec.
+func (JSONCodec) Marshal(stmts []Statement) ([]byte, error) {
+	tagged := make([]Statement, len(stmts))
+	for i, stmt := range stmts {
+		tagged[i] = stmt
+		copied := false
+		for j, arg := range stmt.Args {
+			b, ok := arg.([]byte)
+			if !ok || b == nil {
+				continue
+			}
+			if !copied {
+				tagged[i].Args = append([]interface{}(nil), stmt.Args...) // Don't modify args of the caller.
+				copied = true
+			}
+			tagged[i].Args[j] = map[string][]byte{jsonCodecBytesKey: b}
+		}
+	}
+	return json.Marshal(tagged)
+}
+
+// Unmarshal implements Codec.
+func (JSONCodec) Unmarshal(payload []byte, schemaVersion int) ([]Statement, error) {
+	if schemaVersion != 1 && schemaVersion != JSONCodecSchemaVersion {
+		return nil, fmt.Errorf("unsupported schema version %d", schemaVersion)
+	}
+	var stmts []Statement
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber() // Don't lose precision of big integers.
+	if err := dec.Decode(&stmts); err != nil {
+		return nil, err
+	}
+	if schemaVersion == 1 {
+		return stmts, nil // []byte args were not tagged, they are decoded as base64 strings.
+	}
+	for _, stmt := range stmts {
+		for i, arg := range stmt.Args {
+			obj, ok := arg.(map[string]interface{})
+			if !ok || len(obj) != 1 {
+				continue
+			}
+			encoded, ok := obj[jsonCodecBytesKey].(string)
+			if !ok {
+				continue
+			}
+			b, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("decode %s arg: %w", jsonCodecBytesKey, err)
+			}
+			stmt.Args[i] = b
+		}
+	}
+	return stmts, nil
+}
+
+func isBinaryCodec(codec Codec) bool {
+	binCodec, ok := codec.(BinaryCodec)
+	return ok && binCodec.Binary()
+}
+
+func encodePayload(codec Codec, stmts []Statement) (string, error) {
+	payload, err := codec.Marshal(stmts)
+	if err != nil {
+		return "", err
+	}
+	if isBinaryCodec(codec) {
+		return base64.StdEncoding.EncodeToString(payload), nil
+	}
+	return string(payload), nil
+}
+
+func decodePayload(codec Codec, payload []byte, schemaVersion int) ([]Statement, error) {
+	if isBinaryCodec(codec) {
+		decoded := make([]byte, base64.StdEncoding.DecodedLen(len(payload)))
+		n, err := base64.StdEncoding.Decode(decoded, payload)
+		if err != nil {
+			return nil, fmt.Errorf("decode base64: %w", err)
+		}
+		payload = decoded[:n]
+	}
+	return codec.Unmarshal(payload, schemaVersion)
+}
@@ -0,0 +1,41 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dualwrite
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONCodec_RoundTrip(t *testing.T) {
+	stmts := []Statement{
+		{Query: "INSERT INTO files (id, name, content) VALUES (?, ?, ?)", Args: []interface{}{1, "a.bin", []byte{0x00, 0xff, 'x'}}},
+		{Query: "UPDATE files SET content = ? WHERE id = ?", Args: []interface{}{[]byte(nil), 1}},
+		{Query: "DELETE FROM files"},
+	}
+	payload, err := JSONCodec{}.Marshal(stmts)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x00, 0xff, 'x'}, stmts[0].Args[2], "args of the caller must not be modified")
+
+	decoded, err := JSONCodec{}.Unmarshal(payload, JSONCodecSchemaVersion)
+	require.NoError(t, err)
+	require.Equal(t, []Statement{
+		{Query: stmts[0].Query, Args: []interface{}{json.Number("1"), "a.bin", []byte{0x00, 0xff, 'x'}}},
+		{Query: stmts[1].Query, Args: []interface{}{nil, json.Number("1")}},
+		{Query: stmts[2].Query},
+	}, decoded)
+
+	// Payloads of schema version 1 are decoded as before.
+	decoded, err = JSONCodec{}.Unmarshal([]byte(`[{"query":"SELECT ?","args":["AP94"]}]`), 1)
+	require.NoError(t, err)
+	require.Equal(t, []Statement{{Query: "SELECT ?", Args: []interface{}{"AP94"}}}, decoded)
+
+	_, err = JSONCodec{}.Unmarshal(payload, 3)
+	require.EqualError(t, err, "unsupported schema version 3")
+}
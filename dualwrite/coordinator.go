@@ -0,0 +1,422 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dualwrite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/acronis/go-appkit/log"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+// Default values for the Coordinator options.
+const (
+	DefaultOutboxTableName = "dual_write_outbox"
+	DefaultRelayBatchSize  = 100
+)
+
+// Mode defines how writes are mirrored to the secondary database.
+type Mode int
+
+// Dual-write modes.
+const (
+	ModeBestEffort Mode = iota
+	ModeOutbox
+)
+
+// String returns the string representation of the mode.
+func (m Mode) String() string {
+	switch m {
+	case ModeBestEffort:
+		return "best_effort"
+	case ModeOutbox:
+		return "outbox"
+	default:
+		return "unknown"
+	}
+}
+
+// Statement is a SQL statement that is executed in both primary and secondary databases.
+// Query should use placeholders that are valid for both databases.
+// In ModeOutbox, statements are serialized by the outbox codec (see WithOutboxCodec),
+// so Args should be of types supported by it (JSON-serializable types for the default JSONCodec).
+type Statement struct {
+	Query string        `json:"query"`
+	Args  []interface{} `json:"args,omitempty"`
+}
+
+// Coordinator mirrors writes to the secondary database.
+type Coordinator struct {
+	primary   *sql.DB
+	secondary *sql.DB
+	queries   dbQueries
+	opts      coordinatorOptions
+
+	shadowReadSem chan struct{}
+	shadowReadsWG sync.WaitGroup
+}
+
+type coordinatorOptions struct {
+	mode            Mode
+	outboxTableName string
+	relayBatchSize  int
+	outboxCodec     Codec
+	outboxDecoders  map[string]Codec
+	logger          log.FieldLogger
+	metrics         *PrometheusMetrics
+
+	shadowReadSampleRate  float64
+	shadowReadTimeout     time.Duration
+	shadowReadMaxInFlight int
+}
+
+// Option is a functional option for NewCoordinator.
+type Option func(*coordinatorOptions)
+
+// WithMode sets the dual-write mode. By default, ModeBestEffort is used.
+func WithMode(mode Mode) Option {
+	return func(o *coordinatorOptions) {
+		o.mode = mode
+	}
+}
+
+// WithOutboxTableName sets a custom name for the outbox table (ModeOutbox only).
+// By default, DefaultOutboxTableName is used.
+func WithOutboxTableName(tableName string) Option {
+	return func(o *coordinatorOptions) {
+		o.outboxTableName = tableName
+	}
+}
+
+// WithRelayBatchSize sets the maximum number of outbox records that are relayed at once (ModeOutbox only).
+// By default, DefaultRelayBatchSize is used.
+func WithRelayBatchSize(batchSize int) Option {
+	return func(o *coordinatorOptions) {
+		o.relayBatchSize = batchSize
+	}
+}
+
+// WithOutboxCodec sets the codec that serializes statements into payloads of outbox records (ModeOutbox only).
+// The codec is also used for deserializing records written by it. By default, JSONCodec is used.
+func WithOutboxCodec(codec Codec) Option {
+	return func(o *coordinatorOptions) {
+		o.outboxCodec = codec
+		o.outboxDecoders[codec.Name()] = codec
+	}
+}
+
+// WithOutboxDecoders adds codecs that are used only for deserializing outbox records written by them (ModeOutbox only).
+// It allows switching the payload format (see WithOutboxCodec) while records in the previous format are still pending,
+// or relaying records written by instances running another version of the service. JSONCodec is always available.
+func WithOutboxDecoders(codecs ...Codec) Option {
+	return func(o *coordinatorOptions) {
+		for _, codec := range codecs {
+			o.outboxDecoders[codec.Name()] = codec
+		}
+	}
+}
+
+// WithLogger sets the logger that is used for reporting failures on the secondary side.
+func WithLogger(logger log.FieldLogger) Option {
+	return func(o *coordinatorOptions) {
+		o.logger = logger
+	}
+}
+
+// WithPrometheusMetrics sets the collector of the divergence metrics.
+func WithPrometheusMetrics(metrics *PrometheusMetrics) Option {
+	return func(o *coordinatorOptions) {
+		o.metrics = metrics
+	}
+}
+
+// NewCoordinator creates a new dual-write coordinator.
+// Dialect is the dialect of the primary database, it's used for working with the outbox table.
+func NewCoordinator(primary, secondary *sql.DB, dialect dbkit.Dialect, options ...Option) (*Coordinator, error) {
+	opts := coordinatorOptions{
+		outboxTableName: DefaultOutboxTableName,
+		relayBatchSize:  DefaultRelayBatchSize,
+		outboxCodec:     JSONCodec{},
+		outboxDecoders:  map[string]Codec{JSONCodecName: JSONCodec{}},
+
+		shadowReadTimeout:     DefaultShadowReadTimeout,
+		shadowReadMaxInFlight: DefaultShadowReadMaxInFlight,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.logger == nil {
+		opts.logger = log.NewDisabledLogger()
+	}
+	q, err := newDBQueries(dialect, opts.outboxTableName)
+	if err != nil {
+		return nil, err
+	}
+	return &Coordinator{
+		primary:       primary,
+		secondary:     secondary,
+		queries:       q,
+		opts:          opts,
+		shadowReadSem: make(chan struct{}, opts.shadowReadMaxInFlight),
+	}, nil
+}
+
+// Migrations returns set of migrations that must be applied to the primary database before using ModeOutbox.
+func (c *Coordinator) Migrations() []migrate.Migration {
+	return []migrate.Migration{
+		migrate.NewCustomMigration(createOutboxTableMigrationID,
+			[]string{c.queries.createTable}, []string{c.queries.dropTable}, nil, nil),
+		migrate.NewCustomMigration(addOutboxCodecColumnsMigrationID,
+			c.queries.addCodecColumns, c.queries.dropCodecColumns, nil, nil),
+	}
+}
+
+// Exec executes statements in a single transaction in the primary database and mirrors them to the secondary one.
+// Only an error from the primary database is returned.
+// In ModeBestEffort, statements are executed in the secondary database right after the primary transaction is committed.
+// In ModeOutbox, statements are stored in the outbox table within the same primary transaction.
+func (c *Coordinator) Exec(ctx context.Context, stmts ...Statement) error {
+	var payload string
+	if c.opts.mode == ModeOutbox {
+		var err error
+		if payload, err = encodePayload(c.opts.outboxCodec, stmts); err != nil {
+			return fmt.Errorf("marshal statements: %w", err)
+		}
+	}
+
+	if err := dbkit.DoInTx(ctx, c.primary, func(tx *sql.Tx) error {
+		if err := execStatements(ctx, tx, stmts); err != nil {
+			return err
+		}
+		if c.opts.mode == ModeOutbox {
+			if _, err := tx.ExecContext(ctx, c.queries.insertRecord,
+				payload, c.opts.outboxCodec.Name(), c.opts.outboxCodec.SchemaVersion()); err != nil {
+				return fmt.Errorf("insert outbox record: %w", err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if c.opts.mode == ModeBestEffort {
+		err := c.execInSecondary(ctx, stmts)
+		c.opts.metrics.observeSecondaryWrite(c.opts.mode, err)
+		if err != nil {
+			c.opts.logger.Error("failed to mirror write to secondary database", log.Error(err))
+		}
+	}
+	return nil
+}
+
+// Relay applies pending outbox records to the secondary database in the order they were created
+// and removes them from the outbox. It stops on the first failure, so the order of writes is preserved.
+// Pending records are locked (SELECT ... FOR UPDATE) until the relay transaction ends, so concurrent relayers
+// (e.g., Run on every service instance) are serialized: the next one waits for the head records
+// instead of skipping them and applying later writes first.
+// Returns the number of relayed records.
+func (c *Coordinator) Relay(ctx context.Context) (int, error) {
+	relayed := 0
+	err := dbkit.DoInTx(ctx, c.primary, func(tx *sql.Tx) error {
+		records, err := c.selectRecords(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for _, rec := range records {
+			err = c.execInSecondary(ctx, rec.stmts)
+			c.opts.metrics.observeSecondaryWrite(ModeOutbox, err)
+			if err != nil {
+				return fmt.Errorf("relay outbox record %d: %w", rec.id, err)
+			}
+			// Record is deleted in the same primary transaction. If it's not committed, the record will be relayed again,
+			// so statements should be idempotent on the secondary side (e.g., upserts).
+			if _, err = tx.ExecContext(ctx, c.queries.deleteRecord, rec.id); err != nil {
+				return fmt.Errorf("delete outbox record %d: %w", rec.id, err)
+			}
+			relayed++
+		}
+		return nil
+	})
+	if err != nil {
+		// Records that were relayed but not deleted will be relayed again.
+		return 0, err
+	}
+	return relayed, nil
+}
+
+// Run relays outbox records periodically until ctx is done.
+func (c *Coordinator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				relayed, err := c.Relay(ctx)
+				if err != nil {
+					c.opts.logger.Error("failed to relay outbox records to secondary database", log.Error(err))
+					break
+				}
+				if relayed < c.opts.relayBatchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+type outboxRecord struct {
+	id    int64
+	stmts []Statement
+}
+
+func (c *Coordinator) selectRecords(ctx context.Context, tx *sql.Tx) (records []outboxRecord, err error) {
+	rows, err := tx.QueryContext(ctx, c.queries.selectRecords, c.opts.relayBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("select outbox records: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	for rows.Next() {
+		var rec outboxRecord
+		var payload []byte
+		var codecName string
+		var schemaVersion int
+		if err = rows.Scan(&rec.id, &payload, &codecName, &schemaVersion); err != nil {
+			return nil, fmt.Errorf("scan outbox record: %w", err)
+		}
+		codec, ok := c.opts.outboxDecoders[codecName]
+		if !ok {
+			return nil, fmt.Errorf("unmarshal outbox record %d: unknown codec %q", rec.id, codecName)
+		}
+		if rec.stmts, err = decodePayload(codec, payload, schemaVersion); err != nil {
+			return nil, fmt.Errorf("unmarshal outbox record %d (codec %q, schema version %d): %w",
+				rec.id, codecName, schemaVersion, err)
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+func (c *Coordinator) execInSecondary(ctx context.Context, stmts []Statement) error {
+	return dbkit.DoInTx(ctx, c.secondary, func(tx *sql.Tx) error {
+		return execStatements(ctx, tx, stmts)
+	})
+}
+
+func execStatements(ctx context.Context, tx *sql.Tx, stmts []Statement) error {
+	for i := range stmts {
+		if _, err := tx.ExecContext(ctx, stmts[i].Query, stmts[i].Args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type dbQueries struct {
+	createTable      string
+	dropTable        string
+	addCodecColumns  []string
+	dropCodecColumns []string
+	insertRecord     string
+	selectRecords    string
+	deleteRecord     string
+}
+
+func newDBQueries(dialect dbkit.Dialect, tableName string) (dbQueries, error) {
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		return dbQueries{
+			createTable:      fmt.Sprintf(postgresCreateTableQuery, tableName),
+			dropTable:        fmt.Sprintf(postgresDropTableQuery, tableName),
+			addCodecColumns:  []string{fmt.Sprintf(postgresAddCodecColumnsQuery, tableName)},
+			dropCodecColumns: []string{fmt.Sprintf(postgresDropCodecColumnsQuery, tableName)},
+			insertRecord:     fmt.Sprintf(postgresInsertRecordQuery, tableName),
+			selectRecords:    fmt.Sprintf(postgresSelectRecordsQuery, tableName),
+			deleteRecord:     fmt.Sprintf(postgresDeleteRecordQuery, tableName),
+		}, nil
+	case dbkit.DialectMySQL:
+		return dbQueries{
+			createTable:      fmt.Sprintf(mySQLCreateTableQuery, tableName),
+			dropTable:        fmt.Sprintf(mySQLDropTableQuery, tableName),
+			addCodecColumns:  []string{fmt.Sprintf(mySQLAddCodecColumnsQuery, tableName)},
+			dropCodecColumns: []string{fmt.Sprintf(mySQLDropCodecColumnsQuery, tableName)},
+			insertRecord:     fmt.Sprintf(mySQLInsertRecordQuery, tableName),
+			selectRecords:    fmt.Sprintf(mySQLSelectRecordsQuery, tableName),
+			deleteRecord:     fmt.Sprintf(mySQLDeleteRecordQuery, tableName),
+		}, nil
+	case dbkit.DialectSQLite:
+		return dbQueries{
+			createTable: fmt.Sprintf(sqliteCreateTableQuery, tableName),
+			dropTable:   fmt.Sprintf(sqliteDropTableQuery, tableName),
+			addCodecColumns: []string{
+				fmt.Sprintf(sqliteAddCodecColumnQuery, tableName),
+				fmt.Sprintf(sqliteAddSchemaVersionColumnQuery, tableName),
+			},
+			dropCodecColumns: []string{
+				fmt.Sprintf(sqliteDropSchemaVersionColumnQuery, tableName),
+				fmt.Sprintf(sqliteDropCodecColumnQuery, tableName),
+			},
+			insertRecord:  fmt.Sprintf(sqliteInsertRecordQuery, tableName),
+			selectRecords: fmt.Sprintf(sqliteSelectRecordsQuery, tableName),
+			deleteRecord:  fmt.Sprintf(sqliteDeleteRecordQuery, tableName),
+		}, nil
+	default:
+		return dbQueries{}, fmt.Errorf("unsupported sql dialect %q", dialect)
+	}
+}
+
+const (
+	createOutboxTableMigrationID     = "dualwrite_00001_create_outbox_table"
+	addOutboxCodecColumnsMigrationID = "dualwrite_00002_add_outbox_codec_columns"
+)
+
+//nolint:lll
+const (
+	postgresCreateTableQuery      = `CREATE TABLE IF NOT EXISTS "%s" (id BIGSERIAL PRIMARY KEY, payload TEXT NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT NOW());`
+	postgresDropTableQuery        = `DROP TABLE IF EXISTS "%s";`
+	postgresAddCodecColumnsQuery  = `ALTER TABLE "%s" ADD COLUMN IF NOT EXISTS codec VARCHAR(64) NOT NULL DEFAULT 'json', ADD COLUMN IF NOT EXISTS schema_version INT NOT NULL DEFAULT 1;`
+	postgresDropCodecColumnsQuery = `ALTER TABLE "%s" DROP COLUMN IF EXISTS codec, DROP COLUMN IF EXISTS schema_version;`
+	postgresInsertRecordQuery     = `INSERT INTO "%s" (payload, codec, schema_version) VALUES ($1, $2, $3);`
+	postgresSelectRecordsQuery    = `SELECT id, payload, codec, schema_version FROM "%s" ORDER BY id LIMIT $1 FOR UPDATE;`
+	postgresDeleteRecordQuery     = `DELETE FROM "%s" WHERE id = $1;`
+)
+
+//nolint:lll
+const (
+	mySQLCreateTableQuery      = "CREATE TABLE IF NOT EXISTS `%s` (id BIGINT AUTO_INCREMENT PRIMARY KEY, payload LONGTEXT NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP);"
+	mySQLDropTableQuery        = "DROP TABLE IF EXISTS `%s`;"
+	mySQLAddCodecColumnsQuery  = "ALTER TABLE `%s` ADD COLUMN codec VARCHAR(64) NOT NULL DEFAULT 'json', ADD COLUMN schema_version INT NOT NULL DEFAULT 1;"
+	mySQLDropCodecColumnsQuery = "ALTER TABLE `%s` DROP COLUMN codec, DROP COLUMN schema_version;"
+	mySQLInsertRecordQuery     = "INSERT INTO `%s` (payload, codec, schema_version) VALUES (?, ?, ?);"
+	mySQLSelectRecordsQuery    = "SELECT id, payload, codec, schema_version FROM `%s` ORDER BY id LIMIT ? FOR UPDATE;"
+	mySQLDeleteRecordQuery     = "DELETE FROM `%s` WHERE id = ?;"
+)
+
+//nolint:lll
+const (
+	sqliteCreateTableQuery             = "CREATE TABLE IF NOT EXISTS `%s` (id INTEGER PRIMARY KEY AUTOINCREMENT, payload TEXT NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP);"
+	sqliteDropTableQuery               = "DROP TABLE IF EXISTS `%s`;"
+	sqliteAddCodecColumnQuery          = "ALTER TABLE `%s` ADD COLUMN codec VARCHAR(64) NOT NULL DEFAULT 'json';"
+	sqliteAddSchemaVersionColumnQuery  = "ALTER TABLE `%s` ADD COLUMN schema_version INT NOT NULL DEFAULT 1;"
+	sqliteDropCodecColumnQuery         = "ALTER TABLE `%s` DROP COLUMN codec;"
+	sqliteDropSchemaVersionColumnQuery = "ALTER TABLE `%s` DROP COLUMN schema_version;"
+	sqliteInsertRecordQuery            = "INSERT INTO `%s` (payload, codec, schema_version) VALUES (?, ?, ?);"
+	sqliteSelectRecordsQuery           = "SELECT id, payload, codec, schema_version FROM `%s` ORDER BY id LIMIT ?;"
+	sqliteDeleteRecordQuery            = "DELETE FROM `%s` WHERE id = ?;"
+)
@@ -0,0 +1,207 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dualwrite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/acronis/go-appkit/log/logtest"
+	"github.com/acronis/go-appkit/testutil"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+const sqlCreateUsersTable = `CREATE TABLE users (id INTEGER NOT NULL PRIMARY KEY, name TEXT NOT NULL);`
+
+func openDB(t *testing.T, name string, createUsers bool) *sql.DB {
+	t.Helper()
+	db, err := dbkit.Open(&dbkit.Config{
+		Dialect:      dbkit.DialectSQLite,
+		SQLite:       dbkit.SQLiteConfig{Path: filepath.Join(t.TempDir(), name+".db")},
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	}, true)
+	require.NoError(t, err)
+	if createUsers {
+		_, err = db.Exec(sqlCreateUsersTable)
+		require.NoError(t, err)
+	}
+	return db
+}
+
+func requireUserNames(t *testing.T, db *sql.DB, wantNames ...string) {
+	t.Helper()
+	rows, err := db.Query("SELECT name FROM users ORDER BY id")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, rows.Close()) }()
+	var names []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, wantNames, names)
+}
+
+func applyOutboxMigrations(t *testing.T, db *sql.DB, coord *Coordinator) {
+	t.Helper()
+	migMngr, err := migrate.NewMigrationsManager(db, dbkit.DialectSQLite, logtest.NewLogger())
+	require.NoError(t, err)
+	require.NoError(t, migMngr.Run(coord.Migrations(), migrate.MigrationsDirectionUp))
+}
+
+func insertUser(id int64, name string) Statement {
+	return Statement{Query: "INSERT INTO users (id, name) VALUES (?, ?)", Args: []interface{}{id, name}}
+}
+
+func TestCoordinator_BestEffort(t *testing.T) {
+	primary := openDB(t, "primary", true)
+	defer func() { require.NoError(t, primary.Close()) }()
+	secondary := openDB(t, "secondary", true)
+	defer func() { require.NoError(t, secondary.Close()) }()
+
+	metrics := NewPrometheusMetrics()
+	coord, err := NewCoordinator(primary, secondary, dbkit.DialectSQLite, WithPrometheusMetrics(metrics))
+	require.NoError(t, err)
+
+	require.NoError(t, coord.Exec(context.Background(), insertUser(1, "Albert"), insertUser(2, "Bob")))
+	requireUserNames(t, primary, "Albert", "Bob")
+	requireUserNames(t, secondary, "Albert", "Bob")
+
+	// Secondary write fails (duplicate key), but the primary one succeeds, and divergence is counted.
+	_, err = secondary.Exec("INSERT INTO users (id, name) VALUES (3, 'Sam')")
+	require.NoError(t, err)
+	require.NoError(t, coord.Exec(context.Background(), insertUser(3, "John")))
+	requireUserNames(t, primary, "Albert", "Bob", "John")
+	requireUserNames(t, secondary, "Albert", "Bob", "Sam")
+
+	testutil.RequireSamplesCountInCounter(t, metrics.SecondaryWrites.WithLabelValues("best_effort", StatusOK), 1)
+	testutil.RequireSamplesCountInCounter(t, metrics.Divergences.WithLabelValues("best_effort"), 1)
+}
+
+func TestCoordinator_Outbox(t *testing.T) {
+	primary := openDB(t, "primary", true)
+	defer func() { require.NoError(t, primary.Close()) }()
+	secondary := openDB(t, "secondary", false)
+	defer func() { require.NoError(t, secondary.Close()) }()
+
+	metrics := NewPrometheusMetrics()
+	coord, err := NewCoordinator(primary, secondary, dbkit.DialectSQLite,
+		WithMode(ModeOutbox), WithRelayBatchSize(2), WithPrometheusMetrics(metrics))
+	require.NoError(t, err)
+	applyOutboxMigrations(t, primary, coord)
+
+	require.NoError(t, coord.Exec(context.Background(), insertUser(1, "Albert")))
+	require.NoError(t, coord.Exec(context.Background(), insertUser(2, "Bob")))
+	require.NoError(t, coord.Exec(context.Background(), insertUser(3, "John")))
+	requireUserNames(t, primary, "Albert", "Bob", "John")
+
+	// Secondary database is not ready yet (no table), outbox records are kept.
+	relayed, err := coord.Relay(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 0, relayed)
+	testutil.RequireSamplesCountInCounter(t, metrics.Divergences.WithLabelValues("outbox"), 1)
+
+	_, err = secondary.Exec(sqlCreateUsersTable)
+	require.NoError(t, err)
+
+	relayed, err = coord.Relay(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, relayed)
+	requireUserNames(t, secondary, "Albert", "Bob")
+
+	relayed, err = coord.Relay(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, relayed)
+	requireUserNames(t, secondary, "Albert", "Bob", "John")
+
+	relayed, err = coord.Relay(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0, relayed)
+}
+
+// reversedJSONCodec is a binary codec for tests, its schema version 2 stores JSON payloads in reversed byte order.
+type reversedJSONCodec struct{}
+
+func (reversedJSONCodec) Name() string       { return "reversed_json" }
+func (reversedJSONCodec) SchemaVersion() int { return 2 }
+func (reversedJSONCodec) Binary() bool       { return true }
+
+func (reversedJSONCodec) Marshal(stmts []Statement) ([]byte, error) {
+	payload, err := JSONCodec{}.Marshal(stmts)
+	if err != nil {
+		return nil, err
+	}
+	reverseBytes(payload)
+	return payload, nil
+}
+
+func (reversedJSONCodec) Unmarshal(payload []byte, schemaVersion int) ([]Statement, error) {
+	if schemaVersion == 2 {
+		payload = append([]byte(nil), payload...)
+		reverseBytes(payload)
+	}
+	return JSONCodec{}.Unmarshal(payload, JSONCodecSchemaVersion)
+}
+
+func reverseBytes(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+func TestCoordinator_OutboxCodecs(t *testing.T) {
+	primary := openDB(t, "primary", true)
+	defer func() { require.NoError(t, primary.Close()) }()
+	secondary := openDB(t, "secondary", true)
+	defer func() { require.NoError(t, secondary.Close()) }()
+
+	jsonCoord, err := NewCoordinator(primary, secondary, dbkit.DialectSQLite, WithMode(ModeOutbox))
+	require.NoError(t, err)
+	applyOutboxMigrations(t, primary, jsonCoord)
+	require.NoError(t, jsonCoord.Exec(context.Background(), insertUser(1, "Albert")))
+
+	customCoord, err := NewCoordinator(primary, secondary, dbkit.DialectSQLite,
+		WithMode(ModeOutbox), WithOutboxCodec(reversedJSONCodec{}))
+	require.NoError(t, err)
+	require.NoError(t, customCoord.Exec(context.Background(), insertUser(2, "Bob")))
+
+	var codecName string
+	var schemaVersion int
+	require.NoError(t, primary.QueryRow("SELECT codec, schema_version FROM "+DefaultOutboxTableName+" WHERE id = 2").
+		Scan(&codecName, &schemaVersion))
+	require.Equal(t, "reversed_json", codecName)
+	require.Equal(t, 2, schemaVersion)
+
+	// Coordinator without the custom codec can't relay its records.
+	relayed, err := jsonCoord.Relay(context.Background())
+	require.ErrorContains(t, err, `unknown codec "reversed_json"`)
+	require.Equal(t, 0, relayed)
+
+	// Records of both formats are relayed by the coordinator that knows both codecs.
+	relayed, err = customCoord.Relay(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, relayed)
+	requireUserNames(t, secondary, "Albert", "Bob")
+
+	// Records written by the custom codec are relayed when it's registered as a decoder only.
+	require.NoError(t, customCoord.Exec(context.Background(), insertUser(3, "John")))
+	decodingCoord, err := NewCoordinator(primary, secondary, dbkit.DialectSQLite,
+		WithMode(ModeOutbox), WithOutboxDecoders(reversedJSONCodec{}))
+	require.NoError(t, err)
+	relayed, err = decodingCoord.Relay(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, relayed)
+	requireUserNames(t, secondary, "Albert", "Bob", "John")
+}
@@ -0,0 +1,20 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package dualwrite provides a coordinator that mirrors writes to a secondary database.
+// It's intended for database migration projects that need a shadow copy of the data before the cutover.
+// Two modes are supported:
+//   - ModeBestEffort: statements are applied to the secondary database right after the primary transaction is committed.
+//     Failures on the secondary side are logged and counted as divergences, but don't fail the write.
+//   - ModeOutbox: statements are stored in the outbox table within the primary transaction
+//     and are relayed to the secondary database asynchronously (see Coordinator.Relay and Coordinator.Run),
+//     so no write is lost even if the secondary database is temporarily unavailable.
+//     Statements are serialized by the pluggable Codec (JSONCodec by default), and the codec name
+//     and the payload schema version are stored with each record, so the payload format may evolve safely.
+//
+// Additionally, Coordinator.Query may asynchronously replay a sample of read queries against the secondary database
+// and compare results and latencies, reporting mismatches, to validate a new database engine before switching.
+package dualwrite
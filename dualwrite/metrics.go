@@ -0,0 +1,151 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dualwrite
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prometheus labels.
+const (
+	PrometheusMetricsLabelMode   = "mode"
+	PrometheusMetricsLabelStatus = "status"
+	PrometheusMetricsLabelResult = "result"
+	PrometheusMetricsLabelTarget = "target"
+)
+
+// Values of the status label.
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// DefaultShadowReadDurationBuckets is default buckets into which observations of shadow read durations are counted.
+var DefaultShadowReadDurationBuckets = []float64{0.001, 0.01, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// PrometheusMetricsOpts represents options for PrometheusMetrics.
+type PrometheusMetricsOpts struct {
+	// Namespace is a namespace for metrics. It will be prepended to all metric names.
+	Namespace string
+
+	// ShadowReadDurationBuckets is a list of buckets into which observations of shadow read durations are counted.
+	ShadowReadDurationBuckets []float64
+
+	// ConstLabels is a set of labels that will be applied to all metrics.
+	ConstLabels prometheus.Labels
+}
+
+// PrometheusMetrics represents collector of metrics for the dual-write coordinator.
+type PrometheusMetrics struct {
+	// SecondaryWrites counts attempts to apply writes to the secondary database.
+	SecondaryWrites *prometheus.CounterVec
+	// Divergences counts writes that were applied to the primary database but were not applied to the secondary one.
+	// For ModeOutbox, it counts failed relay attempts (the write is retried on the next relay).
+	Divergences *prometheus.CounterVec
+	// ShadowReads counts shadow reads by the comparison result.
+	ShadowReads *prometheus.CounterVec
+	// ShadowReadDurations observes durations of the shadowed read queries in primary and secondary databases.
+	ShadowReadDurations *prometheus.HistogramVec
+}
+
+// NewPrometheusMetrics creates a new metrics collector.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return NewPrometheusMetricsWithOpts(PrometheusMetricsOpts{})
+}
+
+// NewPrometheusMetricsWithOpts is a more configurable version of creating PrometheusMetrics.
+func NewPrometheusMetricsWithOpts(opts PrometheusMetricsOpts) *PrometheusMetrics {
+	secondaryWrites := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "db_dual_write_secondary_writes_total",
+			Help:        "Number of attempts to apply writes to the secondary database.",
+			ConstLabels: opts.ConstLabels,
+		},
+		[]string{PrometheusMetricsLabelMode, PrometheusMetricsLabelStatus},
+	)
+	divergences := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "db_dual_write_divergences_total",
+			Help:        "Number of writes that were applied to the primary database but not to the secondary one.",
+			ConstLabels: opts.ConstLabels,
+		},
+		[]string{PrometheusMetricsLabelMode},
+	)
+	shadowReads := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "db_dual_write_shadow_reads_total",
+			Help:        "Number of read queries replayed against the secondary database by the comparison result.",
+			ConstLabels: opts.ConstLabels,
+		},
+		[]string{PrometheusMetricsLabelResult},
+	)
+	shadowReadDurationBuckets := opts.ShadowReadDurationBuckets
+	if shadowReadDurationBuckets == nil {
+		shadowReadDurationBuckets = DefaultShadowReadDurationBuckets
+	}
+	shadowReadDurations := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Name:        "db_dual_write_shadow_read_duration_seconds",
+			Help:        "A histogram of the shadowed read query durations in primary and secondary databases.",
+			Buckets:     shadowReadDurationBuckets,
+			ConstLabels: opts.ConstLabels,
+		},
+		[]string{PrometheusMetricsLabelTarget},
+	)
+	return &PrometheusMetrics{
+		SecondaryWrites:     secondaryWrites,
+		Divergences:         divergences,
+		ShadowReads:         shadowReads,
+		ShadowReadDurations: shadowReadDurations,
+	}
+}
+
+// MustRegister does registration of metrics collector in Prometheus and panics if any error occurs.
+func (pm *PrometheusMetrics) MustRegister() {
+	prometheus.MustRegister(pm.AllMetrics()...)
+}
+
+// Unregister cancels registration of metrics collector in Prometheus.
+func (pm *PrometheusMetrics) Unregister() {
+	for _, m := range pm.AllMetrics() {
+		prometheus.Unregister(m)
+	}
+}
+
+// AllMetrics returns a list of metrics of this collector. This can be used to register these metrics in push gateway.
+func (pm *PrometheusMetrics) AllMetrics() []prometheus.Collector {
+	return []prometheus.Collector{pm.SecondaryWrites, pm.Divergences, pm.ShadowReads, pm.ShadowReadDurations}
+}
+
+func (pm *PrometheusMetrics) observeSecondaryWrite(mode Mode, err error) {
+	if pm == nil {
+		return
+	}
+	if err != nil {
+		pm.SecondaryWrites.WithLabelValues(mode.String(), StatusFailed).Inc()
+		pm.Divergences.WithLabelValues(mode.String()).Inc()
+		return
+	}
+	pm.SecondaryWrites.WithLabelValues(mode.String(), StatusOK).Inc()
+}
+
+func (pm *PrometheusMetrics) observeShadowRead(result string, primaryDuration, secondaryDuration time.Duration) {
+	if pm == nil {
+		return
+	}
+	pm.ShadowReads.WithLabelValues(result).Inc()
+	pm.ShadowReadDurations.WithLabelValues(ShadowReadTargetPrimary).Observe(primaryDuration.Seconds())
+	if result != ShadowReadResultError {
+		pm.ShadowReadDurations.WithLabelValues(ShadowReadTargetSecondary).Observe(secondaryDuration.Seconds())
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dualwrite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/acronis/go-appkit/log"
+)
+
+// Default values for the read shadowing options.
+const (
+	DefaultShadowReadTimeout     = 10 * time.Second
+	DefaultShadowReadMaxInFlight = 10
+)
+
+// Results of the shadow read comparison.
+const (
+	ShadowReadResultMatch    = "match"
+	ShadowReadResultMismatch = "mismatch"
+	ShadowReadResultError    = "error"
+)
+
+// Targets of the shadow read.
+const (
+	ShadowReadTargetPrimary   = "primary"
+	ShadowReadTargetSecondary = "secondary"
+)
+
+// WithShadowReadSampleRate enables read shadowing (see Coordinator.Query).
+// Rate is a fraction (from 0 to 1) of read queries that are replayed against the secondary database.
+// By default, it's 0, so read shadowing is disabled.
+func WithShadowReadSampleRate(rate float64) Option {
+	return func(o *coordinatorOptions) {
+		o.shadowReadSampleRate = rate
+	}
+}
+
+// WithShadowReadTimeout sets timeout for replaying the read query against the secondary database.
+// By default, DefaultShadowReadTimeout is used.
+func WithShadowReadTimeout(timeout time.Duration) Option {
+	return func(o *coordinatorOptions) {
+		o.shadowReadTimeout = timeout
+	}
+}
+
+// WithShadowReadMaxInFlight sets the maximum number of shadow reads that are executed concurrently.
+// If the limit is reached, new shadow reads are skipped, so the secondary database is never overloaded.
+// By default, DefaultShadowReadMaxInFlight is used.
+func WithShadowReadMaxInFlight(maxInFlight int) Option {
+	return func(o *coordinatorOptions) {
+		o.shadowReadMaxInFlight = maxInFlight
+	}
+}
+
+// ResultSet is a fully read result of the query.
+type ResultSet struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// Query executes the read query in the primary database and returns its fully read result.
+// A sample of queries (see WithShadowReadSampleRate) is asynchronously replayed against the secondary database.
+// Results and latencies are compared and reported via metrics, and mismatches are logged.
+func (c *Coordinator) Query(ctx context.Context, query string, args ...interface{}) (*ResultSet, error) {
+	startTime := time.Now()
+	primaryRes, err := queryResultSet(ctx, c.primary, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	primaryDuration := time.Since(startTime)
+
+	if c.opts.shadowReadSampleRate <= 0 || rand.Float64() >= c.opts.shadowReadSampleRate { //nolint:gosec // Sampling doesn't need crypto rand.
+		return primaryRes, nil
+	}
+	select {
+	case c.shadowReadSem <- struct{}{}:
+	default:
+		return primaryRes, nil // Too many shadow reads in flight, skip this one.
+	}
+	c.shadowReadsWG.Add(1)
+	go func() {
+		defer func() {
+			<-c.shadowReadSem
+			c.shadowReadsWG.Done()
+		}()
+		c.shadowRead(query, args, primaryRes, primaryDuration)
+	}()
+	return primaryRes, nil
+}
+
+// WaitShadowReads waits until all in-flight shadow reads are finished.
+// It may be used for graceful shutdown.
+func (c *Coordinator) WaitShadowReads() {
+	c.shadowReadsWG.Wait()
+}
+
+func (c *Coordinator) shadowRead(query string, args []interface{}, primaryRes *ResultSet, primaryDuration time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.shadowReadTimeout)
+	defer cancel()
+
+	startTime := time.Now()
+	secondaryRes, err := queryResultSet(ctx, c.secondary, query, args...)
+	secondaryDuration := time.Since(startTime)
+
+	logger := c.opts.logger.With(log.String("query", query))
+	if err != nil {
+		c.opts.metrics.observeShadowRead(ShadowReadResultError, primaryDuration, 0)
+		logger.Error("failed to execute shadow read in secondary database", log.Error(err))
+		return
+	}
+	if diff := compareResultSets(primaryRes, secondaryRes); diff != "" {
+		c.opts.metrics.observeShadowRead(ShadowReadResultMismatch, primaryDuration, secondaryDuration)
+		logger.Warn("shadow read result mismatch", log.String("diff", diff),
+			log.Int64("primary_duration_ms", primaryDuration.Milliseconds()),
+			log.Int64("secondary_duration_ms", secondaryDuration.Milliseconds()))
+		return
+	}
+	c.opts.metrics.observeShadowRead(ShadowReadResultMatch, primaryDuration, secondaryDuration)
+}
+
+func queryResultSet(ctx context.Context, db *sql.DB, query string, args ...interface{}) (res *ResultSet, err error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	res = &ResultSet{}
+	if res.Columns, err = rows.Columns(); err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		row := make([]interface{}, len(res.Columns))
+		dest := make([]interface{}, len(res.Columns))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i := range row {
+			if b, ok := row[i].([]byte); ok {
+				row[i] = string(b) // Driver may reuse the buffer.
+			}
+		}
+		res.Rows = append(res.Rows, row)
+	}
+	return res, rows.Err()
+}
+
+// compareResultSets returns a human-readable description of the first difference or an empty string if results are equal.
+// Values are compared by their string representation, since different engines may return different Go types for the same data.
+func compareResultSets(primary, secondary *ResultSet) string {
+	if len(primary.Columns) != len(secondary.Columns) {
+		return fmt.Sprintf("columns count: %d != %d", len(primary.Columns), len(secondary.Columns))
+	}
+	if len(primary.Rows) != len(secondary.Rows) {
+		return fmt.Sprintf("rows count: %d != %d", len(primary.Rows), len(secondary.Rows))
+	}
+	for i := range primary.Rows {
+		for j := range primary.Rows[i] {
+			pv, sv := normalizeValue(primary.Rows[i][j]), normalizeValue(secondary.Rows[i][j])
+			if pv != sv {
+				return fmt.Sprintf("row %d, column %q: %q != %q", i, primary.Columns[j], pv, sv)
+			}
+		}
+	}
+	return ""
+}
+
+func normalizeValue(v interface{}) string {
+	switch tv := v.(type) {
+	case nil:
+		return "NULL"
+	case time.Time:
+		return tv.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(tv)
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dualwrite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/acronis/go-appkit/log/logtest"
+	"github.com/acronis/go-appkit/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+func TestCoordinator_Query(t *testing.T) {
+	primary := openDB(t, "primary", true)
+	defer func() { require.NoError(t, primary.Close()) }()
+	secondary := openDB(t, "secondary", true)
+	defer func() { require.NoError(t, secondary.Close()) }()
+
+	_, err := primary.Exec("INSERT INTO users (id, name) VALUES (1, 'Albert'), (2, 'Bob')")
+	require.NoError(t, err)
+	_, err = secondary.Exec("INSERT INTO users (id, name) VALUES (1, 'Albert'), (2, 'Sam')")
+	require.NoError(t, err)
+
+	metrics := NewPrometheusMetrics()
+	logRecorder := logtest.NewRecorder()
+	coord, err := NewCoordinator(primary, secondary, dbkit.DialectSQLite,
+		WithShadowReadSampleRate(1), WithPrometheusMetrics(metrics), WithLogger(logRecorder))
+	require.NoError(t, err)
+
+	res, err := coord.Query(context.Background(), "SELECT id, name FROM users WHERE id = ?", 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"id", "name"}, res.Columns)
+	require.Equal(t, [][]interface{}{{int64(1), "Albert"}}, res.Rows)
+
+	res, err = coord.Query(context.Background(), "SELECT id, name FROM users ORDER BY id")
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 2)
+
+	_, err = coord.Query(context.Background(), "SELECT unknown_column FROM users")
+	require.Error(t, err) // Primary errors are returned as is, and the query is not shadowed.
+
+	coord.WaitShadowReads()
+	testutil.RequireSamplesCountInCounter(t, metrics.ShadowReads.WithLabelValues(ShadowReadResultMatch), 1)
+	testutil.RequireSamplesCountInCounter(t, metrics.ShadowReads.WithLabelValues(ShadowReadResultMismatch), 1)
+	testutil.RequireSamplesCountInCounter(t, metrics.ShadowReads.WithLabelValues(ShadowReadResultError), 0)
+
+	logEntry, found := logRecorder.FindEntry("shadow read result mismatch")
+	require.True(t, found)
+	diffField, found := logEntry.FindField("diff")
+	require.True(t, found)
+	require.Equal(t, `row 1, column "name": "Bob" != "Sam"`, string(diffField.Bytes))
+}
@@ -0,0 +1,220 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/acronis/go-appkit/config"
+)
+
+// DefaultEnvPrefix is the prefix of environment variables used by ConfigFromEnv when the empty prefix is passed.
+const DefaultEnvPrefix = "DB"
+
+// Suffixes of environment variables read by ConfigFromEnv.
+const (
+	EnvDialect          = "DIALECT"
+	EnvDSN              = "DSN"
+	EnvHost             = "HOST"
+	EnvPort             = "PORT"
+	EnvUser             = "USER"
+	EnvPassword         = "PASSWORD" //nolint: gosec
+	EnvPasswordFile     = "PASSWORD_FILE"
+	EnvName             = "NAME"
+	EnvPath             = "PATH"
+	EnvTxLevel          = "TX_LEVEL"
+	EnvProfile          = "PROFILE"
+	EnvMaxOpenConns     = "MAX_OPEN_CONNS"
+	EnvMaxIdleConns     = "MAX_IDLE_CONNS"
+	EnvConnMaxLifetime  = "CONN_MAX_LIFETIME"
+	EnvStatementTimeout = "STATEMENT_TIMEOUT"
+	EnvSSLMode          = "SSL_MODE"
+	EnvSSLCert          = "SSL_CERT"
+	EnvSSLKey           = "SSL_KEY"
+	EnvSSLRootCert      = "SSL_ROOT_CERT"
+	EnvSearchPath       = "SEARCH_PATH"
+)
+
+// ConfigFromEnv creates Config from environment variables for container deployments
+// where configuration files are unavailable. Names of variables consist of the prefix
+// (DefaultEnvPrefix if empty), "_" and the suffix (e.g., DB_HOST):
+//   - DIALECT (required): one of the supported dialects (see Dialect);
+//   - DSN: DSN of the dialect (see ParseMySQLDSN, ParsePostgresDSN, ParseMSSQLDSN, the path for SQLite),
+//     other variables override values parsed from it;
+//   - HOST, PORT, USER, PASSWORD (or PASSWORD_FILE with the path of the file containing the password, e.g. Docker secret),
+//     NAME (database name), TX_LEVEL: connection parameters of MySQL, Postgres and MSSQL.
+//     Postgres HOST may contain multiple comma-separated hosts (see PostgresConfig.Hosts);
+//   - PATH: path of the SQLite database;
+//   - SSL_MODE, SSL_CERT, SSL_KEY, SSL_ROOT_CERT, SEARCH_PATH: Postgres specific parameters;
+//   - PROFILE, MAX_OPEN_CONNS, MAX_IDLE_CONNS, CONN_MAX_LIFETIME (e.g., "10m"): pool parameters;
+//   - STATEMENT_TIMEOUT (e.g., "30s"): client-side timeout of each statement (see Config.StatementTimeout).
+//
+// Values are processed in the same way as by config.Loader (including defaults and validation).
+func ConfigFromEnv(prefix string) (*Config, error) {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	env := envLookup{prefix: prefix + "_"}
+	cfg := NewConfig(nil)
+	dialect := Dialect(env.get(EnvDialect))
+	if dialect == "" {
+		return nil, fmt.Errorf("environment variable %s must be set", env.name(EnvDialect))
+	}
+
+	values := map[string]interface{}{cfgKeyDialect: string(dialect)}
+	if dsn := env.get(EnvDSN); dsn != "" {
+		if err := setConfigValuesFromDSN(values, dialect, dsn); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", env.name(EnvDSN), err)
+		}
+	}
+	env.set(values, cfgKeyProfile, EnvProfile)
+	env.set(values, cfgKeyMaxOpenConns, EnvMaxOpenConns)
+	env.set(values, cfgKeyMaxIdleConns, EnvMaxIdleConns)
+	env.set(values, cfgKeyConnMaxLifetime, EnvConnMaxLifetime)
+	env.set(values, cfgKeyStatementTimeout, EnvStatementTimeout)
+
+	password := env.get(EnvPassword)
+	if passwordFile := env.get(EnvPasswordFile); passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", env.name(EnvPasswordFile), err)
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	}
+	switch dialect {
+	case DialectMySQL:
+		env.setServerValues(values, "mysql.", password)
+	case DialectMSSQL:
+		env.setServerValues(values, "mssql.", password)
+	case DialectPostgres, DialectPgx:
+		env.setServerValues(values, "postgres.", password)
+		if host := env.get(EnvHost); host != "" {
+			delete(values, cfgKeyPostgresHosts) // Hosts parsed from DSN are overridden too.
+			if strings.Contains(host, ",") {
+				delete(values, cfgKeyPostgresHost)
+				values[cfgKeyPostgresHosts] = strings.Split(host, ",")
+			}
+		}
+		env.set(values, cfgKeyPostgresSSLMode, EnvSSLMode)
+		env.set(values, cfgKeyPostgresSSLCert, EnvSSLCert)
+		env.set(values, cfgKeyPostgresSSLKey, EnvSSLKey)
+		env.set(values, cfgKeyPostgresSSLRootCert, EnvSSLRootCert)
+		env.set(values, cfgKeyPostgresSearchPath, EnvSearchPath)
+	case DialectSQLite:
+		env.set(values, cfgKeySQLitePath, EnvPath)
+	}
+
+	dp := config.NewKeyPrefixedDataProvider(config.NewViperAdapter(), cfg.KeyPrefix())
+	for key, value := range values {
+		dp.Set(key, value)
+	}
+	cfg.SetProviderDefaults(dp)
+	if err := cfg.Set(dp); err != nil {
+		return nil, fmt.Errorf("set config from environment variables with %s prefix: %w", env.prefix, err)
+	}
+	return cfg, nil
+}
+
+type envLookup struct {
+	prefix string
+}
+
+func (e envLookup) name(suffix string) string {
+	return e.prefix + suffix
+}
+
+func (e envLookup) get(suffix string) string {
+	return os.Getenv(e.name(suffix))
+}
+
+// set sets the value of the config key if the environment variable is not empty.
+func (e envLookup) set(values map[string]interface{}, key, suffix string) {
+	if value := e.get(suffix); value != "" {
+		values[key] = value
+	}
+}
+
+func (e envLookup) setServerValues(values map[string]interface{}, keyPrefix, password string) {
+	e.set(values, keyPrefix+"host", EnvHost)
+	e.set(values, keyPrefix+"port", EnvPort)
+	e.set(values, keyPrefix+"user", EnvUser)
+	e.set(values, keyPrefix+"database", EnvName)
+	e.set(values, keyPrefix+"txLevel", EnvTxLevel)
+	if password != "" {
+		values[keyPrefix+"password"] = password
+	}
+}
+
+func setConfigValuesFromDSN(values map[string]interface{}, dialect Dialect, dsn string) error {
+	setNotEmpty := func(key string, value interface{}) {
+		switch v := value.(type) {
+		case string:
+			if v == "" {
+				return
+			}
+		case int:
+			if v == 0 {
+				return
+			}
+		}
+		values[key] = value
+	}
+	switch dialect {
+	case DialectMySQL:
+		mysqlCfg, err := ParseMySQLDSN(dsn)
+		if err != nil {
+			return err
+		}
+		setNotEmpty(cfgKeyMySQLHost, mysqlCfg.Host)
+		setNotEmpty(cfgKeyMySQLPort, mysqlCfg.Port)
+		setNotEmpty(cfgKeyMySQLUser, mysqlCfg.User)
+		setNotEmpty(cfgKeyMySQLPassword, mysqlCfg.Password)
+		setNotEmpty(cfgKeyMySQLDatabase, mysqlCfg.Database)
+		if len(mysqlCfg.AdditionalParameters) != 0 {
+			values[cfgKeyMySQLAdditionalParams] = mysqlCfg.AdditionalParameters
+		}
+	case DialectMSSQL:
+		mssqlCfg, err := ParseMSSQLDSN(dsn)
+		if err != nil {
+			return err
+		}
+		setNotEmpty(cfgKeyMSSQLHost, mssqlCfg.Host)
+		setNotEmpty(cfgKeyMSSQLPort, mssqlCfg.Port)
+		setNotEmpty(cfgKeyMSSQLUser, mssqlCfg.User)
+		setNotEmpty(cfgKeyMSSQLPassword, mssqlCfg.Password)
+		setNotEmpty(cfgKeyMSSQLDatabase, mssqlCfg.Database)
+		if len(mssqlCfg.AdditionalParameters) != 0 {
+			values[cfgKeyMSSQLAdditionalParams] = mssqlCfg.AdditionalParameters
+		}
+	case DialectPostgres, DialectPgx:
+		pgCfg, err := ParsePostgresDSN(dsn)
+		if err != nil {
+			return err
+		}
+		setNotEmpty(cfgKeyPostgresHost, pgCfg.Host)
+		if len(pgCfg.Hosts) != 0 {
+			values[cfgKeyPostgresHosts] = pgCfg.Hosts
+		}
+		setNotEmpty(cfgKeyPostgresPort, pgCfg.Port)
+		setNotEmpty(cfgKeyPostgresUser, pgCfg.User)
+		setNotEmpty(cfgKeyPostgresPassword, pgCfg.Password)
+		setNotEmpty(cfgKeyPostgresDatabase, pgCfg.Database)
+		setNotEmpty(cfgKeyPostgresSSLMode, string(pgCfg.SSLMode))
+		setNotEmpty(cfgKeyPostgresSSLCert, pgCfg.SSLCert)
+		setNotEmpty(cfgKeyPostgresSSLKey, pgCfg.SSLKey)
+		setNotEmpty(cfgKeyPostgresSSLRootCert, pgCfg.SSLRootCert)
+		setNotEmpty(cfgKeyPostgresSearchPath, pgCfg.SearchPath)
+		if len(pgCfg.AdditionalParameters) != 0 {
+			values[cfgKeyPostgresAdditionalParams] = pgCfg.AdditionalParameters
+		}
+	case DialectSQLite:
+		values[cfgKeySQLitePath] = dsn
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+)
+
+// ErrorClass defines a class of the error that occurred while working with a database.
+// Its string representation is stable and may be used as a label value in metrics.
+type ErrorClass string
+
+// Error classes.
+const (
+	// ErrorClassNone is used for nil error.
+	ErrorClassNone ErrorClass = ""
+	// ErrorClassContextCanceled means that the caller canceled the context.
+	ErrorClassContextCanceled ErrorClass = "context_canceled"
+	// ErrorClassContextDeadlineExceeded means that the caller's context deadline was exceeded.
+	ErrorClassContextDeadlineExceeded ErrorClass = "context_deadline_exceeded"
+	// ErrorClassStatementTimeout means that the statement was aborted by the server
+	// because of the server-side timeout (e.g., statement_timeout in Postgres or max_execution_time in MySQL).
+	ErrorClassStatementTimeout ErrorClass = "statement_timeout"
+	// ErrorClassNetworkTimeout means that the network I/O operation timed out.
+	ErrorClassNetworkTimeout ErrorClass = "network_timeout"
+	// ErrorClassDeadlock means that the transaction was aborted because of the deadlock.
+	ErrorClassDeadlock ErrorClass = "deadlock"
+	// ErrorClassSerializationFailure means that the transaction was aborted
+	// because it could not be serialized with the concurrent transactions.
+	ErrorClassSerializationFailure ErrorClass = "serialization_failure"
+	// ErrorClassLockTimeout means that the lock could not be acquired in time (or the database is busy).
+	ErrorClassLockTimeout ErrorClass = "lock_timeout"
+	// ErrorClassConnectionFailure means that the connection to the database could not be established or was broken.
+	ErrorClassConnectionFailure ErrorClass = "connection_failure"
+	// ErrorClassConstraintViolation means that the statement violated an integrity constraint
+	// (e.g., unique, foreign key, not null or check).
+	ErrorClassConstraintViolation ErrorClass = "constraint_violation"
+	// ErrorClassStaleObject means that the row was modified or deleted concurrently
+	// since it was read (see ErrStaleObject and VersionedUpdate).
+	ErrorClassStaleObject ErrorClass = "stale_object"
+	// ErrorClassOther is used for all errors that don't fall into any other class.
+	ErrorClassOther ErrorClass = "other"
+)
+
+// String returns the string representation of the error class.
+// Implements fmt.Stringer interface.
+func (c ErrorClass) String() string {
+	return string(c)
+}
+
+// ErrorClassifier is a function that returns class of the passed error
+// or ErrorClassNone if it cannot classify it.
+type ErrorClassifier func(err error) ErrorClass
+
+var errorClassifiers []ErrorClassifier
+
+// RegisterErrorClassifier registers dialect-specific function for the error classification.
+// Registered functions are called in FIFO order until some of them returns not ErrorClassNone.
+// Note: this function is not concurrent-safe. Typical scenario: register all classifiers in module init().
+func RegisterErrorClassifier(classifier ErrorClassifier) {
+	errorClassifiers = append(errorClassifiers, classifier)
+}
+
+// ClassifyError returns the class of the error.
+// Caller's context cancellation and deadline and ErrStaleObject are checked first,
+// then dialect-specific classifiers (registered by dialect packages, e.g. mysql, postgres or pgx) are called,
+// and finally broken connections and network errors are detected.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassNone
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassContextCanceled
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassContextDeadlineExceeded
+	}
+	if errors.Is(err, ErrStaleObject) {
+		return ErrorClassStaleObject
+	}
+	for _, classifier := range errorClassifiers {
+		if class := classifier(err); class != ErrorClassNone {
+			return class
+		}
+	}
+	if errors.Is(err, driver.ErrBadConn) {
+		return ErrorClassConnectionFailure
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorClassNetworkTimeout
+		}
+		return ErrorClassConnectionFailure
+	}
+	return ErrorClassOther
+}
@@ -0,0 +1,68 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	statementTimeoutErr := errors.New("statement timeout")
+	prevClassifiers := errorClassifiers
+	defer func() { errorClassifiers = prevClassifiers }()
+	RegisterErrorClassifier(func(err error) ErrorClass {
+		if errors.Is(err, statementTimeoutErr) {
+			return ErrorClassStatementTimeout
+		}
+		return ErrorClassNone
+	})
+
+	tests := []struct {
+		name      string
+		err       error
+		wantClass ErrorClass
+	}{
+		{name: "nil", err: nil, wantClass: ErrorClassNone},
+		{name: "context canceled", err: fmt.Errorf("exec: %w", context.Canceled), wantClass: ErrorClassContextCanceled},
+		{
+			name:      "context deadline exceeded",
+			err:       fmt.Errorf("exec: %w", context.DeadlineExceeded),
+			wantClass: ErrorClassContextDeadlineExceeded,
+		},
+		{
+			name:      "statement timeout from dialect classifier",
+			err:       fmt.Errorf("exec: %w", statementTimeoutErr),
+			wantClass: ErrorClassStatementTimeout,
+		},
+		{
+			name:      "network timeout",
+			err:       &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded},
+			wantClass: ErrorClassNetworkTimeout,
+		},
+		{
+			name:      "connection refused",
+			err:       &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			wantClass: ErrorClassConnectionFailure,
+		},
+		{name: "bad connection", err: fmt.Errorf("exec: %w", driver.ErrBadConn), wantClass: ErrorClassConnectionFailure},
+		{name: "stale object", err: fmt.Errorf("update: %w", ErrStaleObject), wantClass: ErrorClassStaleObject},
+		{name: "other", err: errors.New("syntax error"), wantClass: ErrorClassOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.wantClass, ClassifyError(tt.err))
+		})
+	}
+}
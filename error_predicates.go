@@ -0,0 +1,74 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+// ConstraintViolation defines a type of the violated integrity constraint.
+type ConstraintViolation string
+
+// Constraint violation types.
+const (
+	// ConstraintViolationNone is used when the error is not a constraint violation (or its type is unknown).
+	ConstraintViolationNone       ConstraintViolation = ""
+	ConstraintViolationUnique     ConstraintViolation = "unique"
+	ConstraintViolationForeignKey ConstraintViolation = "foreign_key"
+	ConstraintViolationNotNull    ConstraintViolation = "not_null"
+	ConstraintViolationCheck      ConstraintViolation = "check"
+)
+
+// ConstraintViolationClassifier is a function that returns type of the violated constraint
+// or ConstraintViolationNone if the passed error is not a constraint violation.
+type ConstraintViolationClassifier func(err error) ConstraintViolation
+
+var constraintViolationClassifiers []ConstraintViolationClassifier
+
+// RegisterConstraintViolationClassifier registers dialect-specific function for the constraint violation classification.
+// Registered functions are called in FIFO order until some of them returns not ConstraintViolationNone.
+// Note: this function is not concurrent-safe. Typical scenario: register all classifiers in module init().
+func RegisterConstraintViolationClassifier(classifier ConstraintViolationClassifier) {
+	constraintViolationClassifiers = append(constraintViolationClassifiers, classifier)
+}
+
+// ClassifyConstraintViolation returns type of the violated constraint.
+// Classifiers are registered by dialect packages (mysql, postgres, pgx, sqlite and mssql), so they should be imported.
+func ClassifyConstraintViolation(err error) ConstraintViolation {
+	if err == nil {
+		return ConstraintViolationNone
+	}
+	for _, classifier := range constraintViolationClassifiers {
+		if violation := classifier(err); violation != ConstraintViolationNone {
+			return violation
+		}
+	}
+	return ConstraintViolationNone
+}
+
+// IsUniqueViolation returns true if the error is caused by the unique (or primary key) constraint violation.
+// It works for all dialects whose packages are imported, so application code doesn't need to switch per dialect.
+func IsUniqueViolation(err error) bool {
+	return ClassifyConstraintViolation(err) == ConstraintViolationUnique
+}
+
+// IsForeignKeyViolation returns true if the error is caused by the foreign key constraint violation.
+func IsForeignKeyViolation(err error) bool {
+	return ClassifyConstraintViolation(err) == ConstraintViolationForeignKey
+}
+
+// IsDeadlock returns true if the transaction was aborted because of the deadlock.
+func IsDeadlock(err error) bool {
+	return ClassifyError(err) == ErrorClassDeadlock
+}
+
+// IsSerializationFailure returns true if the transaction was aborted
+// because it could not be serialized with the concurrent transactions.
+func IsSerializationFailure(err error) bool {
+	return ClassifyError(err) == ErrorClassSerializationFailure
+}
+
+// IsConnectionError returns true if the connection to the database could not be established or was broken.
+func IsConnectionError(err error) bool {
+	return ClassifyError(err) == ErrorClassConnectionFailure
+}
@@ -0,0 +1,54 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorPredicates(t *testing.T) {
+	uniqueErr := errors.New("duplicate key")
+	fkErr := errors.New("foreign key")
+	deadlockErr := errors.New("deadlock")
+	serializationErr := errors.New("serialization failure")
+
+	prevClassifiers, prevViolationClassifiers := errorClassifiers, constraintViolationClassifiers
+	defer func() { errorClassifiers, constraintViolationClassifiers = prevClassifiers, prevViolationClassifiers }()
+	RegisterErrorClassifier(func(err error) ErrorClass {
+		switch {
+		case errors.Is(err, deadlockErr):
+			return ErrorClassDeadlock
+		case errors.Is(err, serializationErr):
+			return ErrorClassSerializationFailure
+		}
+		return ErrorClassNone
+	})
+	RegisterConstraintViolationClassifier(func(err error) ConstraintViolation {
+		switch {
+		case errors.Is(err, uniqueErr):
+			return ConstraintViolationUnique
+		case errors.Is(err, fkErr):
+			return ConstraintViolationForeignKey
+		}
+		return ConstraintViolationNone
+	})
+
+	require.True(t, IsUniqueViolation(fmt.Errorf("insert: %w", uniqueErr)))
+	require.False(t, IsUniqueViolation(fkErr))
+	require.True(t, IsForeignKeyViolation(fkErr))
+	require.True(t, IsDeadlock(deadlockErr))
+	require.False(t, IsDeadlock(serializationErr))
+	require.True(t, IsSerializationFailure(serializationErr))
+	require.True(t, IsConnectionError(driver.ErrBadConn))
+	require.False(t, IsConnectionError(nil))
+	require.Equal(t, ConstraintViolationNone, ClassifyConstraintViolation(errors.New("syntax error")))
+}
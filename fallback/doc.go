@@ -0,0 +1,11 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package fallback provides an optional degradation layer for designated read queries.
+// Results of successful reads are remembered, and when the circuit breaker protecting the database is open,
+// the last-known-good result is served together with its staleness metadata instead of an error.
+// It's useful for endpoints where stale data is better than an error page.
+package fallback
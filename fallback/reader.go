@@ -0,0 +1,138 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package fallback
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when the circuit breaker is open and there is no cached result that may be served.
+var ErrCircuitOpen = errors.New("circuit breaker is open and no cached result is available")
+
+// CircuitBreaker is an interface of the circuit breaker that protects the database.
+// It may be easily implemented on top of any circuit breaker library (e.g., github.com/sony/gobreaker).
+type CircuitBreaker interface {
+	IsOpen() bool
+}
+
+// CircuitBreakerFunc is an adapter to allow the use of ordinary functions as CircuitBreaker.
+type CircuitBreakerFunc func() bool
+
+// IsOpen returns true if the circuit breaker is open.
+func (f CircuitBreakerFunc) IsOpen() bool {
+	return f()
+}
+
+// ReadFunc is a function that reads data from the database.
+type ReadFunc func(ctx context.Context) (interface{}, error)
+
+// Result is a result of the read.
+type Result struct {
+	Value interface{}
+	// Stale is true if the value is served from the cache because the database is not available.
+	Stale bool
+	// CachedAt is the time when the value was read from the database.
+	CachedAt time.Time
+}
+
+// Age returns how long ago the value was read from the database.
+func (r Result) Age() time.Duration {
+	return time.Since(r.CachedAt)
+}
+
+type readerOptions struct {
+	maxStaleness    time.Duration
+	fallbackOnError func(err error) bool
+}
+
+// ReaderOption is a functional option for NewReader.
+type ReaderOption func(*readerOptions)
+
+// WithMaxStaleness sets the maximum age of the cached result that may be served.
+// By default, cached results are served regardless of their age.
+func WithMaxStaleness(maxStaleness time.Duration) ReaderOption {
+	return func(opts *readerOptions) {
+		opts.maxStaleness = maxStaleness
+	}
+}
+
+// WithFallbackOnError allows serving cached results not only when the circuit breaker is open,
+// but also when the read fails with an error for which the passed function returns true
+// (e.g., a connection error that was not counted by the circuit breaker yet).
+func WithFallbackOnError(fallbackOnError func(err error) bool) ReaderOption {
+	return func(opts *readerOptions) {
+		opts.fallbackOnError = fallbackOnError
+	}
+}
+
+// Reader reads data from the database and remembers last-known-good results by keys.
+// When the circuit breaker is open, it serves cached results instead of accessing the database.
+type Reader struct {
+	breaker CircuitBreaker
+	opts    readerOptions
+
+	mu      sync.RWMutex
+	results map[string]Result
+}
+
+// NewReader creates a new Reader.
+func NewReader(breaker CircuitBreaker, options ...ReaderOption) *Reader {
+	var opts readerOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return &Reader{breaker: breaker, opts: opts, results: make(map[string]Result)}
+}
+
+// Read calls readFn and caches its result by the key if the circuit breaker is closed.
+// Otherwise, it returns the last-known-good result for the key with Stale flag set,
+// or ErrCircuitOpen if there is no such result.
+func (r *Reader) Read(ctx context.Context, key string, readFn ReadFunc) (Result, error) {
+	if r.breaker.IsOpen() {
+		if cached, ok := r.getCached(key); ok {
+			return cached, nil
+		}
+		return Result{}, ErrCircuitOpen
+	}
+
+	value, err := readFn(ctx)
+	if err != nil {
+		if r.opts.fallbackOnError != nil && r.opts.fallbackOnError(err) {
+			if cached, ok := r.getCached(key); ok {
+				return cached, nil
+			}
+		}
+		return Result{}, err
+	}
+
+	result := Result{Value: value, CachedAt: time.Now()}
+	r.mu.Lock()
+	r.results[key] = result
+	r.mu.Unlock()
+	return result, nil
+}
+
+// Forget removes the cached result for the key (e.g., when the data was changed by the application).
+func (r *Reader) Forget(key string) {
+	r.mu.Lock()
+	delete(r.results, key)
+	r.mu.Unlock()
+}
+
+func (r *Reader) getCached(key string) (Result, bool) {
+	r.mu.RLock()
+	cached, ok := r.results[key]
+	r.mu.RUnlock()
+	if !ok || (r.opts.maxStaleness > 0 && cached.Age() > r.opts.maxStaleness) {
+		return Result{}, false
+	}
+	cached.Stale = true
+	return cached, true
+}
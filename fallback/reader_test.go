@@ -0,0 +1,80 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package fallback
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader_Read(t *testing.T) {
+	errConn := errors.New("connection refused")
+	readValue := func(value interface{}) ReadFunc {
+		return func(ctx context.Context) (interface{}, error) { return value, nil }
+	}
+	readErr := func(ctx context.Context) (interface{}, error) { return nil, errConn }
+
+	t.Run("cached result is served when circuit breaker is open", func(t *testing.T) {
+		var open bool
+		reader := NewReader(CircuitBreakerFunc(func() bool { return open }))
+
+		_, err := reader.Read(context.Background(), "users", readValue(10))
+		require.NoError(t, err)
+
+		open = true
+		res, err := reader.Read(context.Background(), "users", readValue(20))
+		require.NoError(t, err)
+		require.Equal(t, 10, res.Value)
+		require.True(t, res.Stale)
+		require.False(t, res.CachedAt.IsZero())
+
+		_, err = reader.Read(context.Background(), "orders", readValue(30))
+		require.ErrorIs(t, err, ErrCircuitOpen)
+
+		open = false
+		res, err = reader.Read(context.Background(), "users", readValue(20))
+		require.NoError(t, err)
+		require.Equal(t, 20, res.Value)
+		require.False(t, res.Stale)
+	})
+
+	t.Run("too stale result is not served", func(t *testing.T) {
+		var open bool
+		reader := NewReader(CircuitBreakerFunc(func() bool { return open }), WithMaxStaleness(time.Millisecond))
+
+		_, err := reader.Read(context.Background(), "users", readValue(10))
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond * 5)
+
+		open = true
+		_, err = reader.Read(context.Background(), "users", readValue(20))
+		require.ErrorIs(t, err, ErrCircuitOpen)
+	})
+
+	t.Run("fallback on error", func(t *testing.T) {
+		reader := NewReader(CircuitBreakerFunc(func() bool { return false }),
+			WithFallbackOnError(func(err error) bool { return errors.Is(err, errConn) }))
+
+		_, err := reader.Read(context.Background(), "users", readErr)
+		require.ErrorIs(t, err, errConn)
+
+		_, err = reader.Read(context.Background(), "users", readValue(10))
+		require.NoError(t, err)
+		res, err := reader.Read(context.Background(), "users", readErr)
+		require.NoError(t, err)
+		require.Equal(t, 10, res.Value)
+		require.True(t, res.Stale)
+
+		reader.Forget("users")
+		_, err = reader.Read(context.Background(), "users", readErr)
+		require.ErrorIs(t, err, errConn)
+	})
+}
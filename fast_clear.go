@@ -0,0 +1,250 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Default values for FastClear.
+const (
+	DefaultFastClearSmallTableRows = 10000
+	DefaultFastClearBatchSize      = 10000
+	DefaultFastClearBatchPause     = 100 * time.Millisecond
+)
+
+// ClearStrategy is a way of removing all rows from a table chosen by FastClear.
+type ClearStrategy string
+
+// Clear strategies.
+const (
+	// ClearStrategyDelete means a single DELETE statement without conditions. It's used for small tables.
+	ClearStrategyDelete ClearStrategy = "delete"
+	// ClearStrategyTruncate means TRUNCATE TABLE statement. It doesn't generate row-level changes,
+	// so it's fast and doesn't cause replication lag, but it takes an exclusive lock of the table,
+	// doesn't fire ON DELETE triggers and fails if the table is referenced by foreign keys.
+	ClearStrategyTruncate ClearStrategy = "truncate"
+	// ClearStrategyBatchedDelete means DELETE statements removing rows in small batches (each in its own transaction)
+	// with pauses between them, so replicas may keep up, and locks are held only for a short time.
+	ClearStrategyBatchedDelete ClearStrategy = "batched-delete"
+	// ClearStrategyDropPartitions means dropping the partitions of the table (see WithFastClearPartitions).
+	ClearStrategyDropPartitions ClearStrategy = "drop-partitions"
+)
+
+type fastClearOptions struct {
+	estimatedRows int64
+	noTruncate    bool
+	partitions    []string
+	batchSize     int
+	batchPause    time.Duration
+}
+
+// FastClearOption is a functional option for FastClear.
+type FastClearOption func(*fastClearOptions)
+
+// WithFastClearEstimatedRows sets the hint of the number of rows in the table (e.g., from table statistics).
+// Tables with no more than DefaultFastClearSmallTableRows rows are cleared with a single DELETE statement.
+// If the hint is not specified, the table is considered large.
+func WithFastClearEstimatedRows(rows int64) FastClearOption {
+	return func(opts *fastClearOptions) {
+		opts.estimatedRows = rows
+	}
+}
+
+// WithFastClearNoTruncate disables TRUNCATE for the table, so large tables are cleared with batched DELETEs.
+// It should be used when the table is referenced by foreign keys, has ON DELETE triggers that must be fired,
+// or its exclusive locking is not acceptable.
+func WithFastClearNoTruncate() FastClearOption {
+	return func(opts *fastClearOptions) {
+		opts.noTruncate = true
+	}
+}
+
+// WithFastClearPartitions specifies partitions of the table holding the rows to be cleared.
+// They are dropped (DROP TABLE of partitions for Postgres, ALTER TABLE ... DROP PARTITION for MySQL),
+// which is the cheapest way to remove large amounts of data. Dropped partitions should be recreated
+// (e.g., by the partition manager) if they are needed for new rows.
+func WithFastClearPartitions(partitions ...string) FastClearOption {
+	return func(opts *fastClearOptions) {
+		opts.partitions = partitions
+	}
+}
+
+// WithFastClearBatchSize sets the number of rows removed by one DELETE statement when batched deletion is used.
+// DefaultFastClearBatchSize is used by default.
+func WithFastClearBatchSize(size int) FastClearOption {
+	return func(opts *fastClearOptions) {
+		opts.batchSize = size
+	}
+}
+
+// WithFastClearBatchPause sets the pause between DELETE statements when batched deletion is used.
+// DefaultFastClearBatchPause is used by default.
+func WithFastClearBatchPause(pause time.Duration) FastClearOption {
+	return func(opts *fastClearOptions) {
+		opts.batchPause = pause
+	}
+}
+
+// ClearResult contains the result of FastClear.
+type ClearResult struct {
+	Strategy ClearStrategy
+	// DeletedRows is the number of deleted rows. It's known only for ClearStrategyDelete and ClearStrategyBatchedDelete.
+	DeletedRows int64
+}
+
+// FastClear removes all rows from the table choosing the strategy by dialect capabilities and table size hints,
+// since a naive DELETE of millions of rows generates a huge transaction that causes replication lag:
+//   - partitions are dropped if they are specified (WithFastClearPartitions, Postgres and MySQL only);
+//   - small tables (see WithFastClearEstimatedRows) are cleared with a single DELETE;
+//   - large tables are truncated (except SQLite that doesn't support TRUNCATE, see also WithFastClearNoTruncate);
+//   - otherwise, rows are deleted in batches with pauses between them.
+//
+// The table name may be schema-qualified (e.g., "reports.events"), it's quoted according to the dialect.
+func FastClear(
+	ctx context.Context, dbConn *sql.DB, dialect Dialect, table string, options ...FastClearOption,
+) (ClearResult, error) {
+	opts := fastClearOptions{
+		estimatedRows: -1,
+		batchSize:     DefaultFastClearBatchSize,
+		batchPause:    DefaultFastClearBatchPause,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.batchSize <= 0 {
+		opts.batchSize = DefaultFastClearBatchSize
+	}
+
+	strategy, err := chooseClearStrategy(dialect, opts)
+	if err != nil {
+		return ClearResult{}, err
+	}
+	result := ClearResult{Strategy: strategy}
+	quotedTable := quoteTableName(dialect, table)
+	switch strategy {
+	case ClearStrategyDropPartitions:
+		err = dropPartitions(ctx, dbConn, dialect, quotedTable, opts.partitions)
+	case ClearStrategyTruncate:
+		if _, err = dbConn.ExecContext(ctx, "TRUNCATE TABLE "+quotedTable); err != nil {
+			err = fmt.Errorf("truncate table %s: %w", table, err)
+		}
+	case ClearStrategyDelete:
+		var res sql.Result
+		if res, err = dbConn.ExecContext(ctx, "DELETE FROM "+quotedTable); err != nil {
+			err = fmt.Errorf("delete rows from table %s: %w", table, err)
+		} else {
+			result.DeletedRows, err = res.RowsAffected()
+		}
+	case ClearStrategyBatchedDelete:
+		result.DeletedRows, err = deleteInBatches(ctx, dbConn, dialect, quotedTable, opts)
+		if err != nil {
+			err = fmt.Errorf("delete rows from table %s in batches: %w", table, err)
+		}
+	}
+	return result, err
+}
+
+func chooseClearStrategy(dialect Dialect, opts fastClearOptions) (ClearStrategy, error) {
+	switch dialect {
+	case DialectPostgres, DialectPgx, DialectMySQL, DialectMSSQL, DialectSQLite:
+	default:
+		return "", fmt.Errorf("unsupported dialect %q", dialect)
+	}
+	if len(opts.partitions) != 0 {
+		if dialect == DialectSQLite || dialect == DialectMSSQL {
+			return "", fmt.Errorf("dropping partitions is not supported for %s dialect", dialect)
+		}
+		return ClearStrategyDropPartitions, nil
+	}
+	if opts.estimatedRows >= 0 && opts.estimatedRows <= DefaultFastClearSmallTableRows {
+		return ClearStrategyDelete, nil
+	}
+	if !opts.noTruncate && dialect != DialectSQLite {
+		return ClearStrategyTruncate, nil
+	}
+	return ClearStrategyBatchedDelete, nil
+}
+
+func dropPartitions(ctx context.Context, dbConn *sql.DB, dialect Dialect, quotedTable string, partitions []string) error {
+	quotedPartitions := make([]string, 0, len(partitions))
+	for _, partition := range partitions {
+		quotedPartitions = append(quotedPartitions, quoteTableName(dialect, partition))
+	}
+	var query string
+	switch dialect {
+	case DialectPostgres, DialectPgx:
+		query = "DROP TABLE " + strings.Join(quotedPartitions, ", ")
+	case DialectMySQL:
+		query = "ALTER TABLE " + quotedTable + " DROP PARTITION " + strings.Join(quotedPartitions, ", ")
+	}
+	if _, err := dbConn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("drop partitions %s: %w", strings.Join(partitions, ", "), err)
+	}
+	return nil
+}
+
+func deleteInBatches(
+	ctx context.Context, dbConn *sql.DB, dialect Dialect, quotedTable string, opts fastClearOptions,
+) (int64, error) {
+	var query string
+	switch dialect {
+	case DialectPostgres, DialectPgx:
+		query = fmt.Sprintf("DELETE FROM %[1]s WHERE ctid IN (SELECT ctid FROM %[1]s LIMIT %[2]d)", quotedTable, opts.batchSize)
+	case DialectMySQL:
+		query = fmt.Sprintf("DELETE FROM %s LIMIT %d", quotedTable, opts.batchSize)
+	case DialectMSSQL:
+		query = fmt.Sprintf("DELETE TOP (%d) FROM %s", opts.batchSize, quotedTable)
+	case DialectSQLite:
+		query = fmt.Sprintf("DELETE FROM %[1]s WHERE rowid IN (SELECT rowid FROM %[1]s LIMIT %[2]d)", quotedTable, opts.batchSize)
+	}
+
+	var deletedRows int64
+	for {
+		res, err := dbConn.ExecContext(ctx, query)
+		if err != nil {
+			return deletedRows, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return deletedRows, err
+		}
+		deletedRows += affected
+		if affected < int64(opts.batchSize) {
+			return deletedRows, nil
+		}
+		if opts.batchPause > 0 {
+			timer := time.NewTimer(opts.batchPause)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return deletedRows, ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// quoteTableName quotes the (possibly schema-qualified) table name according to the dialect.
+func quoteTableName(dialect Dialect, table string) string {
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		switch dialect {
+		case DialectMySQL:
+			parts[i] = "`" + strings.ReplaceAll(part, "`", "``") + "`"
+		case DialectMSSQL:
+			parts[i] = "[" + strings.ReplaceAll(part, "]", "]]") + "]"
+		default:
+			parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+		}
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,173 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFastClear_SQLite(t *testing.T) {
+	ctx := context.Background()
+	openDBWithRows := func(t *testing.T, rows int) *sql.DB {
+		t.Helper()
+		dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "clear.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = dbConn.Close() })
+		_, err = dbConn.Exec(`CREATE TABLE "events" (id INTEGER PRIMARY KEY)`)
+		require.NoError(t, err)
+		_, err = dbConn.Exec(`WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < ?)
+INSERT INTO "events" (id) SELECT n FROM seq`, rows)
+		require.NoError(t, err)
+		return dbConn
+	}
+	requireEmpty := func(t *testing.T, dbConn *sql.DB) {
+		t.Helper()
+		var count int
+		require.NoError(t, dbConn.QueryRow(`SELECT COUNT(*) FROM "events"`).Scan(&count))
+		require.Zero(t, count)
+	}
+
+	t.Run("small table", func(t *testing.T) {
+		dbConn := openDBWithRows(t, 100)
+		result, err := FastClear(ctx, dbConn, DialectSQLite, "events", WithFastClearEstimatedRows(100))
+		require.NoError(t, err)
+		require.Equal(t, ClearResult{Strategy: ClearStrategyDelete, DeletedRows: 100}, result)
+		requireEmpty(t, dbConn)
+	})
+
+	t.Run("large table", func(t *testing.T) {
+		dbConn := openDBWithRows(t, 2500)
+		result, err := FastClear(ctx, dbConn, DialectSQLite, "events", WithFastClearBatchSize(1000), WithFastClearBatchPause(0))
+		require.NoError(t, err)
+		require.Equal(t, ClearResult{Strategy: ClearStrategyBatchedDelete, DeletedRows: 2500}, result)
+		requireEmpty(t, dbConn)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		dbConn := openDBWithRows(t, 2500)
+		cancelCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		result, err := FastClear(cancelCtx, dbConn, DialectSQLite, "events")
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, ClearStrategyBatchedDelete, result.Strategy)
+	})
+
+	t.Run("partitions are not supported", func(t *testing.T) {
+		dbConn := openDBWithRows(t, 1)
+		_, err := FastClear(ctx, dbConn, DialectSQLite, "events", WithFastClearPartitions("events_2024"))
+		require.EqualError(t, err, "dropping partitions is not supported for sqlite3 dialect")
+	})
+}
+
+func TestFastClear_Statements(t *testing.T) {
+	tests := []struct {
+		name         string
+		dialect      Dialect
+		table        string
+		options      []FastClearOption
+		wantStrategy ClearStrategy
+		wantQueries  []string
+	}{
+		{
+			name:         "postgres truncate",
+			dialect:      DialectPgx,
+			table:        "reports.events",
+			wantStrategy: ClearStrategyTruncate,
+			wantQueries:  []string{`TRUNCATE TABLE "reports"."events"`},
+		},
+		{
+			name:         "postgres drop partitions",
+			dialect:      DialectPostgres,
+			table:        "events",
+			options:      []FastClearOption{WithFastClearPartitions("events_2024_01", "events_2024_02")},
+			wantStrategy: ClearStrategyDropPartitions,
+			wantQueries:  []string{`DROP TABLE "events_2024_01", "events_2024_02"`},
+		},
+		{
+			name:         "postgres batched delete",
+			dialect:      DialectPostgres,
+			table:        "events",
+			options:      []FastClearOption{WithFastClearNoTruncate(), WithFastClearBatchSize(2), WithFastClearBatchPause(0)},
+			wantStrategy: ClearStrategyBatchedDelete,
+			wantQueries: []string{
+				`DELETE FROM "events" WHERE ctid IN (SELECT ctid FROM "events" LIMIT 2)`,
+				`DELETE FROM "events" WHERE ctid IN (SELECT ctid FROM "events" LIMIT 2)`,
+			},
+		},
+		{
+			name:         "mysql drop partitions",
+			dialect:      DialectMySQL,
+			table:        "events",
+			options:      []FastClearOption{WithFastClearPartitions("p2024")},
+			wantStrategy: ClearStrategyDropPartitions,
+			wantQueries:  []string{"ALTER TABLE `events` DROP PARTITION `p2024`"},
+		},
+		{
+			name:         "mysql batched delete",
+			dialect:      DialectMySQL,
+			table:        "events",
+			options:      []FastClearOption{WithFastClearNoTruncate(), WithFastClearBatchSize(2), WithFastClearBatchPause(0)},
+			wantStrategy: ClearStrategyBatchedDelete,
+			wantQueries:  []string{"DELETE FROM `events` LIMIT 2", "DELETE FROM `events` LIMIT 2"},
+		},
+		{
+			name:         "mssql truncate",
+			dialect:      DialectMSSQL,
+			table:        "events",
+			options:      []FastClearOption{WithFastClearEstimatedRows(DefaultFastClearSmallTableRows + 1)},
+			wantStrategy: ClearStrategyTruncate,
+			wantQueries:  []string{"TRUNCATE TABLE [events]"},
+		},
+		{
+			name:         "mssql batched delete",
+			dialect:      DialectMSSQL,
+			table:        "events",
+			options:      []FastClearOption{WithFastClearNoTruncate(), WithFastClearBatchSize(2), WithFastClearBatchPause(0)},
+			wantStrategy: ClearStrategyBatchedDelete,
+			wantQueries:  []string{"DELETE TOP (2) FROM [events]", "DELETE TOP (2) FROM [events]"},
+		},
+		{
+			name:         "mysql small table",
+			dialect:      DialectMySQL,
+			table:        "events",
+			options:      []FastClearOption{WithFastClearEstimatedRows(10)},
+			wantStrategy: ClearStrategyDelete,
+			wantQueries:  []string{"DELETE FROM `events`"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer func() { _ = db.Close() }()
+
+			// Batched delete stops when the batch is not full.
+			for i, query := range tt.wantQueries {
+				affected := int64(2)
+				if i == len(tt.wantQueries)-1 {
+					affected = 1
+				}
+				mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(0, affected))
+			}
+
+			result, err := FastClear(context.Background(), db, tt.dialect, tt.table, tt.options...)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStrategy, result.Strategy)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	_, err := FastClear(context.Background(), nil, "oracle", "events")
+	require.EqualError(t, err, `unsupported dialect "oracle"`)
+}
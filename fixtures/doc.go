@@ -0,0 +1,22 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package fixtures loads test data from YAML or JSON files into database tables.
+// A fixture file maps table names to lists of rows (or contains a list of rows for the table named after the file):
+//
+//	users:
+//	  - id: 1
+//	    name: alice
+//	orders:
+//	  - id: 10
+//	    user_id: 1
+//	    details: {"sku": "A-1"} # nested values are inserted as JSON
+//
+// Load inserts rows in a single transaction, ordering tables by foreign keys read from the database schema,
+// so parent rows are inserted before the rows referencing them regardless of the order in files.
+// Existing rows of the loaded tables may be deleted first (see WithTruncate).
+// Setup applies migrations before loading fixtures, so tests get the schema and the data in one call.
+package fixtures
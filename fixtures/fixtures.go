@@ -0,0 +1,138 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture contains rows that are inserted into the table.
+type Fixture struct {
+	Table string
+	// Rows map column names to values. Nested maps and lists are inserted as JSON.
+	Rows []map[string]interface{}
+}
+
+// ReadFiles reads fixtures from YAML (.yml, .yaml) or JSON (.json) files.
+func ReadFiles(paths ...string) ([]Fixture, error) {
+	var fixtures []Fixture
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read fixture file: %w", err)
+		}
+		fileFixtures, err := Parse(path, data)
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, fileFixtures...)
+	}
+	return fixtures, nil
+}
+
+// ReadFS reads fixtures from YAML (.yml, .yaml) or JSON (.json) files of the file system (e.g., embed.FS)
+// matching the patterns (see fs.Glob).
+func ReadFS(fsys fs.FS, patterns ...string) ([]Fixture, error) {
+	var fixtures []Fixture
+	for _, pattern := range patterns {
+		paths, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("match fixture files: %w", err)
+		}
+		for _, path := range paths {
+			data, err := fs.ReadFile(fsys, path)
+			if err != nil {
+				return nil, fmt.Errorf("read fixture file: %w", err)
+			}
+			fileFixtures, err := Parse(path, data)
+			if err != nil {
+				return nil, err
+			}
+			fixtures = append(fixtures, fileFixtures...)
+		}
+	}
+	return fixtures, nil
+}
+
+// Parse parses fixtures from the content of the YAML or JSON file.
+// The file either maps table names to lists of rows, or contains a list of rows for the table
+// named after the file (without extension). Tables are returned in the order of their names.
+func Parse(fileName string, data []byte) ([]Fixture, error) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	switch ext {
+	case ".yml", ".yaml", ".json": // JSON is a subset of YAML, so both are parsed by the YAML decoder.
+	default:
+		return nil, fmt.Errorf("unsupported fixture file extension %q", ext)
+	}
+
+	var content interface{}
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("parse fixture file %s: %w", fileName, err)
+	}
+	switch c := content.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		table := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+		rows, err := parseRows(c)
+		if err != nil {
+			return nil, fmt.Errorf("parse fixture file %s: table %s: %w", fileName, table, err)
+		}
+		return []Fixture{{Table: table, Rows: rows}}, nil
+	case map[string]interface{}:
+		tables := make([]string, 0, len(c))
+		for table := range c {
+			tables = append(tables, table)
+		}
+		sort.Strings(tables)
+		fixtures := make([]Fixture, 0, len(tables))
+		for _, table := range tables {
+			list, ok := c[table].([]interface{})
+			if !ok && c[table] != nil {
+				return nil, fmt.Errorf("parse fixture file %s: table %s: rows must be a list", fileName, table)
+			}
+			rows, err := parseRows(list)
+			if err != nil {
+				return nil, fmt.Errorf("parse fixture file %s: table %s: %w", fileName, table, err)
+			}
+			fixtures = append(fixtures, Fixture{Table: table, Rows: rows})
+		}
+		return fixtures, nil
+	default:
+		return nil, fmt.Errorf("parse fixture file %s: content must be a map of tables or a list of rows", fileName)
+	}
+}
+
+func parseRows(list []interface{}) ([]map[string]interface{}, error) {
+	rows := make([]map[string]interface{}, 0, len(list))
+	for i, item := range list {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("row #%d must be a map of columns", i+1)
+		}
+		for column, value := range row {
+			switch value.(type) {
+			case map[string]interface{}, []interface{}:
+				jsonValue, err := json.Marshal(value)
+				if err != nil {
+					return nil, fmt.Errorf("row #%d: column %s: %w", i+1, column, err)
+				}
+				row[column] = string(jsonValue)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package fixtures
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+var shopMigrations = []migrate.Migration{
+	migrate.NewCustomMigration("00001_create_shop_tables", []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, active BOOLEAN NOT NULL)`,
+		`CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users (id), details TEXT)`,
+	}, []string{`DROP TABLE orders`, `DROP TABLE users`}, nil, nil),
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "fixtures.db")+"?_foreign_keys=1")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	return dbConn
+}
+
+func TestParse(t *testing.T) {
+	fixtures, err := ReadFiles("testdata/shop.yml", "testdata/users.json")
+	require.NoError(t, err)
+	require.Equal(t, []Fixture{
+		{Table: "orders", Rows: []map[string]interface{}{
+			{"id": 10, "user_id": 1, "details": `{"count":2,"sku":"A-1"}`},
+			{"id": 11, "user_id": 2, "details": nil},
+		}},
+		{Table: "users", Rows: []map[string]interface{}{{"id": 1, "name": "alice", "active": true}}},
+		{Table: "users", Rows: []map[string]interface{}{{"id": 2, "name": "bob", "active": false}}},
+	}, fixtures)
+
+	fsFixtures, err := ReadFS(os.DirFS("testdata"), "*.yml", "*.json")
+	require.NoError(t, err)
+	require.Equal(t, fixtures, fsFixtures)
+
+	_, err = Parse("users.txt", []byte("[]"))
+	require.EqualError(t, err, `unsupported fixture file extension ".txt"`)
+	_, err = Parse("users.yml", []byte("- 1"))
+	require.EqualError(t, err, "parse fixture file users.yml: table users: row #1 must be a map of columns")
+	_, err = Parse("shop.yml", []byte("users: 1"))
+	require.EqualError(t, err, "parse fixture file shop.yml: table users: rows must be a list")
+	_, err = ReadFiles("testdata/missing.yml")
+	require.Error(t, err)
+}
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+	dbConn := openTestDB(t)
+	fixtures, err := ReadFiles("testdata/shop.yml", "testdata/users.json")
+	require.NoError(t, err)
+
+	require.NoError(t, Setup(ctx, dbConn, dbkit.DialectSQLite, shopMigrations, fixtures))
+
+	var name, details string
+	require.NoError(t, dbConn.QueryRow(
+		`SELECT u.name, o.details FROM orders o JOIN users u ON u.id = o.user_id WHERE o.id = 10`).Scan(&name, &details))
+	require.Equal(t, "alice", name)
+	require.JSONEq(t, `{"sku": "A-1", "count": 2}`, details)
+	var ordersCount int
+	require.NoError(t, dbConn.QueryRow(`SELECT COUNT(*) FROM orders`).Scan(&ordersCount))
+	require.Equal(t, 2, ordersCount)
+
+	// Loading the same fixtures again fails because of duplicated keys unless tables are truncated first.
+	require.Error(t, Load(ctx, dbConn, dbkit.DialectSQLite, fixtures))
+	require.NoError(t, Load(ctx, dbConn, dbkit.DialectSQLite, fixtures, WithTruncate()))
+	require.NoError(t, dbConn.QueryRow(`SELECT COUNT(*) FROM orders`).Scan(&ordersCount))
+	require.Equal(t, 2, ordersCount)
+
+	require.Error(t, Load(ctx, dbConn, dbkit.DialectSQLite, []Fixture{
+		{Table: "orders", Rows: []map[string]interface{}{{"id": 12, "user_id": 100}}},
+	}), "foreign key violation is expected")
+
+	require.EqualError(t, Load(ctx, dbConn, "unknown", fixtures), `unsupported dialect "unknown"`)
+}
+
+func TestSortFixtures(t *testing.T) {
+	fixtures := []Fixture{{Table: "c"}, {Table: "b"}, {Table: "a"}, {Table: "d"}}
+	sorted, err := sortFixtures(fixtures, map[string][]string{
+		"c": {"b", "a", "external"},
+		"b": {"a", "b"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Fixture{{Table: "a"}, {Table: "b"}, {Table: "c"}, {Table: "d"}}, sorted)
+
+	_, err = sortFixtures(fixtures, map[string][]string{"a": {"b"}, "b": {"a"}})
+	require.EqualError(t, err, "foreign keys between tables b, a form a cycle")
+}
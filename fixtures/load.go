@@ -0,0 +1,342 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package fixtures
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/acronis/go-appkit/log"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+type loadOptions struct {
+	truncate         bool
+	migrationsLogger log.FieldLogger
+}
+
+// Option is a functional option for Load and Setup.
+type Option func(*loadOptions)
+
+// WithTruncate makes Load delete all existing rows of the loaded tables before inserting fixtures.
+// Rows are deleted in the reverse foreign keys order, in the same transaction.
+func WithTruncate() Option {
+	return func(opts *loadOptions) {
+		opts.truncate = true
+	}
+}
+
+// WithMigrationsLogger sets the logger that is used by Setup for applying migrations. It's disabled by default.
+func WithMigrationsLogger(logger log.FieldLogger) Option {
+	return func(opts *loadOptions) {
+		opts.migrationsLogger = logger
+	}
+}
+
+// Setup applies migrations (see migrate.MigrationsManager) and loads fixtures into the database.
+func Setup(
+	ctx context.Context, dbConn *sql.DB, dialect dbkit.Dialect, migrations []migrate.Migration, fixtures []Fixture,
+	options ...Option,
+) error {
+	opts := makeOptions(options)
+	mm, err := migrate.NewMigrationsManager(dbConn, dialect, opts.migrationsLogger)
+	if err != nil {
+		return fmt.Errorf("create migrations manager: %w", err)
+	}
+	if err = mm.Run(migrations, migrate.MigrationsDirectionUp); err != nil {
+		return fmt.Errorf("apply migrations: %w", err)
+	}
+	return Load(ctx, dbConn, dialect, fixtures, options...)
+}
+
+// Load inserts fixtures into the database in a single transaction.
+// Tables are ordered by foreign keys between them (read from the database schema),
+// so rows of referenced tables are inserted first. Fixtures of the same table are merged.
+func Load(ctx context.Context, dbConn *sql.DB, dialect dbkit.Dialect, fixtures []Fixture, options ...Option) error {
+	opts := makeOptions(options)
+	q, err := newDialectQueries(dialect)
+	if err != nil {
+		return err
+	}
+	fixtures = mergeFixtures(fixtures)
+	if len(fixtures) == 0 {
+		return nil
+	}
+	tables := make([]string, 0, len(fixtures))
+	for _, fixture := range fixtures {
+		tables = append(tables, fixture.Table)
+	}
+	references, err := q.readReferences(ctx, dbConn, tables)
+	if err != nil {
+		return err
+	}
+	if fixtures, err = sortFixtures(fixtures, references); err != nil {
+		return err
+	}
+
+	return dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+		if opts.truncate {
+			for i := len(fixtures) - 1; i >= 0; i-- {
+				if _, err := tx.ExecContext(ctx, "DELETE FROM "+q.quote(fixtures[i].Table)); err != nil {
+					return fmt.Errorf("delete rows from table %s: %w", fixtures[i].Table, err)
+				}
+			}
+		}
+		for _, fixture := range fixtures {
+			if err := q.insertRows(ctx, tx, fixture); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func makeOptions(options []Option) loadOptions {
+	opts := loadOptions{migrationsLogger: log.NewDisabledLogger()}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
+
+// mergeFixtures merges fixtures of the same table keeping the order of their first appearance.
+func mergeFixtures(fixtures []Fixture) []Fixture {
+	merged := make([]Fixture, 0, len(fixtures))
+	indexes := make(map[string]int, len(fixtures))
+	for _, fixture := range fixtures {
+		if i, ok := indexes[fixture.Table]; ok {
+			merged[i].Rows = append(merged[i].Rows, fixture.Rows...)
+			continue
+		}
+		indexes[fixture.Table] = len(merged)
+		merged = append(merged, Fixture{Table: fixture.Table, Rows: append([]map[string]interface{}(nil), fixture.Rows...)})
+	}
+	return merged
+}
+
+// sortFixtures orders fixtures topologically, so referenced tables go before the referencing ones.
+// references maps a table to the tables it references. Self-references are ignored.
+func sortFixtures(fixtures []Fixture, references map[string][]string) ([]Fixture, error) {
+	indexes := make(map[string]int, len(fixtures))
+	for i, fixture := range fixtures {
+		indexes[fixture.Table] = i
+	}
+	inDegrees := make([]int, len(fixtures))
+	dependents := make([][]int, len(fixtures))
+	for i, fixture := range fixtures {
+		for _, referenced := range references[fixture.Table] {
+			j, ok := indexes[referenced]
+			if !ok || j == i {
+				continue
+			}
+			inDegrees[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	sorted := make([]Fixture, 0, len(fixtures))
+	var ready []int
+	for i := range fixtures {
+		if inDegrees[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	for len(ready) != 0 {
+		i := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, fixtures[i])
+		for _, j := range dependents[i] {
+			inDegrees[j]--
+			if inDegrees[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+		sort.Ints(ready) // Keep the original order of independent tables.
+	}
+	if len(sorted) != len(fixtures) {
+		var cycled []string
+		for i, inDegree := range inDegrees {
+			if inDegree != 0 {
+				cycled = append(cycled, fixtures[i].Table)
+			}
+		}
+		return nil, fmt.Errorf("foreign keys between tables %s form a cycle", strings.Join(cycled, ", "))
+	}
+	return sorted, nil
+}
+
+type dialectQueries struct {
+	dialect     dbkit.Dialect
+	quote       func(identifier string) string
+	placeholder func(i int) string
+	references  string
+}
+
+func newDialectQueries(dialect dbkit.Dialect) (dialectQueries, error) {
+	doubleQuote := func(identifier string) string { return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"` }
+	questionMark := func(int) string { return "?" }
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		return dialectQueries{
+			dialect:     dialect,
+			quote:       doubleQuote,
+			placeholder: func(i int) string { return "$" + strconv.Itoa(i) },
+			references:  postgresReferencesQuery,
+		}, nil
+	case dbkit.DialectMySQL:
+		return dialectQueries{
+			dialect:     dialect,
+			quote:       func(identifier string) string { return "`" + strings.ReplaceAll(identifier, "`", "``") + "`" },
+			placeholder: questionMark,
+			references:  mySQLReferencesQuery,
+		}, nil
+	case dbkit.DialectSQLite:
+		return dialectQueries{
+			dialect:     dialect,
+			quote:       doubleQuote,
+			placeholder: questionMark,
+			references:  sqliteReferencesQuery,
+		}, nil
+	case dbkit.DialectMSSQL:
+		return dialectQueries{
+			dialect:     dialect,
+			quote:       func(identifier string) string { return "[" + strings.ReplaceAll(identifier, "]", "]]") + "]" },
+			placeholder: func(i int) string { return "@p" + strconv.Itoa(i) },
+			references:  mssqlReferencesQuery,
+		}, nil
+	default:
+		return dialectQueries{}, fmt.Errorf("unsupported dialect %q", dialect)
+	}
+}
+
+//nolint:lll
+const (
+	postgresReferencesQuery = `SELECT tc.table_name, ccu.table_name FROM information_schema.table_constraints tc JOIN information_schema.constraint_column_usage ccu ON tc.constraint_name = ccu.constraint_name AND tc.constraint_schema = ccu.constraint_schema WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema()`
+	mySQLReferencesQuery    = `SELECT table_name, referenced_table_name FROM information_schema.key_column_usage WHERE table_schema = DATABASE() AND referenced_table_name IS NOT NULL`
+	mssqlReferencesQuery    = `SELECT OBJECT_NAME(parent_object_id), OBJECT_NAME(referenced_object_id) FROM sys.foreign_keys`
+	// SQLite doesn't have the catalog of foreign keys, so they are read table by table.
+	sqliteReferencesQuery = `SELECT "table" FROM pragma_foreign_key_list(?)`
+)
+
+// readReferences returns the tables referenced by foreign keys of each table.
+func (q dialectQueries) readReferences(ctx context.Context, dbConn *sql.DB, tables []string) (map[string][]string, error) {
+	references := make(map[string][]string)
+	if q.dialect == dbkit.DialectSQLite {
+		for _, table := range tables {
+			referenced, err := queryStrings(ctx, dbConn, q.references, table)
+			if err != nil {
+				return nil, fmt.Errorf("read foreign keys of table %s: %w", table, err)
+			}
+			references[table] = referenced
+		}
+		return references, nil
+	}
+
+	rows, err := dbConn.QueryContext(ctx, q.references)
+	if err != nil {
+		return nil, fmt.Errorf("read foreign keys: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var table, referenced string
+		if err = rows.Scan(&table, &referenced); err != nil {
+			return nil, fmt.Errorf("scan foreign key: %w", err)
+		}
+		references[table] = append(references[table], referenced)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("read foreign keys: %w", err)
+	}
+	return references, nil
+}
+
+func (q dialectQueries) insertRows(ctx context.Context, tx *sql.Tx, fixture Fixture) (err error) {
+	table := q.quote(fixture.Table)
+	if q.dialect == dbkit.DialectMSSQL {
+		// Explicit values may be inserted into the identity column only with IDENTITY_INSERT enabled.
+		var identityInsert bool
+		if identityInsert, err = q.hasIdentityValues(ctx, tx, fixture); err != nil {
+			return err
+		}
+		if identityInsert {
+			if _, err = tx.ExecContext(ctx, "SET IDENTITY_INSERT "+table+" ON"); err != nil {
+				return fmt.Errorf("enable identity insert for table %s: %w", fixture.Table, err)
+			}
+			defer func() {
+				if _, offErr := tx.ExecContext(ctx, "SET IDENTITY_INSERT "+table+" OFF"); offErr != nil && err == nil {
+					err = fmt.Errorf("disable identity insert for table %s: %w", fixture.Table, offErr)
+				}
+			}()
+		}
+	}
+
+	for i, row := range fixture.Rows {
+		columns := make([]string, 0, len(row))
+		for column := range row {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		quotedColumns := make([]string, 0, len(columns))
+		placeholders := make([]string, 0, len(columns))
+		args := make([]interface{}, 0, len(columns))
+		for j, column := range columns {
+			quotedColumns = append(quotedColumns, q.quote(column))
+			placeholders = append(placeholders, q.placeholder(j+1))
+			args = append(args, row[column])
+		}
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+			table, strings.Join(quotedColumns, ", "), strings.Join(placeholders, ", "))
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("insert row #%d into table %s: %w", i+1, fixture.Table, err)
+		}
+	}
+	return nil
+}
+
+// hasIdentityValues reports whether rows of the MSSQL fixture contain values of the identity column.
+func (q dialectQueries) hasIdentityValues(ctx context.Context, tx *sql.Tx, fixture Fixture) (bool, error) {
+	identityColumns, err := queryStrings(ctx, tx,
+		"SELECT name FROM sys.identity_columns WHERE object_id = OBJECT_ID(@p1)", fixture.Table)
+	if err != nil {
+		return false, fmt.Errorf("read identity column of table %s: %w", fixture.Table, err)
+	}
+	for _, column := range identityColumns {
+		for _, row := range fixture.Rows {
+			if _, ok := row[column]; ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+type sqlQueryExecutor interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func queryStrings(ctx context.Context, executor sqlQueryExecutor, query string, args ...interface{}) ([]string, error) {
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var result []string
+	for rows.Next() {
+		var s string
+		if err = rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,11 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package gormutil provides helpers for working with SQL databases via GORM (https://gorm.io).
+// It allows opening *gorm.DB from dbkit.Config with any GORM dialector, collecting metrics about executed statements
+// with MetricsPlugin (annotated by the operation and the table of the statement model), and executing retryable
+// transactions where retryable errors are determined by the dbkit registry.
+package gormutil
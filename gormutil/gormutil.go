@@ -0,0 +1,120 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package gormutil
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/acronis/go-appkit/retry"
+	"github.com/cenkalti/backoff/v4"
+	"gorm.io/gorm"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// DialectorFactory creates gorm.Dialector that uses the already opened connection pool
+// (e.g., postgres.New(postgres.Config{Conn: conn}) or sqlite.New(sqlite.Config{Conn: conn})).
+type DialectorFactory func(conn gorm.ConnPool) gorm.Dialector
+
+// Open opens database (using dbkit.Open) with specified configuration parameters, verifies (if ping argument is true)
+// that connection can be established and wraps it into *gorm.DB with the dialector created by newDialector.
+// Nil gormCfg means the default GORM configuration.
+func Open(
+	cfg *dbkit.Config, ping bool, newDialector DialectorFactory, gormCfg *gorm.Config, options ...dbkit.OpenOption,
+) (*gorm.DB, error) {
+	sqlDB, err := dbkit.Open(cfg, ping, options...)
+	if err != nil {
+		return nil, err
+	}
+	if gormCfg == nil {
+		gormCfg = &gorm.Config{}
+	}
+	db, err := gorm.Open(newDialector(sqlDB), gormCfg)
+	if err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// GetIsRetryable returns a function that can tell if the error returned by GORM is retryable
+// for the driver of the database (see dbkit.GetIsRetryableForDB).
+func GetIsRetryable(db *gorm.DB) (retry.IsRetryable, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	return dbkit.GetIsRetryableForDB(sqlDB), nil
+}
+
+type doInTxOptions struct {
+	txOpts      *sql.TxOptions
+	retryPolicy retry.Policy
+	txMetrics   dbkit.TxMetricsCollector
+}
+
+// DoInTxOption is a functional option for DoInTx.
+type DoInTxOption func(*doInTxOptions)
+
+// WithTxOptions sets transaction options for DoInTx.
+func WithTxOptions(txOpts *sql.TxOptions) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.txOpts = txOpts
+	}
+}
+
+// WithRetryPolicy sets retry policy for DoInTx. Whether the error is retryable is determined by GetIsRetryable.
+func WithRetryPolicy(policy retry.Policy) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.retryPolicy = policy
+	}
+}
+
+// WithTxMetrics sets the collector (e.g., dbkit.PrometheusMetrics) that is used by DoInTx to observe the duration
+// of each attempt of the transaction and to count commits, rollbacks and retries.
+// Metrics are labeled by the transaction name stored in the context (see dbkit.ContextWithTxName).
+func WithTxMetrics(collector dbkit.TxMetricsCollector) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.txMetrics = collector
+	}
+}
+
+// DoInTx executes the function in a transaction (see gorm.DB.Transaction) bound to ctx.
+// If the retry policy is set (see WithRetryPolicy), the whole transaction is retried on retryable errors.
+func DoInTx(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error, options ...DoInTxOption) error {
+	var opts doInTxOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.retryPolicy == nil {
+		return doInTx(ctx, db, fn, opts)
+	}
+	isRetryable, err := GetIsRetryable(db)
+	if err != nil {
+		return err
+	}
+	var notify backoff.Notify
+	if opts.txMetrics != nil {
+		notify = func(err error, d time.Duration) {
+			opts.txMetrics.IncTxRetries(dbkit.TxNameFromContext(ctx))
+		}
+	}
+	return retry.DoWithRetry(ctx, opts.retryPolicy, isRetryable, notify, func(ctx context.Context) error {
+		return doInTx(ctx, db, fn, opts)
+	})
+}
+
+func doInTx(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error, opts doInTxOptions) error {
+	startedAt := time.Now()
+	err := db.WithContext(ctx).Transaction(fn, opts.txOpts)
+	if opts.txMetrics != nil {
+		dbkit.ObserveTx(opts.txMetrics, dbkit.TxNameFromContext(ctx), startedAt, err == nil)
+	}
+	return err
+}
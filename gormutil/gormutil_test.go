@@ -0,0 +1,124 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package gormutil
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/retry"
+	"github.com/acronis/go-appkit/testutil"
+	"github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/acronis/go-dbkit"
+	_ "github.com/acronis/go-dbkit/sqlite"
+)
+
+type User struct {
+	ID   int64
+	Name string
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := &dbkit.Config{
+		Dialect:      dbkit.DialectSQLite,
+		SQLite:       dbkit.SQLiteConfig{Path: filepath.Join(t.TempDir(), "gormutil.db")},
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	}
+	db, err := Open(cfg, true, func(conn gorm.ConnPool) gorm.Dialector {
+		return sqlite.New(sqlite.Config{Conn: conn})
+	}, &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, dbErr := db.DB(); dbErr == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	require.NoError(t, db.AutoMigrate(&User{}))
+	return db
+}
+
+func TestMetricsPlugin(t *testing.T) {
+	db := openTestDB(t)
+	metrics := dbkit.NewPrometheusMetrics()
+	require.NoError(t, db.Use(NewMetricsPlugin(metrics, "query_")))
+
+	require.NoError(t, db.Create(&User{Name: "Alice"}).Error)
+	var user User
+	require.NoError(t, db.First(&user, "name = ?", "Alice").Error)
+	require.NoError(t, db.Model(&user).Update("name", "Alex").Error)
+	require.ErrorIs(t, db.First(&User{}, "name = ?", "Bob").Error, gorm.ErrRecordNotFound)
+	ctx := dbkit.WithQueryName(context.Background(), "count_users")
+	var count int64
+	require.NoError(t, db.WithContext(ctx).Model(&User{}).Count(&count).Error)
+	require.Equal(t, int64(1), count)
+	require.NoError(t, db.Exec("DELETE FROM users WHERE name = ?", "Bob").Error) // Raw SQL without the name is not observed.
+	require.Error(t, db.Table("unknown").Create(map[string]interface{}{"name": "Bob"}).Error)
+	require.NoError(t, db.Delete(&user).Error)
+
+	for query, want := range map[string]int{
+		"query_insert_users": 1, "query_select_users": 2, "query_update_users": 1, "query_delete_users": 1,
+		"query_count_users": 1, "query_insert_unknown": 1,
+	} {
+		hist := metrics.QueryDurations.With(prometheus.Labels{dbkit.PrometheusMetricsLabelQuery: query}).(prometheus.Histogram)
+		testutil.RequireSamplesCountInHistogram(t, hist, want)
+	}
+	errorLabels := func(query string) prometheus.Labels {
+		return prometheus.Labels{
+			dbkit.PrometheusMetricsLabelQuery: query, dbkit.PrometheusMetricsLabelErrorClass: string(dbkit.QueryErrorClassOther)}
+	}
+	testutil.RequireSamplesCountInCounter(t, metrics.QueryErrors.With(errorLabels("query_insert_unknown")), 1)
+	testutil.RequireSamplesCountInCounter(t, metrics.QueryErrors.With(errorLabels("query_select_users")), 0)
+}
+
+func TestDoInTx(t *testing.T) {
+	db := openTestDB(t)
+	metrics := dbkit.NewPrometheusMetrics()
+	ctx := dbkit.ContextWithTxName(context.Background(), "create_user")
+
+	insertErr := errors.New("insert error")
+	require.ErrorIs(t, DoInTx(ctx, db, func(tx *gorm.DB) error {
+		if err := tx.Create(&User{Name: "Alice"}).Error; err != nil {
+			return err
+		}
+		return insertErr
+	}, WithTxMetrics(metrics)), insertErr)
+
+	attempts := 0
+	require.NoError(t, DoInTx(ctx, db, func(tx *gorm.DB) error {
+		attempts++
+		if attempts == 1 {
+			return sqlite3.Error{Code: sqlite3.ErrBusy}
+		}
+		return tx.Create(&User{Name: "Bob"}).Error
+	}, WithTxMetrics(metrics), WithRetryPolicy(retry.NewConstantBackoffPolicy(time.Millisecond, 2))))
+	require.Equal(t, 2, attempts)
+
+	var names []string
+	require.NoError(t, db.Model(&User{}).Pluck("name", &names).Error)
+	require.Equal(t, []string{"Bob"}, names)
+
+	labels := prometheus.Labels{dbkit.PrometheusMetricsLabelTx: "create_user"}
+	testutil.RequireSamplesCountInCounter(t, metrics.TxCommits.With(labels), 1)
+	testutil.RequireSamplesCountInCounter(t, metrics.TxRollbacks.With(labels), 2)
+	testutil.RequireSamplesCountInCounter(t, metrics.TxRetries.With(labels), 1)
+
+	isRetryable, err := GetIsRetryable(db)
+	require.NoError(t, err)
+	require.True(t, isRetryable(sqlite3.Error{Code: sqlite3.ErrLocked}))
+	require.False(t, isRetryable(insertErr))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package gormutil
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/dbrutil"
+)
+
+// Operations that are used in annotations of GORM statements besides dbrutil.AnnotationOperation* ones.
+const (
+	AnnotationOperationRow = "row"
+	AnnotationOperationRaw = "raw"
+)
+
+const metricsPluginStartedAtKey = "dbkit:metrics_started_at"
+
+// MetricsPlugin is a GORM plugin that collects metrics about executed statements.
+// Statements are annotated in the same format as dbrutil.AnnotatingSessionRunner does (<prefix><operation>_<table>,
+// e.g. "query_select_users"), the table is taken from the statement model. If the context of the statement contains
+// the query name (see dbkit.WithQueryName), it's used instead (<prefix><name>).
+// Statements without the table and the query name (e.g., raw SQL) are not observed.
+// Besides durations, errors are counted by class if the collector implements dbkit.ErrorMetricsCollector
+// (gorm.ErrRecordNotFound is not counted).
+type MetricsPlugin struct {
+	collector        dbkit.QueryMetricsCollector
+	annotationPrefix string
+}
+
+var _ gorm.Plugin = (*MetricsPlugin)(nil)
+
+// NewMetricsPlugin creates a new MetricsPlugin. It should be registered with gorm.DB.Use.
+func NewMetricsPlugin(collector dbkit.QueryMetricsCollector, annotationPrefix string) *MetricsPlugin {
+	return &MetricsPlugin{collector: collector, annotationPrefix: annotationPrefix}
+}
+
+// Name returns the name of the plugin.
+func (p *MetricsPlugin) Name() string {
+	return "dbkit:metrics"
+}
+
+// Initialize registers callbacks of the plugin.
+func (p *MetricsPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	var errs []error
+	register := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	register(cb.Create().Before("gorm:create").Register("dbkit:metrics_before_create", p.before))
+	register(cb.Create().After("gorm:create").Register("dbkit:metrics_after_create", p.after(dbrutil.AnnotationOperationInsert)))
+	register(cb.Query().Before("gorm:query").Register("dbkit:metrics_before_query", p.before))
+	register(cb.Query().After("gorm:query").Register("dbkit:metrics_after_query", p.after(dbrutil.AnnotationOperationSelect)))
+	register(cb.Update().Before("gorm:update").Register("dbkit:metrics_before_update", p.before))
+	register(cb.Update().After("gorm:update").Register("dbkit:metrics_after_update", p.after(dbrutil.AnnotationOperationUpdate)))
+	register(cb.Delete().Before("gorm:delete").Register("dbkit:metrics_before_delete", p.before))
+	register(cb.Delete().After("gorm:delete").Register("dbkit:metrics_after_delete", p.after(dbrutil.AnnotationOperationDelete)))
+	register(cb.Row().Before("gorm:row").Register("dbkit:metrics_before_row", p.before))
+	register(cb.Row().After("gorm:row").Register("dbkit:metrics_after_row", p.after(AnnotationOperationRow)))
+	register(cb.Raw().Before("gorm:raw").Register("dbkit:metrics_before_raw", p.before))
+	register(cb.Raw().After("gorm:raw").Register("dbkit:metrics_after_raw", p.after(AnnotationOperationRaw)))
+	return errors.Join(errs...)
+}
+
+func (p *MetricsPlugin) before(db *gorm.DB) {
+	db.InstanceSet(metricsPluginStartedAtKey, time.Now())
+}
+
+func (p *MetricsPlugin) after(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(metricsPluginStartedAtKey)
+		if !ok {
+			return
+		}
+		startedAt, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		annotation := p.annotation(db.Statement, operation)
+		if annotation == "" {
+			return
+		}
+		p.collector.ObserveQueryDuration(annotation, time.Since(startedAt))
+		if db.Error == nil || errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return
+		}
+		if errCollector, ok := p.collector.(dbkit.ErrorMetricsCollector); ok {
+			errCollector.ObserveQueryError(annotation, db.Error)
+		}
+	}
+}
+
+func (p *MetricsPlugin) annotation(stmt *gorm.Statement, operation string) string {
+	if stmt.Context != nil {
+		if name := dbkit.QueryNameFromContext(stmt.Context); name != "" {
+			return p.annotationPrefix + name
+		}
+	}
+	if stmt.Table == "" {
+		return ""
+	}
+	return dbrutil.MakeAnnotation(p.annotationPrefix, operation, stmt.Table)
+}
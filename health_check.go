@@ -0,0 +1,148 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/acronis/go-appkit/httpserver"
+)
+
+// Default values for HealthCheckerOpts.
+const (
+	DefaultHealthCheckComponentName = "db"
+	DefaultHealthCheckTimeout       = 5 * time.Second
+	DefaultHealthCheckCacheTTL      = time.Second
+)
+
+// HealthCheckerOpts contains options for HealthChecker.
+type HealthCheckerOpts struct {
+	// ComponentName is the name of the primary database component in the health-check result.
+	// DefaultHealthCheckComponentName is used if it's not set.
+	ComponentName string
+	// ProbeQuery is an optional query (e.g., "SELECT 1" or a query to a critical table)
+	// that is executed after the successful ping.
+	ProbeQuery string
+	// Timeout is the timeout of the check of each database. DefaultHealthCheckTimeout is used if it's not set.
+	Timeout time.Duration
+	// CacheTTL is the interval during which the result of the last check is returned without querying databases,
+	// so frequent health-check requests don't load them. DefaultHealthCheckCacheTTL is used if it's not set.
+	// Negative value disables caching.
+	CacheTTL time.Duration
+	// Replicas are additional databases (e.g., read replicas used by the cluster router)
+	// that are checked the same way. Keys are component names.
+	Replicas map[string]*sql.DB
+	// OnCheckFailed is called when the check of the component fails (e.g., for logging).
+	// It may be called concurrently for different components.
+	OnCheckFailed func(componentName string, err error)
+}
+
+// HealthChecker checks the health of the database (and its replicas) with PingContext and optional probe query.
+// Its Check method is compatible with go-appkit's health-check handler:
+//
+//	handler := httpserver.NewHealthCheckHandlerContext(dbkit.NewHealthChecker(dbConn, opts).Check)
+type HealthChecker struct {
+	dbs  map[string]*sql.DB
+	opts HealthCheckerOpts
+
+	mu        sync.Mutex
+	result    httpserver.HealthCheckResult
+	checkedAt time.Time
+}
+
+// NewHealthChecker creates a new HealthChecker.
+func NewHealthChecker(db *sql.DB, opts HealthCheckerOpts) *HealthChecker {
+	if opts.ComponentName == "" {
+		opts.ComponentName = DefaultHealthCheckComponentName
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultHealthCheckTimeout
+	}
+	if opts.CacheTTL == 0 {
+		opts.CacheTTL = DefaultHealthCheckCacheTTL
+	}
+	dbs := map[string]*sql.DB{opts.ComponentName: db}
+	for name, replica := range opts.Replicas {
+		dbs[name] = replica
+	}
+	return &HealthChecker{dbs: dbs, opts: opts}
+}
+
+// Check checks the health of all databases concurrently and returns the status of each of them.
+// The result of the last check is returned if it's not older than CacheTTL.
+// The error is returned only if ctx is done.
+func (h *HealthChecker) Check(ctx context.Context) (httpserver.HealthCheckResult, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.result != nil && time.Since(h.checkedAt) < h.opts.CacheTTL {
+		return copyHealthCheckResult(h.result), nil
+	}
+
+	result := make(httpserver.HealthCheckResult, len(h.dbs))
+	var resultMu sync.Mutex
+	var wg sync.WaitGroup
+	for name, db := range h.dbs {
+		wg.Add(1)
+		go func(name string, db *sql.DB) {
+			defer wg.Done()
+			status := httpserver.HealthCheckStatusOK
+			if err := h.checkDB(ctx, db); err != nil {
+				status = httpserver.HealthCheckStatusFail
+				if h.opts.OnCheckFailed != nil && ctx.Err() == nil {
+					h.opts.OnCheckFailed(name, err)
+				}
+			}
+			resultMu.Lock()
+			result[name] = status
+			resultMu.Unlock()
+		}(name, db)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err // Don't cache the result since failures may be caused by the canceled context.
+	}
+	h.result = result
+	h.checkedAt = time.Now()
+	return copyHealthCheckResult(result), nil
+}
+
+func (h *HealthChecker) checkDB(ctx context.Context, db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(ctx, h.opts.Timeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping: %w", err)
+	}
+	if h.opts.ProbeQuery == "" {
+		return nil
+	}
+	rows, err := db.QueryContext(ctx, h.opts.ProbeQuery)
+	if err != nil {
+		return fmt.Errorf("probe query: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		// Rows are drained to make sure the whole result is received.
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("probe query: %w", err)
+	}
+	return nil
+}
+
+func copyHealthCheckResult(result httpserver.HealthCheckResult) httpserver.HealthCheckResult {
+	resultCopy := make(httpserver.HealthCheckResult, len(result))
+	for name, status := range result {
+		resultCopy[name] = status
+	}
+	return resultCopy
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/acronis/go-appkit/httpserver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthChecker(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer func() { _ = primary.Close() }()
+	replica, replicaMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer func() { _ = replica.Close() }()
+
+	var failedComponents []string
+	checker := NewHealthChecker(primary, HealthCheckerOpts{
+		ProbeQuery: "SELECT 1",
+		CacheTTL:   time.Millisecond * 100,
+		Replicas:   map[string]*sql.DB{"db_replica": replica},
+		OnCheckFailed: func(componentName string, err error) {
+			failedComponents = append(failedComponents, componentName)
+		},
+	})
+
+	primaryMock.ExpectPing()
+	primaryMock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	replicaMock.ExpectPing()
+	replicaMock.ExpectQuery("SELECT 1").WillReturnError(errors.New("replica is not ready"))
+
+	wantResult := httpserver.HealthCheckResult{
+		DefaultHealthCheckComponentName: httpserver.HealthCheckStatusOK,
+		"db_replica":                    httpserver.HealthCheckStatusFail,
+	}
+	result, err := checker.Check(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, wantResult, result)
+	require.Equal(t, []string{"db_replica"}, failedComponents)
+
+	// Cached result is returned without querying databases.
+	result, err = checker.Check(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, wantResult, result)
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+
+	// Result is refreshed after the cache TTL.
+	time.Sleep(time.Millisecond * 100)
+	primaryMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	replicaMock.ExpectPing()
+	replicaMock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	result, err = checker.Check(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, httpserver.HealthCheckResult{
+		DefaultHealthCheckComponentName: httpserver.HealthCheckStatusFail,
+		"db_replica":                    httpserver.HealthCheckStatusOK,
+	}, result)
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+
+	// Canceled context.
+	checker = NewHealthChecker(primary, HealthCheckerOpts{CacheTTL: -1})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = checker.Check(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
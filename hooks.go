@@ -0,0 +1,398 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/acronis/go-dbkit/internal/driverwrap"
+)
+
+// QueryEvent describes the SQL statement passed to Hooks.
+type QueryEvent struct {
+	// Query is the text of the statement. BeforeQuery may modify it (e.g., prepend a comment with the tenant ID)
+	// unless the statement is prepared (Prepared is true), since the prepared statement can't be changed.
+	Query string
+	Args  []driver.NamedValue
+
+	// Exec is true for statements that don't return rows (see sql.DB.ExecContext).
+	Exec bool
+
+	// Prepared is true if the statement is executed via the prepared statement (see sql.DB.PrepareContext).
+	Prepared bool
+
+	// StartTime is the time when the execution of the statement is started (after BeforeQuery is called).
+	StartTime time.Time
+
+	// The fields below are set before AfterQuery is called.
+
+	// Err is the error of the statement or of reading its result set.
+	Err error
+
+	// RowsAffected is the number of rows affected by Exec statements (-1 if it's unknown).
+	RowsAffected int64
+
+	// RowsReturned is the number of rows read from the result set of queries.
+	RowsReturned int
+}
+
+// TxAction is the action that finishes the transaction (see TxEvent).
+type TxAction string
+
+// Transaction actions.
+const (
+	TxActionBegin    TxAction = "begin" // The transaction failed to begin.
+	TxActionCommit   TxAction = "commit"
+	TxActionRollback TxAction = "rollback"
+)
+
+// TxEvent describes the transaction passed to Hooks.
+type TxEvent struct {
+	Options driver.TxOptions
+
+	// StartTime is the time when the transaction is started (after BeforeTx is called).
+	StartTime time.Time
+
+	// The fields below are set before AfterTx is called.
+
+	// Action is the action that finished the transaction.
+	Action TxAction
+
+	// Err is the error of the action.
+	Err error
+}
+
+// Hooks is an interface for attaching custom cross-cutting behavior (tracing, auditing, tenant tagging, etc.)
+// to SQL statements and transactions of any driver (see WrapDriver, NewHooksConnector and OpenWithHooks).
+// The context returned by BeforeQuery (BeforeTx) is passed to the driver and then to AfterQuery (AfterTx),
+// so, for example, a span may be started in the former and finished in the latter.
+// AfterQuery of queries is called when their rows are closed, so it covers reading the result set as well.
+// AfterTx is called when the transaction is committed or rolled back, or when it fails to begin.
+// NoOpHooks may be embedded for implementing only some of the methods.
+type Hooks interface {
+	BeforeQuery(ctx context.Context, event *QueryEvent) context.Context
+	AfterQuery(ctx context.Context, event *QueryEvent)
+	BeforeTx(ctx context.Context, event *TxEvent) context.Context
+	AfterTx(ctx context.Context, event *TxEvent)
+}
+
+// NoOpHooks is a Hooks implementation that does nothing.
+type NoOpHooks struct{}
+
+var _ Hooks = NoOpHooks{}
+
+// BeforeQuery does nothing.
+func (NoOpHooks) BeforeQuery(ctx context.Context, _ *QueryEvent) context.Context { return ctx }
+
+// AfterQuery does nothing.
+func (NoOpHooks) AfterQuery(context.Context, *QueryEvent) {}
+
+// BeforeTx does nothing.
+func (NoOpHooks) BeforeTx(ctx context.Context, _ *TxEvent) context.Context { return ctx }
+
+// AfterTx does nothing.
+func (NoOpHooks) AfterTx(context.Context, *TxEvent) {}
+
+type chainedHooks []Hooks
+
+// ChainHooks combines several hooks into one.
+// Before* methods are called in the order of hooks, and After* methods are called in the reverse order.
+func ChainHooks(hooks ...Hooks) Hooks {
+	return chainedHooks(hooks)
+}
+
+func (h chainedHooks) BeforeQuery(ctx context.Context, event *QueryEvent) context.Context {
+	for _, hooks := range h {
+		ctx = hooks.BeforeQuery(ctx, event)
+	}
+	return ctx
+}
+
+func (h chainedHooks) AfterQuery(ctx context.Context, event *QueryEvent) {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i].AfterQuery(ctx, event)
+	}
+}
+
+func (h chainedHooks) BeforeTx(ctx context.Context, event *TxEvent) context.Context {
+	for _, hooks := range h {
+		ctx = hooks.BeforeTx(ctx, event)
+	}
+	return ctx
+}
+
+func (h chainedHooks) AfterTx(ctx context.Context, event *TxEvent) {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i].AfterTx(ctx, event)
+	}
+}
+
+// WrapDriver wraps the driver with calling the hooks for statements and transactions of its connections.
+// The returned driver may be registered with sql.Register under a new name.
+// Connectors opened by it (see driver.DriverContext) return the original driver from their Driver method,
+// so retryable errors of the driver are still recognized (see RetryableRegistry).
+func WrapDriver(drv driver.Driver, hooks Hooks) driver.Driver {
+	return hooksDriver{driver: drv, hooks: hooks}
+}
+
+type hooksDriver struct {
+	driver driver.Driver
+	hooks  Hooks
+}
+
+var (
+	_ driver.Driver        = hooksDriver{}
+	_ driver.DriverContext = hooksDriver{}
+)
+
+func (d hooksDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &hooksConn{Conn: driverwrap.Conn{Conn: conn}, hooks: d.hooks}, nil
+}
+
+func (d hooksDriver) OpenConnector(name string) (driver.Connector, error) {
+	if driverCtx, ok := d.driver.(driver.DriverContext); ok {
+		connector, err := driverCtx.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return NewHooksConnector(connector, d.hooks), nil
+	}
+	return NewHooksConnector(dsnConnector{dsn: name, driver: d.driver}, d.hooks), nil
+}
+
+// HooksConnector implements driver.Connector and calls the hooks for statements and transactions of its connections.
+type HooksConnector struct {
+	connector driver.Connector
+	hooks     Hooks
+}
+
+var _ driver.Connector = (*HooksConnector)(nil)
+
+// NewHooksConnector wraps the passed connector with calling the hooks (see Hooks).
+func NewHooksConnector(connector driver.Connector, hooks Hooks) *HooksConnector {
+	return &HooksConnector{connector: connector, hooks: hooks}
+}
+
+// OpenWithHooks opens a new database connection pool using the provided configuration (see Open)
+// with calling the hooks for statements and transactions (see Hooks).
+func OpenWithHooks(cfg *Config, ping bool, hooks Hooks) (*sql.DB, error) {
+	connector, err := OpenConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(NewHooksConnector(connector, hooks))
+	return db, InitOpenedDB(db, cfg, ping)
+}
+
+// Connect establishes a new connection.
+func (c *HooksConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &hooksConn{Conn: driverwrap.Conn{Conn: conn}, hooks: c.hooks}, nil
+}
+
+// Driver returns the underlying driver.
+func (c *HooksConnector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+// Unwrap returns the wrapped connector.
+func (c *HooksConnector) Unwrap() driver.Connector {
+	return c.connector
+}
+
+// hooksConn wraps driver.Conn and calls the hooks for statements executed directly or via prepared statements
+// and for transactions.
+// If the driver can't execute a statement directly (driver.ErrSkip is returned), it's prepared and executed here,
+// as database/sql would do, so the hooks are called once for it.
+type hooksConn struct {
+	driverwrap.Conn
+	hooks Hooks
+}
+
+func (c *hooksConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &hooksStmt{Stmt: driverwrap.Stmt{Stmt: stmt, Conn: c.Conn}, hooks: c.hooks, query: query}, nil
+}
+
+func (c *hooksConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &hooksStmt{Stmt: driverwrap.Stmt{Stmt: stmt, Conn: c.Conn}, hooks: c.hooks, query: query}, nil
+}
+
+func (c *hooksConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	event := &TxEvent{Options: opts}
+	ctx = c.hooks.BeforeTx(ctx, event)
+	event.StartTime = time.Now()
+	tx, err := c.Conn.BeginTx(ctx, opts)
+	if err != nil {
+		event.Action, event.Err = TxActionBegin, err
+		c.hooks.AfterTx(ctx, event)
+		return nil, err
+	}
+	return &hooksTx{tx: tx, ctx: ctx, hooks: c.hooks, event: event}, nil
+}
+
+func (c *hooksConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	event := &QueryEvent{Query: query, Args: args, RowsAffected: -1}
+	ctx = c.hooks.BeforeQuery(ctx, event)
+	event.StartTime = time.Now()
+	rows, err := c.Conn.QueryContext(ctx, event.Query, args)
+	var stmt driver.Stmt
+	if errors.Is(err, driver.ErrSkip) {
+		if stmt, err = c.Conn.PrepareContext(ctx, event.Query); err == nil {
+			if rows, err = (driverwrap.Stmt{Stmt: stmt, Conn: c.Conn}).QueryContext(ctx, args); err != nil {
+				_ = stmt.Close()
+			}
+		}
+	}
+	if err != nil {
+		event.Err = err
+		c.hooks.AfterQuery(ctx, event)
+		return nil, err
+	}
+	return &hooksRows{Rows: driverwrap.Rows{Rows: rows}, ctx: ctx, hooks: c.hooks, event: event, stmt: stmt}, nil
+}
+
+func (c *hooksConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	event := &QueryEvent{Query: query, Args: args, Exec: true, RowsAffected: -1}
+	ctx = c.hooks.BeforeQuery(ctx, event)
+	event.StartTime = time.Now()
+	result, err := c.Conn.ExecContext(ctx, event.Query, args)
+	if errors.Is(err, driver.ErrSkip) {
+		var stmt driver.Stmt
+		if stmt, err = c.Conn.PrepareContext(ctx, event.Query); err == nil {
+			result, err = (driverwrap.Stmt{Stmt: stmt, Conn: c.Conn}).ExecContext(ctx, args)
+			if closeErr := stmt.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+	}
+	afterExec(ctx, c.hooks, event, result, err)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func afterExec(ctx context.Context, hooks Hooks, event *QueryEvent, result driver.Result, err error) {
+	if err != nil {
+		event.Err = err
+	} else if affected, affectedErr := result.RowsAffected(); affectedErr == nil {
+		event.RowsAffected = affected
+	}
+	hooks.AfterQuery(ctx, event)
+}
+
+type hooksTx struct {
+	tx    driver.Tx
+	ctx   context.Context
+	hooks Hooks
+	event *TxEvent
+}
+
+func (t *hooksTx) Commit() error {
+	err := t.tx.Commit()
+	t.event.Action, t.event.Err = TxActionCommit, err
+	t.hooks.AfterTx(t.ctx, t.event)
+	return err
+}
+
+func (t *hooksTx) Rollback() error {
+	err := t.tx.Rollback()
+	t.event.Action, t.event.Err = TxActionRollback, err
+	t.hooks.AfterTx(t.ctx, t.event)
+	return err
+}
+
+type hooksStmt struct {
+	driverwrap.Stmt
+	hooks Hooks
+	query string
+}
+
+func (s *hooksStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	event := &QueryEvent{Query: s.query, Args: args, Exec: true, Prepared: true, RowsAffected: -1}
+	ctx = s.hooks.BeforeQuery(ctx, event)
+	event.StartTime = time.Now()
+	result, err := s.Stmt.ExecContext(ctx, args)
+	afterExec(ctx, s.hooks, event, result, err)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *hooksStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	event := &QueryEvent{Query: s.query, Args: args, Prepared: true, RowsAffected: -1}
+	ctx = s.hooks.BeforeQuery(ctx, event)
+	event.StartTime = time.Now()
+	rows, err := s.Stmt.QueryContext(ctx, args)
+	if err != nil {
+		event.Err = err
+		s.hooks.AfterQuery(ctx, event)
+		return nil, err
+	}
+	return &hooksRows{Rows: driverwrap.Rows{Rows: rows}, ctx: ctx, hooks: s.hooks, event: event}, nil
+}
+
+// hooksRows counts rows of the result set and calls AfterQuery when it's closed.
+// If the query was prepared by hooksConn, the statement is closed along with the rows.
+type hooksRows struct {
+	driverwrap.Rows
+	ctx    context.Context
+	hooks  Hooks
+	event  *QueryEvent
+	stmt   driver.Stmt
+	closed bool
+}
+
+func (r *hooksRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.event.RowsReturned++
+	case !errors.Is(err, io.EOF):
+		r.event.Err = err
+	}
+	return err
+}
+
+func (r *hooksRows) Close() error {
+	if r.closed {
+		return r.Rows.Close()
+	}
+	r.closed = true
+	err := r.Rows.Close()
+	if r.stmt != nil {
+		if closeErr := r.stmt.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	if r.event.Err == nil {
+		r.event.Err = err
+	}
+	r.hooks.AfterQuery(r.ctx, r.event)
+	return err
+}
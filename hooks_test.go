@@ -0,0 +1,135 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+type hooksCtxKey struct{}
+
+// recordingHooks records events and prepends the comment with the tenant to not prepared statements.
+type recordingHooks struct {
+	name    string
+	calls   *[]string
+	queries []QueryEvent
+	txs     []TxEvent
+}
+
+func (h *recordingHooks) BeforeQuery(ctx context.Context, event *QueryEvent) context.Context {
+	*h.calls = append(*h.calls, h.name+".BeforeQuery")
+	if tenant, ok := ctx.Value(hooksCtxKey{}).(string); ok && !event.Prepared {
+		event.Query = fmt.Sprintf("/* tenant:%s */ %s", tenant, event.Query)
+	}
+	return context.WithValue(ctx, hooksCtxKey{}, h.name)
+}
+
+func (h *recordingHooks) AfterQuery(ctx context.Context, event *QueryEvent) {
+	*h.calls = append(*h.calls, h.name+".AfterQuery:"+ctx.Value(hooksCtxKey{}).(string))
+	h.queries = append(h.queries, *event)
+}
+
+func (h *recordingHooks) BeforeTx(ctx context.Context, _ *TxEvent) context.Context {
+	*h.calls = append(*h.calls, h.name+".BeforeTx")
+	return context.WithValue(ctx, hooksCtxKey{}, h.name)
+}
+
+func (h *recordingHooks) AfterTx(ctx context.Context, event *TxEvent) {
+	*h.calls = append(*h.calls, h.name+".AfterTx:"+ctx.Value(hooksCtxKey{}).(string))
+	h.txs = append(h.txs, *event)
+}
+
+func TestOpenWithHooks(t *testing.T) {
+	cfg := &Config{Dialect: DialectSQLite, SQLite: SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")}}
+	var calls []string
+	hooks := &recordingHooks{name: "h", calls: &calls}
+	db, err := OpenWithHooks(cfg, true, hooks)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	ctx := context.WithValue(context.Background(), hooksCtxKey{}, "acme")
+	_, err = db.ExecContext(ctx, `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE)`)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `INSERT INTO users (name) VALUES ('Alice'), ('Bob'), ('Sam')`)
+	require.NoError(t, err)
+	rows, err := db.QueryContext(ctx, `SELECT id FROM users WHERE id > ?`, 1)
+	require.NoError(t, err)
+	for rows.Next() {
+	}
+	require.NoError(t, rows.Close())
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	require.NoError(t, err)
+	stmt, err := tx.PrepareContext(ctx, `SELECT name FROM users WHERE id = ?`)
+	require.NoError(t, err)
+	var name string
+	require.NoError(t, stmt.QueryRowContext(ctx, 2).Scan(&name))
+	require.Equal(t, "Bob", name)
+	require.NoError(t, stmt.Close())
+	require.NoError(t, tx.Rollback())
+
+	tx, err = db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, `INSERT INTO users (name) VALUES ('Alice')`)
+	require.Error(t, err)
+	require.NoError(t, tx.Commit())
+
+	require.Len(t, hooks.queries, 5)
+	require.Equal(t, `/* tenant:acme */ INSERT INTO users (name) VALUES ('Alice'), ('Bob'), ('Sam')`, hooks.queries[1].Query)
+	require.True(t, hooks.queries[1].Exec)
+	require.EqualValues(t, 3, hooks.queries[1].RowsAffected)
+	require.Equal(t, `/* tenant:acme */ SELECT id FROM users WHERE id > ?`, hooks.queries[2].Query)
+	require.False(t, hooks.queries[2].Exec)
+	require.Equal(t, 2, hooks.queries[2].RowsReturned)
+	require.EqualValues(t, -1, hooks.queries[2].RowsAffected)
+	require.Equal(t, `SELECT name FROM users WHERE id = ?`, hooks.queries[3].Query)
+	require.True(t, hooks.queries[3].Prepared)
+	require.Equal(t, 1, hooks.queries[3].RowsReturned)
+	var sqliteErr sqlite3.Error
+	require.ErrorAs(t, hooks.queries[4].Err, &sqliteErr)
+	require.Equal(t, sqlite3.ErrConstraint, sqliteErr.Code)
+
+	require.Len(t, hooks.txs, 2)
+	require.True(t, hooks.txs[0].Options.ReadOnly)
+	require.Equal(t, TxActionRollback, hooks.txs[0].Action)
+	require.Equal(t, TxActionCommit, hooks.txs[1].Action)
+	require.NoError(t, hooks.txs[1].Err)
+	require.False(t, hooks.txs[1].StartTime.IsZero())
+}
+
+func TestWrapDriverWithChainedHooks(t *testing.T) {
+	var calls []string
+	first, second := &recordingHooks{name: "first", calls: &calls}, &recordingHooks{name: "second", calls: &calls}
+	driverName := "sqlite3-hooks-" + strings.ReplaceAll(t.Name(), "/", "-")
+	sql.Register(driverName, WrapDriver(&sqlite3.SQLiteDriver{}, ChainHooks(first, second, NoOpHooks{})))
+	db, err := sql.Open(driverName, "file:"+filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+	require.IsType(t, &sqlite3.SQLiteDriver{}, db.Driver())
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, `SELECT 1`)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	// The context returned by the last Before* method is passed to all After* methods.
+	require.Equal(t, []string{
+		"first.BeforeTx", "second.BeforeTx",
+		"first.BeforeQuery", "second.BeforeQuery", "second.AfterQuery:second", "first.AfterQuery:second",
+		"second.AfterTx:second", "first.AfterTx:second",
+	}, calls)
+}
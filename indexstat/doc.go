@@ -0,0 +1,12 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package indexstat provides a reporter that collects statistics about indexing debt
+// (unused indexes, tables with sequential-scan-heavy access patterns and indexes suggested by the database engine)
+// and logs them as a periodic digest.
+// PostgreSQL (pg_stat_user_indexes, pg_stat_user_tables), MySQL (performance_schema)
+// and MSSQL (sys.dm_db_index_usage_stats, sys.dm_db_missing_index_details) are supported now.
+package indexstat
@@ -0,0 +1,322 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package indexstat
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/acronis/go-appkit/log"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// Default values for the Reporter options.
+const (
+	DefaultLimit        = 20
+	DefaultMinSeqScans  = 1000
+	DefaultMinTableRows = 10000
+)
+
+// UnusedIndex represents an index that has never been used for scanning since statistics were reset.
+type UnusedIndex struct {
+	Schema    string
+	Table     string
+	Index     string
+	Scans     int64
+	SizeBytes int64
+}
+
+// SeqScanHeavyTable represents a table that is scanned sequentially more often than via indexes.
+type SeqScanHeavyTable struct {
+	Schema     string
+	Table      string
+	SeqScans   int64
+	IndexScans int64
+	LiveRows   int64
+}
+
+// MissingIndex represents an index suggested by the database engine.
+type MissingIndex struct {
+	Table             string
+	EqualityColumns   string
+	InequalityColumns string
+	IncludedColumns   string
+	UserSeeks         int64
+	AvgUserImpact     float64
+}
+
+// Digest contains all collected statistics about indexing debt.
+type Digest struct {
+	CollectedAt        time.Time
+	UnusedIndexes      []UnusedIndex
+	SeqScanHeavyTables []SeqScanHeavyTable
+	MissingIndexes     []MissingIndex
+}
+
+// Empty returns true if the digest doesn't contain any findings.
+func (d *Digest) Empty() bool {
+	return len(d.UnusedIndexes) == 0 && len(d.SeqScanHeavyTables) == 0 && len(d.MissingIndexes) == 0
+}
+
+// Reporter collects index usage statistics and logs them as a digest.
+type Reporter struct {
+	dbConn  *sql.DB
+	logger  log.FieldLogger
+	queries dbQueries
+	opts    reporterOptions
+}
+
+type reporterOptions struct {
+	limit        int
+	minSeqScans  int64
+	minTableRows int64
+}
+
+// ReporterOption is an option for NewReporter.
+type ReporterOption func(*reporterOptions)
+
+// WithLimit sets the maximum number of entries of each kind in the digest.
+func WithLimit(limit int) ReporterOption {
+	return func(o *reporterOptions) {
+		o.limit = limit
+	}
+}
+
+// WithMinSeqScans sets the minimum number of sequential scans for the table to be reported as sequential-scan-heavy.
+func WithMinSeqScans(n int64) ReporterOption {
+	return func(o *reporterOptions) {
+		o.minSeqScans = n
+	}
+}
+
+// WithMinTableRows sets the minimum number of live rows for the table to be reported as sequential-scan-heavy.
+// Small tables are usually scanned sequentially by design, so they are skipped.
+func WithMinTableRows(n int64) ReporterOption {
+	return func(o *reporterOptions) {
+		o.minTableRows = n
+	}
+}
+
+// NewReporter creates a new Reporter for the given database dialect.
+func NewReporter(dbConn *sql.DB, dialect dbkit.Dialect, logger log.FieldLogger, options ...ReporterOption) (*Reporter, error) {
+	opts := reporterOptions{limit: DefaultLimit, minSeqScans: DefaultMinSeqScans, minTableRows: DefaultMinTableRows}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	q, err := newDBQueries(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Reporter{dbConn: dbConn, logger: logger, queries: q, opts: opts}, nil
+}
+
+// Collect collects index usage statistics from the database.
+// Statistics that are not available for the dialect are left empty.
+func (r *Reporter) Collect(ctx context.Context) (*Digest, error) {
+	digest := &Digest{CollectedAt: time.Now()}
+	var err error
+	if digest.UnusedIndexes, err = r.collectUnusedIndexes(ctx); err != nil {
+		return nil, fmt.Errorf("collect unused indexes: %w", err)
+	}
+	if digest.SeqScanHeavyTables, err = r.collectSeqScanHeavyTables(ctx); err != nil {
+		return nil, fmt.Errorf("collect sequential-scan-heavy tables: %w", err)
+	}
+	if digest.MissingIndexes, err = r.collectMissingIndexes(ctx); err != nil {
+		return nil, fmt.Errorf("collect missing indexes: %w", err)
+	}
+	return digest, nil
+}
+
+// Report collects index usage statistics and logs them.
+func (r *Reporter) Report(ctx context.Context) error {
+	digest, err := r.Collect(ctx)
+	if err != nil {
+		return err
+	}
+	r.logDigest(digest)
+	return nil
+}
+
+// Run reports index usage statistics periodically with the given interval until ctx is done.
+// Collecting errors are logged and don't stop the reporter.
+func (r *Reporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Report(ctx); err != nil && ctx.Err() == nil {
+				r.logger.Error("failed to collect index usage statistics", log.Error(err))
+			}
+		}
+	}
+}
+
+func (r *Reporter) logDigest(digest *Digest) {
+	r.logger.Info("index usage digest",
+		log.Int("unused_indexes", len(digest.UnusedIndexes)),
+		log.Int("seq_scan_heavy_tables", len(digest.SeqScanHeavyTables)),
+		log.Int("missing_indexes", len(digest.MissingIndexes)),
+	)
+	for _, idx := range digest.UnusedIndexes {
+		r.logger.Info("unused index",
+			log.String("schema", idx.Schema),
+			log.String("table", idx.Table),
+			log.String("index", idx.Index),
+			log.Int64("size_bytes", idx.SizeBytes),
+		)
+	}
+	for _, tbl := range digest.SeqScanHeavyTables {
+		r.logger.Info("sequential-scan-heavy table",
+			log.String("schema", tbl.Schema),
+			log.String("table", tbl.Table),
+			log.Int64("seq_scans", tbl.SeqScans),
+			log.Int64("index_scans", tbl.IndexScans),
+			log.Int64("live_rows", tbl.LiveRows),
+		)
+	}
+	for _, idx := range digest.MissingIndexes {
+		r.logger.Info("missing index",
+			log.String("table", idx.Table),
+			log.String("equality_columns", idx.EqualityColumns),
+			log.String("inequality_columns", idx.InequalityColumns),
+			log.String("included_columns", idx.IncludedColumns),
+			log.Int64("user_seeks", idx.UserSeeks),
+			log.Float64("avg_user_impact", idx.AvgUserImpact),
+		)
+	}
+}
+
+func (r *Reporter) collectUnusedIndexes(ctx context.Context) ([]UnusedIndex, error) {
+	if r.queries.unusedIndexes == "" {
+		return nil, nil
+	}
+	rows, err := r.dbConn.QueryContext(ctx, r.queries.unusedIndexes, r.opts.limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var res []UnusedIndex
+	for rows.Next() {
+		var idx UnusedIndex
+		if err = rows.Scan(&idx.Schema, &idx.Table, &idx.Index, &idx.Scans, &idx.SizeBytes); err != nil {
+			return nil, err
+		}
+		res = append(res, idx)
+	}
+	return res, rows.Err()
+}
+
+func (r *Reporter) collectSeqScanHeavyTables(ctx context.Context) ([]SeqScanHeavyTable, error) {
+	if r.queries.seqScanHeavyTables == "" {
+		return nil, nil
+	}
+	rows, err := r.dbConn.QueryContext(ctx, r.queries.seqScanHeavyTables, r.opts.minSeqScans, r.opts.minTableRows, r.opts.limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var res []SeqScanHeavyTable
+	for rows.Next() {
+		var tbl SeqScanHeavyTable
+		if err = rows.Scan(&tbl.Schema, &tbl.Table, &tbl.SeqScans, &tbl.IndexScans, &tbl.LiveRows); err != nil {
+			return nil, err
+		}
+		res = append(res, tbl)
+	}
+	return res, rows.Err()
+}
+
+func (r *Reporter) collectMissingIndexes(ctx context.Context) ([]MissingIndex, error) {
+	if r.queries.missingIndexes == "" {
+		return nil, nil
+	}
+	rows, err := r.dbConn.QueryContext(ctx, r.queries.missingIndexes, r.opts.limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var res []MissingIndex
+	for rows.Next() {
+		var idx MissingIndex
+		if err = rows.Scan(&idx.Table, &idx.EqualityColumns, &idx.InequalityColumns, &idx.IncludedColumns,
+			&idx.UserSeeks, &idx.AvgUserImpact); err != nil {
+			return nil, err
+		}
+		res = append(res, idx)
+	}
+	return res, rows.Err()
+}
+
+// dbQueries contains dialect-specific queries. Empty query means that the statistics is not available for the dialect.
+type dbQueries struct {
+	unusedIndexes      string
+	seqScanHeavyTables string
+	missingIndexes     string
+}
+
+func newDBQueries(dialect dbkit.Dialect) (dbQueries, error) {
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		return dbQueries{
+			unusedIndexes:      postgresUnusedIndexesQuery,
+			seqScanHeavyTables: postgresSeqScanHeavyTablesQuery,
+		}, nil
+	case dbkit.DialectMySQL:
+		return dbQueries{
+			unusedIndexes: mySQLUnusedIndexesQuery,
+		}, nil
+	case dbkit.DialectMSSQL:
+		return dbQueries{
+			unusedIndexes:  msSQLUnusedIndexesQuery,
+			missingIndexes: msSQLMissingIndexesQuery,
+		}, nil
+	default:
+		return dbQueries{}, fmt.Errorf("unsupported sql dialect %q", dialect)
+	}
+}
+
+//nolint:lll
+const (
+	postgresUnusedIndexesQuery = `SELECT s.schemaname, s.relname, s.indexrelname, s.idx_scan, pg_relation_size(s.indexrelid)
+FROM pg_stat_user_indexes s JOIN pg_index i ON i.indexrelid = s.indexrelid
+WHERE s.idx_scan = 0 AND NOT i.indisunique AND NOT i.indisprimary
+ORDER BY pg_relation_size(s.indexrelid) DESC LIMIT $1`
+
+	postgresSeqScanHeavyTablesQuery = `SELECT schemaname, relname, seq_scan, COALESCE(idx_scan, 0), n_live_tup
+FROM pg_stat_user_tables
+WHERE seq_scan > COALESCE(idx_scan, 0) AND seq_scan >= $1 AND n_live_tup >= $2
+ORDER BY seq_scan DESC LIMIT $3`
+
+	mySQLUnusedIndexesQuery = `SELECT object_schema, object_name, index_name, count_star, 0
+FROM performance_schema.table_io_waits_summary_by_index_usage
+WHERE index_name IS NOT NULL AND index_name <> 'PRIMARY' AND count_star = 0 AND object_schema = DATABASE()
+ORDER BY object_name, index_name LIMIT ?`
+
+	msSQLUnusedIndexesQuery = `SELECT TOP (@p1) SCHEMA_NAME(o.schema_id), o.name, i.name,
+COALESCE(us.user_seeks + us.user_scans + us.user_lookups, 0),
+COALESCE((SELECT SUM(ps.used_page_count) * 8192 FROM sys.dm_db_partition_stats ps WHERE ps.object_id = i.object_id AND ps.index_id = i.index_id), 0)
+FROM sys.indexes i
+JOIN sys.objects o ON o.object_id = i.object_id
+LEFT JOIN sys.dm_db_index_usage_stats us ON us.object_id = i.object_id AND us.index_id = i.index_id AND us.database_id = DB_ID()
+WHERE o.type = 'U' AND i.type_desc = 'NONCLUSTERED' AND i.is_primary_key = 0 AND i.is_unique = 0
+AND COALESCE(us.user_seeks + us.user_scans + us.user_lookups, 0) = 0
+ORDER BY o.name, i.name`
+
+	msSQLMissingIndexesQuery = `SELECT TOP (@p1) d.statement, COALESCE(d.equality_columns, ''), COALESCE(d.inequality_columns, ''),
+COALESCE(d.included_columns, ''), s.user_seeks, s.avg_user_impact
+FROM sys.dm_db_missing_index_details d
+JOIN sys.dm_db_missing_index_groups g ON g.index_handle = d.index_handle
+JOIN sys.dm_db_missing_index_group_stats s ON s.group_handle = g.index_group_handle
+WHERE d.database_id = DB_ID()
+ORDER BY s.avg_user_impact * (s.user_seeks + s.user_scans) DESC`
+)
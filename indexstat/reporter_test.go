@@ -0,0 +1,90 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package indexstat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/acronis/go-appkit/log/logtest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+func TestReporter_Postgres(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	logRecorder := logtest.NewRecorder()
+	reporter, err := NewReporter(db, dbkit.DialectPostgres, logRecorder, WithLimit(5), WithMinSeqScans(10), WithMinTableRows(100))
+	require.NoError(t, err)
+
+	mock.ExpectQuery(postgresUnusedIndexesQuery).WithArgs(5).WillReturnRows(
+		sqlmock.NewRows([]string{"schemaname", "relname", "indexrelname", "idx_scan", "size"}).
+			AddRow("public", "users", "users_name_idx", 0, 8192))
+	mock.ExpectQuery(postgresSeqScanHeavyTablesQuery).WithArgs(10, 100, 5).WillReturnRows(
+		sqlmock.NewRows([]string{"schemaname", "relname", "seq_scan", "idx_scan", "n_live_tup"}).
+			AddRow("public", "notes", 500, 3, 1000))
+
+	require.NoError(t, reporter.Report(context.Background()))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	digestEntry, found := logRecorder.FindEntry("index usage digest")
+	require.True(t, found)
+	field, found := digestEntry.FindField("unused_indexes")
+	require.True(t, found)
+	require.EqualValues(t, 1, field.Int)
+	field, found = digestEntry.FindField("missing_indexes")
+	require.True(t, found)
+	require.EqualValues(t, 0, field.Int)
+
+	unusedEntry, found := logRecorder.FindEntry("unused index")
+	require.True(t, found)
+	field, found = unusedEntry.FindField("index")
+	require.True(t, found)
+	require.Equal(t, "users_name_idx", string(field.Bytes))
+
+	_, found = logRecorder.FindEntry("sequential-scan-heavy table")
+	require.True(t, found)
+}
+
+func TestReporter_MSSQL(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	reporter, err := NewReporter(db, dbkit.DialectMSSQL, logtest.NewLogger())
+	require.NoError(t, err)
+
+	mock.ExpectQuery(msSQLUnusedIndexesQuery).WithArgs(DefaultLimit).WillReturnRows(
+		sqlmock.NewRows([]string{"schema", "table", "index", "scans", "size"}))
+	mock.ExpectQuery(msSQLMissingIndexesQuery).WithArgs(DefaultLimit).WillReturnRows(
+		sqlmock.NewRows([]string{"statement", "eq", "ineq", "incl", "user_seeks", "avg_user_impact"}).
+			AddRow("[db].[dbo].[users]", "[name]", "", "[email]", 42, 97.5))
+
+	digest, err := reporter.Collect(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.False(t, digest.Empty())
+	require.Empty(t, digest.UnusedIndexes)
+	require.Empty(t, digest.SeqScanHeavyTables)
+	require.Equal(t, []MissingIndex{{
+		Table:           "[db].[dbo].[users]",
+		EqualityColumns: "[name]",
+		IncludedColumns: "[email]",
+		UserSeeks:       42,
+		AvgUserImpact:   97.5,
+	}}, digest.MissingIndexes)
+}
+
+func TestNewReporter_UnsupportedDialect(t *testing.T) {
+	_, err := NewReporter(nil, dbkit.DialectSQLite, logtest.NewLogger())
+	require.EqualError(t, err, `unsupported sql dialect "sqlite3"`)
+}
@@ -0,0 +1,285 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package driverwrap provides building blocks for database/sql driver wrappers (connectors that decorate
+// connections, statements and rows of the underlying driver).
+package driverwrap
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+)
+
+// Conn wraps driver.Conn and forwards optional interfaces to it.
+// It's embedded by the wrappers that override some of the methods (usually QueryContext, ExecContext and PrepareContext).
+// If the underlying connection doesn't implement some of them, the behavior of database/sql is preserved
+// (e.g., driver.ErrSkip is returned from QueryContext, so the query is executed via the prepared statement).
+type Conn struct {
+	Conn driver.Conn
+}
+
+var (
+	_ driver.Conn               = Conn{}
+	_ driver.ConnBeginTx        = Conn{}
+	_ driver.ConnPrepareContext = Conn{}
+	_ driver.QueryerContext     = Conn{}
+	_ driver.ExecerContext      = Conn{}
+	_ driver.Pinger             = Conn{}
+	_ driver.SessionResetter    = Conn{}
+	_ driver.Validator          = Conn{}
+	_ driver.NamedValueChecker  = Conn{}
+)
+
+// Unwrap returns the wrapped connection.
+func (c Conn) Unwrap() driver.Conn {
+	return c.Conn
+}
+
+func (c Conn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(query)
+}
+
+func (c Conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	connPrepareCtx, ok := c.Conn.(driver.ConnPrepareContext)
+	if !ok {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return c.Prepare(query)
+	}
+	return connPrepareCtx.PrepareContext(ctx, query)
+}
+
+func (c Conn) Close() error {
+	return c.Conn.Close()
+}
+
+func (c Conn) Begin() (driver.Tx, error) {
+	return c.Conn.Begin() //nolint:staticcheck // Deprecated method is a part of driver.Conn interface.
+}
+
+func (c Conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if connBeginTx, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return connBeginTx.BeginTx(ctx, opts)
+	}
+	// The same checks as database/sql does for drivers that don't implement driver.ConnBeginTx.
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New("sql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("sql: driver does not support read-only transactions")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Conn.Begin() //nolint:staticcheck // Fallback for drivers that don't implement driver.ConnBeginTx.
+}
+
+func (c Conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c Conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c Conn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c Conn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c Conn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c Conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// Stmt wraps driver.Stmt and forwards optional interfaces to it.
+type Stmt struct {
+	Stmt driver.Stmt
+	Conn Conn
+}
+
+var (
+	_ driver.Stmt              = Stmt{}
+	_ driver.StmtExecContext   = Stmt{}
+	_ driver.StmtQueryContext  = Stmt{}
+	_ driver.NamedValueChecker = Stmt{}
+)
+
+func (s Stmt) Close() error {
+	return s.Stmt.Close()
+}
+
+func (s Stmt) NumInput() int {
+	return s.Stmt.NumInput()
+}
+
+func (s Stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.Stmt.Exec(args) //nolint:staticcheck // Deprecated method is a part of driver.Stmt interface.
+}
+
+func (s Stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.Stmt.Query(args) //nolint:staticcheck // Deprecated method is a part of driver.Stmt interface.
+}
+
+func (s Stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	stmtExecCtx, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		values, err := NamedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		return s.Exec(values)
+	}
+	return stmtExecCtx.ExecContext(ctx, args)
+}
+
+func (s Stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	stmtQueryCtx, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		values, err := NamedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		return s.Query(values)
+	}
+	return stmtQueryCtx.QueryContext(ctx, args)
+}
+
+// CheckNamedValue is called by database/sql instead of the connection's one if the statement implements it,
+// so it falls back to the connection's checker.
+func (s Stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return s.Conn.CheckNamedValue(nv)
+}
+
+// NamedValuesToValues converts arguments for the deprecated driver.Stmt methods (as database/sql does).
+func NamedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("sql: driver does not support the use of Named Parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+// Rows wraps driver.Rows and forwards optional interfaces to them.
+// It's embedded by the wrappers that override some of the methods (usually Next and Close).
+// Optional interfaces return the same defaults as database/sql uses if the underlying rows don't implement them.
+type Rows struct {
+	Rows driver.Rows
+}
+
+var (
+	_ driver.Rows                           = Rows{}
+	_ driver.RowsNextResultSet              = Rows{}
+	_ driver.RowsColumnTypeScanType         = Rows{}
+	_ driver.RowsColumnTypeDatabaseTypeName = Rows{}
+	_ driver.RowsColumnTypeLength           = Rows{}
+	_ driver.RowsColumnTypeNullable         = Rows{}
+	_ driver.RowsColumnTypePrecisionScale   = Rows{}
+)
+
+func (r Rows) Columns() []string {
+	return r.Rows.Columns()
+}
+
+func (r Rows) Close() error {
+	return r.Rows.Close()
+}
+
+func (r Rows) Next(dest []driver.Value) error {
+	return r.Rows.Next(dest)
+}
+
+func (r Rows) HasNextResultSet() bool {
+	if nextResultSet, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return nextResultSet.HasNextResultSet()
+	}
+	return false
+}
+
+func (r Rows) NextResultSet() error {
+	if nextResultSet, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return nextResultSet.NextResultSet()
+	}
+	return io.EOF
+}
+
+func (r Rows) ColumnTypeScanType(index int) reflect.Type {
+	if scanType, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return scanType.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+func (r Rows) ColumnTypeDatabaseTypeName(index int) string {
+	if typeName, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return typeName.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r Rows) ColumnTypeLength(index int) (length int64, ok bool) {
+	if typeLength, isImpl := r.Rows.(driver.RowsColumnTypeLength); isImpl {
+		return typeLength.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r Rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if typeNullable, isImpl := r.Rows.(driver.RowsColumnTypeNullable); isImpl {
+		return typeNullable.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r Rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	if precisionScale, isImpl := r.Rows.(driver.RowsColumnTypePrecisionScale); isImpl {
+		return precisionScale.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
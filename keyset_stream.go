@@ -0,0 +1,169 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/acronis/go-appkit/retry"
+	"github.com/cenkalti/backoff/v4"
+)
+
+// Default values for StreamByKeyset options.
+const (
+	DefaultKeysetStreamRetryInterval    = 500 * time.Millisecond
+	DefaultKeysetStreamMaxRetryAttempts = 5
+)
+
+// KeysetQueryFunc executes the query that returns rows ordered by the key.
+// If afterKey is nil, rows should be read from the beginning,
+// otherwise only rows with the key greater than afterKey should be returned (e.g., "WHERE id > ? ORDER BY id").
+type KeysetQueryFunc func(ctx context.Context, dbConn *sql.DB, afterKey interface{}) (*sql.Rows, error)
+
+// KeysetRowFunc scans and handles the current row and returns its key.
+// Returned error is never retried and stops the stream.
+type KeysetRowFunc func(rows *sql.Rows) (key interface{}, err error)
+
+type keysetStreamOptions struct {
+	retryPolicy retry.Policy
+	isRetryable retry.IsRetryable
+	notify      backoff.Notify
+}
+
+// KeysetStreamOption is a functional option for StreamByKeyset.
+type KeysetStreamOption func(*keysetStreamOptions)
+
+// WithKeysetStreamRetryPolicy sets retry policy that is used when the stream is interrupted by a retryable error.
+// The policy's backoff is reset each time the stream makes progress after resuming.
+// By default, exponential backoff with DefaultKeysetStreamRetryInterval and DefaultKeysetStreamMaxRetryAttempts is used.
+func WithKeysetStreamRetryPolicy(policy retry.Policy) KeysetStreamOption {
+	return func(opts *keysetStreamOptions) {
+		opts.retryPolicy = policy
+	}
+}
+
+// WithKeysetStreamIsRetryable sets the function that determines whether the stream may be resumed after the error.
+// By default, connection errors (driver.ErrBadConn, unexpected EOF, network errors)
+// and errors that are retryable for the driver (see GetIsRetryable) are considered retryable.
+func WithKeysetStreamIsRetryable(isRetryable retry.IsRetryable) KeysetStreamOption {
+	return func(opts *keysetStreamOptions) {
+		opts.isRetryable = isRetryable
+	}
+}
+
+// WithKeysetStreamNotify sets the function that is called before each resume attempt
+// with the error that interrupted the stream and the delay before the next attempt.
+func WithKeysetStreamNotify(notify backoff.Notify) KeysetStreamOption {
+	return func(opts *keysetStreamOptions) {
+		opts.notify = notify
+	}
+}
+
+// errKeysetRowHandling wraps errors returned by KeysetRowFunc to never retry them.
+type errKeysetRowHandling struct {
+	err error
+}
+
+func (e *errKeysetRowHandling) Error() string {
+	return e.err.Error()
+}
+
+// StreamByKeyset reads a large keyset-ordered result set row by row.
+// If the stream is interrupted by a retryable (typically, connection) error, the query is executed again
+// (database/sql takes a new connection from the pool) and reading is resumed from the last handled key,
+// so already handled rows are not read twice, and the whole export doesn't fail.
+func StreamByKeyset(
+	ctx context.Context, dbConn *sql.DB, query KeysetQueryFunc, handleRow KeysetRowFunc, options ...KeysetStreamOption,
+) error {
+	var opts keysetStreamOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.retryPolicy == nil {
+		opts.retryPolicy = retry.NewExponentialBackoffPolicy(
+			DefaultKeysetStreamRetryInterval, DefaultKeysetStreamMaxRetryAttempts)
+	}
+	if opts.isRetryable == nil {
+		opts.isRetryable = makeKeysetStreamIsRetryable(dbConn)
+	}
+
+	b := opts.retryPolicy.NewBackOff()
+	var lastKey interface{}
+	for {
+		progressed, err := streamByKeysetOnce(ctx, dbConn, query, handleRow, &lastKey)
+		if err == nil {
+			return nil
+		}
+		var rowErr *errKeysetRowHandling
+		if errors.As(err, &rowErr) {
+			return rowErr.err
+		}
+		if ctx.Err() != nil || !opts.isRetryable(err) {
+			return err
+		}
+		if progressed {
+			b.Reset()
+		}
+		delay := b.NextBackOff()
+		if delay == backoff.Stop {
+			return err
+		}
+		if opts.notify != nil {
+			opts.notify(err, delay)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func streamByKeysetOnce(
+	ctx context.Context, dbConn *sql.DB, query KeysetQueryFunc, handleRow KeysetRowFunc, lastKey *interface{},
+) (progressed bool, err error) {
+	rows, err := query(ctx, dbConn, *lastKey)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	for rows.Next() {
+		key, handleErr := handleRow(rows)
+		if handleErr != nil {
+			return progressed, &errKeysetRowHandling{handleErr}
+		}
+		*lastKey = key
+		progressed = true
+	}
+	return progressed, rows.Err()
+}
+
+func makeKeysetStreamIsRetryable(dbConn *sql.DB) retry.IsRetryable {
+	isRetryableForDriver := GetIsRetryable(dbConn.Driver())
+	return func(err error) bool {
+		if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return true
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			return true
+		}
+		return isRetryableForDriver(err)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/acronis/go-appkit/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamByKeyset(t *testing.T) {
+	const (
+		queryFromStart = "SELECT id FROM users ORDER BY id"
+		queryAfterKey  = "SELECT id FROM users WHERE id > ? ORDER BY id"
+	)
+
+	keysetQuery := func(ctx context.Context, dbConn *sql.DB, afterKey interface{}) (*sql.Rows, error) {
+		if afterKey == nil {
+			return dbConn.QueryContext(ctx, queryFromStart)
+		}
+		return dbConn.QueryContext(ctx, queryAfterKey, afterKey)
+	}
+	scanIDs := func(ids *[]int64) KeysetRowFunc {
+		return func(rows *sql.Rows) (interface{}, error) {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return nil, err
+			}
+			*ids = append(*ids, id)
+			return id, nil
+		}
+	}
+	retryPolicy := WithKeysetStreamRetryPolicy(retry.NewConstantBackoffPolicy(time.Millisecond, 1))
+
+	t.Run("stream is resumed from the last key after connection error", func(t *testing.T) {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		require.NoError(t, err)
+		defer func() { _ = db.Close() }()
+
+		mock.ExpectQuery(queryFromStart).WillReturnRows(
+			sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3).RowError(2, io.ErrUnexpectedEOF))
+		mock.ExpectQuery(queryAfterKey).WithArgs(int64(2)).WillReturnRows(
+			sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(4))
+
+		var ids []int64
+		var notified []error
+		require.NoError(t, StreamByKeyset(context.Background(), db, keysetQuery, scanIDs(&ids), retryPolicy,
+			WithKeysetStreamNotify(func(err error, _ time.Duration) { notified = append(notified, err) })))
+		require.Equal(t, []int64{1, 2, 3, 4}, ids)
+		require.Len(t, notified, 1)
+		require.ErrorIs(t, notified[0], io.ErrUnexpectedEOF)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("retry attempts are exceeded", func(t *testing.T) {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		require.NoError(t, err)
+		defer func() { _ = db.Close() }()
+
+		mock.ExpectQuery(queryFromStart).WillReturnError(io.ErrUnexpectedEOF)
+		mock.ExpectQuery(queryFromStart).WillReturnError(io.ErrUnexpectedEOF)
+
+		var ids []int64
+		err = StreamByKeyset(context.Background(), db, keysetQuery, scanIDs(&ids), retryPolicy)
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		require.Empty(t, ids)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not retryable errors are not retried", func(t *testing.T) {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		require.NoError(t, err)
+		defer func() { _ = db.Close() }()
+
+		mock.ExpectQuery(queryFromStart).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+		handleErr := errors.New("handle error")
+		err = StreamByKeyset(context.Background(), db, keysetQuery, func(rows *sql.Rows) (interface{}, error) {
+			return nil, handleErr
+		}, retryPolicy, WithKeysetStreamIsRetryable(func(error) bool { return true }))
+		require.ErrorIs(t, err, handleErr)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,12 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package lease provides a registry of service instances based on the SQL database.
+// Each service instance registers itself with a lease that expires unless it's renewed periodically
+// (see Heartbeat), so the live instances may be queried from the database at any time.
+// It's useful for rebalancing work between instances (e.g., queue partitions) and for "which replicas are alive"
+// admin views in architectures where the database is the only shared infrastructure.
+package lease
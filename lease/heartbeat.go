@@ -0,0 +1,125 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package lease
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Default values for the Heartbeat options.
+const (
+	DefaultLeaseTTL          = 30 * time.Second
+	DefaultDeregisterTimeout = 5 * time.Second
+)
+
+// Logger is an interface for logging errors.
+type Logger interface {
+	Errorf(format string, args ...interface{})
+}
+
+type heartbeatOptions struct {
+	ttl               time.Duration
+	renewInterval     time.Duration
+	deregisterTimeout time.Duration
+	logger            Logger
+}
+
+// HeartbeatOption is a functional option for NewHeartbeat.
+type HeartbeatOption func(*heartbeatOptions)
+
+// WithLeaseTTL sets the TTL of the instance lease. By default, DefaultLeaseTTL is used.
+func WithLeaseTTL(ttl time.Duration) HeartbeatOption {
+	return func(opts *heartbeatOptions) {
+		opts.ttl = ttl
+	}
+}
+
+// WithRenewInterval sets the interval of the lease renewal. By default, it's a third of the lease TTL.
+func WithRenewInterval(interval time.Duration) HeartbeatOption {
+	return func(opts *heartbeatOptions) {
+		opts.renewInterval = interval
+	}
+}
+
+// WithDeregisterTimeout sets the timeout for deregistering the instance when the heartbeat is stopped.
+// By default, DefaultDeregisterTimeout is used.
+func WithDeregisterTimeout(timeout time.Duration) HeartbeatOption {
+	return func(opts *heartbeatOptions) {
+		opts.deregisterTimeout = timeout
+	}
+}
+
+// WithHeartbeatLogger sets the logger for errors that occur during the lease renewal.
+func WithHeartbeatLogger(logger Logger) HeartbeatOption {
+	return func(opts *heartbeatOptions) {
+		opts.logger = logger
+	}
+}
+
+// Heartbeat keeps the instance registered in the Registry by renewing its lease periodically.
+type Heartbeat struct {
+	dbConn   *sql.DB
+	registry *Registry
+	instance Instance
+	opts     heartbeatOptions
+}
+
+// NewHeartbeat creates a new Heartbeat for the instance.
+func NewHeartbeat(dbConn *sql.DB, registry *Registry, instance Instance, options ...HeartbeatOption) *Heartbeat {
+	opts := heartbeatOptions{ttl: DefaultLeaseTTL, deregisterTimeout: DefaultDeregisterTimeout, logger: disabledLogger{}}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.renewInterval <= 0 {
+		opts.renewInterval = opts.ttl / 3
+	}
+	return &Heartbeat{dbConn: dbConn, registry: registry, instance: instance, opts: opts}
+}
+
+// Run registers the instance and renews its lease until ctx is done. Then the instance is deregistered.
+// If the lease is expired (e.g., because the database was unavailable for a long time), the instance is registered again.
+// Errors of the renewal are logged, and only an error of the initial registration is returned.
+// Otherwise, Run returns ctx.Err().
+func (h *Heartbeat) Run(ctx context.Context) error {
+	if err := h.registry.Register(ctx, h.dbConn, h.instance, h.opts.ttl); err != nil {
+		return err
+	}
+	defer h.deregister()
+
+	ticker := time.NewTicker(h.opts.renewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		err := h.registry.Renew(ctx, h.dbConn, h.instance.ID, h.opts.ttl)
+		if errors.Is(err, ErrLeaseExpired) {
+			h.opts.logger.Errorf("lease of instance %s expired, registering it again", h.instance.ID)
+			err = h.registry.Register(ctx, h.dbConn, h.instance, h.opts.ttl)
+		}
+		if err != nil && ctx.Err() == nil {
+			h.opts.logger.Errorf("failed to renew lease of instance %s: %v", h.instance.ID, err)
+		}
+	}
+}
+
+func (h *Heartbeat) deregister() {
+	ctx, cancel := context.WithTimeout(context.Background(), h.opts.deregisterTimeout)
+	defer cancel()
+	if err := h.registry.Deregister(ctx, h.dbConn, h.instance.ID); err != nil {
+		h.opts.logger.Errorf("failed to deregister instance %s: %v", h.instance.ID, err)
+	}
+}
+
+type disabledLogger struct{}
+
+func (disabledLogger) Errorf(format string, args ...interface{}) {}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package lease
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeat(t *testing.T) {
+	registry, dbConn := newTestRegistry(t)
+	instance := Instance{ID: DefaultInstanceID(), Service: "api"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	heartbeatErr := make(chan error)
+	go func() {
+		heartbeatErr <- NewHeartbeat(dbConn, registry, instance,
+			WithLeaseTTL(time.Millisecond*300), WithRenewInterval(time.Millisecond*20)).Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		instances, err := registry.ListLive(context.Background(), dbConn, "api")
+		return err == nil && len(instances) == 1
+	}, time.Second, time.Millisecond*10)
+
+	// Lease is deregistered externally (e.g., by an admin), heartbeat registers the instance again.
+	require.NoError(t, registry.Deregister(context.Background(), dbConn, instance.ID))
+	require.Eventually(t, func() bool {
+		instances, err := registry.ListLive(context.Background(), dbConn, "api")
+		return err == nil && len(instances) == 1
+	}, time.Second, time.Millisecond*10)
+
+	// Lease stays live for longer than its TTL since it's renewed.
+	time.Sleep(time.Millisecond * 500)
+	requireLiveIDs(t, registry, dbConn, "api", instance.ID)
+
+	cancel()
+	require.ErrorIs(t, <-heartbeatErr, context.Canceled)
+	requireLiveIDs(t, registry, dbConn, "api")
+}
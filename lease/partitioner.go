@@ -0,0 +1,262 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package lease
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Default values for the Partitioner options.
+const (
+	DefaultVirtualNodes           = 100
+	DefaultPartitionRefreshPeriod = 5 * time.Second
+)
+
+// Ring is a consistent hash ring that assigns keys to members.
+// When a member is added or removed, only keys of this member are reassigned.
+// Ring is immutable and safe for concurrent use.
+type Ring struct {
+	members []string
+	hashes  []uint64
+	owners  map[uint64]string
+}
+
+// NewRing creates a new consistent hash ring with the given members.
+// Each member is placed on the ring virtualNodes times to distribute keys evenly.
+// DefaultVirtualNodes is used if virtualNodes is not positive.
+func NewRing(members []string, virtualNodes int) *Ring {
+	if virtualNodes <= 0 {
+		virtualNodes = DefaultVirtualNodes
+	}
+	r := &Ring{
+		members: append([]string(nil), members...),
+		hashes:  make([]uint64, 0, len(members)*virtualNodes),
+		owners:  make(map[uint64]string, len(members)*virtualNodes),
+	}
+	sort.Strings(r.members)
+	for _, member := range r.members {
+		for i := 0; i < virtualNodes; i++ {
+			h := hashKey(member + "#" + strconv.Itoa(i))
+			if owner, ok := r.owners[h]; ok && owner < member {
+				continue // Resolve hash collisions deterministically.
+			}
+			if _, ok := r.owners[h]; !ok {
+				r.hashes = append(r.hashes, h)
+			}
+			r.owners[h] = member
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+// Members returns sorted members of the ring.
+func (r *Ring) Members() []string {
+	return append([]string(nil), r.members...)
+}
+
+// Owner returns the member that owns the key. It returns an empty string if the ring has no members.
+func (r *Ring) Owner(key string) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+	h := hashKey(key)
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.owners[r.hashes[i]]
+}
+
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	// FNV alone distributes similar short keys (e.g., virtual nodes "id#1", "id#2") poorly,
+	// so the hash is additionally mixed with the MurmurHash3 finalizer.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+type partitionerOptions struct {
+	refreshPeriod time.Duration
+	virtualNodes  int
+	onRebalance   func(owned []string)
+	logger        Logger
+}
+
+// PartitionerOption is a functional option for NewPartitioner.
+type PartitionerOption func(*partitionerOptions)
+
+// WithPartitionRefreshPeriod sets how often live instances are queried to detect membership changes.
+// By default, DefaultPartitionRefreshPeriod is used.
+func WithPartitionRefreshPeriod(period time.Duration) PartitionerOption {
+	return func(opts *partitionerOptions) {
+		opts.refreshPeriod = period
+	}
+}
+
+// WithVirtualNodes sets the number of virtual nodes of each instance on the hash ring.
+// By default, DefaultVirtualNodes is used.
+func WithVirtualNodes(n int) PartitionerOption {
+	return func(opts *partitionerOptions) {
+		opts.virtualNodes = n
+	}
+}
+
+// WithOnRebalance sets the callback that is called with the shard keys owned by the instance
+// each time the membership of the live instances changes.
+func WithOnRebalance(fn func(owned []string)) PartitionerOption {
+	return func(opts *partitionerOptions) {
+		opts.onRebalance = fn
+	}
+}
+
+// WithPartitionerLogger sets the logger for errors that occur while refreshing live instances.
+func WithPartitionerLogger(logger Logger) PartitionerOption {
+	return func(opts *partitionerOptions) {
+		opts.logger = logger
+	}
+}
+
+// Partitioner splits work between live instances of the service without a coordinator.
+// It assigns a stable subset of shard keys to the instance using consistent hashing
+// over live instances from the Registry (instances keep their leases with Heartbeat),
+// and rebalances the assignment when the membership changes.
+// Since instances observe membership changes independently, the same key may be briefly owned
+// by two instances (or by none) during rebalancing, so the processing of each key should be idempotent
+// or protected additionally (e.g., with distributed lock).
+type Partitioner struct {
+	dbConn     *sql.DB
+	registry   *Registry
+	service    string
+	instanceID string
+	shardKeys  []string
+	opts       partitionerOptions
+
+	mu    sync.RWMutex
+	ring  *Ring
+	owned []string
+}
+
+// NewPartitioner creates a new Partitioner for the instance of the service.
+// Shard keys are the keys that are split between instances (they are used for Owned and WithOnRebalance),
+// though any key may be checked with Owns.
+func NewPartitioner(
+	dbConn *sql.DB, registry *Registry, service, instanceID string, shardKeys []string, options ...PartitionerOption,
+) *Partitioner {
+	opts := partitionerOptions{
+		refreshPeriod: DefaultPartitionRefreshPeriod,
+		virtualNodes:  DefaultVirtualNodes,
+		logger:        disabledLogger{},
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return &Partitioner{
+		dbConn:     dbConn,
+		registry:   registry,
+		service:    service,
+		instanceID: instanceID,
+		shardKeys:  append([]string(nil), shardKeys...),
+		opts:       opts,
+		ring:       NewRing(nil, opts.virtualNodes),
+	}
+}
+
+// Run refreshes live instances periodically until ctx is done. It returns ctx.Err().
+func (p *Partitioner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.opts.refreshPeriod)
+	defer ticker.Stop()
+	for {
+		if err := p.Refresh(ctx); err != nil && ctx.Err() == nil {
+			p.opts.logger.Errorf("failed to refresh live instances of service %s: %v", p.service, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh queries live instances and rebalances shard keys if the membership is changed.
+func (p *Partitioner) Refresh(ctx context.Context) error {
+	instances, err := p.registry.ListLive(ctx, p.dbConn, p.service)
+	if err != nil {
+		return err
+	}
+	members := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		members = append(members, instance.ID)
+	}
+	sort.Strings(members)
+	if equalStrings(members, p.Members()) {
+		return nil
+	}
+
+	ring := NewRing(members, p.opts.virtualNodes)
+	owned := make([]string, 0, len(p.shardKeys))
+	for _, key := range p.shardKeys {
+		if ring.Owner(key) == p.instanceID {
+			owned = append(owned, key)
+		}
+	}
+	p.mu.Lock()
+	p.ring = ring
+	p.owned = owned
+	p.mu.Unlock()
+
+	if p.opts.onRebalance != nil {
+		p.opts.onRebalance(append([]string(nil), owned...))
+	}
+	return nil
+}
+
+// Members returns IDs of the live instances known after the last refresh.
+func (p *Partitioner) Members() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.ring.Members()
+}
+
+// Owned returns the shard keys owned by the instance.
+// If the instance itself isn't live (e.g., it's not registered yet), it owns nothing.
+func (p *Partitioner) Owned() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]string(nil), p.owned...)
+}
+
+// Owns reports whether the key is owned by the instance.
+func (p *Partitioner) Owns(key string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.ring.Owner(key) == p.instanceID
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package lease
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func makeShardKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("tenant-%d", i)
+	}
+	return keys
+}
+
+func TestRing(t *testing.T) {
+	keys := makeShardKeys(1000)
+
+	require.Equal(t, "", NewRing(nil, 0).Owner("tenant-1"))
+
+	ring := NewRing([]string{"c", "a", "b"}, 0)
+	require.Equal(t, []string{"a", "b", "c"}, ring.Members())
+	counts := map[string]int{}
+	for _, key := range keys {
+		counts[ring.Owner(key)]++
+	}
+	for _, member := range ring.Members() {
+		require.InDelta(t, len(keys)/3, counts[member], float64(len(keys))/6, "keys should be distributed evenly")
+	}
+
+	// Only keys of the removed member are reassigned.
+	ringWithoutB := NewRing([]string{"a", "c"}, 0)
+	for _, key := range keys {
+		if owner := ring.Owner(key); owner != "b" {
+			require.Equal(t, owner, ringWithoutB.Owner(key))
+		}
+	}
+}
+
+func TestPartitioner(t *testing.T) {
+	ctx := context.Background()
+	registry, dbConn := newTestRegistry(t)
+	keys := makeShardKeys(100)
+
+	var rebalances [][]string
+	partitioner1 := NewPartitioner(dbConn, registry, "worker", "worker-1", keys,
+		WithOnRebalance(func(owned []string) { rebalances = append(rebalances, owned) }))
+	partitioner2 := NewPartitioner(dbConn, registry, "worker", "worker-2", keys)
+
+	// Instance is not registered yet, so it owns nothing.
+	require.NoError(t, partitioner1.Refresh(ctx))
+	require.Empty(t, partitioner1.Owned())
+	require.Empty(t, rebalances)
+
+	require.NoError(t, registry.Register(ctx, dbConn, Instance{ID: "worker-1", Service: "worker"}, time.Minute))
+	require.NoError(t, partitioner1.Refresh(ctx))
+	require.Equal(t, keys, partitioner1.Owned())
+	require.True(t, partitioner1.Owns("any-key"))
+	require.Len(t, rebalances, 1)
+
+	// The second instance joins, keys are split between instances.
+	require.NoError(t, registry.Register(ctx, dbConn, Instance{ID: "worker-2", Service: "worker"}, time.Minute))
+	require.NoError(t, registry.Register(ctx, dbConn, Instance{ID: "other-service", Service: "api"}, time.Minute))
+	require.NoError(t, partitioner1.Refresh(ctx))
+	require.NoError(t, partitioner2.Refresh(ctx))
+	require.Equal(t, []string{"worker-1", "worker-2"}, partitioner1.Members())
+	require.Len(t, rebalances, 2)
+	owned1, owned2 := partitioner1.Owned(), partitioner2.Owned()
+	require.NotEmpty(t, owned1)
+	require.NotEmpty(t, owned2)
+	require.ElementsMatch(t, keys, append(owned1, owned2...))
+	for _, key := range owned1 {
+		require.True(t, partitioner1.Owns(key))
+		require.False(t, partitioner2.Owns(key))
+	}
+
+	// Membership isn't changed, so there is no rebalancing.
+	require.NoError(t, partitioner1.Refresh(ctx))
+	require.Len(t, rebalances, 2)
+
+	// The second instance leaves, the first one owns all keys again.
+	require.NoError(t, registry.Deregister(ctx, dbConn, "worker-2"))
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	runErr := make(chan error)
+	go func() { runErr <- partitioner1.Run(runCtx) }()
+	require.Eventually(t, func() bool { return len(partitioner1.Owned()) == len(keys) }, time.Second, time.Millisecond*10)
+	cancel()
+	require.ErrorIs(t, <-runErr, context.Canceled)
+	require.Len(t, rebalances, 3)
+}
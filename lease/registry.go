@@ -0,0 +1,315 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package lease
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+// DefaultTableName is a default name for the table that stores instance leases.
+const DefaultTableName = "instance_leases"
+
+const maxIDLen = 255
+
+// ErrLeaseExpired is returned when the lease cannot be renewed because it's expired or deregistered.
+// The instance should be registered again.
+var ErrLeaseExpired = errors.New("instance lease expired")
+
+// Instance describes a registered service instance.
+type Instance struct {
+	// ID is the unique identifier of the instance (see DefaultInstanceID).
+	ID string
+	// Service is the name of the service the instance belongs to.
+	Service string
+	// Metadata is arbitrary information about the instance (e.g., address or version), usually in JSON.
+	Metadata string
+	// RegisteredAt is the time when the instance was registered.
+	RegisteredAt time.Time
+	// RenewedAt is the time when the lease was renewed last time.
+	RenewedAt time.Time
+	// ExpireAt is the time when the lease expires unless it's renewed.
+	ExpireAt time.Time
+}
+
+// DefaultInstanceID returns the default instance identifier in the "<hostname>:<pid>" format.
+func DefaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return hostname + ":" + strconv.Itoa(os.Getpid())
+}
+
+// Registry manages leases of service instances stored in the database table.
+// Lease times are calculated using the local clock, so clocks of the service instances should be synchronized.
+type Registry struct {
+	queries dbQueries
+	clock   dbkit.Clock
+}
+
+// Option is an option for NewRegistry.
+type Option func(*registryOptions)
+
+type registryOptions struct {
+	tableName string
+	clock     dbkit.Clock
+}
+
+// WithTableName sets a custom table name for the table that stores instance leases.
+func WithTableName(tableName string) Option {
+	return func(o *registryOptions) {
+		o.tableName = tableName
+	}
+}
+
+// WithClock sets the clock that is used for calculating lease times (dbkit.RealClock by default). It's intended for tests.
+func WithClock(clock dbkit.Clock) Option {
+	return func(o *registryOptions) {
+		o.clock = clock
+	}
+}
+
+// NewRegistry creates a new Registry.
+func NewRegistry(dialect dbkit.Dialect, options ...Option) (*Registry, error) {
+	opts := registryOptions{clock: dbkit.RealClock{}}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.tableName == "" {
+		opts.tableName = DefaultTableName
+	}
+	q, err := newDBQueries(dialect, opts.tableName)
+	if err != nil {
+		return nil, err
+	}
+	return &Registry{queries: q, clock: opts.clock}, nil
+}
+
+// Migrations returns set of migrations that must be applied before using the registry.
+func (r *Registry) Migrations() []migrate.Migration {
+	return []migrate.Migration{
+		migrate.NewCustomMigration(createTableMigrationID,
+			[]string{r.queries.createTable, r.queries.createIndex}, []string{r.queries.dropTable}, nil, nil),
+	}
+}
+
+// CreateTableSQL returns SQL query for creating a table that stores instance leases.
+func (r *Registry) CreateTableSQL() string {
+	return r.queries.createTable
+}
+
+// CreateIndexSQL returns SQL query for creating an index for querying live instances of the service.
+func (r *Registry) CreateIndexSQL() string {
+	return r.queries.createIndex
+}
+
+// DropTableSQL returns SQL query for dropping a table that stores instance leases.
+func (r *Registry) DropTableSQL() string {
+	return r.queries.dropTable
+}
+
+// Register registers the instance with the lease for the given TTL.
+// If the instance with the same ID is already registered, its registration is replaced.
+func (r *Registry) Register(ctx context.Context, executor SQLExecutor, instance Instance, ttl time.Duration) error {
+	if instance.ID == "" {
+		return fmt.Errorf("instance id cannot be empty")
+	}
+	if len(instance.ID) > maxIDLen || len(instance.Service) > maxIDLen {
+		return fmt.Errorf("instance id and service cannot be longer than %d symbols", maxIDLen)
+	}
+	now := r.clock.Now()
+	if _, err := executor.ExecContext(ctx, r.queries.register, instance.ID, instance.Service, instance.Metadata,
+		now.UnixMilli(), now.UnixMilli(), now.Add(ttl).UnixMilli()); err != nil {
+		return fmt.Errorf("register instance %s: %w", instance.ID, err)
+	}
+	return nil
+}
+
+// Renew extends the lease of the instance for the given TTL.
+// ErrLeaseExpired is returned if the lease is already expired or the instance is deregistered.
+func (r *Registry) Renew(ctx context.Context, executor SQLExecutor, instanceID string, ttl time.Duration) error {
+	now := r.clock.Now()
+	result, err := executor.ExecContext(ctx, r.queries.renew,
+		now.UnixMilli(), now.Add(ttl).UnixMilli(), instanceID, now.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("renew lease of instance %s: %w", instanceID, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("renew lease of instance %s: %w", instanceID, err)
+	}
+	if affected == 0 {
+		return ErrLeaseExpired
+	}
+	return nil
+}
+
+// Deregister removes the instance from the registry.
+func (r *Registry) Deregister(ctx context.Context, executor SQLExecutor, instanceID string) error {
+	if _, err := executor.ExecContext(ctx, r.queries.deregister, instanceID); err != nil {
+		return fmt.Errorf("deregister instance %s: %w", instanceID, err)
+	}
+	return nil
+}
+
+// ListLive returns live (with not expired leases) instances of the service ordered by ID.
+// If service is empty, live instances of all services are returned.
+func (r *Registry) ListLive(ctx context.Context, executor SQLQueryExecutor, service string) ([]Instance, error) {
+	var rows *sql.Rows
+	var err error
+	if service == "" {
+		rows, err = executor.QueryContext(ctx, r.queries.listAllLive, r.clock.Now().UnixMilli())
+	} else {
+		rows, err = executor.QueryContext(ctx, r.queries.listLive, service, r.clock.Now().UnixMilli())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list live instances: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var instances []Instance
+	for rows.Next() {
+		var instance Instance
+		var registeredAt, renewedAt, expireAt int64
+		if err = rows.Scan(&instance.ID, &instance.Service, &instance.Metadata,
+			&registeredAt, &renewedAt, &expireAt); err != nil {
+			return nil, fmt.Errorf("scan instance: %w", err)
+		}
+		instance.RegisteredAt = time.UnixMilli(registeredAt)
+		instance.RenewedAt = time.UnixMilli(renewedAt)
+		instance.ExpireAt = time.UnixMilli(expireAt)
+		instances = append(instances, instance)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list live instances: %w", err)
+	}
+	return instances, nil
+}
+
+// DeleteExpired deletes instances with expired leases and returns the number of deleted instances.
+func (r *Registry) DeleteExpired(ctx context.Context, executor SQLExecutor) (int64, error) {
+	result, err := executor.ExecContext(ctx, r.queries.deleteExpired, r.clock.Now().UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("delete expired instance leases: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// SQLExecutor is an interface for executing SQL queries (e.g., *sql.DB or *sql.Tx).
+type SQLExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// SQLQueryExecutor is an interface for executing SQL queries that return rows (e.g., *sql.DB or *sql.Tx).
+type SQLQueryExecutor interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+type dbQueries struct {
+	createTable   string
+	createIndex   string
+	dropTable     string
+	register      string
+	renew         string
+	deregister    string
+	listLive      string
+	listAllLive   string
+	deleteExpired string
+}
+
+func newDBQueries(dialect dbkit.Dialect, tableName string) (dbQueries, error) {
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		return dbQueries{
+			createTable:   fmt.Sprintf(postgresCreateTableQuery, tableName),
+			createIndex:   fmt.Sprintf(postgresCreateIndexQuery, tableName),
+			dropTable:     fmt.Sprintf(postgresDropTableQuery, tableName),
+			register:      fmt.Sprintf(postgresRegisterQuery, tableName),
+			renew:         fmt.Sprintf(postgresRenewQuery, tableName),
+			deregister:    fmt.Sprintf(postgresDeregisterQuery, tableName),
+			listLive:      fmt.Sprintf(postgresListLiveQuery, tableName),
+			listAllLive:   fmt.Sprintf(postgresListAllLiveQuery, tableName),
+			deleteExpired: fmt.Sprintf(postgresDeleteExpiredQuery, tableName),
+		}, nil
+	case dbkit.DialectMySQL:
+		return dbQueries{
+			createTable:   fmt.Sprintf(mySQLCreateTableQuery, tableName),
+			createIndex:   fmt.Sprintf(mySQLCreateIndexQuery, tableName),
+			dropTable:     fmt.Sprintf(mySQLDropTableQuery, tableName),
+			register:      fmt.Sprintf(mySQLRegisterQuery, tableName),
+			renew:         fmt.Sprintf(mySQLRenewQuery, tableName),
+			deregister:    fmt.Sprintf(mySQLDeregisterQuery, tableName),
+			listLive:      fmt.Sprintf(mySQLListLiveQuery, tableName),
+			listAllLive:   fmt.Sprintf(mySQLListAllLiveQuery, tableName),
+			deleteExpired: fmt.Sprintf(mySQLDeleteExpiredQuery, tableName),
+		}, nil
+	case dbkit.DialectSQLite:
+		return dbQueries{
+			createTable:   fmt.Sprintf(sqliteCreateTableQuery, tableName),
+			createIndex:   fmt.Sprintf(sqliteCreateIndexQuery, tableName),
+			dropTable:     fmt.Sprintf(sqliteDropTableQuery, tableName),
+			register:      fmt.Sprintf(sqliteRegisterQuery, tableName),
+			renew:         fmt.Sprintf(sqliteRenewQuery, tableName),
+			deregister:    fmt.Sprintf(sqliteDeregisterQuery, tableName),
+			listLive:      fmt.Sprintf(sqliteListLiveQuery, tableName),
+			listAllLive:   fmt.Sprintf(sqliteListAllLiveQuery, tableName),
+			deleteExpired: fmt.Sprintf(sqliteDeleteExpiredQuery, tableName),
+		}, nil
+	default:
+		return dbQueries{}, fmt.Errorf("unsupported sql dialect %q", dialect)
+	}
+}
+
+const createTableMigrationID = "lease_00001_create_table"
+
+//nolint:lll
+const (
+	postgresCreateTableQuery   = `CREATE TABLE IF NOT EXISTS "%s" (instance_id varchar(255) PRIMARY KEY, service varchar(255) NOT NULL, metadata text NOT NULL, registered_at bigint NOT NULL, renewed_at bigint NOT NULL, expire_at bigint NOT NULL);`
+	postgresCreateIndexQuery   = `CREATE INDEX IF NOT EXISTS "%[1]s_service_expire_at_idx" ON "%[1]s" (service, expire_at);`
+	postgresDropTableQuery     = `DROP TABLE IF EXISTS "%s";`
+	postgresRegisterQuery      = `INSERT INTO "%s" (instance_id, service, metadata, registered_at, renewed_at, expire_at) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (instance_id) DO UPDATE SET service = EXCLUDED.service, metadata = EXCLUDED.metadata, registered_at = EXCLUDED.registered_at, renewed_at = EXCLUDED.renewed_at, expire_at = EXCLUDED.expire_at;`
+	postgresRenewQuery         = `UPDATE "%s" SET renewed_at = $1, expire_at = $2 WHERE instance_id = $3 AND expire_at >= $4;`
+	postgresDeregisterQuery    = `DELETE FROM "%s" WHERE instance_id = $1;`
+	postgresListLiveQuery      = `SELECT instance_id, service, metadata, registered_at, renewed_at, expire_at FROM "%s" WHERE service = $1 AND expire_at >= $2 ORDER BY instance_id;`
+	postgresListAllLiveQuery   = `SELECT instance_id, service, metadata, registered_at, renewed_at, expire_at FROM "%s" WHERE expire_at >= $1 ORDER BY instance_id;`
+	postgresDeleteExpiredQuery = `DELETE FROM "%s" WHERE expire_at < $1;`
+)
+
+//nolint:lll
+const (
+	mySQLCreateTableQuery   = "CREATE TABLE IF NOT EXISTS `%s` (instance_id VARCHAR(255) PRIMARY KEY, service VARCHAR(255) NOT NULL, metadata TEXT NOT NULL, registered_at BIGINT NOT NULL, renewed_at BIGINT NOT NULL, expire_at BIGINT NOT NULL);"
+	mySQLCreateIndexQuery   = "CREATE INDEX `%[1]s_service_expire_at_idx` ON `%[1]s` (service, expire_at);"
+	mySQLDropTableQuery     = "DROP TABLE IF EXISTS `%s`;"
+	mySQLRegisterQuery      = "INSERT INTO `%s` (instance_id, service, metadata, registered_at, renewed_at, expire_at) VALUES (?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE service = VALUES(service), metadata = VALUES(metadata), registered_at = VALUES(registered_at), renewed_at = VALUES(renewed_at), expire_at = VALUES(expire_at);"
+	mySQLRenewQuery         = "UPDATE `%s` SET renewed_at = ?, expire_at = ? WHERE instance_id = ? AND expire_at >= ?;"
+	mySQLDeregisterQuery    = "DELETE FROM `%s` WHERE instance_id = ?;"
+	mySQLListLiveQuery      = "SELECT instance_id, service, metadata, registered_at, renewed_at, expire_at FROM `%s` WHERE service = ? AND expire_at >= ? ORDER BY instance_id;"
+	mySQLListAllLiveQuery   = "SELECT instance_id, service, metadata, registered_at, renewed_at, expire_at FROM `%s` WHERE expire_at >= ? ORDER BY instance_id;"
+	mySQLDeleteExpiredQuery = "DELETE FROM `%s` WHERE expire_at < ?;"
+)
+
+//nolint:lll
+const (
+	sqliteCreateTableQuery   = `CREATE TABLE IF NOT EXISTS "%s" (instance_id TEXT PRIMARY KEY, service TEXT NOT NULL, metadata TEXT NOT NULL, registered_at INTEGER NOT NULL, renewed_at INTEGER NOT NULL, expire_at INTEGER NOT NULL);`
+	sqliteCreateIndexQuery   = `CREATE INDEX IF NOT EXISTS "%[1]s_service_expire_at_idx" ON "%[1]s" (service, expire_at);`
+	sqliteDropTableQuery     = `DROP TABLE IF EXISTS "%s";`
+	sqliteRegisterQuery      = `INSERT INTO "%s" (instance_id, service, metadata, registered_at, renewed_at, expire_at) VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (instance_id) DO UPDATE SET service = excluded.service, metadata = excluded.metadata, registered_at = excluded.registered_at, renewed_at = excluded.renewed_at, expire_at = excluded.expire_at;`
+	sqliteRenewQuery         = `UPDATE "%s" SET renewed_at = ?, expire_at = ? WHERE instance_id = ? AND expire_at >= ?;`
+	sqliteDeregisterQuery    = `DELETE FROM "%s" WHERE instance_id = ?;`
+	sqliteListLiveQuery      = `SELECT instance_id, service, metadata, registered_at, renewed_at, expire_at FROM "%s" WHERE service = ? AND expire_at >= ? ORDER BY instance_id;`
+	sqliteListAllLiveQuery   = `SELECT instance_id, service, metadata, registered_at, renewed_at, expire_at FROM "%s" WHERE expire_at >= ? ORDER BY instance_id;`
+	sqliteDeleteExpiredQuery = `DELETE FROM "%s" WHERE expire_at < ?;`
+)
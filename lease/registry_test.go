@@ -0,0 +1,106 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package lease
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/testkit"
+)
+
+func newTestRegistry(t *testing.T) (*Registry, *sql.DB) {
+	t.Helper()
+	registry, err := NewRegistry(dbkit.DialectSQLite)
+	require.NoError(t, err)
+	dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "lease.db")+"?_journal=MEMORY&_sync=OFF&_busy_timeout=5000")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	_, err = dbConn.Exec(registry.CreateTableSQL())
+	require.NoError(t, err)
+	_, err = dbConn.Exec(registry.CreateIndexSQL())
+	require.NoError(t, err)
+	return registry, dbConn
+}
+
+func requireLiveIDs(t *testing.T, registry *Registry, dbConn *sql.DB, service string, wantIDs ...string) {
+	t.Helper()
+	instances, err := registry.ListLive(context.Background(), dbConn, service)
+	require.NoError(t, err)
+	var ids []string
+	for _, instance := range instances {
+		ids = append(ids, instance.ID)
+	}
+	require.Equal(t, wantIDs, ids)
+}
+
+func TestNewRegistry(t *testing.T) {
+	_, err := NewRegistry(dbkit.DialectPostgres)
+	require.NoError(t, err)
+	registry, err := NewRegistry(dbkit.DialectMySQL, WithTableName("replicas"))
+	require.NoError(t, err)
+	require.Contains(t, registry.CreateTableSQL(), "`replicas`")
+	require.Len(t, registry.Migrations(), 1)
+
+	_, err = NewRegistry(dbkit.DialectMSSQL)
+	require.Error(t, err)
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	registry, dbConn := newTestRegistry(t)
+	clock := testkit.NewFakeClock(now)
+	registry.clock = clock
+
+	require.NoError(t, registry.Register(ctx, dbConn, Instance{ID: "api-2", Service: "api", Metadata: `{"version":"1.2"}`}, time.Minute))
+	require.NoError(t, registry.Register(ctx, dbConn, Instance{ID: "api-1", Service: "api"}, time.Minute))
+	require.NoError(t, registry.Register(ctx, dbConn, Instance{ID: "worker-1", Service: "worker"}, time.Second*10))
+	require.Error(t, registry.Register(ctx, dbConn, Instance{Service: "api"}, time.Minute))
+
+	instances, err := registry.ListLive(ctx, dbConn, "api")
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+	require.Equal(t, Instance{
+		ID:           "api-2",
+		Service:      "api",
+		Metadata:     `{"version":"1.2"}`,
+		RegisteredAt: time.UnixMilli(now.UnixMilli()),
+		RenewedAt:    time.UnixMilli(now.UnixMilli()),
+		ExpireAt:     time.UnixMilli(now.Add(time.Minute).UnixMilli()),
+	}, instances[1])
+	requireLiveIDs(t, registry, dbConn, "", "api-1", "api-2", "worker-1")
+
+	// Lease of worker-1 expires, it cannot be renewed anymore and is not live.
+	clock.Advance(time.Second * 30)
+	require.NoError(t, registry.Renew(ctx, dbConn, "api-1", time.Minute))
+	require.ErrorIs(t, registry.Renew(ctx, dbConn, "worker-1", time.Minute), ErrLeaseExpired)
+	requireLiveIDs(t, registry, dbConn, "", "api-1", "api-2")
+
+	// api-2 is not renewed and expires, api-1 is renewed and still live.
+	clock.Advance(time.Second * 40)
+	requireLiveIDs(t, registry, dbConn, "api", "api-1")
+
+	deleted, err := registry.DeleteExpired(ctx, dbConn)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), deleted)
+
+	// Re-registration after the expiration.
+	require.NoError(t, registry.Register(ctx, dbConn, Instance{ID: "worker-1", Service: "worker"}, time.Minute))
+	requireLiveIDs(t, registry, dbConn, "worker", "worker-1")
+
+	require.NoError(t, registry.Deregister(ctx, dbConn, "api-1"))
+	require.ErrorIs(t, registry.Renew(ctx, dbConn, "api-1", time.Minute), ErrLeaseExpired)
+	requireLiveIDs(t, registry, dbConn, "", "worker-1")
+}
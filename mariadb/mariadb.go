@@ -0,0 +1,71 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package mariadb provides helpers for working with the MariaDB database (including Galera clusters)
+// using the github.com/go-sql-driver/mysql driver.
+// It extends the mysql package (which is imported by this one) with MariaDB-specific error codes.
+// Should be imported explicitly.
+// To register MariaDB-specific retryable func use side effect import like so:
+//
+//	import _ "github.com/acronis/go-dbkit/mariadb"
+package mariadb
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/acronis/go-dbkit"
+	_ "github.com/acronis/go-dbkit/mysql" // Register retryable funcs and error classifiers for MySQL error codes.
+)
+
+// nolint
+func init() {
+	dbkit.RegisterIsRetryableFunc(&mysql.MySQLDriver{}, func(err error) bool {
+		var mySQLError *mysql.MySQLError
+		if errors.As(err, &mySQLError) {
+			switch ErrCode(mySQLError.Number) {
+			case ErrConnectionKilled, ErrUnknownCommand:
+				return true
+			}
+		}
+		return false
+	})
+	dbkit.RegisterErrorClassifier(func(err error) dbkit.ErrorClass {
+		var mySQLError *mysql.MySQLError
+		if !errors.As(err, &mySQLError) {
+			return dbkit.ErrorClassNone
+		}
+		switch ErrCode(mySQLError.Number) {
+		case ErrConnectionKilled, ErrUnknownCommand:
+			return dbkit.ErrorClassConnectionFailure
+		}
+		return dbkit.ErrorClassNone
+	})
+}
+
+// ErrCode defines the type for MariaDB error codes.
+type ErrCode uint16
+
+// MariaDB-specific error codes (will be filled gradually).
+const (
+	// ErrConnectionKilled is returned when the connection is killed (e.g., by KILL statement or on node shutdown).
+	ErrConnectionKilled ErrCode = 1927
+	// ErrUnknownCommand is returned by a Galera node that is not ready to serve queries
+	// ("WSREP has not yet prepared node for application use"), e.g., while it's joining the cluster
+	// or lost the quorum. The query may be retried (possibly on another node).
+	ErrUnknownCommand ErrCode = 1047
+)
+
+// CheckMariaDBError checks if the passed error relates to MariaDB,
+// and it's internal code matches the one from the argument.
+func CheckMariaDBError(err error, errCode ErrCode) bool {
+	var mySQLError *mysql.MySQLError
+	if errors.As(err, &mySQLError) {
+		return mySQLError.Number == uint16(errCode)
+	}
+	return false
+}
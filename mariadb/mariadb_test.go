@@ -0,0 +1,46 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package mariadb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	dbkitmysql "github.com/acronis/go-dbkit/mysql"
+)
+
+func TestMariaDBIsRetryable(t *testing.T) {
+	isRetryable := dbkit.GetIsRetryable(&mysql.MySQLDriver{})
+	require.NotNil(t, isRetryable)
+	require.True(t, isRetryable(&mysql.MySQLError{Number: uint16(ErrConnectionKilled)}))
+	require.True(t, isRetryable(fmt.Errorf("wrapped error: %w", &mysql.MySQLError{Number: uint16(ErrUnknownCommand)})))
+	require.True(t, isRetryable(&mysql.MySQLError{Number: uint16(dbkitmysql.ErrDeadlock)}), "MySQL codes must be retryable too")
+	require.False(t, isRetryable(&mysql.MySQLError{Number: uint16(dbkitmysql.ErrCodeDupEntry)}))
+	require.False(t, isRetryable(driver.ErrBadConn))
+	require.True(t, dbkit.GetIsRetryableForDialect(dbkit.DialectMySQL)(&mysql.MySQLError{Number: uint16(ErrConnectionKilled)}))
+}
+
+func TestCheckMariaDBError(t *testing.T) {
+	err := fmt.Errorf("wrapped error: %w", &mysql.MySQLError{Number: 1927, Message: "Connection was killed"})
+	require.True(t, CheckMariaDBError(err, ErrConnectionKilled))
+	require.False(t, CheckMariaDBError(err, ErrUnknownCommand))
+	require.False(t, CheckMariaDBError(fmt.Errorf("some error"), ErrConnectionKilled))
+}
+
+func TestClassifyError(t *testing.T) {
+	require.Equal(t, dbkit.ErrorClassConnectionFailure, dbkit.ClassifyError(&mysql.MySQLError{Number: uint16(ErrConnectionKilled)}))
+	require.Equal(t, dbkit.ErrorClassConnectionFailure, dbkit.ClassifyError(&mysql.MySQLError{Number: uint16(ErrUnknownCommand)}))
+	require.Equal(t, dbkit.ErrorClassStatementTimeout,
+		dbkit.ClassifyError(&mysql.MySQLError{Number: uint16(dbkitmysql.ErrStatementTimeout)}))
+	require.Equal(t, dbkit.ErrorClassOther, dbkit.ClassifyError(&mysql.MySQLError{Number: 1064}))
+	require.True(t, dbkit.IsConnectionError(&mysql.MySQLError{Number: uint16(ErrConnectionKilled)}))
+}
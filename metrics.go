@@ -7,6 +7,9 @@ Released under MIT license.
 package dbkit
 
 import (
+	"database/sql"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -15,9 +18,63 @@ import (
 // PrometheusMetricsLabelQuery is a label name for SQL query in Prometheus metrics.
 const PrometheusMetricsLabelQuery = "query"
 
+// PrometheusMetricsLabelTx is a label name for the transaction name in Prometheus metrics (see ContextWithTxName).
+const PrometheusMetricsLabelTx = "tx"
+
+// PrometheusMetricsLabelDBName is a label name for the database name in Prometheus metrics of the connection pool.
+const PrometheusMetricsLabelDBName = "db_name"
+
+// PrometheusMetricsLabelErrorClass is a label name for the class of the query error in Prometheus metrics
+// (see QueryErrorClass).
+const PrometheusMetricsLabelErrorClass = "error_class"
+
+// QueryErrorClass defines a coarse class of the query error that is used as a label value in metrics.
+// Unlike ErrorClass, it has a small fixed set of values suitable for error-rate alerting.
+type QueryErrorClass string
+
+// Query error classes.
+const (
+	QueryErrorClassDeadlock        QueryErrorClass = "deadlock"
+	QueryErrorClassUniqueViolation QueryErrorClass = "unique_violation"
+	QueryErrorClassTimeout         QueryErrorClass = "timeout"
+	QueryErrorClassConnection      QueryErrorClass = "connection"
+	QueryErrorClassOther           QueryErrorClass = "other"
+)
+
+// ClassifyQueryError returns the class of the query error based on ClassifyError.
+// Deadline exceeding, statement, network and lock timeouts are classified as QueryErrorClassTimeout,
+// constraint violations other than the unique one and serialization failures as QueryErrorClassOther.
+// Empty string is returned for nil error.
+func ClassifyQueryError(err error) QueryErrorClass {
+	switch ClassifyError(err) {
+	case ErrorClassNone:
+		return ""
+	case ErrorClassDeadlock:
+		return QueryErrorClassDeadlock
+	case ErrorClassContextDeadlineExceeded, ErrorClassStatementTimeout, ErrorClassNetworkTimeout, ErrorClassLockTimeout:
+		return QueryErrorClassTimeout
+	case ErrorClassConnectionFailure:
+		return QueryErrorClassConnection
+	case ErrorClassConstraintViolation:
+		if IsUniqueViolation(err) {
+			return QueryErrorClassUniqueViolation
+		}
+	}
+	return QueryErrorClassOther
+}
+
+// ErrorMetricsCollector is an interface for collecting metrics about failed SQL queries and transactions.
+// Failed transactions (see WithErrorMetrics) are labeled in the same way as in TxMetricsCollector.
+type ErrorMetricsCollector interface {
+	ObserveQueryError(query string, err error)
+}
+
 // DefaultQueryDurationBuckets is default buckets into which observations of executing SQL queries are counted.
 var DefaultQueryDurationBuckets = []float64{0.001, 0.01, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
 
+// DefaultQueryRowsBuckets is default buckets into which observations of rows returned and affected by SQL queries are counted.
+var DefaultQueryRowsBuckets = []float64{0, 1, 10, 100, 1000, 10000, 100000}
+
 // PrometheusMetricsOpts represents an options for PrometheusMetrics.
 type PrometheusMetricsOpts struct {
 	// Namespace is a namespace for metrics. It will be prepended to all metric names.
@@ -26,6 +83,18 @@ type PrometheusMetricsOpts struct {
 	// QueryDurationBuckets is a list of buckets into which observations of executing SQL queries are counted.
 	QueryDurationBuckets []float64
 
+	// TxDurationBuckets is a list of buckets into which observations of executing transactions are counted.
+	// DefaultQueryDurationBuckets are used if it's nil.
+	TxDurationBuckets []float64
+
+	// EnableQueryRowsMetrics enables histograms of the number of rows returned and affected by SQL queries
+	// (see RowsMetricsConnector).
+	EnableQueryRowsMetrics bool
+
+	// QueryRowsBuckets is a list of buckets into which observations of returned and affected rows are counted.
+	// DefaultQueryRowsBuckets are used if it's nil.
+	QueryRowsBuckets []float64
+
 	// ConstLabels is a set of labels that will be applied to all metrics.
 	ConstLabels prometheus.Labels
 
@@ -40,8 +109,37 @@ type PrometheusMetricsOpts struct {
 // PrometheusMetrics represents collector of metrics.
 type PrometheusMetrics struct {
 	QueryDurations *prometheus.HistogramVec
+	QueryErrors    *prometheus.CounterVec
+	TxDurations    *prometheus.HistogramVec
+	TxCommits      *prometheus.CounterVec
+	TxRollbacks    *prometheus.CounterVec
+	TxRetries      *prometheus.CounterVec
+	QueryRetries   *prometheus.CounterVec
+
+	// QueryRowsReturned and QueryRowsAffected are nil if PrometheusMetricsOpts.EnableQueryRowsMetrics is false.
+	QueryRowsReturned *prometheus.HistogramVec
+	QueryRowsAffected *prometheus.HistogramVec
+
+	PoolMaxOpenConns     *prometheus.GaugeVec
+	PoolOpenConns        *prometheus.GaugeVec
+	PoolInUseConns       *prometheus.GaugeVec
+	PoolIdleConns        *prometheus.GaugeVec
+	PoolWaitCount        *prometheus.CounterVec
+	PoolWaitDurationSecs *prometheus.CounterVec
+
+	// poolWaitTotals holds the last observed totals of waiting for connections (see ObservePoolStats).
+	// It's shared by curried collectors, so curriedLabels are a part of the key.
+	poolWaitTotals *poolWaitTotals
+	curriedLabels  prometheus.Labels
+}
+
+type poolWaitTotals struct {
+	mu     sync.Mutex
+	totals map[string]sql.DBStats
 }
 
+var _ MetricsCollector = (*PrometheusMetrics)(nil)
+
 // NewPrometheusMetrics creates a new metrics collector.
 func NewPrometheusMetrics() *PrometheusMetrics {
 	return NewPrometheusMetricsWithOpts(PrometheusMetricsOpts{})
@@ -65,32 +163,241 @@ func NewPrometheusMetricsWithOpts(opts PrometheusMetricsOpts) *PrometheusMetrics
 		},
 		labelNames,
 	)
-	return &PrometheusMetrics{QueryDurations: queryDurations}
+	queryRetries := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "db_query_retries_total",
+			Help:        "A counter of the SQL query retries outside of transactions.",
+			ConstLabels: opts.ConstLabels,
+		},
+		labelNames,
+	)
+	errorLabelNames := append(labelNames[:len(labelNames):len(labelNames)], PrometheusMetricsLabelErrorClass)
+	queryErrors := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "db_query_errors_total",
+			Help:        "A counter of the failed SQL queries by the error class.",
+			ConstLabels: opts.ConstLabels,
+		},
+		errorLabelNames,
+	)
+
+	txDurationBuckets := opts.TxDurationBuckets
+	if txDurationBuckets == nil {
+		txDurationBuckets = DefaultQueryDurationBuckets
+	}
+	txLabelNames := append(make([]string, 0, len(opts.CurriedLabelNames)+1), opts.CurriedLabelNames...)
+	txLabelNames = append(txLabelNames, PrometheusMetricsLabelTx)
+	txDurations := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Name:        "db_tx_duration_seconds",
+			Help:        "A histogram of the transaction durations.",
+			Buckets:     txDurationBuckets,
+			ConstLabels: opts.ConstLabels,
+		},
+		txLabelNames,
+	)
+	newTxCounter := func(name, help string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(
+			prometheus.CounterOpts{Namespace: opts.Namespace, Name: name, Help: help, ConstLabels: opts.ConstLabels},
+			txLabelNames,
+		)
+	}
+
+	poolLabelNames := append(make([]string, 0, len(opts.CurriedLabelNames)+1), opts.CurriedLabelNames...)
+	poolLabelNames = append(poolLabelNames, PrometheusMetricsLabelDBName)
+	newPoolGauge := func(name, help string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Namespace: opts.Namespace, Name: name, Help: help, ConstLabels: opts.ConstLabels},
+			poolLabelNames,
+		)
+	}
+	newPoolCounter := func(name, help string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(
+			prometheus.CounterOpts{Namespace: opts.Namespace, Name: name, Help: help, ConstLabels: opts.ConstLabels},
+			poolLabelNames,
+		)
+	}
+
+	pm := &PrometheusMetrics{
+		QueryDurations: queryDurations,
+		QueryErrors:    queryErrors,
+		TxDurations:    txDurations,
+		TxCommits:      newTxCounter("db_tx_commits_total", "A counter of the committed transactions."),
+		TxRollbacks:    newTxCounter("db_tx_rollbacks_total", "A counter of the rolled back transactions."),
+		TxRetries:      newTxCounter("db_tx_retries_total", "A counter of the transaction retries."),
+		QueryRetries:   queryRetries,
+
+		PoolMaxOpenConns: newPoolGauge("db_pool_max_open_connections", "Maximum number of open connections to the database."),
+		PoolOpenConns:    newPoolGauge("db_pool_open_connections", "The number of established connections both in use and idle."),
+		PoolInUseConns:   newPoolGauge("db_pool_in_use_connections", "The number of connections currently in use."),
+		PoolIdleConns:    newPoolGauge("db_pool_idle_connections", "The number of idle connections."),
+		PoolWaitCount:    newPoolCounter("db_pool_wait_count_total", "The total number of connections waited for."),
+		PoolWaitDurationSecs: newPoolCounter("db_pool_wait_duration_seconds_total",
+			"The total time blocked waiting for a new connection."),
+
+		poolWaitTotals: &poolWaitTotals{totals: make(map[string]sql.DBStats)},
+	}
+
+	if opts.EnableQueryRowsMetrics {
+		queryRowsBuckets := opts.QueryRowsBuckets
+		if queryRowsBuckets == nil {
+			queryRowsBuckets = DefaultQueryRowsBuckets
+		}
+		newRowsHistogram := func(name, help string) *prometheus.HistogramVec {
+			return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Namespace: opts.Namespace, Name: name, Help: help, Buckets: queryRowsBuckets, ConstLabels: opts.ConstLabels,
+			}, labelNames)
+		}
+		pm.QueryRowsReturned = newRowsHistogram("db_query_rows_returned", "A histogram of the number of rows returned by SQL queries.")
+		pm.QueryRowsAffected = newRowsHistogram("db_query_rows_affected", "A histogram of the number of rows affected by SQL queries.")
+	}
+
+	return pm
 }
 
 // MustCurryWith curries the metrics collector with the provided labels.
 func (pm *PrometheusMetrics) MustCurryWith(labels prometheus.Labels) *PrometheusMetrics {
-	return &PrometheusMetrics{
+	curried := &PrometheusMetrics{
 		QueryDurations: pm.QueryDurations.MustCurryWith(labels).(*prometheus.HistogramVec),
+		QueryErrors:    pm.QueryErrors.MustCurryWith(labels),
+		TxDurations:    pm.TxDurations.MustCurryWith(labels).(*prometheus.HistogramVec),
+		TxCommits:      pm.TxCommits.MustCurryWith(labels),
+		TxRollbacks:    pm.TxRollbacks.MustCurryWith(labels),
+		TxRetries:      pm.TxRetries.MustCurryWith(labels),
+		QueryRetries:   pm.QueryRetries.MustCurryWith(labels),
+
+		PoolMaxOpenConns:     pm.PoolMaxOpenConns.MustCurryWith(labels),
+		PoolOpenConns:        pm.PoolOpenConns.MustCurryWith(labels),
+		PoolInUseConns:       pm.PoolInUseConns.MustCurryWith(labels),
+		PoolIdleConns:        pm.PoolIdleConns.MustCurryWith(labels),
+		PoolWaitCount:        pm.PoolWaitCount.MustCurryWith(labels),
+		PoolWaitDurationSecs: pm.PoolWaitDurationSecs.MustCurryWith(labels),
+
+		poolWaitTotals: pm.poolWaitTotals,
+		curriedLabels:  make(prometheus.Labels, len(pm.curriedLabels)+len(labels)),
 	}
+	for name, value := range pm.curriedLabels {
+		curried.curriedLabels[name] = value
+	}
+	for name, value := range labels {
+		curried.curriedLabels[name] = value
+	}
+	if pm.QueryRowsReturned != nil {
+		curried.QueryRowsReturned = pm.QueryRowsReturned.MustCurryWith(labels).(*prometheus.HistogramVec)
+		curried.QueryRowsAffected = pm.QueryRowsAffected.MustCurryWith(labels).(*prometheus.HistogramVec)
+	}
+	return curried
 }
 
 // MustRegister does registration of metrics collector in Prometheus and panics if any error occurs.
 func (pm *PrometheusMetrics) MustRegister() {
-	prometheus.MustRegister(pm.QueryDurations)
+	prometheus.MustRegister(pm.AllMetrics()...)
 }
 
 // Unregister cancels registration of metrics collector in Prometheus.
 func (pm *PrometheusMetrics) Unregister() {
-	prometheus.Unregister(pm.QueryDurations)
+	for _, collector := range pm.AllMetrics() {
+		prometheus.Unregister(collector)
+	}
 }
 
 // AllMetrics returns a list of metrics of this collector. This can be used to register these metrics in push gateway.
 func (pm *PrometheusMetrics) AllMetrics() []prometheus.Collector {
-	return []prometheus.Collector{pm.QueryDurations}
+	collectors := []prometheus.Collector{
+		pm.QueryDurations, pm.QueryErrors, pm.TxDurations, pm.TxCommits, pm.TxRollbacks, pm.TxRetries, pm.QueryRetries,
+		pm.PoolMaxOpenConns, pm.PoolOpenConns, pm.PoolInUseConns, pm.PoolIdleConns, pm.PoolWaitCount, pm.PoolWaitDurationSecs,
+	}
+	if pm.QueryRowsReturned != nil {
+		collectors = append(collectors, pm.QueryRowsReturned, pm.QueryRowsAffected)
+	}
+	return collectors
 }
 
 // ObserveQueryDuration observes the duration of executing SQL query.
 func (pm *PrometheusMetrics) ObserveQueryDuration(query string, duration time.Duration) {
 	pm.QueryDurations.With(prometheus.Labels{PrometheusMetricsLabelQuery: query}).Observe(duration.Seconds())
 }
+
+// ObserveQueryError increments the counter of failed SQL queries with the class of the error (see ClassifyQueryError).
+// Nil error is ignored.
+func (pm *PrometheusMetrics) ObserveQueryError(query string, err error) {
+	if err == nil {
+		return
+	}
+	pm.QueryErrors.With(prometheus.Labels{
+		PrometheusMetricsLabelQuery:      query,
+		PrometheusMetricsLabelErrorClass: string(ClassifyQueryError(err)),
+	}).Inc()
+}
+
+// ObserveTxDuration observes the duration of executing transaction.
+func (pm *PrometheusMetrics) ObserveTxDuration(tx string, duration time.Duration) {
+	pm.TxDurations.With(prometheus.Labels{PrometheusMetricsLabelTx: tx}).Observe(duration.Seconds())
+}
+
+// IncTxCommits increments the counter of committed transactions.
+func (pm *PrometheusMetrics) IncTxCommits(tx string) {
+	pm.TxCommits.With(prometheus.Labels{PrometheusMetricsLabelTx: tx}).Inc()
+}
+
+// IncTxRollbacks increments the counter of rolled back transactions.
+func (pm *PrometheusMetrics) IncTxRollbacks(tx string) {
+	pm.TxRollbacks.With(prometheus.Labels{PrometheusMetricsLabelTx: tx}).Inc()
+}
+
+// IncTxRetries increments the counter of transaction retries.
+func (pm *PrometheusMetrics) IncTxRetries(tx string) {
+	pm.TxRetries.With(prometheus.Labels{PrometheusMetricsLabelTx: tx}).Inc()
+}
+
+// IncQueryRetries increments the counter of SQL query retries (see ExecWithRetry and QueryWithRetry).
+func (pm *PrometheusMetrics) IncQueryRetries(query string) {
+	pm.QueryRetries.With(prometheus.Labels{PrometheusMetricsLabelQuery: query}).Inc()
+}
+
+// ObserveQueryRowsReturned observes the number of rows returned by SQL query.
+// It does nothing if the rows metrics are not enabled (see PrometheusMetricsOpts.EnableQueryRowsMetrics).
+func (pm *PrometheusMetrics) ObserveQueryRowsReturned(query string, rows int) {
+	if pm.QueryRowsReturned != nil {
+		pm.QueryRowsReturned.With(prometheus.Labels{PrometheusMetricsLabelQuery: query}).Observe(float64(rows))
+	}
+}
+
+// ObserveQueryRowsAffected observes the number of rows affected by SQL query.
+// It does nothing if the rows metrics are not enabled (see PrometheusMetricsOpts.EnableQueryRowsMetrics).
+func (pm *PrometheusMetrics) ObserveQueryRowsAffected(query string, rows int64) {
+	if pm.QueryRowsAffected != nil {
+		pm.QueryRowsAffected.With(prometheus.Labels{PrometheusMetricsLabelQuery: query}).Observe(float64(rows))
+	}
+}
+
+// ObservePoolStats sets the gauges of the connection pool statistics (see ObservePoolStats function).
+// sql.DBStats.WaitCount and WaitDuration are monotonically increasing totals, so they are exported as counters
+// that are increased by the difference with the previously observed totals of the same pool.
+func (pm *PrometheusMetrics) ObservePoolStats(dbName string, stats sql.DBStats) {
+	labels := prometheus.Labels{PrometheusMetricsLabelDBName: dbName}
+	pm.PoolMaxOpenConns.With(labels).Set(float64(stats.MaxOpenConnections))
+	pm.PoolOpenConns.With(labels).Set(float64(stats.OpenConnections))
+	pm.PoolInUseConns.With(labels).Set(float64(stats.InUse))
+	pm.PoolIdleConns.With(labels).Set(float64(stats.Idle))
+	waitCount, waitDuration := pm.poolWaitDeltas(dbName, stats)
+	pm.PoolWaitCount.With(labels).Add(float64(waitCount))
+	pm.PoolWaitDurationSecs.With(labels).Add(waitDuration.Seconds())
+}
+
+// poolWaitDeltas returns the increase of the waiting totals since the previous observation of the pool.
+// If the totals decreased (e.g., the pool with the same name was reopened), they are counted from zero.
+func (pm *PrometheusMetrics) poolWaitDeltas(dbName string, stats sql.DBStats) (int64, time.Duration) {
+	key := fmt.Sprint(pm.curriedLabels) + "\x00" + dbName // fmt sorts map keys, so the key is stable.
+	pm.poolWaitTotals.mu.Lock()
+	defer pm.poolWaitTotals.mu.Unlock()
+	prev := pm.poolWaitTotals.totals[key]
+	pm.poolWaitTotals.totals[key] = stats
+	if stats.WaitCount < prev.WaitCount || stats.WaitDuration < prev.WaitDuration {
+		return stats.WaitCount, stats.WaitDuration
+	}
+	return stats.WaitCount - prev.WaitCount, stats.WaitDuration - prev.WaitDuration
+}
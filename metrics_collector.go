@@ -0,0 +1,97 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// QueryMetricsCollector is an interface for collecting metrics about durations of SQL queries.
+type QueryMetricsCollector interface {
+	ObserveQueryDuration(query string, duration time.Duration)
+}
+
+// PoolMetricsCollector is an interface for collecting metrics about the connection pool (see ObservePoolStats).
+type PoolMetricsCollector interface {
+	ObservePoolStats(dbName string, stats sql.DBStats)
+}
+
+// MetricsCollector is the complete interface for collecting metrics about SQL queries, transactions
+// (including retries) and the connection pool. It's implemented by PrometheusMetrics and NoOpMetricsCollector
+// and may be passed wherever one of the narrower interfaces is accepted (e.g., WithQueryRetryMetrics
+// or OpenWithRowsMetrics). It's accepted as a whole by WithMetrics for DoInTx, by migrate.MigrationsManagerOpts.Metrics
+// and by distrlock.WithMetrics, so all subsystems may report to the same collector.
+type MetricsCollector interface {
+	QueryMetricsCollector
+	ErrorMetricsCollector
+	TxMetricsCollector
+	QueryRetryMetricsCollector
+	RowsMetricsCollector
+	PoolMetricsCollector
+}
+
+// NoOpMetricsCollector is a MetricsCollector that does nothing.
+// It may be used as a default when metrics are not needed.
+type NoOpMetricsCollector struct{}
+
+var _ MetricsCollector = NoOpMetricsCollector{}
+
+// ObserveQueryDuration does nothing.
+func (NoOpMetricsCollector) ObserveQueryDuration(string, time.Duration) {}
+
+// ObserveQueryError does nothing.
+func (NoOpMetricsCollector) ObserveQueryError(string, error) {}
+
+// ObserveTxDuration does nothing.
+func (NoOpMetricsCollector) ObserveTxDuration(string, time.Duration) {}
+
+// IncTxCommits does nothing.
+func (NoOpMetricsCollector) IncTxCommits(string) {}
+
+// IncTxRollbacks does nothing.
+func (NoOpMetricsCollector) IncTxRollbacks(string) {}
+
+// IncTxRetries does nothing.
+func (NoOpMetricsCollector) IncTxRetries(string) {}
+
+// IncQueryRetries does nothing.
+func (NoOpMetricsCollector) IncQueryRetries(string) {}
+
+// ObserveQueryRowsReturned does nothing.
+func (NoOpMetricsCollector) ObserveQueryRowsReturned(string, int) {}
+
+// ObserveQueryRowsAffected does nothing.
+func (NoOpMetricsCollector) ObserveQueryRowsAffected(string, int64) {}
+
+// ObservePoolStats does nothing.
+func (NoOpMetricsCollector) ObservePoolStats(string, sql.DBStats) {}
+
+// WithMetrics sets the collector that is used by DoInTx to count failed attempts of the transaction
+// and to observe transaction metrics. It's a shortcut for WithErrorMetrics and WithTxMetrics with the same collector.
+func WithMetrics(collector MetricsCollector) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.errorMetrics = collector
+		opts.txMetrics = collector
+	}
+}
+
+// ObservePoolStats observes statistics of the connection pool with the given interval until ctx is done.
+// It's supposed to be run in a separate goroutine.
+func ObservePoolStats(ctx context.Context, db *sql.DB, dbName string, collector PoolMetricsCollector, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		collector.ObservePoolStats(dbName, db.Stats())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/acronis/go-appkit/testutil"
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type poolStatsRecorder struct {
+	observations atomic.Int32
+}
+
+func (r *poolStatsRecorder) ObservePoolStats(dbName string, _ sql.DBStats) {
+	if dbName == "users" {
+		r.observations.Add(1)
+	}
+}
+
+func TestDoInTxWithMetrics(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, mock.ExpectationsWereMet())
+	}()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	require.Error(t, DoInTx(context.Background(), db, func(tx *sql.Tx) error {
+		return errors.New("insert error")
+	}, WithMetrics(NoOpMetricsCollector{})))
+
+	metrics := NewPrometheusMetrics()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	require.Error(t, DoInTx(context.Background(), db, func(tx *sql.Tx) error {
+		return errors.New("insert error")
+	}, WithMetrics(metrics), WithTxAnnotation("create_user")))
+
+	testutil.RequireSamplesCountInCounter(t, metrics.TxRollbacks.With(prometheus.Labels{PrometheusMetricsLabelTx: "create_user"}), 1)
+	testutil.RequireSamplesCountInCounter(t, metrics.QueryErrors.With(prometheus.Labels{
+		PrometheusMetricsLabelQuery: "create_user", PrometheusMetricsLabelErrorClass: string(QueryErrorClassOther)}), 1)
+}
+
+func TestObservePoolStats(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db.SetMaxOpenConns(5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	recorder := &poolStatsRecorder{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ObservePoolStats(ctx, db, "users", recorder, time.Millisecond)
+	}()
+	require.Eventually(t, func() bool { return recorder.observations.Load() >= 2 }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	metrics := NewPrometheusMetrics()
+	metrics.ObservePoolStats("users", db.Stats())
+	require.Equal(t, 5.0, promtestutil.ToFloat64(metrics.PoolMaxOpenConns.With(prometheus.Labels{PrometheusMetricsLabelDBName: "users"})))
+}
+
+func TestPrometheusMetrics_PoolWaitCounters(t *testing.T) {
+	metrics := NewPrometheusMetricsWithOpts(PrometheusMetricsOpts{CurriedLabelNames: []string{"service"}})
+	usersMetrics := metrics.MustCurryWith(prometheus.Labels{"service": "users"})
+	ordersMetrics := metrics.MustCurryWith(prometheus.Labels{"service": "orders"})
+	labels := prometheus.Labels{PrometheusMetricsLabelDBName: "main"}
+
+	usersMetrics.ObservePoolStats("main", sql.DBStats{WaitCount: 3, WaitDuration: 2 * time.Second})
+	usersMetrics.ObservePoolStats("main", sql.DBStats{WaitCount: 5, WaitDuration: 3 * time.Second})
+	ordersMetrics.ObservePoolStats("main", sql.DBStats{WaitCount: 1, WaitDuration: time.Second})
+	require.Equal(t, 5.0, promtestutil.ToFloat64(usersMetrics.PoolWaitCount.With(labels)))
+	require.Equal(t, 3.0, promtestutil.ToFloat64(usersMetrics.PoolWaitDurationSecs.With(labels)))
+	require.Equal(t, 1.0, promtestutil.ToFloat64(ordersMetrics.PoolWaitCount.With(labels)))
+
+	// Totals of the reopened pool start from zero, so they are added as is.
+	usersMetrics.ObservePoolStats("main", sql.DBStats{WaitCount: 2, WaitDuration: time.Second})
+	require.Equal(t, 7.0, promtestutil.ToFloat64(usersMetrics.PoolWaitCount.With(labels)))
+	require.Equal(t, 4.0, promtestutil.ToFloat64(usersMetrics.PoolWaitDurationSecs.With(labels)))
+
+	problems, err := promtestutil.CollectAndLint(metrics.PoolWaitCount)
+	require.NoError(t, err)
+	require.Empty(t, problems)
+}
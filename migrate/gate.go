@@ -0,0 +1,250 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/acronis/go-appkit/httpserver"
+	"github.com/acronis/go-appkit/log"
+)
+
+// Default values for MigrationGateOpts.
+const (
+	DefaultMigrationGateTimeout      = 5 * time.Minute
+	DefaultMigrationGatePollInterval = 2 * time.Second
+	DefaultMigrationGateComponent    = "db_migrations"
+)
+
+// ErrMigrationGateTimeout is returned when migrations are not applied within the timeout of the MigrationGate.
+var ErrMigrationGateTimeout = errors.New("timed out waiting for db migrations")
+
+// MigrationLocker is used by MigrationGate to ensure that migrations are applied by only one service instance at a time
+// (see distrlock.NewMigrationLocker).
+type MigrationLocker interface {
+	// TryLock tries to acquire the lock. It returns false if the lock is held by someone else.
+	TryLock(ctx context.Context) (bool, error)
+	// Unlock releases the acquired lock.
+	Unlock(ctx context.Context) error
+}
+
+// MigrationGateOpts contains options for MigrationGate.
+type MigrationGateOpts struct {
+	// Locker guards applying of migrations. If it's nil, migrations are applied without locking
+	// (it's safe only if there is a single service instance).
+	Locker MigrationLocker
+	// Timeout is the maximum time of waiting for migrations. DefaultMigrationGateTimeout is used if it's not set.
+	Timeout time.Duration
+	// PollInterval is the interval of checking whether migrations are applied by another instance
+	// that holds the lock. DefaultMigrationGatePollInterval is used if it's not set.
+	PollInterval time.Duration
+	// ComponentName is the name of the component in the health-check result (see MigrationGate.Check).
+	// DefaultMigrationGateComponent is used if it's not set.
+	ComponentName string
+}
+
+// MigrationGate blocks the service readiness until the migrations are applied, either by this instance
+// under the lock, or by another instance that holds the lock (in this case, the gate waits until it finishes).
+// It replaces init containers that run migrations and race with each other.
+// MigrationGate implements go-appkit's service.Unit interface, so it may be started together with other units,
+// and its Check method may be used in the readiness health-check handler.
+type MigrationGate struct {
+	mm         *MigrationsManager
+	migrations []Migration
+	logger     log.FieldLogger
+	opts       MigrationGateOpts
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	done      chan struct{}
+	doneOnce  sync.Once
+	mu        sync.Mutex
+	err       error
+}
+
+// NewMigrationGate creates a new MigrationGate.
+func NewMigrationGate(
+	mm *MigrationsManager, migrations []Migration, logger log.FieldLogger, opts MigrationGateOpts,
+) *MigrationGate {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultMigrationGateTimeout
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultMigrationGatePollInterval
+	}
+	if opts.ComponentName == "" {
+		opts.ComponentName = DefaultMigrationGateComponent
+	}
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	return &MigrationGate{
+		mm:         mm,
+		migrations: migrations,
+		logger:     logger,
+		opts:       opts,
+		ctx:        ctx,
+		ctxCancel:  ctxCancel,
+		done:       make(chan struct{}),
+	}
+}
+
+// Start waits until migrations are applied (see Wait). It's called by go-appkit's service.
+// If migrations cannot be applied, the error is sent to the fatalError channel.
+func (g *MigrationGate) Start(fatalError chan<- error) {
+	if err := g.Wait(g.ctx); err != nil && !errors.Is(err, context.Canceled) {
+		fatalError <- err
+	}
+}
+
+// Stop stops waiting for migrations.
+func (g *MigrationGate) Stop(gracefully bool) error {
+	g.ctxCancel()
+	return nil
+}
+
+// Done returns a channel that is closed when migrations are applied or waiting for them is failed.
+func (g *MigrationGate) Done() <-chan struct{} {
+	return g.done
+}
+
+// Ready returns true if migrations are applied.
+func (g *MigrationGate) Ready() bool {
+	select {
+	case <-g.done:
+		return g.Err() == nil
+	default:
+		return false
+	}
+}
+
+// Err returns the error of waiting for migrations.
+func (g *MigrationGate) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
+
+// Check returns the status of the gate as a health-check result, so it's compatible
+// with go-appkit's httpserver.NewHealthCheckHandlerContext and may be used for the readiness probe.
+func (g *MigrationGate) Check(ctx context.Context) (httpserver.HealthCheckResult, error) {
+	status := httpserver.HealthCheckStatusOK
+	if !g.Ready() {
+		status = httpserver.HealthCheckStatusFail
+	}
+	return httpserver.HealthCheckResult{g.opts.ComponentName: status}, ctx.Err()
+}
+
+// Wait blocks until migrations are applied. If they are not applied yet, Wait tries to acquire the lock and apply them.
+// If the lock is held by another instance, Wait checks periodically whether migrations are applied
+// and tries to acquire the lock again (e.g., if another instance failed).
+func (g *MigrationGate) Wait(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, g.opts.Timeout)
+	defer cancel()
+	err := g.wait(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("%w: %w", ErrMigrationGateTimeout, err)
+	}
+	g.mu.Lock()
+	g.err = err
+	g.mu.Unlock()
+	g.doneOnce.Do(func() { close(g.done) })
+	return err
+}
+
+func (g *MigrationGate) wait(ctx context.Context) error {
+	startedAt := time.Now()
+	waitingLogged := false
+	for {
+		applied, err := g.applied()
+		if err != nil {
+			return err
+		}
+		if applied {
+			g.logger.Info("db migrations are applied", log.Duration("waited", time.Since(startedAt)))
+			return nil
+		}
+
+		locked, err := g.tryLock(ctx)
+		if err != nil {
+			return err
+		}
+		if locked {
+			return g.runUnderLock(ctx, startedAt)
+		}
+
+		if !waitingLogged {
+			g.logger.Info("db migrations are being applied by another instance, waiting")
+			waitingLogged = true
+		}
+		timer := time.NewTimer(g.opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (g *MigrationGate) tryLock(ctx context.Context) (bool, error) {
+	if g.opts.Locker == nil {
+		return true, nil
+	}
+	locked, err := g.opts.Locker.TryLock(ctx)
+	if err != nil {
+		return false, fmt.Errorf("acquire db migrations lock: %w", err)
+	}
+	return locked, nil
+}
+
+func (g *MigrationGate) runUnderLock(ctx context.Context, startedAt time.Time) (err error) {
+	if g.opts.Locker != nil {
+		defer func() {
+			// Lock should be released even if ctx is done.
+			if unlockErr := g.opts.Locker.Unlock(context.Background()); unlockErr != nil {
+				g.logger.Error("failed to release db migrations lock", log.Error(unlockErr))
+				if err == nil {
+					err = fmt.Errorf("release db migrations lock: %w", unlockErr)
+				}
+			}
+		}()
+	}
+	// Migrations may be applied by another instance before the lock was acquired, so they are checked again.
+	applied, err := g.applied()
+	if err != nil {
+		return err
+	}
+	if !applied {
+		g.logger.Info("applying db migrations")
+		if err = g.mm.Run(g.migrations, MigrationsDirectionUp); err != nil {
+			return err
+		}
+	}
+	g.logger.Info("db migrations are applied", log.Duration("waited", time.Since(startedAt)))
+	return nil
+}
+
+// applied returns true if all migrations of the gate are applied.
+func (g *MigrationGate) applied() (bool, error) {
+	status, err := g.mm.Status()
+	if err != nil {
+		return false, err
+	}
+	appliedIDs := make(map[string]struct{}, len(status.AppliedMigrations))
+	for _, appliedMig := range status.AppliedMigrations {
+		appliedIDs[appliedMig.ID] = struct{}{}
+	}
+	for _, mig := range g.migrations {
+		if _, ok := appliedIDs[mig.ID()]; !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,144 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/httpserver"
+	"github.com/acronis/go-appkit/log/logtest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// fakeMigrationLocker emulates the lock shared between instances (the shared object holds the state).
+type fakeMigrationLocker struct {
+	mu       sync.Mutex
+	lockedBy *fakeMigrationLocker
+	shared   *fakeMigrationLocker
+}
+
+func (l *fakeMigrationLocker) TryLock(ctx context.Context) (bool, error) {
+	l.shared.mu.Lock()
+	defer l.shared.mu.Unlock()
+	if l.shared.lockedBy != nil {
+		return false, nil
+	}
+	l.shared.lockedBy = l
+	return true, nil
+}
+
+func (l *fakeMigrationLocker) Unlock(ctx context.Context) error {
+	l.shared.mu.Lock()
+	defer l.shared.mu.Unlock()
+	l.shared.lockedBy = nil
+	return nil
+}
+
+func newTestMigrationGateDB(t *testing.T) (*sql.DB, *MigrationsManager) {
+	t.Helper()
+	dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "gate.db")+"?_journal=MEMORY&_sync=OFF&_busy_timeout=5000")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	migMngr, err := NewMigrationsManager(dbConn, dbkit.DialectSQLite, logtest.NewLogger())
+	require.NoError(t, err)
+	return dbConn, migMngr
+}
+
+func TestMigrationGate(t *testing.T) {
+	migrations := []Migration{newTestMigration00001CreateTables(), newTestMigration00002SeedTabled()}
+
+	t.Run("migrations are applied by the instance under lock", func(t *testing.T) {
+		dbConn, migMngr := newTestMigrationGateDB(t)
+		shared := &fakeMigrationLocker{}
+		gate := NewMigrationGate(migMngr, migrations, logtest.NewLogger(),
+			MigrationGateOpts{Locker: &fakeMigrationLocker{shared: shared}})
+
+		result, err := gate.Check(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, httpserver.HealthCheckResult{DefaultMigrationGateComponent: httpserver.HealthCheckStatusFail}, result)
+
+		fatalErr := make(chan error, 1)
+		gate.Start(fatalErr)
+		require.Empty(t, fatalErr)
+		require.True(t, gate.Ready())
+		require.Nil(t, shared.lockedBy, "lock should be released")
+
+		result, err = gate.Check(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, httpserver.HealthCheckResult{DefaultMigrationGateComponent: httpserver.HealthCheckStatusOK}, result)
+
+		var usersCount int
+		require.NoError(t, dbConn.QueryRow("SELECT COUNT(*) FROM users").Scan(&usersCount))
+		require.Equal(t, 5, usersCount)
+
+		// Already applied migrations are not applied again (seeding would fail on the second run).
+		gate = NewMigrationGate(migMngr, migrations, logtest.NewLogger(), MigrationGateOpts{})
+		require.NoError(t, gate.Wait(context.Background()))
+	})
+
+	t.Run("waiting for another instance", func(t *testing.T) {
+		_, migMngr := newTestMigrationGateDB(t)
+		shared := &fakeMigrationLocker{}
+		anotherInstanceLocker := &fakeMigrationLocker{shared: shared}
+		locked, err := anotherInstanceLocker.TryLock(context.Background())
+		require.NoError(t, err)
+		require.True(t, locked)
+
+		gate := NewMigrationGate(migMngr, migrations, logtest.NewLogger(), MigrationGateOpts{
+			Locker: &fakeMigrationLocker{shared: shared}, PollInterval: time.Millisecond * 10,
+		})
+		waitErr := make(chan error)
+		go func() { waitErr <- gate.Wait(context.Background()) }()
+
+		time.Sleep(time.Millisecond * 50)
+		require.False(t, gate.Ready())
+
+		// Another instance applies migrations and releases the lock.
+		require.NoError(t, migMngr.Run(migrations, MigrationsDirectionUp))
+		require.NoError(t, anotherInstanceLocker.Unlock(context.Background()))
+		require.NoError(t, <-waitErr)
+		require.True(t, gate.Ready())
+		require.Nil(t, shared.lockedBy)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		_, migMngr := newTestMigrationGateDB(t)
+		shared := &fakeMigrationLocker{}
+		shared.lockedBy = shared // Held by another instance forever.
+		gate := NewMigrationGate(migMngr, migrations, logtest.NewLogger(), MigrationGateOpts{
+			Locker: &fakeMigrationLocker{shared: shared}, PollInterval: time.Millisecond * 10, Timeout: time.Millisecond * 50,
+		})
+		fatalErr := make(chan error, 1)
+		gate.Start(fatalErr)
+		require.ErrorIs(t, <-fatalErr, ErrMigrationGateTimeout)
+		require.ErrorIs(t, gate.Err(), ErrMigrationGateTimeout)
+		require.False(t, gate.Ready())
+		<-gate.Done()
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		_, migMngr := newTestMigrationGateDB(t)
+		shared := &fakeMigrationLocker{}
+		shared.lockedBy = shared
+		gate := NewMigrationGate(migMngr, migrations, logtest.NewLogger(), MigrationGateOpts{
+			Locker: &fakeMigrationLocker{shared: shared}, PollInterval: time.Millisecond * 10,
+		})
+		fatalErr := make(chan error, 1)
+		go gate.Start(fatalErr)
+		require.NoError(t, gate.Stop(true))
+		<-gate.Done()
+		require.Empty(t, fatalErr)
+		require.False(t, gate.Ready())
+	})
+}
@@ -34,12 +34,19 @@ const (
 	MigrationsDirectionDown MigrationsDirection = "down"
 )
 
+// Names of the migration runs that are passed to the metrics collector as query names (see MigrationsManagerOpts.Metrics).
+const (
+	MetricsQueryMigrateUp   = "migrate_up"
+	MetricsQueryMigrateDown = "migrate_down"
+)
+
 // MigrationsNoLimit contains a special value that will not limit the number of migrations to apply.
 const MigrationsNoLimit = 0
 
 // Migration is an interface for all database migrations.
 // Migration may implement RawMigrator interface for full control.
 // Migration may implement TxDisabler interface to control transactions.
+// Migration may implement Verifier interface to check its result after applying.
 type Migration interface {
 	ID() string
 	UpSQL() []string
@@ -97,6 +104,8 @@ type CustomMigration struct {
 	downSQL []string
 	upFn    func(tx *sql.Tx) error
 	downFn  func(tx *sql.Tx) error
+
+	upVerifications []Verification
 }
 
 // NewCustomMigration creates simplified but customizable migration.
@@ -131,21 +140,30 @@ func (m *CustomMigration) DownFn() func(tx *sql.Tx) error {
 
 // MigrationsManager is an object for running migrations.
 type MigrationsManager struct {
-	db      *sql.DB
-	Dialect dbkit.Dialect
-	migSet  migrate.MigrationSet
-	logger  log.FieldLogger
+	db           *sql.DB
+	Dialect      dbkit.Dialect
+	migSet       migrate.MigrationSet
+	logger       log.FieldLogger
+	idComparator IDComparator
+	metrics      dbkit.MetricsCollector
 }
 
 // MigrationsManagerOpts holds the Migration Manager options to be used in NewMigrationsManagerWithOpts
 type MigrationsManagerOpts struct {
 	TableName string
+	// IDComparator defines the order of migrations (e.g., NumericPrefixIDComparator, TimestampPrefixIDComparator,
+	// SemVerIDComparator). If specified, IDs of passed migrations are validated to match the numbering scheme
+	// and be unique in it, the order of passed migrations doesn't matter.
+	IDComparator IDComparator
+	// Metrics observes durations and failures of running migrations (see Run and RunLimit)
+	// as queries named MetricsQueryMigrateUp and MetricsQueryMigrateDown. dbkit.NoOpMetricsCollector is used if it's nil.
+	Metrics dbkit.MetricsCollector
 }
 
 // NewMigrationsManager creates a new MigrationsManager.
 func NewMigrationsManager(dbConn *sql.DB, dialect dbkit.Dialect, logger log.FieldLogger) (*MigrationsManager, error) {
 	migSet := migrate.MigrationSet{TableName: MigrationsTableName}
-	return &MigrationsManager{dbConn, normalizeDialect(dialect), migSet, logger}, nil
+	return &MigrationsManager{dbConn, normalizeDialect(dialect), migSet, logger, nil, dbkit.NoOpMetricsCollector{}}, nil
 }
 
 // NewMigrationsManagerWithOpts creates a new MigrationsManager with custom options
@@ -159,8 +177,12 @@ func NewMigrationsManagerWithOpts(
 	if tableName == "" {
 		tableName = MigrationsTableName
 	}
+	metrics := opts.Metrics
+	if metrics == nil {
+		metrics = dbkit.NoOpMetricsCollector{}
+	}
 	migSet := migrate.MigrationSet{TableName: tableName}
-	return &MigrationsManager{dbConn, normalizeDialect(dialect), migSet, logger}, nil
+	return &MigrationsManager{dbConn, normalizeDialect(dialect), migSet, logger, opts.IDComparator, metrics}, nil
 }
 
 // TODO: normalizeDialect sets standard lib/pq driver for pgx dialect because pgx isn't supported by sql-migrate yet.
@@ -232,16 +254,30 @@ func (mm *MigrationsManager) RunLimit(migrations []Migration, direction Migratio
 	source := &migrate.MemoryMigrationSource{Migrations: convertedMigrationList}
 
 	var dir migrate.MigrationDirection
+	var metricsQuery string
 	switch direction {
 	case MigrationsDirectionUp:
-		dir = migrate.Up
+		dir, metricsQuery = migrate.Up, MetricsQueryMigrateUp
 	case MigrationsDirectionDown:
-		dir = migrate.Down
+		dir, metricsQuery = migrate.Down, MetricsQueryMigrateDown
 	default:
 		return fmt.Errorf("unknown direction %q", dir)
 	}
 
-	n, err := mm.migSet.ExecMax(mm.db, string(mm.Dialect), source, dir, limit)
+	var n int
+	var err error
+	startedAt := time.Now()
+	if verifications := getVerifications(migrations); mm.idComparator != nil {
+		n, err = mm.execOrdered(convertedMigrationList, dir, verifications, limit)
+	} else if dir == migrate.Up && len(verifications) != 0 {
+		n, err = mm.execUpWithVerification(source, verifications, limit)
+	} else {
+		n, err = mm.migSet.ExecMax(mm.db, string(mm.Dialect), source, dir, limit)
+	}
+	mm.metrics.ObserveQueryDuration(metricsQuery, time.Since(startedAt))
+	if err != nil {
+		mm.metrics.ObserveQueryError(metricsQuery, err)
+	}
 
 	logger := mm.logger.With(log.String("direction", string(direction)), log.Int("applied", n))
 	if err != nil {
@@ -253,6 +289,7 @@ func (mm *MigrationsManager) RunLimit(migrations []Migration, direction Migratio
 }
 
 // Status returns the current migration status.
+// Applied migrations are sorted by IDComparator if it's specified, or by ID otherwise.
 func (mm *MigrationsManager) Status() (MigrationStatus, error) {
 	var migStatus MigrationStatus
 
@@ -264,6 +301,12 @@ func (mm *MigrationsManager) Status() (MigrationStatus, error) {
 	for _, migRec := range appliedMigRecords {
 		migStatus.AppliedMigrations = append(migStatus.AppliedMigrations, AppliedMigration{ID: migRec.Id, AppliedAt: migRec.AppliedAt})
 	}
+	if mm.idComparator != nil {
+		sort.SliceStable(migStatus.AppliedMigrations, func(i, j int) bool {
+			res, _ := mm.idComparator.Compare(migStatus.AppliedMigrations[i].ID, migStatus.AppliedMigrations[j].ID)
+			return res < 0
+		})
+	}
 
 	return migStatus, nil
 }
@@ -12,6 +12,7 @@ import (
 	"embed"
 	"fmt"
 	"io"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -257,6 +258,41 @@ func TestCreationMigrationManagerWithOpts(t *testing.T) {
 	require.Equal(t, 0, rowsNum)
 }
 
+// recordingMetrics records the number of observed durations and errors by the query name.
+type recordingMetrics struct {
+	dbkit.NoOpMetricsCollector
+	durations map[string]int
+	errors    map[string]int
+}
+
+func (m *recordingMetrics) ObserveQueryDuration(query string, _ time.Duration) {
+	m.durations[query]++
+}
+
+func (m *recordingMetrics) ObserveQueryError(query string, _ error) {
+	m.errors[query]++
+}
+
+func TestMigrationsManager_Metrics(t *testing.T) {
+	dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer requireNoErrOnClose(t, dbConn)
+
+	metrics := &recordingMetrics{durations: make(map[string]int), errors: make(map[string]int)}
+	migMngr, err := NewMigrationsManagerWithOpts(dbConn, dbkit.DialectSQLite, logtest.NewLogger(),
+		MigrationsManagerOpts{Metrics: metrics})
+	require.NoError(t, err)
+
+	migrations := []Migration{newTestMigration00001CreateTables(), newTestMigration00002SeedTabled()}
+	require.NoError(t, migMngr.Run(migrations, MigrationsDirectionUp))
+	require.NoError(t, migMngr.Run(migrations, MigrationsDirectionDown))
+	brokenMigration := NewCustomMigration("00003_broken", []string{"CREATE TABLE"}, nil, nil, nil)
+	require.Error(t, migMngr.Run([]Migration{brokenMigration}, MigrationsDirectionUp))
+
+	require.Equal(t, map[string]int{MetricsQueryMigrateUp: 2, MetricsQueryMigrateDown: 1}, metrics.durations)
+	require.Equal(t, map[string]int{MetricsQueryMigrateUp: 1}, metrics.errors)
+}
+
 func requireNoErrOnClose(t *testing.T, closer io.Closer) {
 	t.Helper()
 	require.NoError(t, closer.Close())
@@ -0,0 +1,240 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+// IDComparator defines the order of migrations by their IDs.
+// By default (if IDComparator is not specified in MigrationsManagerOpts), migrations are ordered by sql-migrate:
+// IDs with a numeric prefix go first in the order of the number, and other IDs are compared as plain strings,
+// so mixed numbering schemes (e.g., "1.10.0_add_index" and "1.9.0_create_table") may be sorted surprisingly.
+type IDComparator interface {
+	// Compare returns a negative number if the migration with ID a must be applied before the migration with ID b,
+	// a positive number if after, and zero if they have the same position in the order
+	// (e.g., "001_a" and "1_b" for NumericPrefixIDComparator).
+	// Error is returned if any of IDs doesn't match the numbering scheme.
+	Compare(a, b string) (int, error)
+}
+
+// NumericPrefixIDComparator orders migrations by the numeric prefix of ID (e.g., "0012_add_users").
+// Unlike sql-migrate, it doesn't accept IDs without the numeric prefix, and the number may be of any length.
+type NumericPrefixIDComparator struct{}
+
+var numericPrefixRegexp = regexp.MustCompile(`^(\d+)`)
+
+// Compare compares IDs by their numeric prefixes.
+func (NumericPrefixIDComparator) Compare(a, b string) (int, error) {
+	aNum, err := parseNumericPrefix(a)
+	if err != nil {
+		return 0, err
+	}
+	bNum, err := parseNumericPrefix(b)
+	if err != nil {
+		return 0, err
+	}
+	return compareNumbers(aNum, bNum), nil
+}
+
+func parseNumericPrefix(id string) (string, error) {
+	matches := numericPrefixRegexp.FindStringSubmatch(id)
+	if matches == nil {
+		return "", fmt.Errorf("migration ID %q doesn't have a numeric prefix", id)
+	}
+	return matches[1], nil
+}
+
+// DefaultTimestampIDLayout is the default layout of the timestamp prefix used by TimestampPrefixIDComparator.
+const DefaultTimestampIDLayout = "20060102150405"
+
+// TimestampPrefixIDComparator orders migrations by the timestamp prefix of ID (e.g., "20250131120000_add_users").
+type TimestampPrefixIDComparator struct {
+	// Layout is the layout of the timestamp (see time.Parse), DefaultTimestampIDLayout is used if empty.
+	Layout string
+}
+
+// Compare compares IDs by their timestamp prefixes.
+func (c TimestampPrefixIDComparator) Compare(a, b string) (int, error) {
+	aTime, err := c.parseTimestamp(a)
+	if err != nil {
+		return 0, err
+	}
+	bTime, err := c.parseTimestamp(b)
+	if err != nil {
+		return 0, err
+	}
+	return aTime.Compare(bTime), nil
+}
+
+func (c TimestampPrefixIDComparator) parseTimestamp(id string) (time.Time, error) {
+	layout := c.Layout
+	if layout == "" {
+		layout = DefaultTimestampIDLayout
+	}
+	if len(id) < len(layout) || (len(id) > len(layout) && isDigit(id[len(layout)])) {
+		return time.Time{}, fmt.Errorf("migration ID %q doesn't have a timestamp prefix in %q layout", id, layout)
+	}
+	t, err := time.Parse(layout, id[:len(layout)])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("migration ID %q doesn't have a timestamp prefix in %q layout: %w", id, layout, err)
+	}
+	return t, nil
+}
+
+// SemVerIDComparator orders migrations by the semantic version prefix of ID
+// in the "[v]MAJOR.MINOR.PATCH" format followed by "_" or the end of ID (e.g., "v1.10.0_add_users").
+type SemVerIDComparator struct{}
+
+var semVerPrefixRegexp = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:_|$)`)
+
+// Compare compares IDs by their semantic version prefixes.
+func (SemVerIDComparator) Compare(a, b string) (int, error) {
+	aVer := semVerPrefixRegexp.FindStringSubmatch(a)
+	if aVer == nil {
+		return 0, fmt.Errorf("migration ID %q doesn't have a semantic version prefix", a)
+	}
+	bVer := semVerPrefixRegexp.FindStringSubmatch(b)
+	if bVer == nil {
+		return 0, fmt.Errorf("migration ID %q doesn't have a semantic version prefix", b)
+	}
+	for i := 1; i < len(aVer); i++ {
+		if res := compareNumbers(aVer[i], bVer[i]); res != 0 {
+			return res, nil
+		}
+	}
+	return 0, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// compareNumbers compares non-negative decimal numbers of any length.
+func compareNumbers(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// sortMigrationIDs sorts IDs with the comparator and checks that they match the numbering scheme
+// and strictly increase (i.e., there are no IDs with the same position in the order).
+func sortMigrationIDs(ids []string, comparator IDComparator) error {
+	for _, id := range ids {
+		if _, err := comparator.Compare(id, id); err != nil {
+			return err
+		}
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		res, _ := comparator.Compare(ids[i], ids[j])
+		return res < 0
+	})
+	for i := 1; i < len(ids); i++ {
+		if res, _ := comparator.Compare(ids[i-1], ids[i]); res == 0 {
+			return fmt.Errorf("migrations %q and %q have the same position in the order", ids[i-1], ids[i])
+		}
+	}
+	return nil
+}
+
+// execOrdered applies (or rolls back) migrations one by one in the order defined by IDComparator,
+// running verification queries after applying each of them.
+// sql-migrate always uses its own order, so each step is planned with the source that makes it
+// to choose exactly the next migration.
+func (mm *MigrationsManager) execOrdered(
+	migrations []*migrate.Migration, dir migrate.MigrationDirection, verifications map[string][]Verification, limit int,
+) (int, error) {
+	migrationsByID := make(map[string]*migrate.Migration, len(migrations))
+	ids := make([]string, 0, len(migrations))
+	for _, m := range migrations {
+		migrationsByID[m.Id] = m
+		ids = append(ids, m.Id)
+	}
+	if err := sortMigrationIDs(ids, mm.idComparator); err != nil {
+		return 0, err
+	}
+
+	records, err := mm.migSet.GetMigrationRecords(mm.db, string(mm.Dialect))
+	if err != nil {
+		return 0, err
+	}
+	applied := make(map[string]bool, len(records))
+	for _, rec := range records {
+		if _, ok := migrationsByID[rec.Id]; !ok {
+			return 0, fmt.Errorf("unknown migration in database: %s", rec.Id)
+		}
+		applied[rec.Id] = true
+	}
+	if dir == migrate.Down {
+		for i, j := 0, len(ids)-1; i < j; i, j = i+1, j-1 {
+			ids[i], ids[j] = ids[j], ids[i]
+		}
+	}
+
+	migSet := mm.migSet
+	migSet.IgnoreUnknown = true // Sources contain only the migrations needed for the step.
+	n := 0
+	for _, id := range ids {
+		if limit != MigrationsNoLimit && n >= limit {
+			break
+		}
+		if applied[id] == (dir == migrate.Up) {
+			continue
+		}
+		m := migrationsByID[id]
+		source := &migrate.MemoryMigrationSource{Migrations: []*migrate.Migration{m}}
+		if dir == migrate.Up {
+			// The migration that precedes the last applied one in sql-migrate order is planned as a "catch-up",
+			// otherwise it's planned only if it follows the last applied one in the source.
+			if lastRun := lastRunMigration(applied); lastRun != nil && !m.Less(lastRun) {
+				source.Migrations = []*migrate.Migration{lastRun, m}
+			}
+		}
+		var stepN int
+		stepN, err = migSet.ExecMax(mm.db, string(mm.Dialect), source, dir, 1)
+		n += stepN
+		if err != nil {
+			return n, err
+		}
+		if stepN != 1 {
+			return n, fmt.Errorf("migration %s was not planned", id)
+		}
+		applied[id] = dir == migrate.Up
+		if dir == migrate.Up {
+			if err = mm.verify(id, verifications[id]); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// lastRunMigration returns the last applied migration in sql-migrate order.
+func lastRunMigration(applied map[string]bool) *migrate.Migration {
+	var lastRun *migrate.Migration
+	for id, ok := range applied {
+		if !ok {
+			continue
+		}
+		if m := (&migrate.Migration{Id: id}); lastRun == nil || lastRun.Less(m) {
+			lastRun = m
+		}
+	}
+	return lastRun
+}
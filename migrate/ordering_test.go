@@ -0,0 +1,127 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package migrate
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/acronis/go-appkit/log/logtest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+func TestIDComparators(t *testing.T) {
+	tests := []struct {
+		name       string
+		comparator IDComparator
+		sortedIDs  []string
+		invalidID  string
+		wantErr    string
+	}{
+		{
+			name:       "numeric prefix",
+			comparator: NumericPrefixIDComparator{},
+			sortedIDs:  []string{"1_create_users", "02_add_email", "10_add_index", "99999999999999999999_last"},
+			invalidID:  "create_users",
+			wantErr:    `migration ID "create_users" doesn't have a numeric prefix`,
+		},
+		{
+			name:       "timestamp prefix",
+			comparator: TimestampPrefixIDComparator{},
+			sortedIDs:  []string{"20241231235959_create_users", "20250101000000_add_email", "20250102000000"},
+			invalidID:  "202501010000001_add_index",
+			wantErr:    `migration ID "202501010000001_add_index" doesn't have a timestamp prefix in "20060102150405" layout`,
+		},
+		{
+			name:       "timestamp prefix, custom layout",
+			comparator: TimestampPrefixIDComparator{Layout: "2006_01_02"},
+			sortedIDs:  []string{"2024_12_31_create_users", "2025_01_01_add_email"},
+			invalidID:  "2025_13_01_add_index",
+			wantErr:    `migration ID "2025_13_01_add_index" doesn't have a timestamp prefix in "2006_01_02" layout: parsing time "2025_13_01": month out of range`,
+		},
+		{
+			name:       "semantic version",
+			comparator: SemVerIDComparator{},
+			sortedIDs:  []string{"0.9.1_create_users", "v1.2.0_add_email", "1.9.0_add_index", "1.10.0_add_notes", "2.0.0"},
+			invalidID:  "1.10_add_notes",
+			wantErr:    `migration ID "1.10_add_notes" doesn't have a semantic version prefix`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := make([]string, len(tt.sortedIDs))
+			for i := range tt.sortedIDs {
+				ids[i] = tt.sortedIDs[len(tt.sortedIDs)-1-i]
+			}
+			require.NoError(t, sortMigrationIDs(ids, tt.comparator))
+			require.Equal(t, tt.sortedIDs, ids)
+
+			_, err := tt.comparator.Compare(tt.sortedIDs[0], tt.invalidID)
+			require.EqualError(t, err, tt.wantErr)
+			require.EqualError(t, sortMigrationIDs(append(ids, tt.invalidID), tt.comparator), tt.wantErr)
+		})
+	}
+
+	t.Run("not unique", func(t *testing.T) {
+		err := sortMigrationIDs([]string{"1_create_users", "2_add_email", "001_add_index"}, NumericPrefixIDComparator{})
+		require.EqualError(t, err, `migrations "1_create_users" and "001_add_index" have the same position in the order`)
+	})
+}
+
+func TestMigrationsManager_RunWithIDComparator(t *testing.T) {
+	dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "migrations.db")+"?_journal=MEMORY&_sync=OFF")
+	require.NoError(t, err)
+	defer requireNoErrOnClose(t, dbConn)
+
+	migMngr, err := NewMigrationsManagerWithOpts(dbConn, dbkit.DialectSQLite, logtest.NewLogger(),
+		MigrationsManagerOpts{IDComparator: SemVerIDComparator{}})
+	require.NoError(t, err)
+
+	// Plain string comparison (and sql-migrate) would apply 1.10.0 before 1.9.0.
+	migrations := []Migration{
+		NewCustomMigration("1.10.0_add_email",
+			[]string{`ALTER TABLE users ADD COLUMN email TEXT`}, []string{`ALTER TABLE users DROP COLUMN email`}, nil, nil),
+		NewCustomMigration("1.9.0_create_users",
+			[]string{`CREATE TABLE users (id INTEGER PRIMARY KEY)`}, []string{`DROP TABLE users`}, nil, nil),
+		NewCustomMigration("1.11.0_create_notes",
+			[]string{`CREATE TABLE notes (id INTEGER PRIMARY KEY)`}, []string{`DROP TABLE notes`}, nil, nil),
+	}
+	appliedIDs := func() []string {
+		status, statusErr := migMngr.Status()
+		require.NoError(t, statusErr)
+		var ids []string
+		for _, m := range status.AppliedMigrations {
+			ids = append(ids, m.ID)
+		}
+		return ids
+	}
+
+	require.NoError(t, migMngr.RunLimit(migrations, MigrationsDirectionUp, 2))
+	require.Equal(t, []string{"1.9.0_create_users", "1.10.0_add_email"}, appliedIDs())
+	require.NoError(t, migMngr.Run(migrations, MigrationsDirectionUp))
+	require.Equal(t, []string{"1.9.0_create_users", "1.10.0_add_email", "1.11.0_create_notes"}, appliedIDs())
+
+	// Migration that is added in the middle of the order is applied too.
+	migrations = append(migrations, NewCustomMigration("1.10.1_add_name",
+		[]string{`ALTER TABLE users ADD COLUMN name TEXT`}, []string{`ALTER TABLE users DROP COLUMN name`}, nil, nil))
+	require.NoError(t, migMngr.Run(migrations, MigrationsDirectionUp))
+	require.Equal(t, []string{"1.9.0_create_users", "1.10.0_add_email", "1.10.1_add_name", "1.11.0_create_notes"}, appliedIDs())
+
+	require.NoError(t, migMngr.RunLimit(migrations, MigrationsDirectionDown, 3))
+	require.Equal(t, []string{"1.9.0_create_users"}, appliedIDs())
+	require.NoError(t, migMngr.Run(migrations, MigrationsDirectionDown))
+	require.Empty(t, appliedIDs())
+
+	// Invalid and duplicated IDs are rejected before applying.
+	err = migMngr.Run(append(migrations, NewCustomMigration("v1.9.0_create_tags",
+		[]string{`CREATE TABLE tags (id INTEGER PRIMARY KEY)`}, []string{`DROP TABLE tags`}, nil, nil)), MigrationsDirectionUp)
+	require.EqualError(t, err, `migrations "1.9.0_create_users" and "v1.9.0_create_tags" have the same position in the order`)
+	require.Empty(t, appliedIDs())
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package migrate
+
+import (
+	"errors"
+	"fmt"
+
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+// ErrVerificationFailed is returned (wrapped) by MigrationsManager when a verification query
+// of the applied migration doesn't return the expected value.
+var ErrVerificationFailed = errors.New("migration verification failed")
+
+// Verification is a query that checks the result of the applied migration (e.g., a backfill).
+type Verification struct {
+	// Description describes the checked invariant, it's used in the error message.
+	Description string
+	// Query must return a single integer value (e.g., SELECT COUNT(*) FROM users WHERE email IS NULL).
+	Query string
+	// Expected is the value that Query must return.
+	Expected int64
+}
+
+// Verifier is an interface for Migration for declaring verification queries.
+// They are run by MigrationsManager right after applying the migration (in the up direction),
+// and the migrations run fails with ErrVerificationFailed if any of them doesn't hold,
+// so bad backfills are caught before traffic does. The migration stays applied in this case
+// (fix it with a new migration or roll it back), and the subsequent migrations are not applied.
+type Verifier interface {
+	UpVerifications() []Verification
+}
+
+// WithUpVerifications sets verification queries that are run after applying the migration (see Verifier).
+func (m *CustomMigration) WithUpVerifications(verifications ...Verification) *CustomMigration {
+	m.upVerifications = verifications
+	return m
+}
+
+// UpVerifications returns verification queries that are run after applying the migration.
+func (m *CustomMigration) UpVerifications() []Verification {
+	return m.upVerifications
+}
+
+func getVerifications(migrations []Migration) map[string][]Verification {
+	result := make(map[string][]Verification)
+	for _, m := range migrations {
+		if verifier, ok := m.(Verifier); ok && len(verifier.UpVerifications()) != 0 {
+			result[m.ID()] = verifier.UpVerifications()
+		}
+	}
+	return result
+}
+
+// execUpWithVerification applies migrations one by one running verification queries after each of them.
+func (mm *MigrationsManager) execUpWithVerification(
+	source migrate.MigrationSource, verifications map[string][]Verification, limit int,
+) (int, error) {
+	applied := 0
+	for limit == MigrationsNoLimit || applied < limit {
+		planned, _, err := mm.migSet.PlanMigration(mm.db, string(mm.Dialect), source, migrate.Up, 1)
+		if err != nil {
+			return applied, err
+		}
+		if len(planned) == 0 {
+			return applied, nil
+		}
+		n, err := mm.migSet.ExecMax(mm.db, string(mm.Dialect), source, migrate.Up, 1)
+		applied += n
+		if err != nil {
+			return applied, err
+		}
+		if err = mm.verify(planned[0].Id, verifications[planned[0].Id]); err != nil {
+			return applied, err
+		}
+	}
+	return applied, nil
+}
+
+func (mm *MigrationsManager) verify(migrationID string, verifications []Verification) error {
+	var errs []error
+	for _, v := range verifications {
+		var got int64
+		if err := mm.db.QueryRow(v.Query).Scan(&got); err != nil {
+			errs = append(errs, fmt.Errorf("%w: migration %s: %s: query: %w", ErrVerificationFailed, migrationID, v.Description, err))
+			continue
+		}
+		if got != v.Expected {
+			errs = append(errs, fmt.Errorf("%w: migration %s: %s: got %d, expected %d",
+				ErrVerificationFailed, migrationID, v.Description, got, v.Expected))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package migrate
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/acronis/go-appkit/log/logtest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+func TestMigrationsManager_RunWithVerifications(t *testing.T) {
+	newMigrations := func(backfillSQL string) []Migration {
+		return []Migration{
+			NewCustomMigration("00001_create_users",
+				[]string{`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, email TEXT)`,
+					`INSERT INTO users (name) VALUES ('Albert'), ('Bob'), ('Sam')`},
+				[]string{`DROP TABLE users`}, nil, nil).
+				WithUpVerifications(Verification{Description: "all users are created", Query: `SELECT COUNT(*) FROM users`, Expected: 3}),
+			NewCustomMigration("00002_backfill_emails",
+				[]string{backfillSQL}, []string{`UPDATE users SET email = NULL`}, nil, nil).
+				WithUpVerifications(
+					Verification{Description: "all users have emails", Query: `SELECT COUNT(*) FROM users WHERE email IS NULL`},
+					Verification{Description: "emails are unique", Query: `SELECT COUNT(*) - COUNT(DISTINCT email) FROM users`},
+				),
+			NewCustomMigration("00003_create_notes",
+				[]string{`CREATE TABLE notes (id INTEGER PRIMARY KEY)`}, []string{`DROP TABLE notes`}, nil, nil),
+		}
+	}
+	newMigrationsManager := func(t *testing.T) *MigrationsManager {
+		t.Helper()
+		dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "migrations.db")+"?_journal=MEMORY&_sync=OFF")
+		require.NoError(t, err)
+		t.Cleanup(func() { requireNoErrOnClose(t, dbConn) })
+		migMngr, err := NewMigrationsManager(dbConn, dbkit.DialectSQLite, logtest.NewLogger())
+		require.NoError(t, err)
+		return migMngr
+	}
+	appliedIDs := func(t *testing.T, migMngr *MigrationsManager) []string {
+		t.Helper()
+		status, err := migMngr.Status()
+		require.NoError(t, err)
+		var ids []string
+		for _, m := range status.AppliedMigrations {
+			ids = append(ids, m.ID)
+		}
+		return ids
+	}
+
+	t.Run("verifications hold", func(t *testing.T) {
+		migMngr := newMigrationsManager(t)
+		migrations := newMigrations(`UPDATE users SET email = lower(name) || '@example.com'`)
+		require.NoError(t, migMngr.RunLimit(migrations, MigrationsDirectionUp, 2))
+		require.Equal(t, []string{"00001_create_users", "00002_backfill_emails"}, appliedIDs(t, migMngr))
+		require.NoError(t, migMngr.Run(migrations, MigrationsDirectionUp))
+		require.Len(t, appliedIDs(t, migMngr), 3)
+
+		// Verifications are not run for the down direction.
+		require.NoError(t, migMngr.Run(migrations, MigrationsDirectionDown))
+		require.Empty(t, appliedIDs(t, migMngr))
+	})
+
+	t.Run("bad backfill", func(t *testing.T) {
+		migMngr := newMigrationsManager(t)
+		migrations := newMigrations(`UPDATE users SET email = 'user@example.com' WHERE name != 'Bob'`)
+		err := migMngr.Run(migrations, MigrationsDirectionUp)
+		require.ErrorIs(t, err, ErrVerificationFailed)
+		require.EqualError(t, err,
+			"migration verification failed: migration 00002_backfill_emails: all users have emails: got 1, expected 0\n"+
+				"migration verification failed: migration 00002_backfill_emails: emails are unique: got 2, expected 0")
+		// The failed migration stays applied, but the subsequent ones are not applied.
+		require.Equal(t, []string{"00001_create_users", "00002_backfill_emails"}, appliedIDs(t, migMngr))
+	})
+
+	t.Run("invalid verification query", func(t *testing.T) {
+		migMngr := newMigrationsManager(t)
+		migrations := newMigrations(`UPDATE users SET email = lower(name) || '@example.com'`)
+		migrations[2].(*CustomMigration).WithUpVerifications(Verification{Description: "notes", Query: `SELECT COUNT(*) FROM note`})
+		err := migMngr.Run(migrations, MigrationsDirectionUp)
+		require.ErrorIs(t, err, ErrVerificationFailed)
+		require.ErrorContains(t, err, "migration 00003_create_notes: notes: query: no such table: note")
+	})
+}
@@ -0,0 +1,301 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package mssql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/microsoft/go-mssqldb/msdsn"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// AzureSQLTokenScope is the OAuth 2.0 scope of access tokens for Azure SQL.
+const AzureSQLTokenScope = "https://database.windows.net/.default"
+
+// AccessTokenRefreshMargin is the time before the expiration of the access token when it's renewed,
+// so the token doesn't expire during the login sequence.
+const AccessTokenRefreshMargin = 5 * time.Minute
+
+const (
+	azureSQLResource         = "https://database.windows.net/"
+	defaultAzureADAuthority  = "https://login.microsoftonline.com"
+	defaultIMDSTokenEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+// AccessToken is an Azure AD access token.
+type AccessToken struct {
+	Token string
+	// ExpiresAt is the expiration time of the token, the token is not cached if it's zero.
+	ExpiresAt time.Time
+}
+
+// AccessTokenProvider provides Azure AD access tokens for Azure SQL (see AzureSQLTokenScope).
+type AccessTokenProvider interface {
+	AccessToken(ctx context.Context) (AccessToken, error)
+}
+
+// AccessTokenProviderFunc is an adapter to allow the use of ordinary functions as AccessTokenProvider.
+// It's a way to use credentials of the Azure SDK, e.g., for github.com/Azure/azure-sdk-for-go/sdk/azidentity:
+//
+//	mssql.AccessTokenProviderFunc(func(ctx context.Context) (mssql.AccessToken, error) {
+//		token, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{mssql.AzureSQLTokenScope}})
+//		return mssql.AccessToken{Token: token.Token, ExpiresAt: token.ExpiresOn}, err
+//	})
+type AccessTokenProviderFunc func(ctx context.Context) (AccessToken, error)
+
+// AccessToken calls f(ctx).
+func (f AccessTokenProviderFunc) AccessToken(ctx context.Context) (AccessToken, error) {
+	return f(ctx)
+}
+
+type azureADOptions struct {
+	tokenProvider AccessTokenProvider
+	httpClient    *http.Client
+}
+
+// AzureADOption is an option for NewAzureADConnector.
+type AzureADOption func(*azureADOptions)
+
+// WithAccessTokenProvider sets the provider of access tokens that is used instead of the one
+// built from dbkit.MSSQLAzureADConfig (e.g., to use the Azure SDK with other authentication methods).
+func WithAccessTokenProvider(provider AccessTokenProvider) AzureADOption {
+	return func(opts *azureADOptions) {
+		opts.tokenProvider = provider
+	}
+}
+
+// WithAzureADHTTPClient sets the HTTP client that is used for obtaining access tokens (http.DefaultClient by default).
+func WithAzureADHTTPClient(client *http.Client) AzureADOption {
+	return func(opts *azureADOptions) {
+		opts.httpClient = client
+	}
+}
+
+// NewAzureADConnector creates a new driver.Connector that authenticates to MSSQL (Azure SQL)
+// with Azure AD access tokens (federated authentication) according to cfg.MSSQL.AzureAD.
+// User and Password of the configuration are not used.
+// Access tokens are cached and renewed AccessTokenRefreshMargin before their expiration,
+// so new connections of a long-living pool don't fail because of the expired token.
+func NewAzureADConnector(cfg *dbkit.Config, options ...AzureADOption) (driver.Connector, error) {
+	if cfg.Dialect != dbkit.DialectMSSQL {
+		return nil, fmt.Errorf("unsupported dialect %q", cfg.Dialect)
+	}
+	opts := azureADOptions{httpClient: http.DefaultClient}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.tokenProvider == nil {
+		var err error
+		if opts.tokenProvider, err = newAzureADTokenProvider(&cfg.MSSQL.AzureAD, opts.httpClient); err != nil {
+			return nil, err
+		}
+	}
+
+	mssqlCfg := cfg.MSSQL
+	mssqlCfg.User, mssqlCfg.Password = "", ""
+	dsnCfg, err := msdsn.Parse(dbkit.MakeMSSQLDSN(&mssqlCfg))
+	if err != nil {
+		return nil, err
+	}
+	tokenCache := newAccessTokenCache(opts.tokenProvider)
+	return mssql.NewSecurityTokenConnector(dsnCfg, func(ctx context.Context) (string, error) {
+		token, tokenErr := tokenCache.AccessToken(ctx)
+		return token.Token, tokenErr
+	})
+}
+
+// OpenAzureAD opens a new database connection pool using Azure AD authentication (see NewAzureADConnector)
+// and initializes it in the same way as dbkit.Open.
+// If Azure AD authentication is not enabled in the configuration, it's the same as dbkit.Open.
+func OpenAzureAD(cfg *dbkit.Config, ping bool, options ...dbkit.OpenOption) (*sql.DB, error) {
+	if !cfg.MSSQL.AzureAD.Enabled() {
+		return dbkit.Open(cfg, ping, options...)
+	}
+	connector, err := NewAzureADConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(connector)
+	if err = dbkit.InitOpenedDB(db, cfg, ping, options...); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func newAzureADTokenProvider(cfg *dbkit.MSSQLAzureADConfig, httpClient *http.Client) (AccessTokenProvider, error) {
+	switch cfg.Method {
+	case dbkit.MSSQLAzureADAuthServicePrincipal:
+		if cfg.TenantID == "" || cfg.ClientID == "" || cfg.ClientSecret == "" {
+			return nil, fmt.Errorf("tenant ID, client ID and client secret must be specified for %s method", cfg.Method)
+		}
+		return &servicePrincipalTokenProvider{
+			authority:    defaultAzureADAuthority,
+			tenantID:     cfg.TenantID,
+			clientID:     cfg.ClientID,
+			clientSecret: cfg.ClientSecret,
+			httpClient:   httpClient,
+		}, nil
+	case dbkit.MSSQLAzureADAuthManagedIdentity:
+		return newManagedIdentityTokenProvider(cfg.ClientID, httpClient), nil
+	case "":
+		return nil, fmt.Errorf("method of Azure AD authentication is not specified")
+	default:
+		return nil, fmt.Errorf("unknown method of Azure AD authentication %q", cfg.Method)
+	}
+}
+
+// accessTokenCache caches the access token until AccessTokenRefreshMargin before its expiration.
+type accessTokenCache struct {
+	provider AccessTokenProvider
+	now      func() time.Time
+
+	mu    sync.Mutex
+	token AccessToken
+}
+
+func newAccessTokenCache(provider AccessTokenProvider) *accessTokenCache {
+	return &accessTokenCache{provider: provider, now: time.Now}
+}
+
+func (c *accessTokenCache) AccessToken(ctx context.Context) (AccessToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token.Token != "" && c.now().Add(AccessTokenRefreshMargin).Before(c.token.ExpiresAt) {
+		return c.token, nil
+	}
+	token, err := c.provider.AccessToken(ctx)
+	if err != nil {
+		return AccessToken{}, fmt.Errorf("get Azure AD access token: %w", err)
+	}
+	c.token = token
+	return token, nil
+}
+
+// servicePrincipalTokenProvider obtains access tokens with the OAuth 2.0 client credentials flow
+// (https://learn.microsoft.com/en-us/entra/identity-platform/v2-oauth2-client-creds-grant-flow).
+type servicePrincipalTokenProvider struct {
+	authority    string
+	tenantID     string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+}
+
+func (p *servicePrincipalTokenProvider) AccessToken(ctx context.Context) (AccessToken, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", p.clientID)
+	form.Set("client_secret", p.clientSecret)
+	form.Set("scope", AzureSQLTokenScope)
+	tokenURL := p.authority + "/" + url.PathEscape(p.tenantID) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return AccessToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doTokenRequest(p.httpClient, req)
+}
+
+// managedIdentityTokenProvider obtains access tokens of the managed identity from the App Service (Functions)
+// identity endpoint if it's available, or from the Azure Instance Metadata Service (VMs, AKS).
+type managedIdentityTokenProvider struct {
+	endpoint   string
+	apiVersion string
+	header     http.Header
+	clientID   string
+	httpClient *http.Client
+}
+
+func newManagedIdentityTokenProvider(clientID string, httpClient *http.Client) *managedIdentityTokenProvider {
+	p := &managedIdentityTokenProvider{clientID: clientID, httpClient: httpClient, header: http.Header{}}
+	if endpoint, secret := os.Getenv("IDENTITY_ENDPOINT"), os.Getenv("IDENTITY_HEADER"); endpoint != "" && secret != "" {
+		p.endpoint = endpoint
+		p.apiVersion = "2019-08-01"
+		p.header.Set("X-IDENTITY-HEADER", secret)
+		return p
+	}
+	p.endpoint = defaultIMDSTokenEndpoint
+	p.apiVersion = "2018-02-01"
+	p.header.Set("Metadata", "true")
+	return p
+}
+
+func (p *managedIdentityTokenProvider) AccessToken(ctx context.Context) (AccessToken, error) {
+	query := url.Values{}
+	query.Set("api-version", p.apiVersion)
+	query.Set("resource", azureSQLResource)
+	if p.clientID != "" {
+		query.Set("client_id", p.clientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint+"?"+query.Encode(), http.NoBody)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	for k, v := range p.header {
+		req.Header[k] = v
+	}
+	return doTokenRequest(p.httpClient, req)
+}
+
+// tokenResponse is a response of the token endpoint.
+// Managed identity endpoints return numbers as strings, json.Number handles both.
+type tokenResponse struct {
+	AccessToken      string      `json:"access_token"`
+	ExpiresIn        json.Number `json:"expires_in"`
+	ExpiresOn        json.Number `json:"expires_on"`
+	Error            string      `json:"error"`
+	ErrorDescription string      `json:"error_description"`
+}
+
+func doTokenRequest(httpClient *http.Client, req *http.Request) (AccessToken, error) {
+	requestedAt := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return AccessToken{}, fmt.Errorf("read token response: %w", err)
+	}
+	var tokenResp tokenResponse
+	if err = json.Unmarshal(body, &tokenResp); err != nil && resp.StatusCode == http.StatusOK {
+		return AccessToken{}, fmt.Errorf("unmarshal token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || tokenResp.AccessToken == "" {
+		if tokenResp.Error != "" {
+			return AccessToken{}, fmt.Errorf("token endpoint responded with status %d: %s: %s",
+				resp.StatusCode, tokenResp.Error, tokenResp.ErrorDescription)
+		}
+		return AccessToken{}, fmt.Errorf("token endpoint responded with status %d", resp.StatusCode)
+	}
+
+	token := AccessToken{Token: tokenResp.AccessToken}
+	if expiresOn, parseErr := strconv.ParseInt(tokenResp.ExpiresOn.String(), 10, 64); parseErr == nil {
+		token.ExpiresAt = time.Unix(expiresOn, 0)
+	} else if expiresIn, parseErr := strconv.ParseInt(tokenResp.ExpiresIn.String(), 10, 64); parseErr == nil {
+		token.ExpiresAt = requestedAt.Add(time.Duration(expiresIn) * time.Second)
+	}
+	return token, nil
+}
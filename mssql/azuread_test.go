@@ -0,0 +1,161 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package mssql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+func TestNewAzureADConnector(t *testing.T) {
+	cfg := &dbkit.Config{
+		Dialect: dbkit.DialectMSSQL,
+		MSSQL: dbkit.MSSQLConfig{Host: "myserver.database.windows.net", Port: 1433, Database: "orders",
+			AzureAD: dbkit.MSSQLAzureADConfig{Method: dbkit.MSSQLAzureADAuthManagedIdentity}},
+	}
+	connector, err := NewAzureADConnector(cfg)
+	require.NoError(t, err)
+	require.IsType(t, &mssql.Connector{}, connector)
+	require.IsType(t, &mssql.Driver{}, connector.Driver())
+
+	_, err = NewAzureADConnector(&dbkit.Config{Dialect: dbkit.DialectPgx})
+	require.EqualError(t, err, `unsupported dialect "pgx"`)
+	_, err = NewAzureADConnector(&dbkit.Config{Dialect: dbkit.DialectMSSQL})
+	require.EqualError(t, err, "method of Azure AD authentication is not specified")
+	_, err = NewAzureADConnector(&dbkit.Config{Dialect: dbkit.DialectMSSQL,
+		MSSQL: dbkit.MSSQLConfig{AzureAD: dbkit.MSSQLAzureADConfig{Method: dbkit.MSSQLAzureADAuthServicePrincipal}}})
+	require.EqualError(t, err, "tenant ID, client ID and client secret must be specified for service-principal method")
+
+	// Custom token provider doesn't require the method to be specified.
+	_, err = NewAzureADConnector(&dbkit.Config{Dialect: dbkit.DialectMSSQL, MSSQL: dbkit.MSSQLConfig{Host: "localhost"}},
+		WithAccessTokenProvider(AccessTokenProviderFunc(func(ctx context.Context) (AccessToken, error) {
+			return AccessToken{Token: "token"}, nil
+		})))
+	require.NoError(t, err)
+}
+
+func TestAccessTokenCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	var calls int
+	providerErr := errors.New("provider error")
+	var failProvider bool
+	cache := newAccessTokenCache(AccessTokenProviderFunc(func(ctx context.Context) (AccessToken, error) {
+		if failProvider {
+			return AccessToken{}, providerErr
+		}
+		calls++
+		return AccessToken{Token: "token-" + strconv.Itoa(calls), ExpiresAt: now.Add(time.Hour)}, nil
+	}))
+	cache.now = func() time.Time { return now }
+
+	token, err := cache.AccessToken(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token.Token)
+
+	now = now.Add(time.Hour - AccessTokenRefreshMargin - time.Second)
+	token, err = cache.AccessToken(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token.Token)
+
+	now = now.Add(time.Second)
+	token, err = cache.AccessToken(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "token-2", token.Token)
+
+	now = now.Add(time.Hour)
+	failProvider = true
+	_, err = cache.AccessToken(ctx)
+	require.ErrorIs(t, err, providerErr)
+}
+
+func TestServicePrincipalTokenProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/my-tenant/oauth2/v2.0/token", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("client_secret") != "secret" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			_, _ = rw.Write([]byte(`{"error":"invalid_client","error_description":"AADSTS7000215: Invalid client secret provided."}`))
+			return
+		}
+		require.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		require.Equal(t, "my-client", r.PostForm.Get("client_id"))
+		require.Equal(t, AzureSQLTokenScope, r.PostForm.Get("scope"))
+		_, _ = rw.Write([]byte(`{"token_type":"Bearer","expires_in":3599,"access_token":"sp-token"}`))
+	}))
+	defer server.Close()
+
+	provider := &servicePrincipalTokenProvider{
+		authority: server.URL, tenantID: "my-tenant", clientID: "my-client", clientSecret: "secret", httpClient: server.Client()}
+	startedAt := time.Now()
+	token, err := provider.AccessToken(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "sp-token", token.Token)
+	require.WithinDuration(t, startedAt.Add(3599*time.Second), token.ExpiresAt, 5*time.Second)
+
+	provider.clientSecret = "wrong"
+	_, err = provider.AccessToken(context.Background())
+	require.EqualError(t, err, "token endpoint responded with status 401: invalid_client: AADSTS7000215: Invalid client secret provided.")
+}
+
+func TestManagedIdentityTokenProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, "https://database.windows.net/", r.URL.Query().Get("resource"))
+		switch r.URL.Path {
+		case "/imds":
+			require.Equal(t, "true", r.Header.Get("Metadata"))
+			require.Equal(t, "2018-02-01", r.URL.Query().Get("api-version"))
+			require.Equal(t, "user-assigned-id", r.URL.Query().Get("client_id"))
+			_, _ = rw.Write([]byte(`{"access_token":"imds-token","expires_in":"86399","expires_on":"1740834000"}`))
+		case "/app-service":
+			require.Equal(t, "identity-secret", r.Header.Get("X-IDENTITY-HEADER"))
+			require.Equal(t, "2019-08-01", r.URL.Query().Get("api-version"))
+			require.Empty(t, r.URL.Query().Get("client_id"))
+			_, _ = rw.Write([]byte(`{"access_token":"app-service-token","expires_on":"1740834000"}`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("IMDS", func(t *testing.T) {
+		t.Setenv("IDENTITY_ENDPOINT", "")
+		provider := newManagedIdentityTokenProvider("user-assigned-id", server.Client())
+		require.Equal(t, defaultIMDSTokenEndpoint, provider.endpoint)
+		provider.endpoint = server.URL + "/imds"
+		token, err := provider.AccessToken(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, AccessToken{Token: "imds-token", ExpiresAt: time.Unix(1740834000, 0)}, token)
+	})
+
+	t.Run("App Service", func(t *testing.T) {
+		t.Setenv("IDENTITY_ENDPOINT", server.URL+"/app-service")
+		t.Setenv("IDENTITY_HEADER", "identity-secret")
+		token, err := newManagedIdentityTokenProvider("", server.Client()).AccessToken(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, AccessToken{Token: "app-service-token", ExpiresAt: time.Unix(1740834000, 0)}, token)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Setenv("IDENTITY_ENDPOINT", server.URL+"/unknown")
+		t.Setenv("IDENTITY_HEADER", "identity-secret")
+		_, err := newManagedIdentityTokenProvider("", server.Client()).AccessToken(context.Background())
+		require.EqualError(t, err, "token endpoint responded with status 404")
+	})
+}
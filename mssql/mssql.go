@@ -13,6 +13,7 @@ package mssql
 
 import (
 	"errors"
+	"strings"
 
 	mssql "github.com/microsoft/go-mssqldb"
 
@@ -21,6 +22,7 @@ import (
 
 // nolint
 func init() {
+	dbkit.RegisterDialectDriver(dbkit.DialectMSSQL, &mssql.Driver{})
 	dbkit.RegisterIsRetryableFunc(&mssql.Driver{}, func(err error) bool {
 		var msErr mssql.Error
 		if errors.As(err, &msErr) {
@@ -30,6 +32,42 @@ func init() {
 		}
 		return false
 	})
+	dbkit.RegisterErrorClassifier(func(err error) dbkit.ErrorClass {
+		var msErr mssql.Error
+		if !errors.As(err, &msErr) {
+			return dbkit.ErrorClassNone
+		}
+		switch ErrCode(msErr.SQLErrorNumber()) {
+		case ErrDeadlock:
+			return dbkit.ErrorClassDeadlock
+		case ErrLockTimeout:
+			return dbkit.ErrorClassLockTimeout
+		case ErrCodeUniqueViolation, ErrCodeUniqueIndexViolation, ErrCodeConstraintViolation, ErrCodeNullViolation:
+			return dbkit.ErrorClassConstraintViolation
+		}
+		return dbkit.ErrorClassNone
+	})
+	dbkit.RegisterConstraintViolationClassifier(func(err error) dbkit.ConstraintViolation {
+		var msErr mssql.Error
+		if !errors.As(err, &msErr) {
+			return dbkit.ConstraintViolationNone
+		}
+		switch ErrCode(msErr.SQLErrorNumber()) {
+		case ErrCodeUniqueViolation, ErrCodeUniqueIndexViolation:
+			return dbkit.ConstraintViolationUnique
+		case ErrCodeNullViolation:
+			return dbkit.ConstraintViolationNotNull
+		case ErrCodeConstraintViolation:
+			// The same error number is used for foreign key and check constraints, they differ only in the message.
+			if strings.Contains(msErr.SQLErrorMessage(), "FOREIGN KEY") || strings.Contains(msErr.SQLErrorMessage(), "REFERENCE") {
+				return dbkit.ConstraintViolationForeignKey
+			}
+			if strings.Contains(msErr.SQLErrorMessage(), "CHECK") {
+				return dbkit.ConstraintViolationCheck
+			}
+		}
+		return dbkit.ConstraintViolationNone
+	})
 }
 
 // ErrCode defines the type for MSSQL error codes.
@@ -40,6 +78,9 @@ const (
 	ErrDeadlock                 ErrCode = 1205
 	ErrCodeUniqueViolation      ErrCode = 2627
 	ErrCodeUniqueIndexViolation ErrCode = 2601
+	ErrLockTimeout              ErrCode = 1222
+	ErrCodeConstraintViolation  ErrCode = 547 // Foreign key or check constraint conflict.
+	ErrCodeNullViolation        ErrCode = 515
 )
 
 // CheckMSSQLError checks if the passed error relates to MSSQL,
@@ -23,6 +23,7 @@ func TestMSSQLIsRetryable(t *testing.T) {
 	require.True(t, isRetryable(mssql.Error{Number: 1205}))
 	require.False(t, isRetryable(driver.ErrBadConn))
 	require.True(t, isRetryable(fmt.Errorf("wrapped error: %w", mssql.Error{Number: 1205})))
+	require.True(t, dbkit.GetIsRetryableForDialect(dbkit.DialectMSSQL)(mssql.Error{Number: 1205}))
 }
 
 func TestCheckMSSQLError(t *testing.T) {
@@ -34,3 +35,19 @@ func TestCheckMSSQLError(t *testing.T) {
 	err = fmt.Errorf("wrapped error: %w", mssql.Error{Number: 1205})
 	require.True(t, CheckMSSQLError(err, ErrDeadlock))
 }
+
+func TestClassifyError(t *testing.T) {
+	require.Equal(t, dbkit.ErrorClassDeadlock, dbkit.ClassifyError(fmt.Errorf("wrapped error: %w", mssql.Error{Number: 1205})))
+	require.Equal(t, dbkit.ErrorClassLockTimeout, dbkit.ClassifyError(mssql.Error{Number: int32(ErrLockTimeout)}))
+	require.Equal(t, dbkit.ErrorClassConstraintViolation, dbkit.ClassifyError(mssql.Error{Number: int32(ErrCodeUniqueViolation)}))
+	require.Equal(t, dbkit.ErrorClassOther, dbkit.ClassifyError(mssql.Error{Number: 102}))
+
+	require.True(t, dbkit.IsUniqueViolation(fmt.Errorf("wrapped error: %w", mssql.Error{Number: int32(ErrCodeUniqueViolation)})))
+	require.True(t, dbkit.IsForeignKeyViolation(mssql.Error{Number: int32(ErrCodeConstraintViolation),
+		Message: `The INSERT statement conflicted with the FOREIGN KEY constraint "fk_orders_users".`}))
+	require.Equal(t, dbkit.ConstraintViolationCheck, dbkit.ClassifyConstraintViolation(mssql.Error{
+		Number: int32(ErrCodeConstraintViolation), Message: `The INSERT statement conflicted with the CHECK constraint "ck_price".`}))
+	require.Equal(t, dbkit.ConstraintViolationNotNull, dbkit.ClassifyConstraintViolation(mssql.Error{Number: int32(ErrCodeNullViolation)}))
+	require.True(t, dbkit.IsDeadlock(mssql.Error{Number: 1205}))
+	require.False(t, dbkit.IsUniqueViolation(mssql.Error{Number: 102}))
+}
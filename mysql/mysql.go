@@ -13,6 +13,8 @@ package mysql
 
 import (
 	"errors"
+	"sort"
+	"sync"
 
 	"github.com/go-sql-driver/mysql"
 
@@ -21,18 +23,52 @@ import (
 
 // nolint
 func init() {
-	dbkit.RegisterIsRetryableFunc(&mysql.MySQLDriver{}, func(err error) bool {
+	dbkit.RegisterDialectDriver(dbkit.DialectMySQL, &mysql.MySQLDriver{})
+	dbkit.RegisterIsRetryableFunc(&mysql.MySQLDriver{}, IsRetryable)
+	dbkit.RegisterErrorClassifier(func(err error) dbkit.ErrorClass {
+		if errors.Is(err, mysql.ErrInvalidConn) {
+			return dbkit.ErrorClassConnectionFailure
+		}
 		var mySQLError *mysql.MySQLError
-		if errors.As(err, &mySQLError) {
-			switch mySQLError.Number {
-			case uint16(ErrDeadlock), uint16(ErrLockTimedOut):
-				return true
+		if !errors.As(err, &mySQLError) {
+			return dbkit.ErrorClassNone
+		}
+		switch ErrCode(mySQLError.Number) {
+		case ErrQueryTimeout, ErrStatementTimeout:
+			return dbkit.ErrorClassStatementTimeout
+		case ErrDeadlock:
+			return dbkit.ErrorClassDeadlock
+		case ErrLockTimedOut:
+			return dbkit.ErrorClassLockTimeout
+		case ErrTooManyConnections:
+			return dbkit.ErrorClassConnectionFailure
+		case ErrOptionPreventsStatement:
+			// Read-only former primary is a connection failure only if the error is configured as retryable,
+			// otherwise it's a permanent error that must not be retried by class retry policies.
+			if isRetryableErrCode(ErrOptionPreventsStatement) {
+				return dbkit.ErrorClassConnectionFailure
 			}
+		case ErrCodeDupEntry, ErrCodeBadNull, ErrCodeRowIsReferenced, ErrCodeNoReferencedRow, ErrCodeCheckConstraintViolated:
+			return dbkit.ErrorClassConstraintViolation
 		}
-		if errors.Is(err, mysql.ErrInvalidConn) {
-			return true
+		return dbkit.ErrorClassNone
+	})
+	dbkit.RegisterConstraintViolationClassifier(func(err error) dbkit.ConstraintViolation {
+		var mySQLError *mysql.MySQLError
+		if !errors.As(err, &mySQLError) {
+			return dbkit.ConstraintViolationNone
+		}
+		switch ErrCode(mySQLError.Number) {
+		case ErrCodeDupEntry:
+			return dbkit.ConstraintViolationUnique
+		case ErrCodeRowIsReferenced, ErrCodeNoReferencedRow:
+			return dbkit.ConstraintViolationForeignKey
+		case ErrCodeBadNull:
+			return dbkit.ConstraintViolationNotNull
+		case ErrCodeCheckConstraintViolated:
+			return dbkit.ConstraintViolationCheck
 		}
-		return false
+		return dbkit.ConstraintViolationNone
 	})
 }
 
@@ -44,8 +80,132 @@ const (
 	ErrCodeDupEntry ErrCode = 1062
 	ErrDeadlock     ErrCode = 1213
 	ErrLockTimedOut ErrCode = 1205
+
+	ErrCodeBadNull                 ErrCode = 1048
+	ErrCodeRowIsReferenced         ErrCode = 1451
+	ErrCodeNoReferencedRow         ErrCode = 1452
+	ErrCodeCheckConstraintViolated ErrCode = 3819
+
+	// ErrQueryTimeout is returned by MySQL when max_execution_time is exceeded.
+	ErrQueryTimeout ErrCode = 3024
+	// ErrStatementTimeout is returned by MariaDB when max_statement_time is exceeded.
+	ErrStatementTimeout ErrCode = 1969
+
+	// ErrTooManyConnections is returned when max_connections is reached.
+	ErrTooManyConnections ErrCode = 1040
+	// ErrTooManyConcurrentTrxs is returned when InnoDB runs out of undo slots for concurrent transactions.
+	ErrTooManyConcurrentTrxs ErrCode = 1637
+	// ErrOptionPreventsStatement is returned when the server option prevents the statement execution.
+	// Most often it's --read-only (or --super-read-only) of the former primary after failover,
+	// while the connection pool still holds connections to it.
+	ErrOptionPreventsStatement ErrCode = 1290
+)
+
+// DefaultRetryableErrCodes returns the error codes that are retryable by default (see IsRetryable).
+// Lost connections are not reported by the server with error codes, the driver returns mysql.ErrInvalidConn
+// for them instead (see WithRetryOnInvalidConn).
+// ErrOptionPreventsStatement is not included, since the same code is returned for permanent errors
+// (e.g., --secure-file-priv restrictions), it may be enabled with WithRetryableErrCodes
+// if the database is behind a failover-capable proxy. Only then it's classified as dbkit.ErrorClassConnectionFailure.
+func DefaultRetryableErrCodes() []ErrCode {
+	return []ErrCode{ErrDeadlock, ErrLockTimedOut, ErrTooManyConnections, ErrTooManyConcurrentTrxs}
+}
+
+type retryableOptions struct {
+	errCodes           map[ErrCode]struct{}
+	retryOnInvalidConn bool
+}
+
+// RetryableOption is a functional option for ConfigureRetryable.
+type RetryableOption func(*retryableOptions)
+
+// WithRetryableErrCodes makes errors with the given codes retryable in addition to the default ones.
+func WithRetryableErrCodes(errCodes ...ErrCode) RetryableOption {
+	return func(opts *retryableOptions) {
+		for _, errCode := range errCodes {
+			opts.errCodes[errCode] = struct{}{}
+		}
+	}
+}
+
+// WithoutRetryableErrCodes makes errors with the given codes non-retryable.
+func WithoutRetryableErrCodes(errCodes ...ErrCode) RetryableOption {
+	return func(opts *retryableOptions) {
+		for _, errCode := range errCodes {
+			delete(opts.errCodes, errCode)
+		}
+	}
+}
+
+// WithRetryOnInvalidConn sets whether mysql.ErrInvalidConn is retryable (true by default).
+func WithRetryOnInvalidConn(retry bool) RetryableOption {
+	return func(opts *retryableOptions) {
+		opts.retryOnInvalidConn = retry
+	}
+}
+
+var (
+	retryableMu   sync.RWMutex
+	retryableOpts = makeRetryableOptions()
 )
 
+func makeRetryableOptions(options ...RetryableOption) retryableOptions {
+	opts := retryableOptions{errCodes: make(map[ErrCode]struct{}), retryOnInvalidConn: true}
+	for _, errCode := range DefaultRetryableErrCodes() {
+		opts.errCodes[errCode] = struct{}{}
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
+
+// ConfigureRetryable tunes the set of errors that are considered retryable by IsRetryable
+// (and so by the function registered for the MySQL driver in dbkit.DefaultRetryableRegistry).
+// Options are applied to DefaultRetryableErrCodes, so each call resets the previous configuration.
+// It's safe for concurrent use, but usually it's called once on the application start.
+//
+//	mysql.ConfigureRetryable(mysql.WithRetryableErrCodes(mysql.ErrOptionPreventsStatement))
+func ConfigureRetryable(options ...RetryableOption) {
+	opts := makeRetryableOptions(options...)
+	retryableMu.Lock()
+	defer retryableMu.Unlock()
+	retryableOpts = opts
+}
+
+// RetryableErrCodes returns the currently configured retryable error codes in ascending order.
+func RetryableErrCodes() []ErrCode {
+	retryableMu.RLock()
+	defer retryableMu.RUnlock()
+	errCodes := make([]ErrCode, 0, len(retryableOpts.errCodes))
+	for errCode := range retryableOpts.errCodes {
+		errCodes = append(errCodes, errCode)
+	}
+	sort.Slice(errCodes, func(i, j int) bool { return errCodes[i] < errCodes[j] })
+	return errCodes
+}
+
+// IsRetryable checks whether the error is a transient MySQL error that makes sense to retry
+// according to the configuration (see ConfigureRetryable).
+func IsRetryable(err error) bool {
+	retryableMu.RLock()
+	defer retryableMu.RUnlock()
+	var mySQLError *mysql.MySQLError
+	if errors.As(err, &mySQLError) {
+		if _, ok := retryableOpts.errCodes[ErrCode(mySQLError.Number)]; ok {
+			return true
+		}
+	}
+	return retryableOpts.retryOnInvalidConn && errors.Is(err, mysql.ErrInvalidConn)
+}
+
+func isRetryableErrCode(errCode ErrCode) bool {
+	retryableMu.RLock()
+	defer retryableMu.RUnlock()
+	_, ok := retryableOpts.errCodes[errCode]
+	return ok
+}
+
 // CheckMySQLError checks if the passed error relates to MySQL,
 // and it's internal code matches the one from the argument.
 func CheckMySQLError(err error, errCode ErrCode) bool {
@@ -44,6 +44,34 @@ func TestMySQLIsRetryable(t *testing.T) {
 	require.True(t, isRetryable(fmt.Errorf("wrapped error: %w", &mysql.MySQLError{
 		Number: uint16(ErrDeadlock),
 	})))
+	require.True(t, dbkit.GetIsRetryableForDialect(dbkit.DialectMySQL)(&mysql.MySQLError{Number: uint16(ErrDeadlock)}))
+}
+
+func TestConfigureRetryable(t *testing.T) {
+	defer ConfigureRetryable()
+	isRetryable := dbkit.GetIsRetryable(&mysql.MySQLDriver{})
+
+	for _, errCode := range []ErrCode{ErrTooManyConnections, ErrTooManyConcurrentTrxs} {
+		require.True(t, isRetryable(&mysql.MySQLError{Number: uint16(errCode)}), "error code %d", errCode)
+	}
+	require.False(t, isRetryable(&mysql.MySQLError{Number: uint16(ErrOptionPreventsStatement)}))
+	require.Equal(t, []ErrCode{ErrTooManyConnections, ErrLockTimedOut, ErrDeadlock, ErrTooManyConcurrentTrxs}, RetryableErrCodes())
+
+	ConfigureRetryable(
+		WithRetryableErrCodes(ErrOptionPreventsStatement),
+		WithoutRetryableErrCodes(ErrTooManyConnections, ErrLockTimedOut),
+		WithRetryOnInvalidConn(false),
+	)
+	require.True(t, isRetryable(fmt.Errorf("wrapped error: %w", &mysql.MySQLError{Number: uint16(ErrOptionPreventsStatement)})))
+	require.False(t, isRetryable(&mysql.MySQLError{Number: uint16(ErrTooManyConnections)}))
+	require.False(t, isRetryable(&mysql.MySQLError{Number: uint16(ErrLockTimedOut)}))
+	require.False(t, isRetryable(mysql.ErrInvalidConn))
+	require.True(t, isRetryable(&mysql.MySQLError{Number: uint16(ErrDeadlock)}))
+
+	// Each call resets the previous configuration.
+	ConfigureRetryable()
+	require.False(t, isRetryable(&mysql.MySQLError{Number: uint16(ErrOptionPreventsStatement)}))
+	require.True(t, isRetryable(mysql.ErrInvalidConn))
 }
 
 // TestCheckMySQLError covers behavior of CheckMySQLError func.
@@ -59,3 +87,29 @@ func TestCheckMySQLError(t *testing.T) {
 	require.True(t, CheckMySQLError(sqlErr, deadlockErr))
 	require.True(t, CheckMySQLError(wrapperSQLErr, deadlockErr))
 }
+
+func TestClassifyError(t *testing.T) {
+	require.Equal(t, dbkit.ErrorClassStatementTimeout, dbkit.ClassifyError(&mysql.MySQLError{Number: uint16(ErrQueryTimeout)}))
+	require.Equal(t, dbkit.ErrorClassStatementTimeout,
+		dbkit.ClassifyError(fmt.Errorf("wrapped error: %w", &mysql.MySQLError{Number: uint16(ErrStatementTimeout)})))
+	require.Equal(t, dbkit.ErrorClassDeadlock, dbkit.ClassifyError(&mysql.MySQLError{Number: uint16(ErrDeadlock)}))
+	require.Equal(t, dbkit.ErrorClassLockTimeout, dbkit.ClassifyError(&mysql.MySQLError{Number: uint16(ErrLockTimedOut)}))
+	require.Equal(t, dbkit.ErrorClassConnectionFailure, dbkit.ClassifyError(&mysql.MySQLError{Number: uint16(ErrTooManyConnections)}))
+	require.Equal(t, dbkit.ErrorClassOther, dbkit.ClassifyError(&mysql.MySQLError{Number: uint16(ErrOptionPreventsStatement)}))
+	ConfigureRetryable(WithRetryableErrCodes(ErrOptionPreventsStatement))
+	defer ConfigureRetryable()
+	require.Equal(t, dbkit.ErrorClassConnectionFailure, dbkit.ClassifyError(&mysql.MySQLError{Number: uint16(ErrOptionPreventsStatement)}))
+	require.Equal(t, dbkit.ErrorClassConstraintViolation, dbkit.ClassifyError(&mysql.MySQLError{Number: uint16(ErrCodeDupEntry)}))
+	require.Equal(t, dbkit.ErrorClassConnectionFailure, dbkit.ClassifyError(mysql.ErrInvalidConn))
+	require.Equal(t, dbkit.ErrorClassOther, dbkit.ClassifyError(&mysql.MySQLError{Number: 1064}))
+
+	require.True(t, dbkit.IsUniqueViolation(fmt.Errorf("wrapped error: %w", &mysql.MySQLError{Number: uint16(ErrCodeDupEntry)})))
+	require.True(t, dbkit.IsForeignKeyViolation(&mysql.MySQLError{Number: uint16(ErrCodeRowIsReferenced)}))
+	require.True(t, dbkit.IsForeignKeyViolation(&mysql.MySQLError{Number: uint16(ErrCodeNoReferencedRow)}))
+	require.Equal(t, dbkit.ConstraintViolationNotNull, dbkit.ClassifyConstraintViolation(&mysql.MySQLError{Number: uint16(ErrCodeBadNull)}))
+	require.Equal(t, dbkit.ConstraintViolationCheck,
+		dbkit.ClassifyConstraintViolation(&mysql.MySQLError{Number: uint16(ErrCodeCheckConstraintViolated)}))
+	require.True(t, dbkit.IsDeadlock(&mysql.MySQLError{Number: uint16(ErrDeadlock)}))
+	require.True(t, dbkit.IsConnectionError(mysql.ErrInvalidConn))
+	require.False(t, dbkit.IsUniqueViolation(&mysql.MySQLError{Number: 1064}))
+}
@@ -0,0 +1,122 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Default names of the columns used by VersionedUpdate.
+const (
+	DefaultVersionedUpdateIDColumn      = "id"
+	DefaultVersionedUpdateVersionColumn = "version"
+)
+
+// ErrStaleObject is returned by VersionedUpdate.Exec when the row was modified (its version was changed)
+// or deleted concurrently since it was read. It's classified as ErrorClassStaleObject,
+// so the transaction that reads and updates the row may be retried with WithClassRetryPolicy.
+var ErrStaleObject = errors.New("stale object: row was modified or deleted concurrently")
+
+// VersionedUpdate is the UPDATE statement of a single row with version-column based optimistic concurrency control.
+// The row is updated only if its version is still equal to Version, and the version is incremented by the statement.
+type VersionedUpdate struct {
+	Table string
+
+	// IDColumn is the name of the primary key column. DefaultVersionedUpdateIDColumn is used if it's empty.
+	IDColumn string
+
+	// VersionColumn is the name of the integer version column. DefaultVersionedUpdateVersionColumn is used if it's empty.
+	VersionColumn string
+
+	// ID is the value of the primary key of the updated row.
+	ID interface{}
+
+	// Version is the version of the row that was read before the update.
+	Version int64
+
+	// Columns and Values are the updated columns (besides the version) and their new values in the same order.
+	Columns []string
+	Values  []interface{}
+}
+
+// Build returns the UPDATE statement for the dialect and its arguments
+// (e.g., `UPDATE "users" SET name = $1, version = version + 1 WHERE id = $2 AND version = $3` for Postgres).
+func (u VersionedUpdate) Build(dialect Dialect) (query string, args []interface{}, err error) {
+	if len(u.Columns) != len(u.Values) {
+		return "", nil, fmt.Errorf("got %d values for %d columns", len(u.Values), len(u.Columns))
+	}
+	var table string
+	var placeholder func(i int) string
+	switch dialect {
+	case DialectPostgres, DialectPgx:
+		table = `"` + u.Table + `"`
+		placeholder = func(i int) string { return "$" + strconv.Itoa(i) }
+	case DialectSQLite:
+		table = `"` + u.Table + `"`
+		placeholder = func(int) string { return "?" }
+	case DialectMySQL:
+		table = "`" + u.Table + "`"
+		placeholder = func(int) string { return "?" }
+	case DialectMSSQL:
+		table = "[" + u.Table + "]"
+		placeholder = func(i int) string { return "@p" + strconv.Itoa(i) }
+	default:
+		return "", nil, fmt.Errorf("unsupported dialect %q", dialect)
+	}
+	idColumn, versionColumn := u.columnNames()
+
+	assignments := make([]string, 0, len(u.Columns)+1)
+	for i, col := range u.Columns {
+		assignments = append(assignments, fmt.Sprintf("%s = %s", col, placeholder(i+1)))
+	}
+	assignments = append(assignments, fmt.Sprintf("%[1]s = %[1]s + 1", versionColumn))
+	query = fmt.Sprintf("UPDATE %s SET %s WHERE %s = %s AND %s = %s", table, strings.Join(assignments, ", "),
+		idColumn, placeholder(len(u.Columns)+1), versionColumn, placeholder(len(u.Columns)+2))
+
+	args = make([]interface{}, 0, len(u.Values)+2)
+	args = append(args, u.Values...)
+	args = append(args, u.ID, u.Version)
+	return query, args, nil
+}
+
+// Exec executes the UPDATE statement and returns the error wrapping ErrStaleObject if no row was updated,
+// i.e. the row doesn't exist anymore or its version differs from the expected one.
+// On success, the new version of the row is Version+1.
+func (u VersionedUpdate) Exec(ctx context.Context, execer SQLExecutor, dialect Dialect) error {
+	query, args, err := u.Build(dialect)
+	if err != nil {
+		return err
+	}
+	result, err := execer.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		idColumn, versionColumn := u.columnNames()
+		return fmt.Errorf("update %s with %s %v and %s %d: %w", u.Table, idColumn, u.ID, versionColumn, u.Version, ErrStaleObject)
+	}
+	return nil
+}
+
+func (u VersionedUpdate) columnNames() (idColumn, versionColumn string) {
+	idColumn, versionColumn = u.IDColumn, u.VersionColumn
+	if idColumn == "" {
+		idColumn = DefaultVersionedUpdateIDColumn
+	}
+	if versionColumn == "" {
+		versionColumn = DefaultVersionedUpdateVersionColumn
+	}
+	return idColumn, versionColumn
+}
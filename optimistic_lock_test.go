@@ -0,0 +1,93 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionedUpdate_Build(t *testing.T) {
+	update := VersionedUpdate{
+		Table:   "users",
+		ID:      42,
+		Version: 3,
+		Columns: []string{"name", "email"},
+		Values:  []interface{}{"alice", "alice@example.com"},
+	}
+	wantArgs := []interface{}{"alice", "alice@example.com", 42, int64(3)}
+
+	tests := []struct {
+		dialect   Dialect
+		wantQuery string
+	}{
+		{DialectPostgres, `UPDATE "users" SET name = $1, email = $2, version = version + 1 WHERE id = $3 AND version = $4`},
+		{DialectSQLite, `UPDATE "users" SET name = ?, email = ?, version = version + 1 WHERE id = ? AND version = ?`},
+		{DialectMySQL, "UPDATE `users` SET name = ?, email = ?, version = version + 1 WHERE id = ? AND version = ?"},
+		{DialectMSSQL, `UPDATE [users] SET name = @p1, email = @p2, version = version + 1 WHERE id = @p3 AND version = @p4`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			query, args, err := update.Build(tt.dialect)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantQuery, query)
+			require.Equal(t, wantArgs, args)
+		})
+	}
+
+	update.IDColumn, update.VersionColumn = "user_id", "rev"
+	query, _, err := update.Build(DialectSQLite)
+	require.NoError(t, err)
+	require.Equal(t, `UPDATE "users" SET name = ?, email = ?, rev = rev + 1 WHERE user_id = ? AND rev = ?`, query)
+
+	_, _, err = update.Build("unknown")
+	require.EqualError(t, err, `unsupported dialect "unknown"`)
+
+	update.Values = update.Values[:1]
+	_, _, err = update.Build(DialectSQLite)
+	require.EqualError(t, err, "got 1 values for 2 columns")
+}
+
+func TestVersionedUpdate_Exec(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "optimistic_lock.db"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, dbConn.Close()) }()
+	_, err = dbConn.Exec(`CREATE TABLE "users" (id INTEGER PRIMARY KEY, name TEXT NOT NULL, version INTEGER NOT NULL)`)
+	require.NoError(t, err)
+	_, err = dbConn.Exec(`INSERT INTO "users" (id, name, version) VALUES (1, 'alice', 1)`)
+	require.NoError(t, err)
+
+	readUser := func() (name string, version int64) {
+		t.Helper()
+		require.NoError(t, dbConn.QueryRow(`SELECT name, version FROM "users" WHERE id = 1`).Scan(&name, &version))
+		return name, version
+	}
+
+	update := VersionedUpdate{Table: "users", ID: 1, Version: 1, Columns: []string{"name"}, Values: []interface{}{"bob"}}
+	require.NoError(t, update.Exec(ctx, dbConn, DialectSQLite))
+	name, version := readUser()
+	require.Equal(t, "bob", name)
+	require.Equal(t, int64(2), version)
+
+	// The same version was already updated, so the object is stale now.
+	update.Values = []interface{}{"carol"}
+	err = update.Exec(ctx, dbConn, DialectSQLite)
+	require.ErrorIs(t, err, ErrStaleObject)
+	require.Equal(t, ErrorClassStaleObject, ClassifyError(err))
+	name, version = readUser()
+	require.Equal(t, "bob", name)
+	require.Equal(t, int64(2), version)
+
+	// Non-existing row.
+	update.ID, update.Version = 2, 2
+	require.ErrorIs(t, update.Exec(ctx, dbConn, DialectSQLite), ErrStaleObject)
+}
@@ -0,0 +1,16 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package otelmetrics provides the OpenTelemetry implementation of the metrics collected by dbkit
+// (query durations, errors and retries, transaction durations, commits, rollbacks and retries, returned and affected rows)
+// and of the connection pool statistics, for teams that have standardized on an OTel collector pipeline
+// and don't scrape Prometheus directly.
+//
+// Metrics implements the same collector interfaces as dbkit.PrometheusMetrics
+// (dbkit.MetricsCollector with all its narrower interfaces and dbrutil.MetricsCollector),
+// so it may be used everywhere instead of it. Statistics of the connection pool are reported
+// either by Metrics (if they are passed to it by dbkit.ObservePoolStats) or by the callback registered by RegisterDBStats.
+package otelmetrics
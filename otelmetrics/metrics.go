@@ -0,0 +1,311 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package otelmetrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// Attribute keys of metrics. They match the label names of dbkit.PrometheusMetrics.
+const (
+	AttrQuery      = attribute.Key(dbkit.PrometheusMetricsLabelQuery)
+	AttrErrorClass = attribute.Key(dbkit.PrometheusMetricsLabelErrorClass)
+	AttrTx         = attribute.Key(dbkit.PrometheusMetricsLabelTx)
+	AttrDBName     = attribute.Key("db_name")
+)
+
+type metricsOptions struct {
+	queryDurationBuckets []float64
+	txDurationBuckets    []float64
+	queryRowsBuckets     []float64
+	attributes           []attribute.KeyValue
+}
+
+// Option is a functional option for NewMetrics.
+type Option func(*metricsOptions)
+
+// WithQueryDurationBuckets sets bucket boundaries (in seconds) of the query duration histogram.
+// dbkit.DefaultQueryDurationBuckets are used by default.
+func WithQueryDurationBuckets(buckets []float64) Option {
+	return func(opts *metricsOptions) {
+		opts.queryDurationBuckets = buckets
+	}
+}
+
+// WithTxDurationBuckets sets bucket boundaries (in seconds) of the transaction duration histogram.
+// dbkit.DefaultQueryDurationBuckets are used by default.
+func WithTxDurationBuckets(buckets []float64) Option {
+	return func(opts *metricsOptions) {
+		opts.txDurationBuckets = buckets
+	}
+}
+
+// WithQueryRowsBuckets sets bucket boundaries of the returned and affected rows histograms.
+// dbkit.DefaultQueryRowsBuckets are used by default.
+func WithQueryRowsBuckets(buckets []float64) Option {
+	return func(opts *metricsOptions) {
+		opts.queryRowsBuckets = buckets
+	}
+}
+
+// WithAttributes sets attributes that are added to all measurements (as dbkit.PrometheusMetricsOpts.ConstLabels).
+func WithAttributes(attributes ...attribute.KeyValue) Option {
+	return func(opts *metricsOptions) {
+		opts.attributes = attributes
+	}
+}
+
+// Metrics collects metrics of SQL queries and transactions with OpenTelemetry instruments.
+type Metrics struct {
+	queryDurations    metric.Float64Histogram
+	queryErrors       metric.Int64Counter
+	txDurations       metric.Float64Histogram
+	txCommits         metric.Int64Counter
+	txRollbacks       metric.Int64Counter
+	txRetries         metric.Int64Counter
+	queryRetries      metric.Int64Counter
+	queryRowsReturned metric.Int64Histogram
+	queryRowsAffected metric.Int64Histogram
+	attributes        []attribute.KeyValue
+
+	// poolStats holds the last observed statistics of the connection pools by their names (see ObservePoolStats).
+	poolStatsMu sync.Mutex
+	poolStats   map[string]sql.DBStats
+}
+
+var (
+	_ dbkit.MetricsCollector           = (*Metrics)(nil)
+	_ dbkit.ErrorMetricsCollector      = (*Metrics)(nil)
+	_ dbkit.TxMetricsCollector         = (*Metrics)(nil)
+	_ dbkit.QueryRetryMetricsCollector = (*Metrics)(nil)
+	_ dbkit.RowsMetricsCollector       = (*Metrics)(nil)
+)
+
+// NewMetrics creates instruments on the passed meter and returns a new Metrics.
+// Instruments of the connection pool statistics are the same as RegisterDBStats creates,
+// they report the statistics passed to ObservePoolStats.
+func NewMetrics(meter metric.Meter, options ...Option) (*Metrics, error) {
+	opts := metricsOptions{
+		queryDurationBuckets: dbkit.DefaultQueryDurationBuckets,
+		txDurationBuckets:    dbkit.DefaultQueryDurationBuckets,
+		queryRowsBuckets:     dbkit.DefaultQueryRowsBuckets,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	m := &Metrics{attributes: opts.attributes, poolStats: make(map[string]sql.DBStats)}
+	var errs []error
+	var err error
+	if m.queryDurations, err = meter.Float64Histogram("db.query.duration",
+		metric.WithDescription("The SQL query durations."), metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(opts.queryDurationBuckets...)); err != nil {
+		errs = append(errs, err)
+	}
+	if m.queryErrors, err = meter.Int64Counter("db.query.errors",
+		metric.WithDescription("The number of failed SQL queries by the error class."), metric.WithUnit("{error}")); err != nil {
+		errs = append(errs, err)
+	}
+	if m.txDurations, err = meter.Float64Histogram("db.tx.duration",
+		metric.WithDescription("The transaction durations."), metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(opts.txDurationBuckets...)); err != nil {
+		errs = append(errs, err)
+	}
+	if m.txCommits, err = meter.Int64Counter("db.tx.commits",
+		metric.WithDescription("The number of committed transactions."), metric.WithUnit("{transaction}")); err != nil {
+		errs = append(errs, err)
+	}
+	if m.txRollbacks, err = meter.Int64Counter("db.tx.rollbacks",
+		metric.WithDescription("The number of rolled back transactions."), metric.WithUnit("{transaction}")); err != nil {
+		errs = append(errs, err)
+	}
+	if m.txRetries, err = meter.Int64Counter("db.tx.retries",
+		metric.WithDescription("The number of transaction retries."), metric.WithUnit("{retry}")); err != nil {
+		errs = append(errs, err)
+	}
+	if m.queryRetries, err = meter.Int64Counter("db.query.retries",
+		metric.WithDescription("The number of SQL query retries outside of transactions."), metric.WithUnit("{retry}")); err != nil {
+		errs = append(errs, err)
+	}
+	if m.queryRowsReturned, err = meter.Int64Histogram("db.query.rows_returned",
+		metric.WithDescription("The number of rows returned by SQL queries."), metric.WithUnit("{row}"),
+		metric.WithExplicitBucketBoundaries(opts.queryRowsBuckets...)); err != nil {
+		errs = append(errs, err)
+	}
+	if m.queryRowsAffected, err = meter.Int64Histogram("db.query.rows_affected",
+		metric.WithDescription("The number of rows affected by SQL queries."), metric.WithUnit("{row}"),
+		metric.WithExplicitBucketBoundaries(opts.queryRowsBuckets...)); err != nil {
+		errs = append(errs, err)
+	}
+	if pool, poolErr := newPoolInstruments(meter); poolErr != nil {
+		errs = append(errs, poolErr)
+	} else if _, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		m.poolStatsMu.Lock()
+		defer m.poolStatsMu.Unlock()
+		for dbName, stats := range m.poolStats {
+			pool.observe(o, stats, metric.WithAttributes(append([]attribute.KeyValue{AttrDBName.String(dbName)}, m.attributes...)...))
+		}
+		return nil
+	}, pool.observables()...); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+	return m, nil
+}
+
+// ObserveQueryDuration records the duration of executing SQL query.
+func (m *Metrics) ObserveQueryDuration(query string, duration time.Duration) {
+	m.queryDurations.Record(context.Background(), duration.Seconds(), m.withAttrs(AttrQuery.String(query)))
+}
+
+// ObserveQueryError counts the failed SQL query with the class of the error (see dbkit.ClassifyQueryError).
+// Nil error is ignored.
+func (m *Metrics) ObserveQueryError(query string, err error) {
+	if err == nil {
+		return
+	}
+	m.queryErrors.Add(context.Background(), 1, m.withAttrs(
+		AttrQuery.String(query), AttrErrorClass.String(string(dbkit.ClassifyQueryError(err)))))
+}
+
+// ObserveTxDuration records the duration of executing transaction.
+func (m *Metrics) ObserveTxDuration(tx string, duration time.Duration) {
+	m.txDurations.Record(context.Background(), duration.Seconds(), m.withAttrs(AttrTx.String(tx)))
+}
+
+// IncTxCommits counts the committed transaction.
+func (m *Metrics) IncTxCommits(tx string) {
+	m.txCommits.Add(context.Background(), 1, m.withAttrs(AttrTx.String(tx)))
+}
+
+// IncTxRollbacks counts the rolled back transaction.
+func (m *Metrics) IncTxRollbacks(tx string) {
+	m.txRollbacks.Add(context.Background(), 1, m.withAttrs(AttrTx.String(tx)))
+}
+
+// IncTxRetries counts the transaction retry.
+func (m *Metrics) IncTxRetries(tx string) {
+	m.txRetries.Add(context.Background(), 1, m.withAttrs(AttrTx.String(tx)))
+}
+
+// IncQueryRetries counts the SQL query retry (see dbkit.ExecWithRetry and dbkit.QueryWithRetry).
+func (m *Metrics) IncQueryRetries(query string) {
+	m.queryRetries.Add(context.Background(), 1, m.withAttrs(AttrQuery.String(query)))
+}
+
+// ObserveQueryRowsReturned records the number of rows returned by SQL query.
+func (m *Metrics) ObserveQueryRowsReturned(query string, rows int) {
+	m.queryRowsReturned.Record(context.Background(), int64(rows), m.withAttrs(AttrQuery.String(query)))
+}
+
+// ObserveQueryRowsAffected records the number of rows affected by SQL query.
+func (m *Metrics) ObserveQueryRowsAffected(query string, rows int64) {
+	m.queryRowsAffected.Record(context.Background(), rows, m.withAttrs(AttrQuery.String(query)))
+}
+
+// ObservePoolStats stores statistics of the connection pool (see dbkit.ObservePoolStats function).
+// They are reported with the db_name attribute when the metrics are collected.
+func (m *Metrics) ObservePoolStats(dbName string, stats sql.DBStats) {
+	m.poolStatsMu.Lock()
+	defer m.poolStatsMu.Unlock()
+	m.poolStats[dbName] = stats
+}
+
+func (m *Metrics) withAttrs(attrs ...attribute.KeyValue) metric.MeasurementOption {
+	return metric.WithAttributes(append(attrs, m.attributes...)...)
+}
+
+// RegisterDBStats registers the callback that reports statistics of the connection pool (see sql.DBStats)
+// with the db_name attribute, as collectors.NewDBStatsCollector does for Prometheus.
+// The returned registration should be unregistered when the pool is closed.
+func RegisterDBStats(meter metric.Meter, db *sql.DB, dbName string) (metric.Registration, error) {
+	pool, err := newPoolInstruments(meter)
+	if err != nil {
+		return nil, err
+	}
+	attrs := metric.WithAttributes(AttrDBName.String(dbName))
+	return meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		pool.observe(o, db.Stats(), attrs)
+		return nil
+	}, pool.observables()...)
+}
+
+// poolInstruments are the instruments of the connection pool statistics.
+type poolInstruments struct {
+	maxOpen           metric.Int64ObservableGauge
+	open              metric.Int64ObservableGauge
+	inUse             metric.Int64ObservableGauge
+	idle              metric.Int64ObservableGauge
+	waitCount         metric.Int64ObservableCounter
+	waitDuration      metric.Float64ObservableCounter
+	maxIdleClosed     metric.Int64ObservableCounter
+	maxIdleTimeClosed metric.Int64ObservableCounter
+	maxLifetimeClosed metric.Int64ObservableCounter
+}
+
+func newPoolInstruments(meter metric.Meter) (*poolInstruments, error) {
+	var errs []error
+	newGauge := func(name, description, unit string) metric.Int64ObservableGauge {
+		gauge, err := meter.Int64ObservableGauge(name, metric.WithDescription(description), metric.WithUnit(unit))
+		errs = append(errs, err)
+		return gauge
+	}
+	newCounter := func(name, description, unit string) metric.Int64ObservableCounter {
+		counter, err := meter.Int64ObservableCounter(name, metric.WithDescription(description), metric.WithUnit(unit))
+		errs = append(errs, err)
+		return counter
+	}
+	pool := &poolInstruments{
+		maxOpen:   newGauge("db.pool.connections.max_open", "Maximum number of open connections to the database.", "{connection}"),
+		open:      newGauge("db.pool.connections.open", "The number of established connections both in use and idle.", "{connection}"),
+		inUse:     newGauge("db.pool.connections.in_use", "The number of connections currently in use.", "{connection}"),
+		idle:      newGauge("db.pool.connections.idle", "The number of idle connections.", "{connection}"),
+		waitCount: newCounter("db.pool.wait.count", "The total number of connections waited for.", "{wait}"),
+		maxIdleClosed: newCounter("db.pool.connections.max_idle_closed",
+			"The total number of connections closed due to SetMaxIdleConns.", "{connection}"),
+		maxIdleTimeClosed: newCounter("db.pool.connections.max_idle_time_closed",
+			"The total number of connections closed due to SetConnMaxIdleTime.", "{connection}"),
+		maxLifetimeClosed: newCounter("db.pool.connections.max_lifetime_closed",
+			"The total number of connections closed due to SetConnMaxLifetime.", "{connection}"),
+	}
+	var err error
+	pool.waitDuration, err = meter.Float64ObservableCounter("db.pool.wait.duration",
+		metric.WithDescription("The total time blocked waiting for a new connection."), metric.WithUnit("s"))
+	errs = append(errs, err)
+	if err = errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+func (pool *poolInstruments) observables() []metric.Observable {
+	return []metric.Observable{pool.maxOpen, pool.open, pool.inUse, pool.idle, pool.waitCount, pool.waitDuration,
+		pool.maxIdleClosed, pool.maxIdleTimeClosed, pool.maxLifetimeClosed}
+}
+
+func (pool *poolInstruments) observe(o metric.Observer, stats sql.DBStats, attrs metric.ObserveOption) {
+	o.ObserveInt64(pool.maxOpen, int64(stats.MaxOpenConnections), attrs)
+	o.ObserveInt64(pool.open, int64(stats.OpenConnections), attrs)
+	o.ObserveInt64(pool.inUse, int64(stats.InUse), attrs)
+	o.ObserveInt64(pool.idle, int64(stats.Idle), attrs)
+	o.ObserveInt64(pool.waitCount, stats.WaitCount, attrs)
+	o.ObserveFloat64(pool.waitDuration, stats.WaitDuration.Seconds(), attrs)
+	o.ObserveInt64(pool.maxIdleClosed, stats.MaxIdleClosed, attrs)
+	o.ObserveInt64(pool.maxIdleTimeClosed, stats.MaxIdleTimeClosed, attrs)
+	o.ObserveInt64(pool.maxLifetimeClosed, stats.MaxLifetimeClosed, attrs)
+}
@@ -0,0 +1,255 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package otelmetrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// recordingMeter records measurements of the instruments by the instrument name and attributes.
+type recordingMeter struct {
+	noop.Meter
+	mu        sync.Mutex
+	values    map[string]float64
+	callbacks []metric.Callback
+}
+
+func newRecordingMeter() *recordingMeter {
+	return &recordingMeter{values: make(map[string]float64)}
+}
+
+func (m *recordingMeter) record(name string, value float64, attrs attribute.Set) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[name+attrs.Encoded(attribute.DefaultEncoder())] += value
+}
+
+func (m *recordingMeter) value(name string, attrs ...attribute.KeyValue) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	set := attribute.NewSet(attrs...)
+	return m.values[name+set.Encoded(attribute.DefaultEncoder())]
+}
+
+func (m *recordingMeter) Int64Counter(name string, _ ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return &recordingInt64Counter{meter: m, name: name}, nil
+}
+
+func (m *recordingMeter) Int64Histogram(name string, _ ...metric.Int64HistogramOption) (metric.Int64Histogram, error) {
+	return &recordingInt64Histogram{meter: m, name: name}, nil
+}
+
+func (m *recordingMeter) Float64Histogram(name string, _ ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return &recordingFloat64Histogram{meter: m, name: name}, nil
+}
+
+func (m *recordingMeter) Int64ObservableGauge(
+	name string, _ ...metric.Int64ObservableGaugeOption,
+) (metric.Int64ObservableGauge, error) {
+	return &namedInt64ObservableGauge{name: name}, nil
+}
+
+func (m *recordingMeter) Int64ObservableCounter(
+	name string, _ ...metric.Int64ObservableCounterOption,
+) (metric.Int64ObservableCounter, error) {
+	return &namedInt64ObservableCounter{name: name}, nil
+}
+
+func (m *recordingMeter) Float64ObservableCounter(
+	name string, _ ...metric.Float64ObservableCounterOption,
+) (metric.Float64ObservableCounter, error) {
+	return &namedFloat64ObservableCounter{name: name}, nil
+}
+
+func (m *recordingMeter) RegisterCallback(f metric.Callback, _ ...metric.Observable) (metric.Registration, error) {
+	m.callbacks = append(m.callbacks, f)
+	return noop.Registration{}, nil
+}
+
+func (m *recordingMeter) collect(ctx context.Context) error {
+	for _, callback := range m.callbacks {
+		if err := callback(ctx, &recordingObserver{meter: m}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type recordingInt64Counter struct {
+	noop.Int64Counter
+	meter *recordingMeter
+	name  string
+}
+
+func (c *recordingInt64Counter) Add(_ context.Context, incr int64, opts ...metric.AddOption) {
+	c.meter.record(c.name, float64(incr), metric.NewAddConfig(opts).Attributes())
+}
+
+type recordingInt64Histogram struct {
+	noop.Int64Histogram
+	meter *recordingMeter
+	name  string
+}
+
+func (h *recordingInt64Histogram) Record(_ context.Context, incr int64, opts ...metric.RecordOption) {
+	h.meter.record(h.name, float64(incr), metric.NewRecordConfig(opts).Attributes())
+}
+
+type recordingFloat64Histogram struct {
+	noop.Float64Histogram
+	meter *recordingMeter
+	name  string
+}
+
+func (h *recordingFloat64Histogram) Record(_ context.Context, _ float64, opts ...metric.RecordOption) {
+	h.meter.record(h.name+".count", 1, metric.NewRecordConfig(opts).Attributes())
+}
+
+type namedInt64ObservableGauge struct {
+	noop.Int64ObservableGauge
+	name string
+}
+
+type namedInt64ObservableCounter struct {
+	noop.Int64ObservableCounter
+	name string
+}
+
+type namedFloat64ObservableCounter struct {
+	noop.Float64ObservableCounter
+	name string
+}
+
+type recordingObserver struct {
+	noop.Observer
+	meter *recordingMeter
+}
+
+func (o *recordingObserver) ObserveInt64(obsrv metric.Int64Observable, value int64, opts ...metric.ObserveOption) {
+	var name string
+	switch instrument := obsrv.(type) {
+	case *namedInt64ObservableGauge:
+		name = instrument.name
+	case *namedInt64ObservableCounter:
+		name = instrument.name
+	}
+	o.meter.record(name, float64(value), metric.NewObserveConfig(opts).Attributes())
+}
+
+func (o *recordingObserver) ObserveFloat64(obsrv metric.Float64Observable, value float64, opts ...metric.ObserveOption) {
+	o.meter.record(obsrv.(*namedFloat64ObservableCounter).name, value, metric.NewObserveConfig(opts).Attributes())
+}
+
+func TestMetrics(t *testing.T) {
+	meter := newRecordingMeter()
+	metrics, err := NewMetrics(meter, WithAttributes(attribute.String("service", "api")))
+	require.NoError(t, err)
+	service := attribute.String("service", "api")
+
+	metrics.ObserveQueryDuration("list_users", time.Millisecond)
+	metrics.ObserveQueryDuration("list_users", time.Second)
+	metrics.ObserveQueryError("list_users", context.DeadlineExceeded)
+	metrics.ObserveQueryError("list_users", nil)
+	metrics.ObserveTxDuration("create_user", time.Millisecond)
+	metrics.IncTxCommits("create_user")
+	metrics.IncTxRollbacks("create_user")
+	metrics.IncTxRetries("create_user")
+	metrics.IncQueryRetries("list_users")
+	metrics.ObserveQueryRowsReturned("list_users", 10)
+	metrics.ObserveQueryRowsAffected("delete_users", 3)
+
+	require.Equal(t, 2.0, meter.value("db.query.duration.count", AttrQuery.String("list_users"), service))
+	require.Equal(t, 1.0, meter.value("db.query.errors",
+		AttrQuery.String("list_users"), AttrErrorClass.String(string(dbkit.QueryErrorClassTimeout)), service))
+	require.Equal(t, 1.0, meter.value("db.tx.duration.count", AttrTx.String("create_user"), service))
+	require.Equal(t, 1.0, meter.value("db.tx.commits", AttrTx.String("create_user"), service))
+	require.Equal(t, 1.0, meter.value("db.tx.rollbacks", AttrTx.String("create_user"), service))
+	require.Equal(t, 1.0, meter.value("db.tx.retries", AttrTx.String("create_user"), service))
+	require.Equal(t, 1.0, meter.value("db.query.retries", AttrQuery.String("list_users"), service))
+	require.Equal(t, 10.0, meter.value("db.query.rows_returned", AttrQuery.String("list_users"), service))
+	require.Equal(t, 3.0, meter.value("db.query.rows_affected", AttrQuery.String("delete_users"), service))
+}
+
+func TestMetrics_DoInTx(t *testing.T) {
+	meter := newRecordingMeter()
+	metrics, err := NewMetrics(meter)
+	require.NoError(t, err)
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	ctx := dbkit.ContextWithTxName(context.Background(), "noop")
+	require.NoError(t, dbkit.DoInTx(ctx, db, func(tx *sql.Tx) error { return nil }, dbkit.WithTxMetrics(metrics)))
+	require.Error(t, dbkit.DoInTx(ctx, db, func(tx *sql.Tx) error { return errors.New("fail") },
+		dbkit.WithTxMetrics(metrics), dbkit.WithErrorMetrics(metrics)))
+
+	require.Equal(t, 2.0, meter.value("db.tx.duration.count", AttrTx.String("noop")))
+	require.Equal(t, 1.0, meter.value("db.tx.commits", AttrTx.String("noop")))
+	require.Equal(t, 1.0, meter.value("db.tx.rollbacks", AttrTx.String("noop")))
+	require.Equal(t, 1.0, meter.value("db.query.errors",
+		AttrQuery.String("noop"), AttrErrorClass.String(string(dbkit.QueryErrorClassOther))))
+}
+
+func TestRegisterDBStats(t *testing.T) {
+	meter := newRecordingMeter()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+	db.SetMaxOpenConns(5)
+	require.NoError(t, db.Ping())
+
+	registration, err := RegisterDBStats(meter, db, "main")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, registration.Unregister()) }()
+	require.NoError(t, meter.collect(context.Background()))
+
+	dbName := AttrDBName.String("main")
+	require.Equal(t, 5.0, meter.value("db.pool.connections.max_open", dbName))
+	require.Equal(t, 1.0, meter.value("db.pool.connections.open", dbName))
+	require.Equal(t, 1.0, meter.value("db.pool.connections.idle", dbName))
+	require.Equal(t, 0.0, meter.value("db.pool.connections.in_use", dbName))
+}
+
+func TestMetrics_ObservePoolStats(t *testing.T) {
+	meter := newRecordingMeter()
+	metrics, err := NewMetrics(meter, WithAttributes(attribute.String("service", "api")))
+	require.NoError(t, err)
+
+	metrics.ObservePoolStats("main", sql.DBStats{MaxOpenConnections: 10, OpenConnections: 4, InUse: 1, Idle: 3})
+	metrics.ObservePoolStats("main", sql.DBStats{MaxOpenConnections: 10, OpenConnections: 5, InUse: 3, Idle: 2,
+		WaitCount: 7, WaitDuration: time.Second * 2})
+	require.NoError(t, meter.collect(context.Background()))
+
+	attrs := []attribute.KeyValue{AttrDBName.String("main"), attribute.String("service", "api")}
+	require.Equal(t, 10.0, meter.value("db.pool.connections.max_open", attrs...))
+	require.Equal(t, 5.0, meter.value("db.pool.connections.open", attrs...))
+	require.Equal(t, 3.0, meter.value("db.pool.connections.in_use", attrs...))
+	require.Equal(t, 2.0, meter.value("db.pool.connections.idle", attrs...))
+	require.Equal(t, 7.0, meter.value("db.pool.wait.count", attrs...))
+	require.Equal(t, 2.0, meter.value("db.pool.wait.duration", attrs...))
+}
+
+func TestNewMetrics_NoopMeter(t *testing.T) {
+	metrics, err := NewMetrics(noop.NewMeterProvider().Meter("dbkit"))
+	require.NoError(t, err)
+	metrics.ObserveQueryDuration("list_users", time.Second)
+}
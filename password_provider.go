@@ -0,0 +1,88 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PasswordProvider provides the database password when the connection pool is opened
+// (see Config.PasswordProvider), so the password may come from a mounted secret file
+// or a secrets manager (e.g., Vault) instead of being embedded in the configuration.
+type PasswordProvider interface {
+	Password(ctx context.Context) (string, error)
+}
+
+// PasswordProviderFunc is an adapter to allow the use of ordinary functions as PasswordProvider
+// (e.g., for Vault lookups).
+type PasswordProviderFunc func(ctx context.Context) (string, error)
+
+// Password calls f(ctx).
+func (f PasswordProviderFunc) Password(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// NewStaticPasswordProvider returns PasswordProvider that always provides the specified password.
+func NewStaticPasswordProvider(password string) PasswordProvider {
+	return PasswordProviderFunc(func(ctx context.Context) (string, error) {
+		return password, nil
+	})
+}
+
+// NewFilePasswordProvider returns PasswordProvider that reads the password from the file (e.g., Docker or Kubernetes secret).
+// The file is read on every call, so the rotated password is used for new connection pools
+// and for new connections of RotatingCredentialsConnector.
+// Trailing line breaks are trimmed.
+func NewFilePasswordProvider(path string) PasswordProvider {
+	return PasswordProviderFunc(func(ctx context.Context) (string, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read password file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	})
+}
+
+// NewEnvPasswordProvider returns PasswordProvider that takes the password from the environment variable.
+// An error is returned if the variable is not set.
+func NewEnvPasswordProvider(name string) PasswordProvider {
+	return PasswordProviderFunc(func(ctx context.Context) (string, error) {
+		password, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return password, nil
+	})
+}
+
+// ResolveDriverNameAndDSN returns driver name and DSN for connecting like DriverNameAndDSN,
+// but the password is taken from PasswordProvider if it's specified.
+// It's used by Open, so the password doesn't have to be stored in the configuration.
+func (c *Config) ResolveDriverNameAndDSN(ctx context.Context) (driverName, dsn string, err error) {
+	if c.PasswordProvider == nil || c.Dialect == DialectSQLite {
+		driverName, dsn = c.DriverNameAndDSN()
+		return driverName, dsn, nil
+	}
+	password, err := c.PasswordProvider.Password(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("get database password: %w", err)
+	}
+	cfgCopy := *c
+	switch c.Dialect {
+	case DialectMySQL:
+		cfgCopy.MySQL.Password = password
+	case DialectPostgres, DialectPgx:
+		cfgCopy.Postgres.Password = password
+	case DialectMSSQL:
+		cfgCopy.MSSQL.Password = password
+	}
+	driverName, dsn = cfgCopy.DriverNameAndDSN()
+	return driverName, dsn, nil
+}
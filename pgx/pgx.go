@@ -13,6 +13,7 @@ package pgx
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/jackc/pgx/v5/pgconn"
 	pg "github.com/jackc/pgx/v5/stdlib"
@@ -22,6 +23,7 @@ import (
 
 // nolint
 func init() {
+	dbkit.RegisterDialectDriver(dbkit.DialectPgx, &pg.Driver{})
 	dbkit.RegisterIsRetryableFunc(&pg.Driver{}, func(err error) bool {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -37,6 +39,33 @@ func init() {
 		}
 		return false
 	})
+	dbkit.RegisterErrorClassifier(func(err error) dbkit.ErrorClass {
+		var pgErr *pgconn.PgError
+		if !errors.As(err, &pgErr) {
+			return dbkit.ErrorClassNone
+		}
+		switch ErrCode(pgErr.Code) {
+		case ErrCodeQueryCanceled:
+			return classifyQueryCanceledError(pgErr.Message)
+		case ErrCodeDeadlockDetected:
+			return dbkit.ErrorClassDeadlock
+		case ErrCodeSerializationFailure:
+			return dbkit.ErrorClassSerializationFailure
+		case ErrCodeLockNotAvailable:
+			return dbkit.ErrorClassLockTimeout
+		}
+		if len(pgErr.Code) < 2 {
+			return dbkit.ErrorClassNone
+		}
+		return classifyErrorByClass(pgErr.Code[:2])
+	})
+	dbkit.RegisterConstraintViolationClassifier(func(err error) dbkit.ConstraintViolation {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			return classifyConstraintViolation(ErrCode(pgErr.Code))
+		}
+		return dbkit.ConstraintViolationNone
+	})
 }
 
 // ErrCode defines the type for Pgx error codes.
@@ -48,8 +77,54 @@ const (
 	ErrCodeDeadlockDetected     ErrCode = "40P01"
 	ErrCodeSerializationFailure ErrCode = "40001"
 	ErrFeatureNotSupported      ErrCode = "0A000"
+	ErrCodeQueryCanceled        ErrCode = "57014"
+	ErrCodeLockNotAvailable     ErrCode = "55P03"
+	ErrCodeForeignKeyViolation  ErrCode = "23503"
+	ErrCodeNotNullViolation     ErrCode = "23502"
+	ErrCodeCheckViolation       ErrCode = "23514"
 )
 
+func classifyConstraintViolation(code ErrCode) dbkit.ConstraintViolation {
+	switch code {
+	case ErrCodeUniqueViolation:
+		return dbkit.ConstraintViolationUnique
+	case ErrCodeForeignKeyViolation:
+		return dbkit.ConstraintViolationForeignKey
+	case ErrCodeNotNullViolation:
+		return dbkit.ConstraintViolationNotNull
+	case ErrCodeCheckViolation:
+		return dbkit.ConstraintViolationCheck
+	}
+	return dbkit.ConstraintViolationNone
+}
+
+// SQLSTATE classes (the first two characters of the error code) used for the error classification.
+const (
+	errClassConnectionException          = "08"
+	errClassIntegrityConstraintViolation = "23"
+)
+
+// classifyErrorByClass classifies the error by its SQLSTATE class.
+func classifyErrorByClass(class string) dbkit.ErrorClass {
+	switch class {
+	case errClassConnectionException:
+		return dbkit.ErrorClassConnectionFailure
+	case errClassIntegrityConstraintViolation:
+		return dbkit.ErrorClassConstraintViolation
+	}
+	return dbkit.ErrorClassNone
+}
+
+// classifyQueryCanceledError distinguishes server-side statement timeout from the cancellation requested by the client.
+// Both cases have the same SQLSTATE code (57014) and differ only in the message.
+// Cancellation "due to user request" is sent by the driver when the caller's context is done.
+func classifyQueryCanceledError(msg string) dbkit.ErrorClass {
+	if strings.Contains(msg, "statement timeout") {
+		return dbkit.ErrorClassStatementTimeout
+	}
+	return dbkit.ErrorClassContextCanceled
+}
+
 // CheckPostgresError checks if the passed error relates to Postgres,
 // and it's internal code matches the one from the argument.
 func CheckPostgresError(err error, errCode ErrCode) bool {
@@ -37,11 +37,37 @@ func TestPostgresIsRetryable(t *gotesting.T) {
 		require.True(t, isRetryable(err))
 		err = fmt.Errorf("One more time wrapped error: %w", err)
 		require.True(t, isRetryable(err))
+		require.True(t, dbkit.GetIsRetryableForDialect(dbkit.DialectPgx)(err))
 	}
 
 	require.False(t, isRetryable(driver.ErrBadConn))
 }
 
+func TestClassifyError(t *gotesting.T) {
+	require.Equal(t, dbkit.ErrorClassStatementTimeout, dbkit.ClassifyError(fmt.Errorf("wrapped error: %w",
+		&pgconn.PgError{Code: string(ErrCodeQueryCanceled), Message: "canceling statement due to statement timeout"})))
+	require.Equal(t, dbkit.ErrorClassContextCanceled, dbkit.ClassifyError(
+		&pgconn.PgError{Code: string(ErrCodeQueryCanceled), Message: "canceling statement due to user request"}))
+	require.Equal(t, dbkit.ErrorClassDeadlock, dbkit.ClassifyError(&pgconn.PgError{Code: string(ErrCodeDeadlockDetected)}))
+	require.Equal(t, dbkit.ErrorClassSerializationFailure,
+		dbkit.ClassifyError(&pgconn.PgError{Code: string(ErrCodeSerializationFailure)}))
+	require.Equal(t, dbkit.ErrorClassLockTimeout, dbkit.ClassifyError(&pgconn.PgError{Code: string(ErrCodeLockNotAvailable)}))
+	require.Equal(t, dbkit.ErrorClassConstraintViolation, dbkit.ClassifyError(&pgconn.PgError{Code: string(ErrCodeUniqueViolation)}))
+	require.Equal(t, dbkit.ErrorClassConnectionFailure, dbkit.ClassifyError(&pgconn.PgError{Code: "08006"}))
+	require.Equal(t, dbkit.ErrorClassOther, dbkit.ClassifyError(&pgconn.PgError{Code: "42601"}))
+
+	require.True(t, dbkit.IsUniqueViolation(fmt.Errorf("wrapped error: %w", &pgconn.PgError{Code: string(ErrCodeUniqueViolation)})))
+	require.True(t, dbkit.IsForeignKeyViolation(&pgconn.PgError{Code: string(ErrCodeForeignKeyViolation)}))
+	require.Equal(t, dbkit.ConstraintViolationNotNull,
+		dbkit.ClassifyConstraintViolation(&pgconn.PgError{Code: string(ErrCodeNotNullViolation)}))
+	require.Equal(t, dbkit.ConstraintViolationCheck,
+		dbkit.ClassifyConstraintViolation(&pgconn.PgError{Code: string(ErrCodeCheckViolation)}))
+	require.False(t, dbkit.IsUniqueViolation(&pgconn.PgError{Code: string(ErrCodeForeignKeyViolation)}))
+	require.True(t, dbkit.IsDeadlock(&pgconn.PgError{Code: string(ErrCodeDeadlockDetected)}))
+	require.True(t, dbkit.IsSerializationFailure(&pgconn.PgError{Code: string(ErrCodeSerializationFailure)}))
+	require.True(t, dbkit.IsConnectionError(&pgconn.PgError{Code: "08006"}))
+}
+
 func TestCheckInvalidCachedPlanError(t *gotesting.T) {
 	ctx, ctxCancel := context.WithTimeout(context.Background(), time.Minute*2)
 	defer ctxCancel()
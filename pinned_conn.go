@@ -0,0 +1,124 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/acronis/go-appkit/log"
+)
+
+type pinnedConnOptions struct {
+	leakThreshold time.Duration
+	leakLogger    log.FieldLogger
+	discard       bool
+}
+
+// PinnedConnOption is an option for WithPinnedConn.
+type PinnedConnOption func(*pinnedConnOptions)
+
+// WithPinnedConnLeakDetection makes WithPinnedConn log a warning (with the call site) if the callback
+// holds the connection longer than threshold, and log when such a callback finally returns.
+// A pinned connection is not available for other users of the pool, so a callback that never returns
+// (e.g., a LISTEN loop without context cancellation) silently shrinks the pool.
+func WithPinnedConnLeakDetection(threshold time.Duration, logger log.FieldLogger) PinnedConnOption {
+	return func(opts *pinnedConnOptions) {
+		opts.leakThreshold = threshold
+		opts.leakLogger = logger
+	}
+}
+
+// WithPinnedConnDiscard makes WithPinnedConn close the underlying connection instead of returning it to the pool,
+// so the session state (session variables, temporary tables, advisory locks, LISTEN subscriptions)
+// is not inherited by other users of the pool.
+func WithPinnedConnDiscard() PinnedConnOption {
+	return func(opts *pinnedConnOptions) {
+		opts.discard = true
+	}
+}
+
+// WithPinnedConn checks out a dedicated connection from the pool and calls fn with it, so all statements
+// executed by fn run in the same database session. It's needed for session-scoped features like
+// advisory locks, temporary tables, session variables or LISTEN.
+// The connection is released when fn returns (or panics), after that it returns sql.ErrConnDone,
+// so it cannot be used by goroutines started in fn by mistake.
+// Use WithPinnedConnDiscard if fn leaves the session in a state that must not be seen by other users of the pool.
+func WithPinnedConn(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error, options ...PinnedConnOption) error {
+	var opts pinnedConnOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	if opts.leakLogger != nil && opts.leakThreshold > 0 {
+		detector := newPinnedConnLeakDetector(opts.leakThreshold, opts.leakLogger, pinnedConnCallSite())
+		defer detector.stop()
+	}
+
+	defer func() {
+		if opts.discard {
+			// Returning driver.ErrBadConn from Raw makes database/sql close the underlying connection.
+			_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		_ = conn.Close()
+	}()
+	return fn(conn)
+}
+
+type pinnedConnLeakDetector struct {
+	logger    log.FieldLogger
+	callSite  string
+	startedAt time.Time
+	timer     *time.Timer
+
+	mu       sync.Mutex
+	reported bool
+}
+
+func newPinnedConnLeakDetector(threshold time.Duration, logger log.FieldLogger, callSite string) *pinnedConnLeakDetector {
+	d := &pinnedConnLeakDetector{logger: logger, callSite: callSite, startedAt: time.Now()}
+	d.timer = time.AfterFunc(threshold, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.reported = true
+		d.logger.Warn("pinned connection is held too long, the callback may never return",
+			log.String("call_site", d.callSite), log.Int64("held_ms", time.Since(d.startedAt).Milliseconds()))
+	})
+	return d
+}
+
+func (d *pinnedConnLeakDetector) stop() {
+	d.timer.Stop()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.reported {
+		d.logger.Info("pinned connection held too long is released",
+			log.String("call_site", d.callSite), log.Int64("held_ms", time.Since(d.startedAt).Milliseconds()))
+	}
+}
+
+// pinnedConnCallSite returns the location of the WithPinnedConn call.
+func pinnedConnCallSite() string {
+	pc, file, line, ok := runtime.Caller(2)
+	if !ok {
+		return ""
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		return fmt.Sprintf("%s:%d %s", file, line, fn.Name())
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/log"
+	"github.com/acronis/go-appkit/log/logtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPinnedConn(t *testing.T) {
+	ctx := context.Background()
+	openDB := func(t *testing.T) *sql.DB {
+		t.Helper()
+		db, err := Open(&Config{
+			Dialect:      DialectSQLite,
+			SQLite:       SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")},
+			MaxOpenConns: 1,
+			MaxIdleConns: 1,
+		}, false)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, db.Close()) })
+		return db
+	}
+	tempTableExists := func(t *testing.T, db *sql.DB) bool {
+		t.Helper()
+		var cnt int
+		require.NoError(t, db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM sqlite_temp_master WHERE type = 'table' AND name = 'pinned'").Scan(&cnt))
+		return cnt == 1
+	}
+	createTempTable := func(conn *sql.Conn) error {
+		if _, err := conn.ExecContext(ctx, "CREATE TEMP TABLE pinned (id INTEGER)"); err != nil {
+			return err
+		}
+		_, err := conn.ExecContext(ctx, "INSERT INTO pinned (id) VALUES (1)")
+		return err
+	}
+
+	t.Run("session state is kept within callback", func(t *testing.T) {
+		db := openDB(t)
+		var pinnedConn *sql.Conn
+		require.NoError(t, WithPinnedConn(ctx, db, func(conn *sql.Conn) error {
+			pinnedConn = conn
+			if err := createTempTable(conn); err != nil {
+				return err
+			}
+			var cnt int
+			return conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM pinned").Scan(&cnt)
+		}))
+		require.ErrorIs(t, pinnedConn.PingContext(ctx), sql.ErrConnDone)
+		require.True(t, tempTableExists(t, db), "connection must be returned to the pool")
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		db := openDB(t)
+		require.NoError(t, WithPinnedConn(ctx, db, createTempTable, WithPinnedConnDiscard()))
+		require.False(t, tempTableExists(t, db), "connection must be closed")
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		db := openDB(t)
+		fnErr := errors.New("callback error")
+		require.ErrorIs(t, WithPinnedConn(ctx, db, func(conn *sql.Conn) error { return fnErr }), fnErr)
+		require.NoError(t, db.PingContext(ctx), "connection must be released")
+	})
+
+	t.Run("leak detection", func(t *testing.T) {
+		db := openDB(t)
+		logRecorder := logtest.NewRecorder()
+		require.NoError(t, WithPinnedConn(ctx, db, func(conn *sql.Conn) error {
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		}, WithPinnedConnLeakDetection(10*time.Millisecond, logRecorder)))
+		entries := logRecorder.Entries()
+		require.Len(t, entries, 2)
+		require.Equal(t, log.LevelWarn, entries[0].Level)
+		require.Equal(t, log.LevelInfo, entries[1].Level)
+		callSite, ok := entries[0].FindField("call_site")
+		require.True(t, ok)
+		require.True(t, strings.Contains(string(callSite.Bytes), "pinned_conn_test.go"), string(callSite.Bytes))
+
+		logRecorder.Reset()
+		require.NoError(t, WithPinnedConn(ctx, db, func(conn *sql.Conn) error { return nil },
+			WithPinnedConnLeakDetection(time.Minute, logRecorder)))
+		require.Empty(t, logRecorder.Entries())
+	})
+}
@@ -5,17 +5,24 @@ Copyright © 2019-2023 Acronis International GmbH.
 package postgres
 
 import (
-	gotesting "testing"
+	"testing"
 
 	_ "github.com/lib/pq"
 
 	"github.com/acronis/go-dbkit"
-	"github.com/acronis/go-dbkit/internal/testing"
+	"github.com/acronis/go-dbkit/dbtest"
+	"github.com/acronis/go-dbkit/drivertest"
 )
 
-func TestDeadlockErrorHandling(t *gotesting.T) {
-	testing.DeadlockTest(t, dbkit.DialectPostgres,
+func TestDeadlockErrorHandling(t *testing.T) {
+	db := dbtest.Open(t, dbkit.DialectPostgres)
+	drivertest.DeadlockTest(t, db.DB, dbkit.DialectPostgres,
 		func(err error) bool {
 			return CheckPostgresError(err, ErrCodeDeadlockDetected)
 		})
 }
+
+func TestConformance(t *testing.T) {
+	db := dbtest.Open(t, dbkit.DialectPostgres)
+	drivertest.RunConformanceTests(t, db.DB, dbkit.DialectPostgres)
+}
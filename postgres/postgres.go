@@ -13,6 +13,7 @@ package postgres
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/lib/pq"
 
@@ -21,6 +22,7 @@ import (
 
 // nolint
 func init() {
+	dbkit.RegisterDialectDriver(dbkit.DialectPostgres, &pq.Driver{})
 	dbkit.RegisterIsRetryableFunc(&pq.Driver{}, func(err error) bool {
 		var pgErr *pq.Error
 		if errors.As(err, &pgErr) {
@@ -34,6 +36,30 @@ func init() {
 		}
 		return false
 	})
+	dbkit.RegisterErrorClassifier(func(err error) dbkit.ErrorClass {
+		var pgErr *pq.Error
+		if !errors.As(err, &pgErr) {
+			return dbkit.ErrorClassNone
+		}
+		switch ErrCode(pgErr.Code.Name()) {
+		case ErrCodeQueryCanceled:
+			return classifyQueryCanceledError(pgErr.Message)
+		case ErrCodeDeadlockDetected:
+			return dbkit.ErrorClassDeadlock
+		case ErrCodeSerializationFailure:
+			return dbkit.ErrorClassSerializationFailure
+		case ErrCodeLockNotAvailable:
+			return dbkit.ErrorClassLockTimeout
+		}
+		return classifyErrorByClass(string(pgErr.Code.Class()))
+	})
+	dbkit.RegisterConstraintViolationClassifier(func(err error) dbkit.ConstraintViolation {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) {
+			return classifyConstraintViolation(ErrCode(pgErr.Code.Name()))
+		}
+		return dbkit.ConstraintViolationNone
+	})
 }
 
 // ErrCode defines the type for Postgres error codes.
@@ -44,8 +70,54 @@ const (
 	ErrCodeUniqueViolation      ErrCode = "unique_violation"
 	ErrCodeDeadlockDetected     ErrCode = "deadlock_detected"
 	ErrCodeSerializationFailure ErrCode = "serialization_failure"
+	ErrCodeQueryCanceled        ErrCode = "query_canceled"
+	ErrCodeLockNotAvailable     ErrCode = "lock_not_available"
+	ErrCodeForeignKeyViolation  ErrCode = "foreign_key_violation"
+	ErrCodeNotNullViolation     ErrCode = "not_null_violation"
+	ErrCodeCheckViolation       ErrCode = "check_violation"
+)
+
+func classifyConstraintViolation(code ErrCode) dbkit.ConstraintViolation {
+	switch code {
+	case ErrCodeUniqueViolation:
+		return dbkit.ConstraintViolationUnique
+	case ErrCodeForeignKeyViolation:
+		return dbkit.ConstraintViolationForeignKey
+	case ErrCodeNotNullViolation:
+		return dbkit.ConstraintViolationNotNull
+	case ErrCodeCheckViolation:
+		return dbkit.ConstraintViolationCheck
+	}
+	return dbkit.ConstraintViolationNone
+}
+
+// SQLSTATE classes (the first two characters of the error code) used for the error classification.
+const (
+	errClassConnectionException          = "08"
+	errClassIntegrityConstraintViolation = "23"
 )
 
+// classifyErrorByClass classifies the error by its SQLSTATE class.
+func classifyErrorByClass(class string) dbkit.ErrorClass {
+	switch class {
+	case errClassConnectionException:
+		return dbkit.ErrorClassConnectionFailure
+	case errClassIntegrityConstraintViolation:
+		return dbkit.ErrorClassConstraintViolation
+	}
+	return dbkit.ErrorClassNone
+}
+
+// classifyQueryCanceledError distinguishes server-side statement timeout from the cancellation requested by the client.
+// Both cases have the same SQLSTATE code (57014) and differ only in the message.
+// Cancellation "due to user request" is sent by the driver when the caller's context is done.
+func classifyQueryCanceledError(msg string) dbkit.ErrorClass {
+	if strings.Contains(msg, "statement timeout") {
+		return dbkit.ErrorClassStatementTimeout
+	}
+	return dbkit.ErrorClassContextCanceled
+}
+
 // CheckPostgresError checks if the passed error relates to Postgres,
 // and it's internal code matches the one from the argument.
 func CheckPostgresError(err error, errCode ErrCode) bool {
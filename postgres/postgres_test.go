@@ -23,4 +23,27 @@ func TestPostgresIsRetryable(t *testing.T) {
 	require.True(t, isRetryable(&pg.Error{Code: "40P01"}))
 	require.False(t, isRetryable(driver.ErrBadConn))
 	require.True(t, isRetryable(fmt.Errorf("wrapped error: %w", &pg.Error{Code: "40P01"})))
+	require.True(t, dbkit.GetIsRetryableForDialect(dbkit.DialectPostgres)(&pg.Error{Code: "40P01"}))
+}
+
+func TestClassifyError(t *testing.T) {
+	require.Equal(t, dbkit.ErrorClassStatementTimeout,
+		dbkit.ClassifyError(&pg.Error{Code: "57014", Message: "canceling statement due to statement timeout"}))
+	require.Equal(t, dbkit.ErrorClassContextCanceled,
+		dbkit.ClassifyError(&pg.Error{Code: "57014", Message: "canceling statement due to user request"}))
+	require.Equal(t, dbkit.ErrorClassDeadlock, dbkit.ClassifyError(&pg.Error{Code: "40P01"}))
+	require.Equal(t, dbkit.ErrorClassSerializationFailure, dbkit.ClassifyError(&pg.Error{Code: "40001"}))
+	require.Equal(t, dbkit.ErrorClassLockTimeout, dbkit.ClassifyError(&pg.Error{Code: "55P03"}))
+	require.Equal(t, dbkit.ErrorClassConstraintViolation, dbkit.ClassifyError(&pg.Error{Code: "23505"}))
+	require.Equal(t, dbkit.ErrorClassConnectionFailure, dbkit.ClassifyError(&pg.Error{Code: "08006"}))
+	require.Equal(t, dbkit.ErrorClassOther, dbkit.ClassifyError(&pg.Error{Code: "42601"}))
+
+	require.True(t, dbkit.IsUniqueViolation(fmt.Errorf("wrapped error: %w", &pg.Error{Code: "23505"})))
+	require.True(t, dbkit.IsForeignKeyViolation(&pg.Error{Code: "23503"}))
+	require.Equal(t, dbkit.ConstraintViolationNotNull, dbkit.ClassifyConstraintViolation(&pg.Error{Code: "23502"}))
+	require.Equal(t, dbkit.ConstraintViolationCheck, dbkit.ClassifyConstraintViolation(&pg.Error{Code: "23514"}))
+	require.False(t, dbkit.IsUniqueViolation(&pg.Error{Code: "23503"}))
+	require.True(t, dbkit.IsDeadlock(&pg.Error{Code: "40P01"}))
+	require.True(t, dbkit.IsSerializationFailure(&pg.Error{Code: "40001"}))
+	require.True(t, dbkit.IsConnectionError(&pg.Error{Code: "08006"}))
 }
@@ -0,0 +1,218 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/acronis/go-appkit/config"
+)
+
+// Profile defines possible values for named tuning profiles.
+// A profile sets the recommended (by the DBA team) pool sizes, connection lifetime, transaction isolation level
+// and timeouts for the used dialect. Explicitly configured values take precedence over the profile ones.
+type Profile string
+
+// Tuning profiles.
+const (
+	// ProfileOLTPSmall is intended for services with moderate load running a few instances.
+	ProfileOLTPSmall Profile = "oltp-small"
+	// ProfileOLTPLarge is intended for high-load services with short transactions
+	// that should fail fast instead of piling up when the database is slow.
+	ProfileOLTPLarge Profile = "oltp-large"
+	// ProfileBatch is intended for background processing and reporting with long-running queries.
+	ProfileBatch Profile = "batch"
+)
+
+// ProfileSettings contains values set by a tuning profile for a specific dialect.
+type ProfileSettings struct {
+	MaxOpenConns     int
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration
+	TxIsolationLevel sql.IsolationLevel
+	// StatementTimeout is passed in the statement_timeout connection parameter for Postgres. Zero means no timeout.
+	StatementTimeout time.Duration
+	// BusyTimeout is used as SQLiteConfig.BusyTimeout for SQLite.
+	BusyTimeout time.Duration
+}
+
+var postgresProfiles = map[Profile]ProfileSettings{
+	ProfileOLTPSmall: {
+		MaxOpenConns:     10,
+		MaxIdleConns:     5,
+		ConnMaxLifetime:  30 * time.Minute,
+		TxIsolationLevel: sql.LevelReadCommitted,
+		StatementTimeout: 30 * time.Second,
+	},
+	ProfileOLTPLarge: {
+		MaxOpenConns:     50,
+		MaxIdleConns:     25,
+		ConnMaxLifetime:  30 * time.Minute,
+		TxIsolationLevel: sql.LevelReadCommitted,
+		StatementTimeout: 10 * time.Second,
+	},
+	ProfileBatch: {
+		MaxOpenConns:     5,
+		MaxIdleConns:     2,
+		ConnMaxLifetime:  time.Hour,
+		TxIsolationLevel: sql.LevelRepeatableRead,
+	},
+}
+
+var profileSettings = map[Profile]map[Dialect]ProfileSettings{
+	ProfileOLTPSmall: {
+		DialectPostgres: postgresProfiles[ProfileOLTPSmall],
+		DialectPgx:      postgresProfiles[ProfileOLTPSmall],
+		DialectMySQL: {
+			MaxOpenConns:     10,
+			MaxIdleConns:     5,
+			ConnMaxLifetime:  10 * time.Minute,
+			TxIsolationLevel: sql.LevelReadCommitted,
+		},
+		DialectMSSQL: {
+			MaxOpenConns:     10,
+			MaxIdleConns:     5,
+			ConnMaxLifetime:  10 * time.Minute,
+			TxIsolationLevel: sql.LevelReadCommitted,
+		},
+		DialectSQLite: {
+			MaxOpenConns: 4,
+			MaxIdleConns: 4,
+			BusyTimeout:  5 * time.Second,
+		},
+	},
+	ProfileOLTPLarge: {
+		DialectPostgres: postgresProfiles[ProfileOLTPLarge],
+		DialectPgx:      postgresProfiles[ProfileOLTPLarge],
+		DialectMySQL: {
+			MaxOpenConns:     50,
+			MaxIdleConns:     25,
+			ConnMaxLifetime:  10 * time.Minute,
+			TxIsolationLevel: sql.LevelReadCommitted,
+		},
+		DialectMSSQL: {
+			MaxOpenConns:     50,
+			MaxIdleConns:     25,
+			ConnMaxLifetime:  10 * time.Minute,
+			TxIsolationLevel: sql.LevelReadCommitted,
+		},
+		DialectSQLite: {
+			MaxOpenConns: 8,
+			MaxIdleConns: 8,
+			BusyTimeout:  5 * time.Second,
+		},
+	},
+	ProfileBatch: {
+		DialectPostgres: postgresProfiles[ProfileBatch],
+		DialectPgx:      postgresProfiles[ProfileBatch],
+		DialectMySQL: {
+			MaxOpenConns:     5,
+			MaxIdleConns:     2,
+			ConnMaxLifetime:  30 * time.Minute,
+			TxIsolationLevel: sql.LevelRepeatableRead,
+		},
+		DialectMSSQL: {
+			MaxOpenConns:     5,
+			MaxIdleConns:     2,
+			ConnMaxLifetime:  30 * time.Minute,
+			TxIsolationLevel: sql.LevelReadCommitted,
+		},
+		DialectSQLite: {
+			MaxOpenConns: 1,
+			MaxIdleConns: 1,
+			BusyTimeout:  time.Minute,
+		},
+	},
+}
+
+// Profiles returns the list of available tuning profiles.
+func Profiles() []Profile {
+	return []Profile{ProfileOLTPSmall, ProfileOLTPLarge, ProfileBatch}
+}
+
+func isKnownProfile(profile Profile) bool {
+	for _, p := range Profiles() {
+		if p == profile {
+			return true
+		}
+	}
+	return false
+}
+
+// GetProfileSettings returns settings of the tuning profile for the dialect.
+// The second returned value is false if the profile or the dialect is unknown.
+func GetProfileSettings(profile Profile, dialect Dialect) (ProfileSettings, bool) {
+	settings, ok := profileSettings[profile][dialect]
+	return settings, ok
+}
+
+// ApplyProfile sets values of the tuning profile for the Config dialect.
+// It's intended for configs created in code, config.Loader applies the profile specified in the "profile" key automatically.
+func (c *Config) ApplyProfile(profile Profile) error {
+	settings, ok := GetProfileSettings(profile, c.Dialect)
+	if !ok {
+		return fmt.Errorf("unknown profile %q for %s dialect", profile, c.Dialect)
+	}
+	c.Profile = profile
+	c.MaxOpenConns = settings.MaxOpenConns
+	c.MaxIdleConns = settings.MaxIdleConns
+	c.ConnMaxLifetime = config.TimeDuration(settings.ConnMaxLifetime)
+	switch c.Dialect {
+	case DialectMySQL:
+		c.MySQL.TxIsolationLevel = IsolationLevel(settings.TxIsolationLevel)
+	case DialectPostgres, DialectPgx:
+		c.Postgres.TxIsolationLevel = IsolationLevel(settings.TxIsolationLevel)
+		c.Postgres.setStatementTimeoutParam(settings.StatementTimeout)
+	case DialectMSSQL:
+		c.MSSQL.TxIsolationLevel = IsolationLevel(settings.TxIsolationLevel)
+	case DialectSQLite:
+		c.SQLite.BusyTimeout = config.TimeDuration(settings.BusyTimeout)
+	}
+	return nil
+}
+
+// setProfileProviderDefaults overrides default values with the ones of the profile specified in the data provider.
+func setProfileProviderDefaults(dp config.DataProvider) {
+	// Errors are ignored here, they will be reported by Config.Set.
+	profile, _ := dp.GetString(cfgKeyProfile)
+	dialect, _ := dp.GetString(cfgKeyDialect)
+	settings, ok := GetProfileSettings(Profile(profile), Dialect(dialect))
+	if !ok {
+		return
+	}
+	dp.SetDefault(cfgKeyMaxOpenConns, settings.MaxOpenConns)
+	dp.SetDefault(cfgKeyMaxIdleConns, settings.MaxIdleConns)
+	dp.SetDefault(cfgKeyConnMaxLifetime, settings.ConnMaxLifetime)
+	switch Dialect(dialect) {
+	case DialectMySQL:
+		dp.SetDefault(cfgKeyMySQLTxLevel, IsolationLevel(settings.TxIsolationLevel).String())
+	case DialectPostgres, DialectPgx:
+		dp.SetDefault(cfgKeyPostgresTxLevel, IsolationLevel(settings.TxIsolationLevel).String())
+	case DialectMSSQL:
+		dp.SetDefault(cfgKeyMSSQLTxLevel, IsolationLevel(settings.TxIsolationLevel).String())
+	case DialectSQLite:
+		dp.SetDefault(cfgKeySQLiteBusyTimeout, settings.BusyTimeout)
+	}
+}
+
+// setStatementTimeoutParam adds the statement_timeout connection parameter (in milliseconds).
+// Already added parameter is not overridden.
+func (c *PostgresConfig) setStatementTimeoutParam(timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	if _, ok := c.AdditionalParameters[PgStatementTimeout]; ok {
+		return
+	}
+	if c.AdditionalParameters == nil {
+		c.AdditionalParameters = make(map[string]string)
+	}
+	c.AdditionalParameters[PgStatementTimeout] = strconv.FormatInt(timeout.Milliseconds(), 10)
+}
@@ -0,0 +1,46 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"strings"
+)
+
+// QueryAnnotationPrefix is the prefix of the query annotation made by AnnotateQuery.
+// It should be passed as the annotation prefix to dbrutil.ParseAnnotationInQuery
+// (and so to dbrutil.NewQueryMetricsEventReceiver and dbrutil.NewSlowQueryLogEventReceiver).
+const QueryAnnotationPrefix = "query:"
+
+type queryNameCtxKey struct{}
+
+// WithQueryName returns a new context with the name of the query that is used by AnnotateQueryContext.
+func WithQueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryNameCtxKey{}, name)
+}
+
+// QueryNameFromContext returns the name of the query stored in the context (see WithQueryName).
+func QueryNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(queryNameCtxKey{}).(string)
+	return name
+}
+
+// AnnotateQuery prepends the "/* query:<name> */" comment to the SQL query, so plain database/sql users get
+// the same metrics labeling and slow query logging as dbr users (see dbrutil.ParseAnnotationInQuery
+// with QueryAnnotationPrefix). The query is returned as is if the name is empty.
+func AnnotateQuery(name, query string) string {
+	name = strings.TrimSpace(strings.ReplaceAll(name, "*/", ""))
+	if name == "" {
+		return query
+	}
+	return "/* " + QueryAnnotationPrefix + name + " */ " + query
+}
+
+// AnnotateQueryContext annotates the SQL query with the name stored in the context (see WithQueryName and AnnotateQuery).
+func AnnotateQueryContext(ctx context.Context, query string) string {
+	return AnnotateQuery(QueryNameFromContext(ctx), query)
+}
@@ -0,0 +1,27 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotateQuery(t *testing.T) {
+	require.Equal(t, "/* query:list_users */ SELECT * FROM users", AnnotateQuery("list_users", "SELECT * FROM users"))
+	require.Equal(t, "/* query:list_users */ SELECT 1", AnnotateQuery("list_users*/", "SELECT 1"))
+	require.Equal(t, "SELECT 1", AnnotateQuery("", "SELECT 1"))
+	require.Equal(t, "query:list_users", QueryLeadingComment(AnnotateQuery("list_users", "SELECT 1")))
+
+	ctx := context.Background()
+	require.Equal(t, "SELECT 1", AnnotateQueryContext(ctx, "SELECT 1"))
+	ctx = WithQueryName(ctx, "count_users")
+	require.Equal(t, "count_users", QueryNameFromContext(ctx))
+	require.Equal(t, "/* query:count_users */ SELECT 1", AnnotateQueryContext(ctx, "SELECT 1"))
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrQueryBudgetExceeded is returned when the query budget of the request is exceeded.
+var ErrQueryBudgetExceeded = errors.New("query budget exceeded")
+
+// QueryBudgetOpts contains options for QueryBudget.
+type QueryBudgetOpts struct {
+	// MaxQueries is the maximum number of queries. Zero means no limit.
+	MaxQueries int
+	// MaxTotalTime is the maximum total execution time of all queries. Zero means no limit.
+	MaxTotalTime time.Duration
+	// Enforce makes queries fail when the budget is exceeded. Otherwise, exceeding is only reported.
+	Enforce bool
+}
+
+// QueryBudgetStats contains statistics of the queries tracked by QueryBudget.
+type QueryBudgetStats struct {
+	Queries   int
+	TotalTime time.Duration
+}
+
+// QueryBudget tracks the number of queries and their total execution time within a single request
+// (see NewContextWithQueryBudget). Exceeding the budget usually means N+1 query pattern,
+// so it may be surfaced in production before it becomes an incident.
+// QueryBudget is safe for concurrent use.
+type QueryBudget struct {
+	opts QueryBudgetOpts
+
+	mu       sync.Mutex
+	stats    QueryBudgetStats
+	exceeded bool
+}
+
+// NewQueryBudget creates a new QueryBudget.
+func NewQueryBudget(opts QueryBudgetOpts) *QueryBudget {
+	return &QueryBudget{opts: opts}
+}
+
+// Opts returns options of the budget.
+func (b *QueryBudget) Opts() QueryBudgetOpts {
+	return b.opts
+}
+
+// BeginQuery should be called before the query execution.
+// It returns true if the budget is exceeded for the first time by this call.
+func (b *QueryBudget) BeginQuery() (exceededNow bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stats.Queries++
+	return b.checkExceeded()
+}
+
+// EndQuery should be called after the query execution with its duration.
+// It returns true if the budget is exceeded for the first time by this call.
+func (b *QueryBudget) EndQuery(duration time.Duration) (exceededNow bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stats.TotalTime += duration
+	return b.checkExceeded()
+}
+
+func (b *QueryBudget) checkExceeded() bool {
+	if b.exceeded {
+		return false
+	}
+	if (b.opts.MaxQueries > 0 && b.stats.Queries > b.opts.MaxQueries) ||
+		(b.opts.MaxTotalTime > 0 && b.stats.TotalTime > b.opts.MaxTotalTime) {
+		b.exceeded = true
+		return true
+	}
+	return false
+}
+
+// Stats returns statistics of the tracked queries.
+func (b *QueryBudget) Stats() QueryBudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// Err returns error wrapping ErrQueryBudgetExceeded if the budget is exceeded, and nil otherwise.
+func (b *QueryBudget) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.exceeded {
+		return nil
+	}
+	return fmt.Errorf("%w: %d queries (max %d), total time %s (max %s)", ErrQueryBudgetExceeded,
+		b.stats.Queries, b.opts.MaxQueries, b.stats.TotalTime, b.opts.MaxTotalTime)
+}
+
+type queryBudgetCtxKey struct{}
+
+// NewContextWithQueryBudget creates a new context with QueryBudget.
+func NewContextWithQueryBudget(ctx context.Context, budget *QueryBudget) context.Context {
+	return context.WithValue(ctx, queryBudgetCtxKey{}, budget)
+}
+
+// GetQueryBudgetFromContext extracts QueryBudget from the context. It returns nil if there is no budget.
+func GetQueryBudgetFromContext(ctx context.Context) *QueryBudget {
+	budget, _ := ctx.Value(queryBudgetCtxKey{}).(*QueryBudget)
+	return budget
+}
@@ -0,0 +1,44 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBudget(t *testing.T) {
+	t.Run("max queries", func(t *testing.T) {
+		budget := NewQueryBudget(QueryBudgetOpts{MaxQueries: 2})
+		require.False(t, budget.BeginQuery())
+		require.False(t, budget.EndQuery(time.Millisecond))
+		require.False(t, budget.BeginQuery())
+		require.NoError(t, budget.Err())
+		require.True(t, budget.BeginQuery())
+		require.False(t, budget.BeginQuery(), "exceeding must be reported only once")
+		require.ErrorIs(t, budget.Err(), ErrQueryBudgetExceeded)
+		require.Equal(t, QueryBudgetStats{Queries: 4, TotalTime: time.Millisecond}, budget.Stats())
+	})
+
+	t.Run("max total time", func(t *testing.T) {
+		budget := NewQueryBudget(QueryBudgetOpts{MaxTotalTime: time.Second})
+		require.False(t, budget.BeginQuery())
+		require.False(t, budget.EndQuery(time.Second))
+		require.False(t, budget.BeginQuery())
+		require.True(t, budget.EndQuery(time.Millisecond))
+		require.ErrorIs(t, budget.Err(), ErrQueryBudgetExceeded)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		require.Nil(t, GetQueryBudgetFromContext(context.Background()))
+		budget := NewQueryBudget(QueryBudgetOpts{MaxQueries: 1})
+		require.Same(t, budget, GetQueryBudgetFromContext(NewContextWithQueryBudget(context.Background(), budget)))
+	})
+}
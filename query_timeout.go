@@ -0,0 +1,212 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"github.com/acronis/go-dbkit/internal/driverwrap"
+)
+
+// SQLQueryExecutor is an interface for executing SQL queries (e.g., *sql.DB, *sql.Tx or *sql.Conn).
+type SQLQueryExecutor interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// SQLQueryRowExecutor is an interface for executing SQL queries that return a single row
+// (e.g., *sql.DB, *sql.Tx or *sql.Conn).
+type SQLQueryRowExecutor interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// withTimeout returns ctx bounded by the timeout. Zero or negative timeout means no timeout.
+// If ctx already has an earlier deadline, it's kept.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// ExecWithTimeout executes the statement with ctx bounded by the timeout,
+// so the statement is canceled even if the caller passes context.Background().
+// Zero or negative timeout means no timeout.
+func ExecWithTimeout(
+	ctx context.Context, executor SQLExecutor, timeout time.Duration, query string, args ...interface{},
+) (sql.Result, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	return executor.ExecContext(ctx, query, args...)
+}
+
+// TimeoutRows is the result of QueryWithTimeout.
+// The context of the query is released when the rows are closed, so Close must always be called.
+type TimeoutRows struct {
+	*sql.Rows
+	cancel context.CancelFunc
+}
+
+// Close closes the rows and releases the context of the query.
+func (r *TimeoutRows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
+
+// QueryWithTimeout executes the query with ctx bounded by the timeout,
+// so the query (including reading the result set) is canceled even if the caller passes context.Background().
+// Zero or negative timeout means no timeout.
+func QueryWithTimeout(
+	ctx context.Context, executor SQLQueryExecutor, timeout time.Duration, query string, args ...interface{},
+) (*TimeoutRows, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &TimeoutRows{Rows: rows, cancel: cancel}, nil
+}
+
+// TimeoutRow is the result of QueryRowWithTimeout.
+// The context of the query is released when the row is scanned, so Scan must always be called.
+type TimeoutRow struct {
+	row    *sql.Row
+	cancel context.CancelFunc
+}
+
+// Scan copies the columns of the row into the values pointed at by dest (see sql.Row.Scan)
+// and releases the context of the query.
+func (r *TimeoutRow) Scan(dest ...interface{}) error {
+	defer r.cancel()
+	return r.row.Scan(dest...)
+}
+
+// Err returns the error of the query, if any (see sql.Row.Err).
+func (r *TimeoutRow) Err() error {
+	return r.row.Err()
+}
+
+// QueryRowWithTimeout executes the query that is expected to return at most one row with ctx bounded by the timeout,
+// so the query is canceled even if the caller passes context.Background().
+// Zero or negative timeout means no timeout.
+func QueryRowWithTimeout(
+	ctx context.Context, executor SQLQueryRowExecutor, timeout time.Duration, query string, args ...interface{},
+) *TimeoutRow {
+	ctx, cancel := withTimeout(ctx, timeout)
+	return &TimeoutRow{row: executor.QueryRowContext(ctx, query, args...), cancel: cancel}
+}
+
+// StatementTimeoutConnector implements driver.Connector and bounds each statement (query, exec or prepare)
+// with the timeout, so statements are canceled client-side even if callers pass context.Background().
+// The timeout of a query covers reading its result set as well.
+// If the context of the statement already has an earlier deadline, it's kept.
+// Transactions are not bounded as a whole, but statements executed in them are.
+// It's applied by Open, OpenConnector and OpenWithCredentialRotation when Config.StatementTimeout is set.
+type StatementTimeoutConnector struct {
+	connector driver.Connector
+	timeout   time.Duration
+}
+
+var _ driver.Connector = (*StatementTimeoutConnector)(nil)
+
+// NewStatementTimeoutConnector wraps the passed connector with bounding each statement with the timeout.
+func NewStatementTimeoutConnector(connector driver.Connector, timeout time.Duration) *StatementTimeoutConnector {
+	return &StatementTimeoutConnector{connector: connector, timeout: timeout}
+}
+
+// Connect establishes a new connection.
+func (c *StatementTimeoutConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &statementTimeoutConn{Conn: driverwrap.Conn{Conn: conn}, timeout: c.timeout}, nil
+}
+
+// Driver returns the underlying driver.
+func (c *StatementTimeoutConnector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+// Unwrap returns the wrapped connector.
+func (c *StatementTimeoutConnector) Unwrap() driver.Connector {
+	return c.connector
+}
+
+type statementTimeoutConn struct {
+	driverwrap.Conn
+	timeout time.Duration
+}
+
+func (c *statementTimeoutConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &statementTimeoutStmt{Stmt: driverwrap.Stmt{Stmt: stmt, Conn: c.Conn}, timeout: c.timeout}, nil
+}
+
+func (c *statementTimeoutConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	stmt, err := c.Conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &statementTimeoutStmt{Stmt: driverwrap.Stmt{Stmt: stmt, Conn: c.Conn}, timeout: c.timeout}, nil
+}
+
+func (c *statementTimeoutConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	rows, err := c.Conn.QueryContext(ctx, query, args)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &statementTimeoutRows{Rows: driverwrap.Rows{Rows: rows}, cancel: cancel}, nil
+}
+
+func (c *statementTimeoutConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Conn.ExecContext(ctx, query, args)
+}
+
+type statementTimeoutStmt struct {
+	driverwrap.Stmt
+	timeout time.Duration
+}
+
+func (s *statementTimeoutStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, cancel := withTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.Stmt.ExecContext(ctx, args)
+}
+
+func (s *statementTimeoutStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, cancel := withTimeout(ctx, s.timeout)
+	rows, err := s.Stmt.QueryContext(ctx, args)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &statementTimeoutRows{Rows: driverwrap.Rows{Rows: rows}, cancel: cancel}, nil
+}
+
+// statementTimeoutRows releases the context of the query when the rows are closed.
+type statementTimeoutRows struct {
+	driverwrap.Rows
+	cancel context.CancelFunc
+}
+
+func (r *statementTimeoutRows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/config"
+	"github.com/stretchr/testify/require"
+)
+
+// infiniteQuery never completes, so it may be stopped only by canceling its context.
+const infiniteQuery = `WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c`
+
+func TestQueryWithTimeout(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	_, err = ExecWithTimeout(ctx, db, time.Second, `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`)
+	require.NoError(t, err)
+	_, err = ExecWithTimeout(ctx, db, 0, `INSERT INTO users (name) VALUES (?), (?)`, "Alice", "Bob")
+	require.NoError(t, err)
+	_, err = ExecWithTimeout(ctx, db, 50*time.Millisecond, `INSERT INTO users (name) SELECT 'Sam' FROM (`+infiniteQuery+`)`)
+	require.Error(t, err)
+
+	rows, err := QueryWithTimeout(ctx, db, time.Second, `SELECT name FROM users ORDER BY id`)
+	require.NoError(t, err)
+	var names []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	require.Equal(t, []string{"Alice", "Bob"}, names)
+
+	var name string
+	require.NoError(t, QueryRowWithTimeout(ctx, db, time.Second, `SELECT name FROM users WHERE id = ?`, 2).Scan(&name))
+	require.Equal(t, "Bob", name)
+	require.ErrorIs(t, QueryRowWithTimeout(ctx, db, time.Second, `SELECT name FROM users WHERE id = ?`, 3).Scan(&name), sql.ErrNoRows)
+
+	var count int64
+	startTime := time.Now()
+	require.Error(t, QueryRowWithTimeout(ctx, db, 50*time.Millisecond, infiniteQuery).Scan(&count))
+	require.Less(t, time.Since(startTime), 10*time.Second)
+}
+
+func TestOpenWithStatementTimeout(t *testing.T) {
+	ctx := context.Background()
+	cfg := &Config{
+		Dialect:          DialectSQLite,
+		SQLite:           SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")},
+		MaxIdleConns:     1,
+		StatementTimeout: config.TimeDuration(100 * time.Millisecond),
+	}
+	db, err := Open(cfg, true)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`)
+	require.NoError(t, err)
+
+	// The statement is bounded even if the context has no deadline.
+	var count int64
+	require.Error(t, db.QueryRowContext(ctx, infiniteQuery).Scan(&count))
+	_, err = db.ExecContext(ctx, `INSERT INTO users (name) SELECT 'Sam' FROM (`+infiniteQuery+`)`)
+	require.Error(t, err)
+
+	// Statements executed in the transaction and prepared ones are bounded as well.
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, `INSERT INTO users (name) VALUES (?)`, "Alice")
+	require.NoError(t, err)
+	require.Error(t, tx.QueryRowContext(ctx, infiniteQuery).Scan(&count))
+	require.NoError(t, tx.Rollback())
+	stmt, err := db.PrepareContext(ctx, infiniteQuery)
+	require.NoError(t, err)
+	require.Error(t, stmt.QueryRowContext(ctx).Scan(&count))
+	require.NoError(t, stmt.Close())
+
+	// Rows of the query are readable until they are closed.
+	rows, err := db.QueryContext(ctx, `SELECT count(*) FROM users`)
+	require.NoError(t, err)
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&count))
+	require.Equal(t, int64(0), count)
+	require.NoError(t, rows.Close())
+}
@@ -0,0 +1,104 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SQLQueryExecutor is an interface for executing SQL queries that return rows (e.g., *sql.DB or *sql.Tx).
+type SQLQueryExecutor interface {
+	SQLExecutor
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// DeadJob represents a dead-lettered job, i.e. the job that exhausted all attempts or was failed explicitly (see Fail).
+type DeadJob struct {
+	ID          int64
+	Queue       string
+	Payload     []byte
+	Attempts    int
+	MaxAttempts int
+	LastError   string // Failure reason.
+	CreatedAt   time.Time
+	FailedAt    time.Time
+}
+
+// CountDead returns the number of dead-lettered jobs in the queue.
+func (q *Queue) CountDead(ctx context.Context, executor SQLQueryExecutor) (int, error) {
+	var cnt int
+	if err := executor.QueryRowContext(ctx, q.queries.countDead, q.name).Scan(&cnt); err != nil {
+		return 0, fmt.Errorf("count dead jobs in queue %s: %w", q.name, err)
+	}
+	return cnt, nil
+}
+
+// ListDead returns up to limit dead-lettered jobs of the queue with IDs greater than afterID, ordered by ID.
+// To iterate over all dead-lettered jobs, pass 0 as afterID first, and then the ID of the last returned job.
+func (q *Queue) ListDead(ctx context.Context, executor SQLQueryExecutor, afterID int64, limit int) (jobs []DeadJob, err error) {
+	rows, err := executor.QueryContext(ctx, q.queries.listDead, q.name, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list dead jobs in queue %s: %w", q.name, err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	for rows.Next() {
+		job := DeadJob{Queue: q.name}
+		var lastError sql.NullString
+		var createdAt, failedAt int64
+		if err = rows.Scan(&job.ID, &job.Payload, &job.Attempts, &job.MaxAttempts,
+			&lastError, &createdAt, &failedAt); err != nil {
+			return nil, fmt.Errorf("scan dead job: %w", err)
+		}
+		job.LastError = lastError.String
+		job.CreatedAt = time.UnixMilli(createdAt)
+		job.FailedAt = time.UnixMilli(failedAt)
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// Requeue moves the dead-lettered jobs with the given IDs back to the queue, so they are claimed by workers
+// as soon as possible with the full number of attempts. The last error is kept until the next failure.
+// Returns the number of requeued jobs (IDs of jobs that are not dead-lettered or belong to other queues are ignored).
+func (q *Queue) Requeue(ctx context.Context, executor SQLExecutor, ids ...int64) (int, error) {
+	runAt := q.clock.Now().UnixMilli()
+	requeued := 0
+	for _, id := range ids {
+		result, err := executor.ExecContext(ctx, q.queries.requeue, runAt, id, q.name)
+		if err != nil {
+			return requeued, fmt.Errorf("requeue dead job %d in queue %s: %w", id, q.name, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return requeued, fmt.Errorf("requeue dead job %d in queue %s: %w", id, q.name, err)
+		}
+		requeued += int(affected)
+	}
+	return requeued, nil
+}
+
+// PurgeDead deletes dead-lettered jobs of the queue that failed before the given time.
+// Returns the number of deleted jobs.
+func (q *Queue) PurgeDead(ctx context.Context, executor SQLExecutor, failedBefore time.Time) (int64, error) {
+	result, err := executor.ExecContext(ctx, q.queries.purgeDead, q.name, failedBefore.UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("purge dead jobs in queue %s: %w", q.name, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("purge dead jobs in queue %s: %w", q.name, err)
+	}
+	return affected, nil
+}
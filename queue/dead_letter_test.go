@@ -0,0 +1,103 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/testkit"
+)
+
+func TestQueue_DeadLetter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	clock := testkit.NewFakeClock(now)
+	q, err := NewQueue(dbkit.DialectSQLite, "emails", WithClock(clock))
+	require.NoError(t, err)
+	dbConn := openTestDB(t, q)
+
+	otherQueue, err := NewQueue(dbkit.DialectSQLite, "reports", WithClock(clock))
+	require.NoError(t, err)
+
+	failJob := func(q *Queue, payload string, failedAt time.Time) {
+		t.Helper()
+		require.NoError(t, q.Enqueue(ctx, dbConn, []byte(payload)))
+		jobs, claimErr := q.Claim(ctx, dbConn, 1, time.Minute)
+		require.NoError(t, claimErr)
+		require.Len(t, jobs, 1)
+		clock.Set(failedAt)
+		require.NoError(t, q.Fail(ctx, dbConn, jobs[0], errors.New("failed: "+payload)))
+		clock.Set(now)
+	}
+	failJob(q, "job1", now.Add(time.Minute))
+	failJob(q, "job2", now.Add(2*time.Minute))
+	failJob(q, "job3", now.Add(3*time.Minute))
+	failJob(otherQueue, "report", now.Add(time.Minute))
+	require.NoError(t, q.Enqueue(ctx, dbConn, []byte("pending job")))
+
+	cnt, err := q.CountDead(ctx, dbConn)
+	require.NoError(t, err)
+	require.Equal(t, 3, cnt)
+
+	metrics := NewPrometheusMetrics()
+	require.NoError(t, metrics.UpdateDeadJobs(ctx, dbConn, q, otherQueue))
+	require.Equal(t, 3.0, promtestutil.ToFloat64(metrics.DeadJobs.WithLabelValues("emails")))
+	require.Equal(t, 1.0, promtestutil.ToFloat64(metrics.DeadJobs.WithLabelValues("reports")))
+
+	// List with pagination.
+	deadJobs, err := q.ListDead(ctx, dbConn, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, deadJobs, 2)
+	require.Equal(t, "job1", string(deadJobs[0].Payload))
+	require.Equal(t, "failed: job1", deadJobs[0].LastError)
+	require.Equal(t, 1, deadJobs[0].Attempts)
+	require.Equal(t, DefaultMaxAttempts, deadJobs[0].MaxAttempts)
+	require.Equal(t, "emails", deadJobs[0].Queue)
+	require.True(t, now.Equal(deadJobs[0].CreatedAt))
+	require.True(t, now.Add(time.Minute).Equal(deadJobs[0].FailedAt))
+	require.Equal(t, "job2", string(deadJobs[1].Payload))
+	deadJobs, err = q.ListDead(ctx, dbConn, deadJobs[1].ID, 2)
+	require.NoError(t, err)
+	require.Len(t, deadJobs, 1)
+	require.Equal(t, "job3", string(deadJobs[0].Payload))
+	job3ID := deadJobs[0].ID
+
+	// Requeue.
+	otherDeadJobs, err := otherQueue.ListDead(ctx, dbConn, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, otherDeadJobs, 1)
+	requeued, err := q.Requeue(ctx, dbConn, job3ID, otherDeadJobs[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, requeued, "job of another queue must not be requeued")
+
+	jobs, err := q.Claim(ctx, dbConn, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	require.Equal(t, "job3", string(jobs[0].Payload))
+	require.Equal(t, 1, jobs[0].Attempt)
+	require.NoError(t, q.Complete(ctx, dbConn, jobs[0]))
+
+	// Purge.
+	purged, err := q.PurgeDead(ctx, dbConn, now.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), purged)
+	deadJobs, err = q.ListDead(ctx, dbConn, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, deadJobs, 1)
+	require.Equal(t, "job2", string(deadJobs[0].Payload))
+
+	require.NoError(t, metrics.UpdateDeadJobs(ctx, dbConn, q, otherQueue))
+	require.Equal(t, 1.0, promtestutil.ToFloat64(metrics.DeadJobs.WithLabelValues("emails")))
+}
@@ -0,0 +1,19 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package queue provides a simple job queue backed by SQL database.
+// Jobs may be enqueued inside the caller's transaction (so they are committed or rolled back atomically with
+// the business data), delayed, and retried with backoff on failures.
+// Worker claims jobs using dialect-appropriate locking: "FOR UPDATE SKIP LOCKED" on PostgreSQL and MySQL 8+,
+// so concurrent workers don't block each other, and optimistic claiming elsewhere (SQLite, MSSQL).
+// Jobs may have priorities (WithPriority) and fairness keys (WithFairnessKey, e.g., tenant IDs).
+// With fair scheduling (WithFairScheduling), claimed jobs are split between fairness keys proportionally
+// to their weights, so one noisy tenant cannot starve others sharing the same queue.
+// High-throughput consumers may claim more jobs per round-trip than they process at once (WithPrefetch)
+// and acknowledge processed jobs in batches within one transaction (WithBatchAck).
+// Jobs that exhausted all attempts are dead-lettered: they may be listed with failure reasons (Queue.ListDead),
+// requeued (Queue.Requeue) or purged (Queue.PurgeDead), and the dead-letter queue depth is exposed by PrometheusMetrics.
+package queue
@@ -0,0 +1,81 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package queue
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusMetricsLabelQueue is a label that contains the name of the queue.
+const PrometheusMetricsLabelQueue = "queue"
+
+// PrometheusMetricsOpts represents options for PrometheusMetrics.
+type PrometheusMetricsOpts struct {
+	// Namespace is a namespace for metrics. It will be prepended to all metric names.
+	Namespace string
+
+	// ConstLabels is a set of labels that will be applied to all metrics.
+	ConstLabels prometheus.Labels
+}
+
+// PrometheusMetrics represents collector of metrics for the job queues.
+type PrometheusMetrics struct {
+	// DeadJobs contains the number of dead-lettered jobs (the dead-letter queue depth) per queue.
+	// It's updated by UpdateDeadJobs.
+	DeadJobs *prometheus.GaugeVec
+}
+
+// NewPrometheusMetrics creates a new metrics collector.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return NewPrometheusMetricsWithOpts(PrometheusMetricsOpts{})
+}
+
+// NewPrometheusMetricsWithOpts is a more configurable version of creating PrometheusMetrics.
+func NewPrometheusMetricsWithOpts(opts PrometheusMetricsOpts) *PrometheusMetrics {
+	deadJobs := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace:   opts.Namespace,
+			Name:        "db_queue_dead_jobs",
+			Help:        "Number of dead-lettered jobs in the queue.",
+			ConstLabels: opts.ConstLabels,
+		},
+		[]string{PrometheusMetricsLabelQueue},
+	)
+	return &PrometheusMetrics{DeadJobs: deadJobs}
+}
+
+// MustRegister does registration of metrics collector in Prometheus and panics if any error occurs.
+func (pm *PrometheusMetrics) MustRegister() {
+	prometheus.MustRegister(pm.AllMetrics()...)
+}
+
+// Unregister cancels registration of metrics collector in Prometheus.
+func (pm *PrometheusMetrics) Unregister() {
+	for _, m := range pm.AllMetrics() {
+		prometheus.Unregister(m)
+	}
+}
+
+// AllMetrics returns a list of metrics of this collector. This can be used to register these metrics in push gateway.
+func (pm *PrometheusMetrics) AllMetrics() []prometheus.Collector {
+	return []prometheus.Collector{pm.DeadJobs}
+}
+
+// UpdateDeadJobs counts dead-lettered jobs in the given queues and updates the DeadJobs gauge.
+// It's supposed to be called periodically (e.g., by the same process that runs workers).
+func (pm *PrometheusMetrics) UpdateDeadJobs(ctx context.Context, executor SQLQueryExecutor, queues ...*Queue) error {
+	for _, q := range queues {
+		cnt, err := q.CountDead(ctx, executor)
+		if err != nil {
+			return err
+		}
+		pm.DeadJobs.WithLabelValues(q.Name()).Set(float64(cnt))
+	}
+	return nil
+}
@@ -0,0 +1,617 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+// DefaultTableName is a default name for the table that stores jobs.
+const DefaultTableName = "queue_jobs"
+
+// DefaultMaxAttempts is a default maximum number of attempts to process the job.
+const DefaultMaxAttempts = 5
+
+const maxQueueNameLen = 64
+
+// SQLExecutor is an interface for executing SQL queries (e.g., *sql.DB or *sql.Tx).
+type SQLExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Job represents a job claimed by the worker.
+type Job struct {
+	ID          int64
+	Queue       string
+	Payload     []byte
+	Attempt     int // Number of the current attempt, starting from 1.
+	MaxAttempts int
+	Priority    int
+	FairnessKey string
+}
+
+// Queue represents a named job queue stored in the SQL database.
+// Multiple queues may share the same table.
+type Queue struct {
+	name       string
+	queries    dbQueries
+	clock      dbkit.Clock
+	fair       bool
+	fairWeight func(fairnessKey string) int
+}
+
+// QueueOption is an option for NewQueue.
+type QueueOption func(*queueOptions)
+
+type queueOptions struct {
+	tableName         string
+	skipLockedEnabled bool
+	fair              bool
+	fairWeight        func(fairnessKey string) int
+	clock             dbkit.Clock
+}
+
+// WithTableName sets a custom table name for the table that stores jobs.
+func WithTableName(tableName string) QueueOption {
+	return func(o *queueOptions) {
+		o.tableName = tableName
+	}
+}
+
+// WithoutSkipLocked disables "FOR UPDATE SKIP LOCKED" when jobs are claimed on PostgreSQL and MySQL,
+// so optimistic claiming is used instead. It's required for MySQL versions older than 8.0.
+func WithoutSkipLocked() QueueOption {
+	return func(o *queueOptions) {
+		o.skipLockedEnabled = false
+	}
+}
+
+// WithClock sets the clock that is used for delays, claim (visibility) timeouts and poll intervals
+// of workers (dbkit.RealClock by default). It's intended for tests.
+func WithClock(clock dbkit.Clock) QueueOption {
+	return func(o *queueOptions) {
+		o.clock = clock
+	}
+}
+
+// NewQueue creates a new queue with the given name.
+func NewQueue(dialect dbkit.Dialect, name string, options ...QueueOption) (*Queue, error) {
+	if name == "" {
+		return nil, fmt.Errorf("queue name cannot be empty")
+	}
+	if len(name) > maxQueueNameLen {
+		return nil, fmt.Errorf("queue name cannot be longer than %d symbols", maxQueueNameLen)
+	}
+	opts := queueOptions{skipLockedEnabled: true, clock: dbkit.RealClock{}}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.tableName == "" {
+		opts.tableName = DefaultTableName
+	}
+	q, err := newDBQueries(dialect, opts.tableName, opts.skipLockedEnabled)
+	if err != nil {
+		return nil, err
+	}
+	return &Queue{name: name, queries: q, clock: opts.clock, fair: opts.fair, fairWeight: opts.fairWeight}, nil
+}
+
+// Name returns the name of the queue.
+func (q *Queue) Name() string {
+	return q.name
+}
+
+// Migrations returns set of migrations that must be applied before using the queue.
+func (q *Queue) Migrations() []migrate.Migration {
+	return []migrate.Migration{
+		migrate.NewCustomMigration(createTableMigrationID,
+			[]string{q.queries.createTable, q.queries.createIndex}, []string{q.queries.dropTable}, nil, nil),
+		migrate.NewCustomMigration(addSchedulingColumnsMigrationID,
+			q.AddSchedulingColumnsSQL(), q.queries.dropSchedulingColumns, nil, nil),
+	}
+}
+
+// CreateTableSQL returns SQL query for creating a table that stores jobs.
+func (q *Queue) CreateTableSQL() string {
+	return q.queries.createTable
+}
+
+// CreateIndexSQL returns SQL query for creating an index that is used for claiming jobs.
+func (q *Queue) CreateIndexSQL() string {
+	return q.queries.createIndex
+}
+
+// AddSchedulingColumnsSQL returns SQL queries for adding the priority and fairness_key columns
+// (and the index that is used for fair scheduling) to the table created by CreateTableSQL.
+func (q *Queue) AddSchedulingColumnsSQL() []string {
+	return append(append([]string{}, q.queries.addSchedulingColumns...), q.queries.createFairnessIndex)
+}
+
+// DropTableSQL returns SQL query for dropping a table that stores jobs.
+func (q *Queue) DropTableSQL() string {
+	return q.queries.dropTable
+}
+
+// EnqueueOption is an option for Enqueue.
+type EnqueueOption func(*enqueueOptions)
+
+type enqueueOptions struct {
+	delay       time.Duration
+	runAt       time.Time
+	maxAttempts int
+	priority    int
+	fairnessKey string
+}
+
+// WithDelay delays the job execution for the given duration.
+func WithDelay(delay time.Duration) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.delay = delay
+	}
+}
+
+// WithRunAt delays the job execution until the given time.
+func WithRunAt(runAt time.Time) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.runAt = runAt
+	}
+}
+
+// WithMaxAttempts sets the maximum number of attempts to process the job.
+// When all attempts fail, the job is marked as failed and isn't claimed anymore. By default, it's 5.
+func WithMaxAttempts(maxAttempts int) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.maxAttempts = maxAttempts
+	}
+}
+
+// Enqueue adds a new job to the queue.
+// If executor is *sql.Tx, the job becomes visible for workers only after the transaction is committed.
+func (q *Queue) Enqueue(ctx context.Context, executor SQLExecutor, payload []byte, options ...EnqueueOption) error {
+	var opts enqueueOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.maxAttempts <= 0 {
+		opts.maxAttempts = DefaultMaxAttempts
+	}
+	now := q.clock.Now()
+	runAt := opts.runAt
+	if runAt.IsZero() {
+		runAt = now.Add(opts.delay)
+	}
+	if len(opts.fairnessKey) > maxFairnessKeyLen {
+		return fmt.Errorf("fairness key cannot be longer than %d symbols", maxFairnessKeyLen)
+	}
+	if _, err := executor.ExecContext(ctx, q.queries.enqueue, q.name, payload, opts.maxAttempts,
+		runAt.UnixMilli(), now.UnixMilli(), opts.priority, opts.fairnessKey); err != nil {
+		return fmt.Errorf("enqueue job to queue %s: %w", q.name, err)
+	}
+	return nil
+}
+
+// Claim claims up to limit ready jobs (including jobs whose previous claim is expired, e.g., because the worker crashed)
+// for the lockTimeout duration. Claimed jobs are not visible to other workers until they are completed, retried,
+// or the lock timeout expires. Jobs whose claim of the last attempt is expired are marked as failed instead.
+// Usually, Worker should be used instead of calling this method directly.
+func (q *Queue) Claim(ctx context.Context, dbConn *sql.DB, limit int, lockTimeout time.Duration) ([]Job, error) {
+	var jobs []Job
+	err := dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+		jobs = jobs[:0]
+		now := q.clock.Now()
+		// Expired claims of the last attempts are not claimed again (e.g., the job crashes its worker every time),
+		// so such jobs are dead-lettered here.
+		if _, err := tx.ExecContext(ctx, q.queries.failExpired,
+			now.UnixMilli(), errExpiredLastAttempt, q.name, now.UnixMilli()); err != nil {
+			return fmt.Errorf("fail expired jobs: %w", err)
+		}
+		var candidates []Job
+		var err error
+		if q.fair {
+			candidates, err = q.selectFairClaimable(ctx, tx, now, limit)
+		} else {
+			candidates, err = q.selectClaimable(ctx, tx, now, limit)
+		}
+		if err != nil {
+			return err
+		}
+		lockedUntil := now.Add(lockTimeout).UnixMilli()
+		for _, job := range candidates {
+			result, err := tx.ExecContext(ctx, q.queries.claim, lockedUntil, job.ID, now.UnixMilli(), now.UnixMilli())
+			if err != nil {
+				return fmt.Errorf("claim job %d: %w", job.ID, err)
+			}
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("claim job %d: %w", job.ID, err)
+			}
+			if affected == 0 {
+				continue // Job is already claimed by someone else.
+			}
+			job.Attempt++
+			jobs = append(jobs, job)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim jobs from queue %s: %w", q.name, err)
+	}
+	return jobs, nil
+}
+
+func (q *Queue) selectClaimable(ctx context.Context, tx *sql.Tx, now time.Time, limit int) ([]Job, error) {
+	rows, err := tx.QueryContext(ctx, q.queries.selectClaimable, q.name, now.UnixMilli(), now.UnixMilli(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("select claimable jobs: %w", err)
+	}
+	return q.scanClaimable(rows)
+}
+
+func (q *Queue) scanClaimable(rows *sql.Rows) (jobs []Job, err error) {
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	for rows.Next() {
+		job := Job{Queue: q.name}
+		if err = rows.Scan(&job.ID, &job.Payload, &job.Attempt, &job.MaxAttempts, &job.Priority, &job.FairnessKey); err != nil {
+			return nil, fmt.Errorf("scan claimable job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// Complete removes the successfully processed job from the queue.
+// ErrJobNotClaimed is returned if the job's claim is expired and it was claimed again.
+func (q *Queue) Complete(ctx context.Context, executor SQLExecutor, job Job) error {
+	return execAndCheckClaim(ctx, executor, q.queries.complete, job.ID, job.Attempt)
+}
+
+// CompleteBatch removes the successfully processed jobs from the queue in a single transaction,
+// reducing per-job overhead for high-throughput consumers.
+// Jobs whose claims are expired and which were claimed again are skipped.
+// Returns the number of completed jobs.
+func (q *Queue) CompleteBatch(ctx context.Context, dbConn *sql.DB, jobs []Job) (int, error) {
+	var completed int
+	err := dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+		completed = 0
+		for _, job := range jobs {
+			if err := execAndCheckClaim(ctx, tx, q.queries.complete, job.ID, job.Attempt); err != nil {
+				if errors.Is(err, ErrJobNotClaimed) {
+					continue
+				}
+				return fmt.Errorf("complete job %d: %w", job.ID, err)
+			}
+			completed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("complete jobs from queue %s: %w", q.name, err)
+	}
+	return completed, nil
+}
+
+// Retry schedules the failed job for the next attempt at the given time.
+// If all attempts are exhausted, the job is marked as failed instead.
+// ErrJobNotClaimed is returned if the job's claim is expired and it was claimed again.
+func (q *Queue) Retry(ctx context.Context, executor SQLExecutor, job Job, runAt time.Time, jobErr error) error {
+	if job.Attempt >= job.MaxAttempts {
+		return q.Fail(ctx, executor, job, jobErr)
+	}
+	return execAndCheckClaim(ctx, executor, q.queries.retry, runAt.UnixMilli(), errorText(jobErr), job.ID, job.Attempt)
+}
+
+// Release returns the claimed job to the queue without counting the current attempt
+// (e.g., when processing is interrupted by the shutdown), so it may be claimed again right away.
+// ErrJobNotClaimed is returned if the job's claim is expired and it was claimed again.
+func (q *Queue) Release(ctx context.Context, executor SQLExecutor, job Job) error {
+	return execAndCheckClaim(ctx, executor, q.queries.release, job.ID, job.Attempt)
+}
+
+// Fail marks the job as failed (dead-lettered), so it isn't claimed anymore (see ListDead and Requeue).
+// The time of the failure is stored in the run_at column.
+// ErrJobNotClaimed is returned if the job's claim is expired and it was claimed again.
+func (q *Queue) Fail(ctx context.Context, executor SQLExecutor, job Job, jobErr error) error {
+	return execAndCheckClaim(ctx, executor, q.queries.fail, q.clock.Now().UnixMilli(), errorText(jobErr), job.ID, job.Attempt)
+}
+
+// ErrJobNotClaimed is returned when the job is not claimed by the caller anymore.
+var ErrJobNotClaimed = errors.New("job is not claimed")
+
+func execAndCheckClaim(ctx context.Context, executor SQLExecutor, query string, args ...interface{}) error {
+	result, err := executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrJobNotClaimed
+	}
+	return nil
+}
+
+const maxErrorTextLen = 4096
+
+const errExpiredLastAttempt = "claim of the last attempt expired"
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	text := err.Error()
+	if len(text) > maxErrorTextLen {
+		text = text[:maxErrorTextLen]
+	}
+	return text
+}
+
+type dbQueries struct {
+	createTable     string
+	createIndex     string
+	dropTable       string
+	enqueue         string
+	selectClaimable string
+	claim           string
+	complete        string
+	retry           string
+	release         string
+	fail            string
+	failExpired     string
+	countDead       string
+	listDead        string
+	requeue         string
+	purgeDead       string
+
+	addSchedulingColumns  []string
+	createFairnessIndex   string
+	dropSchedulingColumns []string
+	selectFairnessKeys    string
+	selectClaimableByKey  string
+}
+
+func newDBQueries(dialect dbkit.Dialect, tableName string, skipLockedEnabled bool) (dbQueries, error) {
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		return dbQueries{
+			createTable:     fmt.Sprintf(postgresCreateTableQuery, tableName),
+			createIndex:     fmt.Sprintf(postgresCreateIndexQuery, tableName),
+			dropTable:       fmt.Sprintf(postgresDropTableQuery, tableName),
+			enqueue:         fmt.Sprintf(postgresEnqueueQuery, tableName),
+			selectClaimable: fmt.Sprintf(postgresSelectClaimableQuery, tableName) + skipLockedClause(skipLockedEnabled),
+			claim:           fmt.Sprintf(postgresClaimQuery, tableName),
+			complete:        fmt.Sprintf(postgresCompleteQuery, tableName),
+			retry:           fmt.Sprintf(postgresRetryQuery, tableName),
+			release:         fmt.Sprintf(postgresReleaseQuery, tableName),
+			fail:            fmt.Sprintf(postgresFailQuery, tableName),
+			failExpired:     fmt.Sprintf(postgresFailExpiredQuery, tableName),
+			countDead:       fmt.Sprintf(postgresCountDeadQuery, tableName),
+			listDead:        fmt.Sprintf(postgresListDeadQuery, tableName),
+			requeue:         fmt.Sprintf(postgresRequeueQuery, tableName),
+			purgeDead:       fmt.Sprintf(postgresPurgeDeadQuery, tableName),
+
+			addSchedulingColumns:  formatQueries(postgresAddSchedulingColumnsQueries, tableName),
+			createFairnessIndex:   fmt.Sprintf(postgresCreateFairnessIndexQuery, tableName),
+			dropSchedulingColumns: formatQueries(postgresDropSchedulingColumnsQueries, tableName),
+			selectFairnessKeys:    fmt.Sprintf(postgresSelectFairnessKeysQuery, tableName),
+			selectClaimableByKey:  fmt.Sprintf(postgresSelectClaimableByKeyQuery, tableName) + skipLockedClause(skipLockedEnabled),
+		}, nil
+	case dbkit.DialectMySQL:
+		return dbQueries{
+			createTable:     fmt.Sprintf(mySQLCreateTableQuery, tableName),
+			createIndex:     fmt.Sprintf(mySQLCreateIndexQuery, tableName),
+			dropTable:       fmt.Sprintf(mySQLDropTableQuery, tableName),
+			enqueue:         fmt.Sprintf(mySQLEnqueueQuery, tableName),
+			selectClaimable: fmt.Sprintf(mySQLSelectClaimableQuery, tableName) + skipLockedClause(skipLockedEnabled),
+			claim:           fmt.Sprintf(mySQLClaimQuery, tableName),
+			complete:        fmt.Sprintf(mySQLCompleteQuery, tableName),
+			retry:           fmt.Sprintf(mySQLRetryQuery, tableName),
+			release:         fmt.Sprintf(mySQLReleaseQuery, tableName),
+			fail:            fmt.Sprintf(mySQLFailQuery, tableName),
+			failExpired:     fmt.Sprintf(mySQLFailExpiredQuery, tableName),
+			countDead:       fmt.Sprintf(mySQLCountDeadQuery, tableName),
+			listDead:        fmt.Sprintf(mySQLListDeadQuery, tableName),
+			requeue:         fmt.Sprintf(mySQLRequeueQuery, tableName),
+			purgeDead:       fmt.Sprintf(mySQLPurgeDeadQuery, tableName),
+
+			addSchedulingColumns:  formatQueries(mySQLAddSchedulingColumnsQueries, tableName),
+			createFairnessIndex:   fmt.Sprintf(mySQLCreateFairnessIndexQuery, tableName),
+			dropSchedulingColumns: formatQueries(mySQLDropSchedulingColumnsQueries, tableName),
+			selectFairnessKeys:    fmt.Sprintf(mySQLSelectFairnessKeysQuery, tableName),
+			selectClaimableByKey:  fmt.Sprintf(mySQLSelectClaimableByKeyQuery, tableName) + skipLockedClause(skipLockedEnabled),
+		}, nil
+	case dbkit.DialectSQLite:
+		return dbQueries{
+			createTable:     fmt.Sprintf(sqliteCreateTableQuery, tableName),
+			createIndex:     fmt.Sprintf(sqliteCreateIndexQuery, tableName),
+			dropTable:       fmt.Sprintf(sqliteDropTableQuery, tableName),
+			enqueue:         fmt.Sprintf(sqliteEnqueueQuery, tableName),
+			selectClaimable: fmt.Sprintf(sqliteSelectClaimableQuery, tableName),
+			claim:           fmt.Sprintf(sqliteClaimQuery, tableName),
+			complete:        fmt.Sprintf(sqliteCompleteQuery, tableName),
+			retry:           fmt.Sprintf(sqliteRetryQuery, tableName),
+			release:         fmt.Sprintf(sqliteReleaseQuery, tableName),
+			fail:            fmt.Sprintf(sqliteFailQuery, tableName),
+			failExpired:     fmt.Sprintf(sqliteFailExpiredQuery, tableName),
+			countDead:       fmt.Sprintf(sqliteCountDeadQuery, tableName),
+			listDead:        fmt.Sprintf(sqliteListDeadQuery, tableName),
+			requeue:         fmt.Sprintf(sqliteRequeueQuery, tableName),
+			purgeDead:       fmt.Sprintf(sqlitePurgeDeadQuery, tableName),
+
+			addSchedulingColumns:  formatQueries(sqliteAddSchedulingColumnsQueries, tableName),
+			createFairnessIndex:   fmt.Sprintf(sqliteCreateFairnessIndexQuery, tableName),
+			dropSchedulingColumns: formatQueries(sqliteDropSchedulingColumnsQueries, tableName),
+			selectFairnessKeys:    fmt.Sprintf(sqliteSelectFairnessKeysQuery, tableName),
+			selectClaimableByKey:  fmt.Sprintf(sqliteSelectClaimableByKeyQuery, tableName),
+		}, nil
+	case dbkit.DialectMSSQL:
+		return dbQueries{
+			createTable:     fmt.Sprintf(msSQLCreateTableQuery, tableName),
+			createIndex:     fmt.Sprintf(msSQLCreateIndexQuery, tableName),
+			dropTable:       fmt.Sprintf(msSQLDropTableQuery, tableName),
+			enqueue:         fmt.Sprintf(msSQLEnqueueQuery, tableName),
+			selectClaimable: fmt.Sprintf(msSQLSelectClaimableQuery, tableName),
+			claim:           fmt.Sprintf(msSQLClaimQuery, tableName),
+			complete:        fmt.Sprintf(msSQLCompleteQuery, tableName),
+			retry:           fmt.Sprintf(msSQLRetryQuery, tableName),
+			release:         fmt.Sprintf(msSQLReleaseQuery, tableName),
+			fail:            fmt.Sprintf(msSQLFailQuery, tableName),
+			failExpired:     fmt.Sprintf(msSQLFailExpiredQuery, tableName),
+			countDead:       fmt.Sprintf(msSQLCountDeadQuery, tableName),
+			listDead:        fmt.Sprintf(msSQLListDeadQuery, tableName),
+			requeue:         fmt.Sprintf(msSQLRequeueQuery, tableName),
+			purgeDead:       fmt.Sprintf(msSQLPurgeDeadQuery, tableName),
+
+			addSchedulingColumns:  formatQueries(msSQLAddSchedulingColumnsQueries, tableName),
+			createFairnessIndex:   fmt.Sprintf(msSQLCreateFairnessIndexQuery, tableName),
+			dropSchedulingColumns: formatQueries(msSQLDropSchedulingColumnsQueries, tableName),
+			selectFairnessKeys:    fmt.Sprintf(msSQLSelectFairnessKeysQuery, tableName),
+			selectClaimableByKey:  fmt.Sprintf(msSQLSelectClaimableByKeyQuery, tableName),
+		}, nil
+	default:
+		return dbQueries{}, fmt.Errorf("unsupported sql dialect %q", dialect)
+	}
+}
+
+func formatQueries(queries []string, tableName string) []string {
+	result := make([]string, 0, len(queries))
+	for _, query := range queries {
+		result = append(result, fmt.Sprintf(query, tableName))
+	}
+	return result
+}
+
+func skipLockedClause(enabled bool) string {
+	if !enabled {
+		return ";"
+	}
+	return " FOR UPDATE SKIP LOCKED;"
+}
+
+const (
+	createTableMigrationID          = "queue_00001_create_table"
+	addSchedulingColumnsMigrationID = "queue_00002_add_scheduling_columns"
+)
+
+//nolint:lll
+const (
+	postgresCreateTableQuery     = `CREATE TABLE IF NOT EXISTS "%s" (id BIGSERIAL PRIMARY KEY, queue varchar(64) NOT NULL, payload bytea, status varchar(16) NOT NULL, attempts integer NOT NULL DEFAULT 0, max_attempts integer NOT NULL, run_at bigint NOT NULL, locked_until bigint, last_error text, created_at bigint NOT NULL);`
+	postgresCreateIndexQuery     = `CREATE INDEX IF NOT EXISTS "%[1]s_queue_status_run_at_idx" ON "%[1]s" (queue, status, run_at);`
+	postgresDropTableQuery       = `DROP TABLE IF EXISTS "%s";`
+	postgresEnqueueQuery         = `INSERT INTO "%s" (queue, payload, status, max_attempts, run_at, created_at, priority, fairness_key) VALUES ($1, $2, 'pending', $3, $4, $5, $6, $7);`
+	postgresSelectClaimableQuery = `SELECT id, payload, attempts, max_attempts, priority, fairness_key FROM "%s" WHERE queue = $1 AND ((status = 'pending' AND run_at <= $2) OR (status = 'running' AND locked_until < $3 AND attempts < max_attempts)) ORDER BY priority DESC, run_at, id LIMIT $4`
+	postgresClaimQuery           = `UPDATE "%s" SET status = 'running', attempts = attempts + 1, locked_until = $1 WHERE id = $2 AND ((status = 'pending' AND run_at <= $3) OR (status = 'running' AND locked_until < $4 AND attempts < max_attempts));`
+	postgresCompleteQuery        = `DELETE FROM "%s" WHERE id = $1 AND status = 'running' AND attempts = $2;`
+	postgresRetryQuery           = `UPDATE "%s" SET status = 'pending', run_at = $1, locked_until = NULL, last_error = $2 WHERE id = $3 AND status = 'running' AND attempts = $4;`
+	postgresReleaseQuery         = `UPDATE "%s" SET status = 'pending', attempts = attempts - 1, locked_until = NULL WHERE id = $1 AND status = 'running' AND attempts = $2;`
+	postgresFailQuery            = `UPDATE "%s" SET status = 'failed', run_at = $1, locked_until = NULL, last_error = $2 WHERE id = $3 AND status = 'running' AND attempts = $4;`
+	postgresFailExpiredQuery     = `UPDATE "%s" SET status = 'failed', run_at = $1, locked_until = NULL, last_error = $2 WHERE queue = $3 AND status = 'running' AND locked_until < $4 AND attempts >= max_attempts;`
+	postgresCountDeadQuery       = `SELECT COUNT(*) FROM "%s" WHERE queue = $1 AND status = 'failed';`
+	postgresListDeadQuery        = `SELECT id, payload, attempts, max_attempts, last_error, created_at, run_at FROM "%s" WHERE queue = $1 AND status = 'failed' AND id > $2 ORDER BY id LIMIT $3;`
+	postgresRequeueQuery         = `UPDATE "%s" SET status = 'pending', attempts = 0, run_at = $1, locked_until = NULL WHERE id = $2 AND queue = $3 AND status = 'failed';`
+	postgresPurgeDeadQuery       = `DELETE FROM "%s" WHERE queue = $1 AND status = 'failed' AND run_at < $2;`
+)
+
+//nolint:lll
+const (
+	mySQLCreateTableQuery     = "CREATE TABLE IF NOT EXISTS `%s` (id BIGINT AUTO_INCREMENT PRIMARY KEY, queue VARCHAR(64) NOT NULL, payload LONGBLOB, status VARCHAR(16) NOT NULL, attempts INT NOT NULL DEFAULT 0, max_attempts INT NOT NULL, run_at BIGINT NOT NULL, locked_until BIGINT, last_error TEXT, created_at BIGINT NOT NULL);"
+	mySQLCreateIndexQuery     = "CREATE INDEX `%[1]s_queue_status_run_at_idx` ON `%[1]s` (queue, status, run_at);"
+	mySQLDropTableQuery       = "DROP TABLE IF EXISTS `%s`;"
+	mySQLEnqueueQuery         = "INSERT INTO `%s` (queue, payload, status, max_attempts, run_at, created_at, priority, fairness_key) VALUES (?, ?, 'pending', ?, ?, ?, ?, ?);"
+	mySQLSelectClaimableQuery = "SELECT id, payload, attempts, max_attempts, priority, fairness_key FROM `%s` WHERE queue = ? AND ((status = 'pending' AND run_at <= ?) OR (status = 'running' AND locked_until < ? AND attempts < max_attempts)) ORDER BY priority DESC, run_at, id LIMIT ?"
+	mySQLClaimQuery           = "UPDATE `%s` SET status = 'running', attempts = attempts + 1, locked_until = ? WHERE id = ? AND ((status = 'pending' AND run_at <= ?) OR (status = 'running' AND locked_until < ? AND attempts < max_attempts));"
+	mySQLCompleteQuery        = "DELETE FROM `%s` WHERE id = ? AND status = 'running' AND attempts = ?;"
+	mySQLRetryQuery           = "UPDATE `%s` SET status = 'pending', run_at = ?, locked_until = NULL, last_error = ? WHERE id = ? AND status = 'running' AND attempts = ?;"
+	mySQLReleaseQuery         = "UPDATE `%s` SET status = 'pending', attempts = attempts - 1, locked_until = NULL WHERE id = ? AND status = 'running' AND attempts = ?;"
+	mySQLFailQuery            = "UPDATE `%s` SET status = 'failed', run_at = ?, locked_until = NULL, last_error = ? WHERE id = ? AND status = 'running' AND attempts = ?;"
+	mySQLFailExpiredQuery     = "UPDATE `%s` SET status = 'failed', run_at = ?, locked_until = NULL, last_error = ? WHERE queue = ? AND status = 'running' AND locked_until < ? AND attempts >= max_attempts;"
+	mySQLCountDeadQuery       = "SELECT COUNT(*) FROM `%s` WHERE queue = ? AND status = 'failed';"
+	mySQLListDeadQuery        = "SELECT id, payload, attempts, max_attempts, last_error, created_at, run_at FROM `%s` WHERE queue = ? AND status = 'failed' AND id > ? ORDER BY id LIMIT ?;"
+	mySQLRequeueQuery         = "UPDATE `%s` SET status = 'pending', attempts = 0, run_at = ?, locked_until = NULL WHERE id = ? AND queue = ? AND status = 'failed';"
+	mySQLPurgeDeadQuery       = "DELETE FROM `%s` WHERE queue = ? AND status = 'failed' AND run_at < ?;"
+)
+
+//nolint:lll
+const (
+	sqliteCreateTableQuery     = `CREATE TABLE IF NOT EXISTS "%s" (id INTEGER PRIMARY KEY AUTOINCREMENT, queue TEXT NOT NULL, payload BLOB, status TEXT NOT NULL, attempts INTEGER NOT NULL DEFAULT 0, max_attempts INTEGER NOT NULL, run_at INTEGER NOT NULL, locked_until INTEGER, last_error TEXT, created_at INTEGER NOT NULL);`
+	sqliteCreateIndexQuery     = `CREATE INDEX IF NOT EXISTS "%[1]s_queue_status_run_at_idx" ON "%[1]s" (queue, status, run_at);`
+	sqliteDropTableQuery       = `DROP TABLE IF EXISTS "%s";`
+	sqliteEnqueueQuery         = `INSERT INTO "%s" (queue, payload, status, max_attempts, run_at, created_at, priority, fairness_key) VALUES (?, ?, 'pending', ?, ?, ?, ?, ?);`
+	sqliteSelectClaimableQuery = `SELECT id, payload, attempts, max_attempts, priority, fairness_key FROM "%s" WHERE queue = ? AND ((status = 'pending' AND run_at <= ?) OR (status = 'running' AND locked_until < ? AND attempts < max_attempts)) ORDER BY priority DESC, run_at, id LIMIT ?;`
+	sqliteClaimQuery           = `UPDATE "%s" SET status = 'running', attempts = attempts + 1, locked_until = ? WHERE id = ? AND ((status = 'pending' AND run_at <= ?) OR (status = 'running' AND locked_until < ? AND attempts < max_attempts));`
+	sqliteCompleteQuery        = `DELETE FROM "%s" WHERE id = ? AND status = 'running' AND attempts = ?;`
+	sqliteRetryQuery           = `UPDATE "%s" SET status = 'pending', run_at = ?, locked_until = NULL, last_error = ? WHERE id = ? AND status = 'running' AND attempts = ?;`
+	sqliteReleaseQuery         = `UPDATE "%s" SET status = 'pending', attempts = attempts - 1, locked_until = NULL WHERE id = ? AND status = 'running' AND attempts = ?;`
+	sqliteFailQuery            = `UPDATE "%s" SET status = 'failed', run_at = ?, locked_until = NULL, last_error = ? WHERE id = ? AND status = 'running' AND attempts = ?;`
+	sqliteFailExpiredQuery     = `UPDATE "%s" SET status = 'failed', run_at = ?, locked_until = NULL, last_error = ? WHERE queue = ? AND status = 'running' AND locked_until < ? AND attempts >= max_attempts;`
+	sqliteCountDeadQuery       = `SELECT COUNT(*) FROM "%s" WHERE queue = ? AND status = 'failed';`
+	sqliteListDeadQuery        = `SELECT id, payload, attempts, max_attempts, last_error, created_at, run_at FROM "%s" WHERE queue = ? AND status = 'failed' AND id > ? ORDER BY id LIMIT ?;`
+	sqliteRequeueQuery         = `UPDATE "%s" SET status = 'pending', attempts = 0, run_at = ?, locked_until = NULL WHERE id = ? AND queue = ? AND status = 'failed';`
+	sqlitePurgeDeadQuery       = `DELETE FROM "%s" WHERE queue = ? AND status = 'failed' AND run_at < ?;`
+)
+
+//nolint:lll
+const (
+	msSQLCreateTableQuery     = `IF OBJECT_ID(N'%[1]s', N'U') IS NULL CREATE TABLE [%[1]s] (id BIGINT IDENTITY(1,1) PRIMARY KEY, queue NVARCHAR(64) NOT NULL, payload VARBINARY(MAX), status NVARCHAR(16) NOT NULL, attempts INT NOT NULL DEFAULT 0, max_attempts INT NOT NULL, run_at BIGINT NOT NULL, locked_until BIGINT, last_error NVARCHAR(MAX), created_at BIGINT NOT NULL);`
+	msSQLCreateIndexQuery     = `CREATE INDEX [%[1]s_queue_status_run_at_idx] ON [%[1]s] (queue, status, run_at);`
+	msSQLDropTableQuery       = `DROP TABLE IF EXISTS [%s];`
+	msSQLEnqueueQuery         = `INSERT INTO [%s] (queue, payload, status, max_attempts, run_at, created_at, priority, fairness_key) VALUES (@p1, @p2, 'pending', @p3, @p4, @p5, @p6, @p7);`
+	msSQLSelectClaimableQuery = `SELECT TOP (@p4) id, payload, attempts, max_attempts, priority, fairness_key FROM [%s] WHERE queue = @p1 AND ((status = 'pending' AND run_at <= @p2) OR (status = 'running' AND locked_until < @p3 AND attempts < max_attempts)) ORDER BY priority DESC, run_at, id;`
+	msSQLClaimQuery           = `UPDATE [%s] SET status = 'running', attempts = attempts + 1, locked_until = @p1 WHERE id = @p2 AND ((status = 'pending' AND run_at <= @p3) OR (status = 'running' AND locked_until < @p4 AND attempts < max_attempts));`
+	msSQLCompleteQuery        = `DELETE FROM [%s] WHERE id = @p1 AND status = 'running' AND attempts = @p2;`
+	msSQLRetryQuery           = `UPDATE [%s] SET status = 'pending', run_at = @p1, locked_until = NULL, last_error = @p2 WHERE id = @p3 AND status = 'running' AND attempts = @p4;`
+	msSQLReleaseQuery         = `UPDATE [%s] SET status = 'pending', attempts = attempts - 1, locked_until = NULL WHERE id = @p1 AND status = 'running' AND attempts = @p2;`
+	msSQLFailQuery            = `UPDATE [%s] SET status = 'failed', run_at = @p1, locked_until = NULL, last_error = @p2 WHERE id = @p3 AND status = 'running' AND attempts = @p4;`
+	msSQLFailExpiredQuery     = `UPDATE [%s] SET status = 'failed', run_at = @p1, locked_until = NULL, last_error = @p2 WHERE queue = @p3 AND status = 'running' AND locked_until < @p4 AND attempts >= max_attempts;`
+	msSQLCountDeadQuery       = `SELECT COUNT(*) FROM [%s] WHERE queue = @p1 AND status = 'failed';`
+	msSQLListDeadQuery        = `SELECT TOP (@p3) id, payload, attempts, max_attempts, last_error, created_at, run_at FROM [%s] WHERE queue = @p1 AND status = 'failed' AND id > @p2 ORDER BY id;`
+	msSQLRequeueQuery         = `UPDATE [%s] SET status = 'pending', attempts = 0, run_at = @p1, locked_until = NULL WHERE id = @p2 AND queue = @p3 AND status = 'failed';`
+	msSQLPurgeDeadQuery       = `DELETE FROM [%s] WHERE queue = @p1 AND status = 'failed' AND run_at < @p2;`
+)
+
+//nolint:lll
+var (
+	postgresAddSchedulingColumnsQueries  = []string{`ALTER TABLE "%s" ADD COLUMN priority integer NOT NULL DEFAULT 0, ADD COLUMN fairness_key varchar(255) NOT NULL DEFAULT '';`}
+	postgresDropSchedulingColumnsQueries = []string{`DROP INDEX IF EXISTS "%s_queue_status_fairness_key_idx";`, `ALTER TABLE "%s" DROP COLUMN fairness_key, DROP COLUMN priority;`}
+
+	mySQLAddSchedulingColumnsQueries  = []string{"ALTER TABLE `%s` ADD COLUMN priority INT NOT NULL DEFAULT 0, ADD COLUMN fairness_key VARCHAR(255) NOT NULL DEFAULT '';"}
+	mySQLDropSchedulingColumnsQueries = []string{"DROP INDEX `%[1]s_queue_status_fairness_key_idx` ON `%[1]s`;", "ALTER TABLE `%s` DROP COLUMN fairness_key, DROP COLUMN priority;"}
+
+	sqliteAddSchedulingColumnsQueries  = []string{`ALTER TABLE "%s" ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;`, `ALTER TABLE "%s" ADD COLUMN fairness_key TEXT NOT NULL DEFAULT '';`}
+	sqliteDropSchedulingColumnsQueries = []string{`DROP INDEX IF EXISTS "%s_queue_status_fairness_key_idx";`, `ALTER TABLE "%s" DROP COLUMN fairness_key;`, `ALTER TABLE "%s" DROP COLUMN priority;`}
+
+	msSQLAddSchedulingColumnsQueries  = []string{`ALTER TABLE [%[1]s] ADD priority INT NOT NULL CONSTRAINT [%[1]s_priority_df] DEFAULT 0, fairness_key NVARCHAR(255) NOT NULL CONSTRAINT [%[1]s_fairness_key_df] DEFAULT '';`}
+	msSQLDropSchedulingColumnsQueries = []string{`DROP INDEX [%[1]s_queue_status_fairness_key_idx] ON [%[1]s];`, `ALTER TABLE [%[1]s] DROP CONSTRAINT [%[1]s_priority_df], [%[1]s_fairness_key_df];`, `ALTER TABLE [%s] DROP COLUMN priority, fairness_key;`}
+)
+
+//nolint:lll
+const (
+	postgresCreateFairnessIndexQuery  = `CREATE INDEX IF NOT EXISTS "%[1]s_queue_status_fairness_key_idx" ON "%[1]s" (queue, status, fairness_key, priority, run_at);`
+	postgresSelectFairnessKeysQuery   = `SELECT fairness_key FROM "%s" WHERE queue = $1 AND ((status = 'pending' AND run_at <= $2) OR (status = 'running' AND locked_until < $3 AND attempts < max_attempts)) GROUP BY fairness_key ORDER BY MAX(priority) DESC, MIN(run_at) LIMIT $4;`
+	postgresSelectClaimableByKeyQuery = `SELECT id, payload, attempts, max_attempts, priority, fairness_key FROM "%s" WHERE queue = $1 AND fairness_key = $2 AND ((status = 'pending' AND run_at <= $3) OR (status = 'running' AND locked_until < $4 AND attempts < max_attempts)) ORDER BY priority DESC, run_at, id LIMIT $5`
+	mySQLCreateFairnessIndexQuery     = "CREATE INDEX `%[1]s_queue_status_fairness_key_idx` ON `%[1]s` (queue, status, fairness_key, priority, run_at);"
+	mySQLSelectFairnessKeysQuery      = "SELECT fairness_key FROM `%s` WHERE queue = ? AND ((status = 'pending' AND run_at <= ?) OR (status = 'running' AND locked_until < ? AND attempts < max_attempts)) GROUP BY fairness_key ORDER BY MAX(priority) DESC, MIN(run_at) LIMIT ?;"
+	mySQLSelectClaimableByKeyQuery    = "SELECT id, payload, attempts, max_attempts, priority, fairness_key FROM `%s` WHERE queue = ? AND fairness_key = ? AND ((status = 'pending' AND run_at <= ?) OR (status = 'running' AND locked_until < ? AND attempts < max_attempts)) ORDER BY priority DESC, run_at, id LIMIT ?"
+	sqliteCreateFairnessIndexQuery    = `CREATE INDEX IF NOT EXISTS "%[1]s_queue_status_fairness_key_idx" ON "%[1]s" (queue, status, fairness_key, priority, run_at);`
+	sqliteSelectFairnessKeysQuery     = `SELECT fairness_key FROM "%s" WHERE queue = ? AND ((status = 'pending' AND run_at <= ?) OR (status = 'running' AND locked_until < ? AND attempts < max_attempts)) GROUP BY fairness_key ORDER BY MAX(priority) DESC, MIN(run_at) LIMIT ?;`
+	sqliteSelectClaimableByKeyQuery   = `SELECT id, payload, attempts, max_attempts, priority, fairness_key FROM "%s" WHERE queue = ? AND fairness_key = ? AND ((status = 'pending' AND run_at <= ?) OR (status = 'running' AND locked_until < ? AND attempts < max_attempts)) ORDER BY priority DESC, run_at, id LIMIT ?;`
+	msSQLCreateFairnessIndexQuery     = `CREATE INDEX [%[1]s_queue_status_fairness_key_idx] ON [%[1]s] (queue, status, fairness_key, priority, run_at);`
+	msSQLSelectFairnessKeysQuery      = `SELECT TOP (@p4) fairness_key FROM [%s] WHERE queue = @p1 AND ((status = 'pending' AND run_at <= @p2) OR (status = 'running' AND locked_until < @p3 AND attempts < max_attempts)) GROUP BY fairness_key ORDER BY MAX(priority) DESC, MIN(run_at);`
+	msSQLSelectClaimableByKeyQuery    = `SELECT TOP (@p5) id, payload, attempts, max_attempts, priority, fairness_key FROM [%s] WHERE queue = @p1 AND fairness_key = @p2 AND ((status = 'pending' AND run_at <= @p3) OR (status = 'running' AND locked_until < @p4 AND attempts < max_attempts)) ORDER BY priority DESC, run_at, id;`
+)
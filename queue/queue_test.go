@@ -0,0 +1,215 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/log/logtest"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+	"github.com/acronis/go-dbkit/testkit"
+)
+
+func openTestDB(t *testing.T, q *Queue) *sql.DB {
+	t.Helper()
+	dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "queue.db")+"?_journal=MEMORY&_sync=OFF")
+	require.NoError(t, err)
+	dbConn.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	migMngr, err := migrate.NewMigrationsManager(dbConn, dbkit.DialectSQLite, logtest.NewLogger())
+	require.NoError(t, err)
+	require.NoError(t, migMngr.Run(q.Migrations(), migrate.MigrationsDirectionUp))
+	return dbConn
+}
+
+func TestNewQueue(t *testing.T) {
+	q, err := NewQueue(dbkit.DialectPostgres, "emails")
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(q.queries.selectClaimable, "FOR UPDATE SKIP LOCKED;"))
+
+	q, err = NewQueue(dbkit.DialectMySQL, "emails", WithoutSkipLocked(), WithTableName("jobs"))
+	require.NoError(t, err)
+	require.NotContains(t, q.queries.selectClaimable, "SKIP LOCKED")
+	require.Contains(t, q.CreateTableSQL(), "`jobs`")
+
+	q, err = NewQueue(dbkit.DialectSQLite, "emails")
+	require.NoError(t, err)
+	require.NotContains(t, q.queries.selectClaimable, "SKIP LOCKED")
+
+	_, err = NewQueue(dbkit.DialectSQLite, "")
+	require.Error(t, err)
+	_, err = NewQueue(dbkit.Dialect("unknown"), "emails")
+	require.Error(t, err)
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	newQueue := func(t *testing.T) (*Queue, *sql.DB) {
+		q, err := NewQueue(dbkit.DialectSQLite, "emails", WithClock(testkit.NewFakeClock(now)))
+		require.NoError(t, err)
+		return q, openTestDB(t, q)
+	}
+
+	t.Run("enqueue in tx", func(t *testing.T) {
+		q, dbConn := newQueue(t)
+
+		rollbackErr := errors.New("rollback")
+		require.ErrorIs(t, dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+			require.NoError(t, q.Enqueue(ctx, tx, []byte("rolled back")))
+			return rollbackErr
+		}), rollbackErr)
+		jobs, err := q.Claim(ctx, dbConn, 10, time.Minute)
+		require.NoError(t, err)
+		require.Empty(t, jobs)
+
+		require.NoError(t, dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+			return q.Enqueue(ctx, tx, []byte("committed"))
+		}))
+		jobs, err = q.Claim(ctx, dbConn, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+		require.Equal(t, "committed", string(jobs[0].Payload))
+		require.Equal(t, "emails", jobs[0].Queue)
+		require.Equal(t, 1, jobs[0].Attempt)
+		require.Equal(t, DefaultMaxAttempts, jobs[0].MaxAttempts)
+	})
+
+	t.Run("delayed job", func(t *testing.T) {
+		q, dbConn := newQueue(t)
+		require.NoError(t, q.Enqueue(ctx, dbConn, []byte("delayed"), WithDelay(time.Minute)))
+
+		jobs, err := q.Claim(ctx, dbConn, 10, time.Minute)
+		require.NoError(t, err)
+		require.Empty(t, jobs)
+
+		q.clock = testkit.NewFakeClock(now.Add(time.Minute))
+		jobs, err = q.Claim(ctx, dbConn, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+	})
+
+	t.Run("expired claim", func(t *testing.T) {
+		q, dbConn := newQueue(t)
+		require.NoError(t, q.Enqueue(ctx, dbConn, []byte("job")))
+
+		jobs, err := q.Claim(ctx, dbConn, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+		staleJob := jobs[0]
+
+		jobs, err = q.Claim(ctx, dbConn, 10, time.Minute)
+		require.NoError(t, err)
+		require.Empty(t, jobs, "claimed job must not be claimed again until the claim expires")
+
+		q.clock = testkit.NewFakeClock(now.Add(2 * time.Minute))
+		jobs, err = q.Claim(ctx, dbConn, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+		require.Equal(t, 2, jobs[0].Attempt)
+
+		require.ErrorIs(t, q.Complete(ctx, dbConn, staleJob), ErrJobNotClaimed)
+		require.NoError(t, q.Complete(ctx, dbConn, jobs[0]))
+	})
+
+	t.Run("complete batch", func(t *testing.T) {
+		q, dbConn := newQueue(t)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, q.Enqueue(ctx, dbConn, []byte("job")))
+		}
+		jobs, err := q.Claim(ctx, dbConn, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, jobs, 3)
+
+		staleJob := jobs[2]
+		staleJob.Attempt = 0
+		completed, err := q.CompleteBatch(ctx, dbConn, []Job{jobs[0], jobs[1], staleJob})
+		require.NoError(t, err)
+		require.Equal(t, 2, completed, "job that is not claimed must be skipped")
+
+		var remaining int
+		require.NoError(t, dbConn.QueryRow(`SELECT COUNT(*) FROM queue_jobs`).Scan(&remaining))
+		require.Equal(t, 1, remaining)
+	})
+
+	t.Run("retry and fail", func(t *testing.T) {
+		q, dbConn := newQueue(t)
+		require.NoError(t, q.Enqueue(ctx, dbConn, []byte("job"), WithMaxAttempts(2)))
+
+		jobs, err := q.Claim(ctx, dbConn, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+		require.NoError(t, q.Retry(ctx, dbConn, jobs[0], now.Add(time.Second), errors.New("temporary error")))
+
+		jobs, err = q.Claim(ctx, dbConn, 10, time.Minute)
+		require.NoError(t, err)
+		require.Empty(t, jobs, "job must not be retried before backoff")
+
+		q.clock = testkit.NewFakeClock(now.Add(time.Second))
+		jobs, err = q.Claim(ctx, dbConn, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+		require.Equal(t, 2, jobs[0].Attempt)
+		require.NoError(t, q.Retry(ctx, dbConn, jobs[0], now.Add(time.Second), errors.New("temporary error")))
+
+		q.clock = testkit.NewFakeClock(now.Add(time.Hour))
+		jobs, err = q.Claim(ctx, dbConn, 10, time.Minute)
+		require.NoError(t, err)
+		require.Empty(t, jobs, "job with exhausted attempts must not be claimed")
+
+		var status, lastError string
+		require.NoError(t, dbConn.QueryRow(`SELECT status, last_error FROM queue_jobs`).Scan(&status, &lastError))
+		require.Equal(t, "failed", status)
+		require.Equal(t, "temporary error", lastError)
+	})
+
+	t.Run("expired claim of the last attempt", func(t *testing.T) {
+		q, dbConn := newQueue(t)
+		require.NoError(t, q.Enqueue(ctx, dbConn, []byte("crashing job"), WithMaxAttempts(2)))
+
+		for attempt := 1; attempt <= 2; attempt++ {
+			q.clock = testkit.NewFakeClock(now.Add(time.Duration(attempt-1) * 2 * time.Minute))
+			jobs, err := q.Claim(ctx, dbConn, 10, time.Minute)
+			require.NoError(t, err)
+			require.Len(t, jobs, 1)
+			require.Equal(t, attempt, jobs[0].Attempt)
+		}
+
+		q.clock = testkit.NewFakeClock(now.Add(4 * time.Minute))
+		jobs, err := q.Claim(ctx, dbConn, 10, time.Minute)
+		require.NoError(t, err)
+		require.Empty(t, jobs, "job with expired claim of the last attempt must not be claimed again")
+
+		deadJobs, err := q.ListDead(ctx, dbConn, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, deadJobs, 1)
+		require.Equal(t, "crashing job", string(deadJobs[0].Payload))
+		require.Equal(t, 2, deadJobs[0].Attempts)
+		require.Equal(t, errExpiredLastAttempt, deadJobs[0].LastError)
+	})
+
+	t.Run("queues are isolated", func(t *testing.T) {
+		q, dbConn := newQueue(t)
+		otherQueue, err := NewQueue(dbkit.DialectSQLite, "reports")
+		require.NoError(t, err)
+		require.NoError(t, otherQueue.Enqueue(ctx, dbConn, []byte("report")))
+
+		jobs, err := q.Claim(ctx, dbConn, 10, time.Minute)
+		require.NoError(t, err)
+		require.Empty(t, jobs)
+	})
+}
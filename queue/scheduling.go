@@ -0,0 +1,140 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const maxFairnessKeyLen = 255
+
+// WithPriority sets the priority of the job. Jobs with higher priority are claimed first, default priority is 0.
+func WithPriority(priority int) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.priority = priority
+	}
+}
+
+// WithFairnessKey sets the key (e.g., tenant ID) that is used for fair scheduling (see WithFairScheduling).
+// Jobs without the key share the same empty key.
+func WithFairnessKey(key string) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.fairnessKey = key
+	}
+}
+
+// WithFairScheduling enables fair scheduling, so one noisy tenant cannot starve others sharing the same queue.
+// Instead of claiming the oldest ready jobs, each Claim call splits the limit between fairness keys
+// (see WithFairnessKey) that have ready jobs proportionally to their weights.
+// The capacity left by keys that have fewer ready jobs than their share is distributed among the others.
+// Keys are served in the order of the highest priority and then the oldest ready job,
+// and jobs of the same key are claimed in the order of priority.
+// If weight is nil or returns a non-positive value, the weight of the key is 1.
+func WithFairScheduling(weight func(fairnessKey string) int) QueueOption {
+	return func(o *queueOptions) {
+		o.fair = true
+		o.fairWeight = weight
+	}
+}
+
+func (q *Queue) selectFairClaimable(ctx context.Context, tx *sql.Tx, now time.Time, limit int) ([]Job, error) {
+	// Each key gets at least one job, so there is no need to consider more keys than the limit.
+	keys, err := q.selectFairnessKeys(ctx, tx, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	quotas := q.fairQuotas(keys, limit)
+
+	var jobs []Job
+	var hungryKeys []int // Indexes of keys that may have more ready jobs than their quotas.
+	for i, key := range keys {
+		if quotas[i] > limit-len(jobs) {
+			quotas[i] = limit - len(jobs)
+		}
+		if quotas[i] == 0 {
+			break
+		}
+		keyJobs, err := q.selectClaimableByKey(ctx, tx, key, now, quotas[i])
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, keyJobs...)
+		if len(keyJobs) == quotas[i] {
+			hungryKeys = append(hungryKeys, i)
+		}
+	}
+
+	for _, i := range hungryKeys {
+		remaining := limit - len(jobs)
+		if remaining == 0 {
+			break
+		}
+		// Jobs of the key are selected in the same order, so the first quota jobs are already taken.
+		keyJobs, err := q.selectClaimableByKey(ctx, tx, keys[i], now, quotas[i]+remaining)
+		if err != nil {
+			return nil, err
+		}
+		if len(keyJobs) > quotas[i] {
+			jobs = append(jobs, keyJobs[quotas[i]:]...)
+		}
+	}
+	return jobs, nil
+}
+
+func (q *Queue) fairQuotas(keys []string, limit int) []int {
+	weights := make([]int, len(keys))
+	totalWeight := 0
+	for i, key := range keys {
+		weights[i] = 1
+		if q.fairWeight != nil {
+			if w := q.fairWeight(key); w > 0 {
+				weights[i] = w
+			}
+		}
+		totalWeight += weights[i]
+	}
+	quotas := make([]int, len(keys))
+	for i := range keys {
+		quotas[i] = limit * weights[i] / totalWeight
+		if quotas[i] < 1 {
+			quotas[i] = 1
+		}
+	}
+	return quotas
+}
+
+func (q *Queue) selectFairnessKeys(ctx context.Context, tx *sql.Tx, now time.Time, limit int) (keys []string, err error) {
+	rows, err := tx.QueryContext(ctx, q.queries.selectFairnessKeys, q.name, now.UnixMilli(), now.UnixMilli(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("select fairness keys: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	for rows.Next() {
+		var key string
+		if err = rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("scan fairness key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (q *Queue) selectClaimableByKey(ctx context.Context, tx *sql.Tx, key string, now time.Time, limit int) ([]Job, error) {
+	rows, err := tx.QueryContext(ctx, q.queries.selectClaimableByKey,
+		q.name, key, now.UnixMilli(), now.UnixMilli(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("select claimable jobs with fairness key %q: %w", key, err)
+	}
+	return q.scanClaimable(rows)
+}
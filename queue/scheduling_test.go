@@ -0,0 +1,123 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/log/logtest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+func TestQueue_Priority(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewQueue(dbkit.DialectSQLite, "emails")
+	require.NoError(t, err)
+	dbConn := openTestDB(t, q)
+
+	require.NoError(t, q.Enqueue(ctx, dbConn, []byte("low"), WithPriority(-1)))
+	require.NoError(t, q.Enqueue(ctx, dbConn, []byte("default")))
+	require.NoError(t, q.Enqueue(ctx, dbConn, []byte("high"), WithPriority(10)))
+
+	jobs, err := q.Claim(ctx, dbConn, 2, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	require.Equal(t, "high", string(jobs[0].Payload))
+	require.Equal(t, 10, jobs[0].Priority)
+	require.Equal(t, "default", string(jobs[1].Payload))
+
+	jobs, err = q.Claim(ctx, dbConn, 2, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.Equal(t, "low", string(jobs[0].Payload))
+}
+
+func TestQueue_FairScheduling(t *testing.T) {
+	ctx := context.Background()
+
+	countByKey := func(jobs []Job) map[string]int {
+		counts := make(map[string]int)
+		for _, job := range jobs {
+			counts[job.FairnessKey]++
+		}
+		return counts
+	}
+
+	t.Run("noisy tenant doesn't starve others", func(t *testing.T) {
+		q, err := NewQueue(dbkit.DialectSQLite, "emails", WithFairScheduling(nil))
+		require.NoError(t, err)
+		dbConn := openTestDB(t, q)
+
+		for i := 0; i < 20; i++ {
+			require.NoError(t, q.Enqueue(ctx, dbConn, []byte("noisy"), WithFairnessKey("noisy")))
+		}
+		require.NoError(t, q.Enqueue(ctx, dbConn, []byte("quiet-1"), WithFairnessKey("quiet-1")))
+		for i := 0; i < 5; i++ {
+			require.NoError(t, q.Enqueue(ctx, dbConn, []byte("quiet-2"), WithFairnessKey("quiet-2")))
+		}
+
+		jobs, err := q.Claim(ctx, dbConn, 6, time.Minute)
+		require.NoError(t, err)
+		// quiet-1 has only one job, so the rest of its share is distributed among the others.
+		require.Equal(t, map[string]int{"noisy": 3, "quiet-1": 1, "quiet-2": 2}, countByKey(jobs))
+
+		jobs, err = q.Claim(ctx, dbConn, 6, time.Minute)
+		require.NoError(t, err)
+		require.Equal(t, map[string]int{"noisy": 3, "quiet-2": 3}, countByKey(jobs))
+	})
+
+	t.Run("weighted", func(t *testing.T) {
+		weights := map[string]int{"premium": 3}
+		q, err := NewQueue(dbkit.DialectSQLite, "emails", WithFairScheduling(func(key string) int { return weights[key] }))
+		require.NoError(t, err)
+		dbConn := openTestDB(t, q)
+
+		for _, key := range []string{"premium", "basic"} {
+			for i := 0; i < 10; i++ {
+				require.NoError(t, q.Enqueue(ctx, dbConn, []byte(key), WithFairnessKey(key)))
+			}
+		}
+
+		jobs, err := q.Claim(ctx, dbConn, 8, time.Minute)
+		require.NoError(t, err)
+		require.Equal(t, map[string]int{"premium": 6, "basic": 2}, countByKey(jobs))
+	})
+
+	t.Run("priority within key", func(t *testing.T) {
+		q, err := NewQueue(dbkit.DialectSQLite, "emails", WithFairScheduling(nil))
+		require.NoError(t, err)
+		dbConn := openTestDB(t, q)
+
+		require.NoError(t, q.Enqueue(ctx, dbConn, []byte("low"), WithFairnessKey("tenant")))
+		require.NoError(t, q.Enqueue(ctx, dbConn, []byte("high"), WithFairnessKey("tenant"), WithPriority(1)))
+
+		jobs, err := q.Claim(ctx, dbConn, 1, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+		require.Equal(t, "high", string(jobs[0].Payload))
+	})
+}
+
+func TestQueue_SchedulingColumnsMigration(t *testing.T) {
+	q, err := NewQueue(dbkit.DialectSQLite, "emails")
+	require.NoError(t, err)
+	dbConn := openTestDB(t, q)
+
+	migMngr, err := migrate.NewMigrationsManager(dbConn, dbkit.DialectSQLite, logtest.NewLogger())
+	require.NoError(t, err)
+	require.NoError(t, migMngr.RunLimit(q.Migrations(), migrate.MigrationsDirectionDown, 1))
+	_, err = dbConn.Exec(`SELECT priority FROM queue_jobs`)
+	require.Error(t, err)
+
+	require.NoError(t, migMngr.Run(q.Migrations(), migrate.MigrationsDirectionUp))
+	require.NoError(t, q.Enqueue(context.Background(), dbConn, []byte("job"), WithPriority(1), WithFairnessKey("tenant")))
+}
@@ -0,0 +1,365 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// Default values for Worker options.
+const (
+	DefaultConcurrency   = 1
+	DefaultPollInterval  = time.Second
+	DefaultLockTimeout   = 5 * time.Minute
+	DefaultFinishTimeout = 5 * time.Second
+	DefaultMinRetryDelay = time.Second
+	DefaultMaxRetryDelay = time.Hour
+
+	DefaultBatchAckFlushInterval = 100 * time.Millisecond
+)
+
+// Handler processes the job. If it returns an error, the job is retried with backoff
+// until the maximum number of attempts is reached.
+// Passed context is canceled when the job's claim expires (see WithLockTimeout) or the worker stops.
+type Handler func(ctx context.Context, job Job) error
+
+// BackoffFunc returns the delay before the next attempt after the given (failed) attempt.
+type BackoffFunc func(attempt int) time.Duration
+
+// ExponentialBackoff returns BackoffFunc that doubles the delay after each attempt starting from minDelay,
+// and caps it by maxDelay.
+func ExponentialBackoff(minDelay, maxDelay time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		delay := minDelay
+		for i := 1; i < attempt && delay < maxDelay; i++ {
+			delay *= 2
+		}
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+		return delay
+	}
+}
+
+// Logger is an interface for logging errors.
+type Logger interface {
+	Errorf(format string, args ...interface{})
+}
+
+// Worker is a pool of goroutines that claims jobs from the queue and processes them with the handler.
+type Worker struct {
+	dbConn  *sql.DB
+	queue   *Queue
+	handler Handler
+	opts    workerOptions
+}
+
+type workerOptions struct {
+	concurrency   int
+	pollInterval  time.Duration
+	lockTimeout   time.Duration
+	finishTimeout time.Duration
+	backoff       BackoffFunc
+	logger        Logger
+
+	prefetch              int
+	batchAckSize          int
+	batchAckFlushInterval time.Duration
+}
+
+// WorkerOption is an option for Worker.
+type WorkerOption func(*workerOptions)
+
+// WithConcurrency sets the maximum number of jobs processed concurrently. By default, it's 1.
+func WithConcurrency(concurrency int) WorkerOption {
+	return func(o *workerOptions) {
+		o.concurrency = concurrency
+	}
+}
+
+// WithPollInterval sets interval for polling the queue when there are no ready jobs. By default, it's 1 second.
+func WithPollInterval(interval time.Duration) WorkerOption {
+	return func(o *workerOptions) {
+		o.pollInterval = interval
+	}
+}
+
+// WithLockTimeout sets the duration for which the job is claimed. The job should be processed within this duration,
+// otherwise, it may be claimed again by another worker. By default, it's 5 minutes.
+func WithLockTimeout(timeout time.Duration) WorkerOption {
+	return func(o *workerOptions) {
+		o.lockTimeout = timeout
+	}
+}
+
+// WithFinishTimeout sets timeout for completing (or rescheduling) the processed job. By default, it's 5 seconds.
+func WithFinishTimeout(timeout time.Duration) WorkerOption {
+	return func(o *workerOptions) {
+		o.finishTimeout = timeout
+	}
+}
+
+// WithBackoff sets the function that calculates the delay before retrying the failed job.
+// By default, ExponentialBackoff(1 second, 1 hour) is used.
+func WithBackoff(backoff BackoffFunc) WorkerOption {
+	return func(o *workerOptions) {
+		o.backoff = backoff
+	}
+}
+
+// WithPrefetch sets the maximum number of jobs claimed per round-trip to the database.
+// By default, the worker claims only as many jobs as it has free slots (see WithConcurrency).
+// With prefetching, jobs are claimed in larger batches and wait for a free slot locally,
+// so the lock timeout (see WithLockTimeout) should cover both waiting and processing.
+// Prefetched jobs that are not started when the worker stops are released (see Queue.Release),
+// so they may be claimed again right away without counting the attempt.
+func WithPrefetch(prefetch int) WorkerOption {
+	return func(o *workerOptions) {
+		o.prefetch = prefetch
+	}
+}
+
+// WithBatchAck enables batch acknowledgment: successfully processed jobs are completed in a single transaction
+// once batchSize jobs are collected or flushInterval passes (DefaultBatchAckFlushInterval if not positive),
+// and on the worker stop. flushInterval should be much shorter than the lock timeout (see WithLockTimeout),
+// otherwise processed jobs may be claimed again before they are acknowledged.
+// Failed jobs are rescheduled individually.
+func WithBatchAck(batchSize int, flushInterval time.Duration) WorkerOption {
+	return func(o *workerOptions) {
+		o.batchAckSize = batchSize
+		o.batchAckFlushInterval = flushInterval
+	}
+}
+
+// WithWorkerLogger sets logger for Worker.
+func WithWorkerLogger(logger Logger) WorkerOption {
+	return func(o *workerOptions) {
+		o.logger = logger
+	}
+}
+
+// NewWorker creates a new Worker that processes jobs from the queue with the handler.
+func NewWorker(dbConn *sql.DB, queue *Queue, handler Handler, options ...WorkerOption) *Worker {
+	opts := workerOptions{
+		concurrency:   DefaultConcurrency,
+		pollInterval:  DefaultPollInterval,
+		lockTimeout:   DefaultLockTimeout,
+		finishTimeout: DefaultFinishTimeout,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.concurrency <= 0 {
+		opts.concurrency = DefaultConcurrency
+	}
+	if opts.backoff == nil {
+		opts.backoff = ExponentialBackoff(DefaultMinRetryDelay, DefaultMaxRetryDelay)
+	}
+	if opts.logger == nil {
+		opts.logger = disabledLogger{}
+	}
+	if opts.batchAckFlushInterval <= 0 {
+		opts.batchAckFlushInterval = DefaultBatchAckFlushInterval
+	}
+	return &Worker{dbConn: dbConn, queue: queue, handler: handler, opts: opts}
+}
+
+// Run claims and processes jobs until ctx is done. When ctx is done, Run waits for the jobs in progress
+// (their context is canceled too) and returns ctx.Err(). Jobs whose handlers fail due to the cancellation
+// and prefetched jobs that are not started yet are released (see Queue.Release), so the shutdown doesn't burn their attempts.
+func (w *Worker) Run(ctx context.Context) error {
+	slots := make(chan struct{}, w.opts.concurrency)
+	var wg sync.WaitGroup
+
+	var acker *batchAcker
+	if w.opts.batchAckSize > 1 {
+		acker = newBatchAcker(w)
+		go acker.run()
+		defer acker.stop() // Deferred calls are executed in LIFO order, so all jobs are finished before the stop.
+	}
+	defer wg.Wait()
+
+	for {
+		// Wait for at least one free slot.
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case slots <- struct{}{}:
+		}
+		free := 1
+	freeLoop:
+		for free < w.opts.concurrency {
+			select {
+			case slots <- struct{}{}:
+				free++
+			default:
+				break freeLoop
+			}
+		}
+
+		limit := free
+		if w.opts.prefetch > limit {
+			limit = w.opts.prefetch
+		}
+		claimDeadline := w.queue.clock.Now().Add(w.opts.lockTimeout)
+		jobs, err := w.queue.Claim(ctx, w.dbConn, limit, w.opts.lockTimeout)
+		if err != nil && ctx.Err() == nil {
+			w.opts.logger.Errorf("failed to claim jobs from queue %s, error: %v", w.queue.name, err)
+		}
+		for i := len(jobs); i < free; i++ {
+			<-slots
+		}
+		for i, job := range jobs {
+			if i >= free {
+				// Prefetched job waits for a free slot.
+				select {
+				case <-ctx.Done():
+					w.release(jobs[i:])
+					return ctx.Err()
+				case slots <- struct{}{}:
+				}
+			}
+			wg.Add(1)
+			go func(job Job) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				w.process(ctx, job, claimDeadline, acker)
+			}(job)
+		}
+
+		if len(jobs) < limit {
+			// There are no more ready jobs (or claiming failed), let's wait before the next poll.
+			if err = w.waitPollInterval(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (w *Worker) waitPollInterval(ctx context.Context) error {
+	timer := w.queue.clock.NewTimer(w.opts.pollInterval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// release returns the prefetched jobs that are not started to the queue, so the shutdown doesn't burn their attempts.
+func (w *Worker) release(jobs []Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.opts.finishTimeout)
+	defer cancel()
+	for _, job := range jobs {
+		if err := w.queue.Release(ctx, w.dbConn, job); err != nil {
+			w.opts.logger.Errorf("failed to release job %d from queue %s, error: %v", job.ID, w.queue.name, err)
+		}
+	}
+}
+
+func (w *Worker) process(ctx context.Context, job Job, claimDeadline time.Time, acker *batchAcker) {
+	// Job should not be processed after its claim expires, since it may be claimed by another worker.
+	// The deadline is measured by the queue's clock, so the context gets the remaining time instead of the deadline itself.
+	jobCtx, jobCtxCancel := context.WithTimeout(ctx, claimDeadline.Sub(w.queue.clock.Now()))
+	defer jobCtxCancel()
+	jobErr := w.handler(jobCtx, job)
+
+	if jobErr == nil && acker != nil {
+		acker.ack(job)
+		return
+	}
+
+	// If the ctx is canceled, we should be able to finish the job.
+	finishCtx, finishCtxCancel := context.WithTimeout(context.Background(), w.opts.finishTimeout)
+	defer finishCtxCancel()
+	if jobErr == nil {
+		if err := w.queue.Complete(finishCtx, w.dbConn, job); err != nil {
+			w.opts.logger.Errorf("failed to complete job %d from queue %s, error: %v", job.ID, w.queue.name, err)
+		}
+		return
+	}
+	if ctx.Err() != nil {
+		// Processing is interrupted by the shutdown, it shouldn't burn the attempt and delay the job by the backoff.
+		if err := w.queue.Release(finishCtx, w.dbConn, job); err != nil {
+			w.opts.logger.Errorf("failed to release job %d from queue %s, error: %v", job.ID, w.queue.name, err)
+		}
+		return
+	}
+	runAt := w.queue.clock.Now().Add(w.opts.backoff(job.Attempt))
+	if err := w.queue.Retry(finishCtx, w.dbConn, job, runAt, jobErr); err != nil {
+		w.opts.logger.Errorf("failed to reschedule job %d from queue %s (attempt %d failed with error: %v), error: %v",
+			job.ID, w.queue.name, job.Attempt, jobErr, err)
+	}
+}
+
+// batchAcker collects successfully processed jobs and completes them in batches.
+type batchAcker struct {
+	worker *Worker
+	jobs   chan Job
+	done   chan struct{}
+}
+
+func newBatchAcker(w *Worker) *batchAcker {
+	return &batchAcker{worker: w, jobs: make(chan Job, w.opts.batchAckSize), done: make(chan struct{})}
+}
+
+func (a *batchAcker) ack(job Job) {
+	a.jobs <- job
+}
+
+func (a *batchAcker) run() {
+	defer close(a.done)
+	timer := a.worker.queue.clock.NewTimer(a.worker.opts.batchAckFlushInterval)
+	defer timer.Stop()
+	batch := make([]Job, 0, a.worker.opts.batchAckSize)
+	for {
+		select {
+		case job, ok := <-a.jobs:
+			if !ok {
+				a.flush(batch)
+				return
+			}
+			batch = append(batch, job)
+			if len(batch) >= a.worker.opts.batchAckSize {
+				a.flush(batch)
+				batch = batch[:0]
+			}
+		case <-timer.C():
+			a.flush(batch)
+			batch = batch[:0]
+			timer.Reset(a.worker.opts.batchAckFlushInterval)
+		}
+	}
+}
+
+// stop flushes the remaining jobs. It must be called after all jobs are processed.
+func (a *batchAcker) stop() {
+	close(a.jobs)
+	<-a.done
+}
+
+func (a *batchAcker) flush(batch []Job) {
+	if len(batch) == 0 {
+		return
+	}
+	w := a.worker
+	ctx, cancel := context.WithTimeout(context.Background(), w.opts.finishTimeout)
+	defer cancel()
+	if _, err := w.queue.CompleteBatch(ctx, w.dbConn, batch); err != nil {
+		w.opts.logger.Errorf("failed to complete batch of %d jobs from queue %s, error: %v", len(batch), w.queue.name, err)
+	}
+}
+
+type disabledLogger struct{}
+
+func (disabledLogger) Errorf(msg string, args ...interface{}) {}
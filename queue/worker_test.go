@@ -0,0 +1,234 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package queue
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/testkit"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Second, 10*time.Second)
+	require.Equal(t, time.Second, backoff(1))
+	require.Equal(t, 2*time.Second, backoff(2))
+	require.Equal(t, 8*time.Second, backoff(4))
+	require.Equal(t, 10*time.Second, backoff(5))
+	require.Equal(t, 10*time.Second, backoff(100))
+}
+
+func TestWorker(t *testing.T) {
+	const jobsNum = 20
+
+	q, err := NewQueue(dbkit.DialectSQLite, "emails")
+	require.NoError(t, err)
+	dbConn := openTestDB(t, q)
+	for i := 0; i < jobsNum; i++ {
+		require.NoError(t, q.Enqueue(context.Background(), dbConn, []byte(strconv.Itoa(i))))
+	}
+
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	done := make(chan struct{})
+	handler := func(ctx context.Context, job Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[string(job.Payload)]++
+		if n, _ := strconv.Atoi(string(job.Payload)); n%2 == 0 && job.Attempt == 1 {
+			return errors.New("temporary error")
+		}
+		if len(attempts) == jobsNum {
+			succeeded := 0
+			for payload, cnt := range attempts {
+				if n, _ := strconv.Atoi(payload); n%2 != 0 || cnt == 2 {
+					succeeded++
+				}
+			}
+			if succeeded == jobsNum {
+				close(done)
+			}
+		}
+		return nil
+	}
+
+	worker := NewWorker(dbConn, q, handler, WithConcurrency(4), WithPollInterval(10*time.Millisecond),
+		WithBackoff(func(int) time.Duration { return 0 }))
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- worker.Run(ctx) }()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("all jobs must be processed")
+	}
+	cancel()
+	require.ErrorIs(t, <-runErr, context.Canceled)
+
+	for payload, cnt := range attempts {
+		if n, _ := strconv.Atoi(payload); n%2 == 0 {
+			require.Equal(t, 2, cnt, "failed job %s must be retried once", payload)
+		} else {
+			require.Equal(t, 1, cnt, "job %s must be processed once", payload)
+		}
+	}
+	var remaining int
+	require.NoError(t, dbConn.QueryRow(`SELECT COUNT(*) FROM queue_jobs`).Scan(&remaining))
+	require.Equal(t, 0, remaining)
+}
+
+func TestWorker_FakeClock(t *testing.T) {
+	clock := testkit.NewFakeClock(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	q, err := NewQueue(dbkit.DialectSQLite, "emails", WithClock(clock))
+	require.NoError(t, err)
+	dbConn := openTestDB(t, q)
+	require.NoError(t, q.Enqueue(context.Background(), dbConn, []byte("email")))
+
+	attempts := make(chan int, 2)
+	handler := func(ctx context.Context, job Job) error {
+		attempts <- job.Attempt
+		if job.Attempt == 1 {
+			return errors.New("temporary error")
+		}
+		return nil
+	}
+	worker := NewWorker(dbConn, q, handler, WithBackoff(func(int) time.Duration { return time.Minute }))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- worker.Run(ctx) }()
+
+	require.Equal(t, 1, <-attempts)
+	// The retried job is not ready yet, so the worker waits for the poll interval.
+	require.NoError(t, clock.WaitForTimers(ctx, 1))
+	clock.Advance(DefaultPollInterval)
+	require.NoError(t, clock.WaitForTimers(ctx, 1))
+	select {
+	case attempt := <-attempts:
+		t.Fatalf("job must not be retried before the backoff delay, attempt %d", attempt)
+	default:
+	}
+
+	clock.Advance(time.Minute)
+	require.Equal(t, 2, <-attempts)
+	cancel()
+	require.ErrorIs(t, <-runErr, context.Canceled)
+}
+
+func TestWorker_ReleaseOnShutdown(t *testing.T) {
+	q, err := NewQueue(dbkit.DialectSQLite, "emails")
+	require.NoError(t, err)
+	dbConn := openTestDB(t, q)
+	require.NoError(t, q.Enqueue(context.Background(), dbConn, []byte("email")))
+
+	started := make(chan struct{})
+	handler := func(ctx context.Context, job Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	worker := NewWorker(dbConn, q, handler, WithBackoff(func(int) time.Duration { return time.Hour }))
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- worker.Run(ctx) }()
+
+	<-started
+	cancel()
+	require.ErrorIs(t, <-runErr, context.Canceled)
+
+	// The interrupted job is pending again, and neither the attempt nor the backoff delay is counted.
+	jobs, err := q.Claim(context.Background(), dbConn, 1, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.Equal(t, 1, jobs[0].Attempt)
+}
+
+func TestWorker_ReleasePrefetchedOnShutdown(t *testing.T) {
+	q, err := NewQueue(dbkit.DialectSQLite, "emails")
+	require.NoError(t, err)
+	dbConn := openTestDB(t, q)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, q.Enqueue(context.Background(), dbConn, []byte(strconv.Itoa(i))))
+	}
+
+	started := make(chan struct{})
+	handler := func(ctx context.Context, job Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	worker := NewWorker(dbConn, q, handler, WithConcurrency(1), WithPrefetch(3))
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- worker.Run(ctx) }()
+
+	<-started
+	cancel()
+	require.ErrorIs(t, <-runErr, context.Canceled)
+
+	// Both the interrupted job and the prefetched ones that waited for a free slot are pending again.
+	jobs, err := q.Claim(context.Background(), dbConn, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, jobs, 3)
+	for _, job := range jobs {
+		require.Equal(t, 1, job.Attempt)
+	}
+}
+
+func TestWorker_PrefetchAndBatchAck(t *testing.T) {
+	const jobsNum = 50
+
+	q, err := NewQueue(dbkit.DialectSQLite, "emails")
+	require.NoError(t, err)
+	dbConn := openTestDB(t, q)
+	for i := 0; i < jobsNum; i++ {
+		require.NoError(t, q.Enqueue(context.Background(), dbConn, []byte(strconv.Itoa(i))))
+	}
+
+	var mu sync.Mutex
+	processed := make(map[string]int)
+	done := make(chan struct{})
+	handler := func(ctx context.Context, job Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		processed[string(job.Payload)]++
+		if len(processed) == jobsNum {
+			close(done)
+		}
+		return nil
+	}
+
+	worker := NewWorker(dbConn, q, handler, WithConcurrency(2), WithPrefetch(10),
+		WithBatchAck(20, time.Hour), WithPollInterval(10*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- worker.Run(ctx) }()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("all jobs must be processed")
+	}
+	cancel()
+	require.ErrorIs(t, <-runErr, context.Canceled)
+
+	for payload, cnt := range processed {
+		require.Equal(t, 1, cnt, "job %s must be processed once", payload)
+	}
+	// The last incomplete batch is acknowledged when the worker stops.
+	var remaining int
+	require.NoError(t, dbConn.QueryRow(`SELECT COUNT(*) FROM queue_jobs`).Scan(&remaining))
+	require.Equal(t, 0, remaining)
+}
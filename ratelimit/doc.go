@@ -0,0 +1,11 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package ratelimit provides a distributed rate limiter backed by SQL database (PostgreSQL, MySQL and SQLite are supported).
+// It's useful for services that have no Redis but need cluster-wide limits (e.g., on outbound API calls).
+// Limits are applied within fixed windows, and the counter of each key is updated atomically
+// with a dialect-specific upsert in a single transaction.
+package ratelimit
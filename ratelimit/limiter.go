@@ -0,0 +1,288 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+// DefaultTableName is a default name for the table that stores rate limit counters.
+const DefaultTableName = "rate_limits"
+
+const maxKeyLen = 255
+
+// Result contains the result of the rate limit check.
+type Result struct {
+	// Allowed is true if the request is allowed.
+	Allowed bool
+	// Remaining is the number of requests that may be allowed within the current window.
+	Remaining int
+	// ResetAt is the time when the current window ends.
+	ResetAt time.Time
+}
+
+// RetryAfter returns the duration after which the denied request may be retried.
+func (r Result) RetryAfter(now time.Time) time.Duration {
+	if r.Allowed {
+		return 0
+	}
+	return r.ResetAt.Sub(now)
+}
+
+// Limiter is a fixed window rate limiter that allows up to limit requests per window for each key
+// across all service instances using the same database table.
+// Windows are aligned to the Unix epoch and calculated using the local clock,
+// so clocks of the service instances should be synchronized.
+// The counter is reset only when a request of a later window comes, so requests of the instance whose clock is behind
+// are counted within the current window instead of resetting it.
+type Limiter struct {
+	limit   int
+	window  time.Duration
+	queries dbQueries
+	clock   dbkit.Clock
+}
+
+// Option is an option for NewLimiter.
+type Option func(*limiterOptions)
+
+type limiterOptions struct {
+	tableName string
+	clock     dbkit.Clock
+}
+
+// WithTableName sets a custom table name for the table that stores rate limit counters.
+func WithTableName(tableName string) Option {
+	return func(o *limiterOptions) {
+		o.tableName = tableName
+	}
+}
+
+// WithClock sets the clock that is used for calculating rate limit windows (dbkit.RealClock by default). It's intended for tests.
+func WithClock(clock dbkit.Clock) Option {
+	return func(o *limiterOptions) {
+		o.clock = clock
+	}
+}
+
+// NewLimiter creates a new Limiter that allows up to limit requests per window.
+func NewLimiter(dialect dbkit.Dialect, limit int, window time.Duration, options ...Option) (*Limiter, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive")
+	}
+	if window < time.Millisecond {
+		return nil, fmt.Errorf("window cannot be less than 1 millisecond")
+	}
+	opts := limiterOptions{clock: dbkit.RealClock{}}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.tableName == "" {
+		opts.tableName = DefaultTableName
+	}
+	q, err := newDBQueries(dialect, opts.tableName)
+	if err != nil {
+		return nil, err
+	}
+	return &Limiter{limit: limit, window: window, queries: q, clock: opts.clock}, nil
+}
+
+// Migrations returns set of migrations that must be applied before using the limiter.
+func (l *Limiter) Migrations() []migrate.Migration {
+	return []migrate.Migration{
+		migrate.NewCustomMigration(createTableMigrationID,
+			[]string{l.queries.createTable}, []string{l.queries.dropTable}, nil, nil),
+	}
+}
+
+// CreateTableSQL returns SQL query for creating a table that stores rate limit counters.
+func (l *Limiter) CreateTableSQL() string {
+	return l.queries.createTable
+}
+
+// DropTableSQL returns SQL query for dropping a table that stores rate limit counters.
+func (l *Limiter) DropTableSQL() string {
+	return l.queries.dropTable
+}
+
+// Allow reports whether the request for the key is allowed and consumes it if so.
+func (l *Limiter) Allow(ctx context.Context, dbConn *sql.DB, key string) (Result, error) {
+	return l.AllowN(ctx, dbConn, key, 1)
+}
+
+var errLimitExceeded = errors.New("rate limit exceeded")
+
+// AllowN reports whether n requests for the key are allowed and consumes them if so.
+// Denied requests are not counted. n must be positive and cannot exceed the limit, since such requests are never allowed.
+func (l *Limiter) AllowN(ctx context.Context, dbConn *sql.DB, key string, n int) (Result, error) {
+	if key == "" {
+		return Result{}, fmt.Errorf("rate limit key cannot be empty")
+	}
+	if len(key) > maxKeyLen {
+		return Result{}, fmt.Errorf("rate limit key cannot be longer than %d symbols", maxKeyLen)
+	}
+	if n <= 0 {
+		return Result{}, fmt.Errorf("number of requests must be positive, got %d", n)
+	}
+	if n > l.limit {
+		return Result{}, fmt.Errorf("number of requests %d exceeds the limit %d", n, l.limit)
+	}
+	windowStart := l.windowStart(l.clock.Now())
+	result := Result{ResetAt: windowStart.Add(l.window)}
+	var used int
+	err := dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+		// Upsert locks the counter row, so concurrent requests for the same key are serialized
+		// until the transaction is finished. If the limit is exceeded, the transaction is rolled back.
+		if _, err := tx.ExecContext(ctx, l.queries.consume, key, windowStart.UnixMilli(), n); err != nil {
+			return fmt.Errorf("consume: %w", err)
+		}
+		if err := tx.QueryRowContext(ctx, l.queries.getUsed, key).Scan(&used); err != nil {
+			return fmt.Errorf("get used: %w", err)
+		}
+		if used > l.limit {
+			return errLimitExceeded
+		}
+		return nil
+	})
+	switch {
+	case err == nil:
+		result.Allowed = true
+		result.Remaining = l.limit - used
+	case errors.Is(err, errLimitExceeded):
+		result.Remaining = l.limit - (used - n)
+		if result.Remaining < 0 {
+			result.Remaining = 0
+		}
+	default:
+		return Result{}, fmt.Errorf("check rate limit for key %s: %w", key, err)
+	}
+	return result, nil
+}
+
+// Wait blocks until the request for the key is allowed (and consumes it) or ctx is done.
+func (l *Limiter) Wait(ctx context.Context, dbConn *sql.DB, key string) error {
+	for {
+		result, err := l.Allow(ctx, dbConn, key)
+		if err != nil {
+			return err
+		}
+		if result.Allowed {
+			return nil
+		}
+		if err = l.waitRetry(ctx, result); err != nil {
+			return err
+		}
+	}
+}
+
+func (l *Limiter) waitRetry(ctx context.Context, result Result) error {
+	timer := l.clock.NewTimer(result.RetryAfter(l.clock.Now()))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// DeleteExpired deletes counters of the windows that are already ended (e.g., for keys that are not used anymore)
+// and returns the number of deleted counters.
+func (l *Limiter) DeleteExpired(ctx context.Context, executor SQLExecutor) (int64, error) {
+	windowStart := l.windowStart(l.clock.Now())
+	result, err := executor.ExecContext(ctx, l.queries.deleteExpired, windowStart.UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("delete expired rate limit counters: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// windowStart returns the start of the window (aligned to the Unix epoch) that contains the given time.
+// Unlike time.Time.Truncate, it doesn't depend on the zero time for windows that don't divide 24 hours (e.g., 7 minutes).
+func (l *Limiter) windowStart(t time.Time) time.Time {
+	return t.Add(-time.Duration(t.UnixNano() % int64(l.window)))
+}
+
+// SQLExecutor is an interface for executing SQL queries (e.g., *sql.DB or *sql.Tx).
+type SQLExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+type dbQueries struct {
+	createTable   string
+	dropTable     string
+	consume       string
+	getUsed       string
+	deleteExpired string
+}
+
+func newDBQueries(dialect dbkit.Dialect, tableName string) (dbQueries, error) {
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		return dbQueries{
+			createTable:   fmt.Sprintf(postgresCreateTableQuery, tableName),
+			dropTable:     fmt.Sprintf(postgresDropTableQuery, tableName),
+			consume:       fmt.Sprintf(postgresConsumeQuery, tableName),
+			getUsed:       fmt.Sprintf(postgresGetUsedQuery, tableName),
+			deleteExpired: fmt.Sprintf(postgresDeleteExpiredQuery, tableName),
+		}, nil
+	case dbkit.DialectMySQL:
+		return dbQueries{
+			createTable:   fmt.Sprintf(mySQLCreateTableQuery, tableName),
+			dropTable:     fmt.Sprintf(mySQLDropTableQuery, tableName),
+			consume:       fmt.Sprintf(mySQLConsumeQuery, tableName),
+			getUsed:       fmt.Sprintf(mySQLGetUsedQuery, tableName),
+			deleteExpired: fmt.Sprintf(mySQLDeleteExpiredQuery, tableName),
+		}, nil
+	case dbkit.DialectSQLite:
+		return dbQueries{
+			createTable:   fmt.Sprintf(sqliteCreateTableQuery, tableName),
+			dropTable:     fmt.Sprintf(sqliteDropTableQuery, tableName),
+			consume:       fmt.Sprintf(sqliteConsumeQuery, tableName),
+			getUsed:       fmt.Sprintf(sqliteGetUsedQuery, tableName),
+			deleteExpired: fmt.Sprintf(sqliteDeleteExpiredQuery, tableName),
+		}, nil
+	default:
+		return dbQueries{}, fmt.Errorf("unsupported sql dialect %q", dialect)
+	}
+}
+
+const createTableMigrationID = "ratelimit_00001_create_table"
+
+//nolint:lll
+const (
+	postgresCreateTableQuery   = `CREATE TABLE IF NOT EXISTS "%s" (limit_key varchar(255) PRIMARY KEY, window_start bigint NOT NULL, used integer NOT NULL);`
+	postgresDropTableQuery     = `DROP TABLE IF EXISTS "%s";`
+	postgresConsumeQuery       = `INSERT INTO "%[1]s" AS t (limit_key, window_start, used) VALUES ($1, $2, $3) ON CONFLICT (limit_key) DO UPDATE SET used = CASE WHEN EXCLUDED.window_start > t.window_start THEN EXCLUDED.used ELSE t.used + EXCLUDED.used END, window_start = GREATEST(t.window_start, EXCLUDED.window_start);`
+	postgresGetUsedQuery       = `SELECT used FROM "%s" WHERE limit_key = $1;`
+	postgresDeleteExpiredQuery = `DELETE FROM "%s" WHERE window_start < $1;`
+)
+
+//nolint:lll
+const (
+	// Assignments in ON DUPLICATE KEY UPDATE are evaluated from left to right, so used is calculated with the old window_start.
+	mySQLCreateTableQuery   = "CREATE TABLE IF NOT EXISTS `%s` (limit_key VARCHAR(255) PRIMARY KEY, window_start BIGINT NOT NULL, used INT NOT NULL);"
+	mySQLDropTableQuery     = "DROP TABLE IF EXISTS `%s`;"
+	mySQLConsumeQuery       = "INSERT INTO `%s` (limit_key, window_start, used) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE used = IF(VALUES(window_start) > window_start, VALUES(used), used + VALUES(used)), window_start = GREATEST(window_start, VALUES(window_start));"
+	mySQLGetUsedQuery       = "SELECT used FROM `%s` WHERE limit_key = ?;"
+	mySQLDeleteExpiredQuery = "DELETE FROM `%s` WHERE window_start < ?;"
+)
+
+//nolint:lll
+const (
+	sqliteCreateTableQuery   = `CREATE TABLE IF NOT EXISTS "%s" (limit_key TEXT PRIMARY KEY, window_start INTEGER NOT NULL, used INTEGER NOT NULL);`
+	sqliteDropTableQuery     = `DROP TABLE IF EXISTS "%s";`
+	sqliteConsumeQuery       = `INSERT INTO "%s" (limit_key, window_start, used) VALUES (?, ?, ?) ON CONFLICT (limit_key) DO UPDATE SET used = CASE WHEN excluded.window_start > window_start THEN excluded.used ELSE used + excluded.used END, window_start = MAX(window_start, excluded.window_start);`
+	sqliteGetUsedQuery       = `SELECT used FROM "%s" WHERE limit_key = ?;`
+	sqliteDeleteExpiredQuery = `DELETE FROM "%s" WHERE window_start < ?;`
+)
@@ -0,0 +1,202 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/testkit"
+)
+
+func newTestLimiter(t *testing.T, limit int, window time.Duration) (*Limiter, *sql.DB) {
+	t.Helper()
+	limiter, err := NewLimiter(dbkit.DialectSQLite, limit, window)
+	require.NoError(t, err)
+	dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "ratelimit.db")+"?_journal=MEMORY&_sync=OFF&_busy_timeout=5000")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	_, err = dbConn.Exec(limiter.CreateTableSQL())
+	require.NoError(t, err)
+	return limiter, dbConn
+}
+
+func TestNewLimiter(t *testing.T) {
+	_, err := NewLimiter(dbkit.DialectPostgres, 10, time.Second)
+	require.NoError(t, err)
+	limiter, err := NewLimiter(dbkit.DialectMySQL, 10, time.Second, WithTableName("api_limits"))
+	require.NoError(t, err)
+	require.Contains(t, limiter.CreateTableSQL(), "`api_limits`")
+
+	_, err = NewLimiter(dbkit.DialectSQLite, 0, time.Second)
+	require.Error(t, err)
+	_, err = NewLimiter(dbkit.DialectSQLite, 10, 0)
+	require.Error(t, err)
+	_, err = NewLimiter(dbkit.DialectMSSQL, 10, time.Second)
+	require.Error(t, err)
+}
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("fixed window", func(t *testing.T) {
+		limiter, dbConn := newTestLimiter(t, 3, time.Minute)
+		limiter.clock = testkit.NewFakeClock(now)
+
+		for i := 0; i < 3; i++ {
+			result, err := limiter.Allow(ctx, dbConn, "api")
+			require.NoError(t, err)
+			require.True(t, result.Allowed)
+			require.Equal(t, 2-i, result.Remaining)
+			require.Equal(t, now.Truncate(time.Minute).Add(time.Minute), result.ResetAt)
+		}
+		result, err := limiter.Allow(ctx, dbConn, "api")
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+		require.Equal(t, 0, result.Remaining)
+		require.Equal(t, 55*time.Second, result.RetryAfter(now))
+
+		result, err = limiter.Allow(ctx, dbConn, "other-api")
+		require.NoError(t, err)
+		require.True(t, result.Allowed, "keys must be limited independently")
+
+		limiter.clock = testkit.NewFakeClock(now.Add(time.Minute))
+		result, err = limiter.Allow(ctx, dbConn, "api")
+		require.NoError(t, err)
+		require.True(t, result.Allowed, "limit must be reset in the next window")
+		require.Equal(t, 2, result.Remaining)
+
+		deleted, err := limiter.DeleteExpired(ctx, dbConn)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted) // "other-api" counter is from the previous window.
+	})
+
+	t.Run("denied requests are not counted", func(t *testing.T) {
+		limiter, dbConn := newTestLimiter(t, 5, time.Minute)
+		limiter.clock = testkit.NewFakeClock(now)
+
+		result, err := limiter.AllowN(ctx, dbConn, "api", 4)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		result, err = limiter.AllowN(ctx, dbConn, "api", 2)
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+		require.Equal(t, 1, result.Remaining)
+		result, err = limiter.Allow(ctx, dbConn, "api")
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, 0, result.Remaining)
+	})
+
+	t.Run("clock behind", func(t *testing.T) {
+		limiter, dbConn := newTestLimiter(t, 3, time.Minute)
+		limiter.clock = testkit.NewFakeClock(now.Add(time.Minute))
+		result, err := limiter.AllowN(ctx, dbConn, "api", 2)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+
+		// The instance whose clock is behind must not reset the counter that already moved to the next window.
+		laggingLimiter, err := NewLimiter(dbkit.DialectSQLite, 3, time.Minute, WithClock(testkit.NewFakeClock(now)))
+		require.NoError(t, err)
+		result, err = laggingLimiter.Allow(ctx, dbConn, "api")
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		result, err = limiter.Allow(ctx, dbConn, "api")
+		require.NoError(t, err)
+		require.False(t, result.Allowed, "requests of the lagging instance must be counted in the current window")
+	})
+
+	t.Run("windows are aligned to the Unix epoch", func(t *testing.T) {
+		const window = 7 * time.Minute
+		limiter, dbConn := newTestLimiter(t, 1, window)
+		limiter.clock = testkit.NewFakeClock(now)
+
+		result, err := limiter.Allow(ctx, dbConn, "api")
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Zero(t, result.ResetAt.UnixMilli()%window.Milliseconds())
+		require.True(t, result.ResetAt.After(now))
+		require.False(t, result.ResetAt.After(now.Add(window)))
+	})
+
+	t.Run("invalid number of requests", func(t *testing.T) {
+		limiter, dbConn := newTestLimiter(t, 3, time.Minute)
+		limiter.clock = testkit.NewFakeClock(now)
+
+		_, err := limiter.AllowN(ctx, dbConn, "api", 0)
+		require.EqualError(t, err, "number of requests must be positive, got 0")
+		_, err = limiter.AllowN(ctx, dbConn, "api", -2)
+		require.EqualError(t, err, "number of requests must be positive, got -2")
+		_, err = limiter.AllowN(ctx, dbConn, "api", 4)
+		require.EqualError(t, err, "number of requests 4 exceeds the limit 3")
+
+		// Rejected calls must not change the counter.
+		result, err := limiter.AllowN(ctx, dbConn, "api", 3)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		result, err = limiter.Allow(ctx, dbConn, "api")
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+	})
+
+	t.Run("concurrent requests", func(t *testing.T) {
+		const limit = 10
+		limiter, dbConn := newTestLimiter(t, limit, time.Hour)
+		limiter.clock = testkit.NewFakeClock(now)
+
+		var allowed int
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for i := 0; i < 30; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := limiter.Allow(ctx, dbConn, "api")
+				require.NoError(t, err)
+				if result.Allowed {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, limit, allowed)
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		limiter, dbConn := newTestLimiter(t, 1, 50*time.Millisecond)
+		require.NoError(t, limiter.Wait(ctx, dbConn, "api"))
+		start := time.Now()
+		require.NoError(t, limiter.Wait(ctx, dbConn, "api"))
+		require.Less(t, time.Since(start), time.Second)
+
+		clock := testkit.NewFakeClock(now)
+		limiter.clock = clock
+		require.NoError(t, limiter.Wait(ctx, dbConn, "frozen-api"))
+		canceledCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, limiter.Wait(canceledCtx, dbConn, "frozen-api"), context.DeadlineExceeded)
+
+		waitErr := make(chan error, 1)
+		go func() { waitErr <- limiter.Wait(ctx, dbConn, "frozen-api") }()
+		waitCtx, waitCtxCancel := context.WithTimeout(ctx, time.Second)
+		defer waitCtxCancel()
+		require.NoError(t, clock.WaitForTimers(waitCtx, 1))
+		clock.Advance(50 * time.Millisecond) // The next window.
+		require.NoError(t, <-waitErr)
+	})
+}
@@ -0,0 +1,177 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Default values for the reconnect throttling.
+const (
+	DefaultMaxConcurrentConnects = 4
+	DefaultReconnectBackoffMin   = 100 * time.Millisecond
+	DefaultReconnectBackoffMax   = 10 * time.Second
+)
+
+type reconnectThrottleOptions struct {
+	maxConcurrentConnects int
+	backoffMin            time.Duration
+	backoffMax            time.Duration
+}
+
+// ReconnectThrottleOption is a functional option for NewReconnectThrottlingConnector.
+type ReconnectThrottleOption func(*reconnectThrottleOptions)
+
+// WithMaxConcurrentConnects sets the maximum number of concurrent connection attempts.
+// DefaultMaxConcurrentConnects is used by default. Zero or negative value means no limit.
+func WithMaxConcurrentConnects(n int) ReconnectThrottleOption {
+	return func(opts *reconnectThrottleOptions) {
+		opts.maxConcurrentConnects = n
+	}
+}
+
+// WithReconnectBackoff sets the minimum and maximum backoff between connection attempts after failures.
+// DefaultReconnectBackoffMin and DefaultReconnectBackoffMax are used by default.
+func WithReconnectBackoff(minBackoff, maxBackoff time.Duration) ReconnectThrottleOption {
+	return func(opts *reconnectThrottleOptions) {
+		opts.backoffMin = minBackoff
+		opts.backoffMax = maxBackoff
+	}
+}
+
+// ReconnectThrottlingConnector implements driver.Connector and protects the database from reconnect storms
+// after mass disconnects (e.g., on database restart). The number of concurrent connection attempts is capped,
+// and after failed attempts the subsequent ones are delayed with exponential backoff and full jitter,
+// so the recovering database isn't overwhelmed by the whole pool reconnecting at once.
+// The backoff is reset after the first successful connection.
+type ReconnectThrottlingConnector struct {
+	connector driver.Connector
+	opts      reconnectThrottleOptions
+	sem       chan struct{}
+
+	mu       sync.Mutex
+	failures int
+}
+
+var _ driver.Connector = (*ReconnectThrottlingConnector)(nil)
+
+// NewReconnectThrottlingConnector wraps the passed connector with reconnect throttling.
+func NewReconnectThrottlingConnector(
+	connector driver.Connector, options ...ReconnectThrottleOption,
+) *ReconnectThrottlingConnector {
+	opts := reconnectThrottleOptions{
+		maxConcurrentConnects: DefaultMaxConcurrentConnects,
+		backoffMin:            DefaultReconnectBackoffMin,
+		backoffMax:            DefaultReconnectBackoffMax,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	c := &ReconnectThrottlingConnector{connector: connector, opts: opts}
+	if opts.maxConcurrentConnects > 0 {
+		c.sem = make(chan struct{}, opts.maxConcurrentConnects)
+	}
+	return c
+}
+
+// OpenWithReconnectThrottling opens a new database connection pool using the provided configuration (see Open)
+// with the reconnect throttling (see ReconnectThrottlingConnector).
+func OpenWithReconnectThrottling(cfg *Config, ping bool, options ...ReconnectThrottleOption) (*sql.DB, error) {
+	connector, err := OpenConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(NewReconnectThrottlingConnector(connector, options...))
+	return db, InitOpenedDB(db, cfg, ping)
+}
+
+func openConnector(driverName, dsn string) (driver.Connector, error) {
+	// sql.Open doesn't establish connections, it's used only to look up the registered driver.
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := db.Driver()
+	if err = db.Close(); err != nil {
+		return nil, err
+	}
+	if driverCtx, ok := drv.(driver.DriverContext); ok {
+		return driverCtx.OpenConnector(dsn)
+	}
+	return dsnConnector{dsn: dsn, driver: drv}, nil
+}
+
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Connect establishes a new connection waiting for the backoff delay (if previous attempts failed)
+// and for a free slot of concurrent connection attempts.
+func (c *ReconnectThrottlingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if delay := c.backoffDelay(); delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+
+	if c.sem != nil {
+		select {
+		case c.sem <- struct{}{}:
+			defer func() { <-c.sem }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	conn, err := c.connector.Connect(ctx)
+
+	c.mu.Lock()
+	if err == nil {
+		c.failures = 0
+	} else if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		c.failures++
+	}
+	c.mu.Unlock()
+
+	return conn, err
+}
+
+// Driver returns the underlying driver.
+func (c *ReconnectThrottlingConnector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+// Unwrap returns the wrapped connector.
+func (c *ReconnectThrottlingConnector) Unwrap() driver.Connector {
+	return c.connector
+}
+
+// backoffDelay returns random delay in [0, min(backoffMax, backoffMin*2^(failures-1))] (full jitter).
+func (c *ReconnectThrottlingConnector) backoffDelay() time.Duration {
+	c.mu.Lock()
+	failures := c.failures
+	c.mu.Unlock()
+	return fullJitterDelay(c.opts.backoffMin, c.opts.backoffMax, failures)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeConn struct {
+	driver.Conn
+}
+
+type fakeConnector struct {
+	delay       time.Duration
+	mu          sync.Mutex
+	err         error
+	connects    atomic.Int32
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (c *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.connects.Add(1)
+	cur := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		prevMax := c.maxInFlight.Load()
+		if cur <= prevMax || c.maxInFlight.CompareAndSwap(prevMax, cur) {
+			break
+		}
+	}
+	time.Sleep(c.delay)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	return fakeConn{}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver {
+	return nil
+}
+
+func TestReconnectThrottlingConnector(t *testing.T) {
+	t.Run("concurrent connects are capped", func(t *testing.T) {
+		fakeConn := &fakeConnector{delay: time.Millisecond * 20}
+		connector := NewReconnectThrottlingConnector(fakeConn, WithMaxConcurrentConnects(2))
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := connector.Connect(context.Background())
+				require.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, int32(10), fakeConn.connects.Load())
+		require.Equal(t, int32(2), fakeConn.maxInFlight.Load())
+	})
+
+	t.Run("backoff after failures", func(t *testing.T) {
+		connErr := errors.New("connection refused")
+		fakeConn := &fakeConnector{err: connErr}
+		connector := NewReconnectThrottlingConnector(fakeConn, WithReconnectBackoff(time.Second, time.Second*10))
+
+		_, err := connector.Connect(context.Background())
+		require.ErrorIs(t, err, connErr)
+		require.Equal(t, 1, connector.failures)
+		for i := 0; i < 100; i++ {
+			require.LessOrEqual(t, connector.backoffDelay(), time.Second)
+		}
+
+		connector.failures = 10
+		for i := 0; i < 100; i++ {
+			require.LessOrEqual(t, connector.backoffDelay(), time.Second*10)
+		}
+
+		// The attempt is delayed by the backoff, so it fails on the context deadline without calling the driver.
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		_, err = connector.Connect(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, int32(1), fakeConn.connects.Load())
+
+		// Backoff is reset after the successful connection.
+		fakeConn.err = nil
+		connector.failures = 1
+		connector.opts.backoffMin = time.Millisecond
+		_, err = connector.Connect(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 0, connector.failures)
+		require.Equal(t, time.Duration(0), connector.backoffDelay())
+	})
+}
+
+func TestOpenWithReconnectThrottling(t *testing.T) {
+	db, err := OpenWithReconnectThrottling(&Config{
+		Dialect:      DialectSQLite,
+		SQLite:       SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")},
+		MaxOpenConns: 2,
+	}, true, WithMaxConcurrentConnects(1))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	var one int
+	require.NoError(t, db.QueryRow("SELECT 1").Scan(&one))
+	require.Equal(t, 1, one)
+}
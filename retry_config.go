@@ -0,0 +1,84 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"time"
+
+	"github.com/acronis/go-appkit/config"
+	"github.com/acronis/go-appkit/retry"
+	"github.com/cenkalti/backoff/v4"
+)
+
+// Default values of the retry configuration (see RetryConfig).
+const (
+	DefaultRetryMaxAttempts     = 3
+	DefaultRetryInitialInterval = 100 * time.Millisecond
+	DefaultRetryMaxInterval     = 5 * time.Second
+)
+
+// RetryPolicyType defines the type of the retry policy (see RetryConfig).
+type RetryPolicyType string
+
+// Retry policy types.
+const (
+	RetryPolicyTypeNone        RetryPolicyType = "none"
+	RetryPolicyTypeConstant    RetryPolicyType = "constant"
+	RetryPolicyTypeExponential RetryPolicyType = "exponential"
+)
+
+// RetryPolicyTypes returns all supported retry policy types.
+func RetryPolicyTypes() []RetryPolicyType {
+	return []RetryPolicyType{RetryPolicyTypeNone, RetryPolicyTypeConstant, RetryPolicyTypeExponential}
+}
+
+// RetryConfig represents the retry policy of transactions and queries, so the organization-wide default
+// may be set in the service configuration instead of being hardcoded (see NewPolicy).
+type RetryConfig struct {
+	// Policy is the type of the retry policy. Empty value is the same as RetryPolicyTypeNone (no retries).
+	Policy RetryPolicyType `mapstructure:"policy" yaml:"policy" json:"policy"`
+	// MaxAttempts is the maximum number of retries (not counting the first attempt), 0 means no limit.
+	MaxAttempts int `mapstructure:"maxAttempts" yaml:"maxAttempts" json:"maxAttempts"`
+	// InitialInterval is the delay before the first retry (and before each retry for the constant policy).
+	// DefaultRetryInitialInterval is used if it's zero.
+	InitialInterval config.TimeDuration `mapstructure:"initialInterval" yaml:"initialInterval" json:"initialInterval"`
+	// MaxInterval caps the growing delay of the exponential policy, 0 means no cap.
+	MaxInterval config.TimeDuration `mapstructure:"maxInterval" yaml:"maxInterval" json:"maxInterval"`
+}
+
+// NewPolicy creates the retry policy according to the configuration.
+// It returns nil if retries are disabled, so the result may be passed to WithRetryPolicy as is:
+//
+//	err := dbkit.DoInTx(ctx, db, fn, dbkit.WithRetryPolicy(cfg.Retry.NewPolicy()))
+//
+// The exponential policy uses 1.5 multiplier and randomization, as retry.NewExponentialBackoffPolicy.
+func (c *RetryConfig) NewPolicy() retry.Policy {
+	initialInterval := time.Duration(c.InitialInterval)
+	if initialInterval <= 0 {
+		initialInterval = DefaultRetryInitialInterval
+	}
+	switch c.Policy {
+	case RetryPolicyTypeConstant:
+		return retry.NewConstantBackoffPolicy(initialInterval, c.MaxAttempts)
+	case RetryPolicyTypeExponential:
+		maxInterval, maxAttempts := time.Duration(c.MaxInterval), c.MaxAttempts
+		return retry.PolicyFunc(func() backoff.BackOff {
+			eb := backoff.NewExponentialBackOff()
+			eb.InitialInterval = initialInterval
+			if maxInterval > 0 {
+				eb.MaxInterval = maxInterval
+			}
+			var b backoff.BackOff = eb
+			if maxAttempts > 0 {
+				b = backoff.WithMaxRetries(eb, uint64(maxAttempts))
+			}
+			b.Reset()
+			return b
+		})
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/config"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryConfig_NewPolicy(t *testing.T) {
+	require.Nil(t, (&RetryConfig{}).NewPolicy())
+	require.Nil(t, (&RetryConfig{Policy: RetryPolicyTypeNone, MaxAttempts: 3}).NewPolicy())
+
+	collectDelays := func(b backoff.BackOff) []time.Duration {
+		var delays []time.Duration
+		for delay := b.NextBackOff(); delay != backoff.Stop; delay = b.NextBackOff() {
+			delays = append(delays, delay)
+		}
+		return delays
+	}
+
+	constant := (&RetryConfig{Policy: RetryPolicyTypeConstant, MaxAttempts: 3}).NewPolicy()
+	require.Equal(t, []time.Duration{DefaultRetryInitialInterval, DefaultRetryInitialInterval, DefaultRetryInitialInterval},
+		collectDelays(constant.NewBackOff()))
+
+	exponential := (&RetryConfig{
+		Policy:          RetryPolicyTypeExponential,
+		MaxAttempts:     10,
+		InitialInterval: config.TimeDuration(10 * time.Millisecond),
+		MaxInterval:     config.TimeDuration(50 * time.Millisecond),
+	}).NewPolicy()
+	delays := collectDelays(exponential.NewBackOff())
+	require.Len(t, delays, 10)
+	for _, delay := range delays {
+		require.LessOrEqual(t, delay, 75*time.Millisecond) // MaxInterval with randomization factor 0.5.
+	}
+	require.Greater(t, delays[9], delays[0])
+
+	// Backoffs of the policy are independent.
+	require.Len(t, collectDelays(exponential.NewBackOff()), 10)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/acronis/go-appkit/retry"
+	"github.com/cenkalti/backoff/v4"
+)
+
+// ErrRetryDeadlineExceeded is returned (wrapped together with the error of the last attempt) by DoInTx
+// and DoWithRetry when retries are stopped because the next backoff delay doesn't fit into the time remaining
+// until the ctx deadline. So, the last attempt isn't started only to be canceled mid-query,
+// and the caller gets the original error of the operation instead of context.DeadlineExceeded.
+var ErrRetryDeadlineExceeded = errors.New("retries exhausted by deadline")
+
+// exceedsDeadline reports whether waiting for delay doesn't leave time for one more attempt before the ctx deadline.
+func exceedsDeadline(ctx context.Context, delay time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && time.Until(deadline) <= delay
+}
+
+func wrapRetryDeadlineExceeded(err error) error {
+	return fmt.Errorf("%w: %w", ErrRetryDeadlineExceeded, err)
+}
+
+// deadlineAwareBackOff stops retries when the next delay exceeds the time remaining until the ctx deadline.
+type deadlineAwareBackOff struct {
+	backoff.BackOff
+	ctx      context.Context
+	exceeded bool
+}
+
+func (b *deadlineAwareBackOff) NextBackOff() time.Duration {
+	delay := b.BackOff.NextBackOff()
+	if delay != backoff.Stop && exceedsDeadline(b.ctx, delay) {
+		b.exceeded = true
+		return backoff.Stop
+	}
+	return delay
+}
+
+// doWithDeadlineAwareRetry calls retry.DoWithRetry with the policy that is stopped by the ctx deadline
+// (see ErrRetryDeadlineExceeded).
+func doWithDeadlineAwareRetry(
+	ctx context.Context, policy retry.Policy, isRetryable retry.IsRetryable, notify backoff.Notify, fn retry.RetryableFunc,
+) error {
+	var b *deadlineAwareBackOff
+	deadlinePolicy := retry.PolicyFunc(func() backoff.BackOff {
+		b = &deadlineAwareBackOff{BackOff: policy.NewBackOff(), ctx: ctx}
+		return b
+	})
+	err := retry.DoWithRetry(ctx, deadlinePolicy, isRetryable, notify, fn)
+	if err != nil && b != nil && b.exceeded {
+		return wrapRetryDeadlineExceeded(err)
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/acronis/go-appkit/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoInTxRetryDeadline(t *testing.T) {
+	retryableErr := errors.New("retryable error")
+	policy := retry.NewConstantBackoffPolicy(200*time.Millisecond, 10)
+
+	tests := []struct {
+		name    string
+		options []DoInTxOption
+	}{
+		{name: "retry policy", options: []DoInTxOption{WithRetryPolicy(policy)}},
+		{name: "class retry policy", options: []DoInTxOption{WithClassRetryPolicy(ErrorClassOther, policy)}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer func() { _ = db.Close() }()
+			registry := NewRetryableRegistry()
+			registry.Register(db.Driver(), func(err error) bool { return errors.Is(err, retryableErr) })
+
+			// The first attempt fails immediately, the second one after 200ms,
+			// the third one would start after 400ms, so it isn't made.
+			for i := 0; i < 2; i++ {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+			attempts := 0
+			err = DoInTx(ctx, db, func(tx *sql.Tx) error {
+				attempts++
+				return retryableErr
+			}, append(tt.options, WithRetryableRegistry(registry))...)
+			require.ErrorIs(t, err, ErrRetryDeadlineExceeded)
+			require.ErrorIs(t, err, retryableErr)
+			require.NotErrorIs(t, err, context.DeadlineExceeded)
+			require.Equal(t, 2, attempts)
+			require.NoError(t, ctx.Err())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDoInTxRetryWithoutDeadline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	retryableErr := errors.New("retryable error")
+	registry := NewRetryableRegistry()
+	registry.Register(db.Driver(), func(err error) bool { return errors.Is(err, retryableErr) })
+
+	for i := 0; i < 3; i++ {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+	}
+	err = DoInTx(context.Background(), db, func(tx *sql.Tx) error {
+		return retryableErr
+	}, WithRetryPolicy(retry.NewConstantBackoffPolicy(time.Millisecond, 2)), WithRetryableRegistry(registry))
+	require.ErrorIs(t, err, retryableErr)
+	require.NotErrorIs(t, err, ErrRetryDeadlineExceeded)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
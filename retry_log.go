@@ -0,0 +1,55 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"time"
+
+	"github.com/acronis/go-appkit/httpserver/middleware"
+	"github.com/acronis/go-appkit/log"
+)
+
+// WithRetryLogger sets the logger that is used by DoInTx to log retries of the transaction.
+// Each retry is logged with the attempt number, the error class (see ClassifyError), the backoff delay,
+// the annotation of the transaction (see WithTxAnnotation) and the request IDs from ctx
+// (go-appkit's middleware.GetRequestIDFromContext and middleware.GetInternalRequestIDFromContext) in structured fields.
+func WithRetryLogger(logger log.FieldLogger) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.retryLogger = logger
+	}
+}
+
+// WithTxAnnotation sets the annotation of the transaction (e.g., the name of the business operation)
+// that is used for logging retries (see WithRetryLogger) and as a label of metrics (see WithTxMetrics and WithErrorMetrics).
+func WithTxAnnotation(annotation string) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.txAnnotation = annotation
+	}
+}
+
+func logTxRetry(ctx context.Context, opts *doInTxOptions, attempt int, err error, delay time.Duration) {
+	if opts.retryLogger == nil {
+		return
+	}
+	fields := []log.Field{
+		log.Int("attempt", attempt),
+		log.String("error_class", string(ClassifyError(err))),
+		log.Int64("backoff_ms", delay.Milliseconds()),
+		log.Error(err),
+	}
+	if opts.txAnnotation != "" {
+		fields = append(fields, log.String("annotation", opts.txAnnotation))
+	}
+	if requestID := middleware.GetRequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, log.String("request_id", requestID))
+	}
+	if internalRequestID := middleware.GetInternalRequestIDFromContext(ctx); internalRequestID != "" {
+		fields = append(fields, log.String("int_request_id", internalRequestID))
+	}
+	opts.retryLogger.Warn("transaction failed, retrying", fields...)
+}
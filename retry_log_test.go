@@ -0,0 +1,98 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/acronis/go-appkit/httpserver/middleware"
+	"github.com/acronis/go-appkit/log"
+	"github.com/acronis/go-appkit/log/logtest"
+	"github.com/acronis/go-appkit/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoInTxWithRetryLogger(t *testing.T) {
+	retryableErr := errors.New("retryable error")
+
+	requireRetryLogged := func(t *testing.T, entry logtest.RecordedEntry, attempt int) {
+		t.Helper()
+		require.Equal(t, log.LevelWarn, entry.Level)
+		for key, want := range map[string]interface{}{
+			"attempt":        int64(attempt),
+			"error_class":    []byte(ErrorClassOther),
+			"backoff_ms":     int64(1),
+			"annotation":     []byte("create-user"),
+			"request_id":     []byte("req-id"),
+			"int_request_id": []byte("int-req-id"),
+		} {
+			field, ok := entry.FindField(key)
+			require.True(t, ok, "field %s must be logged", key)
+			switch w := want.(type) {
+			case int64:
+				require.Equal(t, w, field.Int, "field %s", key)
+			case []byte:
+				require.Equal(t, string(w), string(field.Bytes), "field %s", key)
+			}
+		}
+	}
+
+	runInTx := func(t *testing.T, options ...DoInTxOption) *logtest.Recorder {
+		t.Helper()
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer func() { _ = db.Close() }()
+		UnregisterAllIsRetryableFuncs(db.Driver())
+		RegisterIsRetryableFunc(db.Driver(), func(err error) bool { return errors.Is(err, retryableErr) })
+		defer UnregisterAllIsRetryableFuncs(db.Driver())
+
+		for i := 0; i < 3; i++ {
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+		}
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		ctx := middleware.NewContextWithRequestID(context.Background(), "req-id")
+		ctx = middleware.NewContextWithInternalRequestID(ctx, "int-req-id")
+		logRecorder := logtest.NewRecorder()
+		attempts := 0
+		options = append(options, WithRetryLogger(logRecorder), WithTxAnnotation("create-user"))
+		require.NoError(t, DoInTx(ctx, db, func(tx *sql.Tx) error {
+			attempts++
+			if attempts <= 3 {
+				return retryableErr
+			}
+			return nil
+		}, options...))
+		require.NoError(t, mock.ExpectationsWereMet())
+		return logRecorder
+	}
+
+	policy := retry.NewConstantBackoffPolicy(time.Millisecond, 5)
+
+	t.Run("retry policy", func(t *testing.T) {
+		logRecorder := runInTx(t, WithRetryPolicy(policy))
+		require.Len(t, logRecorder.Entries(), 3)
+		for i, entry := range logRecorder.Entries() {
+			requireRetryLogged(t, entry, i+1)
+		}
+	})
+
+	t.Run("class retry policy", func(t *testing.T) {
+		logRecorder := runInTx(t, WithClassRetryPolicy(ErrorClassOther, policy))
+		require.Len(t, logRecorder.Entries(), 3)
+		for i, entry := range logRecorder.Entries() {
+			requireRetryLogged(t, entry, i+1)
+		}
+	})
+}
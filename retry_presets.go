@@ -0,0 +1,74 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/acronis/go-appkit/retry"
+	"github.com/cenkalti/backoff/v4"
+)
+
+// Ready-made retry policies for DoInTx (see WithRetryPolicy and WithClassRetryPolicy) and DoWithRetry,
+// so services don't copy-paste differing backoff parameters. Both use exponential backoff with full jitter
+// (see NewFullJitterBackoffPolicy): randomized delays spread retries of the transactions that conflicted
+// with each other, so they don't collide again in lockstep.
+var (
+	// DefaultDeadlockRetryPolicy is tuned for occasional deadlocks, serialization and lock timeout failures:
+	// up to 5 retries with delays growing from 10ms and capped at 1s.
+	DefaultDeadlockRetryPolicy retry.Policy = NewFullJitterBackoffPolicy(10*time.Millisecond, time.Second, 5)
+
+	// AggressiveRetryPolicy is tuned for short transactions under high contention (e.g., hot rows counters),
+	// where a conflict is expected to be resolved quickly: up to 10 retries with delays growing from 5ms
+	// and capped at 200ms.
+	AggressiveRetryPolicy retry.Policy = NewFullJitterBackoffPolicy(5*time.Millisecond, 200*time.Millisecond, 10)
+)
+
+// NewFullJitterBackoffPolicy returns the retry policy with exponential backoff and full jitter:
+// the delay before the n-th retry is random in [0, min(maxInterval, initialInterval*2^(n-1))].
+// Zero or negative maxInterval means no cap, zero or negative maxRetries means no limit of retries.
+func NewFullJitterBackoffPolicy(initialInterval, maxInterval time.Duration, maxRetries int) retry.Policy {
+	return retry.PolicyFunc(func() backoff.BackOff {
+		return &fullJitterBackOff{initialInterval: initialInterval, maxInterval: maxInterval, maxRetries: maxRetries}
+	})
+}
+
+type fullJitterBackOff struct {
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	maxRetries      int
+	retries         int
+}
+
+func (b *fullJitterBackOff) NextBackOff() time.Duration {
+	if b.maxRetries > 0 && b.retries >= b.maxRetries {
+		return backoff.Stop
+	}
+	b.retries++
+	return fullJitterDelay(b.initialInterval, b.maxInterval, b.retries)
+}
+
+func (b *fullJitterBackOff) Reset() {
+	b.retries = 0
+}
+
+// fullJitterDelay returns random delay in [0, min(maxDelay, minDelay*2^(attempt-1))].
+func fullJitterDelay(minDelay, maxDelay time.Duration, attempt int) time.Duration {
+	if attempt <= 0 || minDelay <= 0 {
+		return 0
+	}
+	delay := minDelay
+	for i := 1; i < attempt && (maxDelay <= 0 || delay < maxDelay) && delay <= math.MaxInt64/2; i++ {
+		delay *= 2
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1)) //nolint:gosec // Jitter doesn't require a cryptographically secure random.
+}
@@ -0,0 +1,45 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFullJitterBackoffPolicy(t *testing.T) {
+	b := NewFullJitterBackoffPolicy(10*time.Millisecond, 50*time.Millisecond, 5).NewBackOff()
+	for _, maxDelay := range []time.Duration{10, 20, 40, 50, 50} {
+		delay := b.NextBackOff()
+		require.GreaterOrEqual(t, delay, time.Duration(0))
+		require.LessOrEqual(t, delay, maxDelay*time.Millisecond)
+	}
+	require.Equal(t, backoff.Stop, b.NextBackOff())
+	b.Reset()
+	require.LessOrEqual(t, b.NextBackOff(), 10*time.Millisecond)
+
+	// No cap and no limit of retries.
+	b = NewFullJitterBackoffPolicy(time.Millisecond, 0, 0).NewBackOff()
+	for i := 0; i < 100; i++ {
+		require.NotEqual(t, backoff.Stop, b.NextBackOff())
+	}
+}
+
+func TestRetryPolicyPresets(t *testing.T) {
+	countRetries := func(b backoff.BackOff) int {
+		retries := 0
+		for b.NextBackOff() != backoff.Stop {
+			retries++
+		}
+		return retries
+	}
+	require.Equal(t, 5, countRetries(DefaultDeadlockRetryPolicy.NewBackOff()))
+	require.Equal(t, 10, countRetries(AggressiveRetryPolicy.NewBackOff()))
+}
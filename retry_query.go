@@ -0,0 +1,143 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/acronis/go-appkit/retry"
+)
+
+// Default values of the retry policy for non-transactional operations (see DoWithRetry).
+const (
+	DefaultQueryRetryInitialInterval = 50 * time.Millisecond
+	DefaultQueryRetryMaxAttempts     = 3
+)
+
+// QueryRetryMetricsCollector is an interface for collecting metrics about retries of SQL queries
+// executed outside of transactions (see ExecWithRetry and QueryWithRetry).
+type QueryRetryMetricsCollector interface {
+	IncQueryRetries(query string)
+}
+
+type queryRetryOptions struct {
+	retryPolicy       retry.Policy
+	retryableRegistry *RetryableRegistry
+	retryMetrics      QueryRetryMetricsCollector
+	queryLabel        func(query string) string
+}
+
+// QueryRetryOption is a functional option for DoWithRetry, ExecWithRetry and QueryWithRetry.
+type QueryRetryOption func(*queryRetryOptions)
+
+// WithQueryRetryPolicy sets the retry policy. By default, the exponential backoff policy
+// with DefaultQueryRetryInitialInterval and DefaultQueryRetryMaxAttempts is used.
+func WithQueryRetryPolicy(policy retry.Policy) QueryRetryOption {
+	return func(opts *queryRetryOptions) {
+		opts.retryPolicy = policy
+	}
+}
+
+// WithQueryRetryableRegistry sets the registry that is used to determine whether errors are retryable.
+// DefaultRetryableRegistry is used by default.
+func WithQueryRetryableRegistry(registry *RetryableRegistry) QueryRetryOption {
+	return func(opts *queryRetryOptions) {
+		opts.retryableRegistry = registry
+	}
+}
+
+// WithQueryRetryMetrics sets the collector (e.g., PrometheusMetrics) that is used to count retries.
+// Retries are labeled by the content of the leading comment of the query (see QueryLeadingComment and AnnotateQuery),
+// or by the label set by WithQueryRetryLabel.
+func WithQueryRetryMetrics(collector QueryRetryMetricsCollector) QueryRetryOption {
+	return func(opts *queryRetryOptions) {
+		opts.retryMetrics = collector
+	}
+}
+
+// WithQueryRetryLabel sets the label of retry metrics (see WithQueryRetryMetrics).
+// It's useful for DoWithRetry, where the query is not known.
+func WithQueryRetryLabel(label string) QueryRetryOption {
+	return func(opts *queryRetryOptions) {
+		opts.queryLabel = func(string) string { return label }
+	}
+}
+
+// DoWithRetry calls fn and retries it while it returns errors that are retryable for the driver of dbConn
+// (see RetryableRegistry), according to the retry policy (see WithQueryRetryPolicy).
+// It's the counterpart of DoInTx with WithRetryPolicy for operations that don't need a transaction
+// (e.g., single statements). fn must be idempotent, since it may be called several times.
+// As in DoInTx, retries are stopped by the ctx deadline (see ErrRetryDeadlineExceeded).
+func DoWithRetry(ctx context.Context, dbConn *sql.DB, fn func(ctx context.Context) error, options ...QueryRetryOption) error {
+	return doWithRetry(ctx, dbConn, "", fn, options)
+}
+
+// ExecWithRetry executes the statement outside of a transaction and retries it on retryable errors (see DoWithRetry).
+// The statement must be idempotent (e.g., UPDATE ... SET status = 'done' WHERE id = ?, but not an INSERT without a unique key),
+// since it may be applied even if the error is returned (e.g., the connection is lost after the commit).
+func ExecWithRetry(
+	ctx context.Context, dbConn *sql.DB, query string, args []interface{}, options ...QueryRetryOption,
+) (sql.Result, error) {
+	var result sql.Result
+	err := doWithRetry(ctx, dbConn, query, func(ctx context.Context) error {
+		var execErr error
+		result, execErr = dbConn.ExecContext(ctx, query, args...)
+		return execErr
+	}, options)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// QueryWithRetry executes the query outside of a transaction, calls fn with its rows and retries both
+// on retryable errors (see DoWithRetry), so errors occurred while the result set is read are retried as well.
+// Rows are closed after fn returns. fn may be called several times, so it must reset
+// the state accumulated by the previous attempt (e.g., truncate the result slice).
+func QueryWithRetry(
+	ctx context.Context, dbConn *sql.DB, query string, args []interface{}, fn func(rows *sql.Rows) error,
+	options ...QueryRetryOption,
+) error {
+	return doWithRetry(ctx, dbConn, query, func(ctx context.Context) (err error) {
+		rows, err := dbConn.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}()
+		if err = fn(rows); err != nil {
+			return err
+		}
+		return rows.Err()
+	}, options)
+}
+
+func doWithRetry(
+	ctx context.Context, dbConn *sql.DB, query string, fn func(ctx context.Context) error, options []QueryRetryOption,
+) error {
+	opts := queryRetryOptions{
+		retryPolicy:       retry.NewExponentialBackoffPolicy(DefaultQueryRetryInitialInterval, DefaultQueryRetryMaxAttempts),
+		retryableRegistry: DefaultRetryableRegistry,
+		queryLabel:        QueryLeadingComment,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	var notify func(err error, delay time.Duration)
+	if opts.retryMetrics != nil {
+		label := opts.queryLabel(query)
+		notify = func(error, time.Duration) {
+			opts.retryMetrics.IncQueryRetries(label)
+		}
+	}
+	return doWithDeadlineAwareRetry(ctx, opts.retryPolicy, opts.retryableRegistry.GetIsRetryable(dbConn.Driver()), notify, fn)
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/acronis/go-appkit/retry"
+	"github.com/stretchr/testify/require"
+)
+
+type countingRetryMetrics struct {
+	retries map[string]int
+}
+
+func (m *countingRetryMetrics) IncQueryRetries(query string) {
+	m.retries[query]++
+}
+
+func newRetryQueryTestDB(t *testing.T, retryableErr error) (*sql.DB, sqlmock.Sqlmock, []QueryRetryOption) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	registry := NewRetryableRegistry()
+	registry.Register(db.Driver(), func(err error) bool { return errors.Is(err, retryableErr) })
+	return db, mock, []QueryRetryOption{
+		WithQueryRetryableRegistry(registry),
+		WithQueryRetryPolicy(retry.NewConstantBackoffPolicy(time.Millisecond, 2)),
+	}
+}
+
+func TestExecWithRetry(t *testing.T) {
+	ctx := context.Background()
+	retryableErr := errors.New("retryable error")
+	db, mock, options := newRetryQueryTestDB(t, retryableErr)
+	metrics := &countingRetryMetrics{retries: map[string]int{}}
+	options = append(options, WithQueryRetryMetrics(metrics))
+
+	const query = "/* mark_done */ UPDATE jobs SET status = 'done' WHERE id = ?"
+	mock.ExpectExec("UPDATE jobs").WithArgs(1).WillReturnError(retryableErr)
+	mock.ExpectExec("UPDATE jobs").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	result, err := ExecWithRetry(ctx, db, query, []interface{}{1}, options...)
+	require.NoError(t, err)
+	affected, err := result.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), affected)
+	require.Equal(t, map[string]int{"mark_done": 1}, metrics.retries)
+
+	// Retries are exhausted (1 attempt + 2 retries).
+	for i := 0; i < 3; i++ {
+		mock.ExpectExec("UPDATE jobs").WithArgs(2).WillReturnError(retryableErr)
+	}
+	_, err = ExecWithRetry(ctx, db, query, []interface{}{2}, options...)
+	require.ErrorIs(t, err, retryableErr)
+	require.Equal(t, map[string]int{"mark_done": 3}, metrics.retries)
+
+	// Non-retryable error is returned immediately.
+	nonRetryableErr := errors.New("non-retryable error")
+	mock.ExpectExec("UPDATE jobs").WithArgs(3).WillReturnError(nonRetryableErr)
+	_, err = ExecWithRetry(ctx, db, query, []interface{}{3}, options...)
+	require.ErrorIs(t, err, nonRetryableErr)
+	require.Equal(t, map[string]int{"mark_done": 3}, metrics.retries)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryWithRetry(t *testing.T) {
+	ctx := context.Background()
+	retryableErr := errors.New("retryable error")
+	db, mock, options := newRetryQueryTestDB(t, retryableErr)
+
+	// The error occurred while the result set is read is retried as well.
+	mock.ExpectQuery("SELECT name FROM users").WillReturnError(retryableErr)
+	mock.ExpectQuery("SELECT name FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"name"}).AddRow("Alice").AddRow("Bob").RowError(1, retryableErr))
+	mock.ExpectQuery("SELECT name FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"name"}).AddRow("Alice").AddRow("Bob"))
+
+	var names []string
+	attempts := 0
+	err := QueryWithRetry(ctx, db, "SELECT name FROM users", nil, func(rows *sql.Rows) error {
+		attempts++
+		names = names[:0]
+		for rows.Next() {
+			var name string
+			if scanErr := rows.Scan(&name); scanErr != nil {
+				return scanErr
+			}
+			names = append(names, name)
+		}
+		return nil
+	}, options...)
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts)
+	require.Equal(t, []string{"Alice", "Bob"}, names)
+
+	// Error returned by fn is not retried unless it's retryable.
+	fnErr := errors.New("fn error")
+	mock.ExpectQuery("SELECT name FROM users").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Alice"))
+	err = QueryWithRetry(ctx, db, "SELECT name FROM users", nil, func(rows *sql.Rows) error {
+		return fnErr
+	}, options...)
+	require.ErrorIs(t, err, fnErr)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDoWithRetry(t *testing.T) {
+	retryableErr := errors.New("retryable error")
+	db, _, options := newRetryQueryTestDB(t, retryableErr)
+	metrics := &countingRetryMetrics{retries: map[string]int{}}
+	options = append(options, WithQueryRetryMetrics(metrics), WithQueryRetryLabel("sync"))
+
+	attempts := 0
+	require.NoError(t, DoWithRetry(context.Background(), db, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return retryableErr
+		}
+		return nil
+	}, options...))
+	require.Equal(t, 3, attempts)
+	require.Equal(t, map[string]int{"sync": 2}, metrics.retries)
+}
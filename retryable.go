@@ -7,34 +7,83 @@ Released under MIT license.
 package dbkit
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"reflect"
+	"sync"
 
 	"github.com/acronis/go-appkit/retry"
 )
 
-var retryableErrors = map[reflect.Type]retry.IsRetryable{}
+// RetryableRegistry holds functions that determine whether DB errors are retryable, per driver.
+// It may be created and populated per application and injected (e.g., with WithRetryableRegistry option for DoInTx)
+// instead of using the global DefaultRetryableRegistry.
+// RetryableRegistry is safe for concurrent use, so functions may be registered at runtime.
+type RetryableRegistry struct {
+	mu             sync.RWMutex
+	retryables     map[reflect.Type]retry.IsRetryable
+	dialectDrivers map[Dialect]reflect.Type
+}
+
+// NewRetryableRegistry creates a new empty RetryableRegistry.
+func NewRetryableRegistry() *RetryableRegistry {
+	return &RetryableRegistry{
+		retryables:     make(map[reflect.Type]retry.IsRetryable),
+		dialectDrivers: make(map[Dialect]reflect.Type),
+	}
+}
+
+// DefaultRetryableRegistry is the global registry used by RegisterIsRetryableFunc, GetIsRetryable
+// and UnregisterAllIsRetryableFuncs. Dialect subpackages (mysql, postgres, pgx, sqlite, mssql) register
+// their functions in it on initialization.
+var DefaultRetryableRegistry = NewRetryableRegistry()
 
 // GetIsRetryable returns a function that can tell for a given driver if error is retryable.
-func GetIsRetryable(d driver.Driver) retry.IsRetryable {
+// The returned function uses functions registered at the moment of the call.
+func (r *RetryableRegistry) GetIsRetryable(d driver.Driver) retry.IsRetryable {
 	t := reflect.TypeOf(d)
-	if r, ok := retryableErrors[t]; ok {
-		return r
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if isRetryable, ok := r.retryables[t]; ok {
+		return isRetryable
 	}
 	return isRetryableNoDriver
 }
 
-func isRetryableNoDriver(error) bool {
-	return false
+// GetIsRetryableForDialect returns a function that can tell for a given dialect if error is retryable.
+// The driver of the dialect should be registered with RegisterDialectDriver.
+func (r *RetryableRegistry) GetIsRetryableForDialect(dialect Dialect) retry.IsRetryable {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if t, ok := r.dialectDrivers[dialect]; ok {
+		if isRetryable, ok := r.retryables[t]; ok {
+			return isRetryable
+		}
+	}
+	return isRetryableNoDriver
+}
+
+// GetIsRetryableForDB returns a function that can tell for a given database if error is retryable.
+func (r *RetryableRegistry) GetIsRetryableForDB(db *sql.DB) retry.IsRetryable {
+	return r.GetIsRetryable(db.Driver())
+}
+
+// RegisterDialectDriver associates the dialect with the driver,
+// so IsRetryable functions registered for the driver may be resolved by the dialect.
+func (r *RetryableRegistry) RegisterDialectDriver(dialect Dialect, d driver.Driver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dialectDrivers[dialect] = reflect.TypeOf(d)
 }
 
-// RegisterIsRetryableFunc registers callback to determinate specific DB error is retryable or not.
+// Register registers callback to determinate specific DB error is retryable or not.
 // Several registered functions will be called one after another in FIFO order before some function returns true.
-// Note: this function is not concurrent-safe. Typical scenario: register all custom IsRetryable in module init()
-func RegisterIsRetryableFunc(d driver.Driver, retryable retry.IsRetryable) {
+func (r *RetryableRegistry) Register(d driver.Driver, retryable retry.IsRetryable) {
 	t := reflect.TypeOf(d)
-	prev, ok := retryableErrors[t]
-	retryableErrors[t] = func(e error) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prev, ok := r.retryables[t]
+	r.retryables[t] = func(e error) bool {
 		if ok && prev(e) {
 			return true
 		}
@@ -42,8 +91,51 @@ func RegisterIsRetryableFunc(d driver.Driver, retryable retry.IsRetryable) {
 	}
 }
 
-// UnregisterAllIsRetryableFuncs removes previously registered IsRetryable function for the given driver.
-func UnregisterAllIsRetryableFuncs(d driver.Driver) {
+// UnregisterAll removes previously registered IsRetryable functions for the given driver.
+func (r *RetryableRegistry) UnregisterAll(d driver.Driver) {
 	t := reflect.TypeOf(d)
-	delete(retryableErrors, t)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.retryables, t)
+}
+
+// GetIsRetryable returns a function that can tell for a given driver if error is retryable.
+// It uses DefaultRetryableRegistry.
+func GetIsRetryable(d driver.Driver) retry.IsRetryable {
+	return DefaultRetryableRegistry.GetIsRetryable(d)
+}
+
+// GetIsRetryableForDialect returns a function that can tell for a given dialect if error is retryable.
+// It uses DefaultRetryableRegistry, where dialect subpackages register their drivers on initialization.
+func GetIsRetryableForDialect(dialect Dialect) retry.IsRetryable {
+	return DefaultRetryableRegistry.GetIsRetryableForDialect(dialect)
+}
+
+// GetIsRetryableForDB returns a function that can tell for a given database if error is retryable.
+// It uses DefaultRetryableRegistry.
+func GetIsRetryableForDB(db *sql.DB) retry.IsRetryable {
+	return DefaultRetryableRegistry.GetIsRetryableForDB(db)
+}
+
+// RegisterDialectDriver associates the dialect with the driver in DefaultRetryableRegistry (see GetIsRetryableForDialect).
+func RegisterDialectDriver(dialect Dialect, d driver.Driver) {
+	DefaultRetryableRegistry.RegisterDialectDriver(dialect, d)
+}
+
+func isRetryableNoDriver(error) bool {
+	return false
+}
+
+// RegisterIsRetryableFunc registers callback to determinate specific DB error is retryable or not
+// in DefaultRetryableRegistry.
+// Several registered functions will be called one after another in FIFO order before some function returns true.
+// Typical scenario: register all custom IsRetryable in module init().
+func RegisterIsRetryableFunc(d driver.Driver, retryable retry.IsRetryable) {
+	DefaultRetryableRegistry.Register(d, retryable)
+}
+
+// UnregisterAllIsRetryableFuncs removes previously registered IsRetryable function for the given driver
+// from DefaultRetryableRegistry.
+func UnregisterAllIsRetryableFuncs(d driver.Driver) {
+	DefaultRetryableRegistry.UnregisterAll(d)
 }
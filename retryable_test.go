@@ -8,12 +8,17 @@ package dbkit
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/acronis/go-appkit/retry"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMultipleIsRetryError(t *testing.T) {
@@ -48,3 +53,53 @@ func TestMultipleIsRetryError(t *testing.T) {
 	})
 	assert.Equal(t, "", called)
 }
+
+func TestRetryableRegistry(t *testing.T) {
+	retryableError := errors.New("retryable error")
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	registry := NewRetryableRegistry()
+	require.False(t, registry.GetIsRetryable(db.Driver())(retryableError))
+
+	// Registration is safe for concurrent use.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			registry.Register(db.Driver(), func(err error) bool {
+				return errors.Is(err, retryableError)
+			})
+			_ = registry.GetIsRetryable(db.Driver())(retryableError)
+		}()
+	}
+	wg.Wait()
+	require.True(t, registry.GetIsRetryable(db.Driver())(retryableError))
+	require.False(t, registry.GetIsRetryable(db.Driver())(fmt.Errorf("fake error")))
+
+	// The registry is independent of the global one.
+	UnregisterAllIsRetryableFuncs(db.Driver())
+	require.False(t, GetIsRetryable(db.Driver())(retryableError))
+
+	// The registry is injected in DoInTx.
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	var attempts int
+	require.NoError(t, DoInTx(context.Background(), db, func(tx *sql.Tx) error {
+		attempts++
+		if attempts < 2 {
+			return retryableError
+		}
+		return nil
+	}, WithRetryPolicy(retry.NewConstantBackoffPolicy(time.Millisecond, 3)), WithRetryableRegistry(registry)))
+	require.Equal(t, 2, attempts)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	registry.UnregisterAll(db.Driver())
+	require.False(t, registry.GetIsRetryable(db.Driver())(retryableError))
+}
@@ -0,0 +1,231 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+
+	"github.com/acronis/go-dbkit/internal/driverwrap"
+)
+
+// RowsMetricsCollector is an interface for collecting metrics about the number of rows
+// returned and affected by SQL queries.
+type RowsMetricsCollector interface {
+	ObserveQueryRowsReturned(query string, rows int)
+	ObserveQueryRowsAffected(query string, rows int64)
+}
+
+type rowsMetricsOptions struct {
+	queryLabel func(query string) string
+}
+
+// RowsMetricsOption is a functional option for NewRowsMetricsConnector.
+type RowsMetricsOption func(*rowsMetricsOptions)
+
+// WithRowsMetricsQueryLabel sets the function that returns the query label of metrics for the SQL query
+// (e.g., the annotation parsed by dbrutil.ParseAnnotationInQuery). Queries with empty label are not observed.
+// By default, the content of the first comment at the beginning of the query is used (see QueryLeadingComment).
+func WithRowsMetricsQueryLabel(fn func(query string) string) RowsMetricsOption {
+	return func(opts *rowsMetricsOptions) {
+		opts.queryLabel = fn
+	}
+}
+
+// QueryLeadingComment returns the trimmed content of the first /* ... */ comment at the beginning of the query
+// or empty string if the query doesn't start with a comment.
+func QueryLeadingComment(query string) string {
+	query = strings.TrimSpace(query)
+	if !strings.HasPrefix(query, "/*") {
+		return ""
+	}
+	end := strings.Index(query, "*/")
+	if end == -1 {
+		return ""
+	}
+	return strings.TrimSpace(query[2:end])
+}
+
+// RowsMetricsConnector implements driver.Connector and observes the number of rows returned by queries
+// (counted while the result set is read) and affected by statements (see driver.Result.RowsAffected) per query label.
+// Queries that suddenly return a lot of rows is a frequent regression that duration metrics alone don't reveal.
+// Transactions are passed to the driver as is, statements executed in them are observed as well.
+type RowsMetricsConnector struct {
+	connector driver.Connector
+	collector RowsMetricsCollector
+	opts      rowsMetricsOptions
+}
+
+var _ driver.Connector = (*RowsMetricsConnector)(nil)
+
+// NewRowsMetricsConnector wraps the passed connector with observing the number of returned and affected rows.
+func NewRowsMetricsConnector(
+	connector driver.Connector, collector RowsMetricsCollector, options ...RowsMetricsOption,
+) *RowsMetricsConnector {
+	opts := rowsMetricsOptions{queryLabel: QueryLeadingComment}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return &RowsMetricsConnector{connector: connector, collector: collector, opts: opts}
+}
+
+// OpenWithRowsMetrics opens a new database connection pool using the provided configuration (see Open)
+// with observing the number of returned and affected rows (see RowsMetricsConnector).
+func OpenWithRowsMetrics(
+	cfg *Config, ping bool, collector RowsMetricsCollector, options ...RowsMetricsOption,
+) (*sql.DB, error) {
+	connector, err := OpenConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(NewRowsMetricsConnector(connector, collector, options...))
+	return db, InitOpenedDB(db, cfg, ping)
+}
+
+// Connect establishes a new connection.
+func (c *RowsMetricsConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rowsMetricsConn{Conn: driverwrap.Conn{Conn: conn}, connector: c}, nil
+}
+
+// Driver returns the underlying driver.
+func (c *RowsMetricsConnector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+// Unwrap returns the wrapped connector.
+func (c *RowsMetricsConnector) Unwrap() driver.Connector {
+	return c.connector
+}
+
+func (c *RowsMetricsConnector) wrapRows(query string, rows driver.Rows) driver.Rows {
+	label := c.opts.queryLabel(query)
+	if label == "" {
+		return rows
+	}
+	return &rowsMetricsRows{Rows: driverwrap.Rows{Rows: rows}, label: label, collector: c.collector}
+}
+
+func (c *RowsMetricsConnector) observeResult(query string, result driver.Result) {
+	label := c.opts.queryLabel(query)
+	if label == "" {
+		return
+	}
+	if affected, err := result.RowsAffected(); err == nil {
+		c.collector.ObserveQueryRowsAffected(label, affected)
+	}
+}
+
+// rowsMetricsConn wraps driver.Conn and wraps the returned rows, results and prepared statements
+// for observing the number of rows.
+type rowsMetricsConn struct {
+	driverwrap.Conn
+	connector *RowsMetricsConnector
+}
+
+func (c *rowsMetricsConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &rowsMetricsStmt{Stmt: driverwrap.Stmt{Stmt: stmt, Conn: c.Conn}, conn: c, query: query}, nil
+}
+
+func (c *rowsMetricsConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &rowsMetricsStmt{Stmt: driverwrap.Stmt{Stmt: stmt, Conn: c.Conn}, conn: c, query: query}, nil
+}
+
+func (c *rowsMetricsConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.Conn.QueryContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return c.connector.wrapRows(query, rows), nil
+}
+
+func (c *rowsMetricsConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result, err := c.Conn.ExecContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	c.connector.observeResult(query, result)
+	return result, nil
+}
+
+type rowsMetricsStmt struct {
+	driverwrap.Stmt
+	conn  *rowsMetricsConn
+	query string
+}
+
+func (s *rowsMetricsStmt) Exec(args []driver.Value) (driver.Result, error) {
+	result, err := s.Stmt.Exec(args)
+	if err != nil {
+		return nil, err
+	}
+	s.conn.connector.observeResult(s.query, result)
+	return result, nil
+}
+
+func (s *rowsMetricsStmt) Query(args []driver.Value) (driver.Rows, error) {
+	rows, err := s.Stmt.Query(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.conn.connector.wrapRows(s.query, rows), nil
+}
+
+func (s *rowsMetricsStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	result, err := s.Stmt.ExecContext(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	s.conn.connector.observeResult(s.query, result)
+	return result, nil
+}
+
+func (s *rowsMetricsStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := s.Stmt.QueryContext(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.conn.connector.wrapRows(s.query, rows), nil
+}
+
+// rowsMetricsRows counts rows of the result set and observes their number when it's closed.
+type rowsMetricsRows struct {
+	driverwrap.Rows
+	label     string
+	collector RowsMetricsCollector
+	count     int
+	closed    bool
+}
+
+func (r *rowsMetricsRows) Close() error {
+	if !r.closed {
+		r.closed = true
+		r.collector.ObserveQueryRowsReturned(r.label, r.count)
+	}
+	return r.Rows.Close()
+}
+
+func (r *rowsMetricsRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err == nil {
+		r.count++
+	}
+	return err
+}
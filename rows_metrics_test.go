@@ -0,0 +1,93 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/acronis/go-appkit/testutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLeadingComment(t *testing.T) {
+	require.Equal(t, "list_users", QueryLeadingComment(" /* list_users */ SELECT * FROM users"))
+	require.Equal(t, "", QueryLeadingComment("SELECT * FROM users /* list_users */"))
+	require.Equal(t, "", QueryLeadingComment("/* unterminated SELECT 1"))
+}
+
+func TestOpenWithRowsMetrics(t *testing.T) {
+	ctx := context.Background()
+	cfg := &Config{Dialect: DialectSQLite, SQLite: SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")}, MaxIdleConns: 1}
+	metrics := NewPrometheusMetricsWithOpts(PrometheusMetricsOpts{EnableQueryRowsMetrics: true})
+	collector := &recordingRowsMetricsCollector{
+		RowsMetricsCollector: metrics, returned: map[string]int{}, affected: map[string]int64{}}
+	db, err := OpenWithRowsMetrics(cfg, true, collector)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `/* insert_users */ INSERT INTO users (name) VALUES ('Alice'), ('Bob'), ('Sam')`)
+	require.NoError(t, err)
+
+	countRows := func(query string, args ...interface{}) {
+		t.Helper()
+		rows, queryErr := db.QueryContext(ctx, query, args...)
+		require.NoError(t, queryErr)
+		for rows.Next() {
+		}
+		require.NoError(t, rows.Err())
+		require.NoError(t, rows.Close())
+	}
+	countRows(`/* list_users */ SELECT id, name FROM users`)
+	countRows(`SELECT id, name FROM users`) // Not annotated, not observed.
+
+	// Statements executed in the transaction and prepared ones are observed as well.
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, `/* rename_users */ UPDATE users SET name = 'Robert' WHERE name IN (?, ?)`, "Alice", "Bob")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	stmt, err := db.PrepareContext(ctx, `/* get_user */ SELECT name FROM users WHERE id = ?`)
+	require.NoError(t, err)
+	var name string
+	require.NoError(t, stmt.QueryRowContext(ctx, 3).Scan(&name))
+	require.Equal(t, "Sam", name)
+	require.NoError(t, stmt.Close())
+
+	require.Equal(t, map[string]int64{"insert_users": 3, "rename_users": 2}, collector.affected)
+	require.Equal(t, map[string]int{"list_users": 3, "get_user": 1}, collector.returned)
+	testutil.RequireSamplesCountInHistogram(t,
+		metrics.QueryRowsReturned.With(prometheus.Labels{PrometheusMetricsLabelQuery: "list_users"}).(prometheus.Histogram), 1)
+}
+
+type recordingRowsMetricsCollector struct {
+	RowsMetricsCollector
+	returned map[string]int
+	affected map[string]int64
+}
+
+func (c *recordingRowsMetricsCollector) ObserveQueryRowsReturned(query string, rows int) {
+	c.RowsMetricsCollector.ObserveQueryRowsReturned(query, rows)
+	c.returned[query] += rows
+}
+
+func (c *recordingRowsMetricsCollector) ObserveQueryRowsAffected(query string, rows int64) {
+	c.RowsMetricsCollector.ObserveQueryRowsAffected(query, rows)
+	c.affected[query] += rows
+}
+
+func TestPrometheusMetrics_QueryRowsMetricsDisabled(t *testing.T) {
+	metrics := NewPrometheusMetrics()
+	require.Nil(t, metrics.QueryRowsReturned)
+	metrics.ObserveQueryRowsReturned("list_users", 10)
+	metrics.ObserveQueryRowsAffected("delete_users", 10)
+	require.Len(t, metrics.AllMetrics(), 13)
+}
@@ -0,0 +1,218 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
+)
+
+// PostgresCompatDriverName is the name of the SQLite driver that translates common Postgres-specific syntax
+// (see TranslatePostgresQuery) before executing queries.
+// It allows unit-test suites to run a useful subset of production Postgres queries against SQLite (e.g., in-memory)
+// without maintaining duplicate SQL. It's not intended to be used in production.
+//
+//	dbConn, err := sql.Open(sqlite.PostgresCompatDriverName, ":memory:")
+const PostgresCompatDriverName = "sqlite3_pgcompat"
+
+// nolint
+func init() {
+	sql.Register(PostgresCompatDriverName, &PostgresCompatDriver{})
+}
+
+// PostgresCompatDriver is the SQLite driver that translates common Postgres-specific syntax before executing queries.
+// Additionally, it registers now() and gen_random_uuid() SQL functions.
+// now() returns the current UTC time in the same format go-sqlite3 uses for storing time.Time values,
+// so time values should be passed in UTC to be comparable with it.
+type PostgresCompatDriver struct {
+	sqlite3.SQLiteDriver
+}
+
+// Open opens a new connection to the SQLite database.
+func (d *PostgresCompatDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	sqliteConn, ok := conn.(*sqlite3.SQLiteConn)
+	if !ok {
+		_ = conn.Close()
+		return nil, fmt.Errorf("unexpected sqlite connection type %T", conn)
+	}
+	if err = registerPostgresCompatFuncs(sqliteConn); err != nil {
+		_ = sqliteConn.Close()
+		return nil, err
+	}
+	return &postgresCompatConn{sqliteConn}, nil
+}
+
+func registerPostgresCompatFuncs(conn *sqlite3.SQLiteConn) error {
+	if err := conn.RegisterFunc("now", func() string {
+		return time.Now().UTC().Format(sqlite3.SQLiteTimestampFormats[0])
+	}, false); err != nil {
+		return fmt.Errorf("register now() function: %w", err)
+	}
+	if err := conn.RegisterFunc("gen_random_uuid", uuid.NewString, false); err != nil {
+		return fmt.Errorf("register gen_random_uuid() function: %w", err)
+	}
+	return nil
+}
+
+// postgresCompatConn translates queries before passing them to the wrapped SQLite connection.
+type postgresCompatConn struct {
+	*sqlite3.SQLiteConn
+}
+
+// Unwrap returns the wrapped SQLite connection (e.g., for using it in sql.Conn.Raw).
+func (c *postgresCompatConn) Unwrap() driver.Conn {
+	return c.SQLiteConn
+}
+
+func (c *postgresCompatConn) Prepare(query string) (driver.Stmt, error) {
+	return c.SQLiteConn.Prepare(TranslatePostgresQuery(query))
+}
+
+func (c *postgresCompatConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.SQLiteConn.PrepareContext(ctx, TranslatePostgresQuery(query))
+}
+
+func (c *postgresCompatConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	return c.SQLiteConn.Exec(TranslatePostgresQuery(query), args) //nolint:staticcheck
+}
+
+func (c *postgresCompatConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.SQLiteConn.ExecContext(ctx, TranslatePostgresQuery(query), args)
+}
+
+func (c *postgresCompatConn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	return c.SQLiteConn.Query(TranslatePostgresQuery(query), args) //nolint:staticcheck
+}
+
+func (c *postgresCompatConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.SQLiteConn.QueryContext(ctx, TranslatePostgresQuery(query), args)
+}
+
+// postgresKeywordReplacements contains Postgres keywords and types that are replaced with their SQLite equivalents.
+var postgresKeywordReplacements = map[string]string{
+	"ILIKE":       "LIKE", // LIKE is case-insensitive for ASCII characters in SQLite.
+	"SERIAL":      "INTEGER",
+	"BIGSERIAL":   "INTEGER",
+	"SMALLSERIAL": "INTEGER",
+	"BYTEA":       "BLOB",
+}
+
+// TranslatePostgresQuery translates common Postgres-specific syntax of the query into SQLite one:
+//   - $N placeholders are replaced with ?N;
+//   - type casts (e.g., "::text", "::varchar(40)", "::int[]") are removed;
+//   - ILIKE is replaced with LIKE;
+//   - SERIAL, BIGSERIAL and SMALLSERIAL types are replaced with INTEGER (INTEGER PRIMARY KEY is auto-incremented in SQLite);
+//   - BYTEA type is replaced with BLOB.
+//
+// String literals, quoted identifiers and comments are kept as is.
+// Constructs like RETURNING, ON CONFLICT, TRUE/FALSE and CURRENT_TIMESTAMP are natively supported by SQLite.
+// Others (e.g., intervals arithmetic) are not translated, so such queries still require dialect-specific SQL.
+func TranslatePostgresQuery(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := skipQuoted(query, i, c)
+			b.WriteString(query[i:end])
+			i = end
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end == -1 {
+				end = len(query) - i
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end == -1 {
+				end = len(query) - i
+			} else {
+				end += 4
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			b.WriteByte('?')
+			i++
+		case c == ':' && strings.HasPrefix(query[i:], "::"):
+			i = skipTypeCast(query, i+2)
+		case isIdentStart(c) && (i == 0 || !isIdentChar(query[i-1])):
+			end := i + 1
+			for end < len(query) && isIdentChar(query[end]) {
+				end++
+			}
+			word := query[i:end]
+			if replacement, ok := postgresKeywordReplacements[strings.ToUpper(word)]; ok {
+				word = replacement
+			}
+			b.WriteString(word)
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// skipQuoted returns the position after the string literal or quoted identifier started at the start position.
+// Doubled quote characters are treated as escaped ones.
+func skipQuoted(query string, start int, quote byte) int {
+	for i := start + 1; i < len(query); i++ {
+		if query[i] != quote {
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(query)
+}
+
+// skipTypeCast returns the position after the type name (with optional modifiers and array brackets) of the cast.
+func skipTypeCast(query string, start int) int {
+	i := start
+	for i < len(query) && (isIdentChar(query[i]) || query[i] == '.') {
+		i++
+	}
+	if i < len(query) && query[i] == '(' {
+		if end := strings.IndexByte(query[i:], ')'); end != -1 {
+			i += end + 1
+		}
+	}
+	for strings.HasPrefix(query[i:], "[]") {
+		i += 2
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '$'
+}
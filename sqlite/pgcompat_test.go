@@ -0,0 +1,108 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+func TestTranslatePostgresQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "placeholders",
+			query: "SELECT * FROM users WHERE id = $1 AND tenant_id = $2",
+			want:  "SELECT * FROM users WHERE id = ?1 AND tenant_id = ?2",
+		},
+		{
+			name:  "type casts",
+			query: "SELECT $1::text, id::varchar(40), ids::int[], ts::pg_catalog.timestamp FROM t",
+			want:  "SELECT ?1, id, ids, ts FROM t",
+		},
+		{
+			name:  "keywords and types",
+			query: "CREATE TABLE t (id bigserial PRIMARY KEY, data BYTEA); SELECT * FROM t WHERE name ILIKE $1",
+			want:  "CREATE TABLE t (id INTEGER PRIMARY KEY, data BLOB); SELECT * FROM t WHERE name LIKE ?1",
+		},
+		{
+			name:  "string literals, quoted identifiers and comments are kept",
+			query: `SELECT 'it''s $1::text ILIKE', "serial" FROM t -- $1::int` + "\n/* ILIKE */ WHERE x = $1",
+			want:  `SELECT 'it''s $1::text ILIKE', "serial" FROM t -- $1::int` + "\n/* ILIKE */ WHERE x = ?1",
+		},
+		{
+			name:  "identifiers containing keywords are kept",
+			query: "SELECT serial_number, ilike_count FROM t",
+			want:  "SELECT serial_number, ilike_count FROM t",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, TranslatePostgresQuery(tt.query))
+		})
+	}
+}
+
+func TestPostgresCompatDriver(t *testing.T) {
+	dbConn, err := sql.Open(PostgresCompatDriverName, ":memory:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, dbConn.Close()) }()
+	dbConn.SetMaxOpenConns(1)
+
+	_, err = dbConn.Exec(`CREATE TABLE users (
+		id BIGSERIAL PRIMARY KEY, uuid TEXT NOT NULL DEFAULT (gen_random_uuid()), name TEXT NOT NULL,
+		active BOOLEAN NOT NULL DEFAULT TRUE, created_at TIMESTAMP NOT NULL)`)
+	require.NoError(t, err)
+
+	createdAt := time.Now().UTC().Add(-time.Hour)
+	var userID int64
+	require.NoError(t, dbConn.QueryRow(
+		`INSERT INTO users (name, created_at) VALUES ($1::text, $2) RETURNING id`, "Alice", createdAt).Scan(&userID))
+	require.Equal(t, int64(1), userID)
+
+	var name, userUUID string
+	var active bool
+	require.NoError(t, dbConn.QueryRow(
+		`SELECT name, uuid, active FROM users WHERE name ILIKE $1 AND created_at < NOW() AND active = TRUE`, "alice",
+	).Scan(&name, &userUUID, &active))
+	require.Equal(t, "Alice", name)
+	require.Len(t, userUUID, 36)
+	require.True(t, active)
+
+	// Prepared statements are translated too.
+	stmt, err := dbConn.Prepare(`UPDATE users SET active = FALSE WHERE id = $1`)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, stmt.Close()) }()
+	res, err := stmt.Exec(userID)
+	require.NoError(t, err)
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), affected)
+}
+
+func TestPostgresCompatDriver_RawDriverConn(t *testing.T) {
+	dbConn, err := sql.Open(PostgresCompatDriverName, ":memory:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, dbConn.Close()) }()
+
+	require.NoError(t, dbkit.RawDriverConn(context.Background(), dbConn, func(driverConn interface{}) error {
+		sqliteConn, ok := driverConn.(*sqlite3.SQLiteConn)
+		require.True(t, ok, "unexpected driver connection type %T", driverConn)
+		require.True(t, sqliteConn.AutoCommit())
+		return nil
+	}))
+}
@@ -21,16 +21,52 @@ import (
 
 // nolint
 func init() {
-	dbkit.RegisterIsRetryableFunc(&sqlite3.SQLiteDriver{}, func(err error) bool {
-		var sqliteErr sqlite3.Error
-		if errors.As(err, &sqliteErr) {
-			switch sqliteErr.Code {
-			case sqlite3.ErrLocked, sqlite3.ErrBusy:
-				return true
-			}
+	dbkit.RegisterDialectDriver(dbkit.DialectSQLite, &sqlite3.SQLiteDriver{})
+	dbkit.RegisterIsRetryableFunc(&sqlite3.SQLiteDriver{}, isRetryable)
+	dbkit.RegisterIsRetryableFunc(&PostgresCompatDriver{}, isRetryable)
+	dbkit.RegisterErrorClassifier(classifyError)
+	dbkit.RegisterConstraintViolationClassifier(classifyConstraintViolation)
+}
+
+func classifyConstraintViolation(err error) dbkit.ConstraintViolation {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return dbkit.ConstraintViolationUnique
+		case sqlite3.ErrConstraintForeignKey:
+			return dbkit.ConstraintViolationForeignKey
+		case sqlite3.ErrConstraintNotNull:
+			return dbkit.ConstraintViolationNotNull
+		case sqlite3.ErrConstraintCheck:
+			return dbkit.ConstraintViolationCheck
+		}
+	}
+	return dbkit.ConstraintViolationNone
+}
+
+func classifyError(err error) dbkit.ErrorClass {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code {
+		case sqlite3.ErrLocked, sqlite3.ErrBusy:
+			return dbkit.ErrorClassLockTimeout
+		case sqlite3.ErrConstraint:
+			return dbkit.ErrorClassConstraintViolation
 		}
-		return false
-	})
+	}
+	return dbkit.ErrorClassNone
+}
+
+func isRetryable(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code {
+		case sqlite3.ErrLocked, sqlite3.ErrBusy:
+			return true
+		}
+	}
+	return false
 }
 
 // CheckSQLiteError checks if the passed error relates to SQLite,
@@ -11,6 +11,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/drivertest"
 )
 
 const createFooTable = `create table foo (id integer not null primary key, name text)`
@@ -138,6 +140,31 @@ func TestSqliteIsRetryable(t *testing.T) {
 	require.True(t, isRetryable(fmt.Errorf("wrapped error: %w", sqlite3.Error{
 		Code: sqlite3.ErrBusy,
 	})))
+
+	require.True(t, dbkit.GetIsRetryableForDialect(dbkit.DialectSQLite)(sqlite3.Error{Code: sqlite3.ErrBusy}))
+	require.False(t, dbkit.GetIsRetryableForDialect("unknown")(sqlite3.Error{Code: sqlite3.ErrBusy}))
+
+	dbConn, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, dbConn.Close()) }()
+	require.True(t, dbkit.GetIsRetryableForDB(dbConn)(sqlite3.Error{Code: sqlite3.ErrBusy}))
+}
+
+func TestClassifyError(t *testing.T) {
+	require.Equal(t, dbkit.ErrorClassLockTimeout, dbkit.ClassifyError(sqlite3.Error{Code: sqlite3.ErrBusy}))
+	require.Equal(t, dbkit.ErrorClassConstraintViolation, dbkit.ClassifyError(fmt.Errorf("wrapped error: %w",
+		sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique})))
+	require.Equal(t, dbkit.ErrorClassOther, dbkit.ClassifyError(sqlite3.Error{Code: sqlite3.ErrError}))
+
+	require.True(t, dbkit.IsUniqueViolation(fmt.Errorf("wrapped error: %w",
+		sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique})))
+	require.True(t, dbkit.IsUniqueViolation(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintPrimaryKey}))
+	require.True(t, dbkit.IsForeignKeyViolation(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintForeignKey}))
+	require.Equal(t, dbkit.ConstraintViolationNotNull, dbkit.ClassifyConstraintViolation(
+		sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintNotNull}))
+	require.Equal(t, dbkit.ConstraintViolationCheck, dbkit.ClassifyConstraintViolation(
+		sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintCheck}))
+	require.False(t, dbkit.IsUniqueViolation(sqlite3.Error{Code: sqlite3.ErrError}))
 }
 
 func TestCheckSQLiteError(t *testing.T) {
@@ -177,3 +204,10 @@ func execInTx(ctx context.Context, dbConn *sql.DB, stmt string) error {
 	}
 	return tr.Commit()
 }
+
+func TestConformance(t *testing.T) {
+	dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "conformance.db")+"?_busy_timeout=5000")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, dbConn.Close()) }()
+	drivertest.RunConformanceTests(t, dbConn, dbkit.DialectSQLite)
+}
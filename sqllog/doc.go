@@ -0,0 +1,10 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package sqllog provides the database/sql driver wrapper that logs every executed SQL statement
+// with its duration, the number of returned or affected rows, the ID of the enclosing transaction and the error.
+// It works with plain *sql.DB (and libraries built on top of it), where dbr's event receivers are not available.
+package sqllog
@@ -0,0 +1,325 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package sqllog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/acronis/go-appkit/httpserver/middleware"
+	"github.com/acronis/go-appkit/log"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/internal/driverwrap"
+)
+
+// Default levels of logged statements.
+const (
+	DefaultLevel      = log.LevelDebug
+	DefaultErrorLevel = log.LevelError
+)
+
+// Pseudo statements that are logged for transactions.
+const (
+	StatementBegin    = "BEGIN"
+	StatementCommit   = "COMMIT"
+	StatementRollback = "ROLLBACK"
+)
+
+type logOptions struct {
+	level          log.Level
+	errorLevel     log.Level
+	slowThreshold  time.Duration
+	slowLevel      log.Level
+	queryFormatter func(query string) string
+}
+
+// Option is a functional option for NewConnector and Open.
+type Option func(*logOptions)
+
+// WithLevel sets the level of successfully executed statements (DefaultLevel by default).
+func WithLevel(level log.Level) Option {
+	return func(opts *logOptions) {
+		opts.level = level
+	}
+}
+
+// WithErrorLevel sets the level of failed statements (DefaultErrorLevel by default).
+func WithErrorLevel(level log.Level) Option {
+	return func(opts *logOptions) {
+		opts.errorLevel = level
+	}
+}
+
+// WithSlowThreshold makes successfully executed statements that take at least the threshold be logged with the level
+// (e.g., log.LevelWarn, while other statements are logged with log.LevelDebug). Zero threshold disables it.
+func WithSlowThreshold(threshold time.Duration, level log.Level) Option {
+	return func(opts *logOptions) {
+		opts.slowThreshold = threshold
+		opts.slowLevel = level
+	}
+}
+
+// WithQueryFormatter sets the function that returns the query text to be logged
+// (e.g., dbrutil.NormalizeQuery for masking literal values). If it returns empty string, the query is not logged at all.
+// By default, the query is logged as is.
+func WithQueryFormatter(fn func(query string) string) Option {
+	return func(opts *logOptions) {
+		opts.queryFormatter = fn
+	}
+}
+
+// Connector implements driver.Connector and logs every statement executed via its connections:
+// queries (with the number of returned rows), execs (with the number of affected rows) and transactions
+// (as the BEGIN, COMMIT and ROLLBACK pseudo statements).
+// Each entry contains the duration, the ID of the transaction (statements executed in it have the same ID)
+// and the request IDs from the context (see go-appkit's middleware.GetRequestIDFromContext).
+// The duration of a query includes reading its result set, since the entry is logged when the rows are closed.
+// Preparing statements is not logged, executions of prepared statements are.
+type Connector struct {
+	connector driver.Connector
+	logger    log.FieldLogger
+	opts      logOptions
+	lastTxID  atomic.Uint64
+}
+
+var _ driver.Connector = (*Connector)(nil)
+
+// NewConnector wraps the passed connector with logging statements.
+func NewConnector(connector driver.Connector, logger log.FieldLogger, options ...Option) *Connector {
+	opts := logOptions{level: DefaultLevel, errorLevel: DefaultErrorLevel}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return &Connector{connector: connector, logger: logger, opts: opts}
+}
+
+// Open opens a new database connection pool using the provided configuration (see dbkit.Open)
+// with logging statements (see Connector).
+func Open(cfg *dbkit.Config, ping bool, logger log.FieldLogger, options ...Option) (*sql.DB, error) {
+	connector, err := dbkit.OpenConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(NewConnector(connector, logger, options...))
+	return db, dbkit.InitOpenedDB(db, cfg, ping)
+}
+
+// Connect establishes a new connection.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	dc, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: driverwrap.Conn{Conn: dc}, connector: c}, nil
+}
+
+// Driver returns the underlying driver.
+func (c *Connector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+// Unwrap returns the wrapped connector.
+func (c *Connector) Unwrap() driver.Connector {
+	return c.connector
+}
+
+func (c *Connector) logStatement(
+	ctx context.Context, query string, txID uint64, startTime time.Time, err error, fields ...log.Field,
+) {
+	duration := time.Since(startTime)
+	level, msg := c.opts.level, "SQL statement executed"
+	switch {
+	case err != nil:
+		level, msg = c.opts.errorLevel, "SQL statement failed"
+		fields = append(fields, log.Error(err))
+	case c.opts.slowThreshold > 0 && duration >= c.opts.slowThreshold:
+		level = c.opts.slowLevel
+	}
+	if c.opts.queryFormatter != nil {
+		query = c.opts.queryFormatter(query)
+	}
+	if query != "" {
+		fields = append(fields, log.String("query", query))
+	}
+	fields = append(fields, log.Int64("duration_ms", duration.Milliseconds()))
+	if txID != 0 {
+		fields = append(fields, log.Uint64("tx_id", txID))
+	}
+	if requestID := middleware.GetRequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, log.String("request_id", requestID))
+	}
+	if internalRequestID := middleware.GetInternalRequestIDFromContext(ctx); internalRequestID != "" {
+		fields = append(fields, log.String("int_request_id", internalRequestID))
+	}
+	c.logger.AtLevel(level, func(logFunc log.LogFunc) {
+		logFunc(msg, fields...)
+	})
+}
+
+func (c *Connector) logResult(
+	ctx context.Context, query string, txID uint64, startTime time.Time, result driver.Result, err error,
+) {
+	if errors.Is(err, driver.ErrSkip) {
+		return // database/sql executes the statement via the prepared statement, it will be logged then.
+	}
+	if err != nil {
+		c.logStatement(ctx, query, txID, startTime, err)
+		return
+	}
+	if affected, affectedErr := result.RowsAffected(); affectedErr == nil {
+		c.logStatement(ctx, query, txID, startTime, nil, log.Int64("rows_affected", affected))
+		return
+	}
+	c.logStatement(ctx, query, txID, startTime, nil)
+}
+
+func (c *Connector) wrapRows(
+	ctx context.Context, query string, txID uint64, startTime time.Time, dr driver.Rows, err error,
+) (driver.Rows, error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return nil, err // database/sql executes the query via the prepared statement, it will be logged then.
+	}
+	if err != nil {
+		c.logStatement(ctx, query, txID, startTime, err)
+		return nil, err
+	}
+	return &rows{Rows: driverwrap.Rows{Rows: dr}, ctx: ctx, connector: c, query: query, txID: txID, startTime: startTime}, nil
+}
+
+// conn wraps driver.Conn and logs statements executed directly or via prepared statements and transactions.
+// The ID of the current transaction is stored in it, since database/sql doesn't use the connection
+// for anything else until the transaction is finished.
+type conn struct {
+	driverwrap.Conn
+	connector *Connector
+	txID      uint64
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	ds, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: driverwrap.Stmt{Stmt: ds, Conn: c.Conn}, conn: c, query: query}, nil
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	ds, err := c.Conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: driverwrap.Stmt{Stmt: ds, Conn: c.Conn}, conn: c, query: query}, nil
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	startTime := time.Now()
+	dt, err := c.Conn.BeginTx(ctx, opts)
+	if err != nil {
+		c.connector.logStatement(ctx, StatementBegin, 0, startTime, err)
+		return nil, err
+	}
+	c.txID = c.connector.lastTxID.Add(1)
+	c.connector.logStatement(ctx, StatementBegin, c.txID, startTime, nil)
+	return &tx{tx: dt, conn: c, id: c.txID}, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	startTime := time.Now()
+	dr, err := c.Conn.QueryContext(ctx, query, args)
+	return c.connector.wrapRows(ctx, query, c.txID, startTime, dr, err)
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	startTime := time.Now()
+	result, err := c.Conn.ExecContext(ctx, query, args)
+	c.connector.logResult(ctx, query, c.txID, startTime, result, err)
+	return result, err
+}
+
+type tx struct {
+	tx   driver.Tx
+	conn *conn
+	id   uint64
+}
+
+func (t *tx) Commit() error {
+	return t.finish(StatementCommit, t.tx.Commit)
+}
+
+func (t *tx) Rollback() error {
+	return t.finish(StatementRollback, t.tx.Rollback)
+}
+
+func (t *tx) finish(statement string, fn func() error) error {
+	startTime := time.Now()
+	err := fn()
+	t.conn.txID = 0
+	t.conn.connector.logStatement(context.Background(), statement, t.id, startTime, err)
+	return err
+}
+
+type stmt struct {
+	driverwrap.Stmt
+	conn  *conn
+	query string
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	startTime := time.Now()
+	result, err := s.Stmt.ExecContext(ctx, args)
+	s.conn.connector.logResult(ctx, s.query, s.conn.txID, startTime, result, err)
+	return result, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	startTime := time.Now()
+	dr, err := s.Stmt.QueryContext(ctx, args)
+	return s.conn.connector.wrapRows(ctx, s.query, s.conn.txID, startTime, dr, err)
+}
+
+// rows counts rows of the result set and logs the query when it's closed.
+type rows struct {
+	driverwrap.Rows
+	ctx       context.Context
+	connector *Connector
+	query     string
+	txID      uint64
+	startTime time.Time
+	count     int
+	err       error
+	closed    bool
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.count++
+	case !errors.Is(err, io.EOF):
+		r.err = err
+	}
+	return err
+}
+
+func (r *rows) Close() error {
+	err := r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		logErr := r.err
+		if logErr == nil {
+			logErr = err
+		}
+		r.connector.logStatement(r.ctx, r.query, r.txID, r.startTime, logErr, log.Int("rows_returned", r.count))
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package sqllog
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/log"
+	"github.com/acronis/go-appkit/log/logtest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	_ "github.com/acronis/go-dbkit/sqlite"
+)
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	cfg := &dbkit.Config{Dialect: dbkit.DialectSQLite, SQLite: dbkit.SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")}}
+	logger := logtest.NewRecorder()
+	db, err := Open(cfg, true, logger)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE)`)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `INSERT INTO users (name) VALUES ('Alice'), ('Bob'), ('Sam')`)
+	require.NoError(t, err)
+	requireEntry(t, logger, `INSERT INTO users (name) VALUES ('Alice'), ('Bob'), ('Sam')`, log.LevelDebug, func(entry logtest.RecordedEntry) {
+		requireField(t, entry, "rows_affected", int64(3))
+		_, found := entry.FindField("tx_id")
+		require.False(t, found)
+	})
+
+	rows, err := db.QueryContext(ctx, `SELECT id, name FROM users WHERE id > ?`, 1)
+	require.NoError(t, err)
+	for rows.Next() {
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	requireEntry(t, logger, `SELECT id, name FROM users WHERE id > ?`, log.LevelDebug, func(entry logtest.RecordedEntry) {
+		requireField(t, entry, "rows_returned", int64(2))
+		_, found := entry.FindField("duration_ms")
+		require.True(t, found)
+	})
+
+	// Statements executed in the transaction (including prepared ones) are logged with its ID.
+	logger.Reset()
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, `UPDATE users SET name = 'Robert' WHERE name = ?`, "Bob")
+	require.NoError(t, err)
+	stmt, err := tx.PrepareContext(ctx, `SELECT name FROM users WHERE id = ?`)
+	require.NoError(t, err)
+	var name string
+	require.NoError(t, stmt.QueryRowContext(ctx, 2).Scan(&name))
+	require.Equal(t, "Robert", name)
+	require.NoError(t, stmt.Close())
+	require.NoError(t, tx.Commit())
+
+	var txID uint64
+	requireEntry(t, logger, StatementBegin, log.LevelDebug, func(entry logtest.RecordedEntry) {
+		field, found := entry.FindField("tx_id")
+		require.True(t, found)
+		txID = uint64(field.Int)
+		require.NotZero(t, txID)
+	})
+	for _, query := range []string{
+		`UPDATE users SET name = 'Robert' WHERE name = ?`, `SELECT name FROM users WHERE id = ?`, StatementCommit,
+	} {
+		requireEntry(t, logger, query, log.LevelDebug, func(entry logtest.RecordedEntry) {
+			requireField(t, entry, "tx_id", int64(txID))
+		})
+	}
+
+	// Failed statements are logged with the error.
+	logger.Reset()
+	_, err = db.ExecContext(ctx, `INSERT INTO users (name) VALUES ('Alice')`)
+	require.Error(t, err)
+	requireEntry(t, logger, `INSERT INTO users (name) VALUES ('Alice')`, log.LevelError, func(entry logtest.RecordedEntry) {
+		require.Equal(t, "SQL statement failed", entry.Text)
+		_, found := entry.FindField("error")
+		require.True(t, found)
+	})
+	tx, err = db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+	requireEntry(t, logger, StatementRollback, log.LevelDebug, nil)
+}
+
+func TestConnectorOptions(t *testing.T) {
+	ctx := context.Background()
+	cfg := &dbkit.Config{Dialect: dbkit.DialectSQLite, SQLite: dbkit.SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")}}
+	logger := logtest.NewRecorder()
+	db, err := Open(cfg, false, logger,
+		WithLevel(log.LevelInfo),
+		WithErrorLevel(log.LevelWarn),
+		WithSlowThreshold(time.Nanosecond, log.LevelError),
+		WithQueryFormatter(func(query string) string {
+			if strings.Contains(query, "secret") {
+				return ""
+			}
+			return strings.ToLower(query)
+		}),
+	)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	_, err = db.ExecContext(ctx, `SELECT 1`)
+	require.NoError(t, err)
+	requireEntry(t, logger, "select 1", log.LevelError, nil) // Any statement is slow with 1ns threshold.
+
+	_, err = db.ExecContext(ctx, `SELECT * FROM unknown_table`)
+	require.Error(t, err)
+	requireEntry(t, logger, "select * from unknown_table", log.LevelWarn, nil)
+
+	logger.Reset()
+	_, err = db.ExecContext(ctx, `SELECT 'secret'`)
+	require.NoError(t, err)
+	entries := logger.Entries()
+	require.Len(t, entries, 1)
+	_, found := entries[0].FindField("query")
+	require.False(t, found)
+}
+
+func requireEntry(
+	t *testing.T, logger *logtest.Recorder, query string, level log.Level, check func(entry logtest.RecordedEntry),
+) {
+	t.Helper()
+	entry, found := logger.FindEntryByFilter(func(entry logtest.RecordedEntry) bool {
+		field, ok := entry.FindField("query")
+		return ok && string(field.Bytes) == query
+	})
+	require.True(t, found, "entry for query %q is not found", query)
+	require.Equal(t, level, entry.Level)
+	if check != nil {
+		check(entry)
+	}
+}
+
+func requireField(t *testing.T, entry logtest.RecordedEntry, key string, value int64) {
+	t.Helper()
+	field, found := entry.FindField(key)
+	require.True(t, found, "field %q is not found", key)
+	require.Equal(t, value, field.Int)
+}
@@ -0,0 +1,11 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package sqlxutil provides helpers for working with SQL databases via the sqlx library
+// (https://github.com/jmoiron/sqlx) for services that prefer it over the dbr query builder.
+// It allows opening *sqlx.DB from dbkit.Config, executing retryable transactions with dbkit retry classifiers
+// and collecting metrics about annotated SQL queries (see MetricsExt).
+package sqlxutil
@@ -0,0 +1,80 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package sqlxutil
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/dbrutil"
+)
+
+// MetricsExt wraps sqlx.ExtContext (*sqlx.DB or *sqlx.Tx) and collects metrics about executed SQL queries.
+// To be collected, SQL query should be annotated (comment starting with the specified prefix,
+// see dbkit.AnnotateQuery and dbrutil.ParseAnnotationInQuery).
+// Besides durations, errors of failed queries are counted by class if the collector implements
+// dbkit.ErrorMetricsCollector (as dbkit.PrometheusMetrics does).
+// It may be passed to the sqlx functions (e.g., sqlx.SelectContext, sqlx.GetContext or sqlx.NamedExecContext).
+type MetricsExt struct {
+	sqlx.ExtContext
+	collector        dbkit.QueryMetricsCollector
+	annotationPrefix string
+}
+
+var _ sqlx.ExtContext = (*MetricsExt)(nil)
+
+// NewMetricsExt creates a new MetricsExt.
+func NewMetricsExt(ext sqlx.ExtContext, collector dbkit.QueryMetricsCollector, annotationPrefix string) *MetricsExt {
+	return &MetricsExt{ExtContext: ext, collector: collector, annotationPrefix: annotationPrefix}
+}
+
+// QueryContext executes the query and collects metrics.
+func (e *MetricsExt) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	startedAt := time.Now()
+	rows, err := e.ExtContext.QueryContext(ctx, query, args...)
+	e.observe(query, startedAt, err)
+	return rows, err
+}
+
+// QueryxContext executes the query and collects metrics.
+func (e *MetricsExt) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	startedAt := time.Now()
+	rows, err := e.ExtContext.QueryxContext(ctx, query, args...)
+	e.observe(query, startedAt, err)
+	return rows, err
+}
+
+// QueryRowxContext executes the query and collects metrics.
+func (e *MetricsExt) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	startedAt := time.Now()
+	row := e.ExtContext.QueryRowxContext(ctx, query, args...)
+	e.observe(query, startedAt, row.Err())
+	return row
+}
+
+// ExecContext executes the query and collects metrics.
+func (e *MetricsExt) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	startedAt := time.Now()
+	result, err := e.ExtContext.ExecContext(ctx, query, args...)
+	e.observe(query, startedAt, err)
+	return result, err
+}
+
+func (e *MetricsExt) observe(query string, startedAt time.Time, err error) {
+	annotation := dbrutil.ParseAnnotationInQuery(query, e.annotationPrefix, nil)
+	if annotation == "" {
+		return
+	}
+	e.collector.ObserveQueryDuration(annotation, time.Since(startedAt))
+	if errCollector, ok := e.collector.(dbkit.ErrorMetricsCollector); ok && err != nil {
+		errCollector.ObserveQueryError(annotation, err)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package sqlxutil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/acronis/go-appkit/retry"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// Open opens database (*sqlx.DB) with specified configuration parameters
+// and verifies (if ping argument is true) that connection can be established
+// (see dbkit.WithPingTimeout and dbkit.WithPingRetry options).
+func Open(cfg *dbkit.Config, ping bool, options ...dbkit.OpenOption) (*sqlx.DB, error) {
+	driver, dsn, err := cfg.ResolveDriverNameAndDSN(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	db, err := sqlx.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err = dbkit.InitOpenedDB(db.DB, cfg, ping, options...); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+type doInTxOptions struct {
+	txOpts      *sql.TxOptions
+	retryPolicy retry.Policy
+	txMetrics   dbkit.TxMetricsCollector
+}
+
+// DoInTxOption is a functional option for DoInTx.
+type DoInTxOption func(*doInTxOptions)
+
+// WithTxOptions sets transaction options for DoInTx.
+func WithTxOptions(txOpts *sql.TxOptions) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.txOpts = txOpts
+	}
+}
+
+// WithRetryPolicy sets retry policy for DoInTx.
+// Whether the error is retryable is determined by dbkit.GetIsRetryable for the driver of the database.
+func WithRetryPolicy(policy retry.Policy) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.retryPolicy = policy
+	}
+}
+
+// WithTxMetrics sets the collector (e.g., dbkit.PrometheusMetrics) that is used by DoInTx to observe the duration
+// of each attempt of the transaction and to count commits, rollbacks and retries.
+// Metrics are labeled by the transaction name stored in the context (see dbkit.ContextWithTxName).
+func WithTxMetrics(collector dbkit.TxMetricsCollector) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.txMetrics = collector
+	}
+}
+
+// TxBeginError is an error that may occur when beginning transaction is failed.
+type TxBeginError struct {
+	Inner error
+}
+
+// Unwrap unwraps internal error for IsRetryable algorithm.
+func (e *TxBeginError) Unwrap() error {
+	return e.Inner
+}
+
+// Error returns a string representation of TxBeginError.
+func (e *TxBeginError) Error() string {
+	return fmt.Sprintf("error while beginning transaction: %s", e.Inner)
+}
+
+// TxCommitError is an error that may occur when committing transaction is failed.
+type TxCommitError struct {
+	Inner error
+}
+
+// Unwrap unwraps internal error for IsRetryable algorithm.
+func (e *TxCommitError) Unwrap() error {
+	return e.Inner
+}
+
+// Error returns a string representation of TxCommitError.
+func (e *TxCommitError) Error() string {
+	return fmt.Sprintf("error while committing transaction: %s", e.Inner)
+}
+
+// DoInTx begins a new transaction, calls passed function and do commit or rollback
+// depending on whether the function returns an error or not.
+// If the retry policy is set (see WithRetryPolicy), the whole transaction is retried on retryable errors.
+func DoInTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error, options ...DoInTxOption) error {
+	var opts doInTxOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.retryPolicy == nil {
+		return doInTx(ctx, db, fn, opts)
+	}
+	var notify backoff.Notify
+	if opts.txMetrics != nil {
+		notify = func(err error, d time.Duration) {
+			opts.txMetrics.IncTxRetries(dbkit.TxNameFromContext(ctx))
+		}
+	}
+	return retry.DoWithRetry(ctx, opts.retryPolicy, dbkit.GetIsRetryable(db.Driver()), notify, func(ctx context.Context) error {
+		return doInTx(ctx, db, fn, opts)
+	})
+}
+
+func doInTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error, opts doInTxOptions) error {
+	tx, err := db.BeginTxx(ctx, opts.txOpts)
+	if err != nil {
+		return &TxBeginError{err}
+	}
+
+	committed := false
+	if opts.txMetrics != nil {
+		startedAt := time.Now()
+		defer func() { dbkit.ObserveTx(opts.txMetrics, dbkit.TxNameFromContext(ctx), startedAt, committed) }()
+	}
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return &TxCommitError{err}
+	}
+	committed = true
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package sqlxutil
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/retry"
+	"github.com/acronis/go-appkit/testutil"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	_ "github.com/acronis/go-dbkit/sqlite"
+)
+
+func openTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	cfg := &dbkit.Config{
+		Dialect:      dbkit.DialectSQLite,
+		SQLite:       dbkit.SQLiteConfig{Path: filepath.Join(t.TempDir(), "sqlxutil.db")},
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	}
+	db, err := Open(cfg, true)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`)
+	require.NoError(t, err)
+	return db
+}
+
+func countUsers(t *testing.T, db *sqlx.DB) int {
+	t.Helper()
+	var count int
+	require.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM users"))
+	return count
+}
+
+func TestDoInTx(t *testing.T) {
+	db := openTestDB(t)
+	metrics := dbkit.NewPrometheusMetrics()
+	ctx := dbkit.ContextWithTxName(context.Background(), "create_user")
+
+	require.NoError(t, DoInTx(ctx, db, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExec("INSERT INTO users (name) VALUES (:name)", map[string]interface{}{"name": "Alice"})
+		return err
+	}, WithTxMetrics(metrics)))
+	require.Equal(t, 1, countUsers(t, db))
+
+	insertErr := errors.New("insert error")
+	require.ErrorIs(t, DoInTx(ctx, db, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec("INSERT INTO users (name) VALUES (?)", "Bob"); err != nil {
+			return err
+		}
+		return insertErr
+	}, WithTxMetrics(metrics)), insertErr)
+	require.Equal(t, 1, countUsers(t, db))
+
+	attempts := 0
+	require.NoError(t, DoInTx(ctx, db, func(tx *sqlx.Tx) error {
+		attempts++
+		if attempts == 1 {
+			return sqlite3.Error{Code: sqlite3.ErrBusy}
+		}
+		_, err := tx.Exec("INSERT INTO users (name) VALUES (?)", "Bob")
+		return err
+	}, WithTxMetrics(metrics), WithRetryPolicy(retry.NewConstantBackoffPolicy(time.Millisecond, 2))))
+	require.Equal(t, 2, attempts)
+	require.Equal(t, 2, countUsers(t, db))
+
+	labels := prometheus.Labels{dbkit.PrometheusMetricsLabelTx: "create_user"}
+	testutil.RequireSamplesCountInCounter(t, metrics.TxCommits.With(labels), 2)
+	testutil.RequireSamplesCountInCounter(t, metrics.TxRollbacks.With(labels), 2)
+	testutil.RequireSamplesCountInCounter(t, metrics.TxRetries.With(labels), 1)
+}
+
+func TestMetricsExt(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	metrics := dbkit.NewPrometheusMetrics()
+	ext := NewMetricsExt(db, metrics, dbkit.QueryAnnotationPrefix)
+
+	_, err := ext.ExecContext(ctx, dbkit.AnnotateQuery("create_user", "INSERT INTO users (name) VALUES (?)"), "Alice")
+	require.NoError(t, err)
+	var names []string
+	require.NoError(t, sqlx.SelectContext(ctx, ext, &names, dbkit.AnnotateQuery("list_users", "SELECT name FROM users")))
+	require.Equal(t, []string{"Alice"}, names)
+	var count int
+	require.NoError(t, sqlx.GetContext(ctx, ext, &count, dbkit.AnnotateQuery("count_users", "SELECT COUNT(*) FROM users")))
+	require.Equal(t, 1, count)
+	require.NoError(t, sqlx.GetContext(ctx, ext, &count, "SELECT COUNT(*) FROM users"))
+	_, err = ext.ExecContext(ctx, dbkit.AnnotateQuery("create_user", "INSERT INTO unknown (name) VALUES (?)"), "Bob")
+	require.Error(t, err)
+
+	require.NoError(t, DoInTx(ctx, db, func(tx *sqlx.Tx) error {
+		_, txErr := NewMetricsExt(tx, metrics, dbkit.QueryAnnotationPrefix).ExecContext(ctx,
+			dbkit.AnnotateQuery("delete_users", "DELETE FROM users"))
+		return txErr
+	}))
+
+	for query, want := range map[string]int{
+		"query:create_user": 2, "query:list_users": 1, "query:count_users": 1, "query:delete_users": 1,
+	} {
+		hist := metrics.QueryDurations.With(prometheus.Labels{dbkit.PrometheusMetricsLabelQuery: query}).(prometheus.Histogram)
+		testutil.RequireSamplesCountInHistogram(t, hist, want)
+	}
+	testutil.RequireSamplesCountInCounter(t, metrics.QueryErrors.With(prometheus.Labels{
+		dbkit.PrometheusMetricsLabelQuery: "query:create_user", dbkit.PrometheusMetricsLabelErrorClass: "other"}), 1)
+}
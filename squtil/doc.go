@@ -0,0 +1,11 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package squtil provides helpers for working with SQL databases via the squirrel query builder
+// (https://github.com/Masterminds/squirrel).
+// It allows constructing statement builders with the placeholder format of the dialect, annotating built statements
+// (see dbkit.AnnotateQuery) and executing them with retries and metrics collection (see Runner).
+package squtil
@@ -0,0 +1,178 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package squtil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/acronis/go-appkit/retry"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/dbrutil"
+)
+
+// PlaceholderFormat returns the placeholder format of the dialect:
+// "$1" for Postgres, "@p1" for MSSQL and "?" for MySQL and SQLite.
+func PlaceholderFormat(dialect dbkit.Dialect) (sq.PlaceholderFormat, error) {
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		return sq.Dollar, nil
+	case dbkit.DialectMSSQL:
+		return sq.AtP, nil
+	case dbkit.DialectMySQL, dbkit.DialectSQLite:
+		return sq.Question, nil
+	default:
+		return nil, fmt.Errorf("unsupported dialect %q", dialect)
+	}
+}
+
+// StatementBuilder returns the statement builder with the placeholder format of the dialect.
+func StatementBuilder(dialect dbkit.Dialect) (sq.StatementBuilderType, error) {
+	format, err := PlaceholderFormat(dialect)
+	if err != nil {
+		return sq.StatementBuilderType{}, err
+	}
+	return sq.StatementBuilder.PlaceholderFormat(format), nil
+}
+
+// Annotate wraps the statement, so its SQL is prepended with the "/* query:<name> */" comment (see dbkit.AnnotateQuery).
+func Annotate(name string, s sq.Sqlizer) sq.Sqlizer {
+	return annotatedSqlizer{name: name, sqlizer: s}
+}
+
+type annotatedSqlizer struct {
+	name    string
+	sqlizer sq.Sqlizer
+}
+
+func (s annotatedSqlizer) ToSql() (string, []interface{}, error) { //nolint:revive,stylecheck // squirrel naming.
+	query, args, err := s.sqlizer.ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+	return dbkit.AnnotateQuery(s.name, query), args, nil
+}
+
+type runnerOptions struct {
+	retryPolicy retry.Policy
+	metrics     dbkit.QueryMetricsCollector
+}
+
+// RunnerOption is a functional option for NewRunner.
+type RunnerOption func(*runnerOptions)
+
+// WithRetryPolicy sets retry policy for the statements executed by Runner.
+// Whether the error is retryable is determined by dbkit.GetIsRetryableForDialect.
+// It shouldn't be used for runners bound to transactions, since the whole transaction should be retried instead.
+func WithRetryPolicy(policy retry.Policy) RunnerOption {
+	return func(opts *runnerOptions) {
+		opts.retryPolicy = policy
+	}
+}
+
+// WithMetrics sets the collector (e.g., dbkit.PrometheusMetrics) of durations of the statements executed by Runner.
+// Statements are labeled by their annotations (see Annotate and dbkit.QueryAnnotationPrefix),
+// not annotated statements are not observed. Errors are counted by class if the collector implements
+// dbkit.ErrorMetricsCollector.
+func WithMetrics(collector dbkit.QueryMetricsCollector) RunnerOption {
+	return func(opts *runnerOptions) {
+		opts.metrics = collector
+	}
+}
+
+// Runner executes statements built by squirrel via *sql.DB or *sql.Tx with retries and metrics collection.
+// Statements that are not annotated explicitly (see Annotate) are annotated with the query name
+// stored in the context (see dbkit.WithQueryName).
+type Runner struct {
+	conn        sq.StdSqlCtx
+	builder     sq.StatementBuilderType
+	isRetryable retry.IsRetryable
+	opts        runnerOptions
+}
+
+// NewRunner creates a new Runner for the connection (*sql.DB or *sql.Tx) of the given dialect.
+func NewRunner(conn sq.StdSqlCtx, dialect dbkit.Dialect, options ...RunnerOption) (*Runner, error) {
+	builder, err := StatementBuilder(dialect)
+	if err != nil {
+		return nil, err
+	}
+	var opts runnerOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return &Runner{conn: conn, builder: builder, isRetryable: dbkit.GetIsRetryableForDialect(dialect), opts: opts}, nil
+}
+
+// Builder returns the statement builder with the placeholder format of the runner's dialect.
+func (r *Runner) Builder() sq.StatementBuilderType {
+	return r.builder
+}
+
+// ExecContext builds and executes the statement.
+func (r *Runner) ExecContext(ctx context.Context, s sq.Sqlizer) (result sql.Result, err error) {
+	query, args, err := r.toSQL(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	err = r.do(ctx, query, func(ctx context.Context) (execErr error) {
+		result, execErr = r.conn.ExecContext(ctx, query, args...)
+		return execErr
+	})
+	return result, err
+}
+
+// QueryContext builds and executes the statement that returns rows.
+func (r *Runner) QueryContext(ctx context.Context, s sq.Sqlizer) (rows *sql.Rows, err error) {
+	query, args, err := r.toSQL(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	err = r.do(ctx, query, func(ctx context.Context) (queryErr error) {
+		rows, queryErr = r.conn.QueryContext(ctx, query, args...) //nolint:sqlclosecheck // Rows are returned to the caller.
+		return queryErr
+	})
+	return rows, err
+}
+
+func (r *Runner) toSQL(ctx context.Context, s sq.Sqlizer) (string, []interface{}, error) {
+	query, args, err := s.ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+	if dbkit.QueryLeadingComment(query) == "" {
+		query = dbkit.AnnotateQueryContext(ctx, query)
+	}
+	return query, args, nil
+}
+
+func (r *Runner) do(ctx context.Context, query string, fn func(ctx context.Context) error) error {
+	if r.opts.metrics != nil {
+		if annotation := dbrutil.ParseAnnotationInQuery(query, dbkit.QueryAnnotationPrefix, nil); annotation != "" {
+			fn = r.observed(annotation, fn)
+		}
+	}
+	if r.opts.retryPolicy == nil {
+		return fn(ctx)
+	}
+	return retry.DoWithRetry(ctx, r.opts.retryPolicy, r.isRetryable, nil, fn)
+}
+
+func (r *Runner) observed(annotation string, fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		startedAt := time.Now()
+		err := fn(ctx)
+		r.opts.metrics.ObserveQueryDuration(annotation, time.Since(startedAt))
+		if errCollector, ok := r.opts.metrics.(dbkit.ErrorMetricsCollector); ok && err != nil {
+			errCollector.ObserveQueryError(annotation, err)
+		}
+		return err
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package squtil
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/acronis/go-appkit/retry"
+	"github.com/acronis/go-appkit/testutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+	_ "github.com/acronis/go-dbkit/sqlite"
+)
+
+func TestStatementBuilder(t *testing.T) {
+	tests := []struct {
+		dialect   dbkit.Dialect
+		wantQuery string
+	}{
+		{dialect: dbkit.DialectPostgres, wantQuery: "SELECT name FROM users WHERE id = $1"},
+		{dialect: dbkit.DialectPgx, wantQuery: "SELECT name FROM users WHERE id = $1"},
+		{dialect: dbkit.DialectMSSQL, wantQuery: "SELECT name FROM users WHERE id = @p1"},
+		{dialect: dbkit.DialectMySQL, wantQuery: "SELECT name FROM users WHERE id = ?"},
+		{dialect: dbkit.DialectSQLite, wantQuery: "SELECT name FROM users WHERE id = ?"},
+	}
+	for _, tt := range tests {
+		builder, err := StatementBuilder(tt.dialect)
+		require.NoError(t, err)
+		query, args, err := builder.Select("name").From("users").Where(sq.Eq{"id": 1}).ToSql()
+		require.NoError(t, err)
+		require.Equal(t, tt.wantQuery, query)
+		require.Equal(t, []interface{}{1}, args)
+	}
+
+	_, err := StatementBuilder("oracle")
+	require.EqualError(t, err, `unsupported dialect "oracle"`)
+}
+
+func TestAnnotate(t *testing.T) {
+	builder, err := StatementBuilder(dbkit.DialectPostgres)
+	require.NoError(t, err)
+	query, args, err := Annotate("get_user", builder.Select("name").From("users").Where(sq.Eq{"id": 1})).ToSql()
+	require.NoError(t, err)
+	require.Equal(t, "/* query:get_user */ SELECT name FROM users WHERE id = $1", query)
+	require.Equal(t, []interface{}{1}, args)
+
+	_, _, err = Annotate("create_user", builder.Insert("users")).ToSql()
+	require.Error(t, err)
+}
+
+func TestRunner(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "squtil.db"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`)
+	require.NoError(t, err)
+
+	metrics := dbkit.NewPrometheusMetrics()
+	runner, err := NewRunner(db, dbkit.DialectSQLite,
+		WithMetrics(metrics), WithRetryPolicy(retry.NewConstantBackoffPolicy(time.Millisecond, 2)))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = runner.ExecContext(ctx, Annotate("create_user", runner.Builder().Insert("users").Columns("name").Values("Alice")))
+	require.NoError(t, err)
+	_, err = runner.ExecContext(dbkit.WithQueryName(ctx, "create_user"),
+		runner.Builder().Insert("users").Columns("name").Values("Bob"))
+	require.NoError(t, err)
+	_, err = runner.ExecContext(ctx, Annotate("create_user", runner.Builder().Insert("unknown").Columns("name").Values("Bob")))
+	require.Error(t, err)
+
+	rows, err := runner.QueryContext(ctx, Annotate("list_users", runner.Builder().Select("name").From("users").OrderBy("id")))
+	require.NoError(t, err)
+	var names []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	require.Equal(t, []string{"Alice", "Bob"}, names)
+
+	testutil.RequireSamplesCountInHistogram(t, metrics.QueryDurations.With(
+		prometheus.Labels{dbkit.PrometheusMetricsLabelQuery: "query:create_user"}).(prometheus.Histogram), 3)
+	testutil.RequireSamplesCountInHistogram(t, metrics.QueryDurations.With(
+		prometheus.Labels{dbkit.PrometheusMetricsLabelQuery: "query:list_users"}).(prometheus.Histogram), 1)
+	testutil.RequireSamplesCountInCounter(t, metrics.QueryErrors.With(prometheus.Labels{
+		dbkit.PrometheusMetricsLabelQuery:      "query:create_user",
+		dbkit.PrometheusMetricsLabelErrorClass: string(dbkit.QueryErrorClassOther),
+	}), 1)
+}
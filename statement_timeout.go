@@ -0,0 +1,61 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// WithStatementTimeoutFromDeadline makes DoInTx convert the time remaining until the ctx deadline
+// at the transaction start into the server-side statement timeout of the transaction (see SetStatementTimeoutFromDeadline).
+// So, queries of the request canceled by the deadline stop consuming DB resources
+// instead of running to completion server-side.
+// The timeout is a transaction-start bound: it's set once, and it doesn't track the deadline afterward,
+// so each statement gets the whole remaining time of the transaction start, and the later ones may run
+// past the deadline server-side. Call SetStatementTimeoutFromDeadline in the transaction before long statements
+// to bound them by the time remaining at their start.
+func WithStatementTimeoutFromDeadline(dialect Dialect) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.deadlineTimeoutEnabled = true
+		opts.deadlineTimeoutDialect = dialect
+	}
+}
+
+// SQLExecutor is an interface for executing SQL statements (e.g., *sql.DB, *sql.Tx or *sql.Conn).
+type SQLExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// SetStatementTimeoutFromDeadline sets the server-side statement timeout for all subsequent statements
+// of the transaction equal to the time remaining until the ctx deadline at the moment of the call.
+// The timeout is not decreased as the time goes, so it may be called again before the next statement to re-bound it.
+// It does nothing if ctx has no deadline, and returns ctx.Err() if the deadline is already exceeded.
+// The timeout is set with "SET LOCAL statement_timeout", so it's reset automatically when the transaction ends.
+// Only Postgres (DialectPostgres and DialectPgx) is supported, since other databases don't provide
+// a transaction-scoped statement timeout, and setting a session-scoped one would leak to other users of the pooled connection.
+func SetStatementTimeoutFromDeadline(ctx context.Context, tx SQLExecutor, dialect Dialect) error {
+	if dialect != DialectPostgres && dialect != DialectPgx {
+		return fmt.Errorf("statement timeout propagation is not supported for sql dialect %q", dialect)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return context.DeadlineExceeded
+	}
+	timeoutMs := (remaining + time.Millisecond - 1) / time.Millisecond // Round up, 0 means no timeout in Postgres.
+	// SET statement doesn't support placeholders, but the value is an integer, so it's safe.
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeoutMs)); err != nil {
+		return fmt.Errorf("set statement timeout: %w", err)
+	}
+	return nil
+}
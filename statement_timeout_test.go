@@ -0,0 +1,77 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoInTxWithStatementTimeoutFromDeadline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	t.Run("deadline is propagated", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`^SET LOCAL statement_timeout = (9\d{3}|10000)$`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`^UPDATE users SET name = \$1$`).WithArgs("Alice").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		require.NoError(t, DoInTx(ctx, db, func(tx *sql.Tx) error {
+			_, execErr := tx.ExecContext(ctx, "UPDATE users SET name = $1", "Alice")
+			return execErr
+		}, WithStatementTimeoutFromDeadline(DialectPostgres)))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("timeout is re-bounded before the statement", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`^SET LOCAL statement_timeout = (9\d{3}|10000)$`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`^SET LOCAL statement_timeout = (9\d{2}|1000)$`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		require.NoError(t, DoInTx(ctx, db, func(tx *sql.Tx) error {
+			stmtCtx, stmtCtxCancel := context.WithTimeout(ctx, time.Second)
+			defer stmtCtxCancel()
+			return SetStatementTimeoutFromDeadline(stmtCtx, tx, DialectPostgres)
+		}, WithStatementTimeoutFromDeadline(DialectPostgres)))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no deadline", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		require.NoError(t, DoInTx(context.Background(), db, func(tx *sql.Tx) error {
+			return nil
+		}, WithStatementTimeoutFromDeadline(DialectPgx)))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unsupported dialect", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		err := DoInTx(ctx, db, func(tx *sql.Tx) error {
+			t.Fatal("function must not be called")
+			return nil
+		}, WithStatementTimeoutFromDeadline(DialectMySQL))
+		require.ErrorContains(t, err, `statement timeout propagation is not supported for sql dialect "mysql"`)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
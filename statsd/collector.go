@@ -0,0 +1,133 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package statsd
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// Metric names (without the prefix set by WithPrefix).
+const (
+	MetricQueryDuration = "db.query.duration"
+	MetricQueryErrors   = "db.query.errors"
+)
+
+type collectorOptions struct {
+	prefix string
+	tags   []string
+}
+
+// Option is a functional option for NewCollector.
+type Option func(*collectorOptions)
+
+// WithPrefix sets the prefix of metric names (e.g., "my_service.").
+func WithPrefix(prefix string) Option {
+	return func(opts *collectorOptions) {
+		opts.prefix = prefix
+	}
+}
+
+// WithTags sets constant tags in the "key:value" format (e.g., "env:prod") that are added to all metrics.
+func WithTags(tags ...string) Option {
+	return func(opts *collectorOptions) {
+		opts.tags = tags
+	}
+}
+
+// Collector sends metrics of SQL queries to the StatsD (DogStatsD) server.
+// It implements dbrutil.MetricsCollector and dbkit.ErrorMetricsCollector interfaces.
+// As usual for StatsD, metrics are sent without waiting for acknowledgment and write errors are ignored.
+type Collector struct {
+	mu         sync.Mutex
+	w          io.Writer
+	prefix     string
+	tagsSuffix string
+}
+
+var _ dbkit.ErrorMetricsCollector = (*Collector)(nil)
+
+// NewCollector creates a new Collector sending metrics over UDP to the given address (e.g., "127.0.0.1:8125").
+// Collector.Close should be called to close the UDP connection.
+func NewCollector(addr string, options ...Option) (*Collector, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewCollectorWithWriter(conn, options...), nil
+}
+
+// NewCollectorWithWriter creates a new Collector writing each metric with a separate Write call to w.
+func NewCollectorWithWriter(w io.Writer, options ...Option) *Collector {
+	var opts collectorOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	c := &Collector{w: w, prefix: opts.prefix}
+	for _, tag := range opts.tags {
+		c.tagsSuffix += "," + sanitizeTag(tag)
+	}
+	return c
+}
+
+// ObserveQueryDuration sends the duration of executing SQL query as a timing (in milliseconds).
+func (c *Collector) ObserveQueryDuration(query string, duration time.Duration) {
+	value := strconv.FormatFloat(float64(duration)/float64(time.Millisecond), 'f', -1, 64)
+	c.send(MetricQueryDuration, value, "ms", "query:"+query)
+}
+
+// ObserveQueryError sends the counter of failed SQL queries with the class of the error (see dbkit.ClassifyQueryError).
+// Nil error is ignored.
+func (c *Collector) ObserveQueryError(query string, err error) {
+	if err == nil {
+		return
+	}
+	c.send(MetricQueryErrors, "1", "c", "query:"+query, "error_class:"+string(dbkit.ClassifyQueryError(err)))
+}
+
+// Close closes the underlying writer if it implements io.Closer (e.g., the UDP connection created by NewCollector).
+func (c *Collector) Close() error {
+	if closer, ok := c.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (c *Collector) send(name, value, metricType string, tags ...string) {
+	var sb strings.Builder
+	sb.WriteString(c.prefix)
+	sb.WriteString(name)
+	sb.WriteByte(':')
+	sb.WriteString(value)
+	sb.WriteByte('|')
+	sb.WriteString(metricType)
+	sb.WriteString("|#")
+	for i, tag := range tags {
+		if i != 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(sanitizeTag(tag))
+	}
+	sb.WriteString(c.tagsSuffix)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, _ = c.w.Write([]byte(sb.String()))
+}
+
+var tagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+
+// sanitizeTag replaces characters that have special meaning in the DogStatsD protocol.
+func sanitizeTag(tag string) string {
+	return tagReplacer.Replace(tag)
+}
@@ -0,0 +1,60 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package statsd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingWriter struct {
+	packets []string
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.packets = append(w.packets, string(p))
+	return len(p), nil
+}
+
+func TestCollector(t *testing.T) {
+	w := &recordingWriter{}
+	collector := NewCollectorWithWriter(w, WithPrefix("api."), WithTags("env:prod", "region:eu|1"))
+
+	collector.ObserveQueryDuration("list_users", 1500*time.Microsecond)
+	collector.ObserveQueryError("list,users", context.DeadlineExceeded)
+	collector.ObserveQueryError("list_users", errors.New("syntax error"))
+	collector.ObserveQueryError("list_users", nil)
+	require.NoError(t, collector.Close())
+
+	require.Equal(t, []string{
+		"api.db.query.duration:1.5|ms|#query:list_users,env:prod,region:eu_1",
+		"api.db.query.errors:1|c|#query:list_users,error_class:timeout,env:prod,region:eu_1",
+		"api.db.query.errors:1|c|#query:list_users,error_class:other,env:prod,region:eu_1",
+	}, w.packets)
+}
+
+func TestNewCollector(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, listener.Close()) }()
+
+	collector, err := NewCollector(listener.LocalAddr().String())
+	require.NoError(t, err)
+	collector.ObserveQueryDuration("list_users", 2*time.Millisecond)
+	require.NoError(t, collector.Close())
+
+	require.NoError(t, listener.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1024)
+	n, _, err := listener.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Equal(t, "db.query.duration:2|ms|#query:list_users", string(buf[:n]))
+}
@@ -0,0 +1,13 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package statsd provides the StatsD (DogStatsD) implementation of the metrics collected by dbkit,
+// so the instrumentation (e.g., dbrutil.QueryMetricsEventReceiver or dbkit.DoInTx with dbkit.WithErrorMetrics)
+// can feed Datadog agents without a Prometheus bridge.
+//
+// Collector sends query durations as timings and query errors as counters over UDP.
+// Tags (the query annotation, the error class and the configured constant tags) are sent in the DogStatsD format.
+package statsd
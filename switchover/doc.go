@@ -0,0 +1,12 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package switchover provides a helper for graceful planned failovers of the primary database
+// (e.g., coordinated with Patroni or Orchestrator).
+// On demand (e.g., on an ops signal), Switcher pauses new transactions, waits until in-flight ones are finished,
+// re-resolves the primary endpoint (usually by opening a new connection pool) and resumes paused transactions
+// against the new primary. Transactions are only delayed for the switchover time instead of failing.
+package switchover
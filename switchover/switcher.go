@@ -0,0 +1,217 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package switchover
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// DefaultDrainTimeout is the default maximum time of waiting for in-flight transactions during the switchover.
+const DefaultDrainTimeout = 10 * time.Second
+
+// ErrSwitchoverInProgress is returned by Switcher.Switchover when another switchover is in progress.
+var ErrSwitchoverInProgress = errors.New("switchover is already in progress")
+
+// ErrDrainTimeout is returned by Switcher.Switchover when in-flight transactions are not finished in time.
+// In this case, the switchover is aborted and the current database is kept.
+var ErrDrainTimeout = errors.New("timed out waiting for in-flight transactions")
+
+// ResolveFunc re-resolves the primary endpoint and returns the database connection pool for it.
+type ResolveFunc func(ctx context.Context) (*sql.DB, error)
+
+// OpenResolver returns ResolveFunc that opens a new connection pool using the provided configuration
+// (see dbkit.Open) and pings it, so the endpoint (e.g., DNS name or service address) is resolved again.
+func OpenResolver(cfg *dbkit.Config) ResolveFunc {
+	return func(ctx context.Context) (*sql.DB, error) {
+		db, err := dbkit.Open(cfg, false)
+		if err != nil {
+			if db != nil {
+				_ = db.Close()
+			}
+			return nil, err
+		}
+		if err = db.PingContext(ctx); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+		return db, nil
+	}
+}
+
+type switcherOptions struct {
+	drainTimeout time.Duration
+	keepOld      bool
+}
+
+// SwitcherOption is a functional option for NewSwitcher.
+type SwitcherOption func(*switcherOptions)
+
+// WithDrainTimeout sets the maximum time of waiting for in-flight transactions during the switchover.
+// DefaultDrainTimeout is used by default.
+func WithDrainTimeout(timeout time.Duration) SwitcherOption {
+	return func(opts *switcherOptions) {
+		opts.drainTimeout = timeout
+	}
+}
+
+// WithKeepOldDB disables closing of the old database connection pool after the switchover.
+// It's useful when the pool is owned by someone else.
+func WithKeepOldDB() SwitcherOption {
+	return func(opts *switcherOptions) {
+		opts.keepOld = true
+	}
+}
+
+// Switcher holds the database connection pool of the primary and allows switching it gracefully.
+// All transactions should be executed via Switcher.DoInTx (or between Switcher.Acquire and release)
+// to be paused and drained during the switchover.
+// Switcher is safe for concurrent use.
+type Switcher struct {
+	resolve ResolveFunc
+	opts    switcherOptions
+
+	mu       sync.Mutex
+	db       *sql.DB
+	inFlight int
+	paused   chan struct{} // not nil while new transactions are paused, closed on resume
+	drained  chan struct{} // not nil while the switchover waits for in-flight transactions
+}
+
+// NewSwitcher creates a new Switcher with the initial database connection pool.
+func NewSwitcher(db *sql.DB, resolve ResolveFunc, options ...SwitcherOption) *Switcher {
+	opts := switcherOptions{drainTimeout: DefaultDrainTimeout}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return &Switcher{db: db, resolve: resolve, opts: opts}
+}
+
+// DB returns the current database connection pool.
+// Queries executed directly via the returned pool are not paused during the switchover.
+func (s *Switcher) DB() *sql.DB {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db
+}
+
+// Paused returns true if new transactions are paused because of the switchover.
+func (s *Switcher) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused != nil
+}
+
+// Acquire returns the current database connection pool and marks the operation as in-flight.
+// If the switchover is in progress, Acquire waits until it's finished or the context is done.
+// The returned release function must be called when the operation is finished.
+func (s *Switcher) Acquire(ctx context.Context) (db *sql.DB, release func(), err error) {
+	s.mu.Lock()
+	for s.paused != nil {
+		paused := s.paused
+		s.mu.Unlock()
+		select {
+		case <-paused:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+		s.mu.Lock()
+	}
+	s.inFlight++
+	db = s.db
+	s.mu.Unlock()
+
+	var once sync.Once
+	return db, func() { once.Do(s.release) }, nil
+}
+
+func (s *Switcher) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	if s.inFlight == 0 && s.drained != nil {
+		close(s.drained)
+		s.drained = nil
+	}
+}
+
+// DoInTx executes a function inside a transaction against the current primary (see dbkit.DoInTx).
+// If the switchover is in progress, the transaction is started after it's finished.
+func (s *Switcher) DoInTx(ctx context.Context, fn func(tx *sql.Tx) error, options ...dbkit.DoInTxOption) error {
+	db, release, err := s.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return dbkit.DoInTx(ctx, db, fn, options...)
+}
+
+// Switchover pauses new transactions, waits until in-flight ones are finished, re-resolves the primary
+// and resumes paused transactions against the new database connection pool.
+// The old pool is closed unless WithKeepOldDB option is used.
+// If in-flight transactions are not drained in time, or the primary cannot be resolved,
+// the switchover is aborted, transactions are resumed against the current pool, and an error is returned.
+func (s *Switcher) Switchover(ctx context.Context) error {
+	s.mu.Lock()
+	if s.paused != nil {
+		s.mu.Unlock()
+		return ErrSwitchoverInProgress
+	}
+	s.paused = make(chan struct{})
+	drained := make(chan struct{})
+	if s.inFlight == 0 {
+		close(drained)
+	} else {
+		s.drained = drained
+	}
+	s.mu.Unlock()
+
+	newDB, err := s.drainAndResolve(ctx, drained)
+
+	s.mu.Lock()
+	s.drained = nil
+	oldDB := s.db
+	if err == nil {
+		s.db = newDB
+	}
+	close(s.paused)
+	s.paused = nil
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if !s.opts.keepOld && oldDB != newDB {
+		if closeErr := oldDB.Close(); closeErr != nil {
+			return fmt.Errorf("close old database: %w", closeErr)
+		}
+	}
+	return nil
+}
+
+func (s *Switcher) drainAndResolve(ctx context.Context, drained <-chan struct{}) (*sql.DB, error) {
+	drainTimer := time.NewTimer(s.opts.drainTimeout)
+	defer drainTimer.Stop()
+	select {
+	case <-drained:
+	case <-drainTimer.C:
+		return nil, ErrDrainTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	newDB, err := s.resolve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("resolve primary: %w", err)
+	}
+	return newDB, nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package switchover
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/acronis/go-appkit/config"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+func openDB(t *testing.T, name string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), name+".db")+"?_journal=MEMORY&_sync=OFF&_busy_timeout=5000")
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER NOT NULL PRIMARY KEY, name TEXT NOT NULL)`)
+	require.NoError(t, err)
+	return db
+}
+
+func countUsers(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var cnt int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM users").Scan(&cnt))
+	return cnt
+}
+
+func insertUser(name string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec("INSERT INTO users (name) VALUES (?)", name)
+		return err
+	}
+}
+
+func TestSwitcher_Switchover(t *testing.T) {
+	oldDB := openDB(t, "old")
+	newDB := openDB(t, "new")
+	defer func() { require.NoError(t, newDB.Close()) }()
+
+	resolveCalls := 0
+	switcher := NewSwitcher(oldDB, func(ctx context.Context) (*sql.DB, error) {
+		resolveCalls++
+		return newDB, nil
+	})
+	ctx := context.Background()
+	require.NoError(t, switcher.DoInTx(ctx, insertUser("Albert")))
+
+	// In-flight transaction is finished against the old database,
+	// and the transaction started during the switchover waits and goes to the new one.
+	txStarted := make(chan struct{})
+	finishTx := make(chan struct{})
+	inFlightErr := make(chan error)
+	go func() {
+		inFlightErr <- switcher.DoInTx(ctx, func(tx *sql.Tx) error {
+			close(txStarted)
+			<-finishTx
+			return insertUser("Bob")(tx)
+		})
+	}()
+	<-txStarted
+
+	switchoverErr := make(chan error)
+	go func() { switchoverErr <- switcher.Switchover(ctx) }()
+	require.Eventually(t, switcher.Paused, time.Second, time.Millisecond*10)
+	require.ErrorIs(t, switcher.Switchover(ctx), ErrSwitchoverInProgress)
+
+	pausedTxErr := make(chan error)
+	go func() { pausedTxErr <- switcher.DoInTx(ctx, insertUser("John")) }()
+	select {
+	case <-pausedTxErr:
+		t.Fatal("transaction should be paused during the switchover")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	close(finishTx)
+	require.NoError(t, <-inFlightErr)
+	require.NoError(t, <-switchoverErr)
+	require.NoError(t, <-pausedTxErr)
+
+	require.Equal(t, 1, resolveCalls)
+	require.False(t, switcher.Paused())
+	require.Same(t, newDB, switcher.DB())
+	require.Equal(t, 1, countUsers(t, newDB))
+	require.Error(t, oldDB.Ping(), "old database should be closed")
+}
+
+func TestSwitcher_SwitchoverAborted(t *testing.T) {
+	oldDB := openDB(t, "old")
+	defer func() { require.NoError(t, oldDB.Close()) }()
+	ctx := context.Background()
+
+	t.Run("drain timeout", func(t *testing.T) {
+		switcher := NewSwitcher(oldDB, func(ctx context.Context) (*sql.DB, error) {
+			t.Fatal("primary should not be resolved")
+			return nil, nil
+		}, WithDrainTimeout(time.Millisecond*50))
+		_, release, err := switcher.Acquire(ctx)
+		require.NoError(t, err)
+		require.ErrorIs(t, switcher.Switchover(ctx), ErrDrainTimeout)
+		release()
+		require.False(t, switcher.Paused())
+		require.Same(t, oldDB, switcher.DB())
+		require.NoError(t, switcher.DoInTx(ctx, insertUser("Albert")))
+	})
+
+	t.Run("resolve error", func(t *testing.T) {
+		resolveErr := errors.New("primary is not elected yet")
+		switcher := NewSwitcher(oldDB, func(ctx context.Context) (*sql.DB, error) {
+			return nil, resolveErr
+		})
+		require.ErrorIs(t, switcher.Switchover(ctx), resolveErr)
+		require.False(t, switcher.Paused())
+		require.Same(t, oldDB, switcher.DB())
+		require.NoError(t, oldDB.Ping())
+	})
+
+	t.Run("context canceled while paused", func(t *testing.T) {
+		switcher := NewSwitcher(oldDB, nil)
+		_, release, err := switcher.Acquire(ctx)
+		require.NoError(t, err)
+		defer release()
+
+		switchoverCtx, switchoverCancel := context.WithCancel(ctx)
+		switchoverErr := make(chan error)
+		go func() { switchoverErr <- switcher.Switchover(switchoverCtx) }()
+		require.Eventually(t, switcher.Paused, time.Second, time.Millisecond*10)
+
+		txCtx, txCancel := context.WithTimeout(ctx, time.Millisecond*50)
+		defer txCancel()
+		require.ErrorIs(t, switcher.DoInTx(txCtx, insertUser("Bob")), context.DeadlineExceeded)
+
+		switchoverCancel()
+		require.ErrorIs(t, <-switchoverErr, context.Canceled)
+		require.False(t, switcher.Paused())
+	})
+
+	require.Equal(t, 1, countUsers(t, oldDB))
+}
+
+func TestOpenResolver(t *testing.T) {
+	resolve := OpenResolver(&dbkit.Config{
+		Dialect:          dbkit.DialectSQLite,
+		SQLite:           dbkit.SQLiteConfig{Path: filepath.Join(t.TempDir(), "primary.db")},
+		MaxOpenConns:     2,
+		StatementTimeout: config.TimeDuration(100 * time.Millisecond),
+	})
+	db, err := resolve(context.Background())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+	require.Equal(t, 2, db.Stats().MaxOpenConnections)
+
+	// The statement is bounded by Config.StatementTimeout even if the context has no deadline.
+	var count int64
+	require.Error(t, db.QueryRow(`WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c`).Scan(&count))
+}
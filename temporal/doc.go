@@ -0,0 +1,11 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package temporal provides helpers for temporal (system-versioned) tables that keep the history of row changes,
+// so it's possible to find out what a row looked like at some point in the past (e.g., "yesterday" during debugging).
+// MSSQL system versioning is used for MSSQL, and for Postgres the history table is maintained by a trigger.
+// Both are created by migrations (see Table.Migrations), and Table.AsOf builds queries of rows as of the given time.
+package temporal
@@ -0,0 +1,125 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package temporal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// AsOfQuery builds a query that selects rows of the temporal table as they were at the specified time.
+type AsOfQuery struct {
+	table      *Table
+	at         time.Time
+	conditions []string
+	args       []interface{}
+	orderBy    []string
+	limit      int
+}
+
+// AsOf returns a builder of the query that selects the versioned columns of rows as they were at the specified time.
+//
+//	query, args := table.AsOf(time.Now().Add(-24*time.Hour)).Where("id = ?", userID).Build()
+//	rows, err := db.QueryContext(ctx, query, args...)
+func (t *Table) AsOf(at time.Time) *AsOfQuery {
+	return &AsOfQuery{table: t, at: at}
+}
+
+// Where adds the condition (combined with other ones by AND). The condition uses "?" as placeholders of arguments
+// regardless of the dialect, they are replaced with the dialect-specific placeholders by Build.
+func (q *AsOfQuery) Where(condition string, args ...interface{}) *AsOfQuery {
+	q.conditions = append(q.conditions, condition)
+	q.args = append(q.args, args...)
+	return q
+}
+
+// OrderBy adds ORDER BY expressions (e.g., "id DESC").
+func (q *AsOfQuery) OrderBy(exprs ...string) *AsOfQuery {
+	q.orderBy = append(q.orderBy, exprs...)
+	return q
+}
+
+// Limit limits the number of returned rows (0 means no limit).
+func (q *AsOfQuery) Limit(limit int) *AsOfQuery {
+	q.limit = limit
+	return q
+}
+
+// Build returns the SQL query and its arguments.
+func (q *AsOfQuery) Build() (query string, args []interface{}) {
+	t := q.table
+	columns := t.quoteColumns()
+	var sb strings.Builder
+	if t.dialect == dbkit.DialectMSSQL {
+		sb.WriteString("SELECT ")
+		if q.limit > 0 {
+			fmt.Fprintf(&sb, "TOP (%d) ", q.limit)
+		}
+		// MSSQL stores the system time in UTC.
+		fmt.Fprintf(&sb, "%s FROM %s FOR SYSTEM_TIME AS OF ?", columns, t.quote(t.name))
+		args = append(args, q.at.UTC())
+	} else {
+		validFrom, validTo := t.quote(t.validFromColumn), t.quote(t.validToColumn)
+		fmt.Fprintf(&sb, "SELECT %[1]s FROM ("+
+			"SELECT %[1]s FROM %[2]s WHERE %[4]s <= ? "+
+			"UNION ALL "+
+			"SELECT %[1]s FROM %[3]s WHERE %[4]s <= ? AND %[5]s > ?"+
+			") AS %[6]s",
+			columns, t.quote(t.name), t.quote(t.historyName), validFrom, validTo, t.quote(unqualifiedName(t.name)))
+		args = append(args, q.at, q.at, q.at)
+	}
+	if len(q.conditions) != 0 {
+		sb.WriteString(" WHERE (")
+		sb.WriteString(strings.Join(q.conditions, ") AND ("))
+		sb.WriteString(")")
+	}
+	args = append(args, q.args...)
+	if len(q.orderBy) != 0 {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(strings.Join(q.orderBy, ", "))
+	}
+	if q.limit > 0 && t.dialect != dbkit.DialectMSSQL {
+		fmt.Fprintf(&sb, " LIMIT %d", q.limit)
+	}
+	return replacePlaceholders(t.dialect, sb.String()), args
+}
+
+// replacePlaceholders replaces "?" placeholders (outside of string literals and quoted identifiers)
+// with the numbered ones of the dialect ($1 for Postgres, @p1 for MSSQL).
+func replacePlaceholders(dialect dbkit.Dialect, query string) string {
+	prefix := "$"
+	if dialect == dbkit.DialectMSSQL {
+		prefix = "@p"
+	}
+	var sb strings.Builder
+	n := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[' && dialect == dbkit.DialectMSSQL:
+			quote = ']'
+		case c == '?':
+			n++
+			sb.WriteString(prefix)
+			sb.WriteString(strconv.Itoa(n))
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
@@ -0,0 +1,244 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package temporal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+// Default names of the columns that store the period of validity of the row version.
+const (
+	DefaultValidFromColumn = "valid_from"
+	DefaultValidToColumn   = "valid_to"
+)
+
+// DefaultHistoryTableSuffix is appended to the name of the table to get the name of its history table by default.
+const DefaultHistoryTableSuffix = "_history"
+
+// Table describes a temporal table. The table itself must exist before applying its migrations
+// (see Migrations), they add the period columns to it and create the history table.
+//
+// For MSSQL, the system versioning is enabled: the period columns are added as hidden ones,
+// so they are not returned by "SELECT *", and timestamps are in UTC.
+// For Postgres, the valid_from column is added to the table, and the history table with the specified columns
+// and the period columns is maintained by BEFORE UPDATE OR DELETE trigger, timestamps are the transaction start time.
+// Schema changes of the table should be applied to the history table as well.
+type Table struct {
+	dialect         dbkit.Dialect
+	name            string
+	historyName     string
+	columns         []string
+	validFromColumn string
+	validToColumn   string
+}
+
+// Option is an option for NewTable.
+type Option func(*tableOptions)
+
+type tableOptions struct {
+	historyName     string
+	validFromColumn string
+	validToColumn   string
+}
+
+// WithHistoryTableName sets a custom name of the history table (the table name with DefaultHistoryTableSuffix by default).
+func WithHistoryTableName(name string) Option {
+	return func(o *tableOptions) {
+		o.historyName = name
+	}
+}
+
+// WithPeriodColumns sets custom names of the columns that store the period of validity of the row version
+// (DefaultValidFromColumn and DefaultValidToColumn by default).
+func WithPeriodColumns(validFrom, validTo string) Option {
+	return func(o *tableOptions) {
+		o.validFromColumn = validFrom
+		o.validToColumn = validTo
+	}
+}
+
+// NewTable creates a new Table. Columns are the columns of the table that are versioned
+// (for Postgres, they are stored in the history table) and returned by AsOf queries.
+// MSSQL and Postgres dialects are supported.
+func NewTable(dialect dbkit.Dialect, name string, columns []string, options ...Option) (*Table, error) {
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx, dbkit.DialectMSSQL:
+	default:
+		return nil, fmt.Errorf("unsupported dialect %q", dialect)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("table name cannot be empty")
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("columns cannot be empty")
+	}
+	opts := tableOptions{validFromColumn: DefaultValidFromColumn, validToColumn: DefaultValidToColumn}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.historyName == "" {
+		opts.historyName = name + DefaultHistoryTableSuffix
+	}
+	if opts.validFromColumn == "" || opts.validToColumn == "" {
+		return nil, fmt.Errorf("period columns cannot be empty")
+	}
+	return &Table{
+		dialect:         dialect,
+		name:            name,
+		historyName:     opts.historyName,
+		columns:         columns,
+		validFromColumn: opts.validFromColumn,
+		validToColumn:   opts.validToColumn,
+	}, nil
+}
+
+// Name returns the name of the table.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// HistoryName returns the name of the history table.
+func (t *Table) HistoryName() string {
+	return t.historyName
+}
+
+// Migrations returns the migration that enables versioning of the table (and disables it on rolling back).
+// The migration ID is "<table>_temporal_00001_enable_versioning".
+func (t *Table) Migrations() []migrate.Migration {
+	return []migrate.Migration{
+		migrate.NewCustomMigration(t.name+"_temporal_00001_enable_versioning",
+			t.EnableVersioningSQL(), t.DisableVersioningSQL(), nil, nil),
+	}
+}
+
+// EnableVersioningSQL returns SQL statements that add the period columns to the table,
+// create the history table and enable versioning.
+func (t *Table) EnableVersioningSQL() []string {
+	if t.dialect == dbkit.DialectMSSQL {
+		return t.mssqlEnableVersioningSQL()
+	}
+	return t.postgresEnableVersioningSQL()
+}
+
+// DisableVersioningSQL returns SQL statements that disable versioning,
+// drop the history table and the period columns of the table.
+func (t *Table) DisableVersioningSQL() []string {
+	if t.dialect == dbkit.DialectMSSQL {
+		return t.mssqlDisableVersioningSQL()
+	}
+	return t.postgresDisableVersioningSQL()
+}
+
+func (t *Table) mssqlEnableVersioningSQL() []string {
+	table := t.quote(t.name)
+	validFrom, validTo := t.quote(t.validFromColumn), t.quote(t.validToColumn)
+	return []string{
+		fmt.Sprintf("ALTER TABLE %s ADD "+
+			"%s DATETIME2 GENERATED ALWAYS AS ROW START HIDDEN NOT NULL CONSTRAINT %s DEFAULT SYSUTCDATETIME(), "+
+			"%s DATETIME2 GENERATED ALWAYS AS ROW END HIDDEN NOT NULL CONSTRAINT %s DEFAULT CONVERT(DATETIME2, '9999-12-31 23:59:59.9999999'), "+
+			"PERIOD FOR SYSTEM_TIME (%s, %s);",
+			table, validFrom, t.mssqlDefaultConstraint(t.validFromColumn), validTo, t.mssqlDefaultConstraint(t.validToColumn),
+			validFrom, validTo),
+		fmt.Sprintf("ALTER TABLE %s SET (SYSTEM_VERSIONING = ON (HISTORY_TABLE = %s));", table, t.mssqlHistoryTable()),
+	}
+}
+
+func (t *Table) mssqlDisableVersioningSQL() []string {
+	table := t.quote(t.name)
+	return []string{
+		fmt.Sprintf("ALTER TABLE %s SET (SYSTEM_VERSIONING = OFF);", table),
+		fmt.Sprintf("ALTER TABLE %s DROP PERIOD FOR SYSTEM_TIME;", table),
+		fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s, %s;", table,
+			t.mssqlDefaultConstraint(t.validFromColumn), t.mssqlDefaultConstraint(t.validToColumn)),
+		fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s, %s;", table, t.quote(t.validFromColumn), t.quote(t.validToColumn)),
+		fmt.Sprintf("DROP TABLE %s;", t.mssqlHistoryTable()),
+	}
+}
+
+func (t *Table) mssqlDefaultConstraint(column string) string {
+	return t.quote("DF_" + unqualifiedName(t.name) + "_" + column)
+}
+
+// mssqlHistoryTable returns the name of the history table, MSSQL requires it to be schema-qualified.
+func (t *Table) mssqlHistoryTable() string {
+	if strings.Contains(t.historyName, ".") {
+		return t.quote(t.historyName)
+	}
+	return t.quote("dbo." + t.historyName)
+}
+
+func (t *Table) postgresEnableVersioningSQL() []string {
+	table, history := t.quote(t.name), t.quote(t.historyName)
+	validFrom, validTo := t.quote(t.validFromColumn), t.quote(t.validToColumn)
+	columns := t.quoteColumns()
+	oldValues := make([]string, 0, len(t.columns))
+	for _, col := range t.columns {
+		oldValues = append(oldValues, "OLD."+t.quote(col))
+	}
+	fn := t.quote(t.name + "_versioning") // The function is created in the schema of the table.
+	return []string{
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s timestamptz NOT NULL DEFAULT now();", table, validFrom),
+		fmt.Sprintf("CREATE TABLE %s AS SELECT %s, %s, CAST(NULL AS timestamptz) AS %s FROM %s WITH NO DATA;",
+			history, columns, validFrom, validTo, table),
+		fmt.Sprintf("CREATE INDEX %s ON %s (%s, %s);",
+			t.quote(unqualifiedName(t.historyName)+"_period_idx"), history, validTo, validFrom),
+		fmt.Sprintf(`CREATE OR REPLACE FUNCTION %[1]s() RETURNS trigger AS $$
+BEGIN
+	INSERT INTO %[2]s (%[3]s, %[4]s, %[5]s) VALUES (%[6]s, OLD.%[4]s, now());
+	IF TG_OP = 'UPDATE' THEN
+		NEW.%[4]s := now();
+		RETURN NEW;
+	END IF;
+	RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;`, fn, history, columns, validFrom, validTo, strings.Join(oldValues, ", ")),
+		fmt.Sprintf("CREATE TRIGGER %s BEFORE UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE FUNCTION %s();",
+			t.quote(unqualifiedName(t.name)+"_versioning"), table, fn),
+	}
+}
+
+func (t *Table) postgresDisableVersioningSQL() []string {
+	table := t.quote(t.name)
+	return []string{
+		fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s;", t.quote(unqualifiedName(t.name)+"_versioning"), table),
+		fmt.Sprintf("DROP FUNCTION IF EXISTS %s();", t.quote(t.name+"_versioning")),
+		fmt.Sprintf("DROP TABLE IF EXISTS %s;", t.quote(t.historyName)),
+		fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;", table, t.quote(t.validFromColumn)),
+	}
+}
+
+func (t *Table) quoteColumns() string {
+	quoted := make([]string, 0, len(t.columns))
+	for _, col := range t.columns {
+		quoted = append(quoted, t.quote(col))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// quote quotes the (possibly schema-qualified) identifier according to the dialect.
+func (t *Table) quote(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if t.dialect == dbkit.DialectMSSQL {
+			parts[i] = "[" + strings.ReplaceAll(part, "]", "]]") + "]"
+		} else {
+			parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+func unqualifiedName(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,120 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package temporal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-dbkit"
+)
+
+func TestNewTable(t *testing.T) {
+	_, err := NewTable(dbkit.DialectMySQL, "users", []string{"id"})
+	require.EqualError(t, err, `unsupported dialect "mysql"`)
+	_, err = NewTable(dbkit.DialectPgx, "", []string{"id"})
+	require.EqualError(t, err, "table name cannot be empty")
+	_, err = NewTable(dbkit.DialectPgx, "users", nil)
+	require.EqualError(t, err, "columns cannot be empty")
+
+	table, err := NewTable(dbkit.DialectPgx, "users", []string{"id", "name"})
+	require.NoError(t, err)
+	require.Equal(t, "users_history", table.HistoryName())
+	migrations := table.Migrations()
+	require.Len(t, migrations, 1)
+	require.Equal(t, "users_temporal_00001_enable_versioning", migrations[0].ID())
+	require.Equal(t, table.EnableVersioningSQL(), migrations[0].UpSQL())
+	require.Equal(t, table.DisableVersioningSQL(), migrations[0].DownSQL())
+}
+
+func TestTable_VersioningSQL(t *testing.T) {
+	t.Run("postgres", func(t *testing.T) {
+		table, err := NewTable(dbkit.DialectPostgres, "app.users", []string{"id", "name"},
+			WithHistoryTableName("app.users_log"), WithPeriodColumns("sys_from", "sys_to"))
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			`ALTER TABLE "app"."users" ADD COLUMN "sys_from" timestamptz NOT NULL DEFAULT now();`,
+			`CREATE TABLE "app"."users_log" AS SELECT "id", "name", "sys_from", CAST(NULL AS timestamptz) AS "sys_to" ` +
+				`FROM "app"."users" WITH NO DATA;`,
+			`CREATE INDEX "users_log_period_idx" ON "app"."users_log" ("sys_to", "sys_from");`,
+			`CREATE OR REPLACE FUNCTION "app"."users_versioning"() RETURNS trigger AS $$
+BEGIN
+	INSERT INTO "app"."users_log" ("id", "name", "sys_from", "sys_to") VALUES (OLD."id", OLD."name", OLD."sys_from", now());
+	IF TG_OP = 'UPDATE' THEN
+		NEW."sys_from" := now();
+		RETURN NEW;
+	END IF;
+	RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;`,
+			`CREATE TRIGGER "users_versioning" BEFORE UPDATE OR DELETE ON "app"."users" FOR EACH ROW EXECUTE FUNCTION "app"."users_versioning"();`,
+		}, table.EnableVersioningSQL())
+		require.Equal(t, []string{
+			`DROP TRIGGER IF EXISTS "users_versioning" ON "app"."users";`,
+			`DROP FUNCTION IF EXISTS "app"."users_versioning"();`,
+			`DROP TABLE IF EXISTS "app"."users_log";`,
+			`ALTER TABLE "app"."users" DROP COLUMN IF EXISTS "sys_from";`,
+		}, table.DisableVersioningSQL())
+	})
+
+	t.Run("mssql", func(t *testing.T) {
+		table, err := NewTable(dbkit.DialectMSSQL, "users", []string{"id", "name"})
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"ALTER TABLE [users] ADD " +
+				"[valid_from] DATETIME2 GENERATED ALWAYS AS ROW START HIDDEN NOT NULL CONSTRAINT [DF_users_valid_from] DEFAULT SYSUTCDATETIME(), " +
+				"[valid_to] DATETIME2 GENERATED ALWAYS AS ROW END HIDDEN NOT NULL CONSTRAINT [DF_users_valid_to] " +
+				"DEFAULT CONVERT(DATETIME2, '9999-12-31 23:59:59.9999999'), " +
+				"PERIOD FOR SYSTEM_TIME ([valid_from], [valid_to]);",
+			"ALTER TABLE [users] SET (SYSTEM_VERSIONING = ON (HISTORY_TABLE = [dbo].[users_history]));",
+		}, table.EnableVersioningSQL())
+		require.Equal(t, []string{
+			"ALTER TABLE [users] SET (SYSTEM_VERSIONING = OFF);",
+			"ALTER TABLE [users] DROP PERIOD FOR SYSTEM_TIME;",
+			"ALTER TABLE [users] DROP CONSTRAINT [DF_users_valid_from], [DF_users_valid_to];",
+			"ALTER TABLE [users] DROP COLUMN [valid_from], [valid_to];",
+			"DROP TABLE [dbo].[users_history];",
+		}, table.DisableVersioningSQL())
+	})
+}
+
+func TestTable_AsOf(t *testing.T) {
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+
+	t.Run("postgres", func(t *testing.T) {
+		table, err := NewTable(dbkit.DialectPgx, "users", []string{"id", "name"})
+		require.NoError(t, err)
+
+		query, args := table.AsOf(at).Build()
+		require.Equal(t, `SELECT "id", "name" FROM (`+
+			`SELECT "id", "name" FROM "users" WHERE "valid_from" <= $1 `+
+			`UNION ALL `+
+			`SELECT "id", "name" FROM "users_history" WHERE "valid_from" <= $2 AND "valid_to" > $3`+
+			`) AS "users"`, query)
+		require.Equal(t, []interface{}{at, at, at}, args)
+
+		query, args = table.AsOf(at).Where("id = ?", 42).Where("name <> '?'").OrderBy("id DESC").Limit(10).Build()
+		require.Equal(t, `SELECT "id", "name" FROM (`+
+			`SELECT "id", "name" FROM "users" WHERE "valid_from" <= $1 `+
+			`UNION ALL `+
+			`SELECT "id", "name" FROM "users_history" WHERE "valid_from" <= $2 AND "valid_to" > $3`+
+			`) AS "users" WHERE (id = $4) AND (name <> '?') ORDER BY id DESC LIMIT 10`, query)
+		require.Equal(t, []interface{}{at, at, at, 42}, args)
+	})
+
+	t.Run("mssql", func(t *testing.T) {
+		table, err := NewTable(dbkit.DialectMSSQL, "users", []string{"id", "name"})
+		require.NoError(t, err)
+
+		query, args := table.AsOf(at).Where("id = ? OR id = ?", 1, 2).OrderBy("id").Limit(5).Build()
+		require.Equal(t, "SELECT TOP (5) [id], [name] FROM [users] FOR SYSTEM_TIME AS OF @p1 "+
+			"WHERE (id = @p2 OR id = @p3) ORDER BY id", query)
+		require.Equal(t, []interface{}{at.UTC(), 1, 2}, args)
+	})
+}
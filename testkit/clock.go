@@ -0,0 +1,168 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package testkit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// FakeClock is a dbkit.Clock that is moved forward manually (see Advance and Set),
+// so TTLs, timeouts and intervals may be tested deterministically without sleeps.
+// Timers fire when the clock reaches their deadlines.
+type FakeClock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+var _ dbkit.Clock = (*FakeClock)(nil)
+
+// NewFakeClock creates a new FakeClock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer creates a new timer that fires when the clock is moved to d after the current time.
+func (c *FakeClock) NewTimer(d time.Duration) dbkit.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, ch: make(chan time.Time, 1)}
+	c.resetTimer(t, d)
+	return t
+}
+
+// Advance moves the clock forward by d and fires the timers that are due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setTime(c.now.Add(d))
+}
+
+// Set moves the clock to the given time and fires the timers that are due.
+// The clock may be moved backward, timers don't fire in this case.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setTime(now)
+}
+
+// PendingTimers returns the number of timers that are waiting to fire.
+func (c *FakeClock) PendingTimers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// WaitForTimers blocks until at least n timers are pending or ctx is done.
+// It's used to wait until the tested code (usually running in another goroutine) starts waiting
+// before the clock is advanced.
+func (c *FakeClock) WaitForTimers(ctx context.Context, n int) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.cond.Broadcast()
+		case <-done:
+		}
+	}()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c.cond.Wait()
+	}
+	return nil
+}
+
+func (c *FakeClock) setTime(now time.Time) {
+	c.now = now
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(now) {
+			pending = append(pending, t)
+			continue
+		}
+		select {
+		case t.ch <- now:
+		default: // Previous tick is not received yet, as in time.Timer.
+		}
+	}
+	for i := len(pending); i < len(c.timers); i++ {
+		c.timers[i] = nil
+	}
+	c.timers = pending
+	c.cond.Broadcast()
+}
+
+// resetTimer schedules the timer, it must be called with c.mu held.
+func (c *FakeClock) resetTimer(t *fakeTimer, d time.Duration) bool {
+	active := c.removeTimer(t)
+	t.deadline = c.now.Add(d)
+	if d <= 0 {
+		select {
+		case t.ch <- c.now:
+		default:
+		}
+		return active
+	}
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+	return active
+}
+
+// removeTimer unschedules the timer, it must be called with c.mu held.
+func (c *FakeClock) removeTimer(t *fakeTimer) bool {
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	ch       chan time.Time
+	deadline time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.removeTimer(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.resetTimer(t, d)
+}
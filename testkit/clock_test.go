@@ -0,0 +1,82 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package testkit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := NewFakeClock(start)
+	require.Equal(t, start, clock.Now())
+
+	requireFired := func(t *testing.T, timerC <-chan time.Time, want time.Time) {
+		t.Helper()
+		select {
+		case got := <-timerC:
+			require.Equal(t, want, got)
+		default:
+			t.Fatal("timer must fire")
+		}
+	}
+	requireNotFired := func(t *testing.T, timerC <-chan time.Time) {
+		t.Helper()
+		select {
+		case <-timerC:
+			t.Fatal("timer must not fire")
+		default:
+		}
+	}
+
+	timer1 := clock.NewTimer(time.Second)
+	timer2 := clock.NewTimer(time.Minute)
+	require.Equal(t, 2, clock.PendingTimers())
+
+	clock.Advance(time.Second - time.Nanosecond)
+	requireNotFired(t, timer1.C())
+	clock.Advance(time.Nanosecond)
+	requireFired(t, timer1.C(), start.Add(time.Second))
+	requireNotFired(t, timer2.C())
+	require.Equal(t, 1, clock.PendingTimers())
+
+	require.True(t, timer2.Stop())
+	require.False(t, timer2.Stop())
+	clock.Set(start.Add(time.Hour))
+	requireNotFired(t, timer2.C())
+	require.Equal(t, start.Add(time.Hour), clock.Now())
+
+	require.False(t, timer1.Reset(time.Second))
+	require.True(t, timer1.Reset(2*time.Second))
+	clock.Advance(2 * time.Second)
+	requireFired(t, timer1.C(), start.Add(time.Hour+2*time.Second))
+
+	requireFired(t, clock.NewTimer(0).C(), clock.Now())
+}
+
+func TestFakeClock_WaitForTimers(t *testing.T) {
+	clock := NewFakeClock(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	fired := make(chan struct{})
+	go func() {
+		<-clock.NewTimer(time.Minute).C()
+		close(fired)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, clock.WaitForTimers(ctx, 1))
+	clock.Advance(time.Minute)
+	<-fired
+
+	canceledCtx, canceledCtxCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer canceledCtxCancel()
+	require.ErrorIs(t, clock.WaitForTimers(canceledCtx, 1), context.DeadlineExceeded)
+}
@@ -0,0 +1,12 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+// Package testkit provides utilities for testing code that works with SQL databases.
+// It allows deterministically provoking deadlocks and serialization failures between two concurrent transactions
+// on the given schema, so retry handling (see dbkit.DoInTx with WithRetryPolicy) can be tested.
+// TruncateAllTables resets the database between tests, regardless of foreign keys between tables.
+// FakeClock is a manually advanced dbkit.Clock for testing TTLs, timeouts and intervals without sleeps.
+package testkit
@@ -0,0 +1,231 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package testkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/acronis/go-dbkit"
+	"github.com/acronis/go-dbkit/migrate"
+)
+
+// ErrProductionDSN is returned by TruncateAllTables if the DSN looks like a production one.
+var ErrProductionDSN = errors.New("refusing to truncate tables, DSN looks like a production one")
+
+var productionDSNRegexp = regexp.MustCompile(`(?i)(^|[^a-z])(prod|production|prd|live)([^a-z]|$)`)
+
+// LooksLikeProductionDSN is the default guard used by TruncateAllTables.
+// It reports whether the DSN contains a word like "prod", "production", "prd" or "live"
+// (e.g., in the host or database name).
+func LooksLikeProductionDSN(dsn string) bool {
+	return productionDSNRegexp.MatchString(dsn)
+}
+
+type truncateOptions struct {
+	excludedTables map[string]bool
+	isProduction   func(dsn string) bool
+}
+
+// TruncateOption is a functional option for TruncateAllTables.
+type TruncateOption func(*truncateOptions)
+
+// WithExcludedTables excludes tables from truncation in addition to the migrations bookkeeping table
+// (migrate.MigrationsTableName) that is always excluded.
+func WithExcludedTables(tables ...string) TruncateOption {
+	return func(opts *truncateOptions) {
+		for _, table := range tables {
+			opts.excludedTables[table] = true
+		}
+	}
+}
+
+// WithProductionDSNGuard sets the function that reports whether the DSN belongs to a production database.
+// LooksLikeProductionDSN is used by default.
+func WithProductionDSNGuard(isProduction func(dsn string) bool) TruncateOption {
+	return func(opts *truncateOptions) {
+		opts.isProduction = isProduction
+	}
+}
+
+// ListTables returns names of all tables in the current schema (database) of the given dialect.
+func ListTables(ctx context.Context, dbConn *sql.DB, dialect dbkit.Dialect) ([]string, error) {
+	var query string
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		query = `SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = 'BASE TABLE'`
+	case dbkit.DialectMySQL:
+		query = `SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'`
+	case dbkit.DialectSQLite:
+		query = `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`
+	case dbkit.DialectMSSQL:
+		query = `SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = SCHEMA_NAME() AND TABLE_TYPE = 'BASE TABLE'`
+	default:
+		return nil, fmt.Errorf("unsupported dialect %q", dialect)
+	}
+
+	rows, err := dbConn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			return nil, fmt.Errorf("scan table name: %w", err)
+		}
+		tables = append(tables, table)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	return tables, nil
+}
+
+// TruncateAllTables removes all rows from all tables of the current schema (database) except bookkeeping ones
+// (see WithExcludedTables). It's intended to reset the database between tests or in dev environments.
+// Foreign keys don't affect the order of truncation:
+//   - Postgres: all tables are truncated by the single TRUNCATE ... RESTART IDENTITY CASCADE statement;
+//   - MySQL: tables are truncated with disabled FOREIGN_KEY_CHECKS for the session;
+//   - SQLite: rows are deleted in the transaction with deferred foreign keys (PRAGMA defer_foreign_keys);
+//   - MSSQL: constraints are disabled (NOCHECK) while rows are deleted and re-enabled then.
+//
+// The database is opened by the configuration for the time of the call, so the guard
+// (see LooksLikeProductionDSN and WithProductionDSNGuard) checks the DSN of the database that is actually truncated
+// (without the password, see dbkit.Config.DriverNameAndDSN).
+// ErrProductionDSN is returned without touching the data if the DSN looks like a production one.
+func TruncateAllTables(ctx context.Context, cfg *dbkit.Config, options ...TruncateOption) (err error) {
+	opts := truncateOptions{
+		excludedTables: map[string]bool{migrate.MigrationsTableName: true},
+		isProduction:   LooksLikeProductionDSN,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if _, dsn := cfg.DriverNameAndDSN(); opts.isProduction(dsn) {
+		return ErrProductionDSN
+	}
+
+	dbConn, err := dbkit.Open(cfg, false)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() {
+		if closeErr := dbConn.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close database: %w", closeErr)
+		}
+	}()
+	return truncateAllTables(ctx, dbConn, cfg.Dialect, opts)
+}
+
+func truncateAllTables(ctx context.Context, dbConn *sql.DB, dialect dbkit.Dialect, opts truncateOptions) error {
+	allTables, err := ListTables(ctx, dbConn, dialect)
+	if err != nil {
+		return err
+	}
+	var tables []string
+	for _, table := range allTables {
+		if !opts.excludedTables[table] {
+			tables = append(tables, table)
+		}
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	switch dialect {
+	case dbkit.DialectPostgres, dbkit.DialectPgx:
+		return truncatePostgresTables(ctx, dbConn, tables)
+	case dbkit.DialectMySQL:
+		return truncateMySQLTables(ctx, dbConn, tables)
+	case dbkit.DialectSQLite:
+		return truncateSQLiteTables(ctx, dbConn, tables)
+	case dbkit.DialectMSSQL:
+		return truncateMSSQLTables(ctx, dbConn, tables)
+	}
+	return fmt.Errorf("unsupported dialect %q", dialect)
+}
+
+func truncatePostgresTables(ctx context.Context, dbConn *sql.DB, tables []string) error {
+	quoted := make([]string, 0, len(tables))
+	for _, table := range tables {
+		quoted = append(quoted, `"`+strings.ReplaceAll(table, `"`, `""`)+`"`)
+	}
+	if _, err := dbConn.ExecContext(ctx, "TRUNCATE TABLE "+strings.Join(quoted, ", ")+" RESTART IDENTITY CASCADE"); err != nil {
+		return fmt.Errorf("truncate tables: %w", err)
+	}
+	return nil
+}
+
+func truncateMySQLTables(ctx context.Context, dbConn *sql.DB, tables []string) (err error) {
+	// FOREIGN_KEY_CHECKS is a session variable, so all statements should be executed on the same connection.
+	conn, err := dbConn.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("get connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err = conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return fmt.Errorf("disable foreign key checks: %w", err)
+	}
+	defer func() {
+		if _, enableErr := conn.ExecContext(context.Background(), "SET FOREIGN_KEY_CHECKS = 1"); enableErr != nil {
+			err = errors.Join(err, fmt.Errorf("enable foreign key checks: %w", enableErr))
+		}
+	}()
+	for _, table := range tables {
+		if _, err = conn.ExecContext(ctx, "TRUNCATE TABLE `"+strings.ReplaceAll(table, "`", "``")+"`"); err != nil {
+			return fmt.Errorf("truncate table %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func truncateSQLiteTables(ctx context.Context, dbConn *sql.DB, tables []string) error {
+	return dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+		// Foreign keys are checked on commit, when all tables are already empty.
+		if _, err := tx.ExecContext(ctx, "PRAGMA defer_foreign_keys = ON"); err != nil {
+			return fmt.Errorf("defer foreign keys: %w", err)
+		}
+		for _, table := range tables {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM "`+strings.ReplaceAll(table, `"`, `""`)+`"`); err != nil {
+				return fmt.Errorf("delete rows from table %s: %w", table, err)
+			}
+		}
+		return nil
+	})
+}
+
+func truncateMSSQLTables(ctx context.Context, dbConn *sql.DB, tables []string) error {
+	quoted := make([]string, 0, len(tables))
+	for _, table := range tables {
+		quoted = append(quoted, "["+strings.ReplaceAll(table, "]", "]]")+"]")
+	}
+	return dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+		for _, table := range quoted {
+			if _, err := tx.ExecContext(ctx, "ALTER TABLE "+table+" NOCHECK CONSTRAINT ALL"); err != nil {
+				return fmt.Errorf("disable constraints of table %s: %w", table, err)
+			}
+		}
+		for _, table := range quoted {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+				return fmt.Errorf("delete rows from table %s: %w", table, err)
+			}
+		}
+		for _, table := range quoted {
+			if _, err := tx.ExecContext(ctx, "ALTER TABLE "+table+" WITH CHECK CHECK CONSTRAINT ALL"); err != nil {
+				return fmt.Errorf("enable constraints of table %s: %w", table, err)
+			}
+		}
+		return nil
+	})
+}
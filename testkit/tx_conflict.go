@@ -0,0 +1,152 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package testkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/acronis/go-dbkit"
+)
+
+// TxStep is a step of the transaction in the conflict scenario.
+type TxStep func(ctx context.Context, tx *sql.Tx) error
+
+// Statement is a SQL statement with arguments.
+// Query should use placeholders of the tested dialect (e.g., "$1" for Postgres and "?" for MySQL).
+type Statement struct {
+	Query string
+	Args  []interface{}
+}
+
+// ExecStep returns the step that executes the statement.
+func ExecStep(stmt Statement) TxStep {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, stmt.Query, stmt.Args...)
+		return err
+	}
+}
+
+// QueryStep returns the step that executes the statement and reads all returned rows.
+func QueryStep(stmt Statement) TxStep {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, stmt.Query, stmt.Args...)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+		}
+		if err = rows.Err(); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		return rows.Close()
+	}
+}
+
+// TxConflict describes two concurrent transactions.
+// Each transaction executes its first step, waits until the other transaction executes its first step too,
+// and then executes its second step. Such a barrier makes the conflict deterministic.
+type TxConflict struct {
+	TxOptions *sql.TxOptions
+	Tx1First  TxStep
+	Tx1Second TxStep
+	Tx2First  TxStep
+	Tx2Second TxStep
+}
+
+// RunTxConflict runs two conflicting transactions concurrently and returns their errors.
+// dbConn should allow at least 2 open connections.
+func RunTxConflict(ctx context.Context, dbConn *sql.DB, conflict TxConflict) (tx1Err, tx2Err error) {
+	tx1FirstDone := make(chan struct{})
+	tx2FirstDone := make(chan struct{})
+
+	runTx := func(first, second TxStep, firstDone chan<- struct{}, otherFirstDone <-chan struct{}) error {
+		var closeOnce sync.Once
+		closeFirstDone := func() { closeOnce.Do(func() { close(firstDone) }) }
+		defer closeFirstDone() // Don't block the other transaction if this one fails before the barrier.
+		return dbkit.DoInTx(ctx, dbConn, func(tx *sql.Tx) error {
+			if err := first(ctx, tx); err != nil {
+				return err
+			}
+			closeFirstDone()
+			select {
+			case <-otherFirstDone:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return second(ctx, tx)
+		}, dbkit.WithTxOptions(conflict.TxOptions))
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		tx1Err = runTx(conflict.Tx1First, conflict.Tx1Second, tx1FirstDone, tx2FirstDone)
+	}()
+	go func() {
+		defer wg.Done()
+		tx2Err = runTx(conflict.Tx2First, conflict.Tx2Second, tx2FirstDone, tx1FirstDone)
+	}()
+	wg.Wait()
+	return tx1Err, tx2Err
+}
+
+// NewDeadlockConflict returns the scenario that leads to the deadlock:
+// the first transaction executes stmt1 and then stmt2, and the second one executes them in the reverse order.
+// Statements should lock different rows (e.g., UPDATE of different rows or different tables).
+func NewDeadlockConflict(stmt1, stmt2 Statement) TxConflict {
+	return TxConflict{
+		TxOptions: &sql.TxOptions{Isolation: sql.LevelReadCommitted},
+		Tx1First:  ExecStep(stmt1),
+		Tx1Second: ExecStep(stmt2),
+		Tx2First:  ExecStep(stmt2),
+		Tx2Second: ExecStep(stmt1),
+	}
+}
+
+// NewSerializationFailureConflict returns the scenario that leads to the serialization failure (write skew):
+// both transactions are serializable, each of them reads the data (read1 and read2)
+// and then writes the data that was read by the other one (write1 and write2).
+func NewSerializationFailureConflict(read1, write1, read2, write2 Statement) TxConflict {
+	return TxConflict{
+		TxOptions: &sql.TxOptions{Isolation: sql.LevelSerializable},
+		Tx1First:  QueryStep(read1),
+		Tx1Second: ExecStep(write1),
+		Tx2First:  QueryStep(read2),
+		Tx2Second: ExecStep(write2),
+	}
+}
+
+// ErrNoExpectedConflictError is returned by CheckOneConflictError if neither transaction failed with the expected error.
+var ErrNoExpectedConflictError = errors.New("none of the conflicting transactions failed with the expected error")
+
+// ErrBothTxsFailed is returned by CheckOneConflictError if both transactions failed.
+var ErrBothTxsFailed = errors.New("both conflicting transactions failed")
+
+// CheckOneConflictError checks that exactly one of the transactions failed with the expected error
+// (e.g., deadlock or serialization failure), and the other one succeeded.
+func CheckOneConflictError(tx1Err, tx2Err error, isExpected func(err error) bool) error {
+	switch {
+	case tx1Err != nil && tx2Err != nil:
+		return ErrBothTxsFailed
+	case tx1Err != nil:
+		if !isExpected(tx1Err) {
+			return errors.Join(ErrNoExpectedConflictError, tx1Err)
+		}
+	case tx2Err != nil:
+		if !isExpected(tx2Err) {
+			return errors.Join(ErrNoExpectedConflictError, tx2Err)
+		}
+	default:
+		return ErrNoExpectedConflictError
+	}
+	return nil
+}
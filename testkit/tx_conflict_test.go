@@ -0,0 +1,79 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package testkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTxConflict(t *testing.T) {
+	dbConn, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, dbConn.Close()) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Run("second steps are executed after both first steps", func(t *testing.T) {
+		var mu sync.Mutex
+		var steps []string
+		recordStep := func(name string) TxStep {
+			return func(ctx context.Context, tx *sql.Tx) error {
+				if err := QueryStep(Statement{Query: "SELECT 1"})(ctx, tx); err != nil {
+					return err
+				}
+				mu.Lock()
+				steps = append(steps, name)
+				mu.Unlock()
+				return nil
+			}
+		}
+		tx1Err, tx2Err := RunTxConflict(ctx, dbConn, TxConflict{
+			Tx1First:  recordStep("first"),
+			Tx1Second: recordStep("second"),
+			Tx2First:  recordStep("first"),
+			Tx2Second: recordStep("second"),
+		})
+		require.NoError(t, tx1Err)
+		require.NoError(t, tx2Err)
+		require.Equal(t, []string{"first", "first", "second", "second"}, steps)
+	})
+
+	t.Run("failed first step doesn't block another transaction", func(t *testing.T) {
+		failErr := errors.New("first step failed")
+		noop := func(ctx context.Context, tx *sql.Tx) error { return nil }
+		tx1Err, tx2Err := RunTxConflict(ctx, dbConn, TxConflict{
+			Tx1First:  func(ctx context.Context, tx *sql.Tx) error { return failErr },
+			Tx1Second: noop,
+			Tx2First:  noop,
+			Tx2Second: noop,
+		})
+		require.ErrorIs(t, tx1Err, failErr)
+		require.NoError(t, tx2Err)
+	})
+}
+
+func TestCheckOneConflictError(t *testing.T) {
+	conflictErr := errors.New("deadlock")
+	otherErr := errors.New("other")
+	isConflictErr := func(err error) bool { return errors.Is(err, conflictErr) }
+
+	require.NoError(t, CheckOneConflictError(conflictErr, nil, isConflictErr))
+	require.NoError(t, CheckOneConflictError(nil, conflictErr, isConflictErr))
+	require.ErrorIs(t, CheckOneConflictError(nil, nil, isConflictErr), ErrNoExpectedConflictError)
+	require.ErrorIs(t, CheckOneConflictError(otherErr, nil, isConflictErr), ErrNoExpectedConflictError)
+	require.ErrorIs(t, CheckOneConflictError(conflictErr, conflictErr, isConflictErr), ErrBothTxsFailed)
+}
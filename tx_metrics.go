@@ -0,0 +1,69 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"time"
+)
+
+// TxMetricsCollector is an interface for collecting metrics about transactions.
+type TxMetricsCollector interface {
+	ObserveTxDuration(tx string, duration time.Duration)
+	IncTxCommits(tx string)
+	IncTxRollbacks(tx string)
+	IncTxRetries(tx string)
+}
+
+type txNameCtxKey struct{}
+
+// ContextWithTxName returns a new context with the name of the transaction (e.g., the name of the business operation)
+// that is used as a label of transaction metrics (see WithTxMetrics) if it's not set by WithTxAnnotation.
+func ContextWithTxName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, txNameCtxKey{}, name)
+}
+
+// TxNameFromContext returns the name of the transaction stored in the context (see ContextWithTxName).
+func TxNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(txNameCtxKey{}).(string)
+	return name
+}
+
+// WithTxMetrics sets the collector (e.g., PrometheusMetrics) that is used by DoInTx to observe the duration
+// of each attempt of the transaction and to count commits, rollbacks and retries.
+// Metrics are labeled by the annotation of the transaction (see WithTxAnnotation)
+// or by the name stored in the context (see ContextWithTxName).
+func WithTxMetrics(collector TxMetricsCollector) DoInTxOption {
+	return func(opts *doInTxOptions) {
+		opts.txMetrics = collector
+	}
+}
+
+// ObserveTx observes the duration of the finished transaction and counts its commit or rollback.
+// It's used by the transaction helpers (e.g., in dbrutil) that don't use DoInTx.
+func ObserveTx(collector TxMetricsCollector, tx string, startedAt time.Time, committed bool) {
+	collector.ObserveTxDuration(tx, time.Since(startedAt))
+	if committed {
+		collector.IncTxCommits(tx)
+	} else {
+		collector.IncTxRollbacks(tx)
+	}
+}
+
+func (opts *doInTxOptions) txName(ctx context.Context) string {
+	if opts.txAnnotation != "" {
+		return opts.txAnnotation
+	}
+	return TxNameFromContext(ctx)
+}
+
+func notifyTxRetry(ctx context.Context, opts *doInTxOptions, attempt int, err error, delay time.Duration) {
+	if opts.txMetrics != nil {
+		opts.txMetrics.IncTxRetries(opts.txName(ctx))
+	}
+	logTxRetry(ctx, opts, attempt, err, delay)
+}
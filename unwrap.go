@@ -0,0 +1,64 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+)
+
+// DriverConnUnwrapper is implemented by driver connections that wrap other ones
+// (e.g., connections of instrumenting or query translating drivers).
+type DriverConnUnwrapper interface {
+	Unwrap() driver.Conn
+}
+
+// ConnectorUnwrapper is implemented by connectors that wrap other ones (e.g., ReconnectThrottlingConnector).
+type ConnectorUnwrapper interface {
+	Unwrap() driver.Connector
+}
+
+// UnwrapDriverConn returns the innermost driver connection unwrapping it while it implements DriverConnUnwrapper.
+func UnwrapDriverConn(conn driver.Conn) driver.Conn {
+	for {
+		unwrapper, ok := conn.(DriverConnUnwrapper)
+		if !ok {
+			return conn
+		}
+		conn = unwrapper.Unwrap()
+	}
+}
+
+// UnwrapConnector returns the innermost connector unwrapping it while it implements ConnectorUnwrapper.
+func UnwrapConnector(connector driver.Connector) driver.Connector {
+	for {
+		unwrapper, ok := connector.(ConnectorUnwrapper)
+		if !ok {
+			return connector
+		}
+		connector = unwrapper.Unwrap()
+	}
+}
+
+// RawDriverConn calls fn with the innermost driver connection (see UnwrapDriverConn) of a connection from the pool.
+// It allows using driver-specific APIs (e.g., COPY via *pgx.Conn or *pgconn.PgConn) while all wrappers
+// stay in place for other usages of the pool.
+// As with sql.Conn.Raw, the driver connection must not be used outside fn.
+func RawDriverConn(ctx context.Context, db *sql.DB, fn func(driverConn interface{}) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	return conn.Raw(func(driverConn interface{}) error {
+		if c, ok := driverConn.(driver.Conn); ok {
+			driverConn = UnwrapDriverConn(c)
+		}
+		return fn(driverConn)
+	})
+}
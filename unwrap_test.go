@@ -0,0 +1,58 @@
+/*
+Copyright © 2025 Acronis International GmbH.
+
+Released under MIT license.
+*/
+
+package dbkit
+
+import (
+	"context"
+	"database/sql/driver"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+type wrappingConn struct {
+	driver.Conn
+}
+
+func (c wrappingConn) Unwrap() driver.Conn {
+	return c.Conn
+}
+
+func TestUnwrapDriverConn(t *testing.T) {
+	conn := fakeConn{}
+	require.Equal(t, conn, UnwrapDriverConn(conn))
+	require.Equal(t, conn, UnwrapDriverConn(wrappingConn{wrappingConn{conn}}))
+}
+
+func TestUnwrapConnector(t *testing.T) {
+	connector := &fakeConnector{}
+	require.Same(t, connector, UnwrapConnector(connector))
+	require.Same(t, connector, UnwrapConnector(NewReconnectThrottlingConnector(NewReconnectThrottlingConnector(connector))))
+}
+
+func TestRawDriverConn(t *testing.T) {
+	db, err := OpenWithReconnectThrottling(&Config{
+		Dialect:      DialectSQLite,
+		SQLite:       SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")},
+		MaxOpenConns: 1,
+	}, false)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	require.NoError(t, RawDriverConn(context.Background(), db, func(driverConn interface{}) error {
+		_, ok := driverConn.(*sqlite3.SQLiteConn)
+		require.True(t, ok, "unexpected driver connection type %T", driverConn)
+		return nil
+	}))
+
+	// The pool is still usable through the wrapper.
+	var one int
+	require.NoError(t, db.QueryRowContext(context.Background(), "SELECT 1").Scan(&one))
+	require.Equal(t, 1, one)
+}